@@ -0,0 +1,643 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AJMBrands/SoftwareThatMatters/export"
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+	"github.com/AJMBrands/SoftwareThatMatters/server"
+	"github.com/spf13/cobra"
+)
+
+func newBuildCommand(s *settings) *cobra.Command {
+	var output, reportPath, profileDir string
+	var provenance bool
+	cmd := &cobra.Command{
+		Use:   "build",
+		Short: "Parse the input, construct the graph and save it as a cache",
+		Args:  exactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output == "" {
+				return usageError{errors.New("no output given, use --output")}
+			}
+			// The metadata file that build writes would otherwise be the one mapped for a cache as input.
+			if s.metadata != "" && !strings.HasSuffix(strings.ToLower(s.input), ".json") {
+				return usageError{errors.New("build writes the --metadata file, so the input must be JSON")}
+			}
+			report := &g.ResolutionReport{}
+			opts := []g.Option{g.WithResolutionReport(report)}
+			var profiler *g.Profiler
+			if profileDir != "" {
+				var err error
+				if profiler, err = g.NewProfiler(profileDir); err != nil {
+					return err
+				}
+				opts = append(opts, g.WithStageHook(profiler))
+			}
+			if provenance {
+				opts = append(opts, g.WithProvenance())
+			}
+			pg, err := s.loadGraph(opts...)
+			if err != nil {
+				return err
+			}
+			if profiler != nil && profiler.Err() != nil {
+				return profiler.Err()
+			}
+			if s.metadata != "" {
+				err = g.SaveGraphFileWithMetadata(output, s.metadata, pg)
+			} else {
+				err = g.SaveGraphFile(output, pg)
+			}
+			if err != nil {
+				return err
+			}
+			if reportPath != "" {
+				err := writeOutput(cmd.OutOrStdout(), reportPath, func(w io.Writer) error {
+					return report.WriteJSON(w, 20)
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return printStats(cmd.OutOrStdout(), s, pg.Stats())
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "", "path of the graph cache to write (required)")
+	cmd.Flags().StringVar(&reportPath, "report", "", "path of a JSON report on how the dependency ranges were resolved")
+	cmd.Flags().StringVar(&profileDir, "profile-dir", "", "directory to write a CPU and a heap profile of every construction stage to")
+	cmd.Flags().BoolVar(&provenance, "provenance", false, "record the input file and byte range of every package in the cache, see export --provenance")
+	return cmd
+}
+
+func newStatsCommand(s *settings) *cobra.Command {
+	return &cobra.Command{
+		Use:   "stats",
+		Short: "Print the number of packages, nodes and edges and the degree statistics",
+		Args:  exactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pg, err := s.loadGraph()
+			if err != nil {
+				return err
+			}
+			return printStats(cmd.OutOrStdout(), s, pg.Stats())
+		},
+	}
+}
+
+func printStats(w io.Writer, s *settings, stats g.GraphStats) error {
+	if s.json {
+		return json.NewEncoder(w).Encode(stats)
+	}
+	_, err := fmt.Fprintf(w, "packages: %d\nnodes: %d\nedges: %d\nmax in-degree: %d\nmax out-degree: %d\nmean degree: %.3f\n",
+		stats.Packages, stats.Nodes, stats.Edges, stats.MaxInDegree, stats.MaxOutDegree, stats.MeanDegree)
+	return err
+}
+
+func newDependenciesCommand(s *settings) *cobra.Command {
+	var depth int
+	cmd := &cobra.Command{
+		Use:   "deps <name@version>",
+		Short: "List the dependencies of a package version",
+		Args:  exactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runNeighbourhood(cmd.OutOrStdout(), s, args[0], func(pg *g.PackageGraph, nameVersion g.NameVersion) ([]g.NodeInfo, bool) {
+				return pg.Dependencies(nameVersion, depth)
+			})
+		},
+	}
+	cmd.Flags().IntVar(&depth, "depth", -1, "maximum number of edges away, negative for all transitive dependencies")
+	return cmd
+}
+
+func newDependentsCommand(s *settings) *cobra.Command {
+	var depth, offset, limit int
+	var order string
+	cmd := &cobra.Command{
+		Use:   "dependents <name@version>",
+		Short: "List the package versions that depend on a package version",
+		Long: `List the package versions that depend on a package version. With --limit or --offset, only a page of the
+dependents is listed, sorted by --sort: at most --limit of them, or all the rest without it, starting after the first
+--offset ones, followed by the total number of dependents on standard error, or as {"nodes": [...], "total": N} with
+--json. Pages require a depth of 1 or a negative depth.`,
+		Args: exactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if limit == 0 && offset == 0 {
+				return runNeighbourhood(cmd.OutOrStdout(), s, args[0], func(pg *g.PackageGraph, nameVersion g.NameVersion) ([]g.NodeInfo, bool) {
+					return pg.Dependents(nameVersion, depth)
+				})
+			}
+			return runDependentsPage(cmd, s, args[0], depth, offset, limit, order)
+		},
+	}
+	cmd.Flags().IntVar(&depth, "depth", 1, "maximum number of edges away, negative for all transitive dependents")
+	cmd.Flags().IntVar(&offset, "offset", 0, "number of dependents to skip before the page")
+	cmd.Flags().IntVar(&limit, "limit", 0, "maximum number of dependents to list, 0 for all of them")
+	cmd.Flags().StringVar(&order, "sort", "name", "order of the page: name, timestamp or dependents")
+	return cmd
+}
+
+func runDependentsPage(cmd *cobra.Command, s *settings, arg string, depth, offset, limit int, order string) error {
+	if depth == 0 || depth > 1 {
+		return usageError{fmt.Errorf("a page of dependents requires a depth of 1 or a negative depth, got %d", depth)}
+	}
+	if offset < 0 || limit < 0 {
+		return usageError{fmt.Errorf("invalid page with offset %d and limit %d", offset, limit)}
+	}
+	if limit == 0 {
+		// A page without a limit holds all the dependents after the offset.
+		limit = math.MaxInt
+	}
+	var sortOrder g.SortOrder
+	switch order {
+	case "name":
+		sortOrder = g.SortByName
+	case "timestamp":
+		sortOrder = g.SortByTimestamp
+	case "dependents":
+		sortOrder = g.SortByDependents
+	default:
+		return usageError{fmt.Errorf("invalid sort %q, expected name, timestamp or dependents", order)}
+	}
+	nameVersion, err := g.ParseNameVersion(arg)
+	if err != nil {
+		return usageError{err}
+	}
+	pg, err := s.loadGraph()
+	if err != nil {
+		return err
+	}
+	if _, ok := pg.FindNode(nameVersion); !ok {
+		return notFoundError{nameVersion}
+	}
+	nodes, total, err := g.DependentsPage(pg, nameVersion, depth < 0, offset, limit, sortOrder)
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	if s.json {
+		if nodes == nil {
+			nodes = []g.NodeInfo{}
+		}
+		return json.NewEncoder(w).Encode(struct {
+			Nodes []g.NodeInfo `json:"nodes"`
+			Total int          `json:"total"`
+		}{nodes, total})
+	}
+	for _, node := range nodes {
+		if _, err := fmt.Fprintln(w, node.NameVersion()); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(cmd.ErrOrStderr(), "%d of %d dependents\n", len(nodes), total)
+	return err
+}
+
+func newImpactCommand(s *settings) *cobra.Command {
+	var op string
+	cmd := &cobra.Command{
+		Use:   "impact <name@version,...>...",
+		Short: "List the package versions that depend on several sets of package versions",
+		Long: `List the package versions that depend on several sets of package versions. Every argument is a comma-separated
+set of roots, such as the versions affected by an advisory, whose transitive dependents are combined from left to
+right with --op: union lists the versions affected by any set, intersection those affected by every set, and
+difference those affected by the first set but none of the others.`,
+		Args: minimumArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var combine func(g.NodeSet, g.NodeSet) g.NodeSet
+			switch op {
+			case "union":
+				combine = g.NodeSet.Union
+			case "intersection":
+				combine = g.NodeSet.Intersect
+			case "difference":
+				combine = g.NodeSet.Difference
+			default:
+				return usageError{fmt.Errorf("invalid op %q, expected union, intersection or difference", op)}
+			}
+			sets := make([][]g.NameVersion, len(args))
+			for i, arg := range args {
+				for _, root := range strings.Split(arg, ",") {
+					nameVersion, err := g.ParseNameVersion(root)
+					if err != nil {
+						return usageError{err}
+					}
+					sets[i] = append(sets[i], nameVersion)
+				}
+			}
+			pg, err := s.loadGraph()
+			if err != nil {
+				return err
+			}
+			var result g.NodeSet
+			for i, roots := range sets {
+				for _, root := range roots {
+					if _, ok := pg.FindNode(root); !ok {
+						return notFoundError{root}
+					}
+				}
+				if set := g.DependentsSet(pg, roots); i == 0 {
+					result = set
+				} else {
+					result = combine(result, set)
+				}
+			}
+			return printNodes(cmd.OutOrStdout(), s, result.NodeInfos())
+		},
+	}
+	cmd.Flags().StringVar(&op, "op", "union", "how the dependents of the sets are combined: union, intersection or difference")
+	return cmd
+}
+
+func newQueryCommand(s *settings) *cobra.Command {
+	return &cobra.Command{
+		Use:   "query <expression>",
+		Short: "Evaluate a query expression, such as dependents(name=\"lodash\") | top(indegree, 20)",
+		Long: `Evaluate a query expression on the graph and list the package versions it selects, one name@version per line
+followed by the scores added by top, tab-separated, or as {"columns": [...], "rows": [...]} with --json. For
+example, the 20 most depended on versions among those that depend on lodash 4.17.21 and were released before 2020:
+
+  depgraph query -i npm.json 'dependents(name="lodash", version="4.17.21", transitive=true)
+    | filter(timestamp < "2020-01-01") | top(indegree, 20)'
+
+The sources are dependents(name, version, transitive, depth), deps(name, version, transitive, depth), path(from, to),
+search(pattern, mode, ignorecase) and all(); the stages after | are filter(condition) and top(metric, n); and results
+combine with + (union), & (intersection) and - (difference). Conditions compare attributes such as name, version,
+timestamp and license, or metrics, with =, !=, <, <=, >, >= or ~ (regular expression), joined by and, or and not.
+The query is checked before the graph is loaded, and errors point at the offending part of the query.`,
+		Args: exactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query, err := g.ParseQuery(args[0])
+			if err != nil {
+				return err
+			}
+			pg, err := s.loadGraph()
+			if err != nil {
+				return err
+			}
+			result, err := query.Evaluate(pg)
+			if err != nil {
+				return err
+			}
+			w := cmd.OutOrStdout()
+			if s.json {
+				return json.NewEncoder(w).Encode(result)
+			}
+			for _, row := range result.Rows {
+				line := row.Node.NameVersion().String()
+				for _, value := range row.Values {
+					line += fmt.Sprintf("\t%g", value)
+				}
+				if _, err := fmt.Fprintln(w, line); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func runNeighbourhood(w io.Writer, s *settings, arg string, query func(*g.PackageGraph, g.NameVersion) ([]g.NodeInfo, bool)) error {
+	nameVersion, err := g.ParseNameVersion(arg)
+	if err != nil {
+		return usageError{err}
+	}
+	pg, err := s.loadGraph()
+	if err != nil {
+		return err
+	}
+	nodes, ok := query(pg, nameVersion)
+	if !ok {
+		return notFoundError{nameVersion}
+	}
+	return printNodes(w, s, nodes)
+}
+
+// printNodes prints one name@version per line, or the nodes as a JSON array with --json.
+func printNodes(w io.Writer, s *settings, nodes []g.NodeInfo) error {
+	if s.json {
+		if nodes == nil {
+			nodes = []g.NodeInfo{}
+		}
+		return json.NewEncoder(w).Encode(nodes)
+	}
+	for _, node := range nodes {
+		if _, err := fmt.Fprintln(w, node.NameVersion()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newExportCommand(s *settings) *cobra.Command {
+	var format, output, externalEdges string
+	var perPackage, metrics []string
+	var depth int
+	var provenance bool
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the graph as DOT, CSV, GraphML, GEXF, node-link JSON, SQLite or Parquet",
+		Long: `Export the graph as DOT, CSV, GraphML, GEXF, node-link JSON, SQLite or Parquet. The output is written to standard
+output unless --output is given. CSV writes two files, <output>.nodes.csv and <output>.edges.csv, so it requires
+--output, as do SQLite, which writes a new database, and Parquet, which writes <output>.nodes.parquet and
+<output>.edges.parquet with a node column for every metric of --metric. With --per-package, the ego network of every
+listed package, up to --depth edges away, is written to a file of its own in the --output directory instead, in any
+format but CSV, SQLite and Parquet. With --provenance, the input file and byte range that every package was decoded
+from, which are recorded for JSON input or by build --provenance, are exported as the node attributes source_file,
+source_start and source_end, or as columns of the packages table.`,
+		Args: exactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			formats := map[string]export.Format{"dot": export.DOT, "json": export.JSON, "graphml": export.GraphML, "gexf": export.GEXF}
+			switch format {
+			case "dot", "graphml", "gexf", "json":
+			case "csv", "sqlite", "parquet":
+				if output == "" || len(perPackage) > 0 {
+					return usageError{fmt.Errorf("the %s format requires --output and does not support --per-package", format)}
+				}
+			default:
+				return usageError{fmt.Errorf("invalid format %q, expected dot, csv, graphml, gexf, json, sqlite or parquet", format)}
+			}
+			if len(metrics) > 0 && format != "parquet" {
+				return usageError{errors.New("--metric is only supported by the parquet format")}
+			}
+			if err := checkMetrics(metrics); err != nil {
+				return err
+			}
+			if len(perPackage) > 0 && output == "" {
+				return usageError{errors.New("--per-package requires the --output directory")}
+			}
+			if len(perPackage) > 0 && provenance {
+				return usageError{errors.New("--provenance does not support --per-package")}
+			}
+			var extra []g.Option
+			if externalEdges != "" {
+				extra = append(extra, g.WithExternalEdges(externalEdges, 0))
+			}
+			if provenance {
+				extra = append(extra, g.WithProvenance())
+			}
+			pg, err := s.loadGraph(extra...)
+			if err != nil {
+				return err
+			}
+			if file, _ := pg.ExternalEdges(); file != nil {
+				defer file.Remove()
+			}
+			switch {
+			case format == "sqlite" && provenance:
+				return export.ExportSQLiteWithProvenance(output, pg)
+			case format == "sqlite":
+				return export.ExportSQLite(output, pg)
+			case format == "parquet":
+				scores, err := g.ComputeMetrics(pg, metrics)
+				if err != nil {
+					return err
+				}
+				return export.ExportParquet(pg, output+".nodes.parquet", output+".edges.parquet", scores)
+			case provenance:
+				if err := pg.Attributes().RegisterProvenance(); err != nil {
+					return err
+				}
+			}
+			if format == "csv" {
+				return exportCSVFiles(pg, output)
+			}
+			if len(perPackage) > 0 {
+				return export.ExportPerPackage(pg, perPackage, output, formats[format], depth)
+			}
+			return writeOutput(cmd.OutOrStdout(), output, func(w io.Writer) error {
+				switch format {
+				case "dot":
+					return export.ExportDOT(pg, w, "dependencies")
+				case "graphml":
+					return export.ExportGraphML(pg, w)
+				case "gexf":
+					return export.ExportGEXF(pg, w)
+				default:
+					return export.ExportJSON(pg, w, nil)
+				}
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&format, "format", "f", "dot", "output format: dot, csv, graphml, gexf, json, sqlite or parquet")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "output file, or the prefix of the output files for csv and parquet")
+	cmd.Flags().StringVar(&externalEdges, "external-edges", "", "sort the edges in temporary files in this directory instead of memory, for JSON input")
+	cmd.Flags().StringSliceVar(&perPackage, "per-package", nil, "packages to export the ego network of, one file per package in the --output directory")
+	cmd.Flags().IntVar(&depth, "depth", 1, "with --per-package, maximum number of edges away from the package, negative for no limit")
+	cmd.Flags().StringSliceVarP(&metrics, "metric", "m", nil, "with the parquet format, metrics to add as node columns: "+strings.Join(g.MetricNames(), ", "))
+	cmd.Flags().BoolVar(&provenance, "provenance", false, "export the input file and byte range of every package, recorded for JSON input or by build --provenance")
+	return cmd
+}
+
+func exportCSVFiles(pg *g.PackageGraph, prefix string) (err error) {
+	nodes, err := os.Create(prefix + ".nodes.csv")
+	if err != nil {
+		return err
+	}
+	defer closeFile(nodes, &err)
+	edges, err := os.Create(prefix + ".edges.csv")
+	if err != nil {
+		return err
+	}
+	defer closeFile(edges, &err)
+	return export.ExportCSV(pg, nodes, edges)
+}
+
+// writeOutput calls write with the file at path, or with stdout if path is empty.
+func writeOutput(stdout io.Writer, path string, write func(io.Writer) error) (err error) {
+	if path == "" {
+		return write(stdout)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer closeFile(file, &err)
+	return write(file)
+}
+
+// closeFile closes the file and reports the error through err, unless an earlier error was already reported.
+func closeFile(file *os.File, err *error) {
+	if closeErr := file.Close(); closeErr != nil && *err == nil {
+		*err = closeErr
+	}
+}
+
+func newAuditCommand(s *settings) *cobra.Command {
+	return &cobra.Command{
+		Use:   "audit",
+		Short: "Print a JSON report of the data-quality problems of the JSON input, without building the graph",
+		Args:  exactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			packagesList, err := s.readPackages()
+			if err != nil {
+				return err
+			}
+			audit := g.AuditInput(*packagesList)
+			return audit.WriteJSON(cmd.OutOrStdout())
+		},
+	}
+}
+
+func newDigestCommand(s *settings) *cobra.Command {
+	var from, to string
+	var watch []string
+	cmd := &cobra.Command{
+		Use:   "digest",
+		Short: "Print a Markdown digest of the changes of the JSON input between two dates",
+		Long: `Print a Markdown digest of the changes of the JSON input between --from, inclusive, and --to, exclusive: the
+number of new packages and versions, of changed dependency ranges and of newly satisfiable dependency declarations,
+followed by the new versions of the --watch packages with their changes.`,
+		Args: exactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if s.input == "" {
+				return usageError{errors.New("no input given, use --input")}
+			}
+			bounds := make([]time.Time, 2)
+			for i, bound := range []string{from, to} {
+				t, err := time.Parse("2006-01-02", bound)
+				if err != nil {
+					return usageError{fmt.Errorf("invalid date %q, expected YYYY-MM-DD for --from and --to", bound)}
+				}
+				bounds[i] = t
+			}
+			packagesList, err := s.readPackages()
+			if err != nil {
+				return err
+			}
+			digest := g.ChangeDigest(*packagesList, watch, bounds[0], bounds[1])
+			return digest.WriteMarkdown(cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVar(&from, "from", "", "start of the digest (YYYY-MM-DD, required)")
+	cmd.Flags().StringVar(&to, "to", "", "end of the digest, exclusive (YYYY-MM-DD, required)")
+	cmd.Flags().StringSliceVar(&watch, "watch", nil, "packages whose new versions are described, comma-separated")
+	return cmd
+}
+
+func newTopCommand(s *settings) *cobra.Command {
+	var (
+		metric string
+		n      int
+		latest bool
+	)
+	cmd := &cobra.Command{
+		Use:   "top",
+		Short: "List the package versions with the highest in-degree, PageRank or another metric",
+		Args:  exactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkMetrics([]string{metric}); err != nil {
+				return err
+			}
+			pg, err := s.loadGraph()
+			if err != nil {
+				return err
+			}
+			var ranked []g.RankedNode
+			if latest && (metric == "outdegree" || metric == "transitive") {
+				ranked = g.TopPackageDependencyCounts(pg, n, metric == "transitive")
+			} else {
+				scores, err := g.ComputeMetrics(pg, []string{metric})
+				if err != nil {
+					return err
+				}
+				ranked = g.TopNodes(scores[metric], pg.NodeMap(), n)
+			}
+
+			w := cmd.OutOrStdout()
+			if s.json {
+				return json.NewEncoder(w).Encode(ranked)
+			}
+			for i, node := range ranked {
+				if _, err := fmt.Fprintf(w, "%d\t%s\t%g\n", i+1, node.NameVersion(), node.Score); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&metric, "metric", "m", "indegree", "ranking metric: "+strings.Join(g.MetricNames(), ", "))
+	cmd.Flags().IntVarP(&n, "number", "n", 20, "number of package versions to list")
+	cmd.Flags().BoolVar(&latest, "latest", false, "rank only the latest version of every package, for outdegree and transitive")
+	return cmd
+}
+
+func newMetricsCommand(s *settings) *cobra.Command {
+	var (
+		names  []string
+		output string
+	)
+	cmd := &cobra.Command{
+		Use:   "metrics",
+		Short: "Write a CSV table with one column per metric and one row per package version",
+		Args:  exactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkMetrics(names); err != nil {
+				return err
+			}
+			pg, err := s.loadGraph()
+			if err != nil {
+				return err
+			}
+			scores, err := g.ComputeMetrics(pg, names)
+			if err != nil {
+				return err
+			}
+			return writeOutput(cmd.OutOrStdout(), output, func(w io.Writer) error {
+				return export.ExportMetricsCSV(pg, scores, w)
+			})
+		},
+	}
+	cmd.Flags().StringSliceVarP(&names, "metric", "m", []string{"indegree", "pagerank"}, "metrics to compute: "+strings.Join(g.MetricNames(), ", "))
+	cmd.Flags().StringVarP(&output, "output", "o", "", "output file instead of standard output")
+	return cmd
+}
+
+// checkMetrics returns a usage error if a name is not a registered metric.
+func checkMetrics(names []string) error {
+	known := g.MetricNames()
+	for _, name := range names {
+		found := false
+		for _, metric := range known {
+			found = found || metric == name
+		}
+		if !found {
+			return usageError{fmt.Errorf("invalid metric %q, expected %s", name, strings.Join(known, ", "))}
+		}
+	}
+	return nil
+}
+
+func newServeCommand(s *settings) *cobra.Command {
+	var addr string
+	var watch time.Duration
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Load the graph once and answer queries about it over HTTP",
+		Args:  exactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if watch > 0 && !strings.HasSuffix(strings.ToLower(s.input), ".json") {
+				return usageError{errors.New("--watch needs a JSON input")}
+			}
+			pg, err := s.loadGraph()
+			if err != nil {
+				return err
+			}
+			if watch > 0 {
+				defer pg.WatchFile(s.input, watch)()
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Serving %d nodes on %s\n", pg.Graph.Nodes().Len(), addr)
+			return server.Serve(addr, pg)
+		},
+	}
+	cmd.Flags().StringVar(&addr, "addr", "localhost:8080", "address to listen on")
+	cmd.Flags().DurationVar(&watch, "watch", 0, "reload the JSON input whenever it changes, checking at this interval")
+	return cmd
+}
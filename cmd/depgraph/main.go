@@ -0,0 +1,363 @@
+// Command depgraph builds dependency graphs from package JSON files and queries or exports them non-interactively,
+// so that it can be used in scripts. Every command reads either a JSON input file or a cache written by "depgraph
+// build". The exit code is 0 on success, 1 on errors, 2 on invalid usage and 3 if a package version is not found.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+	"github.com/spf13/cobra"
+)
+
+const (
+	exitError    = 1
+	exitUsage    = 2
+	exitNotFound = 3
+)
+
+// usageError marks errors caused by invalid arguments or flags.
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string { return e.err.Error() }
+
+// notFoundError is returned when a requested package version is not part of the graph.
+type notFoundError struct {
+	nameVersion g.NameVersion
+}
+
+func (e notFoundError) Error() string {
+	return fmt.Sprintf("%s is not part of the graph", e.nameVersion)
+}
+
+// settings holds the persistent flags shared by all commands.
+type settings struct {
+	input         string
+	json          bool
+	maven         bool
+	cutoff        string
+	windowStart   string
+	windowEnd     string
+	unparseable   bool
+	resolution    string
+	classes       []string
+	workers       int
+	truncate      bool
+	prerelease    string
+	verbose       bool
+	names         string
+	undeprecated  bool
+	lazy          bool
+	ids           string
+	edgeDirection string
+	maxCorrupt    int
+	corrupt       g.CorruptInputReport
+	aliases       string
+	metadata      string
+	// stderr is the error output of the command being run, and changed reports whether one of its flags was set on
+	// the command line.
+	stderr  io.Writer
+	changed func(name string) bool
+}
+
+// cacheFlags are the construction flags that a graph cache records, which it is checked against if any of them is set.
+var cacheFlags = []string{
+	"maven", "cutoff", "window-start", "window-end", "exclude-unparseable", "resolution", "classes", "prereleases",
+	"truncate-versions", "names", "prefer-non-deprecated", "ids", "edge-direction", "aliases",
+}
+
+func main() {
+	err := newRootCommand().Execute()
+	if err == nil {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "Error:", err)
+	var usage usageError
+	var notFound notFoundError
+	var query *g.QueryError
+	var mismatch *g.OptionMismatchError
+	switch {
+	case errors.As(err, &query):
+		fmt.Fprintln(os.Stderr, query.Context())
+		if query.NotFound {
+			os.Exit(exitNotFound)
+		}
+		os.Exit(exitUsage)
+	case errors.As(err, &usage), errors.As(err, &mismatch):
+		os.Exit(exitUsage)
+	case errors.As(err, &notFound):
+		os.Exit(exitNotFound)
+	default:
+		os.Exit(exitError)
+	}
+}
+
+func newRootCommand() *cobra.Command {
+	s := &settings{}
+	root := &cobra.Command{
+		Use:           "depgraph",
+		Short:         "depgraph builds, queries and exports package dependency graphs",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	root.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return usageError{err}
+	})
+	root.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		s.stderr = cmd.ErrOrStderr()
+		s.changed = cmd.Flags().Changed
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVarP(&s.input, "input", "i", "", "JSON input file or graph cache (required)")
+	flags.BoolVar(&s.json, "json", false, "print the output as JSON")
+	flags.BoolVar(&s.maven, "maven", false, "parse the dependency ranges as Maven ranges")
+	flags.StringVar(&s.cutoff, "cutoff", "", "leave out the versions released after this date (YYYY-MM-DD)")
+	flags.StringVar(&s.windowStart, "window-start", "", "only parse the versions released on or after this date (YYYY-MM-DD)")
+	flags.StringVar(&s.windowEnd, "window-end", "", "only parse the versions released before this date (YYYY-MM-DD)")
+	flags.BoolVar(&s.unparseable, "exclude-unparseable", false, "leave the versions without a parseable timestamp out of the window")
+	flags.StringVar(&s.resolution, "resolution", "all", "versions that edges are created to: all or highest")
+	flags.StringSliceVar(&s.classes, "classes", []string{"runtime"}, "dependency classes: runtime, dev, peer, optional")
+	flags.IntVar(&s.workers, "workers", 1, "number of goroutines matching dependency ranges")
+	flags.StringVar(&s.prerelease, "prereleases", "range", "when prereleases satisfy a range: exclude, range (if it has a prerelease) or always")
+	flags.BoolVar(&s.truncate, "truncate-versions", false, "match four-part versions such as 1.2.3.4 by their first three parts")
+	flags.StringVar(&s.names, "names", "decode", "package name normalization outside npm and maven: keep, decode (URL-encoded names) or npm (decode and lowercase)")
+	flags.BoolVar(&s.undeprecated, "prefer-non-deprecated", false, "with --resolution highest, avoid deprecated versions unless only they satisfy a range")
+	flags.BoolVar(&s.lazy, "lazy-edges", false, "create the edges of a version only once a query reaches it, for serve")
+	flags.StringVar(&s.ids, "ids", "sequential", "node IDs: sequential, or hashed to keep the ID of a version stable across builds")
+	flags.StringVar(&s.edgeDirection, "edge-direction", g.DependentToDependency.String(), "direction of the stored and exported edges: dependent-to-dependency or dependency-to-dependent")
+	flags.IntVar(&s.maxCorrupt, "max-corrupt", 0, "skip up to this many corrupt package records of the JSON input instead of failing, negative for any number")
+	flags.StringVar(&s.aliases, "aliases", "", "JSON file of package renames [{\"from\", \"to\", \"since\"}] whose old names resolve against the new ones")
+	flags.StringVar(&s.metadata, "metadata", "", "metadata file of the graph cache holding its strings: written by build, and mapped into memory read-only where --input is such a cache, so that processes on one machine share them")
+	flags.BoolVarP(&s.verbose, "verbose", "v", false, "log the duration of every construction stage to stderr")
+
+	root.AddCommand(
+		newBuildCommand(s),
+		newStatsCommand(s),
+		newAuditCommand(s),
+		newDigestCommand(s),
+		newDependenciesCommand(s),
+		newDependentsCommand(s),
+		newImpactCommand(s),
+		newQueryCommand(s),
+		newExportCommand(s),
+		newTopCommand(s),
+		newMetricsCommand(s),
+		newServeCommand(s),
+	)
+	return root
+}
+
+// exactArgs is cobra.ExactArgs, with its error marked as a usage error.
+func exactArgs(n int) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(n)(cmd, args); err != nil {
+			return usageError{err}
+		}
+		return nil
+	}
+}
+
+// minimumArgs is cobra.MinimumNArgs, with its error marked as a usage error.
+func minimumArgs(n int) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := cobra.MinimumNArgs(n)(cmd, args); err != nil {
+			return usageError{err}
+		}
+		return nil
+	}
+}
+
+// options converts the construction flags to graph options.
+func (s *settings) options() ([]g.Option, error) {
+	opts := []g.Option{g.WithWorkers(s.workers)}
+	switch s.resolution {
+	case "all":
+		opts = append(opts, g.WithResolution(g.ResolveAll))
+	case "highest":
+		opts = append(opts, g.WithResolution(g.ResolveHighest))
+	default:
+		return nil, usageError{fmt.Errorf("invalid resolution %q, expected all or highest", s.resolution)}
+	}
+
+	switch s.prerelease {
+	case "exclude":
+		opts = append(opts, g.WithPrereleases(g.ExcludePrereleases))
+	case "range":
+		opts = append(opts, g.WithPrereleases(g.IncludeIfRangeHasPrerelease))
+	case "always":
+		opts = append(opts, g.WithPrereleases(g.AlwaysIncludePrereleases))
+	default:
+		return nil, usageError{fmt.Errorf("invalid prerelease policy %q, expected exclude, range or always", s.prerelease)}
+	}
+
+	switch s.names {
+	case "keep":
+		opts = append(opts, g.WithNameNormalization(g.KeepNames))
+	case "decode":
+		opts = append(opts, g.WithNameNormalization(g.DecodeNames))
+	case "npm":
+		opts = append(opts, g.WithNameNormalization(g.NpmNames))
+	default:
+		return nil, usageError{fmt.Errorf("invalid name normalization %q, expected keep, decode or npm", s.names)}
+	}
+
+	switch s.ids {
+	case "sequential":
+		opts = append(opts, g.WithIDScheme(g.SequentialIDs))
+	case "hashed":
+		opts = append(opts, g.WithIDScheme(g.HashedIDs))
+	default:
+		return nil, usageError{fmt.Errorf("invalid ID scheme %q, expected sequential or hashed", s.ids)}
+	}
+
+	switch s.edgeDirection {
+	case g.DependentToDependency.String():
+		opts = append(opts, g.WithEdgeDirection(g.DependentToDependency))
+	case g.DependencyToDependent.String():
+		opts = append(opts, g.WithEdgeDirection(g.DependencyToDependent))
+	default:
+		return nil, usageError{fmt.Errorf("invalid edge direction %q, expected dependent-to-dependency or dependency-to-dependent", s.edgeDirection)}
+	}
+
+	classes := make([]g.DependencyClass, 0, len(s.classes))
+	for _, name := range s.classes {
+		class, ok := parseDependencyClass(name)
+		if !ok {
+			return nil, usageError{fmt.Errorf("invalid dependency class %q", name)}
+		}
+		classes = append(classes, class)
+	}
+	opts = append(opts, g.WithDependencyClasses(classes...))
+
+	if s.cutoff != "" {
+		cutoff, err := time.Parse("2006-01-02", s.cutoff)
+		if err != nil {
+			return nil, usageError{fmt.Errorf("invalid cutoff %q, expected YYYY-MM-DD", s.cutoff)}
+		}
+		opts = append(opts, g.WithCutoff(cutoff))
+	}
+	if s.windowStart != "" || s.windowEnd != "" {
+		var bounds [2]time.Time
+		for i, bound := range []string{s.windowStart, s.windowEnd} {
+			if bound == "" {
+				continue
+			}
+			t, err := time.Parse("2006-01-02", bound)
+			if err != nil {
+				return nil, usageError{fmt.Errorf("invalid window bound %q, expected YYYY-MM-DD", bound)}
+			}
+			bounds[i] = t
+		}
+		opts = append(opts, g.WithTimeWindow(bounds[0], bounds[1]))
+	}
+	if s.unparseable {
+		opts = append(opts, g.WithoutUnparseableTimestamps())
+	}
+	if s.truncate {
+		opts = append(opts, g.WithTruncatedVersions())
+	}
+	if s.undeprecated {
+		opts = append(opts, g.WithPreferNonDeprecated())
+	}
+	if s.lazy {
+		opts = append(opts, g.WithLazyEdges())
+	}
+	if s.maxCorrupt != 0 {
+		opts = append(opts, g.WithSkipCorrupt(&s.corrupt, s.maxCorrupt))
+	}
+	if s.aliases != "" {
+		table, err := g.ReadAliasTable(s.aliases)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, g.WithAliases(table))
+	}
+	if s.verbose {
+		opts = append(opts, g.WithLogger(g.NewStdLogger(log.New(s.stderr, "", log.LstdFlags), g.LevelInfo)))
+	}
+	return opts, nil
+}
+
+func parseDependencyClass(name string) (g.DependencyClass, bool) {
+	for _, class := range []g.DependencyClass{g.Runtime, g.Development, g.Peer, g.Optional} {
+		if class.String() == name {
+			return class, true
+		}
+	}
+	return 0, false
+}
+
+// loadGraph builds the graph from a JSON input file or loads it from a cache. The extra options only apply to JSON
+// input. A cache already contains the edges, so it is loaded with the options it was built with, unless one of the
+// cacheFlags is set on the command line: the cache must then have been built with the construction flags as given,
+// the ones left unset at their defaults, and is otherwise rejected with an *g.OptionMismatchError naming the first
+// flag that differs. A cache saved with --metadata is loaded with its metadata file mapped into memory. The corrupt
+// records skipped with --max-corrupt are listed on stderr.
+func (s *settings) loadGraph(extra ...g.Option) (*g.PackageGraph, error) {
+	if s.input == "" {
+		return nil, usageError{errors.New("no input given, use --input")}
+	}
+	if !strings.HasSuffix(strings.ToLower(s.input), ".json") {
+		return s.loadCache()
+	}
+	opts, err := s.options()
+	if err != nil {
+		return nil, err
+	}
+	pg, err := g.OpenPackageGraph(s.input, s.maven, append(opts, extra...)...)
+	s.reportCorrupt()
+	return pg, err
+}
+
+// loadCache loads the graph cache of --input, see loadGraph.
+func (s *settings) loadCache() (*g.PackageGraph, error) {
+	checked := false
+	for _, name := range cacheFlags {
+		checked = checked || s.changed != nil && s.changed(name)
+	}
+	if !checked {
+		if s.metadata != "" {
+			return g.LoadGraphFileWithMetadata(s.input, s.metadata)
+		}
+		return g.LoadGraphFile(s.input)
+	}
+	opts, err := s.options()
+	if err != nil {
+		return nil, err
+	}
+	if s.metadata != "" {
+		return g.LoadGraphFileWithMetadataAndOptions(s.input, s.metadata, s.maven, opts...)
+	}
+	return g.LoadGraphFileWithOptions(s.input, s.maven, opts...)
+}
+
+// readPackages reads the packages of the JSON input without building the graph, skipping corrupt records like
+// loadGraph.
+func (s *settings) readPackages() (*[]g.PackageInfo, error) {
+	if s.input == "" {
+		return nil, usageError{errors.New("no input given, use --input")}
+	}
+	var opts []g.Option
+	if s.maxCorrupt != 0 {
+		opts = append(opts, g.WithSkipCorrupt(&s.corrupt, s.maxCorrupt))
+	}
+	packagesList, err := g.ReadPackagesJSON(s.input, g.NewInterner(), opts...)
+	s.reportCorrupt()
+	return packagesList, err
+}
+
+// reportCorrupt lists the corrupt records skipped with --max-corrupt on stderr.
+func (s *settings) reportCorrupt() {
+	for _, record := range s.corrupt.Skipped {
+		fmt.Fprintf(s.stderr, "skipped the corrupt record at offset %d: %s\n", record.Offset, record.Error)
+	}
+}
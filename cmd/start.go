@@ -11,7 +11,6 @@ import (
 
 	g "github.com/AJMBrands/SoftwareThatMatters/graph"
 	"github.com/spf13/cobra"
-	"gonum.org/v1/gonum/graph/simple"
 )
 
 // startCmd represents the start command
@@ -66,10 +65,8 @@ func start() {
 		panic(err)
 	}
 
-	//graph, packagesList, stringIDToNodeInfo, idToNodeInfo, nameToVersions := g.CreateGraph(path, isUsingMaven)
-	graph, _, stringIDToNodeInfo, idToNodeInfo, _ := g.CreateGraph(path, isUsingMaven)
-	// TODO: remove this when we use the actual variables. It is here to get rid of the unused variables warning
-	//_, _, _, _, _ = g.CreateGraph(path, isUsingMaven)
+	pg := g.CreatePackageGraph(path, isUsingMaven)
+	idToNodeInfo := pg.NodeMap()
 
 	//"View the graph", "View the packages list", "View the packages list with versions", "View the packages list with versions and dependencies"
 	stop := false
@@ -100,16 +97,15 @@ func start() {
 			}
 		case 1:
 			fmt.Println("This should find all the possible dependencies of a package")
-			name := generateAndRunPackageNamePrompt("Please input the package name", stringIDToNodeInfo)
-			nodes := g.GetTransitiveDependenciesNode(graph, idToNodeInfo, stringIDToNodeInfo, name)
-			for _, node := range *nodes {
+			nameVersion := generateAndRunPackageNamePrompt("Please input the package name", pg)
+			for _, node := range transitiveDependencies(pg, nameVersion) {
 				fmt.Println(node)
 			}
 
 		case 2:
 			fmt.Println("This should find all the possible dependencies of a package between two timestamps")
-			nodes := findAllDependenciesOfAPackageBetweenTwoTimestamps(graph, idToNodeInfo, stringIDToNodeInfo)
-			for _, node := range *nodes {
+			nodes := findAllDependenciesOfAPackageBetweenTwoTimestamps(pg, idToNodeInfo)
+			for _, node := range nodes {
 				fmt.Println(node)
 			}
 		case 3:
@@ -172,12 +168,23 @@ func findAllPackagesBetweenTwoTimestamps(idToNodeInfo map[int64]g.NodeInfo) *[]g
 
 }
 
-func findAllDependenciesOfAPackageBetweenTwoTimestamps(graph *simple.DirectedGraph, nodeMap map[int64]g.NodeInfo, stringIDToNodeInfo map[string]g.NodeInfo) *[]g.NodeInfo {
+func findAllDependenciesOfAPackageBetweenTwoTimestamps(pg *g.PackageGraph, nodeMap map[int64]g.NodeInfo) []g.NodeInfo {
 	beginTime := generateAndRunDatePrompt("Please input the beginning date of the interval (DD-MM-YYYY)")
 	endTime := generateAndRunDatePrompt("Please input the end date of the interval (DD-MM-YYYY)")
-	nodeStringId := generateAndRunPackageNamePrompt("Please select the name and the version of the package", stringIDToNodeInfo)
-	g.FilterGraph(graph, nodeMap, beginTime, endTime)
-	return g.GetTransitiveDependenciesNode(graph, nodeMap, stringIDToNodeInfo, nodeStringId)
+	nameVersion := generateAndRunPackageNamePrompt("Please select the name and the version of the package", pg)
+	g.FilterGraph(pg.Graph, nodeMap, beginTime, endTime)
+	return transitiveDependencies(pg, nameVersion)
+}
+
+// transitiveDependencies returns the given package version followed by all its transitive dependencies, or nothing if
+// the version is not part of the graph.
+func transitiveDependencies(pg *g.PackageGraph, nameVersion g.NameVersion) []g.NodeInfo {
+	root, ok := pg.FindNode(nameVersion)
+	if !ok {
+		return nil
+	}
+	dependencies, _ := pg.Dependencies(nameVersion, -1)
+	return append([]g.NodeInfo{root}, dependencies...)
 }
 
 func generateAndRunDatePrompt(message string) time.Time {
@@ -217,10 +224,12 @@ func generateAndRunDatePrompt(message string) time.Time {
 
 }
 
-func generateAndRunPackageNamePrompt(message string, stringIDToNodeInfo map[string]g.NodeInfo) string {
-	keys := make([]string, 0, len(stringIDToNodeInfo))
-	for key := range stringIDToNodeInfo {
-		keys = append(keys, key)
+func generateAndRunPackageNamePrompt(message string, pg *g.PackageGraph) g.NameVersion {
+	keys := make([]string, 0, len(pg.Nodes))
+	for _, node := range pg.Nodes {
+		if node.StringID() != "" {
+			keys = append(keys, node.NameVersion().String())
+		}
 	}
 	packagePrompt := &survey.Select{
 		Message: message,
@@ -237,7 +246,11 @@ func generateAndRunPackageNamePrompt(message string, stringIDToNodeInfo map[stri
 		panic(err)
 	}
 
-	return packageID
+	nameVersion, err := g.ParseNameVersion(packageID)
+	if err != nil {
+		panic(err)
+	}
+	return nameVersion
 }
 
 func init() {
@@ -0,0 +1,35 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+// ExportReleaseAdoptionCSV writes a release adoption report with the columns version, previous, kind and released,
+// followed by a dependents_<days>d and a share_<days>d column for every number of days in g.AdoptionDays, after a
+// header row. The release time is written in RFC 3339.
+func ExportReleaseAdoptionCSV(rows []g.AdoptionRow, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{"version", "previous", "kind", "released"}
+	for _, days := range g.AdoptionDays {
+		header = append(header, "dependents_"+strconv.Itoa(days)+"d", "share_"+strconv.Itoa(days)+"d")
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := []string{row.Version, row.Previous, row.Kind.String(), row.Released.UTC().Format(time.RFC3339)}
+		for i := range g.AdoptionDays {
+			record = append(record, strconv.Itoa(row.Dependents[i]), strconv.FormatFloat(row.Shares[i], 'f', -1, 64))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,29 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+func TestExportReleaseAdoptionCSV(t *testing.T) {
+	rows := []g.AdoptionRow{{
+		Version:    "1.1.0",
+		Previous:   "1.0.0",
+		Kind:       g.MinorRelease,
+		Released:   time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		Dependents: []int{2, 4, 4},
+		Shares:     []float64{0.5, 0.75, 1},
+	}}
+	var buffer bytes.Buffer
+	if err := ExportReleaseAdoptionCSV(rows, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	expected := "version,previous,kind,released,dependents_30d,share_30d,dependents_90d,share_90d,dependents_180d,share_180d\n" +
+		"1.1.0,1.0.0,minor,2021-01-01T00:00:00Z,2,0.5,4,0.75,4,1\n"
+	if buffer.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, buffer.String())
+	}
+}
@@ -0,0 +1,47 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+// ExportVersionCountHistogramCSV writes a histogram of the number of versions per package with the columns versions
+// and packages, after a header row, in increasing number of versions.
+func ExportVersionCountHistogramCSV(histogram map[int]int, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"versions", "packages"}); err != nil {
+		return err
+	}
+	for _, bin := range g.SortedHistogram(histogram) {
+		if err := writer.Write([]string{strconv.Itoa(bin.Versions), strconv.Itoa(bin.Packages)}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ExportCadenceCSV writes a release cadence report with the columns name, versions, median_gap_days and
+// mean_gap_days, after a header row.
+func ExportCadenceCSV(rows []g.CadenceRow, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"name", "versions", "median_gap_days", "mean_gap_days"}); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := []string{
+			row.Name,
+			strconv.Itoa(row.Versions),
+			strconv.FormatFloat(row.MedianGapDays, 'f', -1, 64),
+			strconv.FormatFloat(row.MeanGapDays, 'f', -1, 64),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
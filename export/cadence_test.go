@@ -0,0 +1,29 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+func TestExportCadenceCSV(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := ExportVersionCountHistogramCSV(map[int]int{5: 1, 2: 3}, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	expected := "versions,packages\n2,3\n5,1\n"
+	if buffer.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, buffer.String())
+	}
+
+	buffer.Reset()
+	rows := []g.CadenceRow{{Name: "A", Versions: 3, MedianGapDays: 2, MeanGapDays: 2.5}}
+	if err := ExportCadenceCSV(rows, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	expected = "name,versions,median_gap_days,mean_gap_days\nA,3,2,2.5\n"
+	if buffer.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, buffer.String())
+	}
+}
@@ -0,0 +1,70 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+// ExportCSV writes the nodes of the graph to nodes, with the column id followed by a column for every node attribute of
+// the schema, see PackageGraph.Attributes, and its edges to edges, with the columns from and to followed by a column
+// for every edge attribute. By default these are name, version, timestamp, license, ecosystem, deprecated and
+// maintainers, and constraint, weight and class. Missing values are written as empty cells. Both start with a header
+// row.
+func ExportCSV(pg *g.PackageGraph, nodes, edges io.Writer) error {
+	schema := pg.Attributes()
+	nodeAttributes, edgeAttributes := schema.Nodes(), schema.Edges()
+
+	nodeWriter := csv.NewWriter(nodes)
+	header := []string{"id"}
+	for _, attribute := range nodeAttributes {
+		header = append(header, attribute.Name)
+	}
+	if err := nodeWriter.Write(header); err != nil {
+		return err
+	}
+	for _, node := range sortedNodes(pg) {
+		row := []string{strconv.FormatInt(node.ID(), 10)}
+		for _, attribute := range nodeAttributes {
+			row = append(row, csvValue(attribute.Value(pg, node)))
+		}
+		if err := nodeWriter.Write(row); err != nil {
+			return err
+		}
+	}
+	nodeWriter.Flush()
+	if err := nodeWriter.Error(); err != nil {
+		return err
+	}
+
+	edgeWriter := csv.NewWriter(edges)
+	header = []string{"from", "to"}
+	for _, attribute := range edgeAttributes {
+		header = append(header, attribute.Name)
+	}
+	if err := edgeWriter.Write(header); err != nil {
+		return err
+	}
+	err := eachEdge(pg, func(from, to int64) error {
+		row := []string{strconv.FormatInt(from, 10), strconv.FormatInt(to, 10)}
+		for _, attribute := range edgeAttributes {
+			row = append(row, csvValue(attribute.Value(pg, from, to)))
+		}
+		return edgeWriter.Write(row)
+	})
+	if err != nil {
+		return err
+	}
+	edgeWriter.Flush()
+	return edgeWriter.Error()
+}
+
+// csvValue returns the cell of an attribute value, which is empty if there is none.
+func csvValue(value interface{}, ok bool) string {
+	if !ok {
+		return ""
+	}
+	return formatAttribute(value)
+}
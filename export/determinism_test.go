@@ -0,0 +1,165 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+// createDeterminismTestPackages returns packages with several versions each, random ranges and every kind of metadata,
+// so that every exporter has to order something.
+func createDeterminismTestPackages() []g.PackageInfo {
+	var packagesInfo []g.PackageInfo
+	licenses := []g.License{"MIT", "Apache-2.0", "", "GPL-3.0"}
+	for i := 0; i < 12; i++ {
+		versions := make(map[string]g.VersionInfo)
+		for j := 0; j < i%4+1; j++ {
+			dependencies := make(map[string]string)
+			devDependencies := make(map[string]string)
+			for k := 0; k < i; k += 3 {
+				dependencies[fmt.Sprintf("P%d", k)] = fmt.Sprintf(">= 1.%d.0", j%2)
+				devDependencies[fmt.Sprintf("P%d", (k+1)%i)] = "*"
+			}
+			versions[fmt.Sprintf("1.%d.0", j)] = g.VersionInfo{
+				Timestamp:       time.Date(2018+j, time.Month(i%12+1), 1+j, 0, 0, 0, 0, time.UTC).Format("2006-01-02T15:04:05"),
+				Dependencies:    dependencies,
+				DevDependencies: devDependencies,
+				License:         licenses[(i+j)%len(licenses)],
+				Maintainers:     g.Maintainers{fmt.Sprintf("m%d", i%3), fmt.Sprintf("m%d", j)},
+			}
+		}
+		packagesInfo = append(packagesInfo, g.PackageInfo{Name: fmt.Sprintf("P%d", i), Versions: versions})
+	}
+	return packagesInfo
+}
+
+func TestDeterminism(t *testing.T) {
+	root := g.NameVersion{Name: "P11", Version: "1.0.0"}
+	exporters := map[string]func(pg *g.PackageGraph, packagesInfo []g.PackageInfo, w io.Writer) error{
+		"CSV":     func(pg *g.PackageGraph, _ []g.PackageInfo, w io.Writer) error { return ExportCSV(pg, w, w) },
+		"DOT":     func(pg *g.PackageGraph, _ []g.PackageInfo, w io.Writer) error { return ExportDOT(pg, w, "graph") },
+		"GEXF":    func(pg *g.PackageGraph, _ []g.PackageInfo, w io.Writer) error { return ExportGEXF(pg, w) },
+		"GraphML": func(pg *g.PackageGraph, _ []g.PackageInfo, w io.Writer) error { return ExportGraphML(pg, w) },
+		"JSON":    func(pg *g.PackageGraph, _ []g.PackageInfo, w io.Writer) error { return ExportJSON(pg, w, nil) },
+		"Neo4j":   func(pg *g.PackageGraph, _ []g.PackageInfo, w io.Writer) error { return ExportNeo4j(pg, w, w) },
+		"Cypher":  func(pg *g.PackageGraph, _ []g.PackageInfo, w io.Writer) error { return ExportCypher(pg, w) },
+		"Metrics": func(pg *g.PackageGraph, _ []g.PackageInfo, w io.Writer) error {
+			scores, err := g.ComputeMetrics(pg, g.MetricNames())
+			if err != nil {
+				return err
+			}
+			if err := ExportMetricsCSV(pg, scores, w); err != nil {
+				return err
+			}
+			return ExportNodeTable(pg, scores, w)
+		},
+		"Resolution": func(pg *g.PackageGraph, _ []g.PackageInfo, w io.Writer) error {
+			return ExportResolution(pg, root, time.Time{}, w)
+		},
+		"Freshness": func(pg *g.PackageGraph, _ []g.PackageInfo, w io.Writer) error {
+			rows, _ := g.FreshnessReport(pg)
+			return ExportFreshnessCSV(rows, w)
+		},
+		"Adoption": func(pg *g.PackageGraph, _ []g.PackageInfo, w io.Writer) error {
+			rows, _ := g.ReleaseAdoption(pg, "P0")
+			return ExportReleaseAdoptionCSV(rows, w)
+		},
+		"Lifespan": func(pg *g.PackageGraph, _ []g.PackageInfo, w io.Writer) error {
+			return ExportLifespanCSV(pg, g.DependentLifespan(pg), w, nil)
+		},
+		"Histogram": func(_ *g.PackageGraph, packagesInfo []g.PackageInfo, w io.Writer) error {
+			return ExportVersionCountHistogramCSV(g.VersionCountHistogram(packagesInfo), w)
+		},
+		"Cadence": func(_ *g.PackageGraph, packagesInfo []g.PackageInfo, w io.Writer) error {
+			rows, _ := g.ReleaseCadence(packagesInfo)
+			return ExportCadenceCSV(rows, w)
+		},
+		"Growth": func(_ *g.PackageGraph, packagesInfo []g.PackageInfo, w io.Writer) error {
+			rows, err := g.GrowthReport(packagesInfo)
+			if err != nil {
+				return err
+			}
+			return ExportGrowthCSV(rows, w)
+		},
+		"TimeSeries": func(_ *g.PackageGraph, packagesInfo []g.PackageInfo, w io.Writer) error {
+			points, _ := g.DependentTimeSeries(packagesInfo, "P0", g.Monthly())
+			return ExportTimeSeriesCSV(points, w)
+		},
+		"RangeChanges": func(_ *g.PackageGraph, packagesInfo []g.PackageInfo, w io.Writer) error {
+			return ExportRangeChangesCSV(g.RangeChangeEvents(packagesInfo), w)
+		},
+		"Cache": func(pg *g.PackageGraph, _ []g.PackageInfo, w io.Writer) error { return g.SaveGraph(w, pg) },
+		"Packages": func(_ *g.PackageGraph, packagesInfo []g.PackageInfo, w io.Writer) error {
+			return g.SavePackages(w, packagesInfo)
+		},
+	}
+	// Every run builds the graph again from new packages, so that the order of every map differs between the runs.
+	export := func(exporter func(pg *g.PackageGraph, packagesInfo []g.PackageInfo, w io.Writer) error) []byte {
+		packagesInfo := createDeterminismTestPackages()
+		pg := g.NewPackageGraph(&packagesInfo, false)
+		var buffer bytes.Buffer
+		if err := exporter(pg, createDeterminismTestPackages(), &buffer); err != nil {
+			t.Fatal(err)
+		}
+		return buffer.Bytes()
+	}
+	for name, exporter := range exporters {
+		t.Run(fmt.Sprintf("Exports %s twice the same", name), func(t *testing.T) {
+			first := export(exporter)
+			if len(first) == 0 {
+				t.Fatalf("Expected output")
+			}
+			for run := 0; run < 5; run++ {
+				if second := export(exporter); !bytes.Equal(first, second) {
+					t.Fatalf("Expected the same output on every run, got\n%s\nand\n%s", first, second)
+				}
+			}
+		})
+	}
+
+	t.Run("Exports the same files per package twice", func(t *testing.T) {
+		var outputs [2]map[string][]byte
+		for run := range outputs {
+			packagesInfo := createDeterminismTestPackages()
+			pg := g.NewPackageGraph(&packagesInfo, false)
+			names := make([]string, len(packagesInfo))
+			for i, packageInfo := range packagesInfo {
+				names[i] = packageInfo.Name
+			}
+			for _, format := range []Format{DOT, GraphML} {
+				dir := t.TempDir()
+				if err := ExportPerPackage(pg, names, dir, format, -1); err != nil {
+					t.Fatal(err)
+				}
+				files, err := filepath.Glob(filepath.Join(dir, "*"))
+				if err != nil || len(files) == 0 {
+					t.Fatalf("Expected exported files, got %v and %v", files, err)
+				}
+				if outputs[run] == nil {
+					outputs[run] = make(map[string][]byte)
+				}
+				for _, file := range files {
+					data, err := os.ReadFile(file)
+					if err != nil {
+						t.Fatal(err)
+					}
+					outputs[run][format.String()+"/"+filepath.Base(file)] = data
+				}
+			}
+		}
+		if len(outputs[0]) != len(outputs[1]) {
+			t.Fatalf("Expected the same files, got %d and %d", len(outputs[0]), len(outputs[1]))
+		}
+		for file, data := range outputs[0] {
+			if !bytes.Equal(data, outputs[1][file]) {
+				t.Errorf("Expected the same contents of %s, got\n%s\nand\n%s", file, data, outputs[1][file])
+			}
+		}
+	})
+}
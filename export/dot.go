@@ -0,0 +1,84 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+// dotNames are the attributes of the schema that are written under another name, because GraphViz gives their names a
+// meaning for the layout: a constraint such as "0.1.0" would read as false and drop the edge from the ranking.
+var dotNames = map[string]string{"constraint": "range"}
+
+// ExportDOT writes the graph as a GraphViz digraph in which every node is labelled with its name and version, and
+// every other attribute of the schema, see PackageGraph.Attributes, becomes a node or an edge attribute. Missing values
+// and values equal to the default of their attribute are left out, and the constraint is written as range. The
+// weight keeps its GraphViz meaning, and edges with a weight above 1 also get a penwidth attribute of that weight. The
+// center of a graph extracted by EgoNetwork is drawn with a bold double outline, as by WriteVisualization.
+func ExportDOT(pg *g.PackageGraph, w io.Writer, name string) error {
+	center, hasCenter := pg.Center()
+	schema := pg.Attributes()
+	nodeAttributes, edgeAttributes := schema.Nodes(), schema.Edges()
+	buffered := bufio.NewWriter(w)
+	if _, err := fmt.Fprintf(buffered, "strict digraph %s {\n", strconv.Quote(name)); err != nil {
+		return err
+	}
+	for _, node := range sortedNodes(pg) {
+		attributes := []string{"label=" + strconv.Quote(node.Name+"\n"+node.Version)}
+		for _, attribute := range nodeAttributes {
+			if attribute.Name == "name" || attribute.Name == "version" {
+				continue
+			}
+			value, ok := attribute.Value(pg, node)
+			attributes = appendDOTAttribute(attributes, attribute.Name, attribute.Default, value, ok)
+		}
+		if hasCenter && node.Name == center.Name && node.Version == center.Version {
+			attributes = append(attributes, "peripheries=2", "penwidth=2")
+		}
+		if _, err := fmt.Fprintf(buffered, "  %d [%s];\n", node.ID(), strings.Join(attributes, ", ")); err != nil {
+			return err
+		}
+	}
+	err := eachEdge(pg, func(from, to int64) error {
+		var attributes []string
+		for _, attribute := range edgeAttributes {
+			value, ok := attribute.Value(pg, from, to)
+			attributes = appendDOTAttribute(attributes, attribute.Name, attribute.Default, value, ok)
+			if attribute.Name == "weight" && !omitAttribute(value, attribute.Default, ok) {
+				attributes = append(attributes, "penwidth="+formatAttribute(value))
+			}
+		}
+		if len(attributes) == 0 {
+			_, err := fmt.Fprintf(buffered, "  %d -> %d;\n", from, to)
+			return err
+		}
+		_, err := fmt.Fprintf(buffered, "  %d -> %d [%s];\n", from, to, strings.Join(attributes, ", "))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := buffered.WriteString("}\n"); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
+
+// appendDOTAttribute appends the value of the attribute to attributes, unless it is omitted. Strings are quoted, and
+// the other values are written as they are.
+func appendDOTAttribute(attributes []string, name string, defaultValue, value interface{}, ok bool) []string {
+	if omitAttribute(value, defaultValue, ok) {
+		return attributes
+	}
+	if renamed, ok := dotNames[name]; ok {
+		name = renamed
+	}
+	if text, isString := value.(string); isString {
+		return append(attributes, name+"="+strconv.Quote(text))
+	}
+	return append(attributes, name+"="+formatAttribute(value))
+}
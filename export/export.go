@@ -1 +1,81 @@
+// Package export writes a PackageGraph to the file formats used by other tools. All exporters write nodes in
+// increasing ID order and edges in increasing (from, to) order, so that exporting the same graph twice produces the
+// same output.
 package export
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+// sortedNodes returns the node information of every node in the graph, sorted by ID.
+func sortedNodes(pg *g.PackageGraph) []g.NodeInfo {
+	nodes := make([]g.NodeInfo, 0, len(pg.Nodes))
+	for _, node := range pg.Nodes {
+		if node.StringID() != "" && pg.Graph.Node(node.ID()) != nil {
+			nodes = append(nodes, node)
+		}
+	}
+	// With HashedIDs, Nodes is in the order in which the nodes were added.
+	if !sort.SliceIsSorted(nodes, func(i, j int) bool { return nodes[i].ID() < nodes[j].ID() }) {
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID() < nodes[j].ID() })
+	}
+	return nodes
+}
+
+// eachEdge calls fn with the (from, to) pairs of every edge in the graph, sorted by from and then by to, and stops at
+// the first error. The edges of a graph built with WithExternalEdges are read from its edge file one at a time.
+func eachEdge(pg *g.PackageGraph, fn func(from, to int64) error) error {
+	file, err := pg.ExternalEdges()
+	if err != nil {
+		return err
+	}
+	if file != nil {
+		return file.Each(fn)
+	}
+	for _, edge := range sortedEdges(pg) {
+		if err := fn(edge[0], edge[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eachDependency is eachEdge for the formats whose edges name the dependent first, whatever the direction in which the
+// graph stores its edges, see graph.WithEdgeDirection.
+func eachDependency(pg *g.PackageGraph, fn func(dependent, dependency int64) error) error {
+	return eachEdge(pg, func(from, to int64) error {
+		return fn(pg.DependencyEdge(from, to))
+	})
+}
+
+// sortedEdges returns the (from, to) pairs of every edge in the graph, sorted by from and then by to.
+func sortedEdges(pg *g.PackageGraph) [][2]int64 {
+	edges := make([][2]int64, 0, pg.Graph.Edges().Len())
+	it := pg.Graph.Edges()
+	for it.Next() {
+		edges = append(edges, [2]int64{it.Edge().From().ID(), it.Edge().To().ID()})
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i][0] != edges[j][0] {
+			return edges[i][0] < edges[j][0]
+		}
+		return edges[i][1] < edges[j][1]
+	})
+	return edges
+}
+
+// formatAttribute formats the value of an attribute as text.
+func formatAttribute(value interface{}) string {
+	return fmt.Sprint(value)
+}
+
+// omitAttribute reports whether the formats that can leave values out omit the value of an attribute: if there is no
+// value, or if it is the default of the attribute. The values are compared with reflect.DeepEqual, so that the values
+// of attributes that are slices or maps can be compared as well.
+func omitAttribute(value, defaultValue interface{}, ok bool) bool {
+	return !ok || (defaultValue != nil && reflect.DeepEqual(value, defaultValue))
+}
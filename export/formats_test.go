@@ -0,0 +1,348 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"testing"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+func TestExportCSV(t *testing.T) {
+	pg := createExportTestGraph()
+	var nodes, edges bytes.Buffer
+	if err := ExportCSV(pg, &nodes, &edges); err != nil {
+		t.Fatal(err)
+	}
+	compareWithGolden(t, nodes.Bytes(), "csv/nodes.csv")
+	compareWithGolden(t, edges.Bytes(), "csv/edges.csv")
+}
+
+func TestExportClassGraph(t *testing.T) {
+	pg := createExportTestGraph(g.WithDependencyClasses(g.Runtime, g.Development), g.WithSplitByClass())
+	var nodes, edges bytes.Buffer
+	if err := ExportCSV(pg.ClassGraphs()[g.Runtime], &nodes, &edges); err != nil {
+		t.Fatal(err)
+	}
+	compareWithGolden(t, nodes.Bytes(), "csv/nodes.csv")
+	compareWithGolden(t, edges.Bytes(), "csv/edges.csv")
+}
+
+func TestExportHashedIDs(t *testing.T) {
+	pg := createExportTestGraph(g.WithIDScheme(g.HashedIDs))
+	var nodes, edges bytes.Buffer
+	if err := ExportCSV(pg, &nodes, &edges); err != nil {
+		t.Fatal(err)
+	}
+	nodeRows, err := csv.NewReader(&nodes).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make(map[string]bool)
+	var previous int64 = -1
+	for _, row := range nodeRows[1:] {
+		id, _ := strconv.ParseInt(row[0], 10, 64)
+		if expected := g.HashedID(g.NameVersion{Name: row[1], Version: row[2]}); id != expected {
+			t.Errorf("Expected ID %d for %s %s, got %d", expected, row[1], row[2], id)
+		}
+		if id <= previous {
+			t.Errorf("Expected the nodes sorted by ID, got %d after %d", id, previous)
+		}
+		previous = id
+		ids[row[0]] = true
+	}
+	edgeRows, err := csv.NewReader(&edges).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodeRows) != 4 || len(edgeRows) != 4 {
+		t.Errorf("Expected 3 nodes and 3 edges, got %d and %d", len(nodeRows)-1, len(edgeRows)-1)
+	}
+	for _, row := range edgeRows[1:] {
+		if !ids[row[0]] || !ids[row[1]] {
+			t.Errorf("Expected an edge between exported nodes, got %v", row)
+		}
+	}
+}
+
+func TestExportExternalEdges(t *testing.T) {
+	pg := createExportTestGraph(g.WithExternalEdges(t.TempDir(), 2))
+	var nodes, edges, graphML bytes.Buffer
+	if err := ExportCSV(pg, &nodes, &edges); err != nil {
+		t.Fatal(err)
+	}
+	compareWithGolden(t, nodes.Bytes(), "csv/nodes.csv")
+	compareWithGolden(t, edges.Bytes(), "csv/edges.csv")
+	if err := ExportGraphML(pg, &graphML); err != nil {
+		t.Fatal(err)
+	}
+	compareWithGolden(t, graphML.Bytes(), "graph.graphml")
+}
+
+func TestExportGraphML(t *testing.T) {
+	pg := createExportTestGraph()
+	var buffer bytes.Buffer
+	if err := ExportGraphML(pg, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	compareWithGolden(t, buffer.Bytes(), "graph.graphml")
+
+	var decoded struct {
+		Nodes []struct{} `xml:"graph>node"`
+		Edges []struct{} `xml:"graph>edge"`
+	}
+	if err := xml.Unmarshal(buffer.Bytes(), &decoded); err != nil {
+		t.Fatalf("Expected valid XML, got %v", err)
+	}
+	if len(decoded.Nodes) != 3 || len(decoded.Edges) != 3 {
+		t.Errorf("Expected 3 nodes and 3 edges, got %d and %d", len(decoded.Nodes), len(decoded.Edges))
+	}
+}
+
+func TestExportDOT(t *testing.T) {
+	pg := createExportTestGraph()
+	var buffer bytes.Buffer
+	if err := ExportDOT(pg, &buffer, "dependencies"); err != nil {
+		t.Fatal(err)
+	}
+	compareWithGolden(t, buffer.Bytes(), "graph.dot")
+
+	ego, err := g.EgoNetwork(pg, g.NameVersion{Name: "B", Version: "1.2.0"}, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buffer.Reset()
+	if err := ExportDOT(ego, &buffer, "ego"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buffer.String(), `label="B\n1.2.0", timestamp="2021-04-22T20:15:37", peripheries=2, penwidth=2`) {
+		t.Errorf("Expected the center to be highlighted, got %s", buffer.String())
+	}
+	if count := strings.Count(buffer.String(), "peripheries=2"); count != 1 {
+		t.Errorf("Expected only the center to be highlighted, got %d highlighted nodes", count)
+	}
+}
+
+func TestExportGEXF(t *testing.T) {
+	pg := createExportTestGraph()
+	var buffer bytes.Buffer
+	if err := ExportGEXF(pg, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	compareWithGolden(t, buffer.Bytes(), "graph.gexf")
+
+	var decoded struct {
+		Nodes []struct{} `xml:"graph>nodes>node"`
+		Edges []struct{} `xml:"graph>edges>edge"`
+	}
+	if err := xml.Unmarshal(buffer.Bytes(), &decoded); err != nil {
+		t.Fatalf("Expected valid XML, got %v", err)
+	}
+	if len(decoded.Nodes) != 3 || len(decoded.Edges) != 3 {
+		t.Errorf("Expected 3 nodes and 3 edges, got %d and %d", len(decoded.Nodes), len(decoded.Edges))
+	}
+}
+
+func TestExportCustomAttributes(t *testing.T) {
+	pg := createExportTestGraph()
+	schema := pg.Attributes()
+	err := schema.RegisterNode(g.NodeAttribute{Name: "name_length", Type: g.IntAttribute, Default: 0,
+		Value: func(_ *g.PackageGraph, node g.NodeInfo) (interface{}, bool) { return len(node.Name), node.Name != "B" }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = schema.RegisterEdge(g.EdgeAttribute{Name: "internal", Type: g.BoolAttribute,
+		Value: func(_ *g.PackageGraph, from, to int64) (interface{}, bool) { return from == 0, true }})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var nodes, edges bytes.Buffer
+	if err := ExportCSV(pg, &nodes, &edges); err != nil {
+		t.Fatal(err)
+	}
+	expected := "id,name,version,timestamp,license,ecosystem,deprecated,maintainers,name_length\n" +
+		"0,App,1.0.0,2022-04-22T20:15:37,MIT,,,,3\n" +
+		"1,B,1.2.0,2021-04-22T20:15:37,,,,,\n" +
+		"2,\"quoted,\"\"name\"\"\",1.0.0,2020-01-01T00:00:00,,,,,13\n"
+	if nodes.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, nodes.String())
+	}
+	if expected := "from,to,constraint,weight,class,internal\n0,1,>= 1.0.0,1,runtime,true\n0,2,1.0.0,1,runtime,true\n1,2,< 2.0.0,1,runtime,false\n"; edges.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, edges.String())
+	}
+
+	exports := map[string]func(w *bytes.Buffer) error{
+		"GraphML": func(w *bytes.Buffer) error { return ExportGraphML(pg, w) },
+		"GEXF":    func(w *bytes.Buffer) error { return ExportGEXF(pg, w) },
+		"DOT":     func(w *bytes.Buffer) error { return ExportDOT(pg, w, "custom") },
+		"JSON":    func(w *bytes.Buffer) error { return ExportJSON(pg, w, nil) },
+	}
+	expectedParts := map[string][]string{
+		"GraphML": {`<key id="name_length" for="node" attr.name="name_length" attr.type="int">`, `<data key="name_length">13</data>`,
+			`<key id="internal" for="edge" attr.name="internal" attr.type="boolean"/>`, `<data key="internal">false</data>`},
+		"GEXF": {`<attribute id="name_length" title="name_length" type="integer">`, `<attvalue for="name_length" value="13"/>`,
+			`<attribute id="internal" title="internal" type="boolean"/>`, `<attvalue for="internal" value="true"/>`},
+		"DOT":  {`2 [label="quoted,\"name\"\n1.0.0", timestamp="2020-01-01T00:00:00", name_length=13];`, `1 -> 2 [range="< 2.0.0", internal=false];`},
+		"JSON": {`"timestamp":"2022-04-22T20:15:37","license":"MIT","name_length":3}`, `"weight":1,"class":"runtime","internal":true}`},
+	}
+	for format, export := range exports {
+		var buffer bytes.Buffer
+		if err := export(&buffer); err != nil {
+			t.Fatal(err)
+		}
+		for _, part := range expectedParts[format] {
+			if !bytes.Contains(buffer.Bytes(), []byte(part)) {
+				t.Errorf("Expected the %s output to contain %s, got %s", format, part, buffer.String())
+			}
+		}
+	}
+
+	t.Run("Omits the default values of slice attributes", func(t *testing.T) {
+		pg := createExportTestGraph()
+		err := pg.Attributes().RegisterNode(g.NodeAttribute{Name: "tags", Default: []string{"none"},
+			Value: func(_ *g.PackageGraph, node g.NodeInfo) (interface{}, bool) {
+				if node.Name == "B" {
+					return []string{"none"}, true
+				}
+				return []string{"app"}, true
+			}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buffer bytes.Buffer
+		if err := ExportDOT(pg, &buffer, "tags"); err != nil {
+			t.Fatal(err)
+		}
+		if count := strings.Count(buffer.String(), "tags=[app]"); count != 2 || strings.Contains(buffer.String(), "tags=[none]") {
+			t.Errorf("Expected the tags of all versions but B, got %s", buffer.String())
+		}
+	})
+}
+
+func TestExportBuiltInAttributes(t *testing.T) {
+	packagesInfo := []g.PackageInfo{
+		{Name: "app", Versions: map[string]g.VersionInfo{
+			"1.0.0": {Dependencies: map[string]string{"lib": "1.0.0"}, DevDependencies: map[string]string{"tool": "1.0.0"},
+				Maintainers: g.Maintainers{"alice", "bob"}},
+		}},
+		{Name: "lib", Versions: map[string]g.VersionInfo{"1.0.0": {Deprecated: "use lib2"}}},
+		{Name: "tool", Versions: map[string]g.VersionInfo{"1.0.0": {}}},
+	}
+	pg := g.NewPackageGraph(&packagesInfo, false, g.WithDependencyClasses(g.Runtime, g.Development))
+
+	var nodes, edges bytes.Buffer
+	if err := ExportCSV(pg, &nodes, &edges); err != nil {
+		t.Fatal(err)
+	}
+	expected := "id,name,version,timestamp,license,ecosystem,deprecated,maintainers\n" +
+		"0,app,1.0.0,,,,,\"alice, bob\"\n" +
+		"1,lib,1.0.0,,,,use lib2,\n" +
+		"2,tool,1.0.0,,,,,\n"
+	if nodes.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, nodes.String())
+	}
+	if expected := "from,to,constraint,weight,class\n0,1,1.0.0,1,runtime\n0,2,1.0.0,1,dev\n"; edges.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, edges.String())
+	}
+}
+
+func TestExportEcosystems(t *testing.T) {
+	packagesInfo := []g.PackageInfo{
+		{Name: "requests", Ecosystem: "pypi", Versions: map[string]g.VersionInfo{
+			"2.31.0": {Timestamp: "2023-05-22T00:00:00", Dependencies: map[string]string{}},
+		}},
+	}
+	pg := g.NewPackageGraph(&packagesInfo, false)
+
+	var nodes, edges bytes.Buffer
+	if err := ExportCSV(pg, &nodes, &edges); err != nil {
+		t.Fatal(err)
+	}
+	expected := "id,name,version,timestamp,license,ecosystem,deprecated,maintainers\n0,requests,2.31.0,2023-05-22T00:00:00,,pypi,,\n"
+	if nodes.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, nodes.String())
+	}
+
+	var graphML bytes.Buffer
+	if err := ExportGraphML(pg, &graphML); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(graphML.Bytes(), []byte(`<data key="ecosystem">pypi</data>`)) {
+		t.Errorf("Expected the ecosystem in the GraphML, got %s", graphML.String())
+	}
+}
+
+func TestExportEdgeWeights(t *testing.T) {
+	packagesInfo := []g.PackageInfo{
+		{Name: "App", Versions: map[string]g.VersionInfo{
+			"1.0.0": {Timestamp: "2023-01-01T00:00:00", Dependencies: map[string]string{"Lib": "^1.0.0"}, PeerDependencies: map[string]string{"Lib": "1.0.0"}},
+		}},
+		{Name: "Lib", Versions: map[string]g.VersionInfo{
+			"1.0.0": {Timestamp: "2022-01-01T00:00:00", Dependencies: map[string]string{}},
+		}},
+	}
+	pg := g.NewPackageGraph(&packagesInfo, false, g.WithDependencyClasses(g.Runtime, g.Peer))
+
+	var nodes, edges bytes.Buffer
+	if err := ExportCSV(pg, &nodes, &edges); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "from,to,constraint,weight,class\n0,1,^1.0.0,2,runtime\n"; edges.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, edges.String())
+	}
+
+	var graphML bytes.Buffer
+	if err := ExportGraphML(pg, &graphML); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(graphML.Bytes(), []byte(`<data key="weight">2</data>`)) {
+		t.Errorf("Expected the weight in the GraphML, got %s", graphML.String())
+	}
+
+	var dot bytes.Buffer
+	if err := ExportDOT(pg, &dot, "weights"); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(dot.Bytes(), []byte("0 -> 1 [range=\"^1.0.0\", weight=2, penwidth=2];")) {
+		t.Errorf("Expected the weight in the DOT output, got %s", dot.String())
+	}
+}
+
+func TestExportMetricsCSV(t *testing.T) {
+	pg := createExportTestGraph()
+	scores := map[string]map[int64]float64{
+		"pagerank": {0: 0.25, 1: 0.5},
+		"indegree": {0: 0, 1: 2, 2: 1},
+	}
+	var buffer bytes.Buffer
+	if err := ExportMetricsCSV(pg, scores, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	expected := "id,name,version,indegree,pagerank\n0,App,1.0.0,0,0.25\n1,B,1.2.0,2,0.5\n2,\"quoted,\"\"name\"\"\",1.0.0,1,\n"
+	if buffer.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, buffer.String())
+	}
+}
+
+func TestExportNodeTable(t *testing.T) {
+	pg := createExportTestGraph()
+	metrics := map[string]map[int64]float64{
+		"transitive": {0: 0, 1: 1, 2: 2},
+		"core":       {0: 1, 2: 1},
+	}
+	var buffer bytes.Buffer
+	if err := ExportNodeTable(pg, metrics, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	expected := "id,name,version,timestamp,core,transitive\n" +
+		"0,App,1.0.0,2022-04-22T20:15:37,1,0\n" +
+		"1,B,1.2.0,2021-04-22T20:15:37,,1\n" +
+		"2,\"quoted,\"\"name\"\"\",1.0.0,2020-01-01T00:00:00,1,2\n"
+	if buffer.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, buffer.String())
+	}
+}
@@ -0,0 +1,36 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+// ExportFreshnessCSV writes a freshness report with the columns name, version, released, dependencies, outdated,
+// min_days, mean_days and max_days, after a header row. The release time is written in RFC 3339.
+func ExportFreshnessCSV(rows []g.FreshnessRow, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"name", "version", "released", "dependencies", "outdated", "min_days", "mean_days", "max_days"}); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := []string{
+			row.Name,
+			row.Version,
+			row.Released.UTC().Format(time.RFC3339),
+			strconv.Itoa(row.Dependencies),
+			strconv.Itoa(row.Outdated),
+			strconv.FormatFloat(row.MinDays, 'f', -1, 64),
+			strconv.FormatFloat(row.MeanDays, 'f', -1, 64),
+			strconv.FormatFloat(row.MaxDays, 'f', -1, 64),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
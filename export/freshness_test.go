@@ -0,0 +1,23 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+func TestExportFreshnessCSV(t *testing.T) {
+	rows := []g.FreshnessRow{
+		{Name: "App", Version: "2.0.0", Released: time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC), Dependencies: 2, Outdated: 1, MaxDays: 92, MeanDays: 46},
+	}
+	var buffer bytes.Buffer
+	if err := ExportFreshnessCSV(rows, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	expected := "name,version,released,dependencies,outdated,min_days,mean_days,max_days\nApp,2.0.0,2020-07-01T00:00:00Z,2,1,0,46,92\n"
+	if buffer.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, buffer.String())
+	}
+}
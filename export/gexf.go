@@ -0,0 +1,151 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+const gexfHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<gexf xmlns="http://gexf.net/1.3" version="1.3">
+  <graph mode="static" defaultedgetype="directed">
+`
+
+const gexfFooter = `  </graph>
+</gexf>
+`
+
+// gexfTypes are the GEXF types of the attribute types.
+var gexfTypes = map[g.AttributeType]string{
+	g.StringAttribute: "string",
+	g.IntAttribute:    "integer",
+	g.FloatAttribute:  "double",
+	g.BoolAttribute:   "boolean",
+}
+
+// ExportGEXF writes the graph as GEXF 1.3, the format of Gephi, with an attribute declaration for every attribute of
+// the schema, see PackageGraph.Attributes, and the values of every node and edge as attvalues. Missing values and
+// values equal to the default of their attribute are left out. Nodes are identified by their ID and labelled as
+// "name@version", and edges are numbered in the order in which they are written.
+func ExportGEXF(pg *g.PackageGraph, w io.Writer) error {
+	schema := pg.Attributes()
+	nodeAttributes, edgeAttributes := schema.Nodes(), schema.Edges()
+	buffered := bufio.NewWriter(w)
+	if _, err := buffered.WriteString(gexfHeader); err != nil {
+		return err
+	}
+	nodeDeclarations := make([]gexfDeclaration, len(nodeAttributes))
+	for i, attribute := range nodeAttributes {
+		nodeDeclarations[i] = gexfDeclaration{attribute.Name, attribute.Type, attribute.Default}
+	}
+	edgeDeclarations := make([]gexfDeclaration, len(edgeAttributes))
+	for i, attribute := range edgeAttributes {
+		edgeDeclarations[i] = gexfDeclaration{attribute.Name, attribute.Type, attribute.Default}
+	}
+	if err := writeGEXFDeclarations(buffered, "node", nodeDeclarations); err != nil {
+		return err
+	}
+	if err := writeGEXFDeclarations(buffered, "edge", edgeDeclarations); err != nil {
+		return err
+	}
+
+	if _, err := buffered.WriteString("    <nodes>\n"); err != nil {
+		return err
+	}
+	for _, node := range sortedNodes(pg) {
+		var values []string
+		for _, attribute := range nodeAttributes {
+			value, ok := attribute.Value(pg, node)
+			values = appendGEXFValue(values, attribute.Name, attribute.Default, value, ok)
+		}
+		element := fmt.Sprintf("      <node id=\"%d\" label=\"%s\"", node.ID(), xmlEscape(node.Name+"@"+node.Version))
+		if err := writeGEXFElement(buffered, element, "node", values); err != nil {
+			return err
+		}
+	}
+	if _, err := buffered.WriteString("    </nodes>\n    <edges>\n"); err != nil {
+		return err
+	}
+	edgeID := 0
+	err := eachEdge(pg, func(from, to int64) error {
+		var values []string
+		for _, attribute := range edgeAttributes {
+			value, ok := attribute.Value(pg, from, to)
+			values = appendGEXFValue(values, attribute.Name, attribute.Default, value, ok)
+		}
+		element := fmt.Sprintf("      <edge id=\"%d\" source=\"%d\" target=\"%d\"", edgeID, from, to)
+		edgeID++
+		return writeGEXFElement(buffered, element, "edge", values)
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := buffered.WriteString("    </edges>\n"); err != nil {
+		return err
+	}
+	if _, err := buffered.WriteString(gexfFooter); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
+
+// gexfDeclaration is the part of a node or edge attribute that GEXF declares.
+type gexfDeclaration struct {
+	name          string
+	attributeType g.AttributeType
+	defaultValue  interface{}
+}
+
+// writeGEXFDeclarations writes the attributes element of the class, unless it has no attributes.
+func writeGEXFDeclarations(w io.Writer, class string, declarations []gexfDeclaration) error {
+	if len(declarations) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintf(w, "    <attributes class=\"%s\">\n", class); err != nil {
+		return err
+	}
+	for _, declaration := range declarations {
+		var err error
+		if declaration.defaultValue == nil {
+			_, err = fmt.Fprintf(w, "      <attribute id=\"%s\" title=\"%s\" type=\"%s\"/>\n",
+				declaration.name, declaration.name, gexfTypes[declaration.attributeType])
+		} else {
+			_, err = fmt.Fprintf(w, "      <attribute id=\"%s\" title=\"%s\" type=\"%s\">\n        <default>%s</default>\n      </attribute>\n",
+				declaration.name, declaration.name, gexfTypes[declaration.attributeType], xmlEscape(formatAttribute(declaration.defaultValue)))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "    </attributes>\n")
+	return err
+}
+
+// appendGEXFValue appends the attvalue element of the value of the attribute to values, unless it is omitted.
+func appendGEXFValue(values []string, name string, defaultValue, value interface{}, ok bool) []string {
+	if omitAttribute(value, defaultValue, ok) {
+		return values
+	}
+	return append(values, fmt.Sprintf("          <attvalue for=\"%s\" value=\"%s\"/>\n", name, xmlEscape(formatAttribute(value))))
+}
+
+// writeGEXFElement completes the opening tag of a node or edge element and writes it with its attvalues, as an empty
+// element if it has none.
+func writeGEXFElement(w io.Writer, element, tag string, values []string) error {
+	if len(values) == 0 {
+		_, err := io.WriteString(w, element+"/>\n")
+		return err
+	}
+	if _, err := io.WriteString(w, element+">\n        <attvalues>\n"); err != nil {
+		return err
+	}
+	for _, value := range values {
+		if _, err := io.WriteString(w, value); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "        </attvalues>\n      </%s>\n", tag)
+	return err
+}
@@ -0,0 +1,114 @@
+package export
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+const graphMLHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="http://graphml.graphdrawing.org/xmlns http://graphml.graphdrawing.org/xmlns/1.0/graphml.xsd">
+`
+
+const graphMLFooter = `  </graph>
+</graphml>
+`
+
+// graphMLTypes are the GraphML types of the attribute types.
+var graphMLTypes = map[g.AttributeType]string{
+	g.StringAttribute: "string",
+	g.IntAttribute:    "int",
+	g.FloatAttribute:  "double",
+	g.BoolAttribute:   "boolean",
+}
+
+// ExportGraphML writes the graph as GraphML, with a key for every attribute of the schema, see PackageGraph.Attributes,
+// and the values of every node and edge as data attributes. Missing values and values equal to the default of their
+// attribute, such as the weight of 1, are left out. Nodes are identified as "n<ID>".
+func ExportGraphML(pg *g.PackageGraph, w io.Writer) error {
+	schema := pg.Attributes()
+	nodeAttributes, edgeAttributes := schema.Nodes(), schema.Edges()
+	buffered := bufio.NewWriter(w)
+	if _, err := buffered.WriteString(graphMLHeader); err != nil {
+		return err
+	}
+	for _, attribute := range nodeAttributes {
+		if err := writeGraphMLKey(buffered, "node", attribute.Name, attribute.Type, attribute.Default); err != nil {
+			return err
+		}
+	}
+	for _, attribute := range edgeAttributes {
+		if err := writeGraphMLKey(buffered, "edge", attribute.Name, attribute.Type, attribute.Default); err != nil {
+			return err
+		}
+	}
+	if _, err := buffered.WriteString("  <graph id=\"dependencies\" edgedefault=\"directed\">\n"); err != nil {
+		return err
+	}
+
+	for _, node := range sortedNodes(pg) {
+		if _, err := fmt.Fprintf(buffered, "    <node id=\"n%d\">\n", node.ID()); err != nil {
+			return err
+		}
+		for _, attribute := range nodeAttributes {
+			value, ok := attribute.Value(pg, node)
+			if err := writeGraphMLData(buffered, attribute.Name, attribute.Default, value, ok); err != nil {
+				return err
+			}
+		}
+		if _, err := buffered.WriteString("    </node>\n"); err != nil {
+			return err
+		}
+	}
+	err := eachEdge(pg, func(from, to int64) error {
+		if _, err := fmt.Fprintf(buffered, "    <edge source=\"n%d\" target=\"n%d\">\n", from, to); err != nil {
+			return err
+		}
+		for _, attribute := range edgeAttributes {
+			value, ok := attribute.Value(pg, from, to)
+			if err := writeGraphMLData(buffered, attribute.Name, attribute.Default, value, ok); err != nil {
+				return err
+			}
+		}
+		_, err := buffered.WriteString("    </edge>\n")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := buffered.WriteString(graphMLFooter); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
+
+// writeGraphMLKey writes the key declaring an attribute, with its default if it has one.
+func writeGraphMLKey(w io.Writer, domain, name string, attributeType g.AttributeType, defaultValue interface{}) error {
+	if defaultValue == nil {
+		_, err := fmt.Fprintf(w, "  <key id=\"%s\" for=\"%s\" attr.name=\"%s\" attr.type=\"%s\"/>\n", name, domain, name, graphMLTypes[attributeType])
+		return err
+	}
+	_, err := fmt.Fprintf(w, "  <key id=\"%s\" for=\"%s\" attr.name=\"%s\" attr.type=\"%s\">\n    <default>%s</default>\n  </key>\n",
+		name, domain, name, graphMLTypes[attributeType], xmlEscape(formatAttribute(defaultValue)))
+	return err
+}
+
+// writeGraphMLData writes the data element of a value of the attribute, unless it is omitted.
+func writeGraphMLData(w io.Writer, name string, defaultValue, value interface{}, ok bool) error {
+	if omitAttribute(value, defaultValue, ok) {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "      <data key=\"%s\">%s</data>\n", name, xmlEscape(formatAttribute(value)))
+	return err
+}
+
+// xmlEscape escapes s for use as XML character data or attribute value.
+func xmlEscape(s string) string {
+	var escaped strings.Builder
+	_ = xml.EscapeText(&escaped, []byte(s))
+	return escaped.String()
+}
@@ -0,0 +1,37 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+// ExportGrowthCSV writes a growth report with the columns year, first_published, versions, declarations,
+// mean_direct and mean_transitive, after a header row. The year of the row for the unknown year is "unknown".
+func ExportGrowthCSV(rows []g.YearRow, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"year", "first_published", "versions", "declarations", "mean_direct", "mean_transitive"}); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		year := strconv.Itoa(row.Year)
+		if row.Unknown {
+			year = "unknown"
+		}
+		record := []string{
+			year,
+			strconv.Itoa(row.FirstPublished),
+			strconv.Itoa(row.Versions),
+			strconv.Itoa(row.Declarations),
+			strconv.FormatFloat(row.MeanDirect, 'f', -1, 64),
+			strconv.FormatFloat(row.MeanTransitive, 'f', -1, 64),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
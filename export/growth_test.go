@@ -0,0 +1,23 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+func TestExportGrowthCSV(t *testing.T) {
+	rows := []g.YearRow{
+		{Year: 2021, FirstPublished: 2, Versions: 3, Declarations: 4, MeanDirect: 1.5, MeanTransitive: 2.25},
+		{Unknown: true, Versions: 1},
+	}
+	var buffer bytes.Buffer
+	if err := ExportGrowthCSV(rows, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	expected := "year,first_published,versions,declarations,mean_direct,mean_transitive\n2021,2,3,4,1.5,2.25\nunknown,0,1,0,0,0\n"
+	if buffer.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, buffer.String())
+	}
+}
@@ -0,0 +1,204 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sort"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+	"gonum.org/v1/gonum/graph"
+)
+
+type jsonGraphNode struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Timestamp string `json:"timestamp"`
+	Ecosystem string `json:"ecosystem,omitempty"`
+}
+
+type jsonGraphLink struct {
+	Source int64 `json:"source"`
+	Target int64 `json:"target"`
+}
+
+// ExportJSONGraph writes the graph in the node-link structure used by D3:
+//
+//	{"nodes":[{"id":0,"name":"A","version":"1.0.0","timestamp":"..."}],"links":[{"source":1,"target":0}]}
+//
+// Nodes and links are encoded one at a time, so the whole document is never held in memory. If include is not nil,
+// only the nodes for which it returns true and the links between them are written, which keeps the output small
+// enough for a browser. The node IDs are the IDs of the graph, so they are stable across builds of the same input.
+// The nodes only have a name, version, timestamp and ecosystem; use ExportJSON to write the attributes of the schema of
+// a PackageGraph.
+func ExportJSONGraph(dependencyGraph graph.Directed, nodeMap map[int64]g.NodeInfo, w io.Writer, include func(id int64) bool) error {
+	if include == nil {
+		include = func(int64) bool { return true }
+	}
+	ids := make([]int64, 0, len(nodeMap))
+	for id := range nodeMap {
+		if include(id) && dependencyGraph.Node(id) != nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	buffered := bufio.NewWriter(w)
+	if _, err := buffered.WriteString(`{"nodes":[`); err != nil {
+		return err
+	}
+	for i, id := range ids {
+		if i > 0 {
+			if err := buffered.WriteByte(','); err != nil {
+				return err
+			}
+		}
+		node := nodeMap[id]
+		if err := writeJSON(buffered, jsonGraphNode{ID: id, Name: node.Name, Version: node.Version, Timestamp: node.Timestamp, Ecosystem: node.Ecosystem}); err != nil {
+			return err
+		}
+	}
+
+	if _, err := buffered.WriteString(`],"links":[`); err != nil {
+		return err
+	}
+	first := true
+	for _, from := range ids {
+		targets := graph.NodesOf(dependencyGraph.From(from))
+		sort.Slice(targets, func(i, j int) bool { return targets[i].ID() < targets[j].ID() })
+		for _, target := range targets {
+			if _, ok := nodeMap[target.ID()]; !ok || !include(target.ID()) {
+				continue
+			}
+			if !first {
+				if err := buffered.WriteByte(','); err != nil {
+					return err
+				}
+			}
+			first = false
+			if err := writeJSON(buffered, jsonGraphLink{Source: from, Target: target.ID()}); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := buffered.WriteString("]}\n"); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
+
+// ExportJSON writes the graph in the node-link structure of ExportJSONGraph, with the id of every node and the source
+// and target of every link followed by the attributes of the schema, see PackageGraph.Attributes, in schema order:
+//
+//	{"nodes":[{"id":0,"name":"A","version":"1.0.0",...}],"links":[{"source":1,"target":0,"weight":1,...}]}
+//
+// Missing values are left out, but since JSON cannot declare defaults, values equal to them are written. If include
+// is not nil, only the nodes for which it returns true and the links between them are written.
+func ExportJSON(pg *g.PackageGraph, w io.Writer, include func(id int64) bool) error {
+	if include == nil {
+		include = func(int64) bool { return true }
+	}
+	schema := pg.Attributes()
+	nodeAttributes, edgeAttributes := schema.Nodes(), schema.Edges()
+	buffered := bufio.NewWriter(w)
+	if _, err := buffered.WriteString(`{"nodes":[`); err != nil {
+		return err
+	}
+	first := true
+	for _, node := range sortedNodes(pg) {
+		if !include(node.ID()) {
+			continue
+		}
+		object := jsonObject{}
+		object.add("id", node.ID(), true)
+		for _, attribute := range nodeAttributes {
+			value, ok := attribute.Value(pg, node)
+			object.add(attribute.Name, value, ok)
+		}
+		if err := object.write(buffered, first); err != nil {
+			return err
+		}
+		first = false
+	}
+
+	if _, err := buffered.WriteString(`],"links":[`); err != nil {
+		return err
+	}
+	first = true
+	err := eachEdge(pg, func(from, to int64) error {
+		if !include(from) || !include(to) {
+			return nil
+		}
+		object := jsonObject{}
+		object.add("source", from, true)
+		object.add("target", to, true)
+		for _, attribute := range edgeAttributes {
+			value, ok := attribute.Value(pg, from, to)
+			object.add(attribute.Name, value, ok)
+		}
+		err := object.write(buffered, first)
+		first = false
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := buffered.WriteString("]}\n"); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
+
+// jsonObject is a JSON object whose fields keep the order in which they were added, unlike those of a map.
+type jsonObject struct {
+	names  []string
+	values []interface{}
+}
+
+// add adds a field, unless there is no value.
+func (object *jsonObject) add(name string, value interface{}, ok bool) {
+	if ok {
+		object.names = append(object.names, name)
+		object.values = append(object.values, value)
+	}
+}
+
+// write writes the object, preceded by a comma unless it is the first element of its array.
+func (object *jsonObject) write(w *bufio.Writer, first bool) error {
+	if !first {
+		if err := w.WriteByte(','); err != nil {
+			return err
+		}
+	}
+	if err := w.WriteByte('{'); err != nil {
+		return err
+	}
+	for i, name := range object.names {
+		if i > 0 {
+			if err := w.WriteByte(','); err != nil {
+				return err
+			}
+		}
+		if err := writeJSON(w, name); err != nil {
+			return err
+		}
+		if err := w.WriteByte(':'); err != nil {
+			return err
+		}
+		if err := writeJSON(w, object.values[i]); err != nil {
+			return err
+		}
+	}
+	return w.WriteByte('}')
+}
+
+// writeJSON writes the JSON encoding of v without the trailing newline that json.Encoder adds.
+func writeJSON(w io.Writer, v interface{}) error {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(encoded)
+	return err
+}
@@ -0,0 +1,77 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestExportJSONGraph(t *testing.T) {
+	pg := createExportTestGraph()
+
+	t.Run("Writes every node and link", func(t *testing.T) {
+		var buffer bytes.Buffer
+		if err := ExportJSONGraph(pg.Graph, pg.NodeMap(), &buffer, nil); err != nil {
+			t.Fatal(err)
+		}
+		compareWithGolden(t, buffer.Bytes(), "graph.json")
+
+		var decoded struct {
+			Nodes []jsonGraphNode `json:"nodes"`
+			Links []jsonGraphLink `json:"links"`
+		}
+		if err := json.Unmarshal(buffer.Bytes(), &decoded); err != nil {
+			t.Fatalf("Expected valid JSON, got %v", err)
+		}
+		if len(decoded.Nodes) != 3 || len(decoded.Links) != 3 {
+			t.Errorf("Expected 3 nodes and 3 links, got %d and %d", len(decoded.Nodes), len(decoded.Links))
+		}
+	})
+
+	t.Run("Restricts the output to a subgraph", func(t *testing.T) {
+		var buffer bytes.Buffer
+		include := func(id int64) bool { return id != 0 }
+		if err := ExportJSONGraph(pg.Graph, pg.NodeMap(), &buffer, include); err != nil {
+			t.Fatal(err)
+		}
+		var decoded struct {
+			Nodes []jsonGraphNode `json:"nodes"`
+			Links []jsonGraphLink `json:"links"`
+		}
+		if err := json.Unmarshal(buffer.Bytes(), &decoded); err != nil {
+			t.Fatalf("Expected valid JSON, got %v", err)
+		}
+		if len(decoded.Nodes) != 2 || len(decoded.Links) != 1 {
+			t.Errorf("Expected 2 nodes and 1 link, got %d and %d", len(decoded.Nodes), len(decoded.Links))
+		}
+	})
+}
+
+func TestExportJSON(t *testing.T) {
+	pg := createExportTestGraph()
+
+	t.Run("Writes the attributes of the schema", func(t *testing.T) {
+		var buffer bytes.Buffer
+		if err := ExportJSON(pg, &buffer, nil); err != nil {
+			t.Fatal(err)
+		}
+		compareWithGolden(t, buffer.Bytes(), "graph_attributes.json")
+	})
+
+	t.Run("Restricts the output to a subgraph", func(t *testing.T) {
+		var buffer bytes.Buffer
+		if err := ExportJSON(pg, &buffer, func(id int64) bool { return id != 0 }); err != nil {
+			t.Fatal(err)
+		}
+		var decoded struct {
+			Nodes []map[string]interface{} `json:"nodes"`
+			Links []map[string]interface{} `json:"links"`
+		}
+		if err := json.Unmarshal(buffer.Bytes(), &decoded); err != nil {
+			t.Fatalf("Expected valid JSON, got %v", err)
+		}
+		if len(decoded.Nodes) != 2 || len(decoded.Links) != 1 {
+			t.Errorf("Expected 2 nodes and 1 link, got %d and %d", len(decoded.Nodes), len(decoded.Links))
+		}
+	})
+}
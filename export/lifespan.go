@@ -0,0 +1,48 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+// ExportLifespanCSV writes the lifespans computed by DependentLifespan with the columns id, name, version, timestamp,
+// dependents, first_dependent and last_dependent, after a header row, in the order of the node IDs. The times of the
+// dependents are written as RFC 3339 in UTC, and left empty for the versions without a dependent with a parseable
+// timestamp. If include is not nil, such as graph.HasDependents, only the nodes for which it returns true are written.
+func ExportLifespanCSV(pg *g.PackageGraph, lifespans map[int64]g.Lifespan, w io.Writer, include func(id int64) bool) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"id", "name", "version", "timestamp", "dependents", "first_dependent", "last_dependent"}); err != nil {
+		return err
+	}
+	for _, node := range sortedNodes(pg) {
+		lifespan, ok := lifespans[node.ID()]
+		if !ok || include != nil && !include(node.ID()) {
+			continue
+		}
+		record := []string{
+			strconv.FormatInt(node.ID(), 10),
+			node.Name,
+			node.Version,
+			node.Timestamp,
+			strconv.Itoa(lifespan.Count),
+			formatLifespanTime(lifespan.First),
+			formatLifespanTime(lifespan.Last),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatLifespanTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,31 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+func TestExportLifespanCSV(t *testing.T) {
+	pg := createExportTestGraph()
+	lifespans := g.DependentLifespan(pg)
+	var buffer bytes.Buffer
+	if err := ExportLifespanCSV(pg, lifespans, &buffer, g.HasDependents(lifespans)); err != nil {
+		t.Fatal(err)
+	}
+	expected := "id,name,version,timestamp,dependents,first_dependent,last_dependent\n" +
+		"1,B,1.2.0,2021-04-22T20:15:37,1,2022-04-22T20:15:37Z,2022-04-22T20:15:37Z\n" +
+		"2,\"quoted,\"\"name\"\"\",1.0.0,2020-01-01T00:00:00,2,2021-04-22T20:15:37Z,2022-04-22T20:15:37Z\n"
+	if buffer.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, buffer.String())
+	}
+
+	buffer.Reset()
+	if err := ExportLifespanCSV(pg, lifespans, &buffer, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buffer.Bytes(), []byte("\n0,App,1.0.0,2022-04-22T20:15:37,0,,\n")) {
+		t.Errorf("Expected App without dependents, got\n%s", buffer.String())
+	}
+}
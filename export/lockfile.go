@@ -0,0 +1,25 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+// ExportResolution writes the simulated installation of root at the given time, as computed by g.ResolveLockfile, in
+// the layout of an npm package-lock.json with lockfileVersion 3. The install locations and dependency names are
+// sorted, and the fields and the indentation are those of npm, so the output can be diffed against real lockfiles
+// directly.
+func ExportResolution(pg *g.PackageGraph, root g.NameVersion, at time.Time, w io.Writer) error {
+	lockfile, err := g.ResolveLockfile(pg, root, at)
+	if err != nil {
+		return err
+	}
+	// Unlike json.Marshal, npm does not escape characters such as '>' in the ranges.
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(lockfile)
+}
@@ -0,0 +1,22 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+func TestExportResolution(t *testing.T) {
+	pg := createExportTestGraph()
+	var buffer bytes.Buffer
+	if err := ExportResolution(pg, g.NameVersion{Name: "App", Version: "1.0.0"}, time.Time{}, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	compareWithGolden(t, buffer.Bytes(), "lockfile.json")
+
+	if err := ExportResolution(pg, g.NameVersion{Name: "Missing", Version: "1.0.0"}, time.Time{}, &buffer); err == nil {
+		t.Error("Expected an error for an unknown root")
+	}
+}
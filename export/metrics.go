@@ -0,0 +1,59 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+// ExportMetricsCSV writes the scores computed by ComputeMetrics with one row per node of the graph and the columns
+// id, name and version, followed by one column per metric in alphabetical order, after a header row. The cell of a
+// node that a metric did not score is empty.
+func ExportMetricsCSV(pg *g.PackageGraph, scores map[string]map[int64]float64, w io.Writer) error {
+	return writeNodeScores(pg, scores, w, []string{"id", "name", "version"}, func(node g.NodeInfo) []string {
+		return []string{strconv.FormatInt(node.ID(), 10), node.Name, node.Version}
+	})
+}
+
+// ExportNodeTable writes the metrics like ExportMetricsCSV, with the timestamp of every node as well, so that metrics
+// computed separately can be analyzed as one table without joining them by node ID. The columns are id, name,
+// version and timestamp, followed by one column per metric in alphabetical order. The rows are written as they are
+// formatted, in the order of the node IDs, and quoted following RFC 4180.
+func ExportNodeTable(pg *g.PackageGraph, metrics map[string]map[int64]float64, w io.Writer) error {
+	return writeNodeScores(pg, metrics, w, []string{"id", "name", "version", "timestamp"}, func(node g.NodeInfo) []string {
+		return []string{strconv.FormatInt(node.ID(), 10), node.Name, node.Version, node.Timestamp}
+	})
+}
+
+// writeNodeScores writes a header row of the columns followed by the metrics in alphabetical order, and a row per node
+// of the values of the columns followed by its scores.
+func writeNodeScores(pg *g.PackageGraph, scores map[string]map[int64]float64, w io.Writer, columns []string, values func(node g.NodeInfo) []string) error {
+	names := make([]string, 0, len(scores))
+	for name := range scores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(append(columns, names...)); err != nil {
+		return err
+	}
+	for _, node := range sortedNodes(pg) {
+		record := values(node)
+		for _, name := range names {
+			cell := ""
+			if score, ok := scores[name][node.ID()]; ok {
+				cell = strconv.FormatFloat(score, 'f', -1, 64)
+			}
+			record = append(record, cell)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,111 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+// neo4jIDSpace is the ID space used in the headers of the neo4j-admin import files. Node IDs are only unique within
+// an ID space, so the relationships have to refer to the same one.
+const neo4jIDSpace = "Package"
+
+// neo4jRelationshipType is the type given to every dependency relationship.
+const neo4jRelationshipType = "DEPENDS_ON"
+
+// ExportNeo4j writes the graph in the neo4j-admin import format. The nodes are written to nodes and the dependency
+// relationships to relationships, each with its own header row. The files can be imported with
+//
+//	neo4j-admin import --nodes=Package=nodes.csv --relationships=relationships.csv
+//
+// Fields are quoted following RFC 4180, which is what neo4j-admin expects by default.
+func ExportNeo4j(pg *g.PackageGraph, nodes, relationships io.Writer) error {
+	nodeWriter := csv.NewWriter(nodes)
+	if err := nodeWriter.Write([]string{"packageId:ID(" + neo4jIDSpace + ")", "name", "version", "timestamp", "ecosystem", ":LABEL"}); err != nil {
+		return err
+	}
+	for _, node := range sortedNodes(pg) {
+		record := []string{strconv.FormatInt(node.ID(), 10), node.Name, node.Version, node.Timestamp, node.Ecosystem, neo4jIDSpace}
+		if err := nodeWriter.Write(record); err != nil {
+			return err
+		}
+	}
+	nodeWriter.Flush()
+	if err := nodeWriter.Error(); err != nil {
+		return err
+	}
+
+	relationshipWriter := csv.NewWriter(relationships)
+	header := []string{":START_ID(" + neo4jIDSpace + ")", ":END_ID(" + neo4jIDSpace + ")", ":TYPE", "constraint"}
+	if err := relationshipWriter.Write(header); err != nil {
+		return err
+	}
+	err := eachDependency(pg, func(from, to int64) error {
+		constraint, _ := pg.Constraint(from, to)
+		return relationshipWriter.Write([]string{strconv.FormatInt(from, 10), strconv.FormatInt(to, 10), neo4jRelationshipType, constraint})
+	})
+	if err != nil {
+		return err
+	}
+	relationshipWriter.Flush()
+	return relationshipWriter.Error()
+}
+
+// ExportNeo4jFiles writes nodes.csv and relationships.csv for neo4j-admin import into the given directory.
+func ExportNeo4jFiles(pg *g.PackageGraph, dir string) (err error) {
+	nodes, err := os.Create(filepath.Join(dir, "nodes.csv"))
+	if err != nil {
+		return err
+	}
+	defer closeFile(nodes, &err)
+	relationships, err := os.Create(filepath.Join(dir, "relationships.csv"))
+	if err != nil {
+		return err
+	}
+	defer closeFile(relationships, &err)
+	return ExportNeo4j(pg, nodes, relationships)
+}
+
+// ExportCypher writes the graph as a stream of Cypher statements, which is convenient for smaller graphs that do not
+// need neo4j-admin. Nodes are created first, followed by an index on the package ID and the relationships.
+func ExportCypher(pg *g.PackageGraph, w io.Writer) error {
+	for _, node := range sortedNodes(pg) {
+		properties := fmt.Sprintf("packageId: %d, name: %s, version: %s, timestamp: %s",
+			node.ID(), cypherString(node.Name), cypherString(node.Version), cypherString(node.Timestamp))
+		if node.Ecosystem != "" {
+			properties += ", ecosystem: " + cypherString(node.Ecosystem)
+		}
+		_, err := fmt.Fprintf(w, "CREATE (:%s {%s});\n", neo4jIDSpace, properties)
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "CREATE INDEX FOR (p:%s) ON (p.packageId);\n", neo4jIDSpace); err != nil {
+		return err
+	}
+	return eachDependency(pg, func(from, to int64) error {
+		constraint, _ := pg.Constraint(from, to)
+		_, err := fmt.Fprintf(w, "MATCH (a:%s {packageId: %d}), (b:%s {packageId: %d}) CREATE (a)-[:%s {constraint: %s}]->(b);\n",
+			neo4jIDSpace, from, neo4jIDSpace, to, neo4jRelationshipType, cypherString(constraint))
+		return err
+	})
+}
+
+// cypherString quotes s as a Cypher string literal.
+func cypherString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`, "\r", `\r`).Replace(s) + "'"
+}
+
+// closeFile closes the file and stores the error in err if no earlier error happened. It is meant to be deferred in
+// functions that write files, so that errors when flushing to disk are not lost.
+func closeFile(file *os.File, err *error) {
+	if closeErr := file.Close(); closeErr != nil && *err == nil {
+		*err = closeErr
+	}
+}
@@ -0,0 +1,84 @@
+package export
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+// createExportTestGraph builds a small graph in which every package has a single version, so that node IDs follow
+// the order of the packages. One of the names needs quoting in CSV and Cypher.
+func createExportTestGraph(opts ...g.Option) *g.PackageGraph {
+	packagesInfo := []g.PackageInfo{
+		{
+			Name: "App",
+			Versions: map[string]g.VersionInfo{
+				"1.0.0": {
+					Timestamp: "2022-04-22T20:15:37",
+					License:   "MIT",
+					Dependencies: map[string]string{
+						"B":             ">= 1.0.0",
+						`quoted,"name"`: "1.0.0",
+					},
+				},
+			},
+		},
+		{
+			Name: "B",
+			Versions: map[string]g.VersionInfo{
+				"1.2.0": {
+					Timestamp: "2021-04-22T20:15:37",
+					Dependencies: map[string]string{
+						`quoted,"name"`: "< 2.0.0",
+					},
+				},
+			},
+		},
+		{
+			Name: `quoted,"name"`,
+			Versions: map[string]g.VersionInfo{
+				"1.0.0": {
+					Timestamp:    "2020-01-01T00:00:00",
+					Dependencies: map[string]string{},
+				},
+			},
+		},
+	}
+	return g.NewPackageGraph(&packagesInfo, false, opts...)
+}
+
+// compareWithGolden fails the test if actual differs from the contents of the file in testdata.
+func compareWithGolden(t *testing.T, actual []byte, golden string) {
+	t.Helper()
+	expected, err := os.ReadFile(filepath.Join("testdata", golden))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(actual, expected) {
+		t.Errorf("Output differs from %s.\nExpected:\n%s\nActual:\n%s", golden, expected, actual)
+	}
+}
+
+func TestExportNeo4j(t *testing.T) {
+	pg := createExportTestGraph()
+
+	t.Run("Writes the neo4j-admin import files", func(t *testing.T) {
+		var nodes, relationships bytes.Buffer
+		if err := ExportNeo4j(pg, &nodes, &relationships); err != nil {
+			t.Fatal(err)
+		}
+		compareWithGolden(t, nodes.Bytes(), "neo4j/nodes.csv")
+		compareWithGolden(t, relationships.Bytes(), "neo4j/relationships.csv")
+	})
+
+	t.Run("Writes the Cypher statements", func(t *testing.T) {
+		var cypher bytes.Buffer
+		if err := ExportCypher(pg, &cypher); err != nil {
+			t.Fatal(err)
+		}
+		compareWithGolden(t, cypher.Bytes(), "neo4j/graph.cypher")
+	})
+}
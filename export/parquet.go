@@ -0,0 +1,143 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+const (
+	// parquetRowGroupSize is the size of the row groups of ExportParquet in bytes. Readers such as DuckDB scan the row
+	// groups in parallel, so the tables of the full dataset are split into many, while a row group is large enough for
+	// the dictionaries of its columns to pay off.
+	parquetRowGroupSize = 128 << 20
+	// parquetPageSize is the size of the data pages within a row group.
+	parquetPageSize = 1 << 20
+)
+
+// parquetNodeColumns and parquetEdgeColumns describe the columns of the tables of ExportParquet. The strings are
+// dictionary-encoded, since names, licenses and ecosystems repeat across the versions and constraints across the
+// edges.
+var (
+	parquetNodeColumns = []string{
+		"name=id, type=INT64, repetitiontype=REQUIRED",
+		"name=name, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY, repetitiontype=REQUIRED",
+		"name=version, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY, repetitiontype=REQUIRED",
+		"name=timestamp, type=INT64, convertedtype=TIMESTAMP_MILLIS, logicaltype=TIMESTAMP, logicaltype.isadjustedtoutc=true, logicaltype.unit=MILLIS, repetitiontype=OPTIONAL",
+		"name=license, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY, repetitiontype=OPTIONAL",
+		"name=ecosystem, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY, repetitiontype=OPTIONAL",
+	}
+	parquetEdgeColumns = []string{
+		"name=from, type=INT64, repetitiontype=REQUIRED",
+		"name=to, type=INT64, repetitiontype=REQUIRED",
+		"name=constraint, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY, repetitiontype=OPTIONAL",
+		"name=weight, type=INT64, repetitiontype=REQUIRED",
+	}
+)
+
+// parquetColumnName matches the metric names that ExportParquet accepts as column names. The schema of the writer is
+// a list of key=value pairs separated by commas, so other characters could not be written as they are.
+var parquetColumnName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ExportParquet writes the node table of the graph to the Parquet file at nodesPath and its edge table to the one at
+// edgesPath, for tools such as DuckDB and pandas that read Parquet directly with the column types kept. The node table
+// has the columns id, name, version, timestamp, license and ecosystem, followed by one column per metric in
+// alphabetical order, such as the scores of ComputeMetrics, which may be nil. The edge table has the columns from and
+// to, like ExportCSV, followed by constraint and weight.
+//
+// The IDs and weights are INT64, the timestamps TIMESTAMP in milliseconds since the epoch in UTC and the metrics
+// DOUBLE. The strings are dictionary-encoded UTF8. The timestamps that cannot be parsed, the empty licenses,
+// ecosystems and constraints and the nodes that a metric did not score are NULL. The rows are in the order of the
+// other exporters and the pages are compressed with Snappy.
+//
+// A metric name must consist of ASCII letters, digits and underscores, not start with a digit and differ from the
+// columns of the node table and the other metrics, ignoring case as readers such as DuckDB do. Otherwise an error is
+// returned before a file is written.
+func ExportParquet(pg *g.PackageGraph, nodesPath, edgesPath string, metrics map[string]map[int64]float64) (err error) {
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	taken := make(map[string]string, len(parquetNodeColumns)+len(names))
+	for _, column := range parquetNodeColumns {
+		name := strings.TrimPrefix(strings.SplitN(column, ",", 2)[0], "name=")
+		taken[name] = name
+	}
+	columns := append([]string(nil), parquetNodeColumns...)
+	for _, name := range names {
+		if !parquetColumnName.MatchString(name) {
+			return fmt.Errorf("metric name %q is not a valid Parquet column name", name)
+		}
+		if column, ok := taken[strings.ToLower(name)]; ok {
+			return fmt.Errorf("metric name %q clashes with the column %s", name, column)
+		}
+		taken[strings.ToLower(name)] = name
+		columns = append(columns, fmt.Sprintf("name=%s, type=DOUBLE, repetitiontype=OPTIONAL", name))
+	}
+	err = writeParquet(nodesPath, columns, func(write func(row []interface{}) error) error {
+		for _, node := range sortedNodes(pg) {
+			row := []interface{}{node.ID(), node.Name, node.Version, nil, parquetString(node.License), parquetString(node.Ecosystem)}
+			if released, err := g.ParseTimestamp(node.Timestamp); err == nil {
+				row[3] = released.UnixMilli()
+			}
+			for _, name := range names {
+				if score, ok := metrics[name][node.ID()]; ok {
+					row = append(row, score)
+				} else {
+					row = append(row, nil)
+				}
+			}
+			if err := write(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return writeParquet(edgesPath, parquetEdgeColumns, func(write func(row []interface{}) error) error {
+		return eachEdge(pg, func(from, to int64) error {
+			dependent, dependency := pg.DependencyEdge(from, to)
+			constraint, _ := pg.Constraint(dependent, dependency)
+			return write([]interface{}{from, to, parquetString(constraint), int64(pg.EdgeWeight(dependent, dependency))})
+		})
+	})
+}
+
+// writeParquet creates the Parquet file at path with the columns, writes the rows that fill passes to write and
+// writes the footer.
+func writeParquet(path string, columns []string, fill func(write func(row []interface{}) error) error) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer closeFile(file, &err)
+	parquetWriter, err := writer.NewCSVWriterFromWriter(columns, file, 1)
+	if err != nil {
+		return err
+	}
+	parquetWriter.RowGroupSize = parquetRowGroupSize
+	parquetWriter.PageSize = parquetPageSize
+	parquetWriter.CompressionType = parquet.CompressionCodec_SNAPPY
+	if err := fill(func(row []interface{}) error { return parquetWriter.Write(row) }); err != nil {
+		return err
+	}
+	return parquetWriter.WriteStop()
+}
+
+// parquetString returns the value of a string column, which is NULL for the empty string.
+func parquetString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
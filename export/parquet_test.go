@@ -0,0 +1,147 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+// readParquet reads the columns of the Parquet file at path and returns its reader for the footer.
+func readParquet(t *testing.T, path string) (*reader.ParquetReader, [][]interface{}) {
+	t.Helper()
+	file, err := local.NewLocalFileReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+	parquetReader, err := reader.NewParquetColumnReader(file, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(parquetReader.ReadStop)
+	columns := make([][]interface{}, len(parquetReader.Footer.Schema)-1)
+	for i := range columns {
+		values, _, _, err := parquetReader.ReadColumnByIndex(int64(i), parquetReader.GetNumRows())
+		if err != nil {
+			t.Fatal(err)
+		}
+		columns[i] = values
+	}
+	return parquetReader, columns
+}
+
+// checkParquetSchema checks the names, types and converted types of the columns of the file, and that the string
+// columns are dictionary-encoded. The reader renames the columns of the footer after the Go fields it would decode them
+// into, so the names are those of the file, as other readers see them.
+func checkParquetSchema(t *testing.T, parquetReader *reader.ParquetReader, expected []parquet.Type, names ...string) {
+	t.Helper()
+	var actual []string
+	for i, element := range parquetReader.Footer.Schema[1:] {
+		actual = append(actual, parquetReader.SchemaHandler.GetExName(i+1))
+		if element.GetType() != expected[i] {
+			t.Errorf("Expected the column %s of type %v, got %v", element.Name, expected[i], element.GetType())
+		}
+		if element.GetType() == parquet.Type_BYTE_ARRAY && element.GetConvertedType() != parquet.ConvertedType_UTF8 {
+			t.Errorf("Expected the column %s to hold UTF8 strings, got %v", element.Name, element.GetConvertedType())
+		}
+	}
+	if !reflect.DeepEqual(actual, names) {
+		t.Errorf("Expected the columns %v, got %v", names, actual)
+	}
+	for _, rowGroup := range parquetReader.Footer.RowGroups {
+		for _, column := range rowGroup.Columns {
+			if column.MetaData.Type != parquet.Type_BYTE_ARRAY {
+				continue
+			}
+			dictionary := false
+			for _, encoding := range column.MetaData.Encodings {
+				dictionary = dictionary || encoding == parquet.Encoding_PLAIN_DICTIONARY || encoding == parquet.Encoding_RLE_DICTIONARY
+			}
+			if !dictionary {
+				t.Errorf("Expected the column %v to be dictionary-encoded, got %v", column.MetaData.PathInSchema, column.MetaData.Encodings)
+			}
+		}
+	}
+}
+
+func TestExportParquet(t *testing.T) {
+	pg := createExportTestGraph()
+	dir := t.TempDir()
+	nodesPath, edgesPath := filepath.Join(dir, "nodes.parquet"), filepath.Join(dir, "edges.parquet")
+	metrics := map[string]map[int64]float64{"pagerank": {0: 0.25, 1: 0.5}, "indegree": {0: 0, 1: 1, 2: 2}}
+	if err := ExportParquet(pg, nodesPath, edgesPath, metrics); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Writes the node table", func(t *testing.T) {
+		parquetReader, columns := readParquet(t, nodesPath)
+		checkParquetSchema(t, parquetReader,
+			[]parquet.Type{parquet.Type_INT64, parquet.Type_BYTE_ARRAY, parquet.Type_BYTE_ARRAY, parquet.Type_INT64,
+				parquet.Type_BYTE_ARRAY, parquet.Type_BYTE_ARRAY, parquet.Type_DOUBLE, parquet.Type_DOUBLE},
+			"id", "name", "version", "timestamp", "license", "ecosystem", "indegree", "pagerank")
+		if rows := parquetReader.GetNumRows(); rows != 3 {
+			t.Fatalf("Expected 3 rows, got %d", rows)
+		}
+		if timestamp := parquetReader.Footer.Schema[4]; timestamp.GetConvertedType() != parquet.ConvertedType_TIMESTAMP_MILLIS ||
+			timestamp.LogicalType == nil || !timestamp.LogicalType.TIMESTAMP.IsAdjustedToUTC {
+			t.Errorf("Expected the timestamps as UTC TIMESTAMP_MILLIS, got %+v", timestamp)
+		}
+		released := time.Date(2022, 4, 22, 20, 15, 37, 0, time.UTC).UnixMilli()
+		if columns[0][0] != int64(0) || columns[1][0] != "App" || columns[3][0] != released || columns[4][0] != "MIT" {
+			t.Errorf("Expected the row of App, got %v, %v, %v and %v", columns[0][0], columns[1][0], columns[3][0], columns[4][0])
+		}
+		if !reflect.DeepEqual(columns[4], []interface{}{"MIT", nil, nil}) || !reflect.DeepEqual(columns[7], []interface{}{0.25, 0.5, nil}) {
+			t.Errorf("Expected NULL for the missing licenses and scores, got %v and %v", columns[4], columns[7])
+		}
+	})
+
+	t.Run("Writes the edge table", func(t *testing.T) {
+		parquetReader, columns := readParquet(t, edgesPath)
+		checkParquetSchema(t, parquetReader,
+			[]parquet.Type{parquet.Type_INT64, parquet.Type_INT64, parquet.Type_BYTE_ARRAY, parquet.Type_INT64},
+			"from", "to", "constraint", "weight")
+		if rows := parquetReader.GetNumRows(); rows != int64(pg.Graph.Edges().Len()) {
+			t.Fatalf("Expected %d rows, got %d", pg.Graph.Edges().Len(), rows)
+		}
+		expected := [][]interface{}{{int64(0), int64(0), int64(1)}, {int64(1), int64(2), int64(2)}, {">= 1.0.0", "1.0.0", "< 2.0.0"}, {int64(1), int64(1), int64(1)}}
+		if !reflect.DeepEqual(columns, expected) {
+			t.Errorf("Expected the edges %v, got %v", expected, columns)
+		}
+	})
+
+	t.Run("Rejects metric names that are not column names", func(t *testing.T) {
+		for _, name := range []string{"page rank", "a,b", "x=y", "1st", "", "ID", "ecosystem"} {
+			dir := t.TempDir()
+			err := ExportParquet(pg, filepath.Join(dir, "nodes.parquet"), filepath.Join(dir, "edges.parquet"), map[string]map[int64]float64{name: {0: 1}})
+			if err == nil {
+				t.Errorf("Expected the metric name %q to be rejected", name)
+			}
+			if _, statErr := os.Stat(filepath.Join(dir, "nodes.parquet")); !os.IsNotExist(statErr) {
+				t.Errorf("Expected no file for the metric name %q, got %v", name, statErr)
+			}
+		}
+		err := ExportParquet(pg, nodesPath, edgesPath, map[string]map[int64]float64{"PageRank": {}, "pagerank": {}})
+		if err == nil || !strings.Contains(err.Error(), "clashes") {
+			t.Errorf("Expected metric names that differ in case to clash, got %v", err)
+		}
+	})
+
+	t.Run("Writes an empty graph", func(t *testing.T) {
+		empty := g.NewPackageGraph(&[]g.PackageInfo{}, false)
+		if err := ExportParquet(empty, nodesPath, edgesPath, nil); err != nil {
+			t.Fatal(err)
+		}
+		if parquetReader, _ := readParquet(t, nodesPath); parquetReader.GetNumRows() != 0 {
+			t.Errorf("Expected no rows, got %d", parquetReader.GetNumRows())
+		}
+	})
+}
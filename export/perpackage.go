@@ -0,0 +1,177 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+// Format is a graph format that ExportPerPackage can write.
+type Format int
+
+const (
+	// DOT is the format of ExportDOT.
+	DOT Format = iota
+	// JSON is the node-link JSON of ExportJSON.
+	JSON
+	// GraphML is the format of ExportGraphML.
+	GraphML
+	// GEXF is the format of ExportGEXF.
+	GEXF
+)
+
+// String returns the name of the format, which is also the extension of its files.
+func (format Format) String() string {
+	switch format {
+	case JSON:
+		return "json"
+	case GraphML:
+		return "graphml"
+	case GEXF:
+		return "gexf"
+	}
+	return "dot"
+}
+
+// write writes the graph in the format, naming a DOT graph after the package.
+func (format Format) write(pg *g.PackageGraph, w io.Writer, name string) error {
+	switch format {
+	case JSON:
+		return ExportJSON(pg, w, nil)
+	case GraphML:
+		return ExportGraphML(pg, w)
+	case GEXF:
+		return ExportGEXF(pg, w)
+	}
+	return ExportDOT(pg, w, name)
+}
+
+// PackageExportError is returned by ExportPerPackage for the packages whose file could not be written. The files of
+// the other packages were written.
+type PackageExportError struct {
+	// Failed maps the names of the failed packages to their error.
+	Failed map[string]error
+}
+
+func (err *PackageExportError) Error() string {
+	names := make([]string, 0, len(err.Failed))
+	for name := range err.Failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	descriptions := make([]string, len(names))
+	for i, name := range names {
+		descriptions[i] = fmt.Sprintf("%s: %v", name, err.Failed[name])
+	}
+	return fmt.Sprintf("%d packages could not be exported: %s", len(names), strings.Join(descriptions, "; "))
+}
+
+// ExportPerPackage writes a file per package to dir, which is created if needed, with the ego network of the package,
+// see graph.EgoNetwork: its highest version without a prerelease suffix, or its highest version if all have one, with
+// the dependencies and the dependents up to depth edges away, a negative depth following the edges without limit. The
+// file is named after the package with PackageFileName and the format as extension. The packages are exported in
+// parallel, by at most GOMAXPROCS goroutines, and a package listed twice only once. A package that is not part of the
+// graph or whose file cannot be written does not stop the others; they are all reported in a *PackageExportError.
+func ExportPerPackage(pg *g.PackageGraph, names []string, dir string, format Format, depth int) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(names) {
+		workers = len(names)
+	}
+	queue := make(chan string)
+	var mutex sync.Mutex
+	failed := make(map[string]error)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range queue {
+				if err := exportPackage(pg, name, dir, format, depth); err != nil {
+					mutex.Lock()
+					failed[name] = err
+					mutex.Unlock()
+				}
+			}
+		}()
+	}
+	queued := make(map[string]bool, len(names))
+	for _, name := range names {
+		if !queued[name] {
+			queued[name] = true
+			queue <- name
+		}
+	}
+	close(queue)
+	wg.Wait()
+	if len(failed) > 0 {
+		return &PackageExportError{Failed: failed}
+	}
+	return nil
+}
+
+func exportPackage(pg *g.PackageGraph, name, dir string, format Format, depth int) (err error) {
+	version, ok := pg.LatestVersion(name, nil, g.SkipPrereleases)
+	if !ok {
+		version, ok = pg.LatestVersion(name, nil, g.AllVersions)
+	}
+	if !ok {
+		return fmt.Errorf("%s is not part of the graph", name)
+	}
+	ego, err := g.EgoNetwork(pg, g.NameVersion{Name: name, Version: version}, depth, depth)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(filepath.Join(dir, PackageFileName(name)+"."+format.String()))
+	if err != nil {
+		return err
+	}
+	defer closeFile(file, &err)
+	return format.write(ego, file, name)
+}
+
+// PackageFileName returns a file name for the package that is valid on Linux, macOS and Windows and differs between
+// packages, even on file systems that ignore case: the bytes other than lowercase ASCII letters, digits, '.', '_' and
+// '-' are escaped as %XX with uppercase hex digits, so that "@babel/core" becomes "%40babel%2Fcore" and "Babel"
+// becomes "%42abel". A leading '.' is escaped as well, so that no name is hidden or refers to a directory, and so is
+// a trailing one, which Windows drops. The first byte of the names that Windows reserves for devices, such as "con"
+// and "nul", is escaped too, also if an extension follows. As escaping triples the length of a byte, the names of
+// packages above about 80 bytes may exceed the limit of 255 bytes of common file systems.
+func PackageFileName(name string) string {
+	var builder strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		safe := 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '_' || c == '-' || c == '.' && i > 0 && i < len(name)-1
+		if safe && !(i == 0 && reservedFileName(name)) {
+			builder.WriteByte(c)
+		} else {
+			fmt.Fprintf(&builder, "%%%02X", c)
+		}
+	}
+	return builder.String()
+}
+
+// windowsDeviceNames are the file names that Windows reserves, in lowercase.
+var windowsDeviceNames = map[string]bool{
+	"con": true, "prn": true, "aux": true, "nul": true,
+	"com1": true, "com2": true, "com3": true, "com4": true, "com5": true, "com6": true, "com7": true, "com8": true, "com9": true,
+	"lpt1": true, "lpt2": true, "lpt3": true, "lpt4": true, "lpt5": true, "lpt6": true, "lpt7": true, "lpt8": true, "lpt9": true,
+}
+
+// reservedFileName reports whether the part of the name before the first '.' is a device name of Windows. Only
+// lowercase names are checked, as the uppercase letters are escaped anyway.
+func reservedFileName(name string) bool {
+	if dot := strings.IndexByte(name, '.'); dot >= 0 {
+		name = name[:dot]
+	}
+	return windowsDeviceNames[name]
+}
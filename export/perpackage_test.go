@@ -0,0 +1,119 @@
+package export
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+func TestPackageFileName(t *testing.T) {
+	tests := map[string]string{
+		"lodash":            "lodash",
+		"@babel/core":       "%40babel%2Fcore",
+		"org.junit:junit":   "org.junit%3Ajunit",
+		"..":                "%2E%2E",
+		"100%_pure-js.v2":   "100%25_pure-js.v2",
+		"C:\\Windows\\hack": "%43%3A%5C%57indows%5Chack",
+		"Babel":             "%42abel",
+		"nul":               "%6Eul",
+		"con.js":            "%63on.js",
+		"com10":             "com10",
+		"next.":             "next%2E",
+	}
+	for name, expected := range tests {
+		if fileName := PackageFileName(name); fileName != expected {
+			t.Errorf("Expected %q for %q, got %q", expected, name, fileName)
+		}
+	}
+
+	t.Run("Differs for names that only differ in case", func(t *testing.T) {
+		names := []string{"babel", "Babel", "BABEL", "%42abel", "%62abel"}
+		seen := make(map[string]string)
+		for _, name := range names {
+			folded := strings.ToLower(PackageFileName(name))
+			if other, ok := seen[folded]; ok {
+				t.Errorf("Expected %q and %q to differ ignoring case, both got %q", other, name, folded)
+			}
+			seen[folded] = name
+		}
+	})
+}
+
+func TestExportPerPackage(t *testing.T) {
+	packagesInfo := []g.PackageInfo{
+		{Name: "App", Versions: map[string]g.VersionInfo{
+			"1.0.0": {Timestamp: "2022-01-01T00:00:00", Dependencies: map[string]string{"@scope/lib": "^1.0.0"}},
+		}},
+		{Name: "@scope/lib", Versions: map[string]g.VersionInfo{
+			"1.0.0":       {Timestamp: "2021-01-01T00:00:00", Dependencies: map[string]string{"Leaf": "1.0.0"}},
+			"2.0.0-rc.1":  {Timestamp: "2021-06-01T00:00:00"},
+			"1.10.0-beta": {Timestamp: "2021-03-01T00:00:00"},
+		}},
+		{Name: "Leaf", Versions: map[string]g.VersionInfo{"1.0.0": {Timestamp: "2020-01-01T00:00:00"}}},
+	}
+	pg := g.NewPackageGraph(&packagesInfo, false)
+
+	t.Run("Writes the ego network of every package", func(t *testing.T) {
+		for _, format := range []Format{DOT, JSON, GraphML, GEXF} {
+			dir := filepath.Join(t.TempDir(), "packages")
+			if err := ExportPerPackage(pg, []string{"@scope/lib", "Leaf", "Leaf"}, dir, format, 1); err != nil {
+				t.Fatal(err)
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 2 {
+				t.Fatalf("Expected 2 %s files, got %d", format, len(entries))
+			}
+			data, err := os.ReadFile(filepath.Join(dir, "%40scope%2Flib."+format.String()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The ego network of @scope/lib@1.0.0 holds its dependent and its dependency, but not its prereleases.
+			for _, name := range []string{"App", "Leaf"} {
+				if !strings.Contains(string(data), name) {
+					t.Errorf("Expected the %s file to contain %s, got %s", format, name, data)
+				}
+			}
+			if strings.Contains(string(data), "rc.1") {
+				t.Errorf("Expected the %s file to leave out the prerelease, got %s", format, data)
+			}
+		}
+	})
+
+	t.Run("Writes the edges of a graph with an edge file", func(t *testing.T) {
+		packagesCopy := append([]g.PackageInfo(nil), packagesInfo...)
+		external := g.NewPackageGraph(&packagesCopy, false, g.WithExternalEdges(t.TempDir(), 0))
+		dir := t.TempDir()
+		if err := ExportPerPackage(external, []string{"@scope/lib", "Leaf"}, dir, DOT, 1); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "%40scope%2Flib.dot"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if edges := strings.Count(string(data), " -> "); edges != 2 {
+			t.Errorf("Expected the 2 edges of the ego network, got %s", data)
+		}
+	})
+
+	t.Run("Reports every package that failed", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.Mkdir(filepath.Join(dir, PackageFileName("App")+".dot"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		err := ExportPerPackage(pg, []string{"App", "Missing", "Leaf"}, dir, DOT, -1)
+		var exportErr *PackageExportError
+		if !errors.As(err, &exportErr) || len(exportErr.Failed) != 2 || exportErr.Failed["App"] == nil || exportErr.Failed["Missing"] == nil {
+			t.Fatalf("Expected App and Missing to fail, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, PackageFileName("Leaf")+".dot")); err != nil {
+			t.Errorf("Expected Leaf to be exported anyway, got %v", err)
+		}
+	})
+}
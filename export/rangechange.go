@@ -0,0 +1,25 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+// ExportRangeChangesCSV writes range changes with the columns name, from, to, dependency, old_range, new_range and
+// kind, after a header row.
+func ExportRangeChangesCSV(changes []g.RangeChange, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"name", "from", "to", "dependency", "old_range", "new_range", "kind"}); err != nil {
+		return err
+	}
+	for _, change := range changes {
+		record := []string{change.Name, change.From, change.To, change.Dependency, change.OldRange, change.NewRange, change.Kind.String()}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
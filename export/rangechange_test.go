@@ -0,0 +1,20 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+func TestExportRangeChangesCSV(t *testing.T) {
+	changes := []g.RangeChange{{Name: "App", From: "2.3.0", To: "2.3.1", Dependency: "A", OldRange: "^1.0.0", NewRange: "1.4.2", Kind: g.Tightened}}
+	var buffer bytes.Buffer
+	if err := ExportRangeChangesCSV(changes, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	expected := "name,from,to,dependency,old_range,new_range,kind\nApp,2.3.0,2.3.1,A,^1.0.0,1.4.2,tightened\n"
+	if buffer.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, buffer.String())
+	}
+}
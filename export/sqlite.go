@@ -0,0 +1,173 @@
+package export
+
+import (
+	"database/sql"
+	"sort"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+	// Registers the sqlite3 driver for database/sql.
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteDefaultClass is the dependency class stored for an edge whose class cannot be told from the dependencies
+// declared by its version, see EdgeClass.
+const sqliteDefaultClass = "runtime"
+
+const sqliteSchema = `
+CREATE TABLE packages (
+	id        INTEGER PRIMARY KEY,
+	name      TEXT NOT NULL,
+	ecosystem TEXT
+);
+CREATE TABLE versions (
+	id         INTEGER PRIMARY KEY,
+	package_id INTEGER NOT NULL REFERENCES packages(id),
+	version    TEXT NOT NULL,
+	timestamp  TEXT
+);
+CREATE TABLE deps (
+	version_id     INTEGER NOT NULL REFERENCES versions(id),
+	dep_version_id INTEGER NOT NULL REFERENCES versions(id),
+	"constraint"   TEXT,
+	class          TEXT NOT NULL
+);`
+
+// sqliteProvenanceColumns are added to the packages table by ExportSQLiteWithProvenance.
+const sqliteProvenanceColumns = `
+ALTER TABLE packages ADD COLUMN source_file TEXT;
+ALTER TABLE packages ADD COLUMN source_start INTEGER;
+ALTER TABLE packages ADD COLUMN source_end INTEGER;`
+
+// The indexes are created after the inserts, which is considerably faster than maintaining them during the inserts.
+const sqliteIndexes = `
+CREATE UNIQUE INDEX packages_name ON packages(name);
+CREATE INDEX versions_package_id ON versions(package_id);
+CREATE INDEX versions_version ON versions(version);
+CREATE INDEX deps_version_id ON deps(version_id);
+CREATE INDEX deps_dep_version_id ON deps(dep_version_id);`
+
+// ExportSQLite writes the packages, their versions and the dependency edges of the graph to a new SQLite database at
+// path. The IDs of the versions are the node IDs of the graph, and packages are numbered in name order. Everything is
+// inserted with prepared statements inside a single transaction, so that the full dataset does not take hours.
+//
+// For example, the number of dependents of every package can be queried with
+//
+//	SELECT p.name, count(*) FROM deps d
+//	JOIN versions v ON v.id = d.dep_version_id
+//	JOIN packages p ON p.id = v.package_id
+//	GROUP BY p.name;
+func ExportSQLite(path string, pg *g.PackageGraph) error {
+	return exportSQLite(path, pg, false)
+}
+
+// ExportSQLiteWithProvenance is ExportSQLite with the columns source_file, source_start and source_end in the packages
+// table, which hold the provenance of every package, see graph.WithProvenance, and are NULL for the packages without
+// one.
+func ExportSQLiteWithProvenance(path string, pg *g.PackageGraph) error {
+	return exportSQLite(path, pg, true)
+}
+
+func exportSQLite(path string, pg *g.PackageGraph, provenance bool) (err error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := db.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return err
+	}
+	if provenance {
+		if _, err := db.Exec(sqliteProvenanceColumns); err != nil {
+			return err
+		}
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := insertSQLiteRows(tx, pg, provenance); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	_, err = db.Exec(sqliteIndexes)
+	return err
+}
+
+func insertSQLiteRows(tx *sql.Tx, pg *g.PackageGraph, provenance bool) error {
+	keys := make([]g.PackageKey, 0, len(pg.NameToVersions))
+	for key := range pg.NameToVersions {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Name != keys[j].Name {
+			return keys[i].Name < keys[j].Name
+		}
+		return keys[i].Ecosystem < keys[j].Ecosystem
+	})
+	packageIDs := make(map[g.PackageKey]int64, len(keys))
+	provenances := make(map[g.PackageKey]*g.Provenance)
+	for _, packageInfo := range *pg.Packages {
+		if packageInfo.Provenance != nil {
+			provenances[g.NewPackageKey(packageInfo.Ecosystem, packageInfo.Name)] = packageInfo.Provenance
+		}
+	}
+
+	statement := "INSERT INTO packages (id, name, ecosystem) VALUES (?, ?, ?)"
+	if provenance {
+		statement = "INSERT INTO packages (id, name, ecosystem, source_file, source_start, source_end) VALUES (?, ?, ?, ?, ?, ?)"
+	}
+	insertPackage, err := tx.Prepare(statement)
+	if err != nil {
+		return err
+	}
+	defer insertPackage.Close()
+	for i, key := range keys {
+		packageIDs[key] = int64(i)
+		values := []interface{}{i, key.FullName(), key.Ecosystem}
+		if provenance {
+			// Nil values are stored as NULL.
+			var file, start, end interface{}
+			if source := provenances[key]; source != nil {
+				file, start, end = source.File, source.Start, source.End
+			}
+			values = append(values, file, start, end)
+		}
+		if _, err := insertPackage.Exec(values...); err != nil {
+			return err
+		}
+	}
+
+	insertVersion, err := tx.Prepare("INSERT INTO versions (id, package_id, version, timestamp) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer insertVersion.Close()
+	for _, node := range sortedNodes(pg) {
+		if _, err := insertVersion.Exec(node.ID(), packageIDs[g.NewPackageKey(node.Ecosystem, node.Name)], node.Version, node.Timestamp); err != nil {
+			return err
+		}
+	}
+
+	insertDependency, err := tx.Prepare(`INSERT INTO deps (version_id, dep_version_id, "constraint", class) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer insertDependency.Close()
+	return eachDependency(pg, func(from, to int64) error {
+		constraint, _ := pg.Constraint(from, to)
+		class := sqliteDefaultClass
+		if edgeClass, ok := pg.EdgeClass(from, to); ok {
+			class = edgeClass.String()
+		}
+		_, err := insertDependency.Exec(from, to, constraint, class)
+		return err
+	})
+}
@@ -0,0 +1,85 @@
+package export
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+func TestExportSQLite(t *testing.T) {
+	pg := createExportTestGraph()
+	path := filepath.Join(t.TempDir(), "graph.db")
+	if err := ExportSQLite(path, pg); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	t.Run("Writes one row per package, version and edge", func(t *testing.T) {
+		for table, expected := range map[string]int{"packages": 3, "versions": 3, "deps": 3} {
+			var count int
+			if err := db.QueryRow("SELECT count(*) FROM " + table).Scan(&count); err != nil {
+				t.Fatal(err)
+			}
+			if count != expected {
+				t.Errorf("Expected %d rows in %s, got %d", expected, table, count)
+			}
+		}
+	})
+
+	t.Run("Allows joining the edges to the package names", func(t *testing.T) {
+		var name, constraint string
+		err := db.QueryRow(`SELECT p.name, d."constraint" FROM deps d
+			JOIN versions v ON v.id = d.dep_version_id
+			JOIN packages p ON p.id = v.package_id
+			JOIN versions dv ON dv.id = d.version_id
+			JOIN packages dp ON dp.id = dv.package_id
+			WHERE dp.name = 'B'`).Scan(&name, &constraint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != `quoted,"name"` || constraint != "< 2.0.0" {
+			t.Errorf(`Expected B to depend on quoted,"name" with < 2.0.0, got %s with %s`, name, constraint)
+		}
+	})
+}
+
+func TestExportSQLiteWithProvenance(t *testing.T) {
+	pg := createExportTestGraph()
+	for i := range *pg.Packages {
+		if (*pg.Packages)[i].Name == "App" {
+			(*pg.Packages)[i].Provenance = &g.Provenance{File: "packages.json", Start: 2, End: 120}
+		}
+	}
+	path := filepath.Join(t.TempDir(), "graph.db")
+	if err := ExportSQLiteWithProvenance(path, pg); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var file string
+	var start, end int64
+	err = db.QueryRow("SELECT source_file, source_start, source_end FROM packages WHERE name = 'App'").Scan(&file, &start, &end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file != "packages.json" || start != 2 || end != 120 {
+		t.Errorf("Expected the provenance of App, got %s, %d and %d", file, start, end)
+	}
+	var missing int
+	if err := db.QueryRow("SELECT count(*) FROM packages WHERE source_file IS NULL").Scan(&missing); err != nil {
+		t.Fatal(err)
+	}
+	if missing != 2 {
+		t.Errorf("Expected NULL provenance for the 2 other packages, got %d", missing)
+	}
+}
@@ -0,0 +1,7 @@
+CREATE (:Package {packageId: 0, name: 'App', version: '1.0.0', timestamp: '2022-04-22T20:15:37'});
+CREATE (:Package {packageId: 1, name: 'B', version: '1.2.0', timestamp: '2021-04-22T20:15:37'});
+CREATE (:Package {packageId: 2, name: 'quoted,"name"', version: '1.0.0', timestamp: '2020-01-01T00:00:00'});
+CREATE INDEX FOR (p:Package) ON (p.packageId);
+MATCH (a:Package {packageId: 0}), (b:Package {packageId: 1}) CREATE (a)-[:DEPENDS_ON {constraint: '>= 1.0.0'}]->(b);
+MATCH (a:Package {packageId: 0}), (b:Package {packageId: 2}) CREATE (a)-[:DEPENDS_ON {constraint: '1.0.0'}]->(b);
+MATCH (a:Package {packageId: 1}), (b:Package {packageId: 2}) CREATE (a)-[:DEPENDS_ON {constraint: '< 2.0.0'}]->(b);
@@ -0,0 +1,27 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+// ExportTimeSeriesCSV writes a dependents time series with the columns time, dependents and new, after a header row.
+// Times are written as RFC 3339 in UTC.
+func ExportTimeSeriesCSV(points []g.TimePoint, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"time", "dependents", "new"}); err != nil {
+		return err
+	}
+	for _, point := range points {
+		record := []string{point.Time.UTC().Format(time.RFC3339), strconv.Itoa(point.Dependents), strconv.Itoa(point.New)}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,24 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+func TestExportTimeSeriesCSV(t *testing.T) {
+	points := []g.TimePoint{
+		{Time: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Dependents: 1, New: 1},
+		{Time: time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC), Dependents: 3, New: 2},
+	}
+	var buffer bytes.Buffer
+	if err := ExportTimeSeriesCSV(points, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	expected := "time,dependents,new\n2021-01-01T00:00:00Z,1,1\n2021-02-01T00:00:00Z,3,2\n"
+	if buffer.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, buffer.String())
+	}
+}
@@ -0,0 +1,215 @@
+// Package gen generates synthetic package ecosystems, for tests and benchmarks that need inputs of a realistic shape
+// and size without checking in large fixtures. The same spec and seed always generate the same packages.
+package gen
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+// Distribution describes the distribution of a count between Min and Max, both inclusive. A Skew above 1 makes
+// smaller counts more likely, similar to the long tail of real ecosystems, while a Skew of 0 or 1 is uniform.
+type Distribution struct {
+	Min  int
+	Max  int
+	Skew float64
+}
+
+// sample draws a count from the distribution.
+func (d Distribution) sample(random *rand.Rand) int {
+	if d.Max <= d.Min {
+		return d.Min
+	}
+	u := random.Float64()
+	if d.Skew > 0 {
+		u = math.Pow(u, d.Skew)
+	}
+	return d.Min + int(u*float64(d.Max-d.Min+1))
+}
+
+// RangeMix holds the relative weights of the range styles of the generated dependencies. The weights do not have to
+// add up to one; a mix without any weight only generates caret ranges.
+type RangeMix struct {
+	// Caret ranges such as "^1.2.3".
+	Caret float64
+	// Tilde ranges such as "~1.2.3".
+	Tilde float64
+	// Exact ranges such as "1.2.3".
+	Exact float64
+	// Star ranges "*".
+	Star float64
+}
+
+// pick returns the range for the version in one of the styles, chosen by weight.
+func (mix RangeMix) pick(random *rand.Rand, version string) string {
+	total := mix.Caret + mix.Tilde + mix.Exact + mix.Star
+	if total <= 0 {
+		return "^" + version
+	}
+	u := random.Float64() * total
+	switch {
+	case u < mix.Caret:
+		return "^" + version
+	case u < mix.Caret+mix.Tilde:
+		return "~" + version
+	case u < mix.Caret+mix.Tilde+mix.Exact:
+		return version
+	}
+	return "*"
+}
+
+// GenSpec describes the ecosystem that Generate creates.
+type GenSpec struct {
+	// Packages is the number of packages.
+	Packages int
+	// Versions is the distribution of the number of versions per package. Every package has at least one version.
+	Versions Distribution
+	// Dependencies is the distribution of the number of dependencies per version.
+	Dependencies Distribution
+	// Ranges is the mix of range styles of the dependencies.
+	Ranges RangeMix
+	// Start is the timestamp of the first version of every package, and Spread the time over which the versions of a
+	// package are released.
+	Start  time.Time
+	Spread time.Duration
+	// CycleFraction is the fraction of dependencies on a package generated later in the list. The other dependencies
+	// are on earlier packages, so without such dependencies the graph has no cycles.
+	CycleFraction float64
+	// UnresolvableFraction is the fraction of dependencies whose range cannot be resolved: a range above every
+	// version of the package, a range that cannot be parsed or a package that does not exist.
+	UnresolvableFraction float64
+}
+
+// DefaultSpec returns a spec for an ecosystem of the given number of packages, with a long tail of versions and
+// dependencies and mostly caret ranges, loosely modelled on npm.
+func DefaultSpec(packages int) GenSpec {
+	return GenSpec{
+		Packages:             packages,
+		Versions:             Distribution{Min: 1, Max: 60, Skew: 3},
+		Dependencies:         Distribution{Min: 0, Max: 20, Skew: 2},
+		Ranges:               RangeMix{Caret: 0.75, Tilde: 0.1, Exact: 0.12, Star: 0.03},
+		Start:                time.Date(2012, 1, 1, 0, 0, 0, 0, time.UTC),
+		Spread:               10 * 365 * 24 * time.Hour,
+		CycleFraction:        0.001,
+		UnresolvableFraction: 0.01,
+	}
+}
+
+// timestampLayout is the timestamp format of the parsed input data.
+const timestampLayout = "2006-01-02T15:04:05"
+
+// Generate returns the packages of a synthetic ecosystem described by spec. The packages are named "package-0" up
+// to the number of packages, and the same spec and seed always return the same packages.
+func Generate(spec GenSpec, seed int64) []g.PackageInfo {
+	random := rand.New(rand.NewSource(seed))
+	packages := make([]g.PackageInfo, spec.Packages)
+	// The versions of every package are generated first, so that the dependencies can refer to the versions of the
+	// packages later in the list as well.
+	versions := make([][]string, spec.Packages)
+	timestamps := make([][]string, spec.Packages)
+	for i := range packages {
+		versions[i], timestamps[i] = generateVersions(random, spec)
+	}
+
+	for i := range packages {
+		packages[i] = g.PackageInfo{
+			Name:     packageName(i),
+			Versions: make(map[string]g.VersionInfo, len(versions[i])),
+		}
+		for v, version := range versions[i] {
+			count := spec.Dependencies.sample(random)
+			dependencies := make(map[string]string, count)
+			for d := 0; d < count; d++ {
+				name, dependencyRange, ok := generateDependency(random, spec, i, versions)
+				if ok {
+					dependencies[name] = dependencyRange
+				}
+			}
+			packages[i].Versions[version] = g.VersionInfo{Timestamp: timestamps[i][v], Dependencies: dependencies}
+		}
+	}
+	return packages
+}
+
+func packageName(i int) string {
+	return fmt.Sprintf("package-%d", i)
+}
+
+// generateVersions returns increasing versions of a package, mostly patch and minor releases, together with their
+// increasing timestamps.
+func generateVersions(random *rand.Rand, spec GenSpec) ([]string, []string) {
+	count := spec.Versions.sample(random)
+	if count < 1 {
+		count = 1
+	}
+	versions := make([]string, count)
+	timestamps := make([]string, count)
+	major, minor, patch := random.Intn(2), random.Intn(5), 0
+	released := spec.Start
+	step := time.Duration(0)
+	if count > 1 {
+		step = spec.Spread / time.Duration(count)
+	}
+	for v := range versions {
+		if v > 0 {
+			switch r := random.Intn(20); {
+			case r == 0:
+				major, minor, patch = major+1, 0, 0
+			case r < 6:
+				minor, patch = minor+1, 0
+			default:
+				patch++
+			}
+			if step > 0 {
+				released = released.Add(time.Duration(random.Int63n(int64(2*step)) + 1))
+			}
+		}
+		versions[v] = fmt.Sprintf("%d.%d.%d", major, minor, patch)
+		timestamps[v] = released.Format(timestampLayout)
+	}
+	return versions, timestamps
+}
+
+// generateDependency picks a dependency of the package with the given index, preferring the packages near the start
+// of the list so that some packages become popular. It returns false if the package has no other package to depend
+// on.
+func generateDependency(random *rand.Rand, spec GenSpec, i int, versions [][]string) (string, string, bool) {
+	if random.Float64() < spec.UnresolvableFraction {
+		switch random.Intn(3) {
+		case 0:
+			return fmt.Sprintf("missing-%d", random.Intn(spec.Packages+1)), "^1.0.0", true
+		case 1:
+			if i > 0 {
+				return packageName(random.Intn(i)), "not-a-range", true
+			}
+		}
+		if i > 0 {
+			target := random.Intn(i)
+			return packageName(target), "^" + aboveAll(versions[target]), true
+		}
+	}
+
+	var target int
+	if i+1 < spec.Packages && random.Float64() < spec.CycleFraction {
+		target = i + 1 + random.Intn(spec.Packages-i-1)
+	} else if i > 0 {
+		// Squaring the uniform value makes the packages near the start of the list more popular.
+		u := random.Float64()
+		target = int(u * u * float64(i))
+	} else {
+		return "", "", false
+	}
+	targetVersions := versions[target]
+	return packageName(target), spec.Ranges.pick(random, targetVersions[random.Intn(len(targetVersions))]), true
+}
+
+// aboveAll returns a version with a major version above that of every version.
+func aboveAll(versions []string) string {
+	var major int
+	fmt.Sscanf(versions[len(versions)-1], "%d.", &major)
+	return fmt.Sprintf("%d.0.0", major+1)
+}
@@ -0,0 +1,75 @@
+package gen
+
+import (
+	"reflect"
+	"testing"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Run("Generates the same packages for the same seed", func(t *testing.T) {
+		spec := DefaultSpec(200)
+		if !reflect.DeepEqual(Generate(spec, 1), Generate(spec, 1)) {
+			t.Errorf("Expected the same packages for the same seed")
+		}
+		if reflect.DeepEqual(Generate(spec, 1), Generate(spec, 2)) {
+			t.Errorf("Expected different packages for different seeds")
+		}
+	})
+
+	t.Run("Follows the distributions", func(t *testing.T) {
+		spec := DefaultSpec(300)
+		spec.Versions = Distribution{Min: 2, Max: 5}
+		spec.Dependencies = Distribution{Min: 1, Max: 3}
+		for _, packageInfo := range Generate(spec, 1) {
+			if len(packageInfo.Versions) < 2 || len(packageInfo.Versions) > 5 {
+				t.Fatalf("Expected 2 to 5 versions, got %d for %s", len(packageInfo.Versions), packageInfo.Name)
+			}
+			for version, versionInfo := range packageInfo.Versions {
+				if _, err := g.ParseTimestamp(versionInfo.Timestamp); err != nil {
+					t.Fatalf("Expected a valid timestamp for %s@%s, got %q", packageInfo.Name, version, versionInfo.Timestamp)
+				}
+				if len(versionInfo.Dependencies) > 3 {
+					t.Fatalf("Expected at most 3 dependencies, got %d for %s@%s", len(versionInfo.Dependencies), packageInfo.Name, version)
+				}
+			}
+		}
+	})
+
+	t.Run("Has no cycles without a cycle fraction", func(t *testing.T) {
+		spec := DefaultSpec(300)
+		spec.CycleFraction = 0
+		packagesInfo := Generate(spec, 1)
+		pg := g.NewPackageGraph(&packagesInfo, false)
+		if _, err := g.DependencyDepths(pg.Graph, pg.NodeMap()); err != nil {
+			t.Errorf("Expected no cycles, got %v", err)
+		}
+
+		spec.CycleFraction = 0.5
+		packagesInfo = Generate(spec, 1)
+		pg = g.NewPackageGraph(&packagesInfo, false)
+		if _, err := g.DependencyDepths(pg.Graph, pg.NodeMap()); err == nil {
+			t.Errorf("Expected cycles with a cycle fraction of 0.5")
+		}
+	})
+
+	t.Run("Generates unresolvable ranges", func(t *testing.T) {
+		spec := DefaultSpec(300)
+		spec.UnresolvableFraction = 0
+		packagesInfo := Generate(spec, 1)
+		report := &g.ResolutionReport{}
+		g.NewPackageGraph(&packagesInfo, false, g.WithResolutionReport(report))
+		if report.Resolved != report.Declarations {
+			t.Errorf("Expected every declaration to be resolved, got %d of %d", report.Resolved, report.Declarations)
+		}
+
+		spec.UnresolvableFraction = 0.2
+		packagesInfo = Generate(spec, 1)
+		report = &g.ResolutionReport{}
+		g.NewPackageGraph(&packagesInfo, false, g.WithResolutionReport(report))
+		if report.Unsatisfied == 0 || report.UnknownPackage == 0 || report.UnparseableRange == 0 {
+			t.Errorf("Expected every kind of unresolvable declaration, got %+v", report)
+		}
+	})
+}
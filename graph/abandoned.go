@@ -0,0 +1,129 @@
+package graph
+
+import (
+	"sort"
+	"time"
+)
+
+// AbandonedPackage describes a package that has not been released for a long time but that is still depended upon.
+// Dependent counts are counted in package versions, and versions of the package itself are never included.
+type AbandonedPackage struct {
+	Name string
+	// Ecosystem is the ecosystem of the package, or empty if it has none.
+	Ecosystem            string
+	LastRelease          time.Time
+	DirectDependents     int
+	TransitiveDependents int
+}
+
+// FindAbandonedPackages flags the packages whose latest release happened more than maxAge before the most recent
+// timestamp in the dataset, but which still have more than minTransitiveDependents transitive dependents. The result is
+// ranked by transitive dependents, then direct dependents, then name.
+//
+// The candidates are found with a single pass over the packages. Only the candidates are traversed afterwards, using
+// one multi-source reverse BFS per package (all its versions at once) that shares its buffers with the other
+// traversals, so the cost is proportional to the part of the graph that actually depends on stale packages.
+func FindAbandonedPackages(pg *PackageGraph, maxAge time.Duration, minTransitiveDependents int) []AbandonedPackage {
+	lastReleases := make(map[PackageKey]time.Time, len(*pg.Packages))
+	var maxTimestamp time.Time
+	for _, packageInfo := range *pg.Packages {
+		for _, versionInfo := range packageInfo.Versions {
+			releaseTime, err := ParseTimestamp(versionInfo.Timestamp)
+			if err != nil {
+				continue
+			}
+			if releaseTime.After(lastReleases[packageInfo.key()]) {
+				lastReleases[packageInfo.key()] = releaseTime
+			}
+			if releaseTime.After(maxTimestamp) {
+				maxTimestamp = releaseTime
+			}
+		}
+	}
+	threshold := maxTimestamp.Add(-maxAge)
+
+	counter := newDependentCounter(pg)
+	var result []AbandonedPackage
+	for key, lastRelease := range lastReleases {
+		if !lastRelease.Before(threshold) {
+			continue
+		}
+		direct, transitive := counter.count(pg.versionIDs(key))
+		if transitive > minTransitiveDependents {
+			result = append(result, AbandonedPackage{
+				Name:                 key.FullName(),
+				Ecosystem:            key.Ecosystem,
+				LastRelease:          lastRelease,
+				DirectDependents:     direct,
+				TransitiveDependents: transitive,
+			})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TransitiveDependents != result[j].TransitiveDependents {
+			return result[i].TransitiveDependents > result[j].TransitiveDependents
+		}
+		if result[i].DirectDependents != result[j].DirectDependents {
+			return result[i].DirectDependents > result[j].DirectDependents
+		}
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].Ecosystem < result[j].Ecosystem
+	})
+	return result
+}
+
+// dependentCounter counts dependents by walking the graph against the direction of the edges. The visited marks and
+// the queue are reused between calls so that counting many packages does not allocate a new map per traversal.
+type dependentCounter struct {
+	pg      *PackageGraph
+	visited map[int64]int
+	round   int
+	queue   []int64
+}
+
+func newDependentCounter(pg *PackageGraph) *dependentCounter {
+	return &dependentCounter{
+		pg:      pg,
+		visited: make(map[int64]int, len(pg.Nodes)),
+	}
+}
+
+// count returns the number of direct and transitive dependents of the given source nodes, excluding the sources.
+func (c *dependentCounter) count(sources []int64) (int, int) {
+	c.round++
+	c.queue = c.queue[:0]
+	for _, id := range sources {
+		c.visited[id] = c.round
+		c.queue = append(c.queue, id)
+	}
+
+	direct := 0
+	for _, id := range sources {
+		dependents := c.pg.DependencyGraph().To(id)
+		for dependents.Next() {
+			dependentID := dependents.Node().ID()
+			if c.visited[dependentID] != c.round && c.visited[dependentID] != -c.round {
+				c.visited[dependentID] = -c.round
+				direct++
+			}
+		}
+	}
+
+	transitive := 0
+	for head := 0; head < len(c.queue); head++ {
+		id := c.queue[head]
+		dependents := c.pg.DependencyGraph().To(id)
+		for dependents.Next() {
+			dependentID := dependents.Node().ID()
+			if c.visited[dependentID] != c.round {
+				c.visited[dependentID] = c.round
+				c.queue = append(c.queue, dependentID)
+				transitive++
+			}
+		}
+	}
+	return direct, transitive
+}
@@ -0,0 +1,89 @@
+package graph
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFindAbandonedPackages(t *testing.T) {
+	packagesInfo := []PackageInfo{
+		{
+			Name: "App",
+			Versions: map[string]VersionInfo{
+				"1.0.0": {
+					Timestamp: "2022-04-22T20:15:37",
+					Dependencies: map[string]string{
+						"B": "1.0.0",
+					},
+				},
+			},
+		},
+		{
+			Name: "B",
+			Versions: map[string]VersionInfo{
+				"1.0.0": {
+					Timestamp: "2021-04-22T20:15:37",
+					Dependencies: map[string]string{
+						"Old": ">= 1.0.0",
+					},
+				},
+			},
+		},
+		{
+			Name: "C",
+			Versions: map[string]VersionInfo{
+				"1.0.0": {
+					Timestamp: "2021-05-22T20:15:37",
+					Dependencies: map[string]string{
+						"Old": "1.1.0",
+					},
+				},
+			},
+		},
+		{
+			Name: "Old",
+			Versions: map[string]VersionInfo{
+				"1.0.0": {
+					Timestamp:    "2018-01-01T00:00:00",
+					Dependencies: map[string]string{},
+				},
+				"1.1.0": {
+					Timestamp:    "2018-06-01T00:00:00",
+					Dependencies: map[string]string{},
+				},
+			},
+		},
+	}
+	pg := NewPackageGraph(&packagesInfo, false)
+	twoYears := 2 * 365 * 24 * time.Hour
+
+	t.Run("Flags only the package older than the threshold", func(t *testing.T) {
+		result := FindAbandonedPackages(pg, twoYears, 0)
+		if len(result) != 1 {
+			t.Fatalf("Expected 1 abandoned package, got %d", len(result))
+		}
+		if result[0].Name != "Old" {
+			t.Errorf("Expected package Old, got %s", result[0].Name)
+		}
+		expectedRelease, _ := ParseTimestamp("2018-06-01T00:00:00")
+		if !result[0].LastRelease.Equal(expectedRelease) {
+			t.Errorf("Expected last release %v, got %v", expectedRelease, result[0].LastRelease)
+		}
+	})
+
+	t.Run("Counts direct and transitive dependents once", func(t *testing.T) {
+		result := FindAbandonedPackages(pg, twoYears, 0)
+		if result[0].DirectDependents != 2 {
+			t.Errorf("Expected 2 direct dependents, got %d", result[0].DirectDependents)
+		}
+		if result[0].TransitiveDependents != 3 {
+			t.Errorf("Expected 3 transitive dependents, got %d", result[0].TransitiveDependents)
+		}
+	})
+
+	t.Run("Ignores packages with too few transitive dependents", func(t *testing.T) {
+		if result := FindAbandonedPackages(pg, twoYears, 3); len(result) != 0 {
+			t.Errorf("Expected no abandoned packages, got %d", len(result))
+		}
+	})
+}
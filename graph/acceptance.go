@@ -0,0 +1,127 @@
+package graph
+
+import (
+	"sort"
+)
+
+// AcceptanceExamples is the number of example dependents that UpgradeAcceptance keeps per group.
+var AcceptanceExamples = 5
+
+// AcceptanceGroup is a group of the dependents of UpgradeAcceptance.
+type AcceptanceGroup struct {
+	Count int `json:"count"`
+	// Examples holds up to AcceptanceExamples of the dependent versions, by name and then in semver order.
+	Examples []NameVersion `json:"examples"`
+}
+
+// AcceptanceSummary classifies the dependency ranges on a package by the releases after its latest release that they
+// would accept automatically.
+type AcceptanceSummary struct {
+	// Latest is the latest release of the package, and NextPatch, NextMinor and NextMajor the releases after it that
+	// the ranges are matched against, such as 1.4.3, 1.5.0 and 2.0.0 after 1.4.2.
+	Latest    string `json:"latest"`
+	NextPatch string `json:"nextPatch"`
+	NextMinor string `json:"nextMinor"`
+	NextMajor string `json:"nextMajor"`
+	// Dependents is the number of dependent versions, which are split between the four groups below.
+	Dependents int `json:"dependents"`
+	// PatchOnly are the dependents whose range accepts the next patch release but not the next minor, such as "~1.4.0".
+	PatchOnly AcceptanceGroup `json:"patchOnly"`
+	// Minor are the dependents whose range accepts the next minor release and the next patch, such as "^1.2.0".
+	Minor AcceptanceGroup `json:"minor"`
+	// Pinned are the dependents whose range accepts neither, such as "1.4.2", or a range that the latest release has
+	// already left behind, such as "^0.9.0".
+	Pinned AcceptanceGroup `json:"pinned"`
+	// Complex are the dependents whose range cannot be parsed, such as a URL, or that accepts the next minor release but
+	// not the next patch, such as "1.4.2 || 1.5.0".
+	Complex AcceptanceGroup `json:"complex"`
+	// Major are the dependents, of any group, whose range also accepts the next major release, such as "*" or ">= 1.0.0".
+	Major AcceptanceGroup `json:"major"`
+}
+
+// UpgradeAcceptance matches the range of every dependent of the package against the next patch, minor and major
+// releases after its latest release, which shows how much of the ecosystem would pick up each kind of release without
+// changing its range. The latest release is the highest version of LatestVersion with SkipPrereleases. The ranges are
+// parsed by the range matcher of the graph, so the releases are accepted as a package manager would resolve them.
+//
+// The dependents are the declarations of ConstraintsOn in the classes of the graph, using the first class in which a
+// version declares the package, and leaving out the versions of the package itself. The summary is empty if the
+// package is not part of the graph or has no release.
+func UpgradeAcceptance(pg *PackageGraph, name string) AcceptanceSummary {
+	key := pg.packageKey("", name)
+	latest, ok := pg.latestIndex().latest(key, nil, SkipPrereleases)
+	if !ok {
+		return AcceptanceSummary{}
+	}
+	parsed, err := parseVersion(latest, pg.options.TruncateFourPartVersions)
+	if err != nil {
+		return AcceptanceSummary{}
+	}
+	patch, minor, major := parsed.IncPatch(), parsed.IncMinor(), parsed.IncMajor()
+	summary := AcceptanceSummary{Latest: latest, NextPatch: patch.String(), NextMinor: minor.String(), NextMajor: major.String()}
+
+	edgeClasses := make(map[DependencyClass]bool, len(pg.options.DependencyClasses))
+	for _, class := range pg.options.DependencyClasses {
+		edgeClasses[class] = true
+	}
+	matcher := pg.edgeMatcher()
+	ranges := make(map[string]Range)
+	var patchOnlyDependents, minorDependents, pinnedDependents, complexDependents, majorDependents []NameVersion
+	// The declarations of a version are consecutive, so it is counted once by skipping the declarations after its first.
+	last := int64(-1)
+	for _, ref := range pg.constraintsOn(key) {
+		info := pg.node(ref.Dependent)
+		if !edgeClasses[ref.Class] || ref.Dependent == last || info.key() == key {
+			continue
+		}
+		last = ref.Dependent
+		dependent := info.NameVersion()
+		summary.Dependents++
+		parsedRange, ok := ranges[ref.Range]
+		if !ok {
+			// A range that cannot be parsed is kept as nil, so that it is not parsed again.
+			parsedRange, _ = matcher.ParseRange(ref.Range)
+			ranges[ref.Range] = parsedRange
+		}
+		if parsedRange == nil {
+			complexDependents = append(complexDependents, dependent)
+			continue
+		}
+		acceptsPatch, acceptsMinor := parsedRange.Matches(summary.NextPatch), parsedRange.Matches(summary.NextMinor)
+		switch {
+		case acceptsPatch && acceptsMinor:
+			minorDependents = append(minorDependents, dependent)
+		case acceptsPatch:
+			patchOnlyDependents = append(patchOnlyDependents, dependent)
+		case acceptsMinor:
+			complexDependents = append(complexDependents, dependent)
+		default:
+			pinnedDependents = append(pinnedDependents, dependent)
+		}
+		if parsedRange.Matches(summary.NextMajor) {
+			majorDependents = append(majorDependents, dependent)
+		}
+	}
+	summary.PatchOnly = newAcceptanceGroup(patchOnlyDependents)
+	summary.Minor = newAcceptanceGroup(minorDependents)
+	summary.Pinned = newAcceptanceGroup(pinnedDependents)
+	summary.Complex = newAcceptanceGroup(complexDependents)
+	summary.Major = newAcceptanceGroup(majorDependents)
+	return summary
+}
+
+// newAcceptanceGroup counts the dependents and keeps the first AcceptanceExamples of them in order.
+func newAcceptanceGroup(dependents []NameVersion) AcceptanceGroup {
+	sort.Slice(dependents, func(i, j int) bool {
+		if dependents[i].Name != dependents[j].Name {
+			return dependents[i].Name < dependents[j].Name
+		}
+		return versionLess(dependents[i].Version, dependents[j].Version)
+	})
+	group := AcceptanceGroup{Count: len(dependents)}
+	if len(dependents) > AcceptanceExamples {
+		dependents = dependents[:AcceptanceExamples]
+	}
+	group.Examples = dependents
+	return group
+}
@@ -0,0 +1,70 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUpgradeAcceptance(t *testing.T) {
+	dependent := func(dependencyRange string) VersionInfo {
+		return VersionInfo{Timestamp: "2021-01-01T00:00:00", Dependencies: map[string]string{"X": dependencyRange}}
+	}
+	packagesInfo := []PackageInfo{
+		{Name: "X", Versions: map[string]VersionInfo{
+			"1.3.0":      {Timestamp: "2020-01-01T00:00:00"},
+			"1.4.2":      {Timestamp: "2020-02-01T00:00:00"},
+			"2.0.0-rc.1": {Timestamp: "2020-03-01T00:00:00"},
+		}},
+		{Name: "Caret", Versions: map[string]VersionInfo{"1.0.0": dependent("^1.2.0"), "1.1.0": dependent("^1.4.0")}},
+		{Name: "Tilde", Versions: map[string]VersionInfo{"1.0.0": dependent("~1.4.0")}},
+		{Name: "Exact", Versions: map[string]VersionInfo{"1.0.0": dependent("1.4.2")}},
+		{Name: "Behind", Versions: map[string]VersionInfo{"1.0.0": dependent("^0.9.0")}},
+		{Name: "Any", Versions: map[string]VersionInfo{"1.0.0": dependent("*")}},
+		{Name: "Git", Versions: map[string]VersionInfo{"1.0.0": dependent("git://example.com/x.git")}},
+		{Name: "Skip", Versions: map[string]VersionInfo{"1.0.0": dependent("1.4.2 || 1.5.0")}},
+		{Name: "Dev", Versions: map[string]VersionInfo{"1.0.0": {DevDependencies: map[string]string{"X": "1.4.2"}}}},
+	}
+	pg := NewPackageGraph(&packagesInfo, false)
+	summary := UpgradeAcceptance(pg, "X")
+
+	t.Run("Matches the releases after the latest release", func(t *testing.T) {
+		if summary.Latest != "1.4.2" || summary.NextPatch != "1.4.3" || summary.NextMinor != "1.5.0" || summary.NextMajor != "2.0.0" {
+			t.Errorf("Expected 1.4.3, 1.5.0 and 2.0.0 after 1.4.2, got %+v", summary)
+		}
+		if summary.Dependents != 8 {
+			t.Errorf("Expected 8 dependents outside the development dependencies, got %d", summary.Dependents)
+		}
+	})
+
+	t.Run("Classifies the ranges", func(t *testing.T) {
+		groups := map[string]struct {
+			group    AcceptanceGroup
+			expected []NameVersion
+		}{
+			"patch only": {summary.PatchOnly, []NameVersion{{Name: "Tilde", Version: "1.0.0"}}},
+			"minor":      {summary.Minor, []NameVersion{{Name: "Any", Version: "1.0.0"}, {Name: "Caret", Version: "1.0.0"}, {Name: "Caret", Version: "1.1.0"}}},
+			"pinned":     {summary.Pinned, []NameVersion{{Name: "Behind", Version: "1.0.0"}, {Name: "Exact", Version: "1.0.0"}}},
+			"complex":    {summary.Complex, []NameVersion{{Name: "Git", Version: "1.0.0"}, {Name: "Skip", Version: "1.0.0"}}},
+			"major":      {summary.Major, []NameVersion{{Name: "Any", Version: "1.0.0"}}},
+		}
+		for name, group := range groups {
+			if group.group.Count != len(group.expected) || !reflect.DeepEqual(group.group.Examples, group.expected) {
+				t.Errorf("Expected the %s dependents %v, got %+v", name, group.expected, group.group)
+			}
+		}
+	})
+
+	t.Run("Keeps a limited number of examples", func(t *testing.T) {
+		defer func(examples int) { AcceptanceExamples = examples }(AcceptanceExamples)
+		AcceptanceExamples = 1
+		if minor := UpgradeAcceptance(pg, "X").Minor; minor.Count != 3 || !reflect.DeepEqual(minor.Examples, []NameVersion{{Name: "Any", Version: "1.0.0"}}) {
+			t.Errorf("Expected 3 dependents with one example, got %+v", minor)
+		}
+	})
+
+	t.Run("Returns an empty summary for an unknown package", func(t *testing.T) {
+		if summary := UpgradeAcceptance(pg, "Unknown"); !reflect.DeepEqual(summary, AcceptanceSummary{}) {
+			t.Errorf("Expected an empty summary, got %+v", summary)
+		}
+	})
+}
@@ -0,0 +1,155 @@
+package graph
+
+import (
+	"time"
+)
+
+// AdoptionDays are the numbers of days after a release at which ReleaseAdoption measures its adoption.
+var AdoptionDays = []int{30, 90, 180}
+
+// ReleaseKind classifies a release by the semver component in which it differs from the previous release.
+type ReleaseKind int
+
+const (
+	// MajorRelease increments the major version, such as 2.0.0 after 1.4.2.
+	MajorRelease ReleaseKind = iota
+	// MinorRelease increments the minor version, such as 1.5.0 after 1.4.2.
+	MinorRelease
+	// PatchRelease only increments the patch version, such as 1.4.3 after 1.4.2.
+	PatchRelease
+)
+
+func (kind ReleaseKind) String() string {
+	switch kind {
+	case MajorRelease:
+		return "major"
+	case MinorRelease:
+		return "minor"
+	}
+	return "patch"
+}
+
+// AdoptionRow is the adoption of a release of a package by its dependents.
+type AdoptionRow struct {
+	Version string
+	// Previous is the release before Version in semver order, and Kind how Version differs from it.
+	Previous string
+	Kind     ReleaseKind
+	Released time.Time
+	// Dependents holds, for every number of days in AdoptionDays, the number of dependent versions that were released
+	// by then and whose range resolved at that time, and Shares the share of them that resolved to Version or a later
+	// release. A share is 0 if there are no dependents.
+	Dependents []int
+	Shares     []float64
+}
+
+// AdoptionSkipped counts the releases that ReleaseAdoption leaves out.
+type AdoptionSkipped struct {
+	// NoPrevious is the number of releases without a previous release, which is the first release of a package.
+	NoPrevious int
+	// NoTimestamp is the number of releases whose timestamp cannot be parsed.
+	NoTimestamp int
+}
+
+// ReleaseAdoption classifies every release of the package as a major, minor or patch release relative to the previous
+// release and measures how quickly the dependents move to it, so that the adoption of the release types can be
+// compared. The releases are the versions that parse as semver and are not prereleases, in semver order. At every
+// number of days in AdoptionDays after a release, the range of every dependent version released by then is resolved
+// with ResolveHighest among the versions released by then, as a package manager installing at that time would.
+//
+// The dependents are the declarations of ConstraintsOn in the classes of the graph, using the first class in which a
+// version declares the package; the dependents whose timestamp cannot be parsed are left out. The rows are in semver
+// order, and the releases that are left out are counted separately. The result is empty if the package is not part of
+// the graph.
+func ReleaseAdoption(pg *PackageGraph, name string) (rows []AdoptionRow, skipped AdoptionSkipped) {
+	key := pg.packageKey("", name)
+	resolver := pg.queryResolver()
+	var releases []*indexedVersion
+	byID := make(map[int64]*indexedVersion)
+	for i := range resolver.versions[key] {
+		version := &resolver.versions[key][i]
+		byID[version.id] = version
+		if version.parsed != nil && version.parsed.Prerelease() == "" {
+			releases = append(releases, version)
+		}
+	}
+
+	type dependent struct {
+		released        time.Time
+		dependencyRange string
+	}
+	edgeClasses := make(map[DependencyClass]bool, len(pg.options.DependencyClasses))
+	for _, class := range pg.options.DependencyClasses {
+		edgeClasses[class] = true
+	}
+	var dependents []dependent
+	// The declarations of a version are consecutive, so it is counted once by skipping the declarations after its first.
+	last := int64(-1)
+	for _, ref := range pg.constraintsOn(key) {
+		info := pg.node(ref.Dependent)
+		if !edgeClasses[ref.Class] || ref.Dependent == last || info.key() == key {
+			continue
+		}
+		last = ref.Dependent
+		released, err := ParseTimestamp(info.Timestamp)
+		if err != nil {
+			continue
+		}
+		dependents = append(dependents, dependent{released: released, dependencyRange: ref.Range})
+	}
+
+	for i, release := range releases {
+		if i == 0 {
+			skipped.NoPrevious++
+			continue
+		}
+		info, _ := resolver.find(key.version(release.version))
+		released, err := ParseTimestamp(info.Timestamp)
+		if err != nil {
+			skipped.NoTimestamp++
+			continue
+		}
+		previous := releases[i-1]
+		row := AdoptionRow{
+			Version:    release.version,
+			Previous:   previous.version,
+			Kind:       releaseKind(previous, release),
+			Released:   released,
+			Dependents: make([]int, len(AdoptionDays)),
+			Shares:     make([]float64, len(AdoptionDays)),
+		}
+		for j, days := range AdoptionDays {
+			at := released.Add(time.Duration(days) * 24 * time.Hour)
+			adopted := 0
+			for _, dependent := range dependents {
+				if dependent.released.After(at) {
+					continue
+				}
+				id, outcome := resolver.highestAt(key, at, dependent.dependencyRange)
+				if outcome != resolved {
+					continue
+				}
+				row.Dependents[j]++
+				if compareIndexed(byID[id], release) >= 0 {
+					adopted++
+				}
+			}
+			if row.Dependents[j] > 0 {
+				row.Shares[j] = float64(adopted) / float64(row.Dependents[j])
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, skipped
+}
+
+// releaseKind classifies the release relative to the previous release.
+func releaseKind(previous, release *indexedVersion) ReleaseKind {
+	switch {
+	case release.parsed.Major() != previous.parsed.Major():
+		return MajorRelease
+	case release.parsed.Minor() != previous.parsed.Minor():
+		return MinorRelease
+	}
+	return PatchRelease
+}
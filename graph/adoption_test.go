@@ -0,0 +1,61 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReleaseAdoption(t *testing.T) {
+	createPackages := func() []PackageInfo {
+		dependent := func(timestamp, dependencyRange string) VersionInfo {
+			return VersionInfo{Timestamp: timestamp, Dependencies: map[string]string{"X": dependencyRange}}
+		}
+		return []PackageInfo{
+			{Name: "X", Versions: map[string]VersionInfo{
+				"1.0.0":      {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{}},
+				"1.0.1":      {Timestamp: "2020-03-01T00:00:00", Dependencies: map[string]string{}},
+				"1.1.0":      {Timestamp: "not a timestamp", Dependencies: map[string]string{}},
+				"2.0.0-rc.1": {Timestamp: "2020-05-01T00:00:00", Dependencies: map[string]string{}},
+				"2.0.0":      {Timestamp: "2020-06-01T00:00:00", Dependencies: map[string]string{}},
+			}},
+			{Name: "Caret", Versions: map[string]VersionInfo{
+				"1.0.0": dependent("2020-01-15T00:00:00", "^1.0.0"),
+				"2.0.0": dependent("2020-08-01T00:00:00", "^2.0.0"),
+			}},
+			{Name: "Pinned", Versions: map[string]VersionInfo{"1.0.0": dependent("2020-02-01T00:00:00", "1.0.0")}},
+			{Name: "Any", Versions: map[string]VersionInfo{"1.0.0": dependent("2020-02-01T00:00:00", "*")}},
+		}
+	}
+	packagesInfo := createPackages()
+	pg := NewPackageGraph(&packagesInfo, false)
+	rows, skipped := ReleaseAdoption(pg, "X")
+
+	t.Run("Skips the first release and the releases without a timestamp", func(t *testing.T) {
+		if skipped != (AdoptionSkipped{NoPrevious: 1, NoTimestamp: 1}) {
+			t.Errorf("Expected one release without a previous release and one without a timestamp, got %+v", skipped)
+		}
+		if len(rows) != 2 || rows[0].Version != "1.0.1" || rows[1].Version != "2.0.0" {
+			t.Fatalf("Expected the rows of 1.0.1 and 2.0.0, got %+v", rows)
+		}
+	})
+
+	t.Run("Classifies the releases", func(t *testing.T) {
+		if rows[0].Kind != PatchRelease || rows[1].Kind != MajorRelease || rows[1].Previous != "1.1.0" {
+			t.Errorf("Expected a patch release and a major release after 1.1.0, got %+v", rows)
+		}
+	})
+
+	t.Run("Measures the share of dependents resolved to the release", func(t *testing.T) {
+		// Caret and Any move to 1.0.1, but Pinned stays on 1.0.0. Caret 2.0.0 is released within 180 days.
+		if expected := []int{3, 3, 4}; !reflect.DeepEqual(rows[0].Dependents, expected) {
+			t.Errorf("Expected %v dependents, got %v", expected, rows[0].Dependents)
+		}
+		if share := rows[0].Shares[0]; share != 2.0/3 {
+			t.Errorf("Expected 2 of 3 dependents to adopt 1.0.1, got %v", share)
+		}
+		// Only Any accepts 2.0.0 until Caret 2.0.0 is released 61 days later.
+		if expected := []float64{1.0 / 3, 2.0 / 4, 2.0 / 4}; !reflect.DeepEqual(rows[1].Shares, expected) {
+			t.Errorf("Expected the shares %v, got %v", expected, rows[1].Shares)
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package graph
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// Advisory is a vulnerability reported against a package version, with a severity such as a CVSS base score.
+type Advisory struct {
+	NameVersion NameVersion
+	Severity    float64
+}
+
+// PrioritizedAdvisory is an advisory with the number of packages that depend on it and its resulting score.
+type PrioritizedAdvisory struct {
+	Advisory
+	// Dependents is the number of distinct packages whose latest version transitively depends on the vulnerable
+	// version, not counting the vulnerable package itself.
+	Dependents int
+	// Score is the severity times Dependents.
+	Score float64
+}
+
+// PrioritizeAdvisories scores every advisory by its severity times the number of distinct packages that are affected
+// by it, counting a package only if its latest version transitively depends on the vulnerable version, and returns
+// them with the highest score first, ties by severity, then name and version. The latest version is chosen like in
+// TopPackageDependencyCounts. Advisories on versions that are not part of the graph have no dependents and score 0.
+//
+// Like TransitiveDependencyCounts, the graph is condensed once and the advisories are traversed in batches with one bit
+// per vulnerable version, here against the direction of the edges, so the cost does not grow with one BFS per
+// advisory. The edges of the graph are used as they are.
+func PrioritizeAdvisories(pg *PackageGraph, advisories []Advisory) []PrioritizedAdvisory {
+	c := condense(pg.DependencyGraph())
+
+	// latestMembers counts, per component, the members that are the latest version of their package.
+	latestMembers := make([]int, len(c.members))
+	for key := range pg.NameToVersions {
+		if id, ok := pg.latestVersion(key); ok {
+			latestMembers[c.componentOf[id]]++
+		}
+	}
+
+	sourceIndex := make(map[int64]int)
+	var sources []NodeInfo
+	for _, advisory := range advisories {
+		node, ok := pg.FindNode(advisory.NameVersion)
+		if _, seen := sourceIndex[node.id]; !ok || seen {
+			continue
+		}
+		sourceIndex[node.id] = len(sources)
+		sources = append(sources, node)
+	}
+
+	dependents := make([]int, len(sources))
+	for start := 0; start < len(sources); start += rootBatchSize {
+		end := start + rootBatchSize
+		if end > len(sources) {
+			end = len(sources)
+		}
+		batch := sources[start:end]
+		words := (len(batch) + 63) / 64
+		reached := make([]uint64, len(c.members)*words)
+		for i, source := range batch {
+			component := c.componentOf[source.id]
+			reached[component*words+i/64] |= 1 << (uint(i) % 64)
+		}
+
+		// The dependents of a component have lower indices, so walking the components backwards visits every
+		// component after all its dependencies.
+		for component := len(c.members) - 1; component >= 0; component-- {
+			own := reached[component*words : (component+1)*words]
+			empty := true
+			for _, word := range own {
+				if word != 0 {
+					empty = false
+					break
+				}
+			}
+			if empty {
+				continue
+			}
+			for _, predecessor := range c.predecessors[component] {
+				predecessorBits := reached[predecessor*words : (predecessor+1)*words]
+				for w := range own {
+					predecessorBits[w] |= own[w]
+				}
+			}
+			if latestMembers[component] == 0 {
+				continue
+			}
+			for w, word := range own {
+				for word != 0 {
+					bit := bits.TrailingZeros64(word)
+					dependents[start+w*64+bit] += latestMembers[component]
+					word &= word - 1
+				}
+			}
+		}
+
+		// The latest version of the vulnerable package is not a dependent, but it was counted if it was reached.
+		for i, source := range batch {
+			latest, _ := pg.latestVersion(source.key())
+			component := c.componentOf[latest]
+			if reached[component*words+i/64]&(1<<(uint(i)%64)) != 0 {
+				dependents[start+i]--
+			}
+		}
+	}
+
+	result := make([]PrioritizedAdvisory, len(advisories))
+	for i, advisory := range advisories {
+		result[i].Advisory = advisory
+		if node, ok := pg.FindNode(advisory.NameVersion); ok {
+			result[i].Dependents = dependents[sourceIndex[node.id]]
+			result[i].Score = advisory.Severity * float64(result[i].Dependents)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		if result[i].Severity != result[j].Severity {
+			return result[i].Severity > result[j].Severity
+		}
+		if result[i].NameVersion.Name != result[j].NameVersion.Name {
+			return result[i].NameVersion.Name < result[j].NameVersion.Name
+		}
+		return compareVersions(result[i].NameVersion.Version, result[j].NameVersion.Version) < 0
+	})
+	return result
+}
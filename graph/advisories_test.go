@@ -0,0 +1,70 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPrioritizeAdvisories(t *testing.T) {
+	version := func(dependencies map[string]string) VersionInfo {
+		return VersionInfo{Timestamp: "2022-01-01T00:00:00", Dependencies: dependencies}
+	}
+	// App and Cli depend on Web, which depends on Log. Only the old version of Cli depends on Log directly, and Lib
+	// 1.0.0 is only depended upon by an old version of Lib itself.
+	packagesInfo := []PackageInfo{
+		{Name: "App", Versions: map[string]VersionInfo{"1.0.0": version(map[string]string{"Web": "^1.0.0"})}},
+		{Name: "Cli", Versions: map[string]VersionInfo{
+			"1.0.0": version(map[string]string{"Log": "1.0.0", "Lib": "1.0.0"}),
+			"2.0.0": version(map[string]string{"Web": "^1.0.0"}),
+		}},
+		{Name: "Web", Versions: map[string]VersionInfo{"1.0.0": version(map[string]string{"Log": "^1.0.0"})}},
+		{Name: "Log", Versions: map[string]VersionInfo{
+			"1.0.0": version(map[string]string{}),
+			"1.1.0": version(map[string]string{"Log": "1.0.0"}),
+		}},
+		{Name: "Lib", Versions: map[string]VersionInfo{
+			"1.0.0": version(map[string]string{}),
+			"0.9.0": version(map[string]string{"Lib": "1.0.0"}),
+		}},
+	}
+	pg := NewPackageGraph(&packagesInfo, false, WithResolution(ResolveAll))
+	advisories := []Advisory{
+		{NameVersion{Name: "Log", Version: "1.0.0"}, 5},
+		{NameVersion{Name: "Web", Version: "1.0.0"}, 9},
+		{NameVersion{Name: "Lib", Version: "1.0.0"}, 10},
+		{NameVersion{Name: "Log", Version: "1.0.0"}, 7},
+		{NameVersion{Name: "Missing", Version: "1.0.0"}, 8},
+	}
+	prioritized := PrioritizeAdvisories(pg, advisories)
+
+	t.Run("Scores by severity times the dependent packages", func(t *testing.T) {
+		// Log 1.0.0 is reached from the latest versions of App, Cli and Web, and from Log 1.1.0, which is not counted.
+		expected := []PrioritizedAdvisory{
+			{Advisory: advisories[3], Dependents: 3, Score: 21},
+			{Advisory: advisories[1], Dependents: 2, Score: 18},
+			{Advisory: advisories[0], Dependents: 3, Score: 15},
+			{Advisory: advisories[2], Dependents: 0, Score: 0},
+			{Advisory: advisories[4], Dependents: 0, Score: 0},
+		}
+		if !reflect.DeepEqual(prioritized, expected) {
+			t.Errorf("Expected %v, got %v", expected, prioritized)
+		}
+	})
+
+	t.Run("Matches a BFS from every advisory", func(t *testing.T) {
+		for _, advisory := range prioritized[:3] {
+			node, _ := pg.FindNode(advisory.NameVersion)
+			names := make(map[string]bool)
+			Traverse(pg.Graph, []int64{node.id}, Backward, func(id int64, depth int) TraverseSignal {
+				name := pg.Nodes[id].Name
+				if latest, _ := pg.latestVersion(pg.Nodes[id].key()); latest == id && name != advisory.NameVersion.Name {
+					names[name] = true
+				}
+				return Continue
+			})
+			if len(names) != advisory.Dependents {
+				t.Errorf("Expected %d dependents of %v, got %d", len(names), advisory.NameVersion, advisory.Dependents)
+			}
+		}
+	})
+}
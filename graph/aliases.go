@@ -0,0 +1,276 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Alias redirects the dependencies on a package that was renamed or relocated, such as an npm package deprecated in
+// favor of a new name or a Maven relocation, to the package it became.
+type Alias struct {
+	// From is the old name and To the new one.
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Since, if not zero, is when the package was renamed. Only the versions released at or after it resolve their
+	// dependencies on the old name against the new one; earlier versions, and the versions without a parseable
+	// timestamp, keep the old name.
+	Since time.Time `json:"since,omitempty"`
+}
+
+// AliasTable holds the aliases of WithAliases, at most one per old name. It does not change after NewAliasTable, so
+// it can be shared between graphs.
+type AliasTable struct {
+	aliases map[string]Alias
+	// sources maps every name to the old names whose chain of aliases passes through it, sorted.
+	sources map[string][]string
+}
+
+// NewAliasTable checks the aliases and returns their table. An alias whose new name is itself aliased is followed to
+// the end of the chain, so the aliases must not form a cycle; an alias without a name, from a name to itself or from
+// a name that already has an alias is also rejected.
+func NewAliasTable(aliases []Alias) (*AliasTable, error) {
+	table := &AliasTable{aliases: make(map[string]Alias, len(aliases)), sources: make(map[string][]string)}
+	for _, alias := range aliases {
+		if alias.From == "" || alias.To == "" {
+			return nil, fmt.Errorf("alias %q -> %q without a name", alias.From, alias.To)
+		}
+		if alias.From == alias.To {
+			return nil, fmt.Errorf("alias of %s to itself", alias.From)
+		}
+		if existing, ok := table.aliases[alias.From]; ok {
+			return nil, fmt.Errorf("%s is aliased to both %s and %s", alias.From, existing.To, alias.To)
+		}
+		table.aliases[alias.From] = alias
+	}
+
+	// Every chain is followed once, marking its names as done, so that a chain that joins a checked one stops there.
+	done := make(map[string]bool, len(table.aliases))
+	for _, name := range table.Names() {
+		var chain []string
+		onChain := make(map[string]bool)
+		for current := name; !done[current]; {
+			if onChain[current] {
+				cycle := append(chain[indexOf(chain, current):], current)
+				return nil, fmt.Errorf("aliases form a cycle: %s", strings.Join(cycle, " -> "))
+			}
+			onChain[current] = true
+			chain = append(chain, current)
+			alias, ok := table.aliases[current]
+			if !ok {
+				break
+			}
+			current = alias.To
+		}
+		for _, current := range chain {
+			done[current] = true
+		}
+	}
+	for _, name := range table.Names() {
+		for alias, ok := table.aliases[name]; ok; alias, ok = table.aliases[alias.To] {
+			table.sources[alias.To] = append(table.sources[alias.To], name)
+		}
+	}
+	for _, sources := range table.sources {
+		sort.Strings(sources)
+	}
+	return table, nil
+}
+
+// ReadAliasTable reads the aliases from a JSON file holding an array of aliases, such as
+// [{"from": "request", "to": "postman-request", "since": "2020-02-11T00:00:00Z"}], and returns their table.
+func ReadAliasTable(path string) (*AliasTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var aliases []Alias
+	if err := json.Unmarshal(data, &aliases); err != nil {
+		return nil, fmt.Errorf("reading the aliases of %s: %w", path, err)
+	}
+	return NewAliasTable(aliases)
+}
+
+func indexOf(names []string, name string) int {
+	for i, other := range names {
+		if other == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// WithAliases makes the dependencies on the old names of the table resolve against the versions of the new names,
+// following chains of aliases. The names are matched against the dependency names after WithNameNormalization, so
+// they must be given normalized. The edges that were created through an alias are reported by EdgeAlias and counted
+// by AliasReport. A dependency on an old name is redirected even if the old name still has versions in the graph.
+func WithAliases(table *AliasTable) Option {
+	return func(options *Options) {
+		options.Aliases = table
+	}
+}
+
+// Names returns the old names of the table, sorted.
+func (table *AliasTable) Names() []string {
+	names := make([]string, 0, len(table.aliases))
+	for name := range table.aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Aliases returns the aliases of the table, sorted by their old name.
+func (table *AliasTable) Aliases() []Alias {
+	aliases := make([]Alias, 0, len(table.aliases))
+	for _, name := range table.Names() {
+		aliases = append(aliases, table.aliases[name])
+	}
+	return aliases
+}
+
+// Resolve follows the aliases of the name that apply to a version released at the given time and returns the name at
+// the end of the chain, and whether any alias applied. A zero time only follows the aliases without a date. A nil
+// table resolves every name to itself.
+func (table *AliasTable) Resolve(name string, at time.Time) (string, bool) {
+	if table == nil {
+		return name, false
+	}
+	aliased := false
+	for alias, ok := table.aliases[name]; ok; alias, ok = table.aliases[name] {
+		if !alias.Since.IsZero() && (at.IsZero() || at.Before(alias.Since)) {
+			break
+		}
+		name, aliased = alias.To, true
+	}
+	return name, aliased
+}
+
+// resolve is Resolve for a version with the given timestamp, which is only parsed if the name has an alias.
+func (table *AliasTable) resolve(name, timestamp string) (string, bool) {
+	if table == nil {
+		return name, false
+	}
+	if _, ok := table.aliases[name]; !ok {
+		return name, false
+	}
+	at, _ := ParseTimestamp(timestamp)
+	return table.Resolve(name, at)
+}
+
+// declaringDependents returns the distinct IDs of the versions that declare a dependency on the package, under its
+// own name or under one of the old names that are aliased to it, in the classes of the options, in increasing order.
+// Whether an alias applies to a version depends on its timestamp, so some of them may not depend on the package.
+func (pg *PackageGraph) declaringDependents(key PackageKey) []int64 {
+	ids := pg.constraints.dependents(key, pg.options.DependencyClasses)
+	sources := pg.options.Aliases.sourcesOf(key.FullName())
+	if len(sources) == 0 {
+		return ids
+	}
+	for _, source := range sources {
+		ids = append(ids, pg.constraints.dependents(NewPackageKey(key.Ecosystem, source), pg.options.DependencyClasses)...)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	distinct := ids[:0]
+	for _, id := range ids {
+		if len(distinct) == 0 || id != distinct[len(distinct)-1] {
+			distinct = append(distinct, id)
+		}
+	}
+	return distinct
+}
+
+func (table *AliasTable) sourcesOf(name string) []string {
+	if table == nil {
+		return nil
+	}
+	return table.sources[name]
+}
+
+// EdgeAlias returns the old name under which the node from declares the dependency that created its edge to the node
+// to, if the edge was created through an alias, see WithAliases. Of several declarations that lead to the package of
+// to, those whose range to satisfies are considered first, and among them a declaration under the name of to itself
+// takes precedence, so the edge is then not reported as aliased.
+func (pg *PackageGraph) EdgeAlias(from, to int64) (string, bool) {
+	if pg.options.Aliases == nil {
+		return "", false
+	}
+	return pg.edgeAlias(from, to, pg.edgeMatcher())
+}
+
+// edgeMatcher returns the range matcher of the graph, without storing a new one, so that the queries using it only
+// read the graph.
+func (pg *PackageGraph) edgeMatcher() RangeMatcher {
+	if pg.matcher != nil {
+		return pg.matcher
+	}
+	return pg.options.rangeMatcher(pg.isMaven)
+}
+
+func (pg *PackageGraph) edgeAlias(from, to int64, matcher RangeMatcher) (string, bool) {
+	fromInfo, ok := pg.Node(from)
+	if !ok {
+		return "", false
+	}
+	toInfo, ok := pg.Node(to)
+	if !ok {
+		return "", false
+	}
+	versionInfo, _ := pg.VersionInfo(fromInfo.NameVersion())
+	// best is the preferred declaration so far, and bestMatches whether its range is satisfied by to.
+	best, bestMatches := "", false
+	for _, class := range pg.options.DependencyClasses {
+		for name, dependencyRange := range versionInfo.DependenciesOf(class) {
+			if target, _ := pg.options.Aliases.resolve(name, versionInfo.Timestamp); target != toInfo.Name {
+				continue
+			}
+			parsedRange, err := matcher.ParseRange(dependencyRange)
+			matches := err == nil && parsedRange.Matches(toInfo.Version)
+			if best != "" && (bestMatches && !matches || bestMatches == matches && !preferredDeclaration(name, best, toInfo.Name)) {
+				continue
+			}
+			best, bestMatches = name, matches
+		}
+	}
+	return best, best != "" && best != toInfo.Name
+}
+
+// preferredDeclaration reports whether a dependency declared under name is preferred over one declared under other,
+// as the declaration that created an edge to the package target: the target itself, and otherwise the lowest name.
+func preferredDeclaration(name, other, target string) bool {
+	if name == target || other == target {
+		return name == target && other != target
+	}
+	return name < other
+}
+
+// AliasReport counts the edges of a graph that were created through aliases, see WithAliases.
+type AliasReport struct {
+	// Edges is the number of edges that EdgeAlias reports as aliased.
+	Edges int `json:"edges"`
+	// ByAlias is the number of those edges per old name.
+	ByAlias map[string]int `json:"byAlias"`
+}
+
+// AliasReport counts the edges that were created through the aliases of the graph. Of a graph with lazy edges, only the
+// edges created so far are counted.
+func (pg *PackageGraph) AliasReport() AliasReport {
+	report := AliasReport{ByAlias: make(map[string]int)}
+	if pg.options.Aliases == nil {
+		return report
+	}
+	matcher := pg.edgeMatcher()
+	edges := pg.Graph.Edges()
+	for edges.Next() {
+		edge := edges.Edge()
+		from, to := pg.DependencyEdge(edge.From().ID(), edge.To().ID())
+		if alias, ok := pg.edgeAlias(from, to, matcher); ok {
+			report.Edges++
+			report.ByAlias[alias]++
+		}
+	}
+	return report
+}
@@ -0,0 +1,164 @@
+package graph
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openRenamedGraph builds the graph of testdata/renamed.json, in which App only depends on left-pad-ng through the
+// aliases left-pad -> pad-left -> left-pad-ng.
+func openRenamedGraph(t *testing.T, opts ...Option) *PackageGraph {
+	t.Helper()
+	table, err := ReadAliasTable(filepath.Join("testdata", "renamed_aliases.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pg, err := OpenPackageGraph(filepath.Join("testdata", "renamed.json"), false, append([]Option{WithAliases(table)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pg
+}
+
+func sortedNameVersions(nodes []NodeInfo) []string {
+	names := make([]string, len(nodes))
+	for i, node := range nodes {
+		names[i] = node.Name + "@" + node.Version
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestAliases(t *testing.T) {
+	app := NameVersion{Name: "App", Version: "1.0.0"}
+
+	t.Run("Resolves dependencies through a chain of aliases", func(t *testing.T) {
+		without, err := OpenPackageGraph(filepath.Join("testdata", "renamed.json"), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dependencies, _ := without.Dependencies(app, 1); len(dependencies) != 0 {
+			t.Fatalf("Expected no dependencies of App without aliases, got %v", sortedNameVersions(dependencies))
+		}
+		report := &ResolutionReport{}
+		pg := openRenamedGraph(t, WithResolutionReport(report))
+		dependencies, _ := pg.Dependencies(app, 1)
+		if names := sortedNameVersions(dependencies); !reflect.DeepEqual(names, []string{"left-pad-ng@1.0.0", "left-pad-ng@1.1.0"}) {
+			t.Fatalf("Expected App to depend on left-pad-ng 1.x, got %v", names)
+		}
+		appNode, _ := pg.FindNode(app)
+		for _, dependency := range dependencies {
+			if alias, ok := pg.EdgeAlias(appNode.ID(), dependency.ID()); !ok || alias != "left-pad" {
+				t.Errorf("Expected the edge to %s to be created through left-pad, got %q", dependency, alias)
+			}
+			if constraint, _ := pg.Constraint(appNode.ID(), dependency.ID()); constraint != "^1.0.0" {
+				t.Errorf("Expected the constraint ^1.0.0 of the aliased edge, got %q", constraint)
+			}
+			if class, ok := pg.EdgeClass(appNode.ID(), dependency.ID()); !ok || class != Runtime {
+				t.Errorf("Expected the aliased edge to be a runtime dependency, got %v", class)
+			}
+		}
+		if report.UnknownPackage != 1 {
+			t.Errorf("Expected only the declaration of Old to stay unknown, got %d", report.UnknownPackage)
+		}
+	})
+
+	t.Run("Only applies the aliases to the versions released since", func(t *testing.T) {
+		pg := openRenamedGraph(t)
+		if dependencies, _ := pg.Dependencies(NameVersion{Name: "Old", Version: "1.0.0"}, 1); len(dependencies) != 0 {
+			t.Errorf("Expected Old, released before pad-left was renamed, to have no dependencies, got %v", sortedNameVersions(dependencies))
+		}
+	})
+
+	t.Run("Counts the aliased edges", func(t *testing.T) {
+		pg := openRenamedGraph(t)
+		expected := AliasReport{Edges: 4, ByAlias: map[string]int{"left-pad": 2, "pad-left": 2}}
+		if report := pg.AliasReport(); !reflect.DeepEqual(report, expected) {
+			t.Errorf("Expected %+v, got %+v", expected, report)
+		}
+		both, _ := pg.FindNode(NameVersion{Name: "Both", Version: "1.0.0"})
+		direct, _ := pg.FindNode(NameVersion{Name: "left-pad-ng", Version: "2.0.0"})
+		if alias, ok := pg.EdgeAlias(both.ID(), direct.ID()); ok {
+			t.Errorf("Expected the edge declared under the new name not to be aliased, got %q", alias)
+		}
+	})
+
+	t.Run("Links added versions to the dependents of old names", func(t *testing.T) {
+		for _, opts := range [][]Option{nil, {WithResolution(ResolveHighest)}, {WithLazyEdges()}} {
+			pg := openRenamedGraph(t, opts...)
+			if err := pg.AddVersion("left-pad-ng", "1.2.0", VersionInfo{Timestamp: "2021-09-01T00:00:00"}); err != nil {
+				t.Fatal(err)
+			}
+			dependents, _ := pg.Dependents(NameVersion{Name: "left-pad-ng", Version: "1.2.0"}, 1)
+			if names := sortedNameVersions(dependents); !reflect.DeepEqual(names, []string{"App@1.0.0", "Both@1.0.0"}) {
+				t.Errorf("Expected App and Both to depend on the added version, got %v", names)
+			}
+		}
+	})
+
+	t.Run("Keeps the aliases in the cache", func(t *testing.T) {
+		pg := openRenamedGraph(t)
+		var cache bytes.Buffer
+		if err := SaveGraph(&cache, pg); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadGraph(bytes.NewReader(cache.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report := loaded.AliasReport(); report.Edges != 4 {
+			t.Errorf("Expected the 4 aliased edges after loading, got %+v", report)
+		}
+		_, err = LoadGraphWithOptions(bytes.NewReader(cache.Bytes()), false)
+		var mismatch *OptionMismatchError
+		if !errors.As(err, &mismatch) || mismatch.Option != "aliases" {
+			t.Errorf("Expected the aliases to mismatch, got %v", err)
+		}
+	})
+
+	t.Run("Rejects invalid tables", func(t *testing.T) {
+		tests := map[string][]Alias{
+			"aliases form a cycle: a -> b -> c -> a": {{From: "a", To: "b"}, {From: "b", To: "c"}, {From: "c", To: "a"}},
+			"alias of a to itself":                   {{From: "a", To: "a"}},
+			"a is aliased to both b and c":           {{From: "a", To: "b"}, {From: "a", To: "c"}},
+			"without a name":                         {{From: "a"}},
+		}
+		for message, aliases := range tests {
+			if _, err := NewAliasTable(aliases); err == nil || !strings.Contains(err.Error(), message) {
+				t.Errorf("Expected %q, got %v", message, err)
+			}
+		}
+		if _, err := NewAliasTable([]Alias{{From: "a", To: "c"}, {From: "b", To: "c"}, {From: "c", To: "d"}}); err != nil {
+			t.Errorf("Expected chains that join to be accepted, got %v", err)
+		}
+	})
+
+	t.Run("Resolves names by date", func(t *testing.T) {
+		table, err := NewAliasTable([]Alias{{From: "a", To: "b"}, {From: "b", To: "c", Since: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tests := []struct {
+			name     string
+			at       time.Time
+			expected string
+			aliased  bool
+		}{
+			{"a", time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), "c", true},
+			{"a", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), "b", true},
+			{"a", time.Time{}, "b", true},
+			{"c", time.Time{}, "c", false},
+		}
+		for _, test := range tests {
+			if name, aliased := table.Resolve(test.name, test.at); name != test.expected || aliased != test.aliased {
+				t.Errorf("Expected %s to resolve to %s at %v, got %s", test.name, test.expected, test.at, name)
+			}
+		}
+	})
+}
@@ -0,0 +1,168 @@
+package graph
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// AttributeType is the type of the values of an attribute, which the exporters declare in the formats with typed
+// attributes, such as GraphML and GEXF.
+type AttributeType int
+
+const (
+	// StringAttribute values are strings.
+	StringAttribute AttributeType = iota
+	// IntAttribute values are ints.
+	IntAttribute
+	// FloatAttribute values are float64s.
+	FloatAttribute
+	// BoolAttribute values are bools.
+	BoolAttribute
+)
+
+// String returns the name of the type.
+func (t AttributeType) String() string {
+	switch t {
+	case IntAttribute:
+		return "int"
+	case FloatAttribute:
+		return "float"
+	case BoolAttribute:
+		return "bool"
+	default:
+		return "string"
+	}
+}
+
+// NodeAttribute is a named property of the nodes that the exporters write. Value returns the value of a node, of the
+// Go type of Type, and false if the node has none. If Default is not nil, the formats that can leave values out omit
+// the values equal to it, and declare it where the format has defaults.
+type NodeAttribute struct {
+	Name    string
+	Type    AttributeType
+	Default interface{}
+	Value   func(pg *PackageGraph, node NodeInfo) (interface{}, bool)
+}
+
+// EdgeAttribute is a named property of the edges that the exporters write, see NodeAttribute. Value returns the value
+// of the edge from one node ID to another, in the direction in which Graph stores it, see DependencyEdge.
+type EdgeAttribute struct {
+	Name    string
+	Type    AttributeType
+	Default interface{}
+	Value   func(pg *PackageGraph, from, to int64) (interface{}, bool)
+}
+
+// AttributeSchema holds the attributes that the exporters write for the nodes and edges of a graph, in registration
+// order. It starts with the built-in attributes: the name, version, timestamp, license, ecosystem, deprecation message
+// and maintainers of the nodes, of which the last four are left out when empty, and the constraint, weight and
+// dependency class of the edges, see PackageGraph.Constraint, PackageGraph.EdgeWeight and PackageGraph.EdgeClass.
+// Registering must not run concurrently with exports of the graph.
+type AttributeSchema struct {
+	nodes []NodeAttribute
+	edges []EdgeAttribute
+}
+
+// attributeName is the form of attribute names, which are valid as identifiers in every export format.
+var attributeName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reservedAttributes are the names that the exporters use for the node and edge IDs and the DOT labels.
+var reservedAttributes = map[string]bool{"id": true, "from": true, "to": true, "source": true, "target": true, "label": true}
+
+// Attributes returns the attribute schema of the graph, which is created with the built-in attributes on the first
+// call. The schema belongs to the graph and is kept by ReloadFrom, but the graphs that are loaded, merged or extracted
+// from it start with the built-in attributes again, since the extractors cannot be stored.
+func (pg *PackageGraph) Attributes() *AttributeSchema {
+	pg.attributesMutex.Lock()
+	defer pg.attributesMutex.Unlock()
+	if pg.attributes == nil {
+		pg.attributes = newAttributeSchema()
+	}
+	return pg.attributes
+}
+
+func newAttributeSchema() *AttributeSchema {
+	nonEmpty := func(value string) (interface{}, bool) { return value, value != "" }
+	return &AttributeSchema{
+		nodes: []NodeAttribute{
+			{Name: "name", Value: func(_ *PackageGraph, node NodeInfo) (interface{}, bool) { return node.Name, true }},
+			{Name: "version", Value: func(_ *PackageGraph, node NodeInfo) (interface{}, bool) { return node.Version, true }},
+			{Name: "timestamp", Value: func(_ *PackageGraph, node NodeInfo) (interface{}, bool) { return node.Timestamp, true }},
+			{Name: "license", Value: func(_ *PackageGraph, node NodeInfo) (interface{}, bool) { return nonEmpty(node.License) }},
+			{Name: "ecosystem", Value: func(_ *PackageGraph, node NodeInfo) (interface{}, bool) { return nonEmpty(node.Ecosystem) }},
+			{Name: "deprecated", Value: func(_ *PackageGraph, node NodeInfo) (interface{}, bool) { return nonEmpty(node.Deprecated) }},
+			{Name: "maintainers", Value: func(pg *PackageGraph, node NodeInfo) (interface{}, bool) {
+				versionInfo, _ := pg.VersionInfo(node.NameVersion())
+				return nonEmpty(strings.Join(versionInfo.Maintainers, ", "))
+			}},
+		},
+		edges: []EdgeAttribute{
+			{Name: "constraint", Value: func(pg *PackageGraph, from, to int64) (interface{}, bool) {
+				return pg.Constraint(pg.DependencyEdge(from, to))
+			}},
+			{Name: "weight", Type: IntAttribute, Default: 1, Value: func(pg *PackageGraph, from, to int64) (interface{}, bool) {
+				return pg.EdgeWeight(pg.DependencyEdge(from, to)), true
+			}},
+			{Name: "class", Default: Runtime.String(), Value: func(pg *PackageGraph, from, to int64) (interface{}, bool) {
+				class, ok := pg.EdgeClass(pg.DependencyEdge(from, to))
+				return class.String(), ok
+			}},
+		},
+	}
+}
+
+// RegisterNode adds a node attribute after the registered ones. It fails if the attribute has no extractor, or if
+// its name is not an identifier, is reserved or is already taken by a node or an edge attribute.
+func (schema *AttributeSchema) RegisterNode(attribute NodeAttribute) error {
+	if attribute.Value == nil {
+		return fmt.Errorf("node attribute %q has no value function", attribute.Name)
+	}
+	if err := schema.checkName(attribute.Name); err != nil {
+		return err
+	}
+	schema.nodes = append(schema.nodes, attribute)
+	return nil
+}
+
+// RegisterEdge adds an edge attribute after the registered ones, see RegisterNode.
+func (schema *AttributeSchema) RegisterEdge(attribute EdgeAttribute) error {
+	if attribute.Value == nil {
+		return fmt.Errorf("edge attribute %q has no value function", attribute.Name)
+	}
+	if err := schema.checkName(attribute.Name); err != nil {
+		return err
+	}
+	schema.edges = append(schema.edges, attribute)
+	return nil
+}
+
+func (schema *AttributeSchema) checkName(name string) error {
+	if !attributeName.MatchString(name) {
+		return fmt.Errorf("attribute name %q is not an identifier", name)
+	}
+	if reservedAttributes[name] {
+		return fmt.Errorf("attribute name %q is reserved", name)
+	}
+	for _, attribute := range schema.nodes {
+		if attribute.Name == name {
+			return fmt.Errorf("attribute %q is already registered for the nodes", name)
+		}
+	}
+	for _, attribute := range schema.edges {
+		if attribute.Name == name {
+			return fmt.Errorf("attribute %q is already registered for the edges", name)
+		}
+	}
+	return nil
+}
+
+// Nodes returns the node attributes in registration order.
+func (schema *AttributeSchema) Nodes() []NodeAttribute {
+	return append([]NodeAttribute(nil), schema.nodes...)
+}
+
+// Edges returns the edge attributes in registration order.
+func (schema *AttributeSchema) Edges() []EdgeAttribute {
+	return append([]EdgeAttribute(nil), schema.edges...)
+}
@@ -0,0 +1,49 @@
+package graph
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAttributeSchema(t *testing.T) {
+	value := func(*PackageGraph, NodeInfo) (interface{}, bool) { return "", true }
+
+	t.Run("Starts with the built-in attributes", func(t *testing.T) {
+		packagesInfo := testPackages("weights")
+		schema := NewPackageGraph(&packagesInfo, false).Attributes()
+		var names []string
+		for _, attribute := range schema.Nodes() {
+			names = append(names, attribute.Name)
+		}
+		for _, attribute := range schema.Edges() {
+			names = append(names, attribute.Name)
+		}
+		if expected := "[name version timestamp license ecosystem deprecated maintainers constraint weight class]"; fmt.Sprint(names) != expected {
+			t.Errorf("Expected %s, got %v", expected, names)
+		}
+	})
+
+	t.Run("Rejects invalid and taken names", func(t *testing.T) {
+		packagesInfo := testPackages("weights")
+		pg := NewPackageGraph(&packagesInfo, false)
+		for _, name := range []string{"", "has space", "id", "label", "license", "maintainers", "class"} {
+			if err := pg.Attributes().RegisterNode(NodeAttribute{Name: name, Value: value}); err == nil {
+				t.Errorf("Expected %q to be rejected", name)
+			}
+		}
+		if err := pg.Attributes().RegisterNode(NodeAttribute{Name: "downloads"}); err == nil {
+			t.Error("Expected an attribute without a value function to be rejected")
+		}
+		if err := pg.Attributes().RegisterNode(NodeAttribute{Name: "downloads", Value: value}); err != nil {
+			t.Fatal(err)
+		}
+		if err := pg.Attributes().RegisterEdge(EdgeAttribute{Name: "downloads", Value: func(*PackageGraph, int64, int64) (interface{}, bool) {
+			return "", true
+		}}); err == nil {
+			t.Error("Expected an edge attribute with the name of a node attribute to be rejected")
+		}
+		if len(pg.Attributes().Nodes()) != 8 {
+			t.Errorf("Expected 8 node attributes, got %d", len(pg.Attributes().Nodes()))
+		}
+	})
+}
@@ -0,0 +1,151 @@
+package graph_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AJMBrands/SoftwareThatMatters/gen"
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+// The benchmarks in this file run on a generated ecosystem, see the gen package.
+const benchmarkPackages = 2000
+
+func BenchmarkCreateEdges(b *testing.B) {
+	packagesInfo := gen.Generate(gen.DefaultSpec(benchmarkPackages), 1)
+	for name, opts := range map[string][]g.Option{
+		"All":     nil,
+		"Highest": {g.WithResolution(g.ResolveHighest)},
+		"Workers": {g.WithWorkers(4)},
+	} {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				g.NewPackageGraph(&packagesInfo, false, opts...)
+			}
+		})
+	}
+}
+
+func BenchmarkEstimateEdges(b *testing.B) {
+	packagesInfo := gen.Generate(gen.DefaultSpec(benchmarkPackages), 1)
+	for name, opts := range map[string][]g.Option{
+		"All":     nil,
+		"Highest": {g.WithResolution(g.ResolveHighest)},
+		"Sampled": {g.WithSampling(0.1, 1)},
+	} {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := g.EstimateEdges(packagesInfo, opts...); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkReadPackagesJSON(b *testing.B) {
+	encoded, err := json.Marshal(gen.Generate(gen.DefaultSpec(benchmarkPackages), 1))
+	if err != nil {
+		b.Fatal(err)
+	}
+	path := filepath.Join(b.TempDir(), "packages.json")
+	if err := os.WriteFile(path, encoded, 0o644); err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(encoded)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := g.ReadPackagesJSON(path, g.NewInterner()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseJSONParallel(b *testing.B) {
+	packagesInfo := gen.Generate(gen.DefaultSpec(benchmarkPackages), 1)
+	const chunks = 8
+	dir := b.TempDir()
+	var paths []string
+	var size int64
+	for i := 0; i < chunks; i++ {
+		encoded, err := json.Marshal(packagesInfo[i*len(packagesInfo)/chunks : (i+1)*len(packagesInfo)/chunks])
+		if err != nil {
+			b.Fatal(err)
+		}
+		path := filepath.Join(dir, fmt.Sprintf("chunk-%d.json", i))
+		if err := os.WriteFile(path, encoded, 0o644); err != nil {
+			b.Fatal(err)
+		}
+		paths = append(paths, path)
+		size += int64(len(encoded))
+	}
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("Workers%d", workers), func(b *testing.B) {
+			b.SetBytes(size)
+			for i := 0; i < b.N; i++ {
+				if _, err := g.ParseJSONParallel(paths, workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkTraversals(b *testing.B) {
+	packagesInfo := gen.Generate(gen.DefaultSpec(benchmarkPackages), 1)
+	pg := g.NewPackageGraph(&packagesInfo, false, g.WithResolution(g.ResolveHighest))
+	// The first packages are the most popular ones, with the most dependents.
+	popular := pg.Versions("package-0")
+	target := g.NameVersion{Name: popular[0].Name, Version: popular[0].Version}
+
+	b.Run("Dependents", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			pg.Dependents(target, -1)
+		}
+	})
+	roots := make([]int64, 0, len(pg.Nodes))
+	for _, node := range pg.Nodes {
+		roots = append(roots, node.ID())
+	}
+	b.Run("TransitiveDependencyCounts", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			g.TransitiveDependencyCounts(pg.Graph, roots)
+		}
+	})
+	b.Run("Reachability", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			g.BuildReachabilityIndex(pg.Graph)
+		}
+	})
+	b.Run("ConstraintsOn", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			pg.ConstraintsOn(target.Name)
+		}
+	})
+}
+
+// BenchmarkNameFilter compares resolving the edges of a generated graph in which 30% of the declarations are on
+// unknown packages with and without the name filter.
+func BenchmarkNameFilter(b *testing.B) {
+	packagesInfo := gen.Generate(gen.DefaultSpec(benchmarkPackages), 1)
+	for i, packageInfo := range packagesInfo {
+		for _, version := range packageInfo.Versions {
+			// Three unknown names for every seven dependencies make up 30% of the declarations.
+			unknown := len(version.Dependencies) * 3 / 7
+			for k := 0; k < unknown; k++ {
+				version.Dependencies[fmt.Sprintf("@private/package-%d-%d", i, k)] = "^1.0.0"
+			}
+		}
+	}
+	pg := g.NewPackageGraph(&packagesInfo, false, g.WithLazyEdges())
+	for name, filtered := range map[string]bool{"Filtered": true, "Unfiltered": false} {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				g.ResolveAllPackages(pg, filtered)
+			}
+		})
+	}
+}
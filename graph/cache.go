@@ -0,0 +1,877 @@
+package graph
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// cacheMagic starts every graph cache, so that other files are rejected with a clear error.
+const cacheMagic = "STM-GRAPH-STORE\n"
+
+// cacheFormatVersion is increased whenever the layout of the container or of one of its sections changes.
+const cacheFormatVersion = 10
+
+// packagesMagic starts every file written by SavePackages.
+const packagesMagic = "STM-PACKAGES\n"
+
+// packagesFormatVersion is increased whenever the layout of packagesFile, including PackageInfo, changes.
+const packagesFormatVersion = 2
+
+// The names of the sections of a graph cache, in the order in which they are written.
+const (
+	optionsSection  = "options"
+	stringsSection  = "strings"
+	packagesSection = "packages"
+	nodesSection    = "nodes"
+	indexSection    = "index"
+	edgesSection    = "edges"
+)
+
+// cacheHeader starts a graph cache, after the magic. It is followed by one cacheSection per name in Sections, all
+// encoded with the same gob encoder.
+type cacheHeader struct {
+	FormatVersion int
+	Sections      []string
+}
+
+// cacheSection holds the gob encoding of one part of the graph together with its CRC-32 checksum, so that a corrupt
+// cache is rejected before any of it is used.
+type cacheSection struct {
+	Name     string
+	Checksum uint32
+	Data     []byte
+}
+
+// cachedOptions are the settings the graph was built with. The settings that change which versions become nodes or
+// how the edges are created are compared with the requested ones by LoadGraphWithOptions; the name filter and the
+// range matcher cannot be stored, so only whether they were set is recorded.
+type cachedOptions struct {
+	IsMaven                  bool
+	Resolution               Resolution
+	DependencyClasses        []DependencyClass
+	Cutoff                   time.Time
+	WindowStart              time.Time
+	WindowEnd                time.Time
+	ExcludeUnparseable       bool
+	TruncateFourPartVersions bool
+	Prereleases              PrereleasePolicy
+	PreferNonDeprecated      bool
+	Names                    NameNormalization
+	// EcosystemNames holds the name normalization of every ecosystem of WithEcosystemNameNormalization, sorted by
+	// ecosystem, as gob encodes the entries of a map in random order.
+	EcosystemNames []cachedEcosystemNames
+	NameFilter     bool
+	Ecosystems     []string
+	Matcher        bool
+	LazyEdges      bool
+	IDScheme       IDScheme
+	EdgeDirection  EdgeDirection
+	// Aliases holds the aliases of WithAliases, sorted by their old name.
+	Aliases []Alias
+}
+
+// cachedPackage and cachedVersion refer to the strings of the strings section by their index, so every distinct
+// string is stored and loaded once, like the strings of an Interner.
+type cachedPackage struct {
+	Name      uint32
+	Ecosystem uint32
+	Versions  []cachedVersion
+	// Provenance is nil for the packages without one, which includes all the packages of older caches.
+	Provenance *cachedProvenance
+}
+
+type cachedProvenance struct {
+	File       uint32
+	Start, End int64
+}
+
+type cachedVersion struct {
+	Version    uint32
+	Timestamp  uint32
+	License    uint32
+	Deprecated uint32
+	// Classes tells which dependency maps, by DependencyClass, are not nil, as gob does not distinguish a nil map
+	// from an empty one.
+	Classes uint8
+	// Dependencies holds the names and ranges of the dependencies of every class, alternating.
+	Dependencies [4][]uint32
+	Maintainers  []uint32
+}
+
+type cachedNode struct {
+	ID         int64
+	Name       uint32
+	Version    uint32
+	Timestamp  uint32
+	License    uint32
+	Deprecated uint32
+	Ecosystem  uint32
+}
+
+// cachedIndex holds NameToVersions, in the order of its slices.
+type cachedIndex struct {
+	Names      []uint32
+	Ecosystems []uint32
+	Versions   [][]uint32
+}
+
+type cachedEdges struct {
+	Edges [][2]int64
+	// Unresolved holds the IDs of the versions of a graph with lazy edges whose edges have not been created yet.
+	Unresolved []int64
+	// Cross holds the cross-ecosystem edges, which are also part of Edges, and CrossConstraints their constraints.
+	Cross            [][2]int64
+	CrossConstraints []uint32
+	// Weighted holds the edges with a weight above 1, which are also part of Edges, and Weights their weights.
+	Weighted [][2]int64
+	Weights  []int32
+}
+
+// packagesFile is the serialized form of a list of parsed packages. The packages are stored like the packages section
+// of a graph cache, with their versions and dependencies sorted, rather than as maps, whose encoding follows their
+// random iteration order. The field Packages of the first format held the packages themselves, and is left out so
+// that decoding such a file reaches the check of the format version.
+type packagesFile struct {
+	FormatVersion int
+	Strings       []string
+	Records       []cachedPackage
+}
+
+// ErrNotACache is returned by LoadGraph and LoadPackages when the input does not start like a graph cache or a list of
+// packages.
+var ErrNotACache = errors.New("input is not a graph cache")
+
+// OptionMismatchError is returned by LoadGraphWithOptions when the graph cache was built with other settings than
+// the requested ones.
+type OptionMismatchError struct {
+	// Option is the name of the setting, as in the flags of the command line, and Cached and Requested its values.
+	Option    string
+	Cached    string
+	Requested string
+}
+
+func (e *OptionMismatchError) Error() string {
+	return fmt.Sprintf("graph cache was built with %s %s, but %s was requested", e.Option, e.Cached, e.Requested)
+}
+
+// stringTable assigns an index to every distinct string written to a cache. The empty string has index 0.
+type stringTable struct {
+	indices map[string]uint32
+	strings []string
+}
+
+func newStringTable() *stringTable {
+	return &stringTable{indices: map[string]uint32{"": 0}, strings: []string{""}}
+}
+
+func (table *stringTable) ref(s string) uint32 {
+	index, ok := table.indices[s]
+	if !ok {
+		index = uint32(len(table.strings))
+		table.indices[s] = index
+		table.strings = append(table.strings, s)
+	}
+	return index
+}
+
+// SaveGraph writes the graph to w: its construction options, its parsed packages, its nodes and name index and its
+// edges, each as a section with a checksum. The strings are stored once, so a loaded graph shares them like a graph
+// built from packages parsed with an Interner. A graph with lazy edges is saved with the edges created so far, and
+// the loaded graph creates the others on demand. The output only depends on the graph, so saving the same graph twice
+// writes the same bytes.
+func SaveGraph(w io.Writer, pg *PackageGraph) error {
+	return saveGraph(w, pg, nil)
+}
+
+// saveGraph writes the graph cache, with its strings in a metadata file written to metadata if it is not nil.
+func saveGraph(w io.Writer, pg *PackageGraph, metadata io.Writer) error {
+	if pg.lockEdges() {
+		defer pg.lazyMutex.Unlock()
+	}
+	table := newStringTable()
+	packages := make([]cachedPackage, 0, len(*pg.Packages))
+	for _, packageInfo := range *pg.Packages {
+		packages = append(packages, cachePackage(table, packageInfo))
+	}
+	nodes := make([]cachedNode, 0, len(pg.Nodes))
+	for _, node := range pg.Nodes {
+		if node.stringID != "" {
+			nodes = append(nodes, cachedNode{node.id, table.ref(node.Name), table.ref(node.Version), table.ref(node.Timestamp), table.ref(node.License), table.ref(node.Deprecated), table.ref(node.Ecosystem)})
+		}
+	}
+	index := cachedIndex{
+		Names:      make([]uint32, 0, len(pg.NameToVersions)),
+		Ecosystems: make([]uint32, 0, len(pg.NameToVersions)),
+		Versions:   make([][]uint32, 0, len(pg.NameToVersions)),
+	}
+	for _, key := range sortedPackageKeys(pg.NameToVersions) {
+		versions := make([]uint32, len(pg.NameToVersions[key]))
+		for i, version := range pg.NameToVersions[key] {
+			versions[i] = table.ref(version)
+		}
+		index.Names = append(index.Names, table.ref(key.FullName()))
+		index.Ecosystems = append(index.Ecosystems, table.ref(key.Ecosystem))
+		index.Versions = append(index.Versions, versions)
+	}
+	edges := cachedEdges{Edges: make([][2]int64, 0, pg.Graph.Edges().Len())}
+	it := pg.Graph.Edges()
+	for it.Next() {
+		edges.Edges = append(edges.Edges, [2]int64{it.Edge().From().ID(), it.Edge().To().ID()})
+	}
+	sortEdges(edges.Edges)
+	for id := range pg.unresolved {
+		edges.Unresolved = append(edges.Unresolved, id)
+	}
+	sort.Slice(edges.Unresolved, func(i, j int) bool { return edges.Unresolved[i] < edges.Unresolved[j] })
+	for edge := range pg.crossEdges {
+		edges.Cross = append(edges.Cross, edge)
+	}
+	sortEdges(edges.Cross)
+	for _, edge := range edges.Cross {
+		edges.CrossConstraints = append(edges.CrossConstraints, table.ref(pg.crossEdges[edge]))
+	}
+	for edge := range pg.weights {
+		edges.Weighted = append(edges.Weighted, edge)
+	}
+	sortEdges(edges.Weighted)
+	for _, edge := range edges.Weighted {
+		edges.Weights = append(edges.Weights, pg.weights[edge])
+	}
+
+	type section struct {
+		name  string
+		value interface{}
+	}
+	cachedStrings := table.strings
+	var metadataSections []section
+	if metadata != nil {
+		written, err := writeMetadata(metadata, table.strings)
+		if err != nil {
+			return err
+		}
+		cachedStrings = nil
+		metadataSections = append(metadataSections, section{metadataSection, written})
+	}
+	sections := append([]section{
+		{optionsSection, newCachedOptions(pg.isMaven, pg.options)},
+		{stringsSection, cachedStrings},
+		{packagesSection, packages},
+		{nodesSection, nodes},
+		{indexSection, index},
+		{edgesSection, edges},
+	}, metadataSections...)
+	header := cacheHeader{FormatVersion: cacheFormatVersion}
+	for _, section := range sections {
+		header.Sections = append(header.Sections, section.name)
+	}
+	buffered := bufio.NewWriter(w)
+	if _, err := buffered.WriteString(cacheMagic); err != nil {
+		return err
+	}
+	encoder := gob.NewEncoder(buffered)
+	if err := encoder.Encode(&header); err != nil {
+		return err
+	}
+	for _, section := range sections {
+		var data bytes.Buffer
+		if err := gob.NewEncoder(&data).Encode(section.value); err != nil {
+			return fmt.Errorf("encoding graph cache section %s: %w", section.name, err)
+		}
+		if err := encoder.Encode(&cacheSection{section.name, crc32.ChecksumIEEE(data.Bytes()), data.Bytes()}); err != nil {
+			return err
+		}
+	}
+	return buffered.Flush()
+}
+
+func cachePackage(table *stringTable, packageInfo PackageInfo) cachedPackage {
+	cached := cachedPackage{Name: table.ref(packageInfo.Name), Ecosystem: table.ref(packageInfo.Ecosystem), Versions: make([]cachedVersion, 0, len(packageInfo.Versions))}
+	if provenance := packageInfo.Provenance; provenance != nil {
+		cached.Provenance = &cachedProvenance{File: table.ref(provenance.File), Start: provenance.Start, End: provenance.End}
+	}
+	versions := make([]string, 0, len(packageInfo.Versions))
+	for version := range packageInfo.Versions {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	for _, version := range versions {
+		versionInfo := packageInfo.Versions[version]
+		cachedVersion := cachedVersion{
+			Version:    table.ref(version),
+			Timestamp:  table.ref(versionInfo.Timestamp),
+			License:    table.ref(string(versionInfo.License)),
+			Deprecated: table.ref(string(versionInfo.Deprecated)),
+		}
+		for _, maintainer := range versionInfo.Maintainers {
+			cachedVersion.Maintainers = append(cachedVersion.Maintainers, table.ref(maintainer))
+		}
+		for _, class := range []DependencyClass{Runtime, Development, Peer, Optional} {
+			dependencies := versionInfo.DependenciesOf(class)
+			if dependencies == nil {
+				continue
+			}
+			cachedVersion.Classes |= 1 << class
+			names := make([]string, 0, len(dependencies))
+			for name := range dependencies {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			refs := make([]uint32, 0, 2*len(names))
+			for _, name := range names {
+				refs = append(refs, table.ref(name), table.ref(dependencies[name]))
+			}
+			cachedVersion.Dependencies[class] = refs
+		}
+		cached.Versions = append(cached.Versions, cachedVersion)
+	}
+	return cached
+}
+
+func newCachedOptions(isMaven bool, options *Options) cachedOptions {
+	var aliases []Alias
+	if options.Aliases != nil {
+		aliases = options.Aliases.Aliases()
+	}
+	return cachedOptions{
+		IsMaven:                  isMaven,
+		Resolution:               options.Resolution,
+		DependencyClasses:        options.DependencyClasses,
+		Cutoff:                   options.Cutoff,
+		WindowStart:              options.WindowStart,
+		WindowEnd:                options.WindowEnd,
+		ExcludeUnparseable:       options.ExcludeUnparseableTimestamps,
+		TruncateFourPartVersions: options.TruncateFourPartVersions,
+		Prereleases:              options.Prereleases,
+		PreferNonDeprecated:      options.PreferNonDeprecated,
+		Names:                    options.Names,
+		EcosystemNames:           cachedEcosystemNamesOf(options.EcosystemNames),
+		NameFilter:               options.NameFilter != nil,
+		Ecosystems:               options.Ecosystems,
+		Matcher:                  options.Matcher != nil,
+		LazyEdges:                options.LazyEdges,
+		IDScheme:                 options.IDScheme,
+		EdgeDirection:            options.EdgeDirection,
+		Aliases:                  aliases,
+	}
+}
+
+// cachedEcosystemNames is the name normalization of an ecosystem.
+type cachedEcosystemNames struct {
+	Ecosystem string
+	Names     NameNormalization
+}
+
+// options returns the Options that the cached options describe, without a name filter and a range matcher.
+func (cached cachedOptions) options() *Options {
+	options := newOptions([]Option{
+		WithResolution(cached.Resolution),
+		WithDependencyClasses(cached.DependencyClasses...),
+		WithPrereleases(cached.Prereleases),
+		WithNameNormalization(cached.Names),
+	})
+	options.Cutoff = cached.Cutoff
+	options.WindowStart = cached.WindowStart
+	options.WindowEnd = cached.WindowEnd
+	options.ExcludeUnparseableTimestamps = cached.ExcludeUnparseable
+	options.TruncateFourPartVersions = cached.TruncateFourPartVersions
+	options.PreferNonDeprecated = cached.PreferNonDeprecated
+	options.LazyEdges = cached.LazyEdges
+	options.EcosystemNames = make(map[string]NameNormalization, len(cached.EcosystemNames))
+	for _, ecosystemNames := range cached.EcosystemNames {
+		options.EcosystemNames[ecosystemNames.Ecosystem] = ecosystemNames.Names
+	}
+	options.Ecosystems = cached.Ecosystems
+	options.IDScheme = cached.IDScheme
+	options.EdgeDirection = cached.EdgeDirection
+	if len(cached.Aliases) > 0 {
+		// The aliases were checked before the graph was saved.
+		options.Aliases, _ = NewAliasTable(cached.Aliases)
+	}
+	return options
+}
+
+// cachedEcosystemNamesOf returns the name normalizations of the ecosystems sorted by ecosystem.
+func cachedEcosystemNamesOf(ecosystemNames map[string]NameNormalization) []cachedEcosystemNames {
+	cached := make([]cachedEcosystemNames, 0, len(ecosystemNames))
+	for ecosystem, names := range ecosystemNames {
+		cached = append(cached, cachedEcosystemNames{Ecosystem: ecosystem, Names: names})
+	}
+	sort.Slice(cached, func(i, j int) bool { return cached[i].Ecosystem < cached[j].Ecosystem })
+	return cached
+}
+
+// settings describes the options that determine the graph, named like the flags of the command line.
+func (cached cachedOptions) settings() [][2]string {
+	classes := make([]string, len(cached.DependencyClasses))
+	for i, class := range cached.DependencyClasses {
+		classes[i] = class.String()
+	}
+	cutoff := "none"
+	if !cached.Cutoff.IsZero() {
+		cutoff = cached.Cutoff.UTC().Format(time.RFC3339)
+	}
+	window := "none"
+	if !cached.WindowStart.IsZero() || !cached.WindowEnd.IsZero() {
+		bound := func(t time.Time) string {
+			if t.IsZero() {
+				return "open"
+			}
+			return t.UTC().Format(time.RFC3339)
+		}
+		window = bound(cached.WindowStart) + "/" + bound(cached.WindowEnd)
+		if cached.ExcludeUnparseable {
+			window += " without unparseable timestamps"
+		}
+	}
+	nameNormalizations := [...]string{KeepNames: "keep", DecodeNames: "decode", NpmNames: "npm"}
+	ecosystemNames := make([]string, 0, len(cached.EcosystemNames))
+	for _, names := range cached.EcosystemNames {
+		ecosystemNames = append(ecosystemNames, names.Ecosystem+"="+nameNormalizations[names.Names])
+	}
+	if len(ecosystemNames) == 0 {
+		ecosystemNames = append(ecosystemNames, "none")
+	}
+	ecosystems := "all"
+	if len(cached.Ecosystems) > 0 {
+		ecosystems = strings.Join(cached.Ecosystems, ",")
+	}
+	// The aliases are described by their number and a checksum, as a table may hold thousands of them.
+	aliases := "none"
+	if len(cached.Aliases) > 0 {
+		checksum := crc32.NewIEEE()
+		for _, alias := range cached.Aliases {
+			fmt.Fprintf(checksum, "%s\x00%s\x00%s\n", alias.From, alias.To, alias.Since.UTC().Format(time.RFC3339Nano))
+		}
+		aliases = fmt.Sprintf("%d with checksum %08x", len(cached.Aliases), checksum.Sum32())
+	}
+	set := func(isSet bool) string {
+		if isSet {
+			return "set"
+		}
+		return "none"
+	}
+	return [][2]string{
+		{"maven", strconv.FormatBool(cached.IsMaven)},
+		{"resolution", [...]string{ResolveAll: "all", ResolveHighest: "highest"}[cached.Resolution]},
+		{"classes", strings.Join(classes, ",")},
+		{"cutoff", cutoff},
+		{"window", window},
+		{"truncate", strconv.FormatBool(cached.TruncateFourPartVersions)},
+		{"prereleases", [...]string{ExcludePrereleases: "exclude", IncludeIfRangeHasPrerelease: "range", AlwaysIncludePrereleases: "always"}[cached.Prereleases]},
+		{"undeprecated", strconv.FormatBool(cached.PreferNonDeprecated)},
+		{"names", nameNormalizations[cached.Names]},
+		{"ecosystem names", strings.Join(ecosystemNames, ",")},
+		{"name filter", set(cached.NameFilter)},
+		{"ecosystems", ecosystems},
+		{"range matcher", set(cached.Matcher)},
+		{"ids", cached.IDScheme.String()},
+		{"edge-direction", cached.EdgeDirection.String()},
+		{"aliases", aliases},
+	}
+}
+
+// mismatch returns an *OptionMismatchError for the first setting in which the cached options differ from the
+// requested ones, or nil.
+func (cached cachedOptions) mismatch(requested cachedOptions) error {
+	requestedSettings := requested.settings()
+	for i, setting := range cached.settings() {
+		if setting[1] != requestedSettings[i][1] {
+			return &OptionMismatchError{Option: setting[0], Cached: setting[1], Requested: requestedSettings[i][1]}
+		}
+	}
+	return nil
+}
+
+// LoadGraph reads a graph written by SaveGraph, with the options that it was built with. Caches written before the
+// cache became a container of sections, with format version 8, can still be read.
+func LoadGraph(r io.Reader) (*PackageGraph, error) {
+	return loadGraph(r, nil, nil)
+}
+
+// LoadGraphWithOptions reads a graph written by SaveGraph like LoadGraph, but returns an *OptionMismatchError if the
+// graph was not built with the package manager and the options given, naming the first option that differs. The
+// options that do not change the graph, such as the logger and the number of workers, are used by the loaded graph
+// for the versions added later. A name filter and a range matcher cannot be compared, so only whether they are set has
+// to match.
+func LoadGraphWithOptions(r io.Reader, isUsingMaven bool, opts ...Option) (*PackageGraph, error) {
+	return loadGraph(r, &requestedOptions{isUsingMaven, newOptions(opts)}, nil)
+}
+
+type requestedOptions struct {
+	isMaven bool
+	options *Options
+}
+
+// loadGraph reads a graph cache, whose strings are looked up in metadata if it was saved with a metadata file.
+func loadGraph(r io.Reader, requested *requestedOptions, metadata *metadataFile) (*PackageGraph, error) {
+	buffered := bufio.NewReader(r)
+	magic := make([]byte, len(cacheMagic))
+	if _, err := io.ReadFull(buffered, magic); err != nil {
+		return nil, ErrNotACache
+	}
+	var pg *PackageGraph
+	var cached cachedOptions
+	var err error
+	switch string(magic) {
+	case cacheMagic:
+		pg, cached, err = loadContainer(buffered, metadata)
+	case legacyCacheMagic:
+		if metadata != nil {
+			return nil, errors.New("graph cache was saved without a metadata file")
+		}
+		pg, cached, err = loadLegacyCache(buffered)
+	default:
+		return nil, ErrNotACache
+	}
+	if err != nil {
+		return nil, err
+	}
+	pg.isMaven = cached.IsMaven
+	pg.options = cached.options()
+	if requested != nil {
+		if err := cached.mismatch(newCachedOptions(requested.isMaven, requested.options)); err != nil {
+			return nil, err
+		}
+		pg.options = requested.options
+	}
+	if len(pg.unresolved) > 0 {
+		pg.options.LazyEdges = true
+	}
+	if pg.options.LatestIndex {
+		pg.latest = newLatestIndex(pg)
+	}
+	return pg, nil
+}
+
+// loadContainer reads the sections of a graph cache, after the magic.
+func loadContainer(r io.Reader, metadata *metadataFile) (*PackageGraph, cachedOptions, error) {
+	var cached cachedOptions
+	decoder := gob.NewDecoder(r)
+	var header cacheHeader
+	if err := decoder.Decode(&header); err != nil {
+		return nil, cached, fmt.Errorf("decoding graph cache: %w", err)
+	}
+	if header.FormatVersion != cacheFormatVersion {
+		return nil, cached, fmt.Errorf("graph cache has format version %d, expected %d", header.FormatVersion, cacheFormatVersion)
+	}
+	sections := make(map[string][]byte, len(header.Sections))
+	for range header.Sections {
+		var section cacheSection
+		if err := decoder.Decode(&section); err != nil {
+			return nil, cached, fmt.Errorf("decoding graph cache: %w", err)
+		}
+		if crc32.ChecksumIEEE(section.Data) != section.Checksum {
+			return nil, cached, fmt.Errorf("graph cache section %s is corrupt: checksum mismatch", section.Name)
+		}
+		sections[section.Name] = section.Data
+	}
+	var stringsTable []string
+	var packages []cachedPackage
+	var nodes []cachedNode
+	var index cachedIndex
+	var edges cachedEdges
+	for _, section := range []struct {
+		name  string
+		value interface{}
+	}{
+		{optionsSection, &cached},
+		{stringsSection, &stringsTable},
+		{packagesSection, &packages},
+		{nodesSection, &nodes},
+		{indexSection, &index},
+		{edgesSection, &edges},
+	} {
+		data, ok := sections[section.name]
+		if !ok {
+			return nil, cached, fmt.Errorf("graph cache has no %s section", section.name)
+		}
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(section.value); err != nil {
+			return nil, cached, fmt.Errorf("decoding graph cache section %s: %w", section.name, err)
+		}
+	}
+
+	lookup := stringLookup(stringsTable)
+	if data, ok := sections[metadataSection]; ok {
+		var written cachedMetadata
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&written); err != nil {
+			return nil, cached, fmt.Errorf("decoding graph cache section %s: %w", metadataSection, err)
+		}
+		if metadata == nil {
+			return nil, cached, errors.New("graph cache keeps its strings in a metadata file, see LoadGraphFileWithMetadata")
+		}
+		if err := metadata.check(written); err != nil {
+			return nil, cached, err
+		}
+		lookup = metadata.lookup
+	} else if metadata != nil {
+		return nil, cached, errors.New("graph cache was saved without a metadata file")
+	}
+	packagesList, err := resolvePackages(packages, lookup)
+	if err != nil {
+		return nil, cached, err
+	}
+	graph := simple.NewDirectedGraph()
+	var nodeInfos []NodeInfo
+	for _, node := range nodes {
+		fields, err := resolveRefs(lookup, node.Name, node.Version, node.Timestamp, node.License, node.Deprecated, node.Ecosystem)
+		if err != nil {
+			return nil, cached, err
+		}
+		info, err := newCachedNodeInfo(graph, node.ID, NewPackageKey(fields[5], fields[0]), fields[1], fields[2], fields[3], fields[4])
+		if err != nil {
+			return nil, cached, err
+		}
+		nodeInfos = appendNodeInfo(nodeInfos, info, cached.IDScheme)
+	}
+	nameToVersions := make(map[PackageKey][]string, len(index.Names))
+	if len(index.Versions) != len(index.Names) || len(index.Ecosystems) != len(index.Names) {
+		return nil, cached, errors.New("graph cache has an invalid name index")
+	}
+	for i, nameRef := range index.Names {
+		key, err := resolveRefs(lookup, nameRef, index.Ecosystems[i])
+		if err != nil {
+			return nil, cached, err
+		}
+		versions, err := resolveRefs(lookup, index.Versions[i]...)
+		if err != nil {
+			return nil, cached, err
+		}
+		nameToVersions[NewPackageKey(key[1], key[0])] = versions
+	}
+	pg, err := newCachedPackageGraph(graph, &packagesList, nodeInfos, nameToVersions, edges.Edges, edges.Unresolved)
+	if err != nil {
+		return nil, cached, err
+	}
+	// The cross-ecosystem edges and the weights name the dependent first, whatever the direction of the stored edges.
+	pg.options.EdgeDirection = cached.EdgeDirection
+	if len(edges.Cross) != len(edges.CrossConstraints) {
+		return nil, cached, errors.New("graph cache has invalid cross-ecosystem edges")
+	}
+	for i, edge := range edges.Cross {
+		constraint, err := lookup(edges.CrossConstraints[i])
+		if err != nil {
+			return nil, cached, err
+		}
+		if !pg.DependencyGraph().HasEdgeFromTo(edge[0], edge[1]) {
+			return nil, cached, fmt.Errorf("graph cache contains an unknown cross-ecosystem edge from %d to %d", edge[0], edge[1])
+		}
+		pg.setCrossEdge(edge[0], edge[1], constraint)
+	}
+	if len(edges.Weighted) != len(edges.Weights) {
+		return nil, cached, errors.New("graph cache has invalid edge weights")
+	}
+	for i, edge := range edges.Weighted {
+		if !pg.DependencyGraph().HasEdgeFromTo(edge[0], edge[1]) {
+			return nil, cached, fmt.Errorf("graph cache contains a weight of an unknown edge from %d to %d", edge[0], edge[1])
+		}
+		pg.setWeight(edge[0], edge[1], int(edges.Weights[i]))
+	}
+	return pg, cached, nil
+}
+
+func stringLookup(strings []string) func(uint32) (string, error) {
+	return func(ref uint32) (string, error) {
+		if int(ref) >= len(strings) {
+			return "", fmt.Errorf("graph cache refers to an unknown string %d", ref)
+		}
+		return strings[ref], nil
+	}
+}
+
+func resolvePackages(packages []cachedPackage, lookup func(uint32) (string, error)) ([]PackageInfo, error) {
+	packagesList := make([]PackageInfo, 0, len(packages))
+	for _, cachedPackage := range packages {
+		packageInfo, err := cachedPackage.resolve(lookup)
+		if err != nil {
+			return nil, err
+		}
+		packagesList = append(packagesList, packageInfo)
+	}
+	return packagesList, nil
+}
+
+func (cached cachedPackage) resolve(lookup func(uint32) (string, error)) (PackageInfo, error) {
+	names, err := resolveRefs(lookup, cached.Name, cached.Ecosystem)
+	if err != nil {
+		return PackageInfo{}, err
+	}
+	name := names[0]
+	packageInfo := PackageInfo{Name: name, Ecosystem: names[1], Versions: make(map[string]VersionInfo, len(cached.Versions))}
+	if cached.Provenance != nil {
+		file, err := resolveRefs(lookup, cached.Provenance.File)
+		if err != nil {
+			return PackageInfo{}, err
+		}
+		packageInfo.Provenance = &Provenance{File: file[0], Start: cached.Provenance.Start, End: cached.Provenance.End}
+	}
+	for _, version := range cached.Versions {
+		fields, err := resolveRefs(lookup, version.Version, version.Timestamp, version.License, version.Deprecated)
+		if err != nil {
+			return PackageInfo{}, err
+		}
+		versionInfo := VersionInfo{Timestamp: fields[1], License: License(fields[2]), Deprecated: Deprecation(fields[3])}
+		if len(version.Maintainers) > 0 {
+			maintainers, err := resolveRefs(lookup, version.Maintainers...)
+			if err != nil {
+				return PackageInfo{}, err
+			}
+			versionInfo.Maintainers = maintainers
+		}
+		for _, class := range []DependencyClass{Runtime, Development, Peer, Optional} {
+			if version.Classes&(1<<class) == 0 {
+				continue
+			}
+			refs := version.Dependencies[class]
+			if len(refs)%2 != 0 {
+				return PackageInfo{}, fmt.Errorf("graph cache has invalid dependencies for %s %s", name, fields[0])
+			}
+			dependencies := make(map[string]string, len(refs)/2)
+			for i := 0; i < len(refs); i += 2 {
+				pair, err := resolveRefs(lookup, refs[i], refs[i+1])
+				if err != nil {
+					return PackageInfo{}, err
+				}
+				dependencies[pair[0]] = pair[1]
+			}
+			switch class {
+			case Runtime:
+				versionInfo.Dependencies = dependencies
+			case Development:
+				versionInfo.DevDependencies = dependencies
+			case Peer:
+				versionInfo.PeerDependencies = dependencies
+			case Optional:
+				versionInfo.OptionalDependencies = dependencies
+			}
+		}
+		packageInfo.Versions[fields[0]] = versionInfo
+	}
+	return packageInfo, nil
+}
+
+func resolveRefs(lookup func(uint32) (string, error), refs ...uint32) ([]string, error) {
+	resolved := make([]string, len(refs))
+	for i, ref := range refs {
+		s, err := lookup(ref)
+		if err != nil {
+			return nil, err
+		}
+		resolved[i] = s
+	}
+	return resolved, nil
+}
+
+// newCachedNodeInfo adds the node of a cache to the graph and returns its node information.
+func newCachedNodeInfo(graph *simple.DirectedGraph, id int64, key PackageKey, version, timestamp, license, deprecated string) (NodeInfo, error) {
+	if id < 0 || graph.Node(id) != nil {
+		return NodeInfo{}, fmt.Errorf("graph cache contains an invalid or duplicate node ID %d", id)
+	}
+	info := newNodeInfo(id, key, version, timestamp)
+	info.License = license
+	info.Deprecated = deprecated
+	graph.AddNode(simple.Node(id))
+	return *info, nil
+}
+
+// newCachedPackageGraph adds the edges of a cache to the graph of its nodes and marks the unresolved versions.
+func newCachedPackageGraph(graph *simple.DirectedGraph, packages *[]PackageInfo, nodes []NodeInfo, nameToVersions map[PackageKey][]string, edges [][2]int64, unresolved []int64) (*PackageGraph, error) {
+	for _, edge := range edges {
+		if graph.Node(edge[0]) == nil || graph.Node(edge[1]) == nil {
+			return nil, fmt.Errorf("graph cache contains an edge between unknown nodes %d and %d", edge[0], edge[1])
+		}
+		graph.SetEdge(simple.Edge{F: simple.Node(edge[0]), T: simple.Node(edge[1])})
+	}
+	pg := newPackageGraphFromIndex(graph, packages, nodes, nameToVersions)
+	if len(unresolved) > 0 {
+		pg.unresolved = make(map[int64]bool, len(unresolved))
+		for _, id := range unresolved {
+			if _, ok := pg.Node(id); !ok {
+				return nil, fmt.Errorf("graph cache contains an unresolved unknown node %d", id)
+			}
+			pg.unresolved[id] = true
+		}
+	}
+	return pg, nil
+}
+
+// SaveGraphFile writes the graph cache to the file at path.
+func SaveGraphFile(path string, pg *PackageGraph) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+	return SaveGraph(file, pg)
+}
+
+// LoadGraphFile reads the graph cache at path.
+func LoadGraphFile(path string) (*PackageGraph, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return LoadGraph(file)
+}
+
+// LoadGraphFileWithOptions reads the graph cache at path like LoadGraphWithOptions.
+func LoadGraphFileWithOptions(path string, isUsingMaven bool, opts ...Option) (*PackageGraph, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return LoadGraphWithOptions(file, isUsingMaven, opts...)
+}
+
+// SavePackages writes the parsed packages to w in a binary form that LoadPackages reads much faster than the JSON
+// input. Like SaveGraph, saving the same packages twice writes the same bytes.
+func SavePackages(w io.Writer, packages []PackageInfo) error {
+	table := newStringTable()
+	file := packagesFile{FormatVersion: packagesFormatVersion, Records: make([]cachedPackage, 0, len(packages))}
+	for _, packageInfo := range packages {
+		file.Records = append(file.Records, cachePackage(table, packageInfo))
+	}
+	file.Strings = table.strings
+	buffered := bufio.NewWriter(w)
+	if _, err := buffered.WriteString(packagesMagic); err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(buffered).Encode(&file); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
+
+// LoadPackages reads the packages written by SavePackages.
+func LoadPackages(r io.Reader) ([]PackageInfo, error) {
+	buffered := bufio.NewReader(r)
+	magic := make([]byte, len(packagesMagic))
+	if _, err := io.ReadFull(buffered, magic); err != nil || string(magic) != packagesMagic {
+		return nil, ErrNotACache
+	}
+	var file packagesFile
+	if err := gob.NewDecoder(buffered).Decode(&file); err != nil {
+		return nil, fmt.Errorf("decoding packages: %w", err)
+	}
+	if file.FormatVersion != packagesFormatVersion {
+		return nil, fmt.Errorf("packages have format version %d, expected %d", file.FormatVersion, packagesFormatVersion)
+	}
+	return resolvePackages(file.Records, stringLookup(file.Strings))
+}
@@ -0,0 +1,226 @@
+package graph
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+// stringData returns the address of the bytes of s, to check whether two strings share their storage.
+func stringData(s string) uintptr {
+	return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+}
+
+func TestGraphCache(t *testing.T) {
+	t.Run("Loads the graph that was saved", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		pg := NewPackageGraph(&packagesInfo, false, WithDependencyClasses(Runtime, Development))
+		var buffer bytes.Buffer
+		if err := SaveGraph(&buffer, pg); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadGraph(&buffer)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		expected, actual := edgeSet(pg), edgeSet(loaded)
+		if len(actual) != len(expected) {
+			t.Fatalf("Expected %d edges, got %d", len(expected), len(actual))
+		}
+		for edge := range expected {
+			if !actual[edge] {
+				t.Errorf("Expected edge %v to be loaded", edge)
+			}
+		}
+		for _, node := range pg.Nodes {
+			if loadedNode, ok := loaded.Node(node.id); !ok || loadedNode != node {
+				t.Errorf("Expected node %v, got %v", node, loadedNode)
+			}
+		}
+		if constraint, _ := loaded.Constraint(pg.StringIDToNodeInfo["App-1.0.0"].id, pg.StringIDToNodeInfo["Test-1.0.0"].id); constraint != "1.0.0" {
+			t.Errorf("Expected the constraint 1.0.0, got %q", constraint)
+		}
+	})
+
+	t.Run("Keeps the settings for adding versions", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		pg := NewPackageGraph(&packagesInfo, false, WithResolution(ResolveHighest))
+		var buffer bytes.Buffer
+		if err := SaveGraph(&buffer, pg); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadGraph(&buffer)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if err := loaded.AddVersion("A", "1.3.0", VersionInfo{Dependencies: map[string]string{}}); err != nil {
+			t.Fatal(err)
+		}
+		edges := edgeSet(loaded)
+		if !edges[[2]string{"App-1.0.0", "A-1.3.0"}] || edges[[2]string{"App-1.0.0", "A-1.2.0"}] {
+			t.Errorf("Expected App 1.0.0 to be re-resolved to A 1.3.0, got %v", edges)
+		}
+	})
+
+	t.Run("Rejects other input", func(t *testing.T) {
+		if _, err := LoadGraph(strings.NewReader(`[{"name": "A"}]`)); !errors.Is(err, ErrNotACache) {
+			t.Errorf("Expected ErrNotACache, got %v", err)
+		}
+	})
+	t.Run("Serves name queries without rebuilding the indexes", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		pg := NewPackageGraph(&packagesInfo, false)
+		var buffer bytes.Buffer
+		if err := SaveGraph(&buffer, pg); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadGraph(&buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(loaded.NameToVersions, pg.NameToVersions) {
+			t.Errorf("Expected the versions %v, got %v", pg.NameToVersions, loaded.NameToVersions)
+		}
+		if !reflect.DeepEqual(loaded.ids, pg.ids) {
+			t.Errorf("Expected the IDs %v, got %v", pg.ids, loaded.ids)
+		}
+		expected, _ := pg.Dependents(NameVersion{Name: "A", Version: "1.0.0"}, -1)
+		if got, ok := loaded.Dependents(NameVersion{Name: "A", Version: "1.0.0"}, -1); !ok || !reflect.DeepEqual(got, expected) {
+			t.Errorf("Expected the dependents %v, got %v", expected, got)
+		}
+		if !reflect.DeepEqual(*loaded.Packages, *pg.Packages) {
+			t.Errorf("Expected the packages %v, got %v", *pg.Packages, *loaded.Packages)
+		}
+	})
+
+	t.Run("Stores every distinct string once", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		pg := NewPackageGraph(&packagesInfo, false)
+		var buffer bytes.Buffer
+		if err := SaveGraph(&buffer, pg); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadGraph(&buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		node, _ := loaded.FindNode(NameVersion{Name: "A", Version: "1.0.0"})
+		versionInfo, _ := loaded.VersionInfo(NameVersion{Name: "App", Version: "1.0.0"})
+		if stringData(node.Name) != stringData((*loaded.Packages)[loaded.packageIndex[NewPackageKey("", "A")]].Name) {
+			t.Error("Expected the node and the package to share the name")
+		}
+		if stringData(node.Version) != stringData(versionInfo.DevDependencies["Test"]) {
+			t.Error("Expected a version and an identical range to share their storage")
+		}
+	})
+
+	t.Run("Rejects caches built with other options", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		pg := NewPackageGraph(&packagesInfo, false, WithResolution(ResolveHighest))
+		var buffer bytes.Buffer
+		if err := SaveGraph(&buffer, pg); err != nil {
+			t.Fatal(err)
+		}
+		saved := buffer.Bytes()
+		_, err := LoadGraphWithOptions(bytes.NewReader(saved), false)
+		var mismatch *OptionMismatchError
+		if !errors.As(err, &mismatch) || mismatch.Option != "resolution" || mismatch.Cached != "highest" || mismatch.Requested != "all" {
+			t.Fatalf("Expected a resolution mismatch, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "resolution highest") {
+			t.Errorf("Expected the message to name the option, got %q", err.Error())
+		}
+		logger := &recordingLogger{}
+		loaded, err := LoadGraphWithOptions(bytes.NewReader(saved), false, WithResolution(ResolveHighest), WithLogger(logger))
+		if err != nil {
+			t.Fatalf("Expected no error for the same options, got %v", err)
+		}
+		if loaded.options.Logger != logger {
+			t.Error("Expected the requested logger to be used")
+		}
+	})
+
+	t.Run("Rejects corrupt sections", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		pg := NewPackageGraph(&packagesInfo, false)
+		var buffer bytes.Buffer
+		if err := SaveGraph(&buffer, pg); err != nil {
+			t.Fatal(err)
+		}
+		saved := buffer.Bytes()
+		saved[bytes.Index(saved, []byte("Test"))] = 'B'
+		if _, err := LoadGraph(bytes.NewReader(saved)); err == nil || !strings.Contains(err.Error(), "section strings is corrupt") {
+			t.Errorf("Expected a checksum error, got %v", err)
+		}
+	})
+
+	t.Run("Writes the same bytes for the same graph", func(t *testing.T) {
+		var caches, packages [2]bytes.Buffer
+		for i := range caches {
+			packagesInfo := testPackages("options")
+			pg := NewPackageGraph(&packagesInfo, false, WithDependencyClasses(Runtime, Development))
+			if err := SaveGraph(&caches[i], pg); err != nil {
+				t.Fatal(err)
+			}
+			if err := SavePackages(&packages[i], packagesInfo); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if !bytes.Equal(caches[0].Bytes(), caches[1].Bytes()) {
+			t.Errorf("Expected the caches of the same graph to be equal")
+		}
+		if !bytes.Equal(packages[0].Bytes(), packages[1].Bytes()) {
+			t.Errorf("Expected the saved packages to be equal")
+		}
+		loaded, err := LoadPackages(&packages[0])
+		if expected := testPackages("options"); err != nil || !reflect.DeepEqual(loaded, expected) {
+			t.Errorf("Expected the packages %v, got %v and %v", expected, loaded, err)
+		}
+	})
+
+	t.Run("Loads caches of the previous format", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		pg := NewPackageGraph(&packagesInfo, false, WithResolution(ResolveHighest))
+		cache := legacyCache{FormatVersion: legacyCacheFormatVersion, Resolution: ResolveHighest, DependencyClasses: []DependencyClass{Runtime}, Prereleases: IncludeIfRangeHasPrerelease, Packages: *pg.Packages}
+		for _, node := range pg.Nodes {
+			cache.Nodes = append(cache.Nodes, legacyNode{node.id, node.Name, node.Version, node.Timestamp, node.License, node.Deprecated})
+		}
+		for edge := range edgeSet(pg) {
+			cache.Edges = append(cache.Edges, [2]int64{pg.StringIDToNodeInfo[edge[0]].id, pg.StringIDToNodeInfo[edge[1]].id})
+		}
+		var buffer bytes.Buffer
+		writer := bufio.NewWriter(&buffer)
+		writer.WriteString(legacyCacheMagic)
+		if err := gob.NewEncoder(writer).Encode(&cache); err != nil {
+			t.Fatal(err)
+		}
+		writer.Flush()
+		loaded, err := LoadGraphWithOptions(&buffer, false, WithResolution(ResolveHighest))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(edgeSet(loaded), edgeSet(pg)) {
+			t.Errorf("Expected the edges %v, got %v", edgeSet(pg), edgeSet(loaded))
+		}
+
+		cache.FormatVersion = legacyCacheFormatVersion - 1
+		buffer.Reset()
+		writer.Reset(&buffer)
+		writer.WriteString(legacyCacheMagic)
+		if err := gob.NewEncoder(writer).Encode(&cache); err != nil {
+			t.Fatal(err)
+		}
+		writer.Flush()
+		expected := fmt.Sprintf("format version %d, expected %d", legacyCacheFormatVersion-1, legacyCacheFormatVersion)
+		if _, err := LoadGraph(&buffer); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected an error with %q, got %v", expected, err)
+		}
+	})
+}
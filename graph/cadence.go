@@ -0,0 +1,82 @@
+package graph
+
+import (
+	"sort"
+	"time"
+)
+
+// CadenceRow is the release cadence of a package.
+type CadenceRow struct {
+	Name string
+	// Versions is the number of versions of the package, including those without a parseable timestamp.
+	Versions int
+	// MedianGapDays and MeanGapDays summarize the time between consecutive releases in days, over the versions with a
+	// parseable timestamp, in release order.
+	MedianGapDays float64
+	MeanGapDays   float64
+}
+
+// VersionCountHistogram counts the packages by their number of versions, so the result maps a number of versions to
+// the number of packages that have as many.
+func VersionCountHistogram(packages []PackageInfo) map[int]int {
+	histogram := make(map[int]int)
+	for _, packageInfo := range packages {
+		histogram[len(packageInfo.Versions)]++
+	}
+	return histogram
+}
+
+// HistogramBin is a bin of VersionCountHistogram: the number of packages that have as many versions.
+type HistogramBin struct {
+	Versions int `json:"versions"`
+	Packages int `json:"packages"`
+}
+
+// SortedHistogram returns the bins of a histogram of VersionCountHistogram in increasing number of versions.
+func SortedHistogram(histogram map[int]int) []HistogramBin {
+	bins := make([]HistogramBin, 0, len(histogram))
+	for versions, packages := range histogram {
+		bins = append(bins, HistogramBin{Versions: versions, Packages: packages})
+	}
+	sort.Slice(bins, func(i, j int) bool { return bins[i].Versions < bins[j].Versions })
+	return bins
+}
+
+// ReleaseCadence computes the time between consecutive releases of every package, sorted by package name. Only the
+// versions whose timestamp can be parsed are taken into account, and the packages with fewer than two of them are
+// left out; their number is returned separately.
+func ReleaseCadence(packages []PackageInfo) (rows []CadenceRow, skipped int) {
+	const day = float64(24 * time.Hour)
+	for _, packageInfo := range packages {
+		var released []time.Time
+		for _, versionInfo := range packageInfo.Versions {
+			if t, err := ParseTimestamp(versionInfo.Timestamp); err == nil {
+				released = append(released, t)
+			}
+		}
+		if len(released) < 2 {
+			skipped++
+			continue
+		}
+		sort.Slice(released, func(i, j int) bool { return released[i].Before(released[j]) })
+		gaps := make([]float64, len(released)-1)
+		total := 0.0
+		for i := range gaps {
+			gaps[i] = float64(released[i+1].Sub(released[i])) / day
+			total += gaps[i]
+		}
+		sort.Float64s(gaps)
+		median := gaps[len(gaps)/2]
+		if len(gaps)%2 == 0 {
+			median = (gaps[len(gaps)/2-1] + median) / 2
+		}
+		rows = append(rows, CadenceRow{
+			Name:          packageInfo.Name,
+			Versions:      len(packageInfo.Versions),
+			MedianGapDays: median,
+			MeanGapDays:   total / float64(len(gaps)),
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Name < rows[j].Name })
+	return rows, skipped
+}
@@ -0,0 +1,60 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReleaseCadence(t *testing.T) {
+	version := func(timestamp string) VersionInfo {
+		return VersionInfo{Timestamp: timestamp, Dependencies: map[string]string{}}
+	}
+	packagesInfo := []PackageInfo{
+		{Name: "B", Versions: map[string]VersionInfo{
+			"1.0.0": version("2020-01-01T00:00:00"),
+			"1.1.0": version("2020-01-11T00:00:00"),
+			"1.2.0": version("2020-01-13T00:00:00"),
+			"1.3.0": version("2020-01-31T00:00:00"),
+			"2.0.0": version("not a timestamp"),
+		}},
+		{Name: "A", Versions: map[string]VersionInfo{
+			"1.0.0": version("2020-01-03T00:00:00"),
+			"2.0.0": version("2020-01-01T00:00:00"),
+		}},
+		{Name: "C", Versions: map[string]VersionInfo{
+			"1.0.0": version("2020-01-01T00:00:00"),
+			"1.1.0": version(""),
+		}},
+	}
+
+	t.Run("Counts the packages by their number of versions", func(t *testing.T) {
+		expected := map[int]int{5: 1, 2: 2}
+		if histogram := VersionCountHistogram(packagesInfo); !reflect.DeepEqual(histogram, expected) {
+			t.Errorf("Expected the histogram %v, got %v", expected, histogram)
+		}
+		if bins := SortedHistogram(VersionCountHistogram(packagesInfo)); !reflect.DeepEqual(bins, []HistogramBin{{2, 2}, {5, 1}}) {
+			t.Errorf("Expected the bins in increasing number of versions, got %v", bins)
+		}
+	})
+
+	rows, skipped := ReleaseCadence(packagesInfo)
+
+	t.Run("Skips the packages with fewer than two timestamps", func(t *testing.T) {
+		if skipped != 1 {
+			t.Errorf("Expected 1 skipped package, got %d", skipped)
+		}
+		if len(rows) != 2 || rows[0].Name != "A" || rows[1].Name != "B" {
+			t.Fatalf("Expected the rows of A and B, got %v", rows)
+		}
+	})
+
+	t.Run("Computes the gaps in release order", func(t *testing.T) {
+		if rows[0].MedianGapDays != 2 || rows[0].MeanGapDays != 2 {
+			t.Errorf("Expected a gap of 2 days for A, got %+v", rows[0])
+		}
+		// The gaps of B are 10, 2 and 18 days.
+		if rows[1].Versions != 5 || rows[1].MedianGapDays != 10 || rows[1].MeanGapDays != 10 {
+			t.Errorf("Expected 5 versions with a median and mean gap of 10 days for B, got %+v", rows[1])
+		}
+	})
+}
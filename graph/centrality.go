@@ -0,0 +1,201 @@
+package graph
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"sort"
+
+	"gonum.org/v1/gonum/graph"
+)
+
+// RankedNode is a node together with the score it was ranked by.
+type RankedNode struct {
+	NodeInfo
+	Score float64
+}
+
+// MarshalJSON encodes the ranked node like NodeInfo, with an additional score. Without it, the MarshalJSON method of
+// the embedded NodeInfo would leave the score out.
+func (rankedNode RankedNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		ID        int64   `json:"id"`
+		Name      string  `json:"name"`
+		Version   string  `json:"version"`
+		Timestamp string  `json:"timestamp"`
+		License   string  `json:"license,omitempty"`
+		Score     float64 `json:"score"`
+	}{rankedNode.id, rankedNode.Name, rankedNode.Version, rankedNode.Timestamp, rankedNode.License, rankedNode.Score})
+}
+
+// TopNodes joins the scores with the node information and returns the n nodes with the highest score. Ties are broken
+// by name, then by version in semver order and finally by node ID, so that the ranking does not depend on the ID
+// scheme. A negative n returns all the scored nodes.
+func TopNodes(scores map[int64]float64, nodeMap map[int64]NodeInfo, n int) []RankedNode {
+	ranked := make([]RankedNode, 0, len(scores))
+	for id, score := range scores {
+		if info, ok := nodeMap[id]; ok {
+			ranked = append(ranked, RankedNode{NodeInfo: info, Score: score})
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		return rankedBefore(ranked[i].Score, ranked[j].Score, ranked[i].NodeInfo, ranked[j].NodeInfo)
+	})
+	if n >= 0 && n < len(ranked) {
+		ranked = ranked[:n]
+	}
+	return ranked
+}
+
+// rankedBefore reports whether the node a with score scoreA is ranked before the node b with score scoreB, in the
+// order of TopNodes.
+func rankedBefore(scoreA, scoreB float64, a, b NodeInfo) bool {
+	switch {
+	case scoreA != scoreB:
+		return scoreA > scoreB
+	case a.Name != b.Name:
+		return a.Name < b.Name
+	case a.Version != b.Version:
+		return versionLess(a.Version, b.Version)
+	}
+	return a.id < b.id
+}
+
+// PageRank computes the PageRank of the nodes of g like network.PageRankSparse, with the damping factor damp and
+// iterating until the 2-norm of the difference between two iterations is below tol. Unlike it, the iteration starts
+// from the uniform distribution rather than a random one and visits the nodes in increasing ID order, so the scores
+// only depend on the graph, down to the last bit.
+func PageRank(g graph.Directed, damp, tol float64) map[int64]float64 {
+	nodes := graph.NodesOf(g.Nodes())
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID() < nodes[j].ID() })
+	indexOf := make(map[int64]int, len(nodes))
+	for i, node := range nodes {
+		indexOf[node.ID()] = i
+	}
+	// targets holds the indices of the nodes that every node links to. Every node receives at most one share per
+	// node linking to it, and receives them in the order of the nodes, whatever the order of the targets.
+	targets := make([][]int, len(nodes))
+	for i, node := range nodes {
+		for to := g.From(node.ID()); to.Next(); {
+			targets[i] = append(targets[i], indexOf[to.Node().ID()])
+		}
+	}
+
+	n := float64(len(nodes))
+	rank := make([]float64, len(nodes))
+	for i := range rank {
+		rank[i] = 1 / n
+	}
+	next := make([]float64, len(nodes))
+	for len(nodes) > 0 {
+		// The rank of the nodes without links is spread over all nodes, like the part of every rank that is not damped.
+		dangling, total := 0.0, 0.0
+		for i, r := range rank {
+			if len(targets[i]) == 0 {
+				dangling += r
+			}
+			total += r
+		}
+		base := (damp*dangling + (1-damp)*total) / n
+		for i := range next {
+			next[i] = base
+		}
+		for i, r := range rank {
+			share := damp * r / float64(len(targets[i]))
+			for _, target := range targets[i] {
+				next[target] += share
+			}
+		}
+		diff := 0.0
+		for i := range next {
+			diff += (next[i] - rank[i]) * (next[i] - rank[i])
+		}
+		rank, next = next, rank
+		if math.Sqrt(diff) < tol {
+			break
+		}
+	}
+
+	ranks := make(map[int64]float64, len(nodes))
+	for i, node := range nodes {
+		ranks[node.ID()] = rank[i]
+	}
+	return ranks
+}
+
+// ApproxBetweenness estimates the betweenness centrality of the nodes in g by running Brandes' algorithm from a random
+// sample of source nodes and scaling the accumulated dependencies by nodes/samples. The sample only depends on the
+// seed, so the result is deterministic. If samples is at least the number of nodes, the exact betweenness is returned.
+// Like network.Betweenness, only non-zero scores are included in the result.
+//
+// Each sampled source costs one BFS over the part of the graph reachable from it, so the total cost is
+// O(samples * (V + E)) in the worst case.
+func ApproxBetweenness(g graph.Directed, samples int, seed int64) map[int64]float64 {
+	nodes := graph.NodesOf(g.Nodes())
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID() < nodes[j].ID() })
+
+	sources := nodes
+	if samples < len(nodes) {
+		rnd := rand.New(rand.NewSource(seed))
+		perm := rnd.Perm(len(nodes))
+		sources = make([]graph.Node, samples)
+		for i := range sources {
+			sources[i] = nodes[perm[i]]
+		}
+	}
+	scale := 1.0
+	if len(sources) > 0 {
+		scale = float64(len(nodes)) / float64(len(sources))
+	}
+
+	var (
+		betweenness  = make(map[int64]float64)
+		predecessors = make(map[int64][]int64)
+		sigma        = make(map[int64]float64)
+		distance     = make(map[int64]int)
+		delta        = make(map[int64]float64)
+		order        []int64
+	)
+	for _, source := range sources {
+		// Only the nodes reached from the previous source have to be reset.
+		for _, id := range order {
+			delete(predecessors, id)
+			delete(sigma, id)
+			delete(distance, id)
+			delete(delta, id)
+		}
+		order = order[:0]
+
+		sourceID := source.ID()
+		sigma[sourceID] = 1
+		distance[sourceID] = 0
+		order = append(order, sourceID)
+		for head := 0; head < len(order); head++ {
+			v := order[head]
+			successors := g.From(v)
+			for successors.Next() {
+				w := successors.Node().ID()
+				if _, seen := distance[w]; !seen {
+					distance[w] = distance[v] + 1
+					order = append(order, w)
+				}
+				if distance[w] == distance[v]+1 {
+					sigma[w] += sigma[v]
+					predecessors[w] = append(predecessors[w], v)
+				}
+			}
+		}
+
+		// The BFS order reversed visits nodes in non-increasing distance from the source.
+		for i := len(order) - 1; i >= 0; i-- {
+			w := order[i]
+			for _, v := range predecessors[w] {
+				delta[v] += sigma[v] / sigma[w] * (1 + delta[w])
+			}
+			if w != sourceID && delta[w] != 0 {
+				betweenness[w] += delta[w] * scale
+			}
+		}
+	}
+	return betweenness
+}
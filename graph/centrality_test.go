@@ -0,0 +1,112 @@
+package graph
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"gonum.org/v1/gonum/graph/network"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+func createBridgeTestGraph() *simple.DirectedGraph {
+	// Two dependents reach two dependencies only through the bridge node 2.
+	graph := simple.NewDirectedGraph()
+	edges := [][2]int64{{0, 2}, {1, 2}, {2, 3}, {2, 4}, {3, 5}, {4, 5}, {0, 6}}
+	for _, edge := range edges {
+		graph.SetEdge(simple.Edge{F: simple.Node(edge[0]), T: simple.Node(edge[1])})
+	}
+	return graph
+}
+
+func TestApproxBetweenness(t *testing.T) {
+	graph := createBridgeTestGraph()
+
+	t.Run("Is exact when every node is sampled", func(t *testing.T) {
+		expected := network.Betweenness(graph)
+		actual := ApproxBetweenness(graph, graph.Nodes().Len(), 1)
+		if len(actual) != len(expected) {
+			t.Errorf("Expected %d scored nodes, got %d", len(expected), len(actual))
+		}
+		for id, score := range expected {
+			if math.Abs(actual[id]-score) > 1e-9 {
+				t.Errorf("Expected betweenness %f for node %d, got %f", score, id, actual[id])
+			}
+		}
+	})
+
+	t.Run("Is deterministic given the seed", func(t *testing.T) {
+		first := ApproxBetweenness(graph, 3, 42)
+		second := ApproxBetweenness(graph, 3, 42)
+		if len(first) != len(second) {
+			t.Fatalf("Expected the same number of scores, got %d and %d", len(first), len(second))
+		}
+		for id, score := range first {
+			if second[id] != score {
+				t.Errorf("Expected the same score for node %d, got %f and %f", id, score, second[id])
+			}
+		}
+	})
+
+	t.Run("Ranks the bridge first", func(t *testing.T) {
+		nodeMap := make(map[int64]NodeInfo)
+		for id := int64(0); id < 7; id++ {
+			nodeMap[id] = *NewNodeInfo(id, "P", string(rune('a'+id)), "")
+		}
+		top := TopNodes(ApproxBetweenness(graph, 7, 1), nodeMap, 1)
+		if len(top) != 1 || top[0].id != 2 {
+			t.Errorf("Expected node 2 to be ranked first, got %v", top)
+		}
+	})
+}
+
+func TestTopNodes(t *testing.T) {
+	nodeMap := map[int64]NodeInfo{
+		0: {id: 0, Name: "B", Version: "1.0.0"},
+		1: {id: 1, Name: "A", Version: "1.10.0"},
+		2: {id: 2, Name: "A", Version: "1.9.0"},
+		3: {id: 3, Name: "C", Version: "1.0.0"},
+	}
+	scores := map[int64]float64{0: 1, 1: 1, 2: 1, 3: 2}
+	var order []int64
+	for _, node := range TopNodes(scores, nodeMap, -1) {
+		order = append(order, node.ID())
+	}
+	if expected := []int64{3, 2, 1, 0}; !reflect.DeepEqual(order, expected) {
+		t.Errorf("Expected the ties by name and version, %v, got %v", expected, order)
+	}
+}
+
+func TestPageRank(t *testing.T) {
+	graph := createBridgeTestGraph()
+
+	t.Run("Converges to the ranks of gonum", func(t *testing.T) {
+		expected := network.PageRankSparse(graph, 0.85, 1e-9)
+		actual := PageRank(graph, 0.85, 1e-9)
+		if len(actual) != len(expected) {
+			t.Errorf("Expected %d ranked nodes, got %d", len(expected), len(actual))
+		}
+		for id, rank := range expected {
+			if math.Abs(actual[id]-rank) > 1e-6 {
+				t.Errorf("Expected rank %f for node %d, got %f", rank, id, actual[id])
+			}
+		}
+	})
+
+	t.Run("Is deterministic", func(t *testing.T) {
+		first := PageRank(graph, 0.85, 1e-6)
+		for run := 0; run < 10; run++ {
+			for id, rank := range PageRank(graph, 0.85, 1e-6) {
+				if rank != first[id] {
+					t.Fatalf("Expected rank %v for node %d on every run, got %v", first[id], id, rank)
+				}
+			}
+		}
+	})
+
+	t.Run("Ranks an empty graph", func(t *testing.T) {
+		if ranks := PageRank(simple.NewDirectedGraph(), 0.85, 1e-6); len(ranks) != 0 {
+			t.Errorf("Expected no ranks, got %v", ranks)
+		}
+	})
+}
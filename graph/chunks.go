@@ -0,0 +1,56 @@
+package graph
+
+import (
+	"sync"
+)
+
+// ParseJSONParallel parses the JSON arrays of packages in the chunk files at paths, such as a dump that was split in
+// advance, decoding up to workers files at once. The packages are concatenated in the order of the paths, so the result
+// is the same as parsing the files one after the other with ReadPackagesJSON, whatever the number of workers. If any
+// file cannot be read, the error of the first such file in the order of the paths is returned.
+//
+// Every worker interns the packages it decodes with its own Interner, so that the workers never wait for each other. A
+// string found in the chunks of several workers is therefore kept once per worker rather than once overall, which
+// costs little next to the savings of interning, as the strings that repeat the most, such as common versions and
+// ranges, are few.
+func ParseJSONParallel(paths []string, workers int) ([]PackageInfo, error) {
+	if workers > len(paths) {
+		workers = len(paths)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	options := newOptions(nil)
+	chunks := make([][]PackageInfo, len(paths))
+	errs := make([]error, len(paths))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			interner := NewInterner()
+			for i := range indices {
+				chunks[i], errs[i] = readPackagesJSON(paths[i], interner, options, 0)
+			}
+		}()
+	}
+	for i := range paths {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	total := 0
+	for i, chunk := range chunks {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		total += len(chunk)
+	}
+	result := make([]PackageInfo, 0, total)
+	for _, chunk := range chunks {
+		result = append(result, chunk...)
+	}
+	return result, nil
+}
@@ -0,0 +1,82 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeChunks splits the packages into the given number of files of about the same size and returns their paths.
+func writeChunks(t *testing.T, packagesInfo []PackageInfo, chunks int) []string {
+	t.Helper()
+	dir := t.TempDir()
+	var paths []string
+	for i := 0; i < chunks; i++ {
+		encoded, err := json.Marshal(packagesInfo[i*len(packagesInfo)/chunks : (i+1)*len(packagesInfo)/chunks])
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, fmt.Sprintf("chunk-%d.json", i))
+		if err := os.WriteFile(path, encoded, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestParseJSONParallel(t *testing.T) {
+	var packagesInfo []PackageInfo
+	for i := 0; i < 50; i++ {
+		versions := make(map[string]VersionInfo)
+		for j := 0; j < i%3+1; j++ {
+			versions[fmt.Sprintf("1.%d.0", j)] = VersionInfo{
+				Timestamp:    "2021-04-22T20:15:37",
+				Dependencies: map[string]string{fmt.Sprintf("P%d", i/2): "^1.0.0"},
+				License:      "MIT",
+			}
+		}
+		packagesInfo = append(packagesInfo, PackageInfo{Name: fmt.Sprintf("P%d", i), Versions: versions})
+	}
+	paths := writeChunks(t, packagesInfo, 8)
+
+	var sequential []PackageInfo
+	for _, path := range paths {
+		chunk, err := ReadPackagesJSON(path, NewInterner())
+		if err != nil {
+			t.Fatal(err)
+		}
+		sequential = append(sequential, *chunk...)
+	}
+
+	t.Run("Concatenates the chunks in order", func(t *testing.T) {
+		for _, workers := range []int{0, 1, 3, 8, 20} {
+			parsed, err := ParseJSONParallel(paths, workers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(parsed, sequential) {
+				t.Errorf("Expected the packages of a sequential parse with %d workers", workers)
+			}
+		}
+	})
+
+	t.Run("Returns the error of the first chunk that fails", func(t *testing.T) {
+		broken := append([]string(nil), paths...)
+		broken[2] = filepath.Join(t.TempDir(), "missing.json")
+		broken[5] = filepath.Join(t.TempDir(), "also-missing.json")
+		if _, err := ParseJSONParallel(broken, 4); err == nil || !strings.Contains(err.Error(), "missing.json") || strings.Contains(err.Error(), "also") {
+			t.Errorf("Expected the error of the third chunk, got %v", err)
+		}
+	})
+
+	t.Run("Parses no chunks", func(t *testing.T) {
+		if parsed, err := ParseJSONParallel(nil, 4); err != nil || len(parsed) != 0 {
+			t.Errorf("Expected no packages, got %d and %v", len(parsed), err)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package graph
+
+import (
+	"sort"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// PackageLevelGraph is the graph obtained by collapsing all the versions of every package into a single node, for the
+// analyses that ask which packages depend on which regardless of the versions. It does not follow later changes to
+// the graph.
+type PackageLevelGraph struct {
+	// Graph has one node per package, whose ID is the index of the package in Names. Its edges point from dependents
+	// to dependencies, and the weight of an edge is the sum of the weights of the version edges between the two
+	// packages, so that it counts the dependency declarations that resolve between them.
+	Graph *simple.WeightedDirectedGraph
+	// Names holds the package names, sorted, with the names of the packages of an ecosystem qualified, see
+	// QualifiedName.
+	Names []string
+
+	// merged counts the version edges behind every package edge.
+	merged map[[2]int64]int
+}
+
+// CollapsePackages collapses the versions of every package of the graph into a single node. The edges between versions
+// of the same package are left out, as a package does not depend on itself. The edges of a graph with lazy edges are
+// those created so far.
+func CollapsePackages(pg *PackageGraph) *PackageLevelGraph {
+	if pg.lockEdges() {
+		defer pg.lazyMutex.Unlock()
+	}
+	collapsed := &PackageLevelGraph{
+		Graph:  simple.NewWeightedDirectedGraph(0, 0),
+		Names:  make([]string, 0, len(pg.NameToVersions)),
+		merged: make(map[[2]int64]int),
+	}
+	keys := make(map[string]PackageKey, len(pg.NameToVersions))
+	for key := range pg.NameToVersions {
+		keys[key.String()] = key
+		collapsed.Names = append(collapsed.Names, key.String())
+	}
+	sort.Strings(collapsed.Names)
+	packageIDs := make(map[PackageKey]int64, len(collapsed.Names))
+	for i, name := range collapsed.Names {
+		packageIDs[keys[name]] = int64(i)
+		collapsed.Graph.AddNode(simple.Node(i))
+	}
+
+	weights := make(map[[2]int64]int)
+	edges := pg.Graph.Edges()
+	for edges.Next() {
+		from, to := pg.DependencyEdge(edges.Edge().From().ID(), edges.Edge().To().ID())
+		fromPackage, toPackage := packageIDs[pg.node(from).key()], packageIDs[pg.node(to).key()]
+		if fromPackage == toPackage {
+			continue
+		}
+		edge := [2]int64{fromPackage, toPackage}
+		weights[edge] += pg.EdgeWeight(from, to)
+		collapsed.merged[edge]++
+	}
+	for edge, weight := range weights {
+		collapsed.Graph.SetWeightedEdge(simple.WeightedEdge{F: simple.Node(edge[0]), T: simple.Node(edge[1]), W: float64(weight)})
+	}
+	return collapsed
+}
+
+// Package returns the node ID of the package with the given name, and false if it is not part of the graph.
+func (collapsed *PackageLevelGraph) Package(name string) (int64, bool) {
+	i := sort.SearchStrings(collapsed.Names, name)
+	if i == len(collapsed.Names) || collapsed.Names[i] != name {
+		return 0, false
+	}
+	return int64(i), true
+}
+
+// Weight returns the weight of the edge from one package to another, or 0 if there is none.
+func (collapsed *PackageLevelGraph) Weight(from, to int64) int {
+	weight, _ := collapsed.Graph.Weight(from, to)
+	return int(weight)
+}
+
+// Merged returns the number of version edges that were collapsed into the edge from one package to another, or 0 if
+// there is none. It is at most the weight, which also counts the declarations that resolve to the same versions.
+func (collapsed *PackageLevelGraph) Merged(from, to int64) int {
+	return collapsed.merged[[2]int64{from, to}]
+}
@@ -0,0 +1,138 @@
+package graph
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// weightSet returns the weights of the edges of the graph that have a weight above 1, by the stringIDs of their ends.
+func weightSet(pg *PackageGraph) map[[2]string]int {
+	weights := make(map[[2]string]int)
+	edges := pg.Graph.Edges()
+	for edges.Next() {
+		from, to := edges.Edge().From().ID(), edges.Edge().To().ID()
+		if weight := pg.EdgeWeight(from, to); weight > 1 {
+			weights[[2]string{pg.node(from).stringID, pg.node(to).stringID}] = weight
+		}
+	}
+	return weights
+}
+
+func TestEdgeWeights(t *testing.T) {
+	classes := WithDependencyClasses(Runtime, Peer)
+	expected := map[[2]string]int{{"App-1.0.0", "Lib-1.1.0"}: 2, {"App-1.0.0", "Lib-1.2.0"}: 2}
+
+	t.Run("Counts the declarations that resolve to the same version", func(t *testing.T) {
+		packagesInfo := testPackages("weights")
+		pg := NewPackageGraph(&packagesInfo, false, classes)
+		if weights := weightSet(pg); !reflect.DeepEqual(weights, expected) {
+			t.Errorf("Expected %v, got %v", expected, weights)
+		}
+		app, _ := pg.FindNode(NameVersion{Name: "App", Version: "1.0.0"})
+		lib, _ := pg.FindNode(NameVersion{Name: "Lib", Version: "1.0.0"})
+		if weight := pg.EdgeWeight(app.id, lib.id); weight != 1 {
+			t.Errorf("Expected weight 1, got %d", weight)
+		}
+	})
+
+	t.Run("Only counts the classes that create edges", func(t *testing.T) {
+		packagesInfo := testPackages("weights")
+		pg := NewPackageGraph(&packagesInfo, false)
+		if weights := weightSet(pg); len(weights) != 0 {
+			t.Errorf("Expected no weights above 1, got %v", weights)
+		}
+	})
+
+	t.Run("Weights the lazy edges like the eager ones", func(t *testing.T) {
+		packagesInfo := testPackages("weights")
+		pg := NewPackageGraph(&packagesInfo, false, classes, WithLazyEdges())
+		if err := pg.ResolveDependencies("App"); err != nil {
+			t.Fatal(err)
+		}
+		if weights := weightSet(pg); !reflect.DeepEqual(weights, expected) {
+			t.Errorf("Expected %v, got %v", expected, weights)
+		}
+	})
+
+	t.Run("Weights the edges of added versions like a rebuilt graph", func(t *testing.T) {
+		for _, resolution := range []Resolution{ResolveAll, ResolveHighest} {
+			packagesInfo := testPackages("weights")
+			rebuilt := NewPackageGraph(&packagesInfo, false, classes, WithResolution(resolution))
+			packagesInfo = testPackages("weights")
+			added := packagesInfo[1].Versions["1.2.0"]
+			delete(packagesInfo[1].Versions, "1.2.0")
+			pg := NewPackageGraph(&packagesInfo, false, classes, WithResolution(resolution))
+			if err := pg.AddVersion("Lib", "1.2.0", added); err != nil {
+				t.Fatal(err)
+			}
+			if weights, expected := weightSet(pg), weightSet(rebuilt); !reflect.DeepEqual(weights, expected) {
+				t.Errorf("Expected %v, got %v", expected, weights)
+			}
+		}
+	})
+
+	t.Run("Removes the weights of removed versions", func(t *testing.T) {
+		packagesInfo := testPackages("weights")
+		pg := NewPackageGraph(&packagesInfo, false, classes)
+		if err := pg.RemoveVersion("Lib", "1.1.0", false); err != nil {
+			t.Fatal(err)
+		}
+		if weights := weightSet(pg); len(weights) != 1 || len(pg.weights) != 1 {
+			t.Errorf("Expected a single weight above 1, got %v", weights)
+		}
+	})
+
+	t.Run("Keeps the weights in the cache", func(t *testing.T) {
+		packagesInfo := testPackages("weights")
+		pg := NewPackageGraph(&packagesInfo, false, classes)
+		var cache bytes.Buffer
+		if err := SaveGraph(&cache, pg); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadGraph(&cache)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if weights := weightSet(loaded); !reflect.DeepEqual(weights, expected) {
+			t.Errorf("Expected %v, got %v", expected, weights)
+		}
+	})
+}
+
+func TestCollapsePackages(t *testing.T) {
+	packagesInfo := testPackages("weights")
+	pg := NewPackageGraph(&packagesInfo, false, WithDependencyClasses(Runtime, Peer))
+	collapsed := CollapsePackages(pg)
+
+	t.Run("Has a node per package", func(t *testing.T) {
+		if expected := []string{"App", "Lib", "Util"}; !reflect.DeepEqual(collapsed.Names, expected) {
+			t.Errorf("Expected %v, got %v", expected, collapsed.Names)
+		}
+		if nodes := collapsed.Graph.Nodes().Len(); nodes != 3 {
+			t.Errorf("Expected 3 nodes, got %d", nodes)
+		}
+	})
+
+	t.Run("Sums the weights of the version edges", func(t *testing.T) {
+		app, _ := collapsed.Package("App")
+		lib, _ := collapsed.Package("Lib")
+		util, _ := collapsed.Package("Util")
+		// App 1.0.0 reaches Lib 1.0.0 once and Lib 1.1.0 and 1.2.0 twice, and App 2.0.0 reaches Lib 1.0.0 once.
+		if weight, merged := collapsed.Weight(app, lib), collapsed.Merged(app, lib); weight != 6 || merged != 4 {
+			t.Errorf("Expected weight 6 from 4 version edges, got %d from %d", weight, merged)
+		}
+		if weight := collapsed.Weight(app, util); weight != 1 {
+			t.Errorf("Expected weight 1, got %d", weight)
+		}
+		if edges := collapsed.Graph.Edges().Len(); edges != 2 {
+			t.Errorf("Expected 2 edges without the edge of Lib on itself, got %d", edges)
+		}
+	})
+
+	t.Run("Does not find unknown packages", func(t *testing.T) {
+		if _, ok := collapsed.Package("Missing"); ok {
+			t.Error("Expected Missing not to be found")
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package graph
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// TestConcurrentQueries runs the query methods from many goroutines at once. Run it with -race to check that they
+// only read the graph.
+func TestConcurrentQueries(t *testing.T) {
+	packagesInfo := testPackages("popular")
+	pg := NewPackageGraph(&packagesInfo, false, WithResolution(ResolveHighest))
+	expectedStats := pg.Stats()
+
+	var wg sync.WaitGroup
+	errors := make(chan error, 16)
+	for worker := 0; worker < 16; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				dependency := NameVersion{Name: "popular", Version: fmt.Sprintf("0.%d.%d", (worker+i)/10%5, (worker+i)%10)}
+				dependents, ok := pg.Dependents(dependency, -1)
+				if !ok {
+					errors <- fmt.Errorf("expected %s to be part of the graph", dependency)
+					return
+				}
+				if len(dependents) > 0 {
+					from := NameVersion{Name: dependents[0].Name, Version: dependents[0].Version}
+					if _, ok := pg.ShortestPath(from, dependency); !ok {
+						errors <- fmt.Errorf("expected a path from %s to %s", from, dependency)
+						return
+					}
+				}
+				if stats := pg.Stats(); stats != expectedStats {
+					errors <- fmt.Errorf("expected stats %+v, got %+v", expectedStats, stats)
+					return
+				}
+				info, _ := pg.FindNode(dependency)
+				if reach := pg.Reachability().Dependents(info.id); len(reach) != len(dependents) {
+					errors <- fmt.Errorf("expected %d dependents of %s from the index, got %d", len(dependents), dependency, len(reach))
+					return
+				}
+				pg.Versions("popular")
+				pg.NodeMap()
+			}
+		}(worker)
+	}
+	wg.Wait()
+	close(errors)
+	for err := range errors {
+		t.Error(err)
+	}
+}
+
+// TestConcurrentConstraintQueries runs the queries on the constraint index of a loaded graph, which builds it on first
+// use, from many goroutines at once.
+func TestConcurrentConstraintQueries(t *testing.T) {
+	packagesInfo := testPackages("popular")
+	var cache bytes.Buffer
+	if err := SaveGraph(&cache, NewPackageGraph(&packagesInfo, false)); err != nil {
+		t.Fatal(err)
+	}
+	pg, err := LoadGraph(&cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	counts := make([]int, 16)
+	for worker := range counts {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			counts[worker] = len(pg.ConstraintsOn("popular"))
+			UpgradeAcceptance(pg, "popular")
+			pg.MemoryStats()
+		}(worker)
+	}
+	wg.Wait()
+	for worker, count := range counts {
+		if count != counts[0] || count == 0 {
+			t.Errorf("Expected the same declarations in every goroutine, got %d in goroutine %d and %d in the first", count, worker, counts[0])
+		}
+	}
+}
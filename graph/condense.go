@@ -0,0 +1,156 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+	"gonum.org/v1/gonum/graph/topo"
+)
+
+// Condensation is the DAG obtained by collapsing every strongly connected component of a dependency graph into a
+// single node. The analyses that need an acyclic graph, such as the transitive dependency counts, the reachability
+// index and the dependency depths, can all run on the same Condensation, so that the components are only computed
+// once. The Condensation does not follow later changes to the graph.
+type Condensation struct {
+	// DAG has one node per component, whose ID is the index of the component. Its edges connect different components
+	// and point from dependents to dependencies, like in the original graph.
+	DAG *simple.DirectedGraph
+	// Components holds the members of every component, sorted by ID. The components are in topological order, with
+	// dependents before their dependencies, so every edge of the DAG goes to a component with a higher index.
+	Components [][]NodeInfo
+
+	// componentOf maps a node ID to the index of its component.
+	componentOf map[int64]int
+	// members holds the node IDs of every component, sorted.
+	members [][]int64
+	// successors and predecessors hold, per component, the sorted indices of the other components it has an edge to
+	// and from.
+	successors   [][]int
+	predecessors [][]int
+}
+
+// Condense computes the strongly connected components of the graph and returns its condensation. The members of the
+// components are resolved with the node map, and an error is returned if one of the nodes of the graph is missing
+// from it.
+func Condense(g graph.Directed, nodeMap map[int64]NodeInfo) (*Condensation, error) {
+	c := condense(g)
+	c.Components = make([][]NodeInfo, len(c.members))
+	c.DAG = simple.NewDirectedGraph()
+	for component, ids := range c.members {
+		c.Components[component] = make([]NodeInfo, len(ids))
+		for i, id := range ids {
+			info, ok := nodeMap[id]
+			if !ok {
+				return nil, fmt.Errorf("node %d is not part of the node map", id)
+			}
+			c.Components[component][i] = info
+		}
+		c.DAG.AddNode(simple.Node(component))
+	}
+	for component, successors := range c.successors {
+		for _, successor := range successors {
+			c.DAG.SetEdge(simple.Edge{F: simple.Node(component), T: simple.Node(successor)})
+		}
+	}
+	return c, nil
+}
+
+// condense computes the components of the graph without resolving their members or building the DAG, for the
+// analyses that only use the node IDs.
+func condense(g graph.Directed) *Condensation {
+	sccs := topo.TarjanSCC(g)
+	c := &Condensation{
+		componentOf:  make(map[int64]int),
+		members:      make([][]int64, len(sccs)),
+		successors:   make([][]int, len(sccs)),
+		predecessors: make([][]int, len(sccs)),
+	}
+	// Tarjan's algorithm emits the components in reverse topological order.
+	for i, scc := range sccs {
+		component := len(sccs) - 1 - i
+		ids := make([]int64, len(scc))
+		for j, node := range scc {
+			ids[j] = node.ID()
+			c.componentOf[node.ID()] = component
+		}
+		sort.Slice(ids, func(a, b int) bool { return ids[a] < ids[b] })
+		c.members[component] = ids
+	}
+	seen := make(map[int]int, len(sccs))
+	for component, ids := range c.members {
+		for _, id := range ids {
+			to := g.From(id)
+			for to.Next() {
+				successor := c.componentOf[to.Node().ID()]
+				if successor != component && seen[successor] != component+1 {
+					seen[successor] = component + 1
+					c.successors[component] = append(c.successors[component], successor)
+				}
+			}
+		}
+		sort.Ints(c.successors[component])
+	}
+	// Walking the components in order appends every predecessor in increasing order.
+	for component, successors := range c.successors {
+		for _, successor := range successors {
+			c.predecessors[successor] = append(c.predecessors[successor], component)
+		}
+	}
+	return c
+}
+
+// Len returns the number of components.
+func (c *Condensation) Len() int {
+	return len(c.members)
+}
+
+// Component returns the index of the component of the node, or false if the node is not part of the graph.
+func (c *Condensation) Component(id int64) (int, bool) {
+	component, ok := c.componentOf[id]
+	return component, ok
+}
+
+// Cycles returns the components that contain more than one node, in topological order.
+func (c *Condensation) Cycles() [][]NodeInfo {
+	var cycles [][]NodeInfo
+	for _, members := range c.Components {
+		if len(members) > 1 {
+			cycles = append(cycles, members)
+		}
+	}
+	return cycles
+}
+
+// ProjectInts maps a value per component, indexed like Components, back to every member of the components.
+func (c *Condensation) ProjectInts(values []int) map[int64]int {
+	projected := make(map[int64]int, len(c.componentOf))
+	for component, ids := range c.members {
+		for _, id := range ids {
+			projected[id] = values[component]
+		}
+	}
+	return projected
+}
+
+// ProjectFloats maps a value per component, indexed like Components, back to every member of the components.
+func (c *Condensation) ProjectFloats(values []float64) map[int64]float64 {
+	projected := make(map[int64]float64, len(c.componentOf))
+	for component, ids := range c.members {
+		for _, id := range ids {
+			projected[id] = values[component]
+		}
+	}
+	return projected
+}
+
+// Expand returns the IDs of the members of the given components, in increasing order.
+func (c *Condensation) Expand(components []int) []int64 {
+	var ids []int64
+	for _, component := range components {
+		ids = append(ids, c.members[component]...)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -0,0 +1,86 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCondense(t *testing.T) {
+	// 0 -> {1, 2}, a cycle 1 -> 2 -> 3 -> 1, and 3 -> 4.
+	graph, nodeMap := createDepthTestGraph([][2]int64{{0, 1}, {0, 2}, {1, 2}, {2, 3}, {3, 1}, {3, 4}})
+	c, err := Condense(graph, nodeMap)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	t.Run("Collapses the cycles into components in topological order", func(t *testing.T) {
+		if c.Len() != 3 {
+			t.Fatalf("Expected 3 components, got %d", c.Len())
+		}
+		expected := [][]int64{{0}, {1, 2, 3}, {4}}
+		for component, ids := range expected {
+			var got []int64
+			for _, info := range c.Components[component] {
+				got = append(got, info.id)
+			}
+			if !reflect.DeepEqual(got, ids) {
+				t.Errorf("Expected the members %v in component %d, got %v", ids, component, got)
+			}
+			for _, id := range ids {
+				if index, ok := c.Component(id); !ok || index != component {
+					t.Errorf("Expected node %d in component %d, got %d", id, component, index)
+				}
+			}
+		}
+		if _, ok := c.Component(5); ok {
+			t.Error("Expected no component for a node that is not part of the graph")
+		}
+	})
+
+	t.Run("Connects the components in the DAG", func(t *testing.T) {
+		if edges := c.DAG.Edges().Len(); edges != 2 {
+			t.Errorf("Expected 2 edges between components, got %d", edges)
+		}
+		if !c.DAG.HasEdgeFromTo(0, 1) || !c.DAG.HasEdgeFromTo(1, 2) {
+			t.Error("Expected the edges 0 -> 1 and 1 -> 2")
+		}
+	})
+
+	t.Run("Projects the results back to the nodes", func(t *testing.T) {
+		depths := c.ProjectInts(c.ComponentDepths())
+		expected := map[int64]int{0: 2, 1: 1, 2: 1, 3: 1, 4: 0}
+		if !reflect.DeepEqual(depths, expected) {
+			t.Errorf("Expected the depths %v, got %v", expected, depths)
+		}
+		if ids := c.Expand([]int{2, 1}); !reflect.DeepEqual(ids, []int64{1, 2, 3, 4}) {
+			t.Errorf("Expected the members [1 2 3 4], got %v", ids)
+		}
+	})
+
+	t.Run("Runs the analyses on the condensation", func(t *testing.T) {
+		counts := c.TransitiveDependencyCounts([]int64{0, 1, 4})
+		if expected := TransitiveDependencyCounts(graph, []int64{0, 1, 4}); !reflect.DeepEqual(counts, expected) {
+			t.Errorf("Expected the counts %v, got %v", expected, counts)
+		}
+		index := c.ReachabilityIndex()
+		if !index.Reaches(0, 4) || index.Reaches(4, 1) {
+			t.Error("Expected 0 to reach 4 and 4 not to reach 1")
+		}
+		if dependents := index.Dependents(2); !reflect.DeepEqual(dependents, []int64{0, 1, 3}) {
+			t.Errorf("Expected the dependents [0 1 3], got %v", dependents)
+		}
+		if _, err := c.DependencyDepths(); err == nil {
+			t.Error("Expected a CycleError for the cyclic graph")
+		}
+		if cycles := c.Cycles(); len(cycles) != 1 || len(cycles[0]) != 3 {
+			t.Errorf("Expected one cycle of three nodes, got %v", cycles)
+		}
+	})
+
+	t.Run("Rejects nodes without metadata", func(t *testing.T) {
+		delete(nodeMap, 4)
+		if _, err := Condense(graph, nodeMap); err == nil {
+			t.Error("Expected an error for a node missing from the node map")
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package graph
+
+import (
+	"sort"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// Conflict describes a package of which several versions are part of the transitive closure of a root. Paths holds one
+// witness path per version, in the same order as Versions, starting at the root and ending at that version.
+type Conflict struct {
+	Name      string
+	Ecosystem string
+	Versions  []string
+	Paths     [][]NodeInfo
+}
+
+// FindVersionConflicts finds the packages of which more than one version is reachable from the root, like
+// PackageGraph.VersionConflicts.
+//
+// Deprecated: the root is looked up by its ambiguous "name-version" key, see CreateStringIDToNodeInfoMap. Use
+// PackageGraph.VersionConflicts instead.
+func FindVersionConflicts(g *simple.DirectedGraph, nodeMap map[int64]NodeInfo, stringIDToNodeInfo map[string]NodeInfo, root NameVersion) []Conflict {
+	rootID, ok := findNode(stringIDToNodeInfo, root.stringID())
+	if !ok {
+		return nil
+	}
+	return versionConflicts(g, func(id int64) NodeInfo { return nodeMap[id] }, rootID)
+}
+
+// VersionConflicts finds the packages of which more than one version is reachable from the root. Ecosystems such as
+// Python and Go can only install one version of a package, so every conflict is a tree that could not be installed
+// there. The witness paths are shortest paths, found with a BFS that visits dependencies in node ID order. Conflicts
+// are sorted by package name and their versions in semver order. The result is empty if the root is not part of the
+// graph.
+func (pg *PackageGraph) VersionConflicts(root NameVersion) []Conflict {
+	rootInfo, ok := pg.FindNode(root)
+	if !ok {
+		return nil
+	}
+	return versionConflicts(pg.DependencyGraph(), pg.node, rootInfo.id)
+}
+
+// versionConflicts finds the conflicts below the root with the given ID, looking up the node information with node.
+func versionConflicts(g graph.Directed, node func(int64) NodeInfo, rootID int64) []Conflict {
+	parents := map[int64]int64{rootID: rootID}
+	queue := []int64{rootID}
+	for head := 0; head < len(queue); head++ {
+		id := queue[head]
+		dependencies := sortedNodeIDs(g.From(id))
+		for _, dependencyID := range dependencies {
+			if _, seen := parents[dependencyID]; !seen {
+				parents[dependencyID] = id
+				queue = append(queue, dependencyID)
+			}
+		}
+	}
+
+	versionsByPackage := make(map[PackageKey]map[string]int64)
+	for _, id := range queue {
+		info := node(id)
+		if versionsByPackage[info.key()] == nil {
+			versionsByPackage[info.key()] = make(map[string]int64)
+		}
+		versionsByPackage[info.key()][info.Version] = id
+	}
+
+	var conflicts []Conflict
+	for key, versions := range versionsByPackage {
+		if len(versions) < 2 {
+			continue
+		}
+		conflict := Conflict{Name: key.FullName(), Ecosystem: key.Ecosystem}
+		for version := range versions {
+			conflict.Versions = append(conflict.Versions, version)
+		}
+		sortVersions(conflict.Versions)
+		for _, version := range conflict.Versions {
+			conflict.Paths = append(conflict.Paths, witnessPath(parents, node, versions[version]))
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Name != conflicts[j].Name {
+			return conflicts[i].Name < conflicts[j].Name
+		}
+		return conflicts[i].Ecosystem < conflicts[j].Ecosystem
+	})
+	return conflicts
+}
+
+// witnessPath walks the BFS parents back from id to the root, whose parent is itself, and returns the path from the
+// root to id.
+func witnessPath(parents map[int64]int64, node func(int64) NodeInfo, id int64) []NodeInfo {
+	var path []NodeInfo
+	for {
+		path = append(path, node(id))
+		parent := parents[id]
+		if parent == id {
+			break
+		}
+		id = parent
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
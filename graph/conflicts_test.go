@@ -0,0 +1,100 @@
+package graph
+
+import (
+	"testing"
+)
+
+func TestFindVersionConflicts(t *testing.T) {
+	packagesInfo := []PackageInfo{
+		{
+			Name: "App",
+			Versions: map[string]VersionInfo{
+				"1.0.0": {
+					Timestamp: "2022-04-22T20:15:37",
+					Dependencies: map[string]string{
+						"B": "1.0.0",
+						"C": "1.0.0",
+					},
+				},
+			},
+		},
+		{
+			Name: "B",
+			Versions: map[string]VersionInfo{
+				"1.0.0": {
+					Timestamp: "2021-04-22T20:15:37",
+					Dependencies: map[string]string{
+						"D": "1.0.0",
+					},
+				},
+			},
+		},
+		{
+			Name: "C",
+			Versions: map[string]VersionInfo{
+				"1.0.0": {
+					Timestamp: "2021-05-22T20:15:37",
+					Dependencies: map[string]string{
+						"D": "2.0.0",
+					},
+				},
+			},
+		},
+		{
+			Name: "D",
+			Versions: map[string]VersionInfo{
+				"1.0.0": {
+					Timestamp:    "2018-01-01T00:00:00",
+					Dependencies: map[string]string{},
+				},
+				"2.0.0": {
+					Timestamp:    "2019-01-01T00:00:00",
+					Dependencies: map[string]string{},
+				},
+			},
+		},
+	}
+	pg := NewPackageGraph(&packagesInfo, false)
+
+	t.Run("Finds the package with two reachable versions", func(t *testing.T) {
+		conflicts := FindVersionConflicts(pg.Graph, pg.NodeMap(), pg.StringIDToNodeInfo, NameVersion{Name: "App", Version: "1.0.0"})
+		if len(conflicts) != 1 {
+			t.Fatalf("Expected 1 conflict, got %d", len(conflicts))
+		}
+		conflict := conflicts[0]
+		if conflict.Name != "D" || len(conflict.Versions) != 2 || conflict.Versions[0] != "1.0.0" || conflict.Versions[1] != "2.0.0" {
+			t.Errorf("Expected a conflict between D 1.0.0 and 2.0.0, got %v", conflict)
+		}
+	})
+
+	t.Run("Gives a witness path per version", func(t *testing.T) {
+		conflict := FindVersionConflicts(pg.Graph, pg.NodeMap(), pg.StringIDToNodeInfo, NameVersion{Name: "App", Version: "1.0.0"})[0]
+		expected := [][]string{{"App", "B", "D"}, {"App", "C", "D"}}
+		for i, path := range conflict.Paths {
+			if len(path) != len(expected[i]) {
+				t.Fatalf("Expected a path of length %d, got %v", len(expected[i]), path)
+			}
+			for j, node := range path {
+				if node.Name != expected[i][j] {
+					t.Errorf("Expected %s at position %d of path %d, got %s", expected[i][j], j, i, node.Name)
+				}
+			}
+		}
+	})
+
+	t.Run("Finds the same conflicts with the PackageGraph method", func(t *testing.T) {
+		conflicts := pg.VersionConflicts(NameVersion{Name: "App", Version: "1.0.0"})
+		if len(conflicts) != 1 || conflicts[0].Name != "D" || len(conflicts[0].Paths) != 2 {
+			t.Errorf("Expected the conflict on D, got %v", conflicts)
+		}
+		if conflicts := pg.VersionConflicts(NameVersion{Name: "Unknown", Version: "1.0.0"}); conflicts != nil {
+			t.Errorf("Expected no conflicts for an unknown root, got %v", conflicts)
+		}
+	})
+
+	t.Run("Finds no conflicts for a tree with one version per package", func(t *testing.T) {
+		if conflicts := FindVersionConflicts(pg.Graph, pg.NodeMap(), pg.StringIDToNodeInfo, NameVersion{Name: "B", Version: "1.0.0"}); len(conflicts) != 0 {
+			t.Errorf("Expected no conflicts, got %v", conflicts)
+		}
+	})
+}
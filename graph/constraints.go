@@ -0,0 +1,162 @@
+package graph
+
+import (
+	"sort"
+)
+
+// ConstraintRef is a dependency declaration on a package: the node ID of the dependent version, the class in which it
+// declares the dependency and the raw range.
+type ConstraintRef struct {
+	Dependent int64
+	Class     DependencyClass
+	Range     string
+}
+
+// constraintEntry is a ConstraintRef as stored in the constraint index, with the range as a reference into the range
+// table, so that an entry takes 16 bytes.
+type constraintEntry struct {
+	dependent int64
+	rangeRef  uint32
+	class     uint8
+}
+
+// constraintIndex maps a package key to the dependency declarations on it in all classes, sorted by dependent ID and
+// then by class. The ranges are interned in a table, as the same few ranges such as "^4.17.21" are declared by
+// hundreds of thousands of dependents.
+type constraintIndex struct {
+	entries   map[PackageKey][]constraintEntry
+	ranges    []string
+	rangeRefs map[string]uint32
+}
+
+// dependencyClasses lists the classes in the order in which the declarations of a dependent are indexed.
+var dependencyClasses = []DependencyClass{Runtime, Development, Peer, Optional}
+
+func newConstraintIndex() *constraintIndex {
+	return &constraintIndex{entries: make(map[PackageKey][]constraintEntry), rangeRefs: make(map[string]uint32)}
+}
+
+// ref returns the reference of the range in the range table, adding it if needed.
+func (index *constraintIndex) ref(dependencyRange string) uint32 {
+	if ref, ok := index.rangeRefs[dependencyRange]; ok {
+		return ref
+	}
+	ref := uint32(len(index.ranges))
+	index.ranges = append(index.ranges, dependencyRange)
+	index.rangeRefs[dependencyRange] = ref
+	return ref
+}
+
+// add indexes the dependency declarations of the version with the given ID in the ecosystem, keeping the entries of
+// every package sorted.
+func (index *constraintIndex) add(id int64, ecosystem string, versionInfo VersionInfo) {
+	for _, class := range dependencyClasses {
+		for name, dependencyRange := range versionInfo.DependenciesOf(class) {
+			key := NewPackageKey(ecosystem, name)
+			entry := constraintEntry{dependent: id, rangeRef: index.ref(dependencyRange), class: uint8(class)}
+			entries := index.entries[key]
+			i := sort.Search(len(entries), func(i int) bool { return !entries[i].less(entry) })
+			entries = append(entries, constraintEntry{})
+			copy(entries[i+1:], entries[i:])
+			entries[i] = entry
+			index.entries[key] = entries
+		}
+	}
+}
+
+// remove removes the dependency declarations of the version with the given ID in the ecosystem.
+func (index *constraintIndex) remove(id int64, ecosystem string, versionInfo VersionInfo) {
+	for _, class := range dependencyClasses {
+		for name := range versionInfo.DependenciesOf(class) {
+			key := NewPackageKey(ecosystem, name)
+			entries := index.entries[key]
+			kept := entries[:0]
+			for _, entry := range entries {
+				if entry.dependent != id {
+					kept = append(kept, entry)
+				}
+			}
+			if len(kept) == 0 {
+				delete(index.entries, key)
+			} else {
+				index.entries[key] = kept
+			}
+		}
+	}
+}
+
+// dependents returns the distinct IDs of the versions that declare a dependency on the package in any of the classes,
+// in increasing order.
+func (index *constraintIndex) dependents(key PackageKey, classes []DependencyClass) []int64 {
+	var included [Optional + 1]bool
+	for _, class := range classes {
+		if class >= 0 && class <= Optional {
+			included[class] = true
+		}
+	}
+	var ids []int64
+	for _, entry := range index.entries[key] {
+		if included[entry.class] && (len(ids) == 0 || ids[len(ids)-1] != entry.dependent) {
+			ids = append(ids, entry.dependent)
+		}
+	}
+	return ids
+}
+
+func (entry constraintEntry) less(other constraintEntry) bool {
+	if entry.dependent != other.dependent {
+		return entry.dependent < other.dependent
+	}
+	return entry.class < other.class
+}
+
+// ensureConstraintIndex returns the constraint index of the dependency declarations of all the versions in the graph.
+// NewPackageGraph builds it while creating the nodes; the graphs that are loaded, merged or extracted build it on
+// first use, while holding constraintsMutex.
+func (pg *PackageGraph) ensureConstraintIndex() *constraintIndex {
+	pg.constraintsMutex.Lock()
+	defer pg.constraintsMutex.Unlock()
+	if pg.constraints != nil {
+		return pg.constraints
+	}
+	index := newConstraintIndex()
+	for _, packageInfo := range *pg.Packages {
+		for version, versionInfo := range packageInfo.Versions {
+			id, ok := pg.ids[packageInfo.key().version(version)]
+			if !ok {
+				continue
+			}
+			for _, class := range dependencyClasses {
+				for name, dependencyRange := range versionInfo.DependenciesOf(class) {
+					key := NewPackageKey(packageInfo.Ecosystem, name)
+					index.entries[key] = append(index.entries[key], constraintEntry{dependent: id, rangeRef: index.ref(dependencyRange), class: uint8(class)})
+				}
+			}
+		}
+	}
+	for _, entries := range index.entries {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].less(entries[j]) })
+	}
+	pg.constraints = index
+	return index
+}
+
+// ConstraintsOn returns the dependency declarations on the package in all classes, whether or not the class creates
+// edges, ordered by the ID of the dependent and then by class. The name is looked up like in Versions, so the
+// declarations are those of the versions in the ecosystem of a qualified name. The declarations on a package that is
+// not part of the graph are returned as well, which shows what a missing package would be resolved against. The index
+// is built when the graph is created; a graph that was loaded, merged or extracted builds it on the first call.
+func (pg *PackageGraph) ConstraintsOn(name string) []ConstraintRef {
+	return pg.constraintsOn(pg.packageKey("", name))
+}
+
+// constraintsOn is ConstraintsOn for the package with the given key.
+func (pg *PackageGraph) constraintsOn(key PackageKey) []ConstraintRef {
+	index := pg.ensureConstraintIndex()
+	entries := index.entries[key]
+	refs := make([]ConstraintRef, len(entries))
+	for i, entry := range entries {
+		refs[i] = ConstraintRef{Dependent: entry.dependent, Class: DependencyClass(entry.class), Range: index.ranges[entry.rangeRef]}
+	}
+	return refs
+}
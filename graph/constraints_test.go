@@ -0,0 +1,72 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConstraintsOn(t *testing.T) {
+	createPackages := func() []PackageInfo {
+		return []PackageInfo{
+			{Name: "A", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{}},
+			}},
+			{Name: "B", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{"A": "^1.0.0"}, DevDependencies: map[string]string{"A": "1.0.0"}},
+				"2.0.0": {Timestamp: "2021-01-01T00:00:00", Dependencies: map[string]string{"A": "^1.0.0"}},
+			}},
+			{Name: "C", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{"Missing": "*"}, PeerDependencies: map[string]string{"A": "*"}},
+			}},
+		}
+	}
+
+	t.Run("Returns the declarations in all classes", func(t *testing.T) {
+		packagesInfo := createPackages()
+		pg := NewPackageGraph(&packagesInfo, false)
+		b1, b2, c := pg.StringIDToNodeInfo["B-1.0.0"].id, pg.StringIDToNodeInfo["B-2.0.0"].id, pg.StringIDToNodeInfo["C-1.0.0"].id
+		expected := []ConstraintRef{
+			{Dependent: b1, Class: Runtime, Range: "^1.0.0"},
+			{Dependent: b1, Class: Development, Range: "1.0.0"},
+			{Dependent: b2, Class: Runtime, Range: "^1.0.0"},
+			{Dependent: c, Class: Peer, Range: "*"},
+		}
+		if refs := pg.ConstraintsOn("A"); !reflect.DeepEqual(refs, expected) {
+			t.Errorf("Expected %v, got %v", expected, refs)
+		}
+		if refs := pg.ConstraintsOn("Missing"); len(refs) != 1 || refs[0].Dependent != c {
+			t.Errorf("Expected the declaration on the missing package, got %v", refs)
+		}
+		if len(pg.constraints.ranges) != 3 {
+			t.Errorf("Expected 3 distinct ranges, got %v", pg.constraints.ranges)
+		}
+	})
+
+	t.Run("Builds the index of an extracted graph on first use", func(t *testing.T) {
+		packagesInfo := createPackages()
+		pg := NewPackageGraph(&packagesInfo, false)
+		ego, err := EgoNetwork(pg, NameVersion{Name: "A", Version: "1.0.0"}, 0, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// C only has a peer dependency on A, so it is not part of the ego network.
+		if refs := ego.ConstraintsOn("A"); len(refs) != 3 {
+			t.Errorf("Expected the 3 declarations of B, got %v", refs)
+		}
+	})
+
+	t.Run("Follows the incremental changes", func(t *testing.T) {
+		packagesInfo := createPackages()
+		pg := NewPackageGraph(&packagesInfo, false)
+		if err := pg.AddVersion("D", "1.0.0", VersionInfo{Dependencies: map[string]string{"A": ">= 1.0.0"}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := pg.RemoveVersion("B", "1.0.0", false); err != nil {
+			t.Fatal(err)
+		}
+		refs := pg.ConstraintsOn("A")
+		if len(refs) != 3 || refs[2].Dependent != pg.StringIDToNodeInfo["D-1.0.0"].id || refs[2].Range != ">= 1.0.0" {
+			t.Errorf("Expected the declarations of B 2.0.0, C and D, got %v", refs)
+		}
+	})
+}
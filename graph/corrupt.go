@@ -0,0 +1,271 @@
+package graph
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// DefaultMaxCorrupt is the number of corrupt records that WithSkipCorrupt skips at most unless told otherwise.
+const DefaultMaxCorrupt = 1000
+
+// maxRecordBytes is the size above which a package record counts as corrupt, so that a record whose end was lost,
+// which the scanner can only tell by its braces, does not pull the rest of the input into memory. The largest records
+// of npm take up a few tens of megabytes.
+const maxRecordBytes = 256 << 20
+
+// errRecordTooLarge is the error of the records larger than maxRecordBytes.
+var errRecordTooLarge = fmt.Errorf("record larger than %d bytes", maxRecordBytes)
+
+// CorruptRecord is a record of the JSON input that WithSkipCorrupt skipped.
+type CorruptRecord struct {
+	// Offset is the byte offset in the input at which the record starts.
+	Offset int64  `json:"offset"`
+	Error  string `json:"error"`
+}
+
+// CorruptInputReport lists the records that ReadPackagesJSON skipped with WithSkipCorrupt, in input order.
+type CorruptInputReport struct {
+	Skipped []CorruptRecord `json:"skipped"`
+}
+
+// WithSkipCorrupt makes ReadPackagesJSON and OpenPackageGraph skip the package records that cannot be decoded instead
+// of failing, and list them in the report. A record is corrupt if it is not valid JSON, contains invalid UTF-8,
+// which the JSON decoder would silently replace, or does not decode as a package. After a corrupt record the input is
+// scanned for the next object that starts an element of the top-level array and decodes as a package with a name and
+// versions, so that a truncated record only costs itself. Once more than maxCorrupt records were skipped, reading
+// fails after all, so that input that is corrupt throughout is not mistaken for a small graph; a maxCorrupt of 0
+// stands for DefaultMaxCorrupt and a negative one never fails. Reading is somewhat slower in this mode, as every
+// record is scanned before it is decoded.
+func WithSkipCorrupt(report *CorruptInputReport, maxCorrupt int) Option {
+	return func(options *Options) {
+		if maxCorrupt == 0 {
+			maxCorrupt = DefaultMaxCorrupt
+		}
+		options.Corrupt = report
+		options.MaxCorrupt = maxCorrupt
+	}
+}
+
+// recordScanner reads the input a byte at a time, tracking the offset, and can push back the bytes of a record that
+// turned out to be corrupt, so that they are scanned again for the start of the next record.
+type recordScanner struct {
+	reader *bufio.Reader
+	// pending holds the pushed back bytes, of which those from position are yet to be read.
+	pending  []byte
+	position int
+	// offset is the offset in the input of the next byte.
+	offset int64
+}
+
+func (scanner *recordScanner) next() (byte, error) {
+	if scanner.position < len(scanner.pending) {
+		b := scanner.pending[scanner.position]
+		scanner.position++
+		scanner.offset++
+		return b, nil
+	}
+	scanner.pending, scanner.position = nil, 0
+	b, err := scanner.reader.ReadByte()
+	if err == nil {
+		scanner.offset++
+	}
+	return b, err
+}
+
+// unread pushes back the byte last returned by next. The position is only past the start of pending while the bytes
+// come from pending.
+func (scanner *recordScanner) unread() {
+	scanner.offset--
+	if scanner.position > 0 {
+		scanner.position--
+		return
+	}
+	_ = scanner.reader.UnreadByte()
+}
+
+// pushBack makes data, which must be the bytes read last, the next bytes to read. If they were all read from pending,
+// the position moves back over them. Otherwise some came from the reader, so pending was used up before them, and data
+// takes its place without being copied, which means that the caller must not change data afterwards.
+func (scanner *recordScanner) pushBack(data []byte) {
+	if len(data) <= scanner.position {
+		scanner.position -= len(data)
+	} else {
+		scanner.pending, scanner.position = data, 0
+	}
+	scanner.offset -= int64(len(data))
+}
+
+// peek returns the next byte that is not whitespace, without consuming it.
+func (scanner *recordScanner) peek() (byte, error) {
+	for {
+		b, err := scanner.next()
+		if err != nil {
+			return 0, err
+		}
+		if b != ' ' && b != '\t' && b != '\n' && b != '\r' {
+			scanner.unread()
+			return b, nil
+		}
+	}
+}
+
+// scanObject returns the bytes of the object that starts with the next byte, up to the brace that closes it outside of
+// a string. The bytes read so far are returned with the error if the input ends first or the object gets too large.
+func (scanner *recordScanner) scanObject() ([]byte, error) {
+	var record []byte
+	depth := 0
+	inString, escaped := false, false
+	for {
+		b, err := scanner.next()
+		if err == io.EOF {
+			return record, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return record, err
+		}
+		record = append(record, b)
+		switch {
+		case inString:
+			switch {
+			case escaped:
+				escaped = false
+			case b == '\\':
+				escaped = true
+			case b == '"':
+				inString = false
+			}
+		case b == '"':
+			inString = true
+		case b == '{' || b == '[':
+			depth++
+		case b == '}' || b == ']':
+			depth--
+			if depth == 0 {
+				return record, nil
+			}
+		}
+		if len(record) > maxRecordBytes {
+			return record, errRecordTooLarge
+		}
+	}
+}
+
+// decodeRecord decodes the bytes of a record as a package.
+func decodeRecord(record []byte) (PackageInfo, error) {
+	var packageInfo PackageInfo
+	if !utf8.Valid(record) {
+		return packageInfo, errors.New("invalid UTF-8")
+	}
+	err := json.Unmarshal(record, &packageInfo)
+	return packageInfo, err
+}
+
+// resync skips to the next object that follows a comma, a bracket or a line break and decodes as a package with a
+// name and versions, and returns that package and the offset at which it starts. It returns false if the input ends
+// first.
+func (scanner *recordScanner) resync() (PackageInfo, int64, bool, error) {
+	var previous byte
+	for {
+		b, err := scanner.next()
+		if err == io.EOF {
+			return PackageInfo{}, 0, false, nil
+		}
+		if err != nil {
+			return PackageInfo{}, 0, false, err
+		}
+		if b == ' ' || b == '\t' || b == '\r' {
+			continue
+		}
+		if b == '{' && (previous == ',' || previous == '[' || previous == '\n') {
+			scanner.unread()
+			start := scanner.offset
+			record, err := scanner.scanObject()
+			if err == nil {
+				if packageInfo, err := decodeRecord(record); err == nil && packageInfo.Name != "" && packageInfo.Versions != nil {
+					return packageInfo, start, true, nil
+				}
+			} else if err != io.ErrUnexpectedEOF && err != errRecordTooLarge {
+				return PackageInfo{}, 0, false, err
+			}
+			scanner.pushBack(record[1:])
+		}
+		previous = b
+	}
+}
+
+// readPackagesSkippingCorrupt reads the JSON array of packages like ReadPackagesJSON, but skips the corrupt records as
+// described by WithSkipCorrupt and passes every other package to add, with the offsets of its record.
+func readPackagesSkippingCorrupt(r io.Reader, options *Options, add func(packageInfo PackageInfo, start, end int64)) error {
+	scanner := &recordScanner{reader: bufio.NewReaderSize(r, 1<<20)}
+	skip := func(offset int64, err error) error {
+		options.Corrupt.Skipped = append(options.Corrupt.Skipped, CorruptRecord{Offset: offset, Error: err.Error()})
+		if options.MaxCorrupt >= 0 && len(options.Corrupt.Skipped) > options.MaxCorrupt {
+			return fmt.Errorf("more than %d corrupt records, the last at offset %d: %w", options.MaxCorrupt, offset, err)
+		}
+		return nil
+	}
+
+	if b, err := scanner.peek(); err != nil || b != '[' {
+		return fmt.Errorf("expected the array of packages at offset %d", scanner.offset)
+	}
+	scanner.next()
+	// separated is set once the next element may follow, at the start of the array or after a comma.
+	separated := true
+	for {
+		b, err := scanner.peek()
+		if err == io.EOF {
+			return skip(scanner.offset, errors.New("unexpected end of input, expected ]"))
+		}
+		if err != nil {
+			return err
+		}
+		switch {
+		case b == ']':
+			return nil
+		case b == ',' && !separated:
+			scanner.next()
+			separated = true
+			continue
+		}
+
+		start := scanner.offset
+		var record []byte
+		var packageInfo PackageInfo
+		switch {
+		case !separated:
+			err = fmt.Errorf("expected , or ] but found %q", b)
+		case b != '{':
+			err = fmt.Errorf("expected a package object but found %q", b)
+		default:
+			if record, err = scanner.scanObject(); err == nil {
+				packageInfo, err = decodeRecord(record)
+			} else if err != io.ErrUnexpectedEOF && err != errRecordTooLarge {
+				return err
+			}
+		}
+		if err == nil {
+			add(packageInfo, start, scanner.offset)
+			separated = false
+			continue
+		}
+
+		if err := skip(start, err); err != nil {
+			return err
+		}
+		if len(record) > 0 {
+			scanner.pushBack(record[1:])
+		} else {
+			scanner.next()
+		}
+		packageInfo, start, found, err := scanner.resync()
+		if err != nil || !found {
+			return err
+		}
+		add(packageInfo, start, scanner.offset)
+		separated = false
+	}
+}
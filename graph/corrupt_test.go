@@ -0,0 +1,119 @@
+package graph
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSkipCorrupt(t *testing.T) {
+	names := func(packages *[]PackageInfo) []string {
+		var names []string
+		for _, packageInfo := range *packages {
+			names = append(names, packageInfo.Name)
+		}
+		return names
+	}
+	offsets := func(t *testing.T, path string, records ...string) []int64 {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var offsets []int64
+		for _, record := range records {
+			offsets = append(offsets, int64(bytes.Index(data, []byte(record))))
+		}
+		return offsets
+	}
+
+	t.Run("Skips corrupt records at the start, in the middle and at the end", func(t *testing.T) {
+		tests := []struct {
+			path     string
+			expected []string
+			// skipped are the beginnings of the skipped records.
+			skipped []string
+		}{
+			{"testdata/corrupt_start.json", []string{"a", "b"}, []string{`{"name":"broken"`}},
+			{"testdata/corrupt_middle.json", []string{"a", "b", "c", "d"}, []string{`{"name":"bad`, `{"name":"cut"`, `"not a package"`}},
+			{"testdata/corrupt_end.json", []string{"a", "b"}, []string{`{"name":"cut"`}},
+		}
+		for _, test := range tests {
+			var report CorruptInputReport
+			packages, err := ReadPackagesJSON(test.path, nil, WithSkipCorrupt(&report, 0))
+			if err != nil {
+				t.Fatalf("Expected %s to be read, got %v", test.path, err)
+			}
+			if !reflect.DeepEqual(names(packages), test.expected) {
+				t.Errorf("Expected the packages %v of %s, got %v", test.expected, test.path, names(packages))
+			}
+			var skipped []int64
+			for _, record := range report.Skipped {
+				skipped = append(skipped, record.Offset)
+				if record.Error == "" {
+					t.Errorf("Expected the error of the record at %d of %s", record.Offset, test.path)
+				}
+			}
+			if expected := offsets(t, test.path, test.skipped...); !reflect.DeepEqual(skipped, expected) {
+				t.Errorf("Expected records at %v of %s to be skipped, got %+v", expected, test.path, report.Skipped)
+			}
+		}
+	})
+
+	t.Run("Reports invalid UTF-8", func(t *testing.T) {
+		var report CorruptInputReport
+		if _, err := ReadPackagesJSON("testdata/corrupt_middle.json", nil, WithSkipCorrupt(&report, 0)); err != nil {
+			t.Fatal(err)
+		}
+		if report.Skipped[0].Error != "invalid UTF-8" {
+			t.Errorf("Expected the invalid UTF-8 to be reported, got %q", report.Skipped[0].Error)
+		}
+	})
+
+	t.Run("Fails once more records than the limit are corrupt", func(t *testing.T) {
+		var report CorruptInputReport
+		_, err := ReadPackagesJSON("testdata/corrupt_middle.json", nil, WithSkipCorrupt(&report, 2))
+		if err == nil || !strings.Contains(err.Error(), "more than 2 corrupt records") {
+			t.Errorf("Expected the limit to be exceeded, got %v", err)
+		}
+		if _, err := ReadPackagesJSON("testdata/corrupt_middle.json", nil, WithSkipCorrupt(&CorruptInputReport{}, -1)); err != nil {
+			t.Errorf("Expected no limit, got %v", err)
+		}
+		if _, err := ReadPackagesJSON("testdata/corrupt_middle.json", nil); err == nil {
+			t.Error("Expected corrupt input to fail without WithSkipCorrupt")
+		}
+	})
+
+	t.Run("Resyncs within pushed back records", func(t *testing.T) {
+		// Neither cut nor half is closed, so a is found by scanning the bytes of both again.
+		input := "[{\"name\":\"cut\",\n{\"name\":\"half\",\n{\"name\":\"a\",\"versions\":{}}\n]"
+		report := CorruptInputReport{}
+		var found []string
+		var starts []int64
+		err := readPackagesSkippingCorrupt(strings.NewReader(input), &Options{Corrupt: &report, MaxCorrupt: -1}, func(packageInfo PackageInfo, start, end int64) {
+			found = append(found, packageInfo.Name)
+			starts = append(starts, start)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := int64(strings.Index(input, `{"name":"a"`)); !reflect.DeepEqual(found, []string{"a"}) || starts[0] != expected {
+			t.Errorf("Expected a at offset %d, got %v at %v", expected, found, starts)
+		}
+		if len(report.Skipped) != 1 || report.Skipped[0].Offset != 1 {
+			t.Errorf("Expected cut to be skipped, got %+v", report.Skipped)
+		}
+	})
+
+	t.Run("Reads valid input like the decoder", func(t *testing.T) {
+		var report CorruptInputReport
+		packages, err := ReadPackagesJSON("testdata/self_dependency.json", nil, WithSkipCorrupt(&report, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := ParseJSONWithInterner("testdata/self_dependency.json", nil); !reflect.DeepEqual(packages, expected) || len(report.Skipped) != 0 {
+			t.Errorf("Expected %v, got %v with %v skipped", *expected, *packages, report.Skipped)
+		}
+	})
+}
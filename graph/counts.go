@@ -0,0 +1,26 @@
+package graph
+
+import "sort"
+
+// NameCount is a name, such as a license, an ecosystem or a maintainer, together with a count.
+type NameCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// SortedCounts returns the counts of a map by name as a slice, most first and ties by name. It is the order of
+// LicenseCounts, EcosystemCounts and MaintainerExposureCounts, so that the output written from them does not depend on
+// the iteration order of the map.
+func SortedCounts(counts map[string]int) []NameCount {
+	sorted := make([]NameCount, 0, len(counts))
+	for name, count := range counts {
+		sorted = append(sorted, NameCount{Name: name, Count: count})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
@@ -0,0 +1,44 @@
+package graph
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Deprecation is the deprecation message of a package version. It is decoded from the npm "deprecated" string, or
+// from a boolean as used by the registries that mark versions as yanked, where true becomes "deprecated". An empty
+// message, false and other values mean that the version is not deprecated.
+type Deprecation string
+
+// UnmarshalJSON decodes the deprecation from a string or a boolean.
+func (deprecation *Deprecation) UnmarshalJSON(data []byte) error {
+	var message string
+	var flag bool
+	switch {
+	case json.Unmarshal(data, &message) == nil:
+		*deprecation = Deprecation(message)
+	case json.Unmarshal(data, &flag) == nil && flag:
+		*deprecation = "deprecated"
+	default:
+		*deprecation = ""
+	}
+	return nil
+}
+
+// DeprecatedExposure returns the deprecated versions among the transitive dependencies of root, in increasing ID
+// order. With WithPreferNonDeprecated and ResolveHighest, these are the deprecated versions that could not be avoided.
+// The bool is false if root is not part of the graph.
+func DeprecatedExposure(pg *PackageGraph, root NameVersion) ([]NodeInfo, bool) {
+	dependencies, ok := pg.Dependencies(root, -1)
+	if !ok {
+		return nil, false
+	}
+	var result []NodeInfo
+	for _, dependency := range dependencies {
+		if dependency.Deprecated != "" {
+			result = append(result, dependency)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].id < result[j].id })
+	return result, true
+}
@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDeprecation(t *testing.T) {
+	t.Run("Decodes messages and flags", func(t *testing.T) {
+		for input, expected := range map[string]string{
+			`"use foo instead"`: "use foo instead",
+			`true`:              "deprecated",
+			`false`:             "",
+			`""`:                "",
+		} {
+			var versionInfo VersionInfo
+			if err := json.Unmarshal([]byte(`{"timestamp": "2020-01-01", "deprecated": `+input+`}`), &versionInfo); err != nil {
+				t.Fatalf("Expected no error for %s, got %v", input, err)
+			}
+			if string(versionInfo.Deprecated) != expected {
+				t.Errorf("Expected %q for %s, got %q", expected, input, versionInfo.Deprecated)
+			}
+		}
+	})
+
+	t.Run("Resolves to deprecated versions by default", func(t *testing.T) {
+		packagesInfo := testPackages("deprecation")
+		pg := NewPackageGraph(&packagesInfo, false, WithResolution(ResolveHighest))
+		if edges := edgeSet(pg); !edges[[2]string{"App-1.0.0", "A-1.1.0"}] || !edges[[2]string{"App-1.0.0", "B-1.0.0"}] {
+			t.Errorf("Expected edges to A 1.1.0 and B 1.0.0, got %v", edges)
+		}
+	})
+
+	t.Run("Avoids deprecated versions unless only they satisfy the range", func(t *testing.T) {
+		packagesInfo := testPackages("deprecation")
+		pg := NewPackageGraph(&packagesInfo, false, WithResolution(ResolveHighest), WithPreferNonDeprecated())
+		edges := edgeSet(pg)
+		if len(edges) != 2 || !edges[[2]string{"App-1.0.0", "A-1.0.0"}] || !edges[[2]string{"App-1.0.0", "B-1.0.0"}] {
+			t.Errorf("Expected edges to A 1.0.0 and B 1.0.0, got %v", edges)
+		}
+
+		exposure, ok := DeprecatedExposure(pg, NameVersion{Name: "App", Version: "1.0.0"})
+		if !ok || len(exposure) != 1 || exposure[0].Name != "B" || exposure[0].Deprecated != "no longer maintained" {
+			t.Errorf("Expected only B 1.0.0 to be deprecated, got %v", exposure)
+		}
+	})
+
+	t.Run("Prefers added versions that are not deprecated", func(t *testing.T) {
+		packagesInfo := testPackages("deprecation")
+		pg := NewPackageGraph(&packagesInfo, false, WithResolution(ResolveHighest), WithPreferNonDeprecated())
+		if err := pg.AddVersion("B", "1.0.1", VersionInfo{Timestamp: "2022-01-01T00:00:00"}); err != nil {
+			t.Fatal(err)
+		}
+		if edges := edgeSet(pg); !edges[[2]string{"App-1.0.0", "B-1.0.1"}] || edges[[2]string{"App-1.0.0", "B-1.0.0"}] {
+			t.Errorf("Expected the edge to move to B 1.0.1, got %v", edges)
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gonum.org/v1/gonum/graph"
+)
+
+// CycleError is returned by analyses that require the dependency graph to be acyclic. It lists the strongly connected
+// components that contain more than one node.
+type CycleError struct {
+	Components [][]NodeInfo
+}
+
+func (e *CycleError) Error() string {
+	descriptions := make([]string, 0, len(e.Components))
+	for _, component := range e.Components {
+		names := make([]string, 0, len(component))
+		for _, node := range component {
+			names = append(names, node.stringID)
+		}
+		descriptions = append(descriptions, "["+strings.Join(names, ", ")+"]")
+	}
+	return fmt.Sprintf("dependency graph contains %d cycles: %s", len(e.Components), strings.Join(descriptions, " "))
+}
+
+// DependencyDepths computes the height of every package version: the number of edges in its longest dependency chain.
+// Versions without dependencies have a depth of 0. The depths are computed over the condensation of the graph, so a
+// *CycleError is returned if the graph contains cycles, and an error if a node of the graph is missing from the node
+// map.
+func DependencyDepths(g graph.Directed, nodeMap map[int64]NodeInfo) (map[int64]int, error) {
+	c, err := Condense(g, nodeMap)
+	if err != nil {
+		return nil, err
+	}
+	return c.DependencyDepths()
+}
+
+// DependencyDepths is like the function of the same name, on the graph that was condensed.
+func (c *Condensation) DependencyDepths() (map[int64]int, error) {
+	if cycles := c.Cycles(); len(cycles) > 0 {
+		return nil, &CycleError{Components: cycles}
+	}
+	return c.ProjectInts(c.ComponentDepths()), nil
+}
+
+// ComponentDepths returns, for every component, the number of edges of the longest chain of components below it in
+// the DAG. Components without dependencies have a depth of 0.
+func (c *Condensation) ComponentDepths() []int {
+	depths := make([]int, c.Len())
+	// Dependencies come after their dependents in the topological order, so walk it backwards.
+	for component := len(depths) - 1; component >= 0; component-- {
+		for _, successor := range c.successors[component] {
+			if d := depths[successor] + 1; d > depths[component] {
+				depths[component] = d
+			}
+		}
+	}
+	return depths
+}
+
+// LongestDependencyChain returns one of the longest dependency chains in the graph, starting at the dependent and
+// ending at a version without dependencies. Ties are broken by the lowest node ID, so the result is deterministic.
+func LongestDependencyChain(g graph.Directed, nodeMap map[int64]NodeInfo) ([]NodeInfo, error) {
+	depths, err := DependencyDepths(g, nodeMap)
+	if err != nil {
+		return nil, err
+	}
+	if len(depths) == 0 {
+		return nil, nil
+	}
+
+	var current int64
+	found := false
+	for id, depth := range depths {
+		if !found || depth > depths[current] || depth == depths[current] && id < current {
+			current, found = id, true
+		}
+	}
+	chain := []NodeInfo{nodeMap[current]}
+	for depths[current] > 0 {
+		dependencies := graph.NodesOf(g.From(current))
+		sort.Slice(dependencies, func(i, j int) bool { return dependencies[i].ID() < dependencies[j].ID() })
+		for _, dependency := range dependencies {
+			if depths[dependency.ID()] == depths[current]-1 {
+				current = dependency.ID()
+				break
+			}
+		}
+		chain = append(chain, nodeMap[current])
+	}
+	return chain, nil
+}
@@ -0,0 +1,66 @@
+package graph
+
+import (
+	"errors"
+	"testing"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+func createDepthTestGraph(edges [][2]int64) (*simple.DirectedGraph, map[int64]NodeInfo) {
+	graph := simple.NewDirectedGraph()
+	nodeMap := make(map[int64]NodeInfo)
+	for _, edge := range edges {
+		graph.SetEdge(simple.Edge{F: simple.Node(edge[0]), T: simple.Node(edge[1])})
+		for _, id := range edge {
+			nodeMap[id] = *NewNodeInfo(id, "P", string(rune('a'+id)), "")
+		}
+	}
+	return graph, nodeMap
+}
+
+func TestDependencyDepths(t *testing.T) {
+	// A diamond 0 -> {1, 2} -> 3 with a longer branch 2 -> 4 -> 3.
+	graph, nodeMap := createDepthTestGraph([][2]int64{{0, 1}, {0, 2}, {1, 3}, {2, 3}, {2, 4}, {4, 3}})
+
+	t.Run("Computes the longest chain below every node", func(t *testing.T) {
+		depths, err := DependencyDepths(graph, nodeMap)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		expected := map[int64]int{0: 3, 1: 1, 2: 2, 3: 0, 4: 1}
+		for id, depth := range expected {
+			if depths[id] != depth {
+				t.Errorf("Expected depth %d for node %d, got %d", depth, id, depths[id])
+			}
+		}
+	})
+
+	t.Run("Returns the longest chain in order", func(t *testing.T) {
+		chain, err := LongestDependencyChain(graph, nodeMap)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		expected := []int64{0, 2, 4, 3}
+		if len(chain) != len(expected) {
+			t.Fatalf("Expected a chain of %d nodes, got %d", len(expected), len(chain))
+		}
+		for i, id := range expected {
+			if chain[i].id != id {
+				t.Errorf("Expected node %d at position %d, got %d", id, i, chain[i].id)
+			}
+		}
+	})
+
+	t.Run("Reports the cycles that prevent the computation", func(t *testing.T) {
+		cyclic, cyclicNodeMap := createDepthTestGraph([][2]int64{{0, 1}, {1, 2}, {2, 1}})
+		_, err := DependencyDepths(cyclic, cyclicNodeMap)
+		var cycleError *CycleError
+		if !errors.As(err, &cycleError) {
+			t.Fatalf("Expected a CycleError, got %v", err)
+		}
+		if len(cycleError.Components) != 1 || len(cycleError.Components[0]) != 2 {
+			t.Errorf("Expected one cycle of two nodes, got %v", cycleError.Components)
+		}
+	})
+}
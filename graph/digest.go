@@ -0,0 +1,259 @@
+package graph
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Digest summarizes the changes of an ecosystem between two dates, see ChangeDigest. The counts cover every package;
+// only the watched packages are described in detail.
+type Digest struct {
+	From time.Time
+	To   time.Time
+	// NewPackages is the number of packages whose first version was released within the window, and NewVersions the
+	// number of versions released within the window.
+	NewPackages int
+	NewVersions int
+	// RangeChanges is the number of runtime dependency ranges that the new versions changed, see RangeChangeEvents.
+	RangeChanges int
+	// NewlySatisfied is the number of dependency declarations that the new versions were the first to satisfy.
+	NewlySatisfied int
+	// Unparseable is the number of versions left out because their timestamp cannot be parsed.
+	Unparseable int
+	// Watched holds the watched packages with a version released within the window, sorted by name.
+	Watched []WatchedPackage
+}
+
+// WatchedPackage describes the versions of a watched package that were released within the window of a Digest.
+type WatchedPackage struct {
+	Name string
+	// New is set if the first version of the package was released within the window.
+	New bool
+	// Versions are the new versions in release order.
+	Versions []DigestVersion
+}
+
+// DigestVersion is a version released within the window of a Digest.
+type DigestVersion struct {
+	Version  string
+	Released time.Time
+	// Previous is the version before it in the order of RangeChangeEvents, against which the ranges are compared,
+	// and empty for the first version of the package.
+	Previous     string
+	RangeChanges []RangeChange
+	// NewlySatisfied are the dependency declarations of versions released before it that no earlier version of the
+	// package satisfied, sorted by dependent and class.
+	NewlySatisfied []SatisfiedDeclaration
+}
+
+// SatisfiedDeclaration is a dependency declaration that a new version satisfies.
+type SatisfiedDeclaration struct {
+	Dependent NameVersion
+	Class     DependencyClass
+	Range     string
+}
+
+// digestRelease is a version of a package with a parseable timestamp.
+type digestRelease struct {
+	version  string
+	released time.Time
+}
+
+// ChangeDigest summarizes what changed between from, inclusive, and to, exclusive, in the style of release notes: the
+// new packages and versions, the runtime dependency ranges that changed in the new versions and the dependency
+// declarations that became satisfiable, because a dependent declared a range, such as "^2.0.0", before any version
+// satisfied it. The new versions of the watched packages are listed with their changes; the other packages are only
+// counted, so an empty watch list gives the ecosystem-wide counts only. The declarations are matched with the default
+// SemverMatcher in every dependency class, and only the declarations of dependents released before a version count as
+// satisfied by it. Versions whose timestamp cannot be parsed are left out.
+func ChangeDigest(packages []PackageInfo, watch []string, from, to time.Time) Digest {
+	digest := Digest{From: from, To: to}
+	watched := make(map[string]bool, len(watch))
+	for _, name := range watch {
+		watched[name] = true
+	}
+	within := func(released time.Time) bool {
+		return !released.Before(from) && released.Before(to)
+	}
+
+	releases := make(map[string][]digestRelease)
+	updated := make(map[string]bool)
+	for _, packageInfo := range packages {
+		for version, versionInfo := range packageInfo.Versions {
+			released, err := ParseTimestamp(versionInfo.Timestamp)
+			if err != nil {
+				digest.Unparseable++
+				continue
+			}
+			releases[packageInfo.Name] = append(releases[packageInfo.Name], digestRelease{version, released})
+			if within(released) {
+				digest.NewVersions++
+				updated[packageInfo.Name] = true
+			}
+		}
+	}
+	for name := range updated {
+		sorted := releases[name]
+		sort.Slice(sorted, func(i, j int) bool {
+			if !sorted[i].released.Equal(sorted[j].released) {
+				return sorted[i].released.Before(sorted[j].released)
+			}
+			return compareVersions(sorted[i].version, sorted[j].version) < 0
+		})
+		if within(sorted[0].released) {
+			digest.NewPackages++
+		}
+	}
+
+	// The declarations on the updated packages are indexed with the position of the dependent in dependents as ID.
+	index := newConstraintIndex()
+	var dependents []NameVersion
+	var dependentReleases []time.Time
+	for _, packageInfo := range packages {
+		for version, versionInfo := range packageInfo.Versions {
+			released, err := ParseTimestamp(versionInfo.Timestamp)
+			if err != nil {
+				continue
+			}
+			id := int64(-1)
+			for _, class := range dependencyClasses {
+				for name, dependencyRange := range versionInfo.DependenciesOf(class) {
+					if !updated[name] {
+						continue
+					}
+					if id < 0 {
+						id = int64(len(dependents))
+						dependents = append(dependents, packageInfo.key().version(version))
+						dependentReleases = append(dependentReleases, released)
+					}
+					key := NewPackageKey(packageInfo.Ecosystem, name)
+					index.entries[key] = append(index.entries[key], constraintEntry{dependent: id, rangeRef: index.ref(dependencyRange), class: uint8(class)})
+				}
+			}
+		}
+	}
+
+	matcher := NewCachedMatcher(SemverMatcher{})
+	details := make(map[string]map[string]*DigestVersion)
+	for _, packageInfo := range packages {
+		if !updated[packageInfo.Name] {
+			continue
+		}
+		var versions map[string]*DigestVersion
+		if watched[packageInfo.Name] {
+			versions = make(map[string]*DigestVersion)
+			details[packageInfo.Name] = versions
+			for _, release := range releases[packageInfo.Name] {
+				if within(release.released) {
+					versions[release.version] = &DigestVersion{Version: release.version, Released: release.released}
+				}
+			}
+		}
+
+		order := releaseOrder(packageInfo)
+		released := make(map[string]time.Time, len(releases[packageInfo.Name]))
+		for _, release := range releases[packageInfo.Name] {
+			released[release.version] = release.released
+		}
+		for i, version := range order {
+			if timestamp, ok := released[version]; !ok || !within(timestamp) || i == 0 {
+				continue
+			}
+			changes := rangeChanges(packageInfo.Name, order[i-1], version, packageInfo.Versions[order[i-1]].Dependencies, packageInfo.Versions[version].Dependencies)
+			digest.RangeChanges += len(changes)
+			if versions != nil {
+				versions[version].Previous = order[i-1]
+				versions[version].RangeChanges = changes
+			}
+		}
+
+		// first holds the position in the releases of the first version that satisfies each range, or -1.
+		first := make(map[uint32]int)
+		sorted := releases[packageInfo.Name]
+		for _, entry := range index.entries[packageInfo.key()] {
+			position, ok := first[entry.rangeRef]
+			if !ok {
+				position = -1
+				if parsedRange, err := matcher.ParseRange(index.ranges[entry.rangeRef]); err == nil {
+					for i, release := range sorted {
+						if parsedRange.Matches(release.version) {
+							position = i
+							break
+						}
+					}
+				}
+				first[entry.rangeRef] = position
+			}
+			if position < 0 || !within(sorted[position].released) || !dependentReleases[entry.dependent].Before(sorted[position].released) {
+				continue
+			}
+			digest.NewlySatisfied++
+			if versions != nil {
+				version := versions[sorted[position].version]
+				version.NewlySatisfied = append(version.NewlySatisfied, SatisfiedDeclaration{
+					Dependent: dependents[entry.dependent],
+					Class:     DependencyClass(entry.class),
+					Range:     index.ranges[entry.rangeRef],
+				})
+			}
+		}
+	}
+
+	for name, versions := range details {
+		watchedPackage := WatchedPackage{Name: name, New: within(releases[name][0].released)}
+		for _, release := range releases[name] {
+			if version, ok := versions[release.version]; ok {
+				sort.Slice(version.NewlySatisfied, func(i, j int) bool {
+					a, b := version.NewlySatisfied[i], version.NewlySatisfied[j]
+					if a.Dependent != b.Dependent {
+						return a.Dependent.Name < b.Dependent.Name || a.Dependent.Name == b.Dependent.Name && compareVersions(a.Dependent.Version, b.Dependent.Version) < 0
+					}
+					return a.Class < b.Class
+				})
+				watchedPackage.Versions = append(watchedPackage.Versions, *version)
+			}
+		}
+		digest.Watched = append(digest.Watched, watchedPackage)
+	}
+	sort.Slice(digest.Watched, func(i, j int) bool { return digest.Watched[i].Name < digest.Watched[j].Name })
+	return digest
+}
+
+// WriteMarkdown writes the digest as a Markdown document: the counts as a list, followed by a section per watched
+// package with a subsection per new version. The Fprintf errors are kept by the buffer and returned by Flush.
+func (digest *Digest) WriteMarkdown(w io.Writer) error {
+	const day = "2006-01-02"
+	buffered := bufio.NewWriter(w)
+	fmt.Fprintf(buffered, "# Changes from %s to %s\n\n", digest.From.Format(day), digest.To.Format(day))
+	fmt.Fprintf(buffered, "- New packages: %d\n- New versions: %d\n- Changed dependency ranges: %d\n", digest.NewPackages, digest.NewVersions, digest.RangeChanges)
+	fmt.Fprintf(buffered, "- Newly satisfiable dependency declarations: %d\n", digest.NewlySatisfied)
+	if digest.Unparseable > 0 {
+		fmt.Fprintf(buffered, "- Versions with an unparseable timestamp, left out: %d\n", digest.Unparseable)
+	}
+	for _, watchedPackage := range digest.Watched {
+		fmt.Fprintf(buffered, "\n## `%s`", watchedPackage.Name)
+		if watchedPackage.New {
+			buffered.WriteString(" (new)")
+		}
+		buffered.WriteString("\n")
+		for _, version := range watchedPackage.Versions {
+			fmt.Fprintf(buffered, "\n### %s (%s)\n", version.Version, version.Released.Format(day))
+			if len(version.RangeChanges) > 0 {
+				fmt.Fprintf(buffered, "\nChanged dependency ranges since %s:\n\n", version.Previous)
+				for _, change := range version.RangeChanges {
+					fmt.Fprintf(buffered, "- `%s`: `%s` → `%s` (%s)\n", change.Dependency, change.OldRange, change.NewRange, change.Kind)
+				}
+			}
+			if len(version.NewlySatisfied) > 0 {
+				fmt.Fprintf(buffered, "\nFirst version to satisfy:\n\n")
+				for _, declaration := range version.NewlySatisfied {
+					fmt.Fprintf(buffered, "- `%s` (%s): `%s`\n", declaration.Dependent, declaration.Class, declaration.Range)
+				}
+			}
+		}
+	}
+	return buffered.Flush()
+}
@@ -0,0 +1,65 @@
+package graph
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestChangeDigest(t *testing.T) {
+	from, to := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Counts the changes of every package", func(t *testing.T) {
+		digest := ChangeDigest(testPackages("digest"), nil, from, to)
+		expected := Digest{From: from, To: to, NewPackages: 2, NewVersions: 4, RangeChanges: 1, NewlySatisfied: 3, Unparseable: 1}
+		if !reflect.DeepEqual(digest, expected) {
+			t.Errorf("Expected %+v, got %+v", expected, digest)
+		}
+	})
+
+	t.Run("Describes the new versions of the watched packages", func(t *testing.T) {
+		digest := ChangeDigest(testPackages("digest"), []string{"Lib", "Missing", "Old"}, from, to)
+		expected := []WatchedPackage{{Name: "Lib", Versions: []DigestVersion{
+			{
+				Version:      "1.1.0",
+				Released:     time.Date(2022, 1, 10, 0, 0, 0, 0, time.UTC),
+				Previous:     "1.0.0",
+				RangeChanges: []RangeChange{{"Lib", "1.0.0", "1.1.0", "Dep", "^1.0.0", "^2.0.0", SwitchedTarget}},
+				NewlySatisfied: []SatisfiedDeclaration{
+					{NameVersion{Name: "Fresh", Version: "1.0.0"}, Peer, "^1.1.0"},
+				},
+			},
+			{
+				Version:  "2.0.0",
+				Released: time.Date(2022, 1, 20, 0, 0, 0, 0, time.UTC),
+				Previous: "1.1.0",
+				NewlySatisfied: []SatisfiedDeclaration{
+					{NameVersion{Name: "App", Version: "1.0.0"}, Runtime, "^2.0.0"},
+					{NameVersion{Name: "Late", Version: "1.0.0"}, Runtime, "^2.0.0"},
+				},
+			},
+		}}}
+		if !reflect.DeepEqual(digest.Watched, expected) {
+			t.Errorf("Expected %+v, got %+v", expected, digest.Watched)
+		}
+	})
+
+	t.Run("Writes Markdown", func(t *testing.T) {
+		digest := ChangeDigest(testPackages("digest"), []string{"Lib", "Late"}, from, to)
+		var buffer bytes.Buffer
+		if err := digest.WriteMarkdown(&buffer); err != nil {
+			t.Fatal(err)
+		}
+		expected := "# Changes from 2022-01-01 to 2022-02-01\n\n" +
+			"- New packages: 2\n- New versions: 4\n- Changed dependency ranges: 1\n- Newly satisfiable dependency declarations: 3\n" +
+			"- Versions with an unparseable timestamp, left out: 1\n\n" +
+			"## `Late` (new)\n\n### 1.0.0 (2022-01-15)\n\n" +
+			"## `Lib`\n\n### 1.1.0 (2022-01-10)\n\nChanged dependency ranges since 1.0.0:\n\n- `Dep`: `^1.0.0` → `^2.0.0` (switched target)\n\n" +
+			"First version to satisfy:\n\n- `Fresh@1.0.0` (peer): `^1.1.0`\n\n" +
+			"### 2.0.0 (2022-01-20)\n\nFirst version to satisfy:\n\n- `App@1.0.0` (runtime): `^2.0.0`\n- `Late@1.0.0` (runtime): `^2.0.0`\n"
+		if buffer.String() != expected {
+			t.Errorf("Expected\n%s\ngot\n%s", expected, buffer.String())
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package graph
+
+import (
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// EdgeDirection is the convention by which the edges of a PackageGraph point.
+type EdgeDirection int
+
+const (
+	// DependentToDependency points every edge from the dependent version to its dependency, so that the edges of a
+	// version lead to what it needs. This is the default.
+	DependentToDependency EdgeDirection = iota
+	// DependencyToDependent points every edge from the dependency to the dependent version, so that the edges of a
+	// version lead to what it supports, as some graph tools and papers expect.
+	DependencyToDependent
+)
+
+func (direction EdgeDirection) String() string {
+	if direction == DependencyToDependent {
+		return "dependency-to-dependent"
+	}
+	return "dependent-to-dependency"
+}
+
+// WithEdgeDirection stores the edges of the graph in the given direction. It only changes how the edges of Graph, the
+// cache and the exporters point: the queries and analyses of a PackageGraph, such as Dependencies, Dependents,
+// TransitiveDependencyCounts and the depths of Metric, give the same answers in either direction, and EdgeWeight and
+// Constraint still take the dependent first. The functions that take a bare graph, such as DependencyDepths, assume
+// DependentToDependency; pass them DependencyGraph. The direction is recorded in the graph cache, so
+// LoadGraphWithOptions rejects a cache stored in another direction than the requested one.
+func WithEdgeDirection(direction EdgeDirection) Option {
+	return func(options *Options) {
+		options.EdgeDirection = direction
+	}
+}
+
+// EdgeDirection returns the direction in which the edges of Graph point.
+func (pg *PackageGraph) EdgeDirection() EdgeDirection {
+	return pg.options.EdgeDirection
+}
+
+// DependencyGraph returns the graph with its edges pointing from the dependents to their dependencies, whatever the
+// direction in which they are stored, for the functions that take a bare graph. It is Graph itself unless the graph is
+// stored with DependencyToDependent, in which case it is a view of Graph that swaps the ends of every edge.
+func (pg *PackageGraph) DependencyGraph() graph.Directed {
+	if pg.options.EdgeDirection == DependencyToDependent {
+		return reversedGraph{pg.Graph}
+	}
+	return pg.Graph
+}
+
+// reversedGraph is a view of a directed graph with every edge reversed.
+type reversedGraph struct {
+	graph.Directed
+}
+
+func (g reversedGraph) From(id int64) graph.Nodes {
+	return g.Directed.To(id)
+}
+
+func (g reversedGraph) To(id int64) graph.Nodes {
+	return g.Directed.From(id)
+}
+
+func (g reversedGraph) HasEdgeFromTo(uid, vid int64) bool {
+	return g.Directed.HasEdgeFromTo(vid, uid)
+}
+
+func (g reversedGraph) Edge(uid, vid int64) graph.Edge {
+	edge := g.Directed.Edge(vid, uid)
+	if edge == nil {
+		return nil
+	}
+	return edge.ReversedEdge()
+}
+
+// storedEdge returns the ends of the edge from the dependent to the dependency in the order in which Graph stores it.
+func (pg *PackageGraph) storedEdge(dependent, dependency int64) (int64, int64) {
+	if pg.options.EdgeDirection == DependencyToDependent {
+		return dependency, dependent
+	}
+	return dependent, dependency
+}
+
+// DependencyEdge returns the dependent and the dependency of the edge of Graph from one node ID to another, for the
+// callers that iterate over the stored edges and look up their Constraint or EdgeWeight.
+func (pg *PackageGraph) DependencyEdge(from, to int64) (dependent, dependency int64) {
+	// Swapping the ends is its own inverse.
+	return pg.storedEdge(from, to)
+}
+
+// removeEdge removes the edge from the dependent to the dependency.
+func (pg *PackageGraph) removeEdge(dependent, dependency int64) {
+	from, to := pg.storedEdge(dependent, dependency)
+	pg.Graph.RemoveEdge(from, to)
+}
+
+// orientEdges reverses the edges from dependents to dependencies in place if the options store them the other way.
+func (options *Options) orientEdges(edges [][2]int64) [][2]int64 {
+	if options.EdgeDirection == DependencyToDependent {
+		for i, edge := range edges {
+			edges[i] = [2]int64{edge[1], edge[0]}
+		}
+	}
+	return edges
+}
+
+// insertOrientedEdges is insertEdges for the edges of CreateEdges, stored in the direction of the options.
+func insertOrientedEdges(graph *simple.DirectedGraph, options *Options) func(edges [][2]int64) {
+	insert := insertEdges(graph)
+	return func(edges [][2]int64) {
+		insert(options.orientEdges(edges))
+	}
+}
@@ -0,0 +1,126 @@
+package graph
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// reversedEdgeSet returns the edges of pg like edgeSet, with their ends swapped.
+func reversedEdgeSet(pg *PackageGraph) map[[2]string]bool {
+	reversed := make(map[[2]string]bool)
+	for edge := range edgeSet(pg) {
+		reversed[[2]string{edge[1], edge[0]}] = true
+	}
+	return reversed
+}
+
+func TestEdgeDirection(t *testing.T) {
+	lib, web, blog := NameVersion{Name: "Lib", Version: "1.0.0"}, NameVersion{Name: "Web", Version: "1.0.0"}, NameVersion{Name: "Blog", Version: "1.0.0"}
+	build := func(opts ...Option) *PackageGraph {
+		packagesInfo := testPackages("page")
+		return NewPackageGraph(&packagesInfo, false, opts...)
+	}
+	reversed := WithEdgeDirection(DependencyToDependent)
+
+	t.Run("Stores the edges in the given direction", func(t *testing.T) {
+		pg := build(reversed)
+		if expected, edges := reversedEdgeSet(build()), edgeSet(pg); !reflect.DeepEqual(edges, expected) {
+			t.Errorf("Expected %v, got %v", expected, edges)
+		}
+		if pg.EdgeDirection() != DependencyToDependent || build().EdgeDirection() != DependentToDependency {
+			t.Errorf("Expected the directions of the options")
+		}
+	})
+
+	t.Run("Answers the queries like the default direction", func(t *testing.T) {
+		expected := build()
+		for _, pg := range []*PackageGraph{build(reversed), build(reversed, WithLazyEdges())} {
+			for _, nameVersion := range []NameVersion{lib, web, blog} {
+				expectedDependencies, _ := expected.Dependencies(nameVersion, -1)
+				dependencies, _ := pg.Dependencies(nameVersion, -1)
+				expectedDependents, _ := expected.Dependents(nameVersion, -1)
+				dependents, _ := pg.Dependents(nameVersion, -1)
+				if !reflect.DeepEqual(pageNames(dependencies), pageNames(expectedDependencies)) || !reflect.DeepEqual(pageNames(dependents), pageNames(expectedDependents)) {
+					t.Errorf("Expected the dependencies %v and dependents %v of %s, got %v and %v", pageNames(expectedDependencies),
+						pageNames(expectedDependents), nameVersion, pageNames(dependencies), pageNames(dependents))
+				}
+			}
+			if path, _ := pg.ShortestPath(blog, lib); !reflect.DeepEqual(pageNames(path), []string{"Blog", "Cli", "Lib"}) {
+				t.Errorf("Expected the path Blog, Cli, Lib, got %v", pageNames(path))
+			}
+			page, total, err := DependentsPage(pg, lib, true, 0, 3, SortByDependents)
+			if err != nil || total != 5 || !reflect.DeepEqual(pageNames(page), []string{"Web", "Cli", "App"}) {
+				t.Errorf("Expected Web, Cli and App of 5 dependents, got %v of %d and %v", pageNames(page), total, err)
+			}
+		}
+	})
+
+	t.Run("Computes the metrics like the default direction", func(t *testing.T) {
+		names := []string{"indegree", "outdegree", "transitive", "pagerank", "core", "depth"}
+		expected, err := ComputeMetrics(build(), names)
+		if err != nil {
+			t.Fatal(err)
+		}
+		metrics, err := ComputeMetrics(build(reversed), names)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// PageRank only converges to within its tolerance, in an order that depends on the direction of the edges.
+		for _, name := range names {
+			for id, score := range expected[name] {
+				if math.Abs(metrics[name][id]-score) > 1e-6 {
+					t.Errorf("Expected %s %v of node %d, got %v", name, score, id, metrics[name][id])
+				}
+			}
+		}
+		if stats, expectedStats := build(reversed).Stats(), build().Stats(); stats != expectedStats {
+			t.Errorf("Expected the stats %+v, got %+v", expectedStats, stats)
+		}
+	})
+
+	t.Run("Updates the graph incrementally in the given direction", func(t *testing.T) {
+		pg, expected := build(reversed), build()
+		for _, graph := range []*PackageGraph{pg, expected} {
+			if err := graph.AddVersion("Lib", "1.1.0", VersionInfo{Timestamp: "2022-04-01T00:00:00"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := graph.RemoveVersion("Web", "1.0.0", true); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if edges := edgeSet(pg); !reflect.DeepEqual(edges, reversedEdgeSet(expected)) {
+			t.Errorf("Expected %v, got %v", reversedEdgeSet(expected), edges)
+		}
+		collapsed := CollapsePackages(pg)
+		app, _ := collapsed.Package("App")
+		libPackage, _ := collapsed.Package("Lib")
+		if collapsed.Weight(app, libPackage) != 2 || collapsed.Weight(libPackage, app) != 0 {
+			t.Errorf("Expected the package edge from App to Lib with weight 2, got %d and %d", collapsed.Weight(app, libPackage), collapsed.Weight(libPackage, app))
+		}
+	})
+
+	t.Run("Records the direction in the cache", func(t *testing.T) {
+		var cache bytes.Buffer
+		if err := SaveGraph(&cache, build(reversed)); err != nil {
+			t.Fatal(err)
+		}
+		data := cache.Bytes()
+		loaded, err := LoadGraph(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loaded.EdgeDirection() != DependencyToDependent || !reflect.DeepEqual(edgeSet(loaded), reversedEdgeSet(build())) {
+			t.Errorf("Expected the reversed edges, got %v", edgeSet(loaded))
+		}
+		if _, err := LoadGraphWithOptions(bytes.NewReader(data), false, reversed); err != nil {
+			t.Errorf("Expected the cache to load in its direction, got %v", err)
+		}
+		var mismatch *OptionMismatchError
+		if _, err := LoadGraphWithOptions(bytes.NewReader(data), false); !errors.As(err, &mismatch) || mismatch.Option != "edge-direction" {
+			t.Errorf("Expected a mismatch of the edge direction, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package graph
+
+// DuplicateStats counts the packages that were listed more than once in the input and merged into one.
+type DuplicateStats struct {
+	// MergedPackages is the number of package entries that were merged into an earlier entry of the same package.
+	MergedPackages int `json:"mergedPackages"`
+	// ConflictingVersions is the number of versions that were listed by several entries of a package with a different
+	// timestamp or different dependencies. The version of the first entry is kept.
+	ConflictingVersions int `json:"conflictingVersions"`
+}
+
+// MergeDuplicatePackages returns the packages with the entries that share a name and an ecosystem merged into the
+// first of them, as found in sharded exports that list a package in several shards. The versions of the later entries
+// are added to the first one; a version that is listed several times keeps the metadata of its first entry, and
+// identical copies are merged silently. The input is returned as is if every package is listed once; otherwise it is
+// copied, leaving the caller's packages unchanged.
+func MergeDuplicatePackages(packagesList *[]PackageInfo) (*[]PackageInfo, DuplicateStats) {
+	var stats DuplicateStats
+	first := make(map[PackageKey]int, len(*packagesList))
+	for i, packageInfo := range *packagesList {
+		if _, seen := first[packageInfo.key()]; seen {
+			stats.MergedPackages++
+		} else {
+			first[packageInfo.key()] = i
+		}
+	}
+	if stats.MergedPackages == 0 {
+		return packagesList, stats
+	}
+
+	merged := make([]PackageInfo, 0, len(first))
+	index := make(map[PackageKey]int, len(first))
+	for _, packageInfo := range *packagesList {
+		i, seen := index[packageInfo.key()]
+		if !seen {
+			index[packageInfo.key()] = len(merged)
+			versions := make(map[string]VersionInfo, len(packageInfo.Versions))
+			for version, versionInfo := range packageInfo.Versions {
+				versions[version] = versionInfo
+			}
+			merged = append(merged, PackageInfo{Name: packageInfo.Name, Ecosystem: packageInfo.Ecosystem, Versions: versions, Provenance: packageInfo.Provenance})
+			continue
+		}
+		versions := merged[i].Versions
+		for version, versionInfo := range packageInfo.Versions {
+			existing, listed := versions[version]
+			if !listed {
+				versions[version] = versionInfo
+			} else if !versionInfoEqual(existing, versionInfo) {
+				stats.ConflictingVersions++
+			}
+		}
+	}
+	return &merged, stats
+}
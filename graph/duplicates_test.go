@@ -0,0 +1,70 @@
+package graph
+
+import (
+	"testing"
+)
+
+func TestMergeDuplicatePackages(t *testing.T) {
+	createPackages := func() []PackageInfo {
+		return []PackageInfo{
+			{Name: "A", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{}},
+				"1.1.0": {Timestamp: "2020-02-01T00:00:00", Dependencies: map[string]string{}},
+			}},
+			{Name: "B", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2020-03-01T00:00:00", Dependencies: map[string]string{"A": "^1.0.0"}},
+			}},
+			{Name: "A", Versions: map[string]VersionInfo{
+				"1.1.0": {Timestamp: "2020-02-01T00:00:00"},
+				"1.2.0": {Timestamp: "2020-04-01T00:00:00", Dependencies: map[string]string{}},
+			}},
+			{Name: "A", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2021-01-01T00:00:00", Dependencies: map[string]string{}},
+			}},
+		}
+	}
+
+	t.Run("Merges the versions into the first entry", func(t *testing.T) {
+		packagesInfo := createPackages()
+		merged, stats := MergeDuplicatePackages(&packagesInfo)
+		if stats.MergedPackages != 2 || stats.ConflictingVersions != 1 {
+			t.Errorf("Expected 2 merged packages and 1 conflicting version, got %+v", stats)
+		}
+		if len(*merged) != 2 || (*merged)[0].Name != "A" || len((*merged)[0].Versions) != 3 {
+			t.Fatalf("Expected A with 3 versions followed by B, got %v", *merged)
+		}
+		if timestamp := (*merged)[0].Versions["1.0.0"].Timestamp; timestamp != "2020-01-01T00:00:00" {
+			t.Errorf("Expected the first entry of A 1.0.0 to be kept, got %s", timestamp)
+		}
+		if len(packagesInfo[0].Versions) != 2 {
+			t.Error("Expected the input to be left unchanged")
+		}
+	})
+
+	t.Run("Returns the input without duplicates as is", func(t *testing.T) {
+		packagesInfo := createPackages()[:2]
+		merged, stats := MergeDuplicatePackages(&packagesInfo)
+		if merged != &packagesInfo || stats != (DuplicateStats{}) {
+			t.Errorf("Expected the input and no merges, got %+v", stats)
+		}
+	})
+
+	t.Run("Creates one node per version", func(t *testing.T) {
+		packagesInfo := createPackages()
+		report := &ResolutionReport{}
+		pg := NewPackageGraph(&packagesInfo, false, WithResolutionReport(report))
+		if nodes := pg.Graph.Nodes().Len(); nodes != 4 {
+			t.Errorf("Expected 4 nodes, got %d", nodes)
+		}
+		if report.Duplicates.MergedPackages != 2 {
+			t.Errorf("Expected 2 merged packages in the report, got %+v", report.Duplicates)
+		}
+		if err := ValidateStrict(pg); err != nil {
+			t.Errorf("Expected a valid graph, got %v", err)
+		}
+		dependencies, _ := pg.Dependencies(NameVersion{Name: "B", Version: "1.0.0"}, 1)
+		if len(dependencies) != 3 {
+			t.Errorf("Expected B to depend on the 3 versions of A, got %v", dependencies)
+		}
+	})
+}
@@ -0,0 +1,265 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ecosystemSeparator separates the ecosystem from the package name in a qualified name, as in "pypi:requests".
+const ecosystemSeparator = ":"
+
+// PackageKey identifies a package of a graph by its ecosystem, its npm scope and its name, under which the graph
+// indexes the package. Packages of the same name in different ecosystems, such as "requests" on PyPI and npm, have
+// different keys, so they stay separate, and the dependencies of a version are only looked up in its own ecosystem.
+// Keys are created with NewPackageKey, which splits the scope off the name.
+type PackageKey struct {
+	// Ecosystem is the ecosystem of the package, or empty for the packages without one.
+	Ecosystem string
+	// Scope is the npm scope without the leading "@", such as "babel" for "@babel/core", or empty for an unscoped
+	// name.
+	Scope string
+	// Name is the name of the package within its scope.
+	Name string
+}
+
+// NewPackageKey returns the key of the package with the given ecosystem and name. A name of the form "@scope/name" is
+// split into its scope and the name within the scope. The name is used as is; the lookups of a graph normalize it
+// first, so that an encoded name such as "%40babel%2Fcore" gets the key of "@babel/core".
+func NewPackageKey(ecosystem, name string) PackageKey {
+	if strings.HasPrefix(name, "@") {
+		if separator := strings.IndexByte(name, '/'); separator > 1 {
+			return PackageKey{Ecosystem: ecosystem, Scope: name[1:separator], Name: name[separator+1:]}
+		}
+	}
+	return PackageKey{Ecosystem: ecosystem, Name: name}
+}
+
+// FullName returns the name of the package in its ecosystem, in the "@scope/name" form for a scoped name.
+func (key PackageKey) FullName() string {
+	if key.Scope == "" {
+		return key.Name
+	}
+	return "@" + key.Scope + "/" + key.Name
+}
+
+// String returns the QualifiedName of the package.
+func (key PackageKey) String() string {
+	return QualifiedName(key.Ecosystem, key.FullName())
+}
+
+// version returns the given version of the package.
+func (key PackageKey) version(version string) NameVersion {
+	return NameVersion{Name: key.FullName(), Version: version, Ecosystem: key.Ecosystem}
+}
+
+// sortedPackageKeys returns the keys of the map sorted by name, then by ecosystem.
+func sortedPackageKeys(m map[PackageKey][]string) []PackageKey {
+	keys := make([]PackageKey, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].less(keys[j]) })
+	return keys
+}
+
+// less orders the keys by full name and then by ecosystem.
+func (key PackageKey) less(other PackageKey) bool {
+	if name, otherName := key.FullName(), other.FullName(); name != otherName {
+		return name < otherName
+	}
+	return key.Ecosystem < other.Ecosystem
+}
+
+// key returns the key of the package.
+func (packageInfo PackageInfo) key() PackageKey {
+	return NewPackageKey(packageInfo.Ecosystem, packageInfo.Name)
+}
+
+// key returns the key of the package of the node.
+func (nodeInfo NodeInfo) key() PackageKey {
+	return NewPackageKey(nodeInfo.Ecosystem, nodeInfo.Name)
+}
+
+// key returns the key of the package of the version.
+func (nameVersion NameVersion) key() PackageKey {
+	return NewPackageKey(nameVersion.Ecosystem, nameVersion.Name)
+}
+
+// QualifiedName returns "<ecosystem>:<name>" for a package of the given ecosystem, and the name as is without an
+// ecosystem. It is how NameVersion and the string IDs of the nodes show the ecosystem, and the lookups of a graph by
+// name, such as FindNode and Versions, accept a qualified name for a package of one of the ecosystems of the graph.
+func QualifiedName(ecosystem, name string) string {
+	if ecosystem == "" {
+		return name
+	}
+	return ecosystem + ecosystemSeparator + name
+}
+
+// packageKey returns the key of the package that a lookup by the given ecosystem and name refers to, with the name
+// normalized like the names the graph was built from. Without an ecosystem, a name qualified with one of the
+// ecosystems of the graph refers to the package of that ecosystem.
+func (pg *PackageGraph) packageKey(ecosystem, name string) PackageKey {
+	if ecosystem == "" {
+		ecosystem, name = splitQualifiedName(pg.ecosystems, name)
+	}
+	return NewPackageKey(ecosystem, pg.normalizeName(ecosystem, name))
+}
+
+// splitQualifiedName splits a name that may be qualified with one of the ecosystems into the ecosystem and the name,
+// without normalizing the name.
+func splitQualifiedName(ecosystems map[string]bool, name string) (string, string) {
+	if separator := strings.Index(name, ecosystemSeparator); separator > 0 && ecosystems[name[:separator]] {
+		return name[:separator], name[separator+len(ecosystemSeparator):]
+	}
+	return "", name
+}
+
+// packageEcosystems returns the set of the ecosystems of the packages, without the empty ecosystem.
+func packageEcosystems(packagesList *[]PackageInfo) map[string]bool {
+	ecosystems := make(map[string]bool)
+	for _, packageInfo := range *packagesList {
+		if packageInfo.Ecosystem != "" {
+			ecosystems[packageInfo.Ecosystem] = true
+		}
+	}
+	return ecosystems
+}
+
+// canonical returns the version that a lookup of the given version refers to, see packageKey.
+func (pg *PackageGraph) canonical(nameVersion NameVersion) NameVersion {
+	return pg.packageKey(nameVersion.Ecosystem, nameVersion.Name).version(nameVersion.Version)
+}
+
+// WithEcosystems only includes the packages of the given ecosystems. The empty ecosystem selects the packages
+// without one.
+func WithEcosystems(ecosystems ...string) Option {
+	return func(options *Options) {
+		options.Ecosystems = ecosystems
+	}
+}
+
+// includesEcosystem reports whether the options include the packages of the ecosystem.
+func (options *Options) includesEcosystem(ecosystem string) bool {
+	if len(options.Ecosystems) == 0 {
+		return true
+	}
+	for _, included := range options.Ecosystems {
+		if included == ecosystem {
+			return true
+		}
+	}
+	return false
+}
+
+// Ecosystems returns the number of versions of every ecosystem of the graph. The versions of the packages without an
+// ecosystem are counted under the empty string.
+func (pg *PackageGraph) Ecosystems() map[string]int {
+	counts := make(map[string]int)
+	for _, node := range pg.Nodes {
+		if node.stringID != "" {
+			counts[node.Ecosystem]++
+		}
+	}
+	return counts
+}
+
+// EcosystemCounts is Ecosystems sorted like SortedCounts, largest ecosystem first.
+func (pg *PackageGraph) EcosystemCounts() []NameCount {
+	return SortedCounts(pg.Ecosystems())
+}
+
+// SearchEcosystem returns the IDs of the versions of the packages of the ecosystem whose name matches the pattern,
+// like SearchNodes, in increasing order.
+func (pg *PackageGraph) SearchEcosystem(ecosystem, pattern string, mode MatchMode, ignoreCase bool) ([]int64, error) {
+	matches, err := nameMatcher(pattern, mode, ignoreCase)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for _, packageInfo := range *pg.Packages {
+		if packageInfo.Ecosystem == ecosystem && matches(packageInfo.Name) {
+			ids = append(ids, pg.versionIDs(packageInfo.key())...)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// CrossEdge is a dependency between versions of different ecosystems, such as a Python package wrapping npm assets,
+// which no dependency declaration creates.
+type CrossEdge struct {
+	From       NameVersion
+	To         NameVersion
+	Constraint string
+}
+
+// MergeWithCrossEdges merges the graphs like Merge and adds the given cross-ecosystem edges between versions of the
+// result. The edges have the class CrossEcosystem, see EdgeClass, and their constraint is returned by Constraint. An
+// error is returned if a version of an edge is not part of the result or if both belong to the same ecosystem.
+func MergeWithCrossEdges(a, b *PackageGraph, policy ConflictPolicy, crossEdges []CrossEdge) (*PackageGraph, error) {
+	pg, err := Merge(a, b, policy)
+	if err != nil {
+		return nil, err
+	}
+	for _, edge := range crossEdges {
+		from, ok := pg.FindNode(edge.From)
+		if !ok {
+			return nil, fmt.Errorf("cross-ecosystem edge from unknown version %s", edge.From)
+		}
+		to, ok := pg.FindNode(edge.To)
+		if !ok {
+			return nil, fmt.Errorf("cross-ecosystem edge to unknown version %s", edge.To)
+		}
+		if from.Ecosystem == to.Ecosystem {
+			return nil, fmt.Errorf("cross-ecosystem edge between %s and %s of the same ecosystem", edge.From, edge.To)
+		}
+		pg.setCrossEdge(from.id, to.id, edge.Constraint)
+	}
+	return pg, nil
+}
+
+// setCrossEdge adds a cross-ecosystem edge with its constraint.
+func (pg *PackageGraph) setCrossEdge(from, to int64, constraint string) {
+	if pg.crossEdges == nil {
+		pg.crossEdges = make(map[[2]int64]string)
+	}
+	pg.crossEdges[[2]int64{from, to}] = constraint
+	pg.setEdge(from, to)
+}
+
+// copyCrossEdges adds the cross-ecosystem edges of source to pg, between the versions that newID maps to pg.
+func (pg *PackageGraph) copyCrossEdges(source *PackageGraph, newID func(id int64) (int64, bool)) {
+	for edge, constraint := range source.crossEdges {
+		from, fromOK := newID(edge[0])
+		to, toOK := newID(edge[1])
+		if fromOK && toOK {
+			pg.setCrossEdge(from, to, constraint)
+		}
+	}
+}
+
+// EdgeClass returns the class of the dependency of the edge from one version to another: CrossEcosystem for the
+// edges added by MergeWithCrossEdges, and otherwise the first class, in the order Runtime, Development, Peer and
+// Optional, in which from declares a dependency on the package of to. The bool is false if from declares none.
+func (pg *PackageGraph) EdgeClass(from, to int64) (DependencyClass, bool) {
+	if _, ok := pg.crossEdges[[2]int64{from, to}]; ok {
+		return CrossEcosystem, true
+	}
+	fromInfo, ok := pg.Node(from)
+	if !ok {
+		return 0, false
+	}
+	toInfo, _ := pg.Node(to)
+	toName := toInfo.Name
+	if alias, ok := pg.EdgeAlias(from, to); ok {
+		toName = alias
+	}
+	versionInfo, _ := pg.VersionInfo(fromInfo.NameVersion())
+	for _, class := range []DependencyClass{Runtime, Development, Peer, Optional} {
+		if _, ok := versionInfo.DependenciesOf(class)[toName]; ok {
+			return class, true
+		}
+	}
+	return 0, false
+}
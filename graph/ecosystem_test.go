@@ -0,0 +1,200 @@
+package graph
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestEcosystems(t *testing.T) {
+	t.Run("Keeps packages of the same name in different ecosystems apart", func(t *testing.T) {
+		packagesInfo := testPackages("ecosystems")
+		pg := NewPackageGraph(&packagesInfo, false)
+		if nodes := pg.Graph.Nodes().Len(); nodes != 5 {
+			t.Fatalf("Expected 5 nodes, got %d", nodes)
+		}
+		node, ok := pg.FindNode(NameVersion{Name: "requests", Version: "0.3.0", Ecosystem: "npm"})
+		if !ok || node.Ecosystem != "npm" || node.Name != "requests" {
+			t.Errorf("Expected npm requests 0.3.0, got %v", node)
+		}
+		if qualified, _ := pg.FindNode(NameVersion{Name: QualifiedName("npm", "requests"), Version: "0.3.0"}); qualified != node {
+			t.Errorf("Expected the qualified name to find npm requests 0.3.0, got %v", qualified)
+		}
+		if _, ok := pg.FindNode(NameVersion{Name: "requests", Version: "0.3.0"}); ok {
+			t.Error("Expected no version of requests without an ecosystem")
+		}
+		if versions := pg.Versions("pypi:requests"); len(versions) != 1 || versions[0].Version != "2.31.0" {
+			t.Errorf("Expected the pypi version of requests, got %v", versions)
+		}
+		if packagesInfo[0].Name != "requests" {
+			t.Error("Expected the input to be left unchanged")
+		}
+		counts := pg.Ecosystems()
+		if counts["pypi"] != 3 || counts["npm"] != 2 {
+			t.Errorf("Expected 3 pypi and 2 npm versions, got %v", counts)
+		}
+		if sorted := pg.EcosystemCounts(); !reflect.DeepEqual(sorted, []NameCount{{"pypi", 3}, {"npm", 2}}) {
+			t.Errorf("Expected pypi before npm, got %v", sorted)
+		}
+	})
+
+	t.Run("Resolves dependencies within their own ecosystem", func(t *testing.T) {
+		packagesInfo := testPackages("ecosystems")
+		pg := NewPackageGraph(&packagesInfo, false)
+		edges := edgeSet(pg)
+		expected := [][2]string{
+			{"pypi:requests-2.31.0", "pypi:urllib3-2.0.0"},
+			{"npm:requests-0.3.0", "npm:urllib3-1.0.0"},
+			{"pypi:app-1.0.0", "pypi:requests-2.31.0"},
+		}
+		if len(edges) != len(expected) {
+			t.Errorf("Expected %d edges, got %v", len(expected), edges)
+		}
+		for _, edge := range expected {
+			if !edges[edge] {
+				t.Errorf("Expected edge %v, got %v", edge, edges)
+			}
+		}
+	})
+
+	t.Run("Resolves the dependencies of added versions within their ecosystem", func(t *testing.T) {
+		packagesInfo := testPackages("ecosystems")
+		pg := NewPackageGraph(&packagesInfo, false)
+		if err := pg.AddVersion("pypi:app", "1.1.0", VersionInfo{Dependencies: map[string]string{"requests": "*"}}); err != nil {
+			t.Fatal(err)
+		}
+		added, _ := pg.FindNode(NameVersion{Name: "pypi:app", Version: "1.1.0"})
+		dependencies, _ := pg.Dependencies(NameVersion{Name: "pypi:app", Version: "1.1.0"}, 1)
+		if added.Ecosystem != "pypi" || len(dependencies) != 1 || dependencies[0].Name != "requests" || dependencies[0].Ecosystem != "pypi" {
+			t.Errorf("Expected a pypi version depending on pypi requests, got %v with %v", added, dependencies)
+		}
+	})
+
+	t.Run("Only includes the selected ecosystems", func(t *testing.T) {
+		packagesInfo := testPackages("ecosystems")
+		pg := NewPackageGraph(&packagesInfo, false, WithEcosystems("npm"))
+		if counts := pg.Ecosystems(); len(counts) != 1 || counts["npm"] != 2 {
+			t.Errorf("Expected only the 2 npm versions, got %v", counts)
+		}
+	})
+
+	t.Run("Searches the local names of an ecosystem", func(t *testing.T) {
+		packagesInfo := testPackages("ecosystems")
+		pg := NewPackageGraph(&packagesInfo, false)
+		ids, err := pg.SearchEcosystem("pypi", "req*", MatchGlob, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ids) != 1 || pg.Nodes[ids[0]].NameVersion() != (NameVersion{Name: "requests", Version: "2.31.0", Ecosystem: "pypi"}) {
+			t.Errorf("Expected pypi requests, got %v", ids)
+		}
+	})
+}
+
+func TestMergeWithCrossEdges(t *testing.T) {
+	createGraphs := func() (*PackageGraph, *PackageGraph) {
+		packagesInfo := testPackages("ecosystems")
+		pypi := append([]PackageInfo{}, packagesInfo[0], packagesInfo[1], packagesInfo[4])
+		npm := append([]PackageInfo{}, packagesInfo[2], packagesInfo[3])
+		return NewPackageGraph(&pypi, false), NewPackageGraph(&npm, false)
+	}
+	crossEdge := CrossEdge{
+		From:       NameVersion{Name: "app", Version: "1.0.0", Ecosystem: "pypi"},
+		To:         NameVersion{Name: "requests", Version: "0.3.0", Ecosystem: "npm"},
+		Constraint: "0.3.x",
+	}
+
+	t.Run("Adds the cross-ecosystem edges", func(t *testing.T) {
+		a, b := createGraphs()
+		pg, err := MergeWithCrossEdges(a, b, PreferA, []CrossEdge{crossEdge})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		from, to := pg.StringIDToNodeInfo["pypi:app-1.0.0"].id, pg.StringIDToNodeInfo["npm:requests-0.3.0"].id
+		if !pg.Graph.HasEdgeFromTo(from, to) {
+			t.Fatal("Expected the cross-ecosystem edge")
+		}
+		if class, ok := pg.EdgeClass(from, to); !ok || class != CrossEcosystem {
+			t.Errorf("Expected the class %v, got %v", CrossEcosystem, class)
+		}
+		if constraint, _ := pg.Constraint(from, to); constraint != "0.3.x" {
+			t.Errorf("Expected the constraint 0.3.x, got %q", constraint)
+		}
+		dependency := pg.StringIDToNodeInfo["pypi:requests-2.31.0"].id
+		if class, ok := pg.EdgeClass(from, dependency); !ok || class != Runtime {
+			t.Errorf("Expected the class %v, got %v", Runtime, class)
+		}
+	})
+
+	t.Run("Rejects edges within an ecosystem or to unknown versions", func(t *testing.T) {
+		for _, edge := range []CrossEdge{
+			{From: NameVersion{Name: "pypi:app", Version: "1.0.0"}, To: NameVersion{Name: "pypi:urllib3", Version: "2.0.0"}},
+			{From: NameVersion{Name: "pypi:app", Version: "1.0.0"}, To: NameVersion{Name: "npm:requests", Version: "9.9.9"}},
+		} {
+			a, b := createGraphs()
+			if _, err := MergeWithCrossEdges(a, b, PreferA, []CrossEdge{edge}); err == nil {
+				t.Errorf("Expected an error for %v", edge)
+			}
+		}
+	})
+
+	t.Run("Keeps the ecosystems and cross-ecosystem edges in the cache", func(t *testing.T) {
+		a, b := createGraphs()
+		pg, err := MergeWithCrossEdges(a, b, PreferA, []CrossEdge{crossEdge})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buffer bytes.Buffer
+		if err := SaveGraph(&buffer, pg); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadGraph(&buffer)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		from, to := loaded.StringIDToNodeInfo["pypi:app-1.0.0"], loaded.StringIDToNodeInfo["npm:requests-0.3.0"]
+		if from.Ecosystem != "pypi" || to.Ecosystem != "npm" {
+			t.Errorf("Expected the ecosystems pypi and npm, got %q and %q", from.Ecosystem, to.Ecosystem)
+		}
+		if class, _ := loaded.EdgeClass(from.id, to.id); class != CrossEcosystem {
+			t.Errorf("Expected the class %v, got %v", CrossEcosystem, class)
+		}
+		if constraint, _ := loaded.Constraint(from.id, to.id); constraint != "0.3.x" {
+			t.Errorf("Expected the constraint 0.3.x, got %q", constraint)
+		}
+	})
+}
+
+func TestPackageKey(t *testing.T) {
+	t.Run("Splits the scope off the name", func(t *testing.T) {
+		key := NewPackageKey("npm", "@babel/core")
+		if key != (PackageKey{Ecosystem: "npm", Scope: "babel", Name: "core"}) {
+			t.Errorf("Expected the scope babel and the name core, got %#v", key)
+		}
+		if key.FullName() != "@babel/core" || key.String() != "npm:@babel/core" {
+			t.Errorf("Expected @babel/core and npm:@babel/core, got %s and %s", key.FullName(), key.String())
+		}
+		for _, name := range []string{"left-pad", "@", "@/core", "@babel"} {
+			if key := NewPackageKey("", name); key.Scope != "" || key.FullName() != name {
+				t.Errorf("Expected %s to have no scope, got %#v", name, key)
+			}
+		}
+	})
+
+	t.Run("Looks up scoped names in any form", func(t *testing.T) {
+		packagesInfo := []PackageInfo{
+			{Name: "%40babel%2Fcore", Ecosystem: "npm", Versions: map[string]VersionInfo{
+				"7.1.0": {Timestamp: "2021-01-01T00:00:00", Dependencies: map[string]string{}},
+			}},
+		}
+		pg := NewPackageGraph(&packagesInfo, false)
+		if _, ok := pg.NameToVersions[PackageKey{Ecosystem: "npm", Scope: "babel", Name: "core"}]; !ok {
+			t.Errorf("Expected the package to be keyed by its scope and name, got %v", pg.NameToVersions)
+		}
+		for _, name := range []string{"npm:@babel/core", "npm:%40babel%2Fcore", "npm:@Babel/Core"} {
+			if versions := pg.Versions(name); len(versions) != 1 || versions[0].Name != "@babel/core" {
+				t.Errorf("Expected the version of @babel/core for %s, got %v", name, versions)
+			}
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package graph
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// EdgeSampleStrategy determines how SampleEdges picks the edges it keeps.
+type EdgeSampleStrategy int
+
+const (
+	// EdgeSampleUniform picks the edges uniformly at random.
+	EdgeSampleUniform EdgeSampleStrategy = iota
+	// EdgeSampleDegree picks the edges with a probability proportional to the sum of the degrees of their ends, so
+	// that the hubs keep a larger share of their edges than the versions with few.
+	EdgeSampleDegree
+	// EdgeSampleSpanning first keeps a random spanning forest of the graph, ignoring the direction of the edges, so
+	// that the kept versions are connected as they are in the graph, and then picks the rest of the edges uniformly at
+	// random. The forest is kept in full even if it has more edges than the fraction.
+	EdgeSampleSpanning
+)
+
+// SampleEdges returns a graph of a fraction of the edges of pg, picked by strategy, for figures of graphs too large to
+// draw in full. The number of edges kept is the fraction of the edges of pg, rounded, and the fraction is clamped to
+// [0, 1]. The sample only depends on the graph and the seed.
+//
+// The sample contains the versions at the ends of the kept edges, so the versions whose edges were all left out, and
+// those without any, are not part of it. Like SampleSubgraph, it is a complete PackageGraph built with the settings of
+// pg, whose versions keep their relative order and get the IDs from 0 up, or keep their ID with HashedIDs. The edges of
+// a graph with lazy edges are those created so far.
+func SampleEdges(pg *PackageGraph, keepFraction float64, strategy EdgeSampleStrategy, seed int64) *PackageGraph {
+	pg.materializeExternalEdges()
+	var edges [][2]int64
+	for it := pg.Graph.Edges(); it.Next(); {
+		edges = append(edges, [2]int64{it.Edge().From().ID(), it.Edge().To().ID()})
+	}
+	// The edges are sorted first, so that the sample does not depend on the order in which Graph returns them.
+	sortEdges(edges)
+	keepFraction = math.Max(0, math.Min(1, keepFraction))
+	n := int(math.Round(keepFraction * float64(len(edges))))
+
+	random := rand.New(rand.NewSource(seed))
+	var kept [][2]int64
+	switch strategy {
+	case EdgeSampleUniform:
+		random.Shuffle(len(edges), func(i, j int) { edges[i], edges[j] = edges[j], edges[i] })
+		kept = edges[:n]
+	case EdgeSampleDegree:
+		kept = pg.sampleEdgesByDegree(edges, n, random)
+	case EdgeSampleSpanning:
+		kept = sampleSpanningEdges(edges, n, random)
+	}
+
+	members := make(map[int64]bool)
+	keep := make(map[[2]int64]bool, len(kept))
+	for _, edge := range kept {
+		members[edge[0]], members[edge[1]] = true, true
+		keep[edge] = true
+	}
+	return pg.subgraph(members, func(from, to int64) bool { return keep[[2]int64{from, to}] })
+}
+
+// sampleEdgesByDegree picks n of the edges without replacement, with a probability proportional to the sum of the
+// degrees of their ends. Every edge gets the key log(u)/w for a uniform u and its weight w, and the edges with the n
+// largest keys are picked, which samples by weight in a single pass (Efraimidis and Spirakis, 2006).
+func (pg *PackageGraph) sampleEdgesByDegree(edges [][2]int64, n int, random *rand.Rand) [][2]int64 {
+	degrees := make(map[int64]int)
+	degree := func(id int64) int {
+		if d, ok := degrees[id]; ok {
+			return d
+		}
+		d := pg.Graph.From(id).Len() + pg.Graph.To(id).Len()
+		degrees[id] = d
+		return d
+	}
+	keys := make([]float64, len(edges))
+	for i, edge := range edges {
+		keys[i] = math.Log(random.Float64()) / float64(degree(edge[0])+degree(edge[1]))
+	}
+	order := make([]int, len(edges))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return keys[order[i]] > keys[order[j]] })
+	kept := make([][2]int64, n)
+	for i := range kept {
+		kept[i] = edges[order[i]]
+	}
+	return kept
+}
+
+// sampleSpanningEdges keeps the edges of a random spanning forest, found by adding the edges in a random order and
+// keeping those that join two trees, followed by the other edges in the same order until there are n.
+func sampleSpanningEdges(edges [][2]int64, n int, random *rand.Rand) [][2]int64 {
+	random.Shuffle(len(edges), func(i, j int) { edges[i], edges[j] = edges[j], edges[i] })
+	parents := make(map[int64]int64)
+	root := func(id int64) int64 {
+		for {
+			parent, ok := parents[id]
+			if !ok {
+				return id
+			}
+			// Path halving keeps the trees flat.
+			if grandparent, ok := parents[parent]; ok {
+				parents[id] = grandparent
+			}
+			id = parent
+		}
+	}
+	var kept, rest [][2]int64
+	for _, edge := range edges {
+		from, to := root(edge[0]), root(edge[1])
+		if from == to {
+			rest = append(rest, edge)
+			continue
+		}
+		parents[from] = to
+		kept = append(kept, edge)
+	}
+	if missing := n - len(kept); missing > 0 {
+		kept = append(kept, rest[:missing]...)
+	}
+	return kept
+}
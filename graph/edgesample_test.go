@@ -0,0 +1,112 @@
+package graph
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// components counts the connected components of the versions of the graph that have an edge, ignoring the direction
+// of the edges.
+func components(pg *PackageGraph) int {
+	component := make(map[int64]int)
+	count := 0
+	for _, id := range pg.nodeIDs() {
+		if _, ok := component[id]; ok || len(pg.neighbours(id)) == 0 {
+			continue
+		}
+		count++
+		queue := []int64{id}
+		component[id] = count
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			for _, neighbour := range pg.neighbours(current) {
+				if _, ok := component[neighbour]; !ok {
+					component[neighbour] = count
+					queue = append(queue, neighbour)
+				}
+			}
+		}
+	}
+	return count
+}
+
+func TestSampleEdges(t *testing.T) {
+	packagesInfo := testPackages("sample")
+	pg := NewPackageGraph(&packagesInfo, false)
+	original := edgeSet(pg)
+
+	for _, strategy := range []EdgeSampleStrategy{EdgeSampleUniform, EdgeSampleDegree, EdgeSampleSpanning} {
+		t.Run(fmt.Sprintf("Samples a valid graph with strategy %d", strategy), func(t *testing.T) {
+			sample := SampleEdges(pg, 0.3, strategy, 7)
+			if errs := Validate(sample); len(errs) != 0 {
+				t.Errorf("Expected no validation errors, got %v", errs)
+			}
+			edges := edgeSet(sample)
+			if expected := (len(original)*3 + 5) / 10; strategy != EdgeSampleSpanning && len(edges) != expected {
+				t.Errorf("Expected %d edges, got %d", expected, len(edges))
+			}
+			for edge := range edges {
+				if !original[edge] {
+					t.Errorf("Expected only edges of the original graph, got %v", edge)
+				}
+			}
+			for _, node := range sample.Nodes {
+				if len(sample.neighbours(node.id)) == 0 {
+					t.Errorf("Expected only versions with a kept edge, got %s", node)
+				}
+			}
+			if again := SampleEdges(pg, 0.3, strategy, 7); !reflect.DeepEqual(edgeSet(again), edges) {
+				t.Errorf("Expected the same sample for the same seed")
+			}
+		})
+	}
+
+	t.Run("Keeps the versions connected with the spanning strategy", func(t *testing.T) {
+		sample := SampleEdges(pg, 0.1, EdgeSampleSpanning, 3)
+		if len(sample.Nodes) != len(pg.Nodes) || components(sample) != components(pg) {
+			t.Errorf("Expected %d versions in %d components, got %d in %d", len(pg.Nodes), components(pg), len(sample.Nodes), components(sample))
+		}
+		if all := SampleEdges(pg, 1, EdgeSampleSpanning, 3); len(edgeSet(all)) != len(original) {
+			t.Errorf("Expected all %d edges, got %d", len(original), len(edgeSet(all)))
+		}
+	})
+
+	t.Run("Keeps more of the edges of the hubs with the degree strategy", func(t *testing.T) {
+		// Hub has 40 dependents, while the 40 pairs A -> B are only connected to each other.
+		hubPackages := []PackageInfo{{Name: "Hub", Versions: map[string]VersionInfo{"1.0.0": {}}}}
+		for i := 0; i < 40; i++ {
+			hubPackages = append(hubPackages,
+				PackageInfo{Name: fmt.Sprintf("D%d", i), Versions: map[string]VersionInfo{"1.0.0": {Dependencies: map[string]string{"Hub": "1.0.0"}}}},
+				PackageInfo{Name: fmt.Sprintf("A%d", i), Versions: map[string]VersionInfo{"1.0.0": {Dependencies: map[string]string{fmt.Sprintf("B%d", i): "1.0.0"}}}},
+				PackageInfo{Name: fmt.Sprintf("B%d", i), Versions: map[string]VersionInfo{"1.0.0": {}}})
+		}
+		hubGraph := NewPackageGraph(&hubPackages, false)
+		shares := func(strategy EdgeSampleStrategy) (hub, pairs float64) {
+			for seed := int64(0); seed < 10; seed++ {
+				sample := SampleEdges(hubGraph, 0.25, strategy, seed)
+				hubEdges := 0
+				if hubNode, ok := sample.FindNode(NameVersion{Name: "Hub", Version: "1.0.0"}); ok {
+					hubEdges = sample.Graph.To(hubNode.ID()).Len()
+				}
+				// Either kind has 40 edges in each of the 10 samples.
+				hub += float64(hubEdges) / 400
+				pairs += float64(len(edgeSet(sample))-hubEdges) / 400
+			}
+			return hub, pairs
+		}
+		if hub, pairs := shares(EdgeSampleDegree); hub < 2*pairs {
+			t.Errorf("Expected the hub to keep a much larger share of its edges, got %.2f and %.2f for the pairs", hub, pairs)
+		}
+		if hub, pairs := shares(EdgeSampleUniform); hub > 2*pairs || pairs > 2*hub {
+			t.Errorf("Expected similar shares with uniform sampling, got %.2f and %.2f", hub, pairs)
+		}
+	})
+
+	t.Run("Keeps nothing of an empty fraction", func(t *testing.T) {
+		if sample := SampleEdges(pg, -1, EdgeSampleUniform, 1); len(sample.Nodes) != 0 {
+			t.Errorf("Expected an empty sample, got %d versions", len(sample.Nodes))
+		}
+	})
+}
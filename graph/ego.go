@@ -0,0 +1,47 @@
+package graph
+
+import (
+	"fmt"
+)
+
+// EgoNetwork returns a PackageGraph of the neighborhood of the center version: its dependencies up to depthOut edges
+// away and its dependents up to depthIn edges away, with all the edges between them. A negative depth follows the
+// edges in that direction without limit. The center is marked in the returned graph, see Center, so that its
+// visualization highlights it. The error is returned if the center is not part of the graph.
+func EgoNetwork(pg *PackageGraph, center NameVersion, depthOut, depthIn int) (*PackageGraph, error) {
+	pg.materializeExternalEdges()
+	dependencies, ok := pg.Dependencies(center, depthOut)
+	if !ok {
+		return nil, fmt.Errorf("version %s is not part of the graph", center)
+	}
+	dependents, _ := pg.Dependents(center, depthIn)
+	root, _ := pg.FindNode(center)
+	members := map[int64]bool{root.id: true}
+	ids := []int64{root.id}
+	for _, neighbours := range [][]NodeInfo{dependencies, dependents} {
+		for _, neighbour := range neighbours {
+			if !members[neighbour.id] {
+				members[neighbour.id] = true
+				ids = append(ids, neighbour.id)
+			}
+		}
+	}
+	if pg.lockEdges() {
+		defer pg.lazyMutex.Unlock()
+	}
+	// The edges between a dependent and a dependency of the center are only created once both are resolved.
+	pg.resolveVersions(ids)
+	subgraph := pg.inducedSubgraph(members)
+	rootVersion := root.NameVersion()
+	subgraph.center = &rootVersion
+	return subgraph, nil
+}
+
+// Center returns the version around which the graph was extracted by EgoNetwork. The bool is false for other graphs.
+// The center is not kept by SaveGraph.
+func (pg *PackageGraph) Center() (NameVersion, bool) {
+	if pg.center == nil {
+		return NameVersion{}, false
+	}
+	return *pg.center, true
+}
@@ -0,0 +1,76 @@
+package graph
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEgoNetwork(t *testing.T) {
+	center := NameVersion{Name: "B", Version: "1.0.0"}
+	expected := map[[2]string]bool{{"B-1.0.0", "A-1.0.0"}: true, {"C-1.0.0", "A-1.0.0"}: true, {"C-1.0.0", "B-1.0.0"}: true, {"D-1.0.0", "B-1.0.0"}: true}
+
+	t.Run("Follows the edges in both directions", func(t *testing.T) {
+		packagesInfo := testPackages("ego")
+		pg := NewPackageGraph(&packagesInfo, false)
+		ego, err := EgoNetwork(pg, center, 1, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if edges := edgeSet(ego); !reflect.DeepEqual(edges, expected) {
+			t.Errorf("Expected the edges %v, got %v", expected, edges)
+		}
+		if err := ValidateStrict(ego); err != nil {
+			t.Errorf("Expected a valid graph, got %v", err)
+		}
+		if got, ok := ego.Center(); !ok || got != center {
+			t.Errorf("Expected the center %v, got %v", center, got)
+		}
+		if _, ok := pg.Center(); ok {
+			t.Error("Expected no center for the full graph")
+		}
+	})
+
+	t.Run("Limits each direction separately", func(t *testing.T) {
+		packagesInfo := testPackages("ego")
+		pg := NewPackageGraph(&packagesInfo, false)
+		ego, _ := EgoNetwork(pg, center, 0, -1)
+		if nodes := ego.Graph.Nodes().Len(); nodes != 4 {
+			t.Errorf("Expected B with its 3 transitive dependents, got %d nodes", nodes)
+		}
+		if _, ok := ego.FindNode(NameVersion{Name: "A", Version: "1.0.0"}); ok {
+			t.Error("Expected the dependency A to be left out")
+		}
+	})
+
+	t.Run("Creates the same edges on a lazy graph", func(t *testing.T) {
+		packagesInfo := testPackages("ego")
+		pg := NewPackageGraph(&packagesInfo, false, WithLazyEdges())
+		ego, _ := EgoNetwork(pg, center, 1, 1)
+		if edges := edgeSet(ego); !reflect.DeepEqual(edges, expected) {
+			t.Errorf("Expected the edges %v, got %v", expected, edges)
+		}
+	})
+
+	t.Run("Highlights the center in the visualization", func(t *testing.T) {
+		packagesInfo := testPackages("ego")
+		pg := NewPackageGraph(&packagesInfo, false)
+		ego, _ := EgoNetwork(pg, center, 1, 1)
+		var buffer bytes.Buffer
+		if err := ego.WriteVisualization(&buffer, "ego"); err != nil {
+			t.Fatal(err)
+		}
+		if count := strings.Count(buffer.String(), "peripheries=2"); count != 1 {
+			t.Errorf("Expected one highlighted node, got %d", count)
+		}
+	})
+
+	t.Run("Rejects unknown versions", func(t *testing.T) {
+		packagesInfo := testPackages("ego")
+		pg := NewPackageGraph(&packagesInfo, false)
+		if _, err := EgoNetwork(pg, NameVersion{Name: "B", Version: "2.0.0"}, 1, 1); err == nil {
+			t.Error("Expected an error for an unknown version")
+		}
+	})
+}
@@ -0,0 +1,172 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// EdgeEstimate is the number of edges that NewPackageGraph would create with a configuration, see EstimateEdges. With
+// WithSampling, the counts are extrapolated from the sampled packages.
+type EdgeEstimate struct {
+	// Edges is the number of edges of the graph. Declarations in several classes that resolve to the same version
+	// create a single edge.
+	Edges int
+	// ByClass is the number of edges that the declarations of every dependency class create, so an edge that is
+	// declared in several classes is counted in each of them.
+	ByClass map[DependencyClass]int
+	// Declarations is the number of dependency declarations in the dependency classes that create edges.
+	Declarations int
+	// Unsatisfied, UnknownPackage and Skipped are the declarations that create no edge: those on a known package that no
+	// version satisfies, those on a package that is not part of the graph, and those whose range cannot be parsed or
+	// uses unsupported syntax.
+	Unsatisfied    int
+	UnknownPackage int
+	Skipped        int
+	// SelfEdges is the number of edges left out because a version satisfies its own dependency range.
+	SelfEdges int
+
+	// Packages is the number of packages of the graph and SampledPackages the number whose edges were counted.
+	Packages        int
+	SampledPackages int
+	// Margin is the half-width of the 95% confidence interval of Edges, which is 0 if every package was counted.
+	Margin int
+	// Note describes how the estimate was obtained.
+	Note string
+}
+
+// EstimateEdges counts the edges that NewPackageGraph would create for the packages with the options, without
+// creating a graph. The packages are prepared and their versions indexed like NewPackageGraph does, and the ranges are
+// matched by the same cached matcher, so the counts are exact, but configurations can be compared, for example
+// ResolveAll with ResolveHighest or a cutoff with none, in a fraction of the time of a full build. The declarations are
+// counted in the report of WithResolutionReport, if given, like during construction.
+//
+// With WithSampling, only the edges of a sample of the packages are counted and extrapolated to all packages. The
+// margin assumes the edge counts of the sampled packages are representative, which holds better for large samples.
+// An error is returned if the sampling fraction is not in (0, 1].
+func EstimateEdges(packages []PackageInfo, opts ...Option) (EdgeEstimate, error) {
+	options := newOptions(opts)
+	if options.SampleFraction < 0 || options.SampleFraction > 1 || math.IsNaN(options.SampleFraction) {
+		return EdgeEstimate{}, fmt.Errorf("sampling fraction %v is not in (0, 1]", options.SampleFraction)
+	}
+	start := time.Now()
+	pg := newEstimateGraph(preparePackages(&packages, options), options)
+	resolver := pg.resolver()
+	report := options.Report
+	if report == nil {
+		report = &ResolutionReport{}
+	}
+	before := *report
+
+	sample := make([]int, len(*pg.Packages))
+	for i := range sample {
+		sample[i] = i
+	}
+	if options.SampleFraction > 0 {
+		size := int(math.Ceil(options.SampleFraction * float64(len(sample))))
+		sample = rand.New(rand.NewSource(options.SampleSeed)).Perm(len(sample))[:size]
+	}
+
+	estimate := EdgeEstimate{
+		ByClass:         make(map[DependencyClass]int, len(options.DependencyClasses)),
+		Packages:        len(*pg.Packages),
+		SampledPackages: len(sample),
+	}
+	// The edge counts of the sampled packages give the variance of the extrapolation.
+	var sum, sumOfSquares float64
+	distinct := make(map[[2]int64]bool)
+	var edges [][2]int64
+	for done, index := range sample {
+		packageInfo := &(*pg.Packages)[index]
+		packageEdges := 0
+		for version, versionInfo := range packageInfo.Versions {
+			node, ok := pg.lookup(packageInfo.key().version(version))
+			if !ok {
+				continue
+			}
+			for edge := range distinct {
+				delete(distinct, edge)
+			}
+			for _, class := range options.DependencyClasses {
+				edges = resolver.resolveClass(edges[:0], node, versionInfo, class, report)
+				estimate.ByClass[class] += len(edges)
+				for _, edge := range edges {
+					distinct[edge] = true
+				}
+			}
+			packageEdges += len(distinct)
+		}
+		estimate.Edges += packageEdges
+		sum += float64(packageEdges)
+		sumOfSquares += float64(packageEdges) * float64(packageEdges)
+		if options.Progress != nil {
+			options.Progress(done+1, len(sample))
+		}
+	}
+	estimate.Declarations = report.Declarations - before.Declarations
+	estimate.Unsatisfied = report.Unsatisfied - before.Unsatisfied
+	estimate.UnknownPackage = report.UnknownPackage - before.UnknownPackage
+	estimate.Skipped = report.UnparseableRange - before.UnparseableRange + report.UnsupportedRange - before.UnsupportedRange
+	estimate.SelfEdges = report.SelfEdges - before.SelfEdges
+
+	if estimate.SampledPackages == estimate.Packages {
+		estimate.Note = fmt.Sprintf("counted exactly over all %d packages", estimate.Packages)
+	} else {
+		estimate.extrapolate(sum, sumOfSquares)
+	}
+	options.log(LevelInfo, "edge estimate",
+		"duration", time.Since(start),
+		"packages", estimate.Packages,
+		"sampled", estimate.SampledPackages,
+		"edges", estimate.Edges,
+		"margin", estimate.Margin)
+	return estimate, nil
+}
+
+// newEstimateGraph returns a graph without nodes or edges that only indexes the versions of the packages. The node IDs
+// are assigned in input order, as unlike in NewPackageGraph they are never seen, and sorting the versions would take
+// most of the time of an estimate.
+func newEstimateGraph(packagesList *[]PackageInfo, options *Options) *PackageGraph {
+	var nodes []NodeInfo
+	for _, packageInfo := range *packagesList {
+		for version, versionInfo := range packageInfo.Versions {
+			nodes = append(nodes, *newNodeInfoFromVersion(int64(len(nodes)), packageInfo.key(), version, versionInfo))
+		}
+	}
+	pg := newPackageGraphFromParts(simple.NewDirectedGraph(), packagesList, nodes)
+	pg.options = options
+	return pg
+}
+
+// extrapolate scales the counts of the sampled packages to all packages, and computes the margin of the edges from
+// the sum and the sum of squares of the edge counts of the sampled packages.
+func (estimate *EdgeEstimate) extrapolate(sum, sumOfSquares float64) {
+	n, total := float64(estimate.SampledPackages), float64(estimate.Packages)
+	scale := func(count int) int {
+		return int(math.Round(float64(count) * total / n))
+	}
+	estimate.Edges = scale(estimate.Edges)
+	for class, count := range estimate.ByClass {
+		estimate.ByClass[class] = scale(count)
+	}
+	estimate.Declarations = scale(estimate.Declarations)
+	estimate.Unsatisfied = scale(estimate.Unsatisfied)
+	estimate.UnknownPackage = scale(estimate.UnknownPackage)
+	estimate.Skipped = scale(estimate.Skipped)
+	estimate.SelfEdges = scale(estimate.SelfEdges)
+
+	if n > 1 {
+		mean := sum / n
+		variance := math.Max(0, (sumOfSquares-n*mean*mean)/(n-1))
+		// The finite population correction accounts for the packages being sampled without replacement.
+		standardError := total * math.Sqrt(variance/n) * math.Sqrt(1-n/total)
+		estimate.Margin = int(math.Ceil(1.96 * standardError))
+	}
+	estimate.Note = fmt.Sprintf("extrapolated from %d of %d packages: %d ± %d edges at 95%% confidence", estimate.SampledPackages, estimate.Packages, estimate.Edges, estimate.Margin)
+	if n < 30 {
+		estimate.Note += ", but the sample is too small for the margin to be reliable"
+	}
+}
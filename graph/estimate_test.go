@@ -0,0 +1,82 @@
+package graph
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEstimateEdges(t *testing.T) {
+	classes := WithDependencyClasses(Runtime, Peer)
+
+	t.Run("Counts the edges of the graph", func(t *testing.T) {
+		cutoff := WithCutoff(time.Date(2021, 2, 15, 0, 0, 0, 0, time.UTC))
+		for _, opts := range [][]Option{
+			{classes},
+			{classes, WithResolution(ResolveHighest)},
+			{classes, cutoff},
+			{},
+		} {
+			packagesInfo := testPackages("weights")
+			var report ResolutionReport
+			pg := NewPackageGraph(&packagesInfo, false, append(opts, WithResolutionReport(&report))...)
+			estimate, err := EstimateEdges(testPackages("weights"), opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if estimate.Edges != pg.Graph.Edges().Len() {
+				t.Errorf("Expected %d edges, got %d", pg.Graph.Edges().Len(), estimate.Edges)
+			}
+			if estimate.Declarations != report.Declarations || estimate.Unsatisfied != report.Unsatisfied || estimate.SelfEdges != report.SelfEdges {
+				t.Errorf("Expected the counts of %+v, got %+v", report, estimate)
+			}
+		}
+	})
+
+	t.Run("Counts the edges of every class", func(t *testing.T) {
+		estimate, err := EstimateEdges(testPackages("weights"), classes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := map[DependencyClass]int{Runtime: 6, Peer: 2}; !reflect.DeepEqual(estimate.ByClass, expected) {
+			t.Errorf("Expected %v, got %v", expected, estimate.ByClass)
+		}
+		if estimate.Edges != 6 || estimate.Margin != 0 || estimate.SampledPackages != 3 {
+			t.Errorf("Expected 6 exact edges over 3 packages, got %+v", estimate)
+		}
+	})
+
+	t.Run("Extrapolates from a sample", func(t *testing.T) {
+		// Package i depends on 4 - i % 4 of the versions of Base, for 500 edges in total.
+		packagesInfo := []PackageInfo{{Name: "Base", Versions: map[string]VersionInfo{}}}
+		for minor := 0; minor < 4; minor++ {
+			packagesInfo[0].Versions[fmt.Sprintf("1.%d.0", minor)] = VersionInfo{Timestamp: "2021-01-01T00:00:00", Dependencies: map[string]string{}}
+		}
+		for i := 0; i < 200; i++ {
+			packagesInfo = append(packagesInfo, PackageInfo{Name: fmt.Sprintf("P%d", i), Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2022-01-01T00:00:00", Dependencies: map[string]string{"Base": fmt.Sprintf(">=1.%d.0", i%4)}},
+			}})
+		}
+		estimate, err := EstimateEdges(packagesInfo, WithSampling(0.5, 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if estimate.SampledPackages != 101 || estimate.Packages != 201 {
+			t.Errorf("Expected 101 of 201 packages, got %d of %d", estimate.SampledPackages, estimate.Packages)
+		}
+		if estimate.Margin <= 0 || estimate.Edges < 500-estimate.Margin || estimate.Edges > 500+estimate.Margin {
+			t.Errorf("Expected 500 edges within the margin, got %d ± %d", estimate.Edges, estimate.Margin)
+		}
+		if !strings.Contains(estimate.Note, "95% confidence") {
+			t.Errorf("Expected a confidence note, got %q", estimate.Note)
+		}
+	})
+
+	t.Run("Rejects fractions outside (0, 1]", func(t *testing.T) {
+		if _, err := EstimateEdges(testPackages("weights"), WithSampling(1.5, 1)); err == nil {
+			t.Error("Expected an error")
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package graph
+
+import (
+	"fmt"
+)
+
+// DependentConstraint is a dependency declaration of a dependent version on a package, with its raw range.
+type DependentConstraint struct {
+	Dependent NameVersion
+	Range     string
+}
+
+// ExcludedFromLatest returns the dependency declarations on the package whose range excludes its latest version, the
+// highest version that is not a prerelease, so that the dependents would not pick up a fix released as that version.
+// The declarations are those in the classes that create edges, ordered by the ID of the dependent, and included is
+// the number of declarations whose range does include the latest version. A range that cannot be parsed includes no
+// version. An error is returned if the package is not part of the graph.
+//
+// The declarations are found with ConstraintsOn, so they come from the index that the incremental changes use.
+func ExcludedFromLatest(pg *PackageGraph, name string) (excluded []DependentConstraint, included int, err error) {
+	key := pg.packageKey("", name)
+	latestID, ok := pg.latestVersion(key)
+	if !ok {
+		return nil, 0, fmt.Errorf("package %s is not part of the graph", key)
+	}
+	resolver := pg.queryResolver()
+	var latest *indexedVersion
+	for i := range resolver.versions[key] {
+		if resolver.versions[key][i].id == latestID {
+			latest = &resolver.versions[key][i]
+		}
+	}
+
+	edgeClasses := make(map[DependencyClass]bool, len(pg.options.DependencyClasses))
+	for _, class := range pg.options.DependencyClasses {
+		edgeClasses[class] = true
+	}
+	// A dependent that declares the same range in several classes is counted once.
+	seen := make(map[DependentConstraint]bool)
+	for _, ref := range pg.constraintsOn(key) {
+		dependent := pg.node(ref.Dependent)
+		constraint := DependentConstraint{Dependent: dependent.NameVersion(), Range: ref.Range}
+		if !edgeClasses[ref.Class] || seen[constraint] {
+			continue
+		}
+		seen[constraint] = true
+		if parsedRange, err := resolver.matcher.ParseRange(ref.Range); err == nil && latest != nil && matchesIndexed(parsedRange, latest) {
+			included++
+		} else {
+			excluded = append(excluded, constraint)
+		}
+	}
+	return excluded, included, nil
+}
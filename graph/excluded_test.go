@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExcludedFromLatest(t *testing.T) {
+	createPackages := func() []PackageInfo {
+		version := func(dependencies map[string]string) VersionInfo {
+			return VersionInfo{Timestamp: "2022-01-01T00:00:00", Dependencies: dependencies}
+		}
+		return []PackageInfo{
+			{Name: "X", Versions: map[string]VersionInfo{
+				"1.0.0":       version(map[string]string{}),
+				"1.1.0":       version(map[string]string{}),
+				"2.0.0":       version(map[string]string{}),
+				"3.0.0-rc.1":  version(map[string]string{}),
+				"0.9.0-alpha": version(map[string]string{}),
+			}},
+			{Name: "Caret", Versions: map[string]VersionInfo{"1.0.0": version(map[string]string{"X": "^1.0.0"})}},
+			{Name: "Open", Versions: map[string]VersionInfo{"1.0.0": version(map[string]string{"X": ">= 1.0.0"})}},
+			{Name: "Pinned", Versions: map[string]VersionInfo{"1.0.0": version(map[string]string{"X": "1.1.0"})}},
+			{Name: "Broken", Versions: map[string]VersionInfo{"1.0.0": version(map[string]string{"X": "not a range"})}},
+			{Name: "Other", Versions: map[string]VersionInfo{"1.0.0": version(map[string]string{})}},
+		}
+	}
+
+	t.Run("Lists the ranges that exclude the latest release", func(t *testing.T) {
+		for _, opts := range [][]Option{nil, {WithLazyEdges()}} {
+			packagesInfo := createPackages()
+			pg := NewPackageGraph(&packagesInfo, false, opts...)
+			excluded, included, err := ExcludedFromLatest(pg, "X")
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := []DependentConstraint{
+				{Dependent: NameVersion{Name: "Caret", Version: "1.0.0"}, Range: "^1.0.0"},
+				{Dependent: NameVersion{Name: "Pinned", Version: "1.0.0"}, Range: "1.1.0"},
+				{Dependent: NameVersion{Name: "Broken", Version: "1.0.0"}, Range: "not a range"},
+			}
+			if !reflect.DeepEqual(excluded, expected) {
+				t.Errorf("Expected %v, got %v", expected, excluded)
+			}
+			if included != 1 {
+				t.Errorf("Expected 1 included range, got %d", included)
+			}
+		}
+	})
+
+	t.Run("Returns an error for an unknown package", func(t *testing.T) {
+		packagesInfo := createPackages()
+		pg := NewPackageGraph(&packagesInfo, false)
+		if _, _, err := ExcludedFromLatest(pg, "Missing"); err == nil {
+			t.Error("Expected an error for an unknown package")
+		}
+	})
+}
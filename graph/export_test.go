@@ -0,0 +1,13 @@
+package graph
+
+// ResolveAllPackages resolves the edges of every package of the graph, with or without the name filter, so that the
+// benchmarks of package graph_test, which can use the gen package, can compare both.
+func ResolveAllPackages(pg *PackageGraph, filtered bool) {
+	resolver := pg.resolver()
+	if !filtered {
+		resolver.names = nil
+	}
+	for i := range *pg.Packages {
+		resolver.resolvePackage(&(*pg.Packages)[i], nil)
+	}
+}
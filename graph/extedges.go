@@ -0,0 +1,313 @@
+package graph
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// defaultExternalRunSize is the number of edges sorted in memory at a time by WithExternalEdges, 16 MiB of edges.
+const defaultExternalRunSize = 1 << 20
+
+// edgeRecordSize is the size of an edge in an edge file: the IDs of both ends as little-endian int64.
+const edgeRecordSize = 16
+
+// EdgeFile is a file of the edges of a graph built with WithExternalEdges, sorted by from and then by to without
+// duplicates, which holds the same edges as the in-memory graph would. It is read sequentially, so the exporters can
+// write a graph whose edges do not fit in memory.
+type EdgeFile struct {
+	path  string
+	edges int
+}
+
+// Path returns the path of the file, a temporary file in the directory given to WithExternalEdges.
+func (file *EdgeFile) Path() string {
+	return file.path
+}
+
+// Len returns the number of edges in the file.
+func (file *EdgeFile) Len() int {
+	return file.edges
+}
+
+// Each calls fn with every edge of the file in order and stops at the first error, which is returned.
+func (file *EdgeFile) Each(fn func(from, to int64) error) error {
+	f, err := os.Open(file.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	reader := bufio.NewReader(f)
+	for {
+		edge, err := readEdge(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(edge[0], edge[1]); err != nil {
+			return err
+		}
+	}
+}
+
+// Remove deletes the file. The file outlives the graph otherwise, so that it can be exported by another process.
+func (file *EdgeFile) Remove() error {
+	return os.Remove(file.path)
+}
+
+// ExternalEdges returns the edge file of a graph built with WithExternalEdges. The file is nil for other graphs; the
+// error is the one that stopped the edges from being written, in which case the graph has no edges at all.
+func (pg *PackageGraph) ExternalEdges() (*EdgeFile, error) {
+	return pg.edgeFile, pg.edgeFileErr
+}
+
+// LoadExternalEdges adds the edges of the edge file for which include returns true to the in-memory graph, so that the
+// queries can run on a part of a graph that is too large to load completely; a nil include loads every edge. It
+// returns the number of edges added. A graph without an edge file adds none.
+func (pg *PackageGraph) LoadExternalEdges(include func(from, to int64) bool) (int, error) {
+	if pg.edgeFile == nil {
+		return 0, pg.edgeFileErr
+	}
+	added := 0
+	err := pg.edgeFile.Each(func(from, to int64) error {
+		if include == nil || include(from, to) {
+			pg.Graph.SetEdge(simple.Edge{F: pg.Graph.Node(from), T: pg.Graph.Node(to)})
+			added++
+		}
+		return nil
+	})
+	if added > 0 {
+		pg.reachMutex.Lock()
+		pg.reach = nil
+		pg.reachMutex.Unlock()
+	}
+	return added, err
+}
+
+// materializeExternalEdges adds all the edges of the edge file to the in-memory graph, once, so that the subgraphs
+// extracted from a graph built with WithExternalEdges, such as by EgoNetwork and Subgraph, have their edges. It holds
+// externalMutex, so that the extractions can run concurrently. A failure is logged, and the edges read so far are kept.
+func (pg *PackageGraph) materializeExternalEdges() {
+	if pg.edgeFile == nil {
+		return
+	}
+	pg.externalMutex.Lock()
+	defer pg.externalMutex.Unlock()
+	if pg.externalLoaded {
+		return
+	}
+	pg.externalLoaded = true
+	start := time.Now()
+	added, err := pg.LoadExternalEdges(nil)
+	if err != nil {
+		pg.options.log(LevelError, "loading the edge file failed", "path", pg.edgeFile.path, "error", err)
+		return
+	}
+	pg.options.log(LevelInfo, "edge file loaded", "duration", time.Since(start), "edges", added)
+}
+
+// createExternalEdges resolves the edges of the packages like createEdges, but writes them to an edge file in the
+// directory of the options instead of inserting them into the graph.
+func createExternalEdges(inputList *[]PackageInfo, resolver *edgeResolver) (*EdgeFile, error) {
+	options := resolver.options
+	sorter := &edgeSorter{dir: options.ExternalEdges, runSize: options.ExternalRunSize}
+	if sorter.runSize <= 0 {
+		sorter.runSize = defaultExternalRunSize
+	}
+	createEdges(func(edges [][2]int64) { sorter.add(options.orientEdges(edges)) }, inputList, resolver)
+	start := time.Now()
+	file, err := sorter.finish()
+	if err != nil {
+		options.log(LevelError, "edge file failed", "dir", options.ExternalEdges, "error", err)
+		return nil, err
+	}
+	options.log(LevelInfo, "edge file", "duration", time.Since(start), "runs", sorter.runCount, "edges", file.edges, "path", file.path)
+	return file, nil
+}
+
+// edgeSorter is an external sort of edges: it sorts runSize edges at a time in memory, writes every sorted run to a
+// temporary file and merges the runs into the edge file at the end.
+type edgeSorter struct {
+	dir      string
+	runSize  int
+	buffer   [][2]int64
+	runs     []string
+	runCount int
+	// err is the first error while writing a run, after which the edges are dropped.
+	err error
+}
+
+func (sorter *edgeSorter) add(edges [][2]int64) {
+	if sorter.err != nil {
+		return
+	}
+	sorter.buffer = append(sorter.buffer, edges...)
+	if len(sorter.buffer) >= sorter.runSize {
+		sorter.err = sorter.writeRun()
+	}
+}
+
+// writeRun writes the sorted buffer without duplicates to a new run file and empties the buffer.
+func (sorter *edgeSorter) writeRun() error {
+	sortEdges(sorter.buffer)
+	f, err := os.CreateTemp(sorter.dir, "edges-run-*")
+	if err != nil {
+		return err
+	}
+	sorter.runs = append(sorter.runs, f.Name())
+	sorter.runCount++
+	writer := bufio.NewWriter(f)
+	var previous [2]int64
+	for i, edge := range sorter.buffer {
+		if i > 0 && edge == previous {
+			continue
+		}
+		previous = edge
+		if err := writeEdge(writer, edge); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	sorter.buffer = sorter.buffer[:0]
+	if err := writer.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// finish merges the runs into the edge file and removes them.
+func (sorter *edgeSorter) finish() (file *EdgeFile, err error) {
+	defer func() {
+		for _, run := range sorter.runs {
+			os.Remove(run)
+		}
+	}()
+	if sorter.err != nil {
+		return nil, sorter.err
+	}
+	if len(sorter.buffer) > 0 || len(sorter.runs) == 0 {
+		if err := sorter.writeRun(); err != nil {
+			return nil, err
+		}
+	}
+	sorter.buffer = nil
+
+	out, err := os.CreateTemp(sorter.dir, "edges-*.bin")
+	if err != nil {
+		return nil, err
+	}
+	file = &EdgeFile{path: out.Name()}
+	defer func() {
+		if closeErr := out.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(out.Name())
+			file = nil
+		}
+	}()
+	merge := make(runHeap, 0, len(sorter.runs))
+	for _, run := range sorter.runs {
+		f, err := os.Open(run)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		reader := &runReader{reader: bufio.NewReader(f)}
+		if ok, err := reader.next(); err != nil {
+			return nil, err
+		} else if ok {
+			merge = append(merge, reader)
+		}
+	}
+	heap.Init(&merge)
+	writer := bufio.NewWriter(out)
+	var previous [2]int64
+	for len(merge) > 0 {
+		reader := merge[0]
+		if edge := reader.edge; file.edges == 0 || edge != previous {
+			if err := writeEdge(writer, edge); err != nil {
+				return nil, err
+			}
+			previous = edge
+			file.edges++
+		}
+		ok, err := reader.next()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			heap.Fix(&merge, 0)
+		} else {
+			heap.Pop(&merge)
+		}
+	}
+	return file, writer.Flush()
+}
+
+// runReader reads the edges of a run file, holding the last edge read.
+type runReader struct {
+	reader *bufio.Reader
+	edge   [2]int64
+}
+
+// next reads the next edge and returns false at the end of the run.
+func (run *runReader) next() (bool, error) {
+	edge, err := readEdge(run.reader)
+	if err == io.EOF {
+		return false, nil
+	}
+	run.edge = edge
+	return err == nil, err
+}
+
+// runHeap is a min-heap of the runs by their current edge.
+type runHeap []*runReader
+
+func (h runHeap) Len() int            { return len(h) }
+func (h runHeap) Less(i, j int) bool  { return edgeLess(h[i].edge, h[j].edge) }
+func (h runHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *runHeap) Push(x interface{}) { *h = append(*h, x.(*runReader)) }
+func (h *runHeap) Pop() interface{} {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
+
+func edgeLess(a, b [2]int64) bool {
+	if a[0] != b[0] {
+		return a[0] < b[0]
+	}
+	return a[1] < b[1]
+}
+
+func sortEdges(edges [][2]int64) {
+	sort.Slice(edges, func(i, j int) bool { return edgeLess(edges[i], edges[j]) })
+}
+
+func writeEdge(w io.Writer, edge [2]int64) error {
+	var record [edgeRecordSize]byte
+	binary.LittleEndian.PutUint64(record[:8], uint64(edge[0]))
+	binary.LittleEndian.PutUint64(record[8:], uint64(edge[1]))
+	_, err := w.Write(record[:])
+	return err
+}
+
+// readEdge reads an edge, returning io.EOF at the end of the input and io.ErrUnexpectedEOF for a truncated edge.
+func readEdge(r io.Reader) ([2]int64, error) {
+	var record [edgeRecordSize]byte
+	if _, err := io.ReadFull(r, record[:]); err != nil {
+		return [2]int64{}, err
+	}
+	return [2]int64{int64(binary.LittleEndian.Uint64(record[:8])), int64(binary.LittleEndian.Uint64(record[8:]))}, nil
+}
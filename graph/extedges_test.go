@@ -0,0 +1,122 @@
+package graph
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExternalEdges(t *testing.T) {
+	createPackages := func() []PackageInfo {
+		packagesInfo := testPackages("options")
+		app := packagesInfo[0].Versions["1.0.0"]
+		// The peer dependency resolves to an edge that the runtime dependency creates as well.
+		app.PeerDependencies = map[string]string{"A": "1.0.0"}
+		packagesInfo[0].Versions["1.0.0"] = app
+		return packagesInfo
+	}
+	classes := WithDependencyClasses(Runtime, Development, Peer)
+	inMemory := func() map[[2]string]bool {
+		packagesInfo := createPackages()
+		return edgeSet(NewPackageGraph(&packagesInfo, false, classes))
+	}()
+	fileEdges := func(pg *PackageGraph, file *EdgeFile) map[[2]string]bool {
+		edges := make(map[[2]string]bool)
+		count := 0
+		file.Each(func(from, to int64) error {
+			edges[[2]string{pg.Nodes[from].stringID, pg.Nodes[to].stringID}] = true
+			count++
+			return nil
+		})
+		if count != len(edges) || count != file.Len() {
+			t.Errorf("Expected %d edges without duplicates, read %d", file.Len(), count)
+		}
+		return edges
+	}
+
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("Writes the in-memory edges to the file with %d workers", workers), func(t *testing.T) {
+			dir := t.TempDir()
+			packagesInfo := createPackages()
+			pg := NewPackageGraph(&packagesInfo, false, classes, WithWorkers(workers), WithExternalEdges(dir, 1))
+			file, err := pg.ExternalEdges()
+			if err != nil || file == nil {
+				t.Fatalf("Expected an edge file, got %v", err)
+			}
+			if edges := pg.Graph.Edges().Len(); edges != 0 {
+				t.Errorf("Expected no edges in memory, got %d", edges)
+			}
+			if edges := fileEdges(pg, file); !reflect.DeepEqual(edges, inMemory) {
+				t.Errorf("Expected the edges %v, got %v", inMemory, edges)
+			}
+			if runs, _ := filepath.Glob(filepath.Join(dir, "edges-run-*")); len(runs) != 0 {
+				t.Errorf("Expected the runs to be removed, got %v", runs)
+			}
+		})
+	}
+
+	t.Run("Loads a part of the edges", func(t *testing.T) {
+		packagesInfo := createPackages()
+		pg := NewPackageGraph(&packagesInfo, false, classes, WithExternalEdges(t.TempDir(), 0))
+		app, _ := pg.FindNode(NameVersion{Name: "App", Version: "1.0.0"})
+		added, err := pg.LoadExternalEdges(func(from, to int64) bool { return from == app.ID() })
+		if err != nil {
+			t.Fatal(err)
+		}
+		dependencies, _ := pg.Dependencies(NameVersion{Name: "App", Version: "1.0.0"}, 1)
+		if added != 4 || len(dependencies) != 4 {
+			t.Errorf("Expected the 4 dependencies of App, got %d edges and %v", added, dependencies)
+		}
+	})
+
+	t.Run("Extracts subgraphs with their edges", func(t *testing.T) {
+		packagesInfo := createPackages()
+		expected := NewPackageGraph(&packagesInfo, false, classes)
+		packagesInfo = createPackages()
+		pg := NewPackageGraph(&packagesInfo, false, classes, WithExternalEdges(t.TempDir(), 0))
+		app := NameVersion{Name: "App", Version: "1.0.0"}
+		ego, err := EgoNetwork(pg, app, 1, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedEgo, _ := EgoNetwork(expected, app, 1, 1)
+		if !reflect.DeepEqual(edgeSet(ego), edgeSet(expectedEgo)) || len(edgeSet(ego)) == 0 {
+			t.Errorf("Expected the ego network edges %v, got %v", edgeSet(expectedEgo), edgeSet(ego))
+		}
+		subgraph, _ := pg.Subgraph(app, -1)
+		expectedSubgraph, _ := expected.Subgraph(app, -1)
+		if !reflect.DeepEqual(edgeSet(subgraph), edgeSet(expectedSubgraph)) {
+			t.Errorf("Expected the subgraph edges %v, got %v", edgeSet(expectedSubgraph), edgeSet(subgraph))
+		}
+	})
+
+	t.Run("Removes the edge file replaced by a reload", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "packages.json")
+		writePackagesFile(t, path, createPackages())
+		pg, err := OpenPackageGraph(path, false, classes, WithExternalEdges(dir, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		old, _ := pg.ExternalEdges()
+		if err := pg.ReloadFrom(path); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(old.Path()); !os.IsNotExist(err) {
+			t.Errorf("Expected the old edge file to be removed, got %v", err)
+		}
+		if current, _ := pg.ExternalEdges(); current == nil || !reflect.DeepEqual(fileEdges(pg, current), inMemory) {
+			t.Error("Expected the edge file of the reloaded graph")
+		}
+	})
+
+	t.Run("Reports an unwritable directory", func(t *testing.T) {
+		packagesInfo := createPackages()
+		pg := NewPackageGraph(&packagesInfo, false, WithExternalEdges(filepath.Join(t.TempDir(), "missing"), 0))
+		if file, err := pg.ExternalEdges(); file != nil || !os.IsNotExist(err) {
+			t.Errorf("Expected a missing directory error, got %v and %v", file, err)
+		}
+	})
+}
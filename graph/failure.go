@@ -0,0 +1,126 @@
+package graph
+
+import (
+	"sort"
+
+	"gonum.org/v1/gonum/graph"
+)
+
+// ArticulationPoints returns the IDs of the nodes whose removal disconnects the underlying undirected graph of g, in
+// increasing order. It is a cheap proxy for single points of failure and runs in O(V + E).
+func ArticulationPoints(g graph.Directed) []int64 {
+	nodes := graph.NodesOf(g.Nodes())
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID() < nodes[j].ID() })
+	index := make(map[int64]int, len(nodes))
+	for i, node := range nodes {
+		index[node.ID()] = i
+	}
+	neighbours := make([][]int, len(nodes))
+	for i, node := range nodes {
+		neighbours[i] = undirectedNeighbours(g, node.ID(), index)
+	}
+
+	// Iterative version of Tarjan's algorithm, since recursion would overflow the stack on long dependency chains.
+	discovery := make([]int, len(nodes))
+	low := make([]int, len(nodes))
+	parent := make([]int, len(nodes))
+	next := make([]int, len(nodes))
+	isArticulation := make([]bool, len(nodes))
+	counter := 0
+	for root := range nodes {
+		if discovery[root] != 0 {
+			continue
+		}
+		counter++
+		discovery[root], low[root], parent[root] = counter, counter, -1
+		rootChildren := 0
+		stack := []int{root}
+		for len(stack) > 0 {
+			v := stack[len(stack)-1]
+			if next[v] < len(neighbours[v]) {
+				u := neighbours[v][next[v]]
+				next[v]++
+				if discovery[u] == 0 {
+					counter++
+					discovery[u], low[u], parent[u] = counter, counter, v
+					if v == root {
+						rootChildren++
+					}
+					stack = append(stack, u)
+				} else if u != parent[v] && discovery[u] < low[v] {
+					low[v] = discovery[u]
+				}
+				continue
+			}
+			stack = stack[:len(stack)-1]
+			if p := parent[v]; p >= 0 {
+				if low[v] < low[p] {
+					low[p] = low[v]
+				}
+				if p != root && low[v] >= discovery[p] {
+					isArticulation[p] = true
+				}
+			}
+		}
+		if rootChildren > 1 {
+			isArticulation[root] = true
+		}
+	}
+
+	var result []int64
+	for i, node := range nodes {
+		if isArticulation[i] {
+			result = append(result, node.ID())
+		}
+	}
+	return result
+}
+
+// DisconnectionScores computes, for each candidate, how many reachable (dependent -> dependency) pairs become
+// unreachable when that candidate is removed from the graph. Pairs that start or end at the candidate itself are
+// counted as lost. The result is ranked by that score. If candidates is nil, every node is a candidate.
+//
+// Only the transitive dependents of a candidate can lose pairs, so a candidate costs one BFS per transitive dependent,
+// O(D * (V + E)) where D is the number of dependents. This is quadratic in the worst case, so on the full dataset the
+// candidates should be restricted, for example to the ArticulationPoints or to the nodes with the highest in-degree.
+func DisconnectionScores(g graph.Directed, nodeMap map[int64]NodeInfo, candidates []int64) []RankedNode {
+	if candidates == nil {
+		for _, node := range graph.NodesOf(g.Nodes()) {
+			candidates = append(candidates, node.ID())
+		}
+	}
+
+	scores := make(map[int64]float64, len(candidates))
+	for _, candidate := range candidates {
+		removed := g.Node(candidate)
+		if removed == nil {
+			continue
+		}
+		lost := len(reachable(g, candidate, nil, true))
+		for _, dependent := range reachable(g, candidate, nil, false) {
+			lost += len(reachable(g, dependent, nil, true)) - len(reachable(g, dependent, removed, true))
+		}
+		scores[candidate] = float64(lost)
+	}
+	return TopNodes(scores, nodeMap, -1)
+}
+
+// reachable returns the nodes reachable from source, excluding source itself, following the edges forwards or
+// backwards. If removed is not nil, that node is treated as if it was not part of the graph.
+func reachable(g graph.Directed, source int64, removed graph.Node, forward bool) []int64 {
+	dir := Backward
+	if forward {
+		dir = Forward
+	}
+	var result []int64
+	Traverse(g, []int64{source}, dir, func(id int64, depth int) TraverseSignal {
+		if removed != nil && id == removed.ID() && depth > 0 {
+			return SkipChildren
+		}
+		if depth > 0 {
+			result = append(result, id)
+		}
+		return Continue
+	})
+	return result
+}
@@ -0,0 +1,42 @@
+package graph
+
+import (
+	"testing"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+func TestSinglePointsOfFailure(t *testing.T) {
+	// 0 and 1 depend on 2, which depends on 3. 0 also depends on 4 directly and through 2.
+	graph := simple.NewDirectedGraph()
+	edges := [][2]int64{{0, 2}, {1, 2}, {2, 3}, {0, 4}, {2, 4}}
+	for _, edge := range edges {
+		graph.SetEdge(simple.Edge{F: simple.Node(edge[0]), T: simple.Node(edge[1])})
+	}
+	nodeMap := make(map[int64]NodeInfo)
+	for id := int64(0); id < 5; id++ {
+		nodeMap[id] = *NewNodeInfo(id, "P", string(rune('a'+id)), "")
+	}
+
+	t.Run("Finds the articulation points of the undirected graph", func(t *testing.T) {
+		points := ArticulationPoints(graph)
+		if len(points) != 1 || points[0] != 2 {
+			t.Errorf("Expected only node 2 to be an articulation point, got %v", points)
+		}
+	})
+
+	t.Run("Scores the removal of a node by the pairs that become unreachable", func(t *testing.T) {
+		result := DisconnectionScores(graph, nodeMap, []int64{2, 4})
+		if len(result) != 2 {
+			t.Fatalf("Expected 2 scored candidates, got %d", len(result))
+		}
+		// Removing 2 loses 2->3, 2->4, 0->2, 0->3, 1->2, 1->3 and 1->4, but not 0->4.
+		if result[0].id != 2 || result[0].Score != 7 {
+			t.Errorf("Expected node 2 with score 7 to be ranked first, got node %d with score %f", result[0].id, result[0].Score)
+		}
+		// Removing 4 only loses the pairs ending at 4.
+		if result[1].id != 4 || result[1].Score != 3 {
+			t.Errorf("Expected node 4 with score 3 to be ranked second, got node %d with score %f", result[1].id, result[1].Score)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package graph
+
+import (
+	"time"
+)
+
+// FreshnessRow describes how stale the dependencies of a version were when it was released.
+type FreshnessRow struct {
+	Name      string
+	Ecosystem string
+	Version   string
+	Released  time.Time
+	// Dependencies is the number of dependency declarations that resolve at the release time.
+	Dependencies int
+	// Outdated is the number of them whose resolved version was not the latest release of the dependency at the time.
+	Outdated int
+	// MinDays, MeanDays and MaxDays summarize the staleness of the declarations in days: the time between the release
+	// of the resolved version and the release of the latest version of the dependency. The staleness of a declaration
+	// that resolves to the latest version is 0.
+	MinDays  float64
+	MeanDays float64
+	MaxDays  float64
+}
+
+// FreshnessReport measures, for every version of the graph, how stale its dependencies were at its release. Each
+// dependency declaration of the classes of the graph is resolved with ResolveHighest among the versions released at
+// or before the dependent, as a package manager installing at that time would, and compared with the latest release
+// of the dependency at that time, leaving out prereleases. The rows are in node ID order and only include versions
+// with at least one resolving declaration.
+//
+// Versions whose timestamp cannot be parsed are left out; their number is returned separately.
+func FreshnessReport(pg *PackageGraph) (rows []FreshnessRow, unparseable int) {
+	resolver := pg.queryResolver()
+	const day = float64(24 * time.Hour)
+	for _, node := range pg.Nodes {
+		if node.stringID == "" {
+			continue
+		}
+		released, err := ParseTimestamp(node.Timestamp)
+		if err != nil {
+			unparseable++
+			continue
+		}
+		row := FreshnessRow{Name: node.Name, Ecosystem: node.Ecosystem, Version: node.Version, Released: released}
+		total := 0.0
+		versionInfo, _ := pg.VersionInfo(node.NameVersion())
+		for _, class := range pg.options.DependencyClasses {
+			dependencies := versionInfo.DependenciesOf(class)
+			// The staleness is summed in name order, so that the mean does not depend on the order of the map.
+			for _, name := range sortedDependencyNames(dependencies) {
+				dependencyRange := dependencies[name]
+				dependency := NewPackageKey(node.Ecosystem, name)
+				id, outcome := resolver.highestAt(dependency, released, dependencyRange)
+				if outcome != resolved {
+					continue
+				}
+				resolvedInfo, _ := pg.Node(id)
+				latest := latestReleaseAt(resolver, dependency, released)
+				staleness := 0.0
+				if latest != nil && compareVersions(latest.version, resolvedInfo.Version) > 0 {
+					row.Outdated++
+					latestInfo, _ := resolver.find(dependency.version(latest.version))
+					latestReleased, _ := ParseTimestamp(latestInfo.Timestamp)
+					resolvedReleased, _ := ParseTimestamp(resolvedInfo.Timestamp)
+					if gap := float64(latestReleased.Sub(resolvedReleased)) / day; gap > 0 {
+						staleness = gap
+					}
+				}
+				if row.Dependencies == 0 || staleness < row.MinDays {
+					row.MinDays = staleness
+				}
+				if staleness > row.MaxDays {
+					row.MaxDays = staleness
+				}
+				total += staleness
+				row.Dependencies++
+			}
+		}
+		if row.Dependencies > 0 {
+			row.MeanDays = total / float64(row.Dependencies)
+			rows = append(rows, row)
+		}
+	}
+	return rows, unparseable
+}
+
+// latestReleaseAt returns the highest version of the package that is not a prerelease and was released at or before
+// at, or nil if there is none.
+func latestReleaseAt(resolver *edgeResolver, key PackageKey, at time.Time) *indexedVersion {
+	versions := resolver.versions[key]
+	var latest *indexedVersion
+	for i := range versions {
+		version := &versions[i]
+		if version.parsed == nil || version.parsed.Prerelease() != "" || !releasedBy(resolver, key, version, at) {
+			continue
+		}
+		if compareIndexed(version, latest) > 0 {
+			latest = version
+		}
+	}
+	return latest
+}
@@ -0,0 +1,59 @@
+package graph
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFreshnessReport(t *testing.T) {
+	packagesInfo := []PackageInfo{
+		{Name: "Lib", Versions: map[string]VersionInfo{
+			"1.0.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{}},
+			"1.1.0": {Timestamp: "2020-03-01T00:00:00", Dependencies: map[string]string{}},
+			"2.0.0": {Timestamp: "2020-06-01T00:00:00", Dependencies: map[string]string{}},
+		}},
+		{Name: "Other", Versions: map[string]VersionInfo{
+			"1.0.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{}},
+		}},
+		{Name: "App", Versions: map[string]VersionInfo{
+			"1.0.0": {Timestamp: "2020-04-01T00:00:00", Dependencies: map[string]string{"Lib": "^1.0.0"}},
+			"2.0.0": {Timestamp: "2020-07-01T00:00:00", Dependencies: map[string]string{"Lib": "^1.0.0", "Other": "1.0.0"}},
+			"3.0.0": {Timestamp: "", Dependencies: map[string]string{"Lib": "^2.0.0"}},
+		}},
+	}
+	pg := NewPackageGraph(&packagesInfo, false)
+	rows, unparseable := FreshnessReport(pg)
+
+	t.Run("Counts the versions without a timestamp", func(t *testing.T) {
+		if unparseable != 1 {
+			t.Errorf("Expected 1 version without a timestamp, got %d", unparseable)
+		}
+	})
+
+	t.Run("Only reports the versions with dependencies", func(t *testing.T) {
+		if len(rows) != 2 {
+			t.Fatalf("Expected 2 rows, got %v", rows)
+		}
+	})
+
+	t.Run("Measures the staleness at the release time", func(t *testing.T) {
+		byVersion := make(map[string]FreshnessRow)
+		for _, row := range rows {
+			byVersion[row.Version] = row
+		}
+		fresh := byVersion["1.0.0"]
+		if fresh.Dependencies != 1 || fresh.Outdated != 0 || fresh.MaxDays != 0 {
+			t.Errorf("Expected an up to date dependency for App 1.0.0, got %+v", fresh)
+		}
+		stale := byVersion["2.0.0"]
+		if stale.Dependencies != 2 || stale.Outdated != 1 {
+			t.Errorf("Expected 1 of 2 dependencies to be outdated for App 2.0.0, got %+v", stale)
+		}
+		if stale.MinDays != 0 || stale.MaxDays != 92 || stale.MeanDays != 46 {
+			t.Errorf("Expected the staleness 0, 46 and 92 days, got %+v", stale)
+		}
+		if !stale.Released.Equal(time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected the release time 2020-07-01, got %v", stale.Released)
+		}
+	})
+}
@@ -6,23 +6,50 @@ import (
 	"log"
 	"os"
 	"regexp"
+	"sync"
 	"time"
 
-	"github.com/Masterminds/semver"
 	"gonum.org/v1/gonum/graph"
-	"gonum.org/v1/gonum/graph/encoding/dot"
 	"gonum.org/v1/gonum/graph/simple"
 	"gonum.org/v1/gonum/graph/traverse"
 )
 
 type VersionInfo struct {
-	Timestamp    string            `json:"timestamp"`
-	Dependencies map[string]string `json:"dependencies"`
+	Timestamp            string            `json:"timestamp"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies,omitempty"`
+	PeerDependencies     map[string]string `json:"peerDependencies,omitempty"`
+	OptionalDependencies map[string]string `json:"optionalDependencies,omitempty"`
+	License              License           `json:"license,omitempty"`
+	// Deprecated is the deprecation message of the version, or empty if it is not deprecated or yanked.
+	Deprecated Deprecation `json:"deprecated,omitempty"`
+	// Maintainers are the names of the people allowed to publish the version, or empty if the input does not say.
+	Maintainers Maintainers `json:"maintainers,omitempty"`
+}
+
+// DependenciesOf returns the dependencies of the given class, mapping the dependency names to their version ranges.
+func (versionInfo VersionInfo) DependenciesOf(class DependencyClass) map[string]string {
+	switch class {
+	case CrossEcosystem:
+		return nil
+	case Development:
+		return versionInfo.DevDependencies
+	case Peer:
+		return versionInfo.PeerDependencies
+	case Optional:
+		return versionInfo.OptionalDependencies
+	}
+	return versionInfo.Dependencies
 }
 
 type PackageInfo struct {
-	Name     string                 `json:"name"`
-	Versions map[string]VersionInfo `json:"versions"`
+	Name string `json:"name"`
+	// Ecosystem is the registry the package is published in, such as "npm" or "pypi", or empty for the graphs of a
+	// single ecosystem. See PackageKey for how it keeps the packages of different ecosystems apart.
+	Ecosystem string                 `json:"ecosystem,omitempty"`
+	Versions  map[string]VersionInfo `json:"versions"`
+	// Provenance is the place in the input that the package was decoded from with WithProvenance, or nil.
+	Provenance *Provenance `json:"-"`
 }
 
 // NodeInfo is a type structure for nodes. Name and Version can be removed if we find we don't use them often enough
@@ -32,6 +59,14 @@ type NodeInfo struct {
 	Name      string
 	Version   string
 	Timestamp string
+	// License is the license of the version as declared in the input, or empty if it declares none.
+	License string
+	// Deprecated is the deprecation message of the version, or empty if it is not deprecated.
+	Deprecated string
+	// Ecosystem is the ecosystem of the package, or empty if it has none.
+	Ecosystem string
+	// Provenance is the provenance of the package, shared by its versions, or nil if it was not recorded.
+	Provenance *Provenance
 }
 
 // NewNodeInfo constructs a NodeInfo structure and automatically fills the stringID.
@@ -44,22 +79,128 @@ func NewNodeInfo(id int64, name string, version string, timestamp string) *NodeI
 		Timestamp: timestamp}
 }
 
+// newNodeInfo constructs the NodeInfo of a version of the package, whose string ID has the QualifiedName of the
+// package.
+func newNodeInfo(id int64, key PackageKey, version string, timestamp string) *NodeInfo {
+	info := NewNodeInfo(id, key.FullName(), version, timestamp)
+	if key.Ecosystem != "" {
+		info.stringID = key.version(version).stringID()
+		info.Ecosystem = key.Ecosystem
+	}
+	return info
+}
+
+// newNodeInfoFromVersion constructs the NodeInfo of a version of the package, including the metadata of the version.
+func newNodeInfoFromVersion(id int64, key PackageKey, version string, versionInfo VersionInfo) *NodeInfo {
+	info := newNodeInfo(id, key, version, versionInfo.Timestamp)
+	info.License = string(versionInfo.License)
+	info.Deprecated = string(versionInfo.Deprecated)
+	return info
+}
+
+// ID returns the ID of the node in the graph.
+func (nodeInfo NodeInfo) ID() int64 {
+	return nodeInfo.id
+}
+
+// StringID returns the name-version key of the node, with the QualifiedName of its package. Different versions can
+// share a key, use NameVersion to identify a version.
+func (nodeInfo NodeInfo) StringID() string {
+	return nodeInfo.stringID
+}
+
+// NameVersion returns the version of the node.
+func (nodeInfo NodeInfo) NameVersion() NameVersion {
+	return NameVersion{Name: nodeInfo.Name, Version: nodeInfo.Version, Ecosystem: nodeInfo.Ecosystem}
+}
+
+// MarshalJSON encodes the node information, including its ID, with lowercase keys.
+func (nodeInfo NodeInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		ID         int64       `json:"id"`
+		Name       string      `json:"name"`
+		Version    string      `json:"version"`
+		Timestamp  string      `json:"timestamp"`
+		License    string      `json:"license,omitempty"`
+		Ecosystem  string      `json:"ecosystem,omitempty"`
+		Provenance *Provenance `json:"provenance,omitempty"`
+	}{nodeInfo.id, nodeInfo.Name, nodeInfo.Version, nodeInfo.Timestamp, nodeInfo.License, nodeInfo.Ecosystem, nodeInfo.Provenance})
+}
+
 func (nodeInfo NodeInfo) String() string {
 	return fmt.Sprintf("Package: %v - Version: %v", nodeInfo.Name, nodeInfo.Version)
 }
 
+// NameVersion identifies a single version of a package.
+type NameVersion struct {
+	Name    string
+	Version string
+	// Ecosystem is the ecosystem of the package, or empty for the packages without one.
+	Ecosystem string `json:",omitempty"`
+}
+
+// stringID returns the key under which the package version is stored in the stringID to NodeInfo map. Different
+// versions can share a key, so it must only be used for the deprecated maps.
+func (nameVersion NameVersion) stringID() string {
+	return fmt.Sprintf("%s-%s", QualifiedName(nameVersion.Ecosystem, nameVersion.Name), nameVersion.Version)
+}
+
+func (nameVersion NameVersion) String() string {
+	return fmt.Sprintf("%s@%s", QualifiedName(nameVersion.Ecosystem, nameVersion.Name), nameVersion.Version)
+}
+
+// lookupMap returns a lookup of the package versions in a map keyed by stringID, for the functions that still take one.
+func lookupMap(stringIDToNodeInfo map[string]NodeInfo) func(NameVersion) (NodeInfo, bool) {
+	return func(nameVersion NameVersion) (NodeInfo, bool) {
+		info, ok := stringIDToNodeInfo[nameVersion.stringID()]
+		return info, ok
+	}
+}
+
+// createNodeInfos adds a node to the graph of the allocator for every version of the packages and returns their node
+// information, indexed by node ID with SequentialIDs. The versions of a package are added in semver order, so the
+// same input always results in the same IDs. The versions whose hashed ID collides with an earlier version are left
+// out.
+func createNodeInfos(packagesInfo *[]PackageInfo, allocator *nodeAllocator) []NodeInfo {
+	var nodes []NodeInfo
+	for _, packageInfo := range *packagesInfo {
+		for _, packageVersion := range sortedVersionKeys(packageInfo.Versions) {
+			id, ok := allocator.add(packageInfo.key().version(packageVersion))
+			if !ok {
+				continue
+			}
+			nodes = appendNodeInfo(nodes, *newNodeInfoFromVersion(id, packageInfo.key(), packageVersion, packageInfo.Versions[packageVersion]), allocator.scheme)
+		}
+	}
+	return nodes
+}
+
+// setNodeInfo stores the node information at its ID in the slice, growing the slice if needed.
+func setNodeInfo(nodes []NodeInfo, info NodeInfo) []NodeInfo {
+	for int64(len(nodes)) <= info.id {
+		nodes = append(nodes, NodeInfo{})
+	}
+	nodes[info.id] = info
+	return nodes
+}
+
 // CreateStringIDToNodeInfoMap takes a list of PackageInfo and a simple.DirectedGraph. For each of the packages,
 // it creates a mapping of stringIDs to NodeInfo and also adds a node to the graph. The handling of the IDs is delegated
-// to Gonum. These IDs are also included in the mapping for ease of access.
+// to Gonum. These IDs are also included in the mapping for ease of access. The versions of a package are added in
+// semver order, so the same input always results in the same IDs.
+//
+// Deprecated: the "name-version" keys are ambiguous, "a-1" at version "2.0.0" and "a" at version "1-2.0.0" share a key
+// and only one of them is kept. Use NewPackageGraph, which indexes the versions by NameVersion.
 func CreateStringIDToNodeInfoMap(packagesInfo *[]PackageInfo, graph *simple.DirectedGraph) map[string]NodeInfo {
 	stringIDToNodeInfoMap := make(map[string]NodeInfo, len(*packagesInfo))
 	for _, packageInfo := range *packagesInfo {
-		for packageVersion, versionInfo := range packageInfo.Versions {
+		for _, packageVersion := range sortedVersionKeys(packageInfo.Versions) {
+			versionInfo := packageInfo.Versions[packageVersion]
 			packageNameVersionString := fmt.Sprintf("%s-%s", packageInfo.Name, packageVersion)
 			// Delegate the work of creating a unique ID to Gonum
 			newNode := graph.NewNode()
 			newId := newNode.ID()
-			stringIDToNodeInfoMap[packageNameVersionString] = *NewNodeInfo(newId, packageInfo.Name, packageVersion, versionInfo.Timestamp)
+			stringIDToNodeInfoMap[packageNameVersionString] = *newNodeInfoFromVersion(newId, NewPackageKey("", packageInfo.Name), packageVersion, versionInfo)
 			// idToNodeInfo[newId] =
 			graph.AddNode(newNode)
 		}
@@ -67,6 +208,23 @@ func CreateStringIDToNodeInfoMap(packagesInfo *[]PackageInfo, graph *simple.Dire
 	return stringIDToNodeInfoMap
 }
 
+// CreateNodeInfoSlice returns the node information indexed by node ID. Gonum hands out the IDs of a new graph
+// sequentially from zero, so the slice is dense and considerably smaller and faster than a map keyed by ID. IDs that
+// are not in use hold the zero NodeInfo.
+func CreateNodeInfoSlice(m map[string]NodeInfo) []NodeInfo {
+	var maxID int64 = -1
+	for _, val := range m {
+		if val.id > maxID {
+			maxID = val.id
+		}
+	}
+	s := make([]NodeInfo, maxID+1)
+	for _, val := range m {
+		s[val.id] = val
+	}
+	return s
+}
+
 // TODO: Maybe change to something like CreateIdToNodeInfoMap so it's not confusing for other people.
 
 func CreateNodeIdToPackageMap(m map[string]NodeInfo) map[int64]NodeInfo {
@@ -77,105 +235,196 @@ func CreateNodeIdToPackageMap(m map[string]NodeInfo) map[int64]NodeInfo {
 	return s
 }
 
+// CreateNameToVersionMap maps the name of every package to its versions, in increasing semver order.
 func CreateNameToVersionMap(m *[]PackageInfo) map[string][]string {
 	newMap := make(map[string][]string, len(*m))
 	for _, value := range *m {
-		name := value.Name
-		for k := range value.Versions {
-			newMap[name] = append(newMap[name], k)
+		if len(value.Versions) == 0 {
+			continue
+		}
+		versions := newMap[value.Name]
+		newMap[value.Name] = append(versions, sortedVersionKeys(value.Versions)...)
+		if len(versions) > 0 {
+			sortVersions(newMap[value.Name])
 		}
 	}
 	return newMap
 }
 
-//Function to write the simple graph to a dot file so it could be visualized with GraphViz. This includes only Ids
-func Visualization(graph *simple.DirectedGraph, name string) {
-	result, _ := dot.Marshal(graph, name, "", "  ")
-
-	file, err := os.Create(name + ".dot")
+// packageVersions maps the key of every package to its versions like CreateNameToVersionMap, which is what
+// NameToVersions holds.
+func packageVersions(m *[]PackageInfo) map[PackageKey][]string {
+	newMap := make(map[PackageKey][]string, len(*m))
+	for _, value := range *m {
+		if len(value.Versions) == 0 {
+			continue
+		}
+		versions := newMap[value.key()]
+		newMap[value.key()] = append(versions, sortedVersionKeys(value.Versions)...)
+		if len(versions) > 0 {
+			sortVersions(newMap[value.key()])
+		}
+	}
+	return newMap
+}
 
-	if err != nil {
-		log.Fatal("Error!", err)
+// packageNameMap returns the versions of the packages without an ecosystem, keyed by name, for the deprecated
+// functions that take the versions of CreateNameToVersionMap.
+func packageNameMap(nameToVersionMap map[string][]string) map[PackageKey][]string {
+	keyed := make(map[PackageKey][]string, len(nameToVersionMap))
+	for name, versions := range nameToVersionMap {
+		keyed[NewPackageKey("", name)] = versions
 	}
-	defer file.Close()
+	return keyed
+}
 
-	fmt.Fprint(file, string(result))
+// mavenRangeRegex matches a single Maven version range, such as "[1.0,2.0)", or a plain version.
+var mavenRangeRegex = regexp.MustCompile("((?P<open>[\\(\\[])(?P<bothVer>((?P<firstVer>(0|[1-9]+)(\\.(0|[1-9]+)(\\.(0|[1-9]+))?)?)(?P<comma1>,)(?P<secondVer1>(0|[1-9]+)(\\.(0|[1-9]+)(\\.(0|[1-9]+))?)?)?)|((?P<comma2>,)?(?P<secondVer2>(0|[1-9]+)(\\.(0|[1-9]+)(\\.(0|[1-9]+))?)?)?))(?P<close>[\\)\\]]))|(?P<simplevers>(0|[1-9]+)(\\.(0|[1-9]+)(\\.(0|[1-9]+))?)?)")
 
+// CreateEdges takes a graph, a list of packages and their dependencies, a map of stringIDs to NodeInfo and
+// a map of names to versions and creates directed edges between the dependent library and its dependencies.
+// Every dependency range is parsed as a semver constraint (after translating it from the Maven syntax if isMaven is
+// set), or by the matcher given with WithRangeMatcher, and matched against the versions of the dependency. Ranges
+// that cannot be parsed do not create edges.
+// Without options, an edge is created to every satisfying version of every runtime dependency; see Option for the
+// other behaviors.
+//
+// Deprecated: the versions are looked up by their ambiguous "name-version" key, see CreateStringIDToNodeInfoMap. Use
+// NewPackageGraph instead.
+func CreateEdges(graph *simple.DirectedGraph, inputList *[]PackageInfo, stringIDToNodeInfo map[string]NodeInfo, nameToVersionMap map[string][]string, isMaven bool, opts ...Option) {
+	options := newOptions(opts)
+	createEdges(insertOrientedEdges(graph, options), inputList, newEdgeResolver(lookupMap(stringIDToNodeInfo), packageNameMap(nameToVersionMap), isMaven, options))
 }
 
-//Writes to dot file manually from the NodeInfoMap to include the Node info in the graphViz
-//TODO: Optimize in the future since this is kind of barbaric probably there is a faster way.
-func VisualizationNodeInfo(iDToNodeInfo *map[string]NodeInfo, graph *simple.DirectedGraph, name string) {
-	file, err := os.Create(name + ".dot")
-	d1 := []byte("strict digraph" + " " + name + " " + "{\n")
-	d2 := []byte("}")
-	lab := string("[label = \" ")
-	edgIt := graph.Edges()
-
-	fmt.Fprint(file, string(d1))
+// insertEdges returns the function with which createEdges inserts the edges into the graph.
+func insertEdges(graph *simple.DirectedGraph) func(edges [][2]int64) {
+	return func(edges [][2]int64) {
+		for _, edge := range edges {
+			graph.SetEdge(simple.Edge{F: graph.Node(edge[0]), T: graph.Node(edge[1])})
+		}
+	}
+}
 
-	for key, element := range *iDToNodeInfo {
-		//fmt.Println("Key:", key, "=>", "Element:", element.id)
-		fmt.Fprintf(file, fmt.Sprint(element.id)+lab+string(key)+` \n `+string(element.Version)+` \n `+string(element.Timestamp)+"\""+"];\n")
+// createEdges resolves the edges of all the packages in inputList with the resolver and passes the edges of every
+// package to insert, from a single goroutine. With a logger, the resolution is counted even without a report, so that
+// the skipped declarations can be logged.
+func createEdges(insert func(edges [][2]int64), inputList *[]PackageInfo, resolver *edgeResolver) {
+	createEdgeSets(func(edgeSets [][][2]int64) { insert(edgeSets[0]) }, inputList, resolver, false)
+}
 
+// createEdgeSets is createEdges, but if byClass is set, the edges of every package are passed to insert separately
+// for every dependency class of the options, in their order, and otherwise as a single set.
+func createEdgeSets(insert func(edgeSets [][][2]int64), inputList *[]PackageInfo, resolver *edgeResolver, byClass bool) {
+	options := resolver.options
+	start := time.Now()
+	resolution := options.Report
+	if resolution == nil && options.Logger != nil {
+		resolution = &ResolutionReport{}
 	}
-
-	for edgIt.Next() {
-		fmt.Fprintf(file, fmt.Sprint(edgIt.Edge().From().ID())+" -> "+fmt.Sprint(edgIt.Edge().To().ID())+";\n")
+	before := *nonNilReport(resolution)
+	edgeCount := 0
+	total := len(*inputList)
+	report := func(done int) {
+		if options.Progress != nil {
+			options.Progress(done, total)
+		}
 	}
 
-	fmt.Fprint(file, string(d2))
-
-	if err != nil {
-		panic(err)
+	resolve := func(packageInfo *PackageInfo, report *ResolutionReport) [][][2]int64 {
+		if byClass {
+			return resolver.resolvePackageByClass(packageInfo, report)
+		}
+		return [][][2]int64{resolver.resolvePackage(packageInfo, report)}
+	}
+	// The edges of a package only start at its versions, so the pairs that repeat within the sets of a package are
+	// the only ones created more than once. Self-dependencies do not become edges.
+	created := make(map[[2]int64]bool)
+	count := func(edgeSets [][][2]int64) {
+		for _, edges := range edgeSets {
+			for _, edge := range edges {
+				if edge[0] != edge[1] && !created[edge] {
+					created[edge] = true
+					edgeCount++
+				}
+			}
+		}
+		for edge := range created {
+			delete(created, edge)
+		}
+	}
+	if options.Workers == 1 {
+		for i := range *inputList {
+			edgeSets := resolve(&(*inputList)[i], resolution)
+			insert(edgeSets)
+			count(edgeSets)
+			report(i + 1)
+		}
+	} else {
+		createEdgesParallel(insert, inputList, resolve, options.Workers, resolution, func(edgeSets [][][2]int64, done int) {
+			count(edgeSets)
+			report(done)
+		})
 	}
 
-	defer file.Close()
+	after := nonNilReport(resolution)
+	options.log(LevelInfo, "edge creation",
+		"duration", time.Since(start),
+		"packages", total,
+		"edges", edgeCount,
+		"declarations", after.Declarations-before.Declarations,
+		"skipped", after.UnparseableRange-before.UnparseableRange+after.UnsupportedRange-before.UnsupportedRange,
+		"unsatisfied", after.Unsatisfied-before.Unsatisfied,
+		"unknownPackage", after.UnknownPackage-before.UnknownPackage,
+		"workers", options.Workers)
+}
 
+// nonNilReport returns the report, or an empty one if it is nil.
+func nonNilReport(report *ResolutionReport) *ResolutionReport {
+	if report == nil {
+		return &ResolutionReport{}
+	}
+	return report
 }
 
-// CreateEdges takes a graph, a list of packages and their dependencies, a map of stringIDs to NodeInfo and
-// a map of names to versions and creates directed edges between the dependent library and its dependencies.
-// TODO: add documentation on how we use semver for edges
-// TODO: Discuss removing pointers from maps since they are reference types without the need of using * : https://stackoverflow.com/questions/40680981/are-maps-passed-by-value-or-by-reference-in-go
-func CreateEdges(graph *simple.DirectedGraph, inputList *[]PackageInfo, stringIDToNodeInfo map[string]NodeInfo, nameToVersionMap map[string][]string, isMaven bool) {
-	r, _ := regexp.Compile("((?P<open>[\\(\\[])(?P<bothVer>((?P<firstVer>(0|[1-9]+)(\\.(0|[1-9]+)(\\.(0|[1-9]+))?)?)(?P<comma1>,)(?P<secondVer1>(0|[1-9]+)(\\.(0|[1-9]+)(\\.(0|[1-9]+))?)?)?)|((?P<comma2>,)?(?P<secondVer2>(0|[1-9]+)(\\.(0|[1-9]+)(\\.(0|[1-9]+))?)?)?))(?P<close>[\\)\\]]))|(?P<simplevers>(0|[1-9]+)(\\.(0|[1-9]+)(\\.(0|[1-9]+))?)?)")
-	for id, packageInfo := range *inputList {
-		for _, dependencyInfo := range packageInfo.Versions {
-			for dependencyName, dependencyVersion := range dependencyInfo.Dependencies {
-				finaldep := dependencyVersion
-				if isMaven {
-					finaldep = parseMultipleMavenSemVers(dependencyVersion, r)
-				}
-				constraint, err := semver.NewConstraint(finaldep)
-				//c, err := semver2.ParseRange(dependencyVersion)
-				if err != nil {
-					continue
-					//fmt.Println("sunt aici")
-					//fmt.Println(finaldep)
-					////log.Fatal(finaldep)
-					//log.Fatal(err)
-				}
-				for _, v := range nameToVersionMap[dependencyName] {
-					//newVersion, _ := semver2.Parse(v)
-					newVersion, err := semver.NewVersion(v)
-					if err != nil {
-						//fmt.Println(v)
-						//panic(err)
-						continue
-					}
-					if constraint.Check(newVersion) {
-						dependencyNameVersionString := fmt.Sprintf("%s-%s", dependencyName, v)
-						dependencyNode := graph.Node(stringIDToNodeInfo[dependencyNameVersionString].id)
-						packageNode := graph.Node(int64(id))
-						// Ensure that we do not create edges to self because some packages do that...
-						if dependencyNode != packageNode {
-							graph.SetEdge(simple.Edge{F: packageNode, T: dependencyNode})
-						}
-
-					}
-				}
+// createEdgesParallel matches the ranges with the given number of workers, counting the resolution in resolution if it
+// is not nil, and calls inserted after the edges of every package have been inserted.
+func createEdgesParallel(insert func(edgeSets [][][2]int64), inputList *[]PackageInfo, resolve func(*PackageInfo, *ResolutionReport) [][][2]int64, workers int, resolution *ResolutionReport, inserted func(edgeSets [][][2]int64, done int)) {
+	// The range matching is done by the workers, while the edges are inserted here because neither the graph nor
+	// the edge file is safe for concurrent mutation.
+	indices := make(chan int)
+	results := make(chan [][][2]int64)
+	var wg sync.WaitGroup
+	// Every worker fills its own report, which are added up once all the edges have been created.
+	reports := make([]*ResolutionReport, workers)
+	for w := 0; w < workers; w++ {
+		if resolution != nil {
+			reports[w] = &ResolutionReport{}
+		}
+		wg.Add(1)
+		go func(report *ResolutionReport) {
+			defer wg.Done()
+			for i := range indices {
+				results <- resolve(&(*inputList)[i], report)
 			}
+		}(reports[w])
+	}
+	go func() {
+		for i := range *inputList {
+			indices <- i
+		}
+		close(indices)
+		wg.Wait()
+		close(results)
+	}()
+	done := 0
+	for edgeSets := range results {
+		insert(edgeSets)
+		done++
+		inserted(edgeSets, done)
+	}
+	if resolution != nil {
+		for _, workerReport := range reports {
+			resolution.add(workerReport)
 		}
 	}
 }
@@ -260,47 +509,137 @@ func translateMavenSemver(s string, reg *regexp.Regexp) string {
 
 }
 
+// ParseJSON parses the JSON array of packages at inPath. Identical strings in the result share their storage, see
+// Interner.
 func ParseJSON(inPath string) *[]PackageInfo {
+	return ParseJSONWithInterner(inPath, NewInterner())
+}
+
+// ParseJSONWithInterner parses the JSON array of packages at inPath, interning the names, versions and dependency
+// ranges of every package with the given interner as soon as it is decoded. Passing a nil interner disables interning.
+// The program exits if the file cannot be read, use ReadPackagesJSON to handle the error instead.
+func ParseJSONWithInterner(inPath string, interner *Interner) *[]PackageInfo {
+	result, err := ReadPackagesJSON(inPath, interner)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return result
+}
+
+// ReadPackagesJSON parses the JSON array of packages at inPath like ParseJSONWithInterner, but returns an error if the
+// file cannot be opened or is not a valid array of packages. Of the options, only the time window of WithTimeWindow
+// is applied, while decoding, so the versions outside it never enter the result, WithSkipCorrupt, which skips the
+// corrupt records instead of failing on the first one, and WithProvenance, which records where every package was
+// decoded from.
+func ReadPackagesJSON(inPath string, interner *Interner, opts ...Option) (*[]PackageInfo, error) {
 	// For NPM at least, about 2 million packages are expected, so we initialize so the array doesn't have to be re-allocated all the time
 	const expectedAmount int = 2000000
+	result, err := readPackagesJSON(inPath, interner, newOptions(opts), expectedAmount)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// readPackagesJSON is ReadPackagesJSON with the capacity of the result, the number of packages expected in the file.
+func readPackagesJSON(inPath string, interner *Interner, options *Options, expected int) ([]PackageInfo, error) {
 	// An array for now since lists aren't type-safe, and they would overcomplicate things
-	result := make([]PackageInfo, 0, expectedAmount)
+	result := make([]PackageInfo, 0, expected)
 	f, err := os.Open(inPath)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	defer f.Close()
+
+	add := func(packageInfo PackageInfo, start, end int64) {
+		packageInfo, ok := options.windowPackage(packageInfo, true)
+		if !ok {
+			return
+		}
+		if options.Provenance {
+			packageInfo.Provenance = &Provenance{File: inPath, Start: start, End: end}
+		}
+		if interner != nil {
+			interner.InternPackage(&packageInfo)
+		}
+		result = append(result, packageInfo)
+	}
+	if options.Corrupt != nil {
+		if err := readPackagesSkippingCorrupt(f, options, add); err != nil {
+			return nil, fmt.Errorf("decoding %s: %w", inPath, err)
+		}
+		return result, nil
 	}
 
 	dec := json.NewDecoder(f)
 
 	//Read opening bracket
 	if _, err := dec.Token(); err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("decoding %s: %w", inPath, err)
 	}
 
 	for dec.More() {
 		var packageInfo PackageInfo
 
-		if err := dec.Decode(&packageInfo); err != nil {
-			log.Fatal(err)
+		if !options.Provenance {
+			if err := dec.Decode(&packageInfo); err != nil {
+				return nil, fmt.Errorf("decoding %s: package %d: %w", inPath, len(result), err)
+			}
+			add(packageInfo, 0, 0)
+			continue
 		}
-		result = append(result, packageInfo)
+		// The decoder only tells the offset after a record, but a raw message holds the record exactly as written, so
+		// its length leads back to the start.
+		var record json.RawMessage
+		err := dec.Decode(&record)
+		if err == nil {
+			err = json.Unmarshal(record, &packageInfo)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s: package %d: %w", inPath, len(result), err)
+		}
+		end := dec.InputOffset()
+		add(packageInfo, end-int64(len(record)), end)
 	}
 
 	//Read closing bracket
 	if _, err := dec.Token(); err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("decoding %s: %w", inPath, err)
 	}
-	return &result
+	return result, nil
 }
 
+// CreateGraph parses the JSON file at inputPath and returns the graph together with its lookup maps.
+//
+// Deprecated: the stringID to NodeInfo map is ambiguous, see CreateStringIDToNodeInfoMap. Use CreatePackageGraph, whose
+// methods look up versions by NameVersion.
 func CreateGraph(inputPath string, isUsingMaven bool) (*simple.DirectedGraph, *[]PackageInfo, map[string]NodeInfo, map[int64]NodeInfo, map[string][]string) {
-	packagesList := ParseJSON(inputPath)
-	graph := simple.NewDirectedGraph()
-	stringIDToNodeInfo := CreateStringIDToNodeInfoMap(packagesList, graph)
-	idToNodeInfo := CreateNodeIdToPackageMap(stringIDToNodeInfo)
-	nameToVersions := CreateNameToVersionMap(packagesList)
-	CreateEdges(graph, packagesList, stringIDToNodeInfo, nameToVersions, isUsingMaven)
-	return graph, packagesList, stringIDToNodeInfo, idToNodeInfo, nameToVersions
+	pg := CreatePackageGraph(inputPath, isUsingMaven)
+	return pg.Graph, pg.Packages, pg.StringIDToNodeInfo, pg.NodeMap(), CreateNameToVersionMap(pg.Packages)
+}
+
+// timestampLayouts are the layouts accepted by ParseTimestamp, tried in order. The datasets we use mostly contain
+// timestamps without a timezone, which time.RFC3339 alone does not accept.
+var timestampLayouts = []string{
+	time.RFC3339,
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// ParseTimestamp parses a package timestamp in any of the formats found in the input data. Timestamps without a
+// timezone are interpreted as UTC.
+func ParseTimestamp(timestamp string) (time.Time, error) {
+	var err error
+	for _, layout := range timestampLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, timestamp); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
 }
 
 // This function returns true when time t lies in the interval [begin, end], false otherwise
@@ -398,6 +737,9 @@ func findNode(stringMap map[string]NodeInfo, stringId string) (int64, bool) {
 	return nodeId, correctOk
 }
 
+// FilterNode removes the stale edges of the part of the graph reachable from the version with the given stringID.
+//
+// Deprecated: the stringID of a version is ambiguous, see CreateStringIDToNodeInfoMap.
 func FilterNode(g *simple.DirectedGraph, nodeMap map[int64]NodeInfo, stringMap map[string]NodeInfo, stringId string, beginTime, endTime time.Time) {
 
 	var nodeId int64
@@ -418,6 +760,9 @@ func FilterNode(g *simple.DirectedGraph, nodeMap map[int64]NodeInfo, stringMap m
 }
 
 // This function returns the specified node and its dependencies
+//
+// Deprecated: the stringID of a version is ambiguous, see CreateStringIDToNodeInfoMap. Use PackageGraph.Dependencies
+// instead.
 func GetTransitiveDependenciesNode(g *simple.DirectedGraph, nodeMap map[int64]NodeInfo, stringMap map[string]NodeInfo, stringId string) *[]NodeInfo {
 	var nodeId int64
 	result := make([]NodeInfo, 0, len(nodeMap)/2)
@@ -2,6 +2,7 @@ package graph
 
 import (
 	"fmt"
+	"math/rand"
 	"testing"
 
 	"gonum.org/v1/gonum/graph/simple"
@@ -136,8 +137,19 @@ func TestNodeCreationMediumComplexity(t *testing.T) {
 
 	t.Run("Creates 8 nodes, one for every package version", func(t *testing.T) {
 
-		if numNodes := graph.Edges().Len(); numNodes != 8 {
-			t.Errorf("Expected 8 edges, got %d", numNodes)
+		if numNodes := graph.Nodes().Len(); numNodes != 8 {
+			t.Errorf("Expected 8 nodes, got %d", numNodes)
+		}
+
+	})
+
+	t.Run("Creates the edges from the dependent version that declared them", func(t *testing.T) {
+
+		if numEdges := graph.Edges().Len(); numEdges != 9 {
+			t.Errorf("Expected 9 edges, got %d", numEdges)
+		}
+		if numDependencies := graph.From(stringNodeInfo["C-2.0.0"].id).Len(); numDependencies != 3 {
+			t.Errorf("Expected 3 dependencies for C-2.0.0, got %d", numDependencies)
 		}
 
 	})
@@ -196,6 +208,450 @@ func createTestNodeInfo(pi PackageInfo, version string) NodeInfo {
 	}
 }
 
+// testPackages returns new packages of the named fixture, which the tests may change.
+func testPackages(name string) []PackageInfo {
+	switch name {
+	case "options":
+		return []PackageInfo{
+			{
+				Name: "App",
+				Versions: map[string]VersionInfo{
+					"1.0.0": {
+						Timestamp: "2022-04-22T20:15:37",
+						Dependencies: map[string]string{
+							"A": ">= 1.0.0",
+						},
+						DevDependencies: map[string]string{
+							"Test": "1.0.0",
+						},
+					},
+				},
+			},
+			{
+				Name: "A",
+				Versions: map[string]VersionInfo{
+					"1.0.0": {
+						Timestamp:    "2020-01-01T00:00:00",
+						Dependencies: map[string]string{},
+					},
+					"1.1.0": {
+						Timestamp:    "2021-01-01T00:00:00",
+						Dependencies: map[string]string{},
+					},
+					"1.2.0": {
+						Timestamp:    "2022-01-01T00:00:00",
+						Dependencies: map[string]string{},
+					},
+				},
+			},
+			{
+				Name: "Test",
+				Versions: map[string]VersionInfo{
+					"1.0.0": {
+						Timestamp:    "2020-01-01T00:00:00",
+						Dependencies: map[string]string{},
+					},
+				},
+			},
+		}
+	case "weights":
+		return []PackageInfo{
+			{Name: "App", Versions: map[string]VersionInfo{
+				"1.0.0": {
+					Timestamp:        "2022-01-01T00:00:00",
+					Dependencies:     map[string]string{"Lib": ">= 1.0.0", "Util": "1.0.0"},
+					PeerDependencies: map[string]string{"Lib": "^1.1.0"},
+				},
+				"2.0.0": {Timestamp: "2022-02-01T00:00:00", Dependencies: map[string]string{"Lib": "1.0.0"}},
+			}},
+			{Name: "Lib", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2021-01-01T00:00:00", Dependencies: map[string]string{}},
+				"1.1.0": {Timestamp: "2021-02-01T00:00:00", Dependencies: map[string]string{}},
+				"1.2.0": {Timestamp: "2021-03-01T00:00:00", Dependencies: map[string]string{"Lib": "1.0.0"}},
+			}},
+			{Name: "Util", Versions: map[string]VersionInfo{"1.0.0": {Timestamp: "2021-01-01T00:00:00", Dependencies: map[string]string{}}}},
+		}
+	case "deprecation":
+		return []PackageInfo{
+			{Name: "App", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2022-01-01T00:00:00", Dependencies: map[string]string{"A": "^1.0.0", "B": "^1.0.0"}},
+			}},
+			{Name: "A", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{}},
+				"1.1.0": {Timestamp: "2021-01-01T00:00:00", Deprecated: "use 1.0.0", Dependencies: map[string]string{}},
+			}},
+			// The only version of B that satisfies the range is deprecated.
+			{Name: "B", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2020-01-01T00:00:00", Deprecated: "no longer maintained", Dependencies: map[string]string{}},
+				"2.0.0": {Timestamp: "2021-01-01T00:00:00", Dependencies: map[string]string{}},
+			}},
+		}
+	case "digest":
+		return []PackageInfo{
+			{Name: "Dep", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2020-01-01T00:00:00"},
+				"2.0.0": {Timestamp: "2021-01-01T00:00:00"},
+			}},
+			{Name: "Lib", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2021-06-01T00:00:00", Dependencies: map[string]string{"Dep": "^1.0.0"}},
+				"1.1.0": {Timestamp: "2022-01-10T00:00:00", Dependencies: map[string]string{"Dep": "^2.0.0"}},
+				"2.0.0": {Timestamp: "2022-01-20T00:00:00", Dependencies: map[string]string{"Dep": "^2.0.0"}},
+			}},
+			{Name: "Old", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2021-07-01T00:00:00", Dependencies: map[string]string{"Lib": "^1.0.0"}},
+			}},
+			{Name: "App", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2021-09-01T00:00:00", Dependencies: map[string]string{"Lib": "^2.0.0"}},
+				"1.1.0": {Timestamp: "yesterday", Dependencies: map[string]string{"Lib": "^3.0.0"}},
+			}},
+			{Name: "Late", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2022-01-15T00:00:00", Dependencies: map[string]string{"Lib": "^2.0.0"}},
+			}},
+			{Name: "Fresh", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2022-01-05T00:00:00", PeerDependencies: map[string]string{"Lib": "^1.1.0"}},
+			}},
+		}
+	case "ecosystems":
+		return []PackageInfo{
+			{Name: "requests", Ecosystem: "pypi", Versions: map[string]VersionInfo{
+				"2.31.0": {Timestamp: "2023-05-22T00:00:00", Dependencies: map[string]string{"urllib3": ">=1.21.1"}},
+			}},
+			{Name: "urllib3", Ecosystem: "pypi", Versions: map[string]VersionInfo{
+				"2.0.0": {Timestamp: "2023-04-26T00:00:00", Dependencies: map[string]string{}},
+			}},
+			{Name: "requests", Ecosystem: "npm", Versions: map[string]VersionInfo{
+				"0.3.0": {Timestamp: "2019-01-01T00:00:00", Dependencies: map[string]string{"urllib3": "*"}},
+			}},
+			{Name: "urllib3", Ecosystem: "npm", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2018-01-01T00:00:00", Dependencies: map[string]string{}},
+			}},
+			{Name: "app", Ecosystem: "pypi", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2023-06-01T00:00:00", Dependencies: map[string]string{"requests": "^2.0.0"}},
+			}},
+		}
+	// Packages with several versions each that depend on random ranges of the packages before them, so that the
+	// versions share dependencies and dependents.
+	case "lazy":
+		random := rand.New(rand.NewSource(1))
+		return generateTestPackages(random, 20, []string{"1.0.0", "1.1.0", "1.2.0"}, 3, func() string { return fmt.Sprintf("^1.%d.0", random.Intn(3)) })
+	// The chain E -> C -> B -> A, with D -> B and C -> A.
+	case "ego":
+		version := func(dependencies map[string]string) map[string]VersionInfo {
+			return map[string]VersionInfo{"1.0.0": {Timestamp: "2022-01-01T00:00:00", Dependencies: dependencies}}
+		}
+		return []PackageInfo{
+			{Name: "A", Versions: version(map[string]string{})},
+			{Name: "B", Versions: version(map[string]string{"A": "1.0.0"})},
+			{Name: "C", Versions: version(map[string]string{"A": "1.0.0", "B": "1.0.0"})},
+			{Name: "D", Versions: version(map[string]string{"B": "1.0.0"})},
+			{Name: "E", Versions: version(map[string]string{"C": "1.0.0"})},
+		}
+	case "growth":
+		return []PackageInfo{
+			{Name: "A", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2019-03-01T00:00:00"},
+				"1.1.0": {Timestamp: "2021-03-01T00:00:00"},
+			}},
+			{Name: "B", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2019-06-01T00:00:00", Dependencies: map[string]string{"A": "^1.0.0"}},
+			}},
+			{Name: "C", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2021-06-01T00:00:00", Dependencies: map[string]string{"A": "^1.0.0", "B": "^1.0.0"}},
+			}},
+			{Name: "D", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "", Dependencies: map[string]string{"A": "^1.0.0"}},
+			}},
+		}
+	case "lockfile":
+		return []PackageInfo{
+			{Name: "App", Versions: map[string]VersionInfo{
+				"1.0.0": {
+					Timestamp:       "2023-01-01T00:00:00",
+					Dependencies:    map[string]string{"A": "^1.0.0", "B": "^1.0.0"},
+					DevDependencies: map[string]string{"T": "1.0.0"},
+				},
+			}},
+			{Name: "A", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2020-01-01T00:00:00"},
+				"1.1.0": {Timestamp: "2021-01-01T00:00:00"},
+				"2.0.0": {Timestamp: "2022-01-01T00:00:00"},
+			}},
+			{Name: "B", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{"A": "^2.0.0", "C": "^1.0.0"}},
+			}},
+			{Name: "C", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{"B": "^1.0.0"}},
+			}},
+			{Name: "T", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{"C": "^1.0.0", "D": "1.0.0"}},
+			}},
+			{Name: "D", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2020-01-01T00:00:00"},
+			}},
+		}
+	case "names":
+		return []PackageInfo{
+			{
+				Name: "app",
+				Versions: map[string]VersionInfo{
+					"1.0.0": {Timestamp: "2022-01-01T00:00:00", Dependencies: map[string]string{
+						"%40babel%2Fcore": "^7.0.0",
+						"Left-Pad":        "^1.0.0",
+					}},
+				},
+			},
+			{
+				Name: "@babel/core",
+				Versions: map[string]VersionInfo{
+					"7.1.0": {Timestamp: "2021-01-01T00:00:00", Dependencies: map[string]string{}},
+				},
+			},
+			{
+				Name: "left-pad",
+				Versions: map[string]VersionInfo{
+					"1.3.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{}},
+				},
+			},
+		}
+	case "normalization":
+		return []PackageInfo{
+			{
+				Name: "App",
+				Versions: map[string]VersionInfo{
+					"1.0.0": {Timestamp: "2022-04-22T20:15:37", Dependencies: map[string]string{"A": ">=1.2.3"}},
+				},
+			},
+			{
+				Name: "A",
+				Versions: map[string]VersionInfo{
+					"v1.2":    {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{}},
+					"1.2.3.4": {Timestamp: "2020-02-01T00:00:00", Dependencies: map[string]string{}},
+					"latest":  {Timestamp: "2020-03-01T00:00:00", Dependencies: map[string]string{}},
+				},
+			},
+		}
+	case "page":
+		version := func(timestamp string, dependencies map[string]string) map[string]VersionInfo {
+			return map[string]VersionInfo{"1.0.0": {Timestamp: timestamp, Dependencies: dependencies}}
+		}
+		lib := map[string]string{"Lib": "^1.0.0"}
+		return []PackageInfo{
+			{Name: "Lib", Versions: version("2020-01-01T00:00:00", nil)},
+			{Name: "Cli", Versions: version("2022-03-01T00:00:00", lib)},
+			{Name: "App", Versions: version("2022-02-01T00:00:00", lib)},
+			{Name: "Web", Versions: version("yesterday", lib)},
+			{Name: "Site", Versions: version("2022-01-01T00:00:00", map[string]string{"Web": "1.0.0"})},
+			{Name: "Blog", Versions: version("2021-01-01T00:00:00", map[string]string{"Web": "1.0.0", "Cli": "1.0.0"})},
+		}
+	case "release":
+		version := func(dependencies map[string]string) VersionInfo {
+			return VersionInfo{Timestamp: "2022-01-01T00:00:00", Dependencies: dependencies}
+		}
+		none := map[string]string{}
+		web := version(map[string]string{"Lib": ">=1.0.0"})
+		web.PeerDependencies = map[string]string{"Lib": "^1.2.0"}
+		return []PackageInfo{
+			{Name: "Lib", Versions: map[string]VersionInfo{"1.0.0": version(none), "1.1.0": version(none)}},
+			{Name: "App", Versions: map[string]VersionInfo{"1.0.0": version(map[string]string{"Lib": "^1.0.0"})}},
+			{Name: "Cli", Versions: map[string]VersionInfo{"1.0.0": version(map[string]string{"Lib": "~1.0.0"})}},
+			{Name: "Web", Versions: map[string]VersionInfo{"1.0.0": web}},
+			{Name: "Old", Versions: map[string]VersionInfo{"1.0.0": version(map[string]string{"Lib": "1.1.0"})}},
+			{Name: "Util", Versions: map[string]VersionInfo{"1.0.0": version(none), "1.3.0": version(none)}},
+		}
+	case "resolution":
+		return []PackageInfo{
+			{
+				Name: "App",
+				Versions: map[string]VersionInfo{
+					"1.0.0": {
+						Timestamp: "2022-04-22T20:15:37",
+						Dependencies: map[string]string{
+							"A":       "^1.0.0",
+							"B":       "^2.0.0",
+							"Missing": "1.0.0",
+						},
+					},
+					"2.0.0": {
+						Timestamp: "2022-05-22T20:15:37",
+						Dependencies: map[string]string{
+							"A": "not a range",
+							"B": "^2.0.0",
+						},
+					},
+				},
+			},
+			{
+				Name: "A",
+				Versions: map[string]VersionInfo{
+					"1.0.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{}},
+				},
+			},
+			{
+				Name: "B",
+				Versions: map[string]VersionInfo{
+					"1.0.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{}},
+				},
+			},
+		}
+	case "sample":
+		return generateTestPackages(rand.New(rand.NewSource(1)), 60, []string{"1.0.0"}, 2, func() string { return "1.0.0" })
+	case "satisfying":
+		packagesInfo := testPackages("options")
+		packagesInfo[1].Versions["1.3.0-beta.1"] = VersionInfo{Timestamp: "2022-02-01T00:00:00", Dependencies: map[string]string{}}
+		packagesInfo[1].Versions["1.2.1"] = VersionInfo{Timestamp: "not a timestamp", Dependencies: map[string]string{}}
+		return packagesInfo
+	// 20 versions that form dependency chains, a diamond and a cycle between Util and Core.
+	case "render":
+		version := func(timestamp string, dependencies map[string]string) VersionInfo {
+			return VersionInfo{Timestamp: timestamp, Dependencies: dependencies}
+		}
+		none := map[string]string{}
+		return []PackageInfo{
+			{Name: "Cli", Versions: map[string]VersionInfo{"1.0.0": version("2022-03-01", map[string]string{"App": "1.0.0", "Term": "^1.0.0"})}},
+			{Name: "App", Versions: map[string]VersionInfo{"1.0.0": version("2022-02-01", map[string]string{"Web": "^2.0.0", "Log": "^1.0.0"})}},
+			{Name: "Web", Versions: map[string]VersionInfo{
+				"2.0.0": version("2021-06-01", map[string]string{"Http": "^1.0.0", "Log": "^1.0.0"}),
+				"2.1.0": version("2021-09-01", map[string]string{"Http": "^1.1.0", "Log": "^1.0.0"}),
+			}},
+			{Name: "Http", Versions: map[string]VersionInfo{
+				"1.0.0": version("2021-01-01", map[string]string{"Util": "^1.0.0", "Json": "^1.0.0"}),
+				"1.1.0": version("2021-04-01", map[string]string{"Util": "^1.0.0", "Json": "^1.1.0"}),
+			}},
+			{Name: "Log", Versions: map[string]VersionInfo{
+				"1.0.0": version("2021-01-01", map[string]string{"Util": "^1.0.0"}),
+				"1.1.0": version("2021-05-01", map[string]string{"Util": "^1.0.0"}),
+			}},
+			{Name: "Util", Versions: map[string]VersionInfo{"1.0.0": version("2020-06-01", map[string]string{"Core": "1.0.0"})}},
+			{Name: "Core", Versions: map[string]VersionInfo{"1.0.0": version("2020-06-01", map[string]string{"Util": "1.0.0"})}},
+			{Name: "Term", Versions: map[string]VersionInfo{
+				"1.0.0": version("2020-01-01", map[string]string{"Util": "^1.0.0"}),
+				"1.1.0": version("2020-03-01", map[string]string{"Util": "^1.0.0"}),
+				"1.2.0": version("2020-09-01", map[string]string{"Util": "^1.0.0"}),
+			}},
+			{Name: "Json", Versions: map[string]VersionInfo{"1.0.0": version("2020-01-01", none), "1.1.0": version("2020-08-01", none)}},
+			{Name: "Conf", Versions: map[string]VersionInfo{"1.0.0": version("2021-02-01", map[string]string{"Json": "^1.0.0", "Yaml": "*"})}},
+			{Name: "Yaml", Versions: map[string]VersionInfo{"1.0.0": version("2019-01-01", none), "2.0.0": version("2020-01-01", none)}},
+			{Name: "Mock", Versions: map[string]VersionInfo{"1.0.0": version("2021-01-01", map[string]string{"Test": "1.0.0"})}},
+			{Name: "Test", Versions: map[string]VersionInfo{"1.0.0": version("2020-01-01", none)}},
+		}
+	case "trace":
+		released := func(timestamp string) VersionInfo {
+			return VersionInfo{Timestamp: timestamp, Dependencies: map[string]string{}}
+		}
+		deprecated := released("2021-02-01T00:00:00")
+		deprecated.Deprecated = "use 1.0.0"
+		return []PackageInfo{
+			{Name: "Lib", Versions: map[string]VersionInfo{
+				"1.0.0":      released("2021-01-01T00:00:00"),
+				"1.1.0":      deprecated,
+				"1.2.0-rc.1": released("2021-03-01T00:00:00"),
+				"1.3.0":      released("2023-01-01T00:00:00"),
+				"2.0.0":      released("2021-04-01T00:00:00"),
+			}},
+			{Name: "Other", Versions: map[string]VersionInfo{"1.0.0": released("2021-01-01T00:00:00")}},
+			{Name: "App", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2022-01-01T00:00:00", Dependencies: map[string]string{"Lib": "^1.0.0", "Other": "1.0.0"}},
+				"2.0.0": {Timestamp: "2022-02-01T00:00:00", Dependencies: map[string]string{"Lib": "~3.0.0"}},
+			}},
+		}
+	// Newly published versions of the options packages, both of new dependents and of existing dependencies.
+	case "delta":
+		return []PackageInfo{
+			{
+				Name: "A",
+				Versions: map[string]VersionInfo{
+					"1.3.0": {Timestamp: "2022-06-01T00:00:00", Dependencies: map[string]string{"Test": "^1.0.0"}},
+				},
+			},
+			{
+				Name: "New",
+				Versions: map[string]VersionInfo{
+					"0.1.0": {Timestamp: "2022-06-01T00:00:00", Dependencies: map[string]string{"A": "~1.1.0", "App": "*"}},
+					"0.2.0": {Timestamp: "2022-07-01T00:00:00", Dependencies: map[string]string{"A": ">= 1.2.0"}},
+				},
+			},
+		}
+	// App depends on A with the range ^1.0.0, which the prerelease tests replace.
+	case "prerelease":
+		return []PackageInfo{
+			{
+				Name: "App",
+				Versions: map[string]VersionInfo{
+					"1.0.0": {Timestamp: "2022-04-22T20:15:37", Dependencies: map[string]string{"A": "^1.0.0"}},
+				},
+			},
+			{
+				Name: "A",
+				Versions: map[string]VersionInfo{
+					"1.0.0-rc.1": {Timestamp: "2019-12-01T00:00:00", Dependencies: map[string]string{}},
+					"1.0.0":      {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{}},
+					"1.1.0-rc.1": {Timestamp: "2020-02-01T00:00:00", Dependencies: map[string]string{}},
+					"2.0.0-rc.1": {Timestamp: "2020-03-01T00:00:00", Dependencies: map[string]string{}},
+				},
+			},
+		}
+	// Packages with ten dependencies per version, of which three are on packages that are not part of the input.
+	case "unknown names":
+		const packages = 200
+		packagesInfo := make([]PackageInfo, 0, packages)
+		for i := 0; i < packages; i++ {
+			dependencies := make(map[string]string, 10)
+			for k := 1; k <= 7; k++ {
+				dependencies[fmt.Sprintf("package-%d", (i+k)%packages)] = "^1.0.0"
+			}
+			for k := 1; k <= 3; k++ {
+				dependencies[fmt.Sprintf("@private/package-%d-%d", i, k)] = "^1.0.0"
+			}
+			versions := map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2022-01-01T00:00:00", Dependencies: dependencies},
+				"1.1.0": {Timestamp: "2022-02-01T00:00:00", Dependencies: dependencies},
+			}
+			packagesInfo = append(packagesInfo, PackageInfo{Name: fmt.Sprintf("package-%d", i), Versions: versions})
+		}
+		return packagesInfo
+	// A package with many versions that all the other packages depend on.
+	case "popular":
+		const versions, dependents = 50, 200
+		popular := PackageInfo{Name: "popular", Versions: make(map[string]VersionInfo, versions)}
+		for i := 0; i < versions; i++ {
+			popular.Versions[fmt.Sprintf("%d.%d.%d", i/100, i/10%10, i%10)] = VersionInfo{Timestamp: "2020-01-01T00:00:00"}
+		}
+		packages := []PackageInfo{popular}
+		random := rand.New(rand.NewSource(1))
+		for i := 0; i < dependents; i++ {
+			i := random.Intn(versions)
+			dependencyRange := fmt.Sprintf("^%d.%d.%d", i/100, i/10%10, i%10)
+			packages = append(packages, PackageInfo{
+				Name: fmt.Sprintf("dependent%d", len(packages)),
+				Versions: map[string]VersionInfo{
+					"1.0.0": {Timestamp: "2021-01-01T00:00:00", Dependencies: map[string]string{"popular": dependencyRange}},
+				},
+			})
+		}
+		return packages
+	}
+	panic("unknown test fixture " + name)
+}
+
+// generateTestPackages returns count packages named P0, P1 and so on with the given versions, all released at the same
+// time, of which every version depends on up to dependencies random packages before it with a range returned by
+// ranges.
+func generateTestPackages(random *rand.Rand, count int, versions []string, dependencies int, ranges func() string) []PackageInfo {
+	packages := make([]PackageInfo, 0, count)
+	for i := 0; i < count; i++ {
+		packageInfo := PackageInfo{Name: fmt.Sprintf("P%d", i), Versions: make(map[string]VersionInfo, len(versions))}
+		for _, version := range versions {
+			dependencyRanges := make(map[string]string)
+			for j := 0; j < dependencies && i > 0; j++ {
+				dependencyRanges[fmt.Sprintf("P%d", random.Intn(i))] = ranges()
+			}
+			packageInfo.Versions[version] = VersionInfo{Timestamp: "2020-01-01T00:00:00", Dependencies: dependencyRanges}
+		}
+		packages = append(packages, packageInfo)
+	}
+	return packages
+}
+
 func TestCreateEdgesBasicGraph(t *testing.T) {
 	simplePackagesInfo := []PackageInfo{
 		{
@@ -325,3 +781,33 @@ func TestCreateEdgesMediumComplexityGraph(t *testing.T) {
 		}
 	})
 }
+
+// BenchmarkNodeInfoStorage compares building and reading the node information as a map keyed by ID and as a dense
+// slice indexed by ID.
+func BenchmarkNodeInfoStorage(b *testing.B) {
+	const nodes = 200000
+	stringIDToNodeInfo := make(map[string]NodeInfo, nodes)
+	for id := int64(0); id < nodes; id++ {
+		info := NewNodeInfo(id, fmt.Sprintf("package-%d", id/10), fmt.Sprintf("1.0.%d", id%10), "2021-04-22T20:15:37")
+		stringIDToNodeInfo[info.stringID] = *info
+	}
+
+	b.Run("map", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			nodeMap := CreateNodeIdToPackageMap(stringIDToNodeInfo)
+			for id := int64(0); id < nodes; id++ {
+				_ = nodeMap[id]
+			}
+		}
+	})
+	b.Run("slice", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			nodeSlice := CreateNodeInfoSlice(stringIDToNodeInfo)
+			for id := int64(0); id < nodes; id++ {
+				_ = nodeSlice[id]
+			}
+		}
+	})
+}
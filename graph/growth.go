@@ -0,0 +1,121 @@
+package graph
+
+import (
+	"time"
+)
+
+// YearRow is the growth of the ecosystem during one calendar year, in UTC, or during an unknown year for the versions
+// whose timestamp cannot be parsed.
+type YearRow struct {
+	// Year is the calendar year. It is 0 for the row of the unknown year, for which Unknown is set.
+	Year    int
+	Unknown bool
+	// FirstPublished is the number of packages whose first version was published in the year. Packages without a
+	// parseable timestamp on any version count for the unknown year.
+	FirstPublished int
+	// Versions is the number of versions published in the year, and Declarations the number of runtime dependencies
+	// they declare.
+	Versions     int
+	Declarations int
+	// MeanDirect is the mean number of runtime dependencies that the versions published in the year declare.
+	MeanDirect float64
+	// MeanTransitive is the mean number of versions that the versions published in the year transitively depend on,
+	// in the graph of all versions published by the end of the year with ResolveHighest. For the unknown year, the
+	// graph at the end of the last year is used.
+	MeanTransitive float64
+}
+
+// GrowthReport summarizes the growth of the ecosystem per calendar year, from the first to the last year in which a
+// version was published, followed by a row for the versions without a parseable timestamp if there are any. The
+// transitive dependencies are counted on year-end snapshots built by SnapshotSeries, so only the first snapshot is
+// built from scratch. The packages are not modified.
+func GrowthReport(packages []PackageInfo) ([]YearRow, error) {
+	// published holds the versions published in every year, with the unknown year under 0.
+	published := make(map[int][]NameVersion)
+	firstYear, lastYear := 0, 0
+	rows := make(map[int]*YearRow)
+	row := func(year int) *YearRow {
+		if rows[year] == nil {
+			rows[year] = &YearRow{Year: year, Unknown: year == 0}
+		}
+		return rows[year]
+	}
+	for _, packageInfo := range packages {
+		first := 0
+		for version, versionInfo := range packageInfo.Versions {
+			year := 0
+			if released, err := ParseTimestamp(versionInfo.Timestamp); err == nil {
+				year = released.UTC().Year()
+				if firstYear == 0 || year < firstYear {
+					firstYear = year
+				}
+				if year > lastYear {
+					lastYear = year
+				}
+				if first == 0 || year < first {
+					first = year
+				}
+			}
+			published[year] = append(published[year], packageInfo.key().version(version))
+			row(year).Versions++
+			row(year).Declarations += len(versionInfo.Dependencies)
+		}
+		if len(packageInfo.Versions) > 0 {
+			row(first).FirstPublished++
+		}
+	}
+
+	var result []YearRow
+	if firstYear != 0 {
+		var dates []time.Time
+		for year := firstYear; year <= lastYear; year++ {
+			dates = append(dates, time.Date(year+1, 1, 1, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond))
+		}
+		year := firstYear
+		_, err := SnapshotSeries(packages, false, dates, func(pg *PackageGraph) (float64, error) {
+			row(year).MeanTransitive = meanTransitiveDependencies(pg, published[year])
+			if year == lastYear && rows[0] != nil {
+				rows[0].MeanTransitive = meanTransitiveDependencies(pg, published[0])
+			}
+			year++
+			return 0, nil
+		}, WithResolution(ResolveHighest))
+		if err != nil {
+			return nil, err
+		}
+		for year := firstYear; year <= lastYear; year++ {
+			result = append(result, *row(year))
+		}
+	} else if rows[0] != nil {
+		pg := NewPackageGraph(&packages, false, WithResolution(ResolveHighest))
+		rows[0].MeanTransitive = meanTransitiveDependencies(pg, published[0])
+	}
+	if rows[0] != nil {
+		result = append(result, *rows[0])
+	}
+	for i := range result {
+		if result[i].Versions > 0 {
+			result[i].MeanDirect = float64(result[i].Declarations) / float64(result[i].Versions)
+		}
+	}
+	return result, nil
+}
+
+// meanTransitiveDependencies returns the mean number of transitive dependencies of the versions in the graph, or 0 if
+// none of them is part of it.
+func meanTransitiveDependencies(pg *PackageGraph, versions []NameVersion) float64 {
+	roots := make([]int64, 0, len(versions))
+	for _, nameVersion := range versions {
+		if info, ok := pg.FindNode(nameVersion); ok {
+			roots = append(roots, info.id)
+		}
+	}
+	if len(roots) == 0 {
+		return 0
+	}
+	total := 0
+	for _, count := range TransitiveDependencyCounts(pg.DependencyGraph(), roots) {
+		total += count
+	}
+	return float64(total) / float64(len(roots))
+}
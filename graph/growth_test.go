@@ -0,0 +1,38 @@
+package graph
+
+import (
+	"testing"
+)
+
+func TestGrowthReport(t *testing.T) {
+	t.Run("Summarizes every year and the unknown year", func(t *testing.T) {
+		rows, err := GrowthReport(testPackages("growth"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []YearRow{
+			{Year: 2019, FirstPublished: 2, Versions: 2, Declarations: 1, MeanDirect: 0.5, MeanTransitive: 0.5},
+			{Year: 2020},
+			{Year: 2021, FirstPublished: 1, Versions: 2, Declarations: 2, MeanDirect: 1, MeanTransitive: 1},
+			{Unknown: true, FirstPublished: 1, Versions: 1, Declarations: 1, MeanDirect: 1, MeanTransitive: 1},
+		}
+		if len(rows) != len(expected) {
+			t.Fatalf("Expected %d rows, got %+v", len(expected), rows)
+		}
+		for i := range expected {
+			if rows[i] != expected[i] {
+				t.Errorf("Expected %+v, got %+v", expected[i], rows[i])
+			}
+		}
+	})
+
+	t.Run("Only has the unknown year without timestamps", func(t *testing.T) {
+		rows, err := GrowthReport(testPackages("growth")[3:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 1 || !rows[0].Unknown || rows[0].Versions != 1 || rows[0].MeanTransitive != 0 {
+			t.Errorf("Expected a single unknown row, got %+v", rows)
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package graph
+
+// TopDependencyCounts ranks the package versions by the number of package versions they depend on, the heaviest
+// installs first, and returns the top n like TopNodes. Without transitive, the score is the number of direct
+// dependency edges, which with ResolveHighest is the number of resolved dependencies. With transitive, it is the
+// number of transitive dependencies counted by TransitiveDependencyCounts, which shares the work between all versions
+// instead of running a BFS per version.
+func TopDependencyCounts(pg *PackageGraph, n int, transitive bool) []RankedNode {
+	return pg.topDependencyCounts(pg.nodeIDs(), n, transitive)
+}
+
+// TopPackageDependencyCounts ranks the packages like TopDependencyCounts, scoring every package by its latest version
+// only. The latest version is the highest release, or the highest prerelease for packages without releases.
+func TopPackageDependencyCounts(pg *PackageGraph, n int, transitive bool) []RankedNode {
+	roots := make([]int64, 0, len(pg.NameToVersions))
+	for key := range pg.NameToVersions {
+		if id, ok := pg.latestVersion(key); ok {
+			roots = append(roots, id)
+		}
+	}
+	return pg.topDependencyCounts(roots, n, transitive)
+}
+
+func (pg *PackageGraph) topDependencyCounts(roots []int64, n int, transitive bool) []RankedNode {
+	scores := make(map[int64]float64, len(roots))
+	if transitive {
+		for id, count := range TransitiveDependencyCounts(pg.DependencyGraph(), roots) {
+			scores[id] = float64(count)
+		}
+	} else {
+		for _, id := range roots {
+			scores[id] = float64(pg.DependencyGraph().From(id).Len())
+		}
+	}
+	nodeMap := make(map[int64]NodeInfo, len(scores))
+	for id := range scores {
+		nodeMap[id], _ = pg.Node(id)
+	}
+	return TopNodes(scores, nodeMap, n)
+}
+
+// latestVersion returns the node ID of the highest release of the package, or of its highest version if it has no
+// releases.
+func (pg *PackageGraph) latestVersion(key PackageKey) (int64, bool) {
+	index := pg.latestIndex()
+	latest, ok := index.latest(key, nil, SkipPrereleases)
+	if !ok {
+		latest, _ = index.latest(key, nil, AllVersions)
+	}
+	info, ok := pg.lookup(key.version(latest))
+	return info.id, ok
+}
@@ -0,0 +1,47 @@
+package graph
+
+import (
+	"testing"
+)
+
+func TestTopDependencyCounts(t *testing.T) {
+	packagesInfo := testPackages("options")
+	packagesInfo = append(packagesInfo, PackageInfo{
+		Name: "Lib",
+		Versions: map[string]VersionInfo{
+			"1.0.0":      {Timestamp: "2022-05-01T00:00:00", Dependencies: map[string]string{"App": "1.0.0"}},
+			"0.9.0":      {Timestamp: "2022-04-01T00:00:00", Dependencies: map[string]string{"A": "1.0.0"}},
+			"2.0.0-rc.1": {Timestamp: "2022-06-01T00:00:00", Dependencies: map[string]string{}},
+		},
+	})
+	pg := NewPackageGraph(&packagesInfo, false, WithDependencyClasses(Runtime, Development))
+
+	t.Run("Ranks by direct dependencies", func(t *testing.T) {
+		top := TopDependencyCounts(pg, 2, false)
+		if len(top) != 2 || top[0].Name != "App" || top[0].Score != 4 || top[1].Score != 1 {
+			t.Errorf("Expected App with 4 dependencies followed by a version with 1, got %v", top)
+		}
+	})
+
+	t.Run("Ranks by transitive dependencies", func(t *testing.T) {
+		top := TopDependencyCounts(pg, 1, true)
+		if len(top) != 1 || top[0].Name != "Lib" || top[0].Version != "1.0.0" || top[0].Score != 5 {
+			t.Errorf("Expected Lib 1.0.0 with 5 transitive dependencies, got %v", top)
+		}
+	})
+
+	t.Run("Scores every package by its latest release", func(t *testing.T) {
+		top := TopPackageDependencyCounts(pg, -1, true)
+		if len(top) != 4 {
+			t.Fatalf("Expected one version of each of the 4 packages, got %v", top)
+		}
+		for _, node := range top {
+			if node.Name == "Lib" && node.Version != "1.0.0" {
+				t.Errorf("Expected the latest release 1.0.0 of Lib, got %s", node.Version)
+			}
+			if node.Name == "A" && node.Version != "1.2.0" {
+				t.Errorf("Expected the latest version 1.2.0 of A, got %s", node.Version)
+			}
+		}
+	})
+}
@@ -0,0 +1,181 @@
+package graph
+
+import (
+	"math"
+	"math/bits"
+)
+
+const (
+	// MinSketchPrecision and MaxSketchPrecision bound the precision of ApproxTransitiveDependentCounts.
+	MinSketchPrecision = 4
+	MaxSketchPrecision = 16
+)
+
+// ApproxTransitiveDependentCounts estimates the number of distinct transitive dependents of every node, the count of
+// ReachIndex.CountDependents, with HyperLogLog sketches instead of exact sets, for rankings that do not need exact
+// counts. The graph is condensed into its strongly connected components, and every component merges the sketches of
+// the components that depend on it directly, together with their members, so each edge of the condensation costs one
+// merge. The members of the own component are counted exactly.
+//
+// A sketch has 2^precision one-byte registers, and the precision is clamped to between MinSketchPrecision and
+// MaxSketchPrecision. The expected relative error of an estimate is about 1.04 / sqrt(2^precision): 1.6% with
+// precision 12, which takes 4 KiB per sketch, and 0.8% with precision 14. Counts of up to 2^precision / 8 are exact,
+// since a sketch holds the IDs themselves until they would take up more memory than the registers, and counts of up to
+// a few times 2^precision are estimated by linear counting, which is more accurate. A sketch is kept only until all
+// the components that depend on its component have merged it, rather than a sketch per component.
+func ApproxTransitiveDependentCounts(pg *PackageGraph, precision int) map[int64]float64 {
+	if precision < MinSketchPrecision {
+		precision = MinSketchPrecision
+	} else if precision > MaxSketchPrecision {
+		precision = MaxSketchPrecision
+	}
+	c := condense(pg.DependencyGraph())
+	// sketches holds, per component, the sketch of its members and of all its transitive dependents.
+	sketches := make([]*hyperLogLog, c.Len())
+	pending := make([]int, c.Len())
+	estimates := make([]float64, c.Len())
+	// The dependents of a component have lower indices, so their sketches are complete when it is processed.
+	for component, members := range c.members {
+		sketch := newHyperLogLog(precision)
+		for _, predecessor := range c.predecessors[component] {
+			sketch.merge(sketches[predecessor])
+			if pending[predecessor]--; pending[predecessor] == 0 {
+				sketches[predecessor] = nil
+			}
+		}
+		estimates[component] = sketch.estimate() + float64(len(members)-1)
+		if len(c.successors[component]) > 0 {
+			sketch.addIDs(members)
+			sketches[component] = sketch
+			pending[component] = len(c.successors[component])
+		}
+	}
+	return c.ProjectFloats(estimates)
+}
+
+// hyperLogLog is a HyperLogLog sketch of a set of node IDs, see Flajolet et al., "HyperLogLog: the analysis of a
+// near-optimal cardinality estimation algorithm". Every register holds the highest rank seen among the hashes that
+// fall into it. Like the sparse representation of HyperLogLog++, a small set is kept exactly as its sorted IDs, and
+// only switches to registers once the IDs would take up more memory than them.
+type hyperLogLog struct {
+	precision uint
+	// ids holds the sorted IDs of the set while registers is nil.
+	ids       []int64
+	registers []uint8
+}
+
+func newHyperLogLog(precision int) *hyperLogLog {
+	return &hyperLogLog{precision: uint(precision)}
+}
+
+// addIDs adds the sorted node IDs to the sketch.
+func (sketch *hyperLogLog) addIDs(ids []int64) {
+	if sketch.registers != nil {
+		for _, id := range ids {
+			sketch.add(id)
+		}
+		return
+	}
+	union := make([]int64, 0, len(sketch.ids)+len(ids))
+	i, j := 0, 0
+	for i < len(sketch.ids) || j < len(ids) {
+		switch {
+		case j == len(ids) || i < len(sketch.ids) && sketch.ids[i] < ids[j]:
+			union = append(union, sketch.ids[i])
+			i++
+		case i == len(sketch.ids) || ids[j] < sketch.ids[i]:
+			union = append(union, ids[j])
+			j++
+		default:
+			union = append(union, ids[j])
+			i++
+			j++
+		}
+	}
+	sketch.ids = union
+	// An ID takes up 8 bytes and a register 1.
+	if len(sketch.ids)*8 > 1<<sketch.precision {
+		sketch.registers = make([]uint8, 1<<sketch.precision)
+		for _, id := range sketch.ids {
+			sketch.add(id)
+		}
+		sketch.ids = nil
+	}
+}
+
+// add adds the node ID to the registers of the sketch.
+func (sketch *hyperLogLog) add(id int64) {
+	hash := mixID(uint64(id))
+	register := hash >> (64 - sketch.precision)
+	// The sentinel bit bounds the rank to 64 - precision + 1 when the remaining bits are all zero.
+	rank := uint8(bits.LeadingZeros64(hash<<sketch.precision|1<<(sketch.precision-1)) + 1)
+	if rank > sketch.registers[register] {
+		sketch.registers[register] = rank
+	}
+}
+
+// merge adds the IDs of the other sketch, which must have the same precision, to the sketch.
+func (sketch *hyperLogLog) merge(other *hyperLogLog) {
+	if other.registers == nil {
+		sketch.addIDs(other.ids)
+		return
+	}
+	if sketch.registers == nil {
+		ids := sketch.ids
+		sketch.registers = append([]uint8(nil), other.registers...)
+		sketch.ids = nil
+		for _, id := range ids {
+			sketch.add(id)
+		}
+		return
+	}
+	for i, rank := range other.registers {
+		if rank > sketch.registers[i] {
+			sketch.registers[i] = rank
+		}
+	}
+}
+
+// estimate returns the estimated number of distinct IDs added to the sketch, which is exact as long as the sketch
+// holds its IDs. The hashes have 64 bits, so the correction of the original algorithm for large counts is not needed.
+func (sketch *hyperLogLog) estimate() float64 {
+	if sketch.registers == nil {
+		return float64(len(sketch.ids))
+	}
+	m := float64(len(sketch.registers))
+	sum := 0.0
+	zeros := 0
+	for _, rank := range sketch.registers {
+		sum += math.Ldexp(1, -int(rank))
+		if rank == 0 {
+			zeros++
+		}
+	}
+	var alpha float64
+	switch len(sketch.registers) {
+	case 16:
+		alpha = 0.673
+	case 32:
+		alpha = 0.697
+	case 64:
+		alpha = 0.709
+	default:
+		alpha = 0.7213 / (1 + 1.079/m)
+	}
+	estimate := alpha * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		return m * math.Log(m/float64(zeros))
+	}
+	return estimate
+}
+
+// mixID spreads the bits of a node ID over the whole hash with the finalizer of SplitMix64, since the IDs themselves
+// can be sequential.
+func mixID(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
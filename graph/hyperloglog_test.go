@@ -0,0 +1,69 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestApproxTransitiveDependentCounts(t *testing.T) {
+	// Every package depends on a few random packages before it, so that the first packages have thousands of transitive
+	// dependents.
+	packagesInfo := generateTestPackages(rand.New(rand.NewSource(1)), 3000, []string{"1.0.0", "1.1.0"}, 3, func() string { return "^1.0.0" })
+	pg := NewPackageGraph(&packagesInfo, false)
+	index := pg.Reachability()
+
+	for _, precision := range []int{8, 11} {
+		t.Run(fmt.Sprintf("Stays within the expected error with precision %d", precision), func(t *testing.T) {
+			counts := ApproxTransitiveDependentCounts(pg, precision)
+			if len(counts) != len(pg.Nodes) {
+				t.Fatalf("Expected a count for all %d nodes, got %d", len(pg.Nodes), len(counts))
+			}
+			standardError := 1.04 / math.Sqrt(float64(int(1)<<uint(precision)))
+			squares, measured, large := 0.0, 0, 0
+			for _, node := range pg.Nodes {
+				exact := float64(index.CountDependents(node.ID()))
+				if exact == 0 {
+					if counts[node.ID()] != 0 {
+						t.Errorf("Expected no dependents of %s@%s, got %f", node.Name, node.Version, counts[node.ID()])
+					}
+					continue
+				}
+				relative := (counts[node.ID()] - exact) / exact
+				if math.Abs(relative) > 4*standardError {
+					t.Errorf("Expected %f dependents of %s@%s within %.1f%%, got %f", exact, node.Name, node.Version,
+						400*standardError, counts[node.ID()])
+				}
+				squares += relative * relative
+				measured++
+				if exact > 2.5*float64(int(1)<<uint(precision)) {
+					large++
+				}
+			}
+			if large == 0 {
+				t.Fatalf("Expected counts above the linear counting range")
+			}
+			if rms := math.Sqrt(squares / float64(measured)); rms > standardError {
+				t.Errorf("Expected a root mean square relative error below %f, got %f", standardError, rms)
+			}
+		})
+	}
+
+	t.Run("Counts the members of a cycle exactly", func(t *testing.T) {
+		packagesInfo := []PackageInfo{
+			{Name: "A", Versions: map[string]VersionInfo{"1.0.0": {Dependencies: map[string]string{"B": "1.0.0"}}}},
+			{Name: "B", Versions: map[string]VersionInfo{"1.0.0": {Dependencies: map[string]string{"A": "1.0.0"}}}},
+			{Name: "C", Versions: map[string]VersionInfo{"1.0.0": {Dependencies: map[string]string{"A": "1.0.0"}}}},
+			{Name: "D", Versions: map[string]VersionInfo{"1.0.0": {}}},
+		}
+		pg := NewPackageGraph(&packagesInfo, false)
+		counts := ApproxTransitiveDependentCounts(pg, 0)
+		expected := map[string]float64{"A": 2, "B": 2, "C": 0, "D": 0}
+		for name, count := range expected {
+			if node, _ := pg.FindNode(NameVersion{Name: name, Version: "1.0.0"}); counts[node.ID()] != count {
+				t.Errorf("Expected %f dependents of %s, got %f", count, name, counts[node.ID()])
+			}
+		}
+	})
+}
@@ -0,0 +1,231 @@
+package graph
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// IDScheme determines which IDs the nodes of a graph get.
+type IDScheme int
+
+const (
+	// SequentialIDs numbers the versions from 0 up, the packages in input order and the versions of a package in
+	// semver order. Nodes is then indexed by ID, but a package added to the input shifts the IDs of every package
+	// after it, so IDs cannot be compared between builds.
+	SequentialIDs IDScheme = iota
+	// HashedIDs gives every version the ID returned by HashedID, so that a version has the same ID in every build,
+	// after incremental changes and in the merges and subgraphs of the graph. Nodes then holds the versions in the
+	// order in which they were added; use Node to look up an ID. Two versions whose hashes collide cannot both be
+	// nodes, see IDCollision.
+	HashedIDs
+)
+
+func (scheme IDScheme) String() string {
+	if scheme == HashedIDs {
+		return "hashed"
+	}
+	return "sequential"
+}
+
+// WithIDScheme sets how the node IDs are assigned. The default is SequentialIDs.
+func WithIDScheme(scheme IDScheme) Option {
+	return func(options *Options) {
+		options.IDScheme = scheme
+	}
+}
+
+// HashedID returns the ID of the version with HashedIDs: the first 8 bytes of the SHA-256 hash of the name, a NUL byte
+// and the version, without the sign bit. The name of a package of an ecosystem is hashed as its QualifiedName.
+func HashedID(nameVersion NameVersion) int64 {
+	hash := sha256.New()
+	hash.Write([]byte(QualifiedName(nameVersion.Ecosystem, nameVersion.Name)))
+	hash.Write([]byte{0})
+	hash.Write([]byte(nameVersion.Version))
+	return int64(binary.BigEndian.Uint64(hash.Sum(nil)) &^ (1 << 63))
+}
+
+// hashID computes the hashed IDs. The tests replace it to make IDs collide.
+var hashID = HashedID
+
+// IDCollision describes two versions with the same hashed ID. The version that was added first keeps its node, the
+// other one is left out of the graph.
+type IDCollision struct {
+	ID      int64
+	Kept    NameVersion
+	Dropped NameVersion
+}
+
+// IDCollisionError is returned for the versions that could not be added to a graph with HashedIDs because their ID is
+// taken. The graph itself is valid, but misses the dropped versions.
+type IDCollisionError struct {
+	Collisions []IDCollision
+}
+
+func (err *IDCollisionError) Error() string {
+	descriptions := make([]string, len(err.Collisions))
+	for i, collision := range err.Collisions {
+		descriptions[i] = fmt.Sprintf("%s collides with %s on ID %d", collision.Dropped, collision.Kept, collision.ID)
+	}
+	return "hashed node IDs collide: " + strings.Join(descriptions, ", ")
+}
+
+// nodeAllocator adds the nodes of a new graph with the IDs of the scheme and collects the collisions.
+type nodeAllocator struct {
+	graph  *simple.DirectedGraph
+	scheme IDScheme
+	// owners holds the version of every hashed ID.
+	owners     map[int64]NameVersion
+	collisions []IDCollision
+}
+
+func newNodeAllocator(graph *simple.DirectedGraph, scheme IDScheme) *nodeAllocator {
+	allocator := &nodeAllocator{graph: graph, scheme: scheme}
+	if scheme == HashedIDs {
+		allocator.owners = make(map[int64]NameVersion)
+	}
+	return allocator
+}
+
+// add adds a node for the version and returns its ID, or false if its hashed ID is taken by another version.
+func (allocator *nodeAllocator) add(nameVersion NameVersion) (int64, bool) {
+	if allocator.scheme != HashedIDs {
+		node := allocator.graph.NewNode()
+		allocator.graph.AddNode(node)
+		return node.ID(), true
+	}
+	id := hashID(nameVersion)
+	if owner, taken := allocator.owners[id]; taken {
+		allocator.collisions = append(allocator.collisions, IDCollision{ID: id, Kept: owner, Dropped: nameVersion})
+		return 0, false
+	}
+	allocator.owners[id] = nameVersion
+	allocator.graph.AddNode(simple.Node(id))
+	return id, true
+}
+
+// err returns an *IDCollisionError for the collisions, or nil.
+func (allocator *nodeAllocator) err() error {
+	if len(allocator.collisions) == 0 {
+		return nil
+	}
+	return &IDCollisionError{Collisions: allocator.collisions}
+}
+
+// appendNodeInfo adds the node information of a new graph to nodes: at its ID with SequentialIDs, and at the end with
+// HashedIDs, whose IDs are far too large to index a slice.
+func appendNodeInfo(nodes []NodeInfo, info NodeInfo, scheme IDScheme) []NodeInfo {
+	if scheme == HashedIDs {
+		return append(nodes, info)
+	}
+	return setNodeInfo(nodes, info)
+}
+
+// withoutVersions returns the packages without the versions that were dropped because of the collisions. The input is
+// returned as is if there are none; otherwise the packages are copied, leaving the caller's packages unchanged.
+func withoutVersions(packagesList *[]PackageInfo, collisions []IDCollision) *[]PackageInfo {
+	if len(collisions) == 0 {
+		return packagesList
+	}
+	dropped := make(map[PackageKey][]string, len(collisions))
+	for _, collision := range collisions {
+		key := collision.Dropped.key()
+		dropped[key] = append(dropped[key], collision.Dropped.Version)
+	}
+	packages := make([]PackageInfo, len(*packagesList))
+	copy(packages, *packagesList)
+	for i, packageInfo := range packages {
+		droppedVersions, ok := dropped[packageInfo.key()]
+		if !ok {
+			continue
+		}
+		versions := make(map[string]VersionInfo, len(packageInfo.Versions))
+		for version, versionInfo := range packageInfo.Versions {
+			versions[version] = versionInfo
+		}
+		for _, version := range droppedVersions {
+			delete(versions, version)
+		}
+		packages[i].Versions = versions
+	}
+	return &packages
+}
+
+// IDCollisions returns the versions that were left out of the graph because their hashed ID collided with another
+// version while the graph was built. OpenPackageGraph returns them as an *IDCollisionError.
+func (pg *PackageGraph) IDCollisions() []IDCollision {
+	return pg.collisions
+}
+
+// slot returns the index in Nodes of the node with the given ID.
+func (pg *PackageGraph) slot(id int64) (int, bool) {
+	if pg.slots != nil {
+		slot, ok := pg.slots[id]
+		return slot, ok
+	}
+	if id < 0 || id >= int64(len(pg.Nodes)) {
+		return 0, false
+	}
+	return int(id), true
+}
+
+// indexSlots maps the IDs to their index in Nodes, which is needed as soon as a node is not stored at its ID.
+func (pg *PackageGraph) indexSlots() {
+	pg.slots = make(map[int64]int, len(pg.Nodes))
+	for i, node := range pg.Nodes {
+		if node.stringID != "" {
+			pg.slots[node.id] = i
+		}
+	}
+}
+
+// storedAtIDs reports whether every node is stored at the index of its ID, as with SequentialIDs.
+func storedAtIDs(nodes []NodeInfo) bool {
+	for i, node := range nodes {
+		if node.stringID != "" && node.id != int64(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkHashedID returns an *IDCollisionError if the version cannot be added to the graph because its hashed ID is
+// taken by another version.
+func (pg *PackageGraph) checkHashedID(nameVersion NameVersion) error {
+	if pg.options.IDScheme != HashedIDs {
+		return nil
+	}
+	id := hashID(nameVersion)
+	if owner, taken := pg.Node(id); taken {
+		return &IDCollisionError{Collisions: []IDCollision{{ID: id, Kept: owner.NameVersion(), Dropped: nameVersion}}}
+	}
+	return nil
+}
+
+// addNode adds a node for a version that is added to the graph and stores its node information with the ID of the
+// node. A hashed ID must have been checked with checkHashedID.
+func (pg *PackageGraph) addNode(info NodeInfo) NodeInfo {
+	if pg.options.IDScheme != HashedIDs {
+		node := pg.Graph.NewNode()
+		pg.Graph.AddNode(node)
+		info.id = node.ID()
+		if pg.slots != nil {
+			pg.slots[info.id] = len(pg.Nodes)
+			pg.Nodes = append(pg.Nodes, info)
+		} else {
+			pg.Nodes = setNodeInfo(pg.Nodes, info)
+		}
+		return info
+	}
+	info.id = hashID(info.NameVersion())
+	pg.Graph.AddNode(simple.Node(info.id))
+	if pg.slots == nil {
+		pg.indexSlots()
+	}
+	pg.slots[info.id] = len(pg.Nodes)
+	pg.Nodes = append(pg.Nodes, info)
+	return info
+}
@@ -0,0 +1,210 @@
+package graph
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// nodeIDsByKey returns the IDs of the versions of the graph by their stringID.
+func nodeIDsByKey(pg *PackageGraph) map[string]int64 {
+	ids := make(map[string]int64)
+	for _, node := range pg.Nodes {
+		if node.stringID != "" {
+			ids[node.stringID] = node.id
+		}
+	}
+	return ids
+}
+
+func TestHashedIDs(t *testing.T) {
+	hashed := WithIDScheme(HashedIDs)
+
+	t.Run("Gives every version its hashed ID", func(t *testing.T) {
+		packagesInfo := testPackages("render")
+		pg := NewPackageGraph(&packagesInfo, false, hashed)
+		for _, node := range pg.Nodes {
+			if expected := HashedID(NameVersion{Name: node.Name, Version: node.Version}); node.id != expected {
+				t.Errorf("Expected ID %d for %s, got %d", expected, node.stringID, node.id)
+			}
+			if found, ok := pg.Node(node.id); !ok || found != node {
+				t.Errorf("Expected to find %s by its ID, got %v", node.stringID, found)
+			}
+		}
+		if errs := Validate(pg); len(errs) > 0 {
+			t.Errorf("Expected a consistent graph, got %v", errs)
+		}
+	})
+
+	t.Run("Keeps the IDs when packages are added to the input", func(t *testing.T) {
+		packagesInfo := testPackages("render")
+		before := nodeIDsByKey(NewPackageGraph(&packagesInfo, false, hashed))
+		packagesInfo = append([]PackageInfo{{Name: "First", Versions: map[string]VersionInfo{"1.0.0": {Timestamp: "2022-01-01"}}}}, testPackages("render")...)
+		after := nodeIDsByKey(NewPackageGraph(&packagesInfo, false, hashed))
+		delete(after, "First-1.0.0")
+		if !reflect.DeepEqual(before, after) {
+			t.Errorf("Expected the same IDs, got %v and %v", before, after)
+		}
+	})
+
+	t.Run("Creates the same edges as sequential IDs", func(t *testing.T) {
+		packagesInfo := testPackages("render")
+		sequential := NewPackageGraph(&packagesInfo, false)
+		packagesInfo = testPackages("render")
+		pg := NewPackageGraph(&packagesInfo, false, hashed)
+		if expected, edges := edgeSet(sequential), edgeSet(pg); !reflect.DeepEqual(edges, expected) {
+			t.Errorf("Expected %v, got %v", expected, edges)
+		}
+		dependencies, _ := pg.Dependencies(NameVersion{Name: "Cli", Version: "1.0.0"}, -1)
+		expected, _ := sequential.Dependencies(NameVersion{Name: "Cli", Version: "1.0.0"}, -1)
+		if len(dependencies) != len(expected) {
+			t.Errorf("Expected %d dependencies, got %d", len(expected), len(dependencies))
+		}
+	})
+
+	t.Run("Keeps the IDs in subgraphs and merges", func(t *testing.T) {
+		packagesInfo := testPackages("render")
+		pg := NewPackageGraph(&packagesInfo, false, hashed)
+		subgraph, _ := pg.Subgraph(NameVersion{Name: "App", Version: "1.0.0"}, -1)
+		ids := nodeIDsByKey(pg)
+		for key, id := range nodeIDsByKey(subgraph) {
+			if ids[key] != id {
+				t.Errorf("Expected ID %d for %s in the subgraph, got %d", ids[key], key, id)
+			}
+		}
+		other := []PackageInfo{{Name: "Extra", Versions: map[string]VersionInfo{"1.0.0": {Timestamp: "2022-01-01", Dependencies: map[string]string{"App": "1.0.0"}}}}}
+		merged, err := Merge(pg, NewPackageGraph(&other, false, hashed), PreferA)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids["Extra-1.0.0"] = HashedID(NameVersion{Name: "Extra", Version: "1.0.0"})
+		if mergedIDs := nodeIDsByKey(merged); !reflect.DeepEqual(mergedIDs, ids) {
+			t.Errorf("Expected %v, got %v", ids, mergedIDs)
+		}
+	})
+
+	t.Run("Keeps the IDs in the cache", func(t *testing.T) {
+		packagesInfo := testPackages("render")
+		pg := NewPackageGraph(&packagesInfo, false, hashed)
+		var cache bytes.Buffer
+		if err := SaveGraph(&cache, pg); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadGraph(&cache)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected, ids := nodeIDsByKey(pg), nodeIDsByKey(loaded); !reflect.DeepEqual(ids, expected) {
+			t.Errorf("Expected %v, got %v", expected, ids)
+		}
+		if expected, edges := edgeSet(pg), edgeSet(loaded); !reflect.DeepEqual(edges, expected) {
+			t.Errorf("Expected %v, got %v", expected, edges)
+		}
+		if err := loaded.AddVersion("Log", "1.2.0", VersionInfo{Timestamp: "2021-06-01", Dependencies: map[string]string{}}); err != nil {
+			t.Fatal(err)
+		}
+		if node, _ := loaded.FindNode(NameVersion{Name: "Log", Version: "1.2.0"}); node.id != HashedID(NameVersion{Name: "Log", Version: "1.2.0"}) {
+			t.Errorf("Expected an added version of a loaded graph to get its hashed ID, got %d", node.id)
+		}
+	})
+
+	t.Run("Does not load a cache with other IDs as requested", func(t *testing.T) {
+		packagesInfo := testPackages("render")
+		var cache bytes.Buffer
+		if err := SaveGraph(&cache, NewPackageGraph(&packagesInfo, false, hashed)); err != nil {
+			t.Fatal(err)
+		}
+		var mismatch *OptionMismatchError
+		if _, err := LoadGraphWithOptions(&cache, false); !errors.As(err, &mismatch) || mismatch.Option != "ids" {
+			t.Errorf("Expected a mismatch of the ids, got %v", err)
+		}
+	})
+
+	t.Run("Updates the graph incrementally like a rebuild", func(t *testing.T) {
+		packagesInfo := testPackages("render")
+		rebuilt := NewPackageGraph(&packagesInfo, false, hashed)
+		packagesInfo = testPackages("render")
+		added := packagesInfo[2].Versions["2.1.0"]
+		delete(packagesInfo[2].Versions, "2.1.0")
+		pg := NewPackageGraph(&packagesInfo, false, hashed)
+		if err := pg.AddVersion("Web", "2.1.0", added); err != nil {
+			t.Fatal(err)
+		}
+		if expected, ids := nodeIDsByKey(rebuilt), nodeIDsByKey(pg); !reflect.DeepEqual(ids, expected) {
+			t.Errorf("Expected %v, got %v", expected, ids)
+		}
+		if expected, edges := edgeSet(rebuilt), edgeSet(pg); !reflect.DeepEqual(edges, expected) {
+			t.Errorf("Expected %v, got %v", expected, edges)
+		}
+		if err := pg.RemoveVersion("Web", "2.1.0", false); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := pg.Node(HashedID(NameVersion{Name: "Web", Version: "2.1.0"})); ok {
+			t.Error("Expected the removed version not to be found by its ID")
+		}
+		if errs := Validate(pg); len(errs) > 0 {
+			t.Errorf("Expected a consistent graph, got %v", errs)
+		}
+	})
+}
+
+func TestIDCollisions(t *testing.T) {
+	// Every version of Yaml gets the ID of Test 1.0.0.
+	defer func(original func(NameVersion) int64) { hashID = original }(hashID)
+	hashID = func(nameVersion NameVersion) int64 {
+		if nameVersion.Name == "Yaml" {
+			return HashedID(NameVersion{Name: "Test", Version: "1.0.0"})
+		}
+		return HashedID(nameVersion)
+	}
+	hashed := WithIDScheme(HashedIDs)
+
+	t.Run("Reports the colliding versions and leaves out the later ones", func(t *testing.T) {
+		// Test is moved before Yaml, so that it keeps its node.
+		packagesInfo := testPackages("render")
+		packagesInfo[10], packagesInfo[12] = packagesInfo[12], packagesInfo[10]
+		pg := NewPackageGraph(&packagesInfo, false, hashed)
+		id := HashedID(NameVersion{Name: "Test", Version: "1.0.0"})
+		expected := []IDCollision{
+			{ID: id, Kept: NameVersion{Name: "Test", Version: "1.0.0"}, Dropped: NameVersion{Name: "Yaml", Version: "1.0.0"}},
+			{ID: id, Kept: NameVersion{Name: "Test", Version: "1.0.0"}, Dropped: NameVersion{Name: "Yaml", Version: "2.0.0"}},
+		}
+		if collisions := pg.IDCollisions(); !reflect.DeepEqual(collisions, expected) {
+			t.Errorf("Expected %v, got %v", expected, collisions)
+		}
+		if _, ok := pg.FindNode(NameVersion{Name: "Yaml", Version: "1.0.0"}); ok {
+			t.Error("Expected Yaml 1.0.0 to be left out")
+		}
+		if errs := Validate(pg); len(errs) > 0 {
+			t.Errorf("Expected a consistent graph, got %v", errs)
+		}
+	})
+
+	t.Run("Fails to open a graph with collisions", func(t *testing.T) {
+		input := filepath.Join(t.TempDir(), "packages.json")
+		writeTestInput(t, input)
+		hashID = func(NameVersion) int64 { return 1 }
+		var collision *IDCollisionError
+		if _, err := OpenPackageGraph(input, false, hashed); !errors.As(err, &collision) || len(collision.Collisions) == 0 {
+			t.Errorf("Expected an *IDCollisionError, got %v", err)
+		}
+	})
+
+	t.Run("Rejects added versions whose ID is taken", func(t *testing.T) {
+		hashID = func(nameVersion NameVersion) int64 {
+			return HashedID(NameVersion{Name: "Test", Version: nameVersion.Version})
+		}
+		packagesInfo := testPackages("render")[12:]
+		pg := NewPackageGraph(&packagesInfo, false, hashed)
+		var collision *IDCollisionError
+		err := pg.AddVersion("Yaml", "1.0.0", VersionInfo{Timestamp: "2022-01-01"})
+		if !errors.As(err, &collision) || collision.Collisions[0].Kept != (NameVersion{Name: "Test", Version: "1.0.0"}) {
+			t.Errorf("Expected a collision with Test 1.0.0, got %v", err)
+		}
+		if len(*pg.Packages) != 1 || pg.Graph.Nodes().Len() != 1 {
+			t.Errorf("Expected the graph to be unchanged, got %d packages and %d nodes", len(*pg.Packages), pg.Graph.Nodes().Len())
+		}
+	})
+}
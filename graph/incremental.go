@@ -0,0 +1,399 @@
+package graph
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// resolver returns an edgeResolver with the settings the graph was built with, indexing the versions of the graph
+// and creating its range matcher first if needed. All the resolvers of a graph share the matcher, so every distinct
+// range is parsed only once.
+func (pg *PackageGraph) resolver() *edgeResolver {
+	if pg.versions == nil {
+		pg.versions = newVersionIndex(pg.lookup, pg.NameToVersions, pg.options.TruncateFourPartVersions, pg.options.Report)
+		pg.names = newNameFilter(pg.versions)
+	}
+	if pg.matcher == nil {
+		pg.matcher = pg.options.rangeMatcher(pg.isMaven)
+	}
+	return &edgeResolver{
+		find:     pg.lookup,
+		versions: pg.versions,
+		names:    pg.names,
+		matcher:  pg.matcher,
+		options:  pg.options,
+	}
+}
+
+// queryResolver returns an edgeResolver like resolver, but indexes the versions of a graph without an index for
+// every call instead of storing the index, so that the queries using it only read the graph.
+func (pg *PackageGraph) queryResolver() *edgeResolver {
+	versions, names := pg.versions, pg.names
+	if versions == nil {
+		versions = newVersionIndex(pg.lookup, pg.NameToVersions, pg.options.TruncateFourPartVersions, nil)
+		names = nil
+	}
+	matcher := pg.matcher
+	if matcher == nil {
+		matcher = pg.options.rangeMatcher(pg.isMaven)
+	}
+	return &edgeResolver{
+		find:     pg.lookup,
+		versions: versions,
+		names:    names,
+		matcher:  matcher,
+		options:  pg.options,
+	}
+}
+
+// declaredDependencyNames returns the distinct names the version depends on in the classes that create edges, after
+// following the aliases.
+func (pg *PackageGraph) declaredDependencyNames(versionInfo VersionInfo) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, class := range pg.options.DependencyClasses {
+		for name := range versionInfo.DependenciesOf(class) {
+			name, _ = pg.options.Aliases.resolve(name, versionInfo.Timestamp)
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// addVersion adds a version to the graph and creates both its outgoing edges and the incoming edges from the existing
+// versions whose ranges it satisfies, exactly as edge creation over the union would have. The ecosystem and the
+// provenance are those of a new package; a version of an existing package gets those of the package.
+func (pg *PackageGraph) addVersion(ecosystem string, provenance *Provenance, name, version string, versionInfo VersionInfo) (NodeInfo, error) {
+	key := NewPackageKey(ecosystem, name)
+	nameVersion := key.version(version)
+	if _, exists := pg.lookup(nameVersion); exists {
+		return NodeInfo{}, fmt.Errorf("%s is already part of the graph", nameVersion)
+	}
+	if err := pg.checkHashedID(nameVersion); err != nil {
+		return NodeInfo{}, err
+	}
+	pg.ensureConstraintIndex()
+	resolver := pg.resolver()
+
+	pg.reach = nil
+	index, ok := pg.packageIndex[key]
+	if !ok {
+		*pg.Packages = append(*pg.Packages, PackageInfo{Name: name, Ecosystem: ecosystem, Versions: make(map[string]VersionInfo), Provenance: provenance})
+		index = len(*pg.Packages) - 1
+		pg.packageIndex[key] = index
+		if ecosystem != "" {
+			pg.ecosystems[ecosystem] = true
+		}
+	}
+	info := *newNodeInfoFromVersion(0, key, version, versionInfo)
+	info.Provenance = (*pg.Packages)[index].Provenance
+	info = pg.addNode(info)
+	pg.ids[nameVersion] = info.id
+	if stored, taken := pg.StringIDToNodeInfo[info.stringID]; !taken || info.id < stored.id {
+		pg.StringIDToNodeInfo[info.stringID] = info
+	}
+	pg.NameToVersions[key] = insertVersion(pg.NameToVersions[key], version)
+	(*pg.Packages)[index].Versions[version] = versionInfo
+	if !pg.versions.add(info, pg.options.TruncateFourPartVersions) {
+		pg.options.Report.recordUnparseableVersion()
+	}
+	pg.names.add(key)
+	if pg.latest != nil {
+		pg.latest.add(info, pg.options.TruncateFourPartVersions)
+	}
+
+	pg.setWeightedEdges(resolver.resolveVersion(nil, info, versionInfo, pg.options.Report))
+	pg.constraints.add(info.id, ecosystem, versionInfo)
+
+	for _, dependentID := range pg.declaringDependents(key) {
+		if pg.options.Resolution == ResolveAll {
+			pg.linkIfSatisfied(resolver, dependentID, info)
+		} else {
+			pg.reresolve(resolver, dependentID, key)
+		}
+	}
+	return info, nil
+}
+
+// linkIfSatisfied creates an edge from the dependent to the version if any of the dependent's ranges on the version's
+// package is satisfied by it, weighted by the number of satisfied ranges.
+func (pg *PackageGraph) linkIfSatisfied(resolver *edgeResolver, dependentID int64, info NodeInfo) {
+	dependent, ok := pg.Node(dependentID)
+	if !ok {
+		return
+	}
+	dependentInfo, _ := pg.VersionInfo(dependent.NameVersion())
+	satisfied := 0
+	for _, dependencyRange := range pg.declaredRanges(dependentInfo, info.Name) {
+		if resolver.resolveRangeAgainst(dependencyRange, info) {
+			satisfied++
+		}
+	}
+	if satisfied > 0 {
+		pg.setEdge(dependentID, info.id)
+		pg.setWeight(dependentID, info.id, satisfied)
+	}
+}
+
+// reresolve recomputes the edges from the dependent to the versions of the package, for example after a higher
+// version has been added or the resolved version has been removed.
+func (pg *PackageGraph) reresolve(resolver *edgeResolver, dependentID int64, key PackageKey) {
+	dependent, ok := pg.Node(dependentID)
+	if !ok {
+		return
+	}
+	dependentInfo, _ := pg.VersionInfo(dependent.NameVersion())
+	// wanted counts the ranges that resolve to every version, which is the weight of its edge.
+	wanted := make(map[int64]int)
+	for _, dependencyRange := range pg.declaredRanges(dependentInfo, key.FullName()) {
+		dependencyIDs, _ := resolver.resolveRange(key, dependencyRange)
+		for _, dependencyID := range dependencyIDs {
+			wanted[dependencyID]++
+		}
+	}
+	for _, dependencyID := range sortedNodeIDs(pg.DependencyGraph().From(dependentID)) {
+		if info, _ := pg.Node(dependencyID); info.key() == key && wanted[dependencyID] == 0 {
+			pg.removeEdge(dependentID, dependencyID)
+			pg.setWeight(dependentID, dependencyID, 1)
+		}
+	}
+	for dependencyID, weight := range wanted {
+		if dependencyID != dependentID {
+			pg.setEdge(dependentID, dependencyID)
+			pg.setWeight(dependentID, dependencyID, weight)
+		}
+	}
+}
+
+// declaredRanges returns the ranges with which the version depends on the named package, in the classes that create
+// edges, including the ranges declared under the old names that are aliased to it.
+func (pg *PackageGraph) declaredRanges(versionInfo VersionInfo, name string) []string {
+	var ranges []string
+	for _, class := range pg.options.DependencyClasses {
+		if pg.options.Aliases == nil {
+			if dependencyRange, ok := versionInfo.DependenciesOf(class)[name]; ok {
+				ranges = append(ranges, dependencyRange)
+			}
+			continue
+		}
+		for dependencyName, dependencyRange := range versionInfo.DependenciesOf(class) {
+			if target, _ := pg.options.Aliases.resolve(dependencyName, versionInfo.Timestamp); target == name {
+				ranges = append(ranges, dependencyRange)
+			}
+		}
+	}
+	return ranges
+}
+
+// setEdge creates the edge from the dependent to the dependency, in the direction of the options, unless it would be a
+// self-edge.
+func (pg *PackageGraph) setEdge(from, to int64) {
+	if from != to {
+		from, to = pg.storedEdge(from, to)
+		pg.Graph.SetEdge(simple.Edge{F: pg.Graph.Node(from), T: pg.Graph.Node(to)})
+	}
+}
+
+// setWeightedEdges creates the edges returned by resolveVersion or resolvePackage and sets the weight of every edge to
+// the number of times it is repeated among them.
+func (pg *PackageGraph) setWeightedEdges(edges [][2]int64) {
+	counts := make(map[[2]int64]int, len(edges))
+	for _, edge := range edges {
+		counts[edge]++
+		pg.setEdge(edge[0], edge[1])
+	}
+	for edge, count := range counts {
+		pg.setWeight(edge[0], edge[1], count)
+	}
+}
+
+// setWeight stores the weight of the edge. Only the weights above 1 are stored, so a weight of 1 removes the edge from
+// the weights.
+func (pg *PackageGraph) setWeight(from, to int64, weight int) {
+	if weight <= 1 {
+		delete(pg.weights, [2]int64{from, to})
+		return
+	}
+	if pg.weights == nil {
+		pg.weights = make(map[[2]int64]int32)
+	}
+	pg.weights[[2]int64{from, to}] = int32(weight)
+}
+
+// AddVersion adds a newly published version to the graph, without rebuilding it. The version gets the next free node
+// ID, or its hashed ID with HashedIDs, and its outgoing edges are created for its dependencies. Existing versions whose
+// ranges it satisfies get an incoming edge, or, if the graph was built with ResolveHighest, have their edges to the
+// package re-resolved. The result has the same edges as a graph built from scratch with the version included. The name
+// filter and cutoff of the options are not applied to added versions. An *IDCollisionError is returned if the hashed ID
+// of the version is taken, and ErrSplitGraph for the graphs split by dependency class. A qualified name, see
+// QualifiedName, adds a version to the package of that ecosystem, whose dependencies resolve within the ecosystem like
+// those of its other versions.
+//
+// The version is also added to Packages, which shares its version maps with the packages the graph was built from.
+// The first change to a graph indexes the dependencies of all its versions, which takes about as long as the range
+// matching of a single package for every package.
+func (pg *PackageGraph) AddVersion(name, version string, info VersionInfo) error {
+	if pg.split {
+		return ErrSplitGraph
+	}
+	ecosystem, name := splitQualifiedName(pg.ecosystems, name)
+	packageInfo := pg.normalizePackage(PackageInfo{Name: name, Ecosystem: ecosystem, Versions: map[string]VersionInfo{version: info}})
+	_, err := pg.addVersion(packageInfo.Ecosystem, nil, packageInfo.Name, version, packageInfo.Versions[version])
+	return err
+}
+
+// AddPackage adds all the versions of a package with AddVersion, from the lowest to the highest version. Nothing is
+// added if any of the versions is already part of the graph or its hashed ID is taken.
+func (pg *PackageGraph) AddPackage(packageInfo PackageInfo) error {
+	if pg.split {
+		return ErrSplitGraph
+	}
+	packageInfo = pg.normalizePackage(packageInfo)
+	versions := sortedVersionKeys(packageInfo.Versions)
+	for _, version := range versions {
+		nameVersion := packageInfo.key().version(version)
+		if _, exists := pg.lookup(nameVersion); exists {
+			return fmt.Errorf("%s is already part of the graph", nameVersion)
+		}
+		if err := pg.checkHashedID(nameVersion); err != nil {
+			return err
+		}
+	}
+	for _, version := range versions {
+		if _, err := pg.addVersion(packageInfo.Ecosystem, packageInfo.Provenance, packageInfo.Name, version, packageInfo.Versions[version]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizePackage normalizes the names of a package that is added like the names the graph was built from.
+func (pg *PackageGraph) normalizePackage(packageInfo PackageInfo) PackageInfo {
+	return (*normalizePackageNames(&[]PackageInfo{packageInfo}, pg.options))[0]
+}
+
+// RemoveVersion removes a version and its edges from the graph, for example to simulate an unpublished version. If
+// reresolve is set, the edges of the versions that depended on it are re-resolved afterwards, so that with
+// ResolveHighest they point to the next-best satisfying version instead; a dependent without a remaining satisfying
+// version is left without an edge to the package. With ResolveAll the other satisfying versions already have edges,
+// so re-resolution does not change anything.
+//
+// Like AddVersion, this changes the version maps shared with the packages the graph was built from.
+func (pg *PackageGraph) RemoveVersion(name, version string, reresolve bool) error {
+	if pg.split {
+		return ErrSplitGraph
+	}
+	key := pg.packageKey("", name)
+	info, ok := pg.lookup(key.version(version))
+	if !ok {
+		return fmt.Errorf("%s is not part of the graph", key.version(version))
+	}
+	pg.ensureConstraintIndex()
+	dependents := sortedNodeIDs(pg.DependencyGraph().To(info.id))
+	pg.removeVersion(info)
+	if reresolve {
+		resolver := pg.resolver()
+		for _, dependentID := range dependents {
+			pg.reresolve(resolver, dependentID, key)
+		}
+	}
+	return nil
+}
+
+// RemovePackage removes all the versions of a package and their edges, and then the package itself. As no version of
+// the package remains, its dependents simply lose their edges to it; reresolve is accepted so that RemovePackage and
+// RemoveVersion can be used interchangeably, but has no further effect.
+func (pg *PackageGraph) RemovePackage(name string, reresolve bool) error {
+	if pg.split {
+		return ErrSplitGraph
+	}
+	key := pg.packageKey("", name)
+	ids := pg.versionIDs(key)
+	if len(ids) == 0 {
+		return fmt.Errorf("package %s is not part of the graph", key)
+	}
+	pg.ensureConstraintIndex()
+	for _, id := range ids {
+		info, _ := pg.Node(id)
+		pg.removeVersion(info)
+	}
+
+	index := pg.packageIndex[key]
+	*pg.Packages = append((*pg.Packages)[:index], (*pg.Packages)[index+1:]...)
+	delete(pg.packageIndex, key)
+	for i := index; i < len(*pg.Packages); i++ {
+		pg.packageIndex[(*pg.Packages)[i].key()] = i
+	}
+	return nil
+}
+
+// replaceStringID stores the remaining version with the lowest ID under the stringID key, or removes the key if no
+// version has it. The versions sharing a key differ in the hyphen at which the key splits into name and version.
+func (pg *PackageGraph) replaceStringID(key string) {
+	delete(pg.StringIDToNodeInfo, key)
+	for i := 0; i < len(key); i++ {
+		if key[i] != '-' {
+			continue
+		}
+		ecosystem, name := splitQualifiedName(pg.ecosystems, key[:i])
+		if id, ok := pg.ids[NewPackageKey(ecosystem, name).version(key[i+1:])]; ok {
+			if stored, taken := pg.StringIDToNodeInfo[key]; !taken || id < stored.id {
+				pg.StringIDToNodeInfo[key] = pg.node(id)
+			}
+		}
+	}
+}
+
+// removeVersion removes the node of the version and updates all the indexes.
+func (pg *PackageGraph) removeVersion(info NodeInfo) {
+	nameVersion := info.NameVersion()
+	versionInfo, _ := pg.VersionInfo(nameVersion)
+	pg.constraints.remove(info.id, info.Ecosystem, versionInfo)
+
+	pg.reach = nil
+	if len(pg.weights) > 0 {
+		dependencyGraph := pg.DependencyGraph()
+		for _, dependencyID := range sortedNodeIDs(dependencyGraph.From(info.id)) {
+			delete(pg.weights, [2]int64{info.id, dependencyID})
+		}
+		for _, dependentID := range sortedNodeIDs(dependencyGraph.To(info.id)) {
+			delete(pg.weights, [2]int64{dependentID, info.id})
+		}
+	}
+	pg.Graph.RemoveNode(info.id)
+	slot, _ := pg.slot(info.id)
+	pg.Nodes[slot] = NodeInfo{}
+	if pg.slots != nil {
+		delete(pg.slots, info.id)
+	}
+	delete(pg.unresolved, info.id)
+	delete(pg.ids, nameVersion)
+	if pg.StringIDToNodeInfo[info.stringID].id == info.id {
+		pg.replaceStringID(info.stringID)
+	}
+	versions := pg.NameToVersions[info.key()]
+	for i, version := range versions {
+		if version == info.Version {
+			versions = append(versions[:i], versions[i+1:]...)
+			break
+		}
+	}
+	if len(versions) == 0 {
+		delete(pg.NameToVersions, info.key())
+	} else {
+		pg.NameToVersions[info.key()] = versions
+	}
+	if pg.versions != nil {
+		pg.versions.remove(info.key(), info.id, len(versions) == 0)
+	}
+	if pg.latest != nil {
+		pg.latest.remove(info.key(), info.Version)
+	}
+	if index, ok := pg.packageIndex[info.key()]; ok {
+		delete((*pg.Packages)[index].Versions, info.Version)
+	}
+}
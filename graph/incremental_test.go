@@ -0,0 +1,145 @@
+package graph
+
+import (
+	"sort"
+	"testing"
+)
+
+// union merges the delta into a copy of the base.
+func union(base, delta []PackageInfo) []PackageInfo {
+	result := make([]PackageInfo, 0, len(base)+len(delta))
+	indices := make(map[string]int)
+	for _, packageInfo := range append(append([]PackageInfo{}, base...), delta...) {
+		index, ok := indices[packageInfo.Name]
+		if !ok {
+			indices[packageInfo.Name] = len(result)
+			result = append(result, PackageInfo{Name: packageInfo.Name, Versions: make(map[string]VersionInfo)})
+			index = len(result) - 1
+		}
+		for version, versionInfo := range packageInfo.Versions {
+			result[index].Versions[version] = versionInfo
+		}
+	}
+	return result
+}
+
+func TestAddVersion(t *testing.T) {
+	for name, resolution := range map[string]Resolution{"all": ResolveAll, "highest": ResolveHighest} {
+		t.Run("Base plus delta equals a build of the union resolving "+name, func(t *testing.T) {
+			base, delta := testPackages("options"), testPackages("delta")
+			all := union(base, delta)
+			expected := edgeSet(NewPackageGraph(&all, false, WithResolution(resolution), WithDependencyClasses(Runtime, Development)))
+
+			pg := NewPackageGraph(&base, false, WithResolution(resolution), WithDependencyClasses(Runtime, Development))
+			for _, packageInfo := range delta {
+				for version, versionInfo := range packageInfo.Versions {
+					if err := pg.AddVersion(packageInfo.Name, version, versionInfo); err != nil {
+						t.Fatalf("Expected no error, got %v", err)
+					}
+				}
+			}
+			actual := edgeSet(pg)
+			if len(actual) != len(expected) {
+				t.Errorf("Expected %d edges, got %d", len(expected), len(actual))
+			}
+			for edge := range expected {
+				if !actual[edge] {
+					t.Errorf("Expected edge %v", edge)
+				}
+			}
+			if versions := pg.Versions("A"); len(versions) != 4 || versions[3].Version != "1.3.0" {
+				t.Errorf("Expected 4 versions of A, got %v", versions)
+			}
+			if versions := pg.NameToVersions[NewPackageKey("", "A")]; !sort.SliceIsSorted(versions, func(i, j int) bool { return versionLess(versions[i], versions[j]) }) {
+				t.Errorf("Expected the versions of A in semver order, got %v", versions)
+			}
+			if _, ok := pg.VersionInfo(NameVersion{Name: "New", Version: "0.2.0"}); !ok {
+				t.Error("Expected the version information of New 0.2.0")
+			}
+		})
+	}
+
+	t.Run("Rejects versions that already exist", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		pg := NewPackageGraph(&packagesInfo, false)
+		if err := pg.AddVersion("A", "1.0.0", VersionInfo{}); err == nil {
+			t.Error("Expected an error for an existing version")
+		}
+		nodes := pg.Graph.Nodes().Len()
+		err := pg.AddPackage(PackageInfo{Name: "A", Versions: map[string]VersionInfo{"2.0.0": {}, "1.2.0": {}}})
+		if err == nil || pg.Graph.Nodes().Len() != nodes {
+			t.Errorf("Expected an error and no new nodes, got %v and %d nodes", err, pg.Graph.Nodes().Len())
+		}
+	})
+}
+
+func TestRemoveVersion(t *testing.T) {
+	build := func() *PackageGraph {
+		packagesInfo := testPackages("options")
+		return NewPackageGraph(&packagesInfo, false, WithResolution(ResolveHighest), WithDependencyClasses(Runtime, Development))
+	}
+
+	t.Run("Falls back to an older version", func(t *testing.T) {
+		pg := build()
+		if err := pg.RemoveVersion("A", "1.2.0", true); err != nil {
+			t.Fatal(err)
+		}
+		edges := edgeSet(pg)
+		if !edges[[2]string{"App-1.0.0", "A-1.1.0"}] || len(edges) != 2 {
+			t.Errorf("Expected App 1.0.0 to depend on A 1.1.0 and Test 1.0.0, got %v", edges)
+		}
+		if _, ok := pg.FindNode(NameVersion{Name: "A", Version: "1.2.0"}); ok || len(pg.Versions("A")) != 2 {
+			t.Error("Expected A 1.2.0 to be removed from the indexes")
+		}
+	})
+
+	t.Run("Leaves the dependent without an edge if no satisfying version remains", func(t *testing.T) {
+		pg := build()
+		if err := pg.RemoveVersion("Test", "1.0.0", true); err != nil {
+			t.Fatal(err)
+		}
+		edges := edgeSet(pg)
+		if len(edges) != 1 || !edges[[2]string{"App-1.0.0", "A-1.2.0"}] {
+			t.Errorf("Expected only the edge to A 1.2.0, got %v", edges)
+		}
+	})
+
+	t.Run("Does not re-resolve unless asked to", func(t *testing.T) {
+		pg := build()
+		if err := pg.RemoveVersion("A", "1.2.0", false); err != nil {
+			t.Fatal(err)
+		}
+		if edges := edgeSet(pg); len(edges) != 1 {
+			t.Errorf("Expected only the edge to Test 1.0.0, got %v", edges)
+		}
+	})
+
+	t.Run("Removes every version of a package", func(t *testing.T) {
+		pg := build()
+		if err := pg.RemovePackage("A", true); err != nil {
+			t.Fatal(err)
+		}
+		if pg.Graph.Nodes().Len() != 2 || len(edgeSet(pg)) != 1 {
+			t.Errorf("Expected 2 nodes and 1 edge, got %d and %d", pg.Graph.Nodes().Len(), len(edgeSet(pg)))
+		}
+		if _, ok := pg.VersionInfo(NameVersion{Name: "Test", Version: "1.0.0"}); !ok {
+			t.Error("Expected the package index to be updated")
+		}
+		if err := pg.RemovePackage("A", true); err == nil {
+			t.Error("Expected an error for a removed package")
+		}
+	})
+
+	t.Run("Keeps the graph consistent with later additions", func(t *testing.T) {
+		pg := build()
+		if err := pg.RemoveVersion("A", "1.2.0", true); err != nil {
+			t.Fatal(err)
+		}
+		if err := pg.AddVersion("A", "1.2.1", VersionInfo{Dependencies: map[string]string{}}); err != nil {
+			t.Fatal(err)
+		}
+		if edges := edgeSet(pg); !edges[[2]string{"App-1.0.0", "A-1.2.1"}] || edges[[2]string{"App-1.0.0", "A-1.1.0"}] {
+			t.Errorf("Expected App 1.0.0 to be re-resolved to A 1.2.1, got %v", edges)
+		}
+	})
+}
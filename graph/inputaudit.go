@@ -0,0 +1,144 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// auditExamples is the number of examples that InputAudit keeps per category.
+const auditExamples = 10
+
+// AuditFinding is a category of problems found by AuditInput: their number, and the first few of them as examples in
+// input order.
+type AuditFinding struct {
+	Count    int      `json:"count"`
+	Examples []string `json:"examples,omitempty"`
+}
+
+func (finding *AuditFinding) add(example string) {
+	finding.Count++
+	if len(finding.Examples) < auditExamples {
+		finding.Examples = append(finding.Examples, example)
+	}
+}
+
+// InputAudit describes the data-quality problems of parsed packages, see AuditInput. The examples name the versions as
+// "name@version".
+type InputAudit struct {
+	Packages int `json:"packages"`
+	Versions int `json:"versions"`
+	// NoVersions are the packages without any version.
+	NoVersions AuditFinding `json:"noVersions"`
+	// NoDependencies are the versions without a dependency in any class. Many are genuine leaves, but a sudden rise
+	// points at an exporter that lost the dependencies.
+	NoDependencies AuditFinding `json:"noDependencies"`
+	// EmptyNames are the packages and the dependency declarations whose name is empty or only whitespace.
+	EmptyNames AuditFinding `json:"emptyNames"`
+	// EquivalentVersions are the sets of versions of a package that only differ before NormalizeVersion, such as
+	// "1.0.0" and "v1.0.0", which all end up as the same version in the graph.
+	EquivalentVersions AuditFinding `json:"equivalentVersions"`
+	// FutureTimestamps and AncientTimestamps are the versions released after the audit or before 1990, and
+	// UnparseableTimestamps those whose timestamp ParseTimestamp does not accept.
+	FutureTimestamps      AuditFinding `json:"futureTimestamps"`
+	AncientTimestamps     AuditFinding `json:"ancientTimestamps"`
+	UnparseableTimestamps AuditFinding `json:"unparseableTimestamps"`
+	// EmptyRanges are the dependency declarations whose range is empty or only whitespace, in any class.
+	EmptyRanges AuditFinding `json:"emptyRanges"`
+}
+
+// AuditInput checks the parsed packages for the problems that break a graph in subtle ways, such as versions that
+// collapse into one or dependencies with empty ranges, so that a broken input is noticed before the graph is built.
+// It only reads the packages once, which takes a fraction of the time of the edge creation. The versions of every
+// package are visited in lexical order, so the examples only depend on the input.
+func AuditInput(packages []PackageInfo) InputAudit {
+	return auditInput(packages, time.Now())
+}
+
+func auditInput(packages []PackageInfo, now time.Time) InputAudit {
+	audit := InputAudit{Packages: len(packages)}
+	for i, packageInfo := range packages {
+		if strings.TrimSpace(packageInfo.Name) == "" {
+			audit.EmptyNames.add(fmt.Sprintf("package %d: %q", i, packageInfo.Name))
+		}
+		if len(packageInfo.Versions) == 0 {
+			audit.NoVersions.add(packageInfo.Name)
+			continue
+		}
+		versions := make([]string, 0, len(packageInfo.Versions))
+		for version := range packageInfo.Versions {
+			versions = append(versions, version)
+		}
+		sort.Strings(versions)
+		audit.Versions += len(versions)
+
+		equivalent := make(map[string][]string)
+		var normalizedOrder []string
+		for _, version := range versions {
+			nameVersion := packageInfo.key().version(version).String()
+			versionInfo := packageInfo.Versions[version]
+			if normalized, err := NormalizeVersion(version, false); err == nil {
+				if len(equivalent[normalized]) == 0 {
+					normalizedOrder = append(normalizedOrder, normalized)
+				}
+				equivalent[normalized] = append(equivalent[normalized], version)
+			}
+			audit.auditTimestamp(nameVersion, versionInfo.Timestamp, now)
+			declarations := 0
+			for _, class := range []DependencyClass{Runtime, Development, Peer, Optional} {
+				dependencies := versionInfo.DependenciesOf(class)
+				declarations += len(dependencies)
+				for _, name := range sortedDependencyNames(dependencies) {
+					if strings.TrimSpace(name) == "" {
+						audit.EmptyNames.add(fmt.Sprintf("%s %s dependency %q", nameVersion, class, name))
+					}
+					if strings.TrimSpace(dependencies[name]) == "" {
+						audit.EmptyRanges.add(fmt.Sprintf("%s %s dependency %s", nameVersion, class, name))
+					}
+				}
+			}
+			if declarations == 0 {
+				audit.NoDependencies.add(nameVersion)
+			}
+		}
+		for _, normalized := range normalizedOrder {
+			if group := equivalent[normalized]; len(group) > 1 {
+				audit.EquivalentVersions.add(fmt.Sprintf("%s: %s", packageInfo.Name, strings.Join(group, ", ")))
+			}
+		}
+	}
+	return audit
+}
+
+// auditTimestamp records the timestamp of the version if it cannot be parsed or is implausible.
+func (audit *InputAudit) auditTimestamp(nameVersion, timestamp string, now time.Time) {
+	released, err := ParseTimestamp(timestamp)
+	switch {
+	case err != nil:
+		audit.UnparseableTimestamps.add(fmt.Sprintf("%s: %q", nameVersion, timestamp))
+	case released.After(now):
+		audit.FutureTimestamps.add(fmt.Sprintf("%s: %s", nameVersion, timestamp))
+	case released.Before(earliestTimestamp):
+		audit.AncientTimestamps.add(fmt.Sprintf("%s: %s", nameVersion, timestamp))
+	}
+}
+
+// sortedDependencyNames returns the names of the dependencies in lexical order.
+func sortedDependencyNames(dependencies map[string]string) []string {
+	names := make([]string, 0, len(dependencies))
+	for name := range dependencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteJSON writes the audit as indented JSON.
+func (audit *InputAudit) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(audit)
+}
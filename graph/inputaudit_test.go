@@ -0,0 +1,72 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAuditInput(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	none := map[string]string{}
+	packagesInfo := []PackageInfo{
+		{Name: "App", Versions: map[string]VersionInfo{
+			"1.0.0":  {Timestamp: "2022-01-01T00:00:00", Dependencies: map[string]string{"Lib": "^1.0.0", " ": "1.0.0"}},
+			"v1.0.0": {Timestamp: "2022-01-02T00:00:00", Dependencies: map[string]string{"Lib": ""}},
+			"2.0.0":  {Timestamp: "2024-01-01T00:00:00", DevDependencies: map[string]string{"Test": "  "}},
+		}},
+		{Name: "Lib", Versions: map[string]VersionInfo{
+			"1.0.0": {Timestamp: "1970-01-01T00:00:00", Dependencies: none},
+			"1.1":   {Timestamp: "yesterday", PeerDependencies: map[string]string{"App": "*"}},
+		}},
+		{Name: "Empty", Versions: map[string]VersionInfo{}},
+		{Name: "\t", Versions: map[string]VersionInfo{"1.0.0": {Timestamp: "2022-01-01", Dependencies: none}}},
+	}
+	audit := auditInput(packagesInfo, now)
+
+	t.Run("Finds every category", func(t *testing.T) {
+		expected := InputAudit{
+			Packages:              4,
+			Versions:              6,
+			NoVersions:            AuditFinding{1, []string{"Empty"}},
+			NoDependencies:        AuditFinding{2, []string{"Lib@1.0.0", "\t@1.0.0"}},
+			EmptyNames:            AuditFinding{2, []string{`App@1.0.0 runtime dependency " "`, `package 3: "\t"`}},
+			EquivalentVersions:    AuditFinding{1, []string{"App: 1.0.0, v1.0.0"}},
+			FutureTimestamps:      AuditFinding{1, []string{"App@2.0.0: 2024-01-01T00:00:00"}},
+			AncientTimestamps:     AuditFinding{1, []string{"Lib@1.0.0: 1970-01-01T00:00:00"}},
+			UnparseableTimestamps: AuditFinding{1, []string{`Lib@1.1: "yesterday"`}},
+			EmptyRanges:           AuditFinding{2, []string{"App@2.0.0 dev dependency Test", "App@v1.0.0 runtime dependency Lib"}},
+		}
+		if !reflect.DeepEqual(audit, expected) {
+			t.Errorf("Expected %+v, got %+v", expected, audit)
+		}
+	})
+
+	t.Run("Keeps a bounded number of examples", func(t *testing.T) {
+		var many []PackageInfo
+		for i := 0; i < 25; i++ {
+			many = append(many, PackageInfo{Name: fmt.Sprintf("P%d", i), Versions: map[string]VersionInfo{}})
+		}
+		finding := auditInput(many, now).NoVersions
+		if finding.Count != 25 || len(finding.Examples) != auditExamples || finding.Examples[0] != "P0" {
+			t.Errorf("Expected 25 packages with %d examples, got %+v", auditExamples, finding)
+		}
+	})
+
+	t.Run("Writes the audit as JSON", func(t *testing.T) {
+		var buffer bytes.Buffer
+		if err := audit.WriteJSON(&buffer); err != nil {
+			t.Fatal(err)
+		}
+		var decoded InputAudit
+		if err := json.Unmarshal(buffer.Bytes(), &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(decoded, audit) {
+			t.Errorf("Expected %+v, got %+v", audit, decoded)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package graph
+
+// Interner deduplicates strings, so that identical strings share their backing storage. In the package data the same
+// package name appears once per version and once per dependency declaration, and version strings such as "1.0.0" are
+// repeated millions of times, so interning them saves a large part of the heap.
+//
+// An Interner is not safe for concurrent use.
+type Interner struct {
+	strings map[string]string
+	bytes   int
+}
+
+// NewInterner creates an empty Interner.
+func NewInterner() *Interner {
+	return &Interner{strings: make(map[string]string)}
+}
+
+// Intern returns the canonical copy of s.
+func (interner *Interner) Intern(s string) string {
+	if canonical, ok := interner.strings[s]; ok {
+		return canonical
+	}
+	interner.strings[s] = s
+	interner.bytes += len(s)
+	return s
+}
+
+// Len returns the number of distinct strings in the pool.
+func (interner *Interner) Len() int {
+	return len(interner.strings)
+}
+
+// Bytes returns the total length of the distinct strings in the pool.
+func (interner *Interner) Bytes() int {
+	return interner.bytes
+}
+
+// InternPackage interns the name of the package, its version keys, their licenses and deprecation messages and the
+// names and ranges of all its dependencies. The maps are rebuilt, so that the keys decoded from the JSON can be garbage
+// collected.
+func (interner *Interner) InternPackage(packageInfo *PackageInfo) {
+	packageInfo.Name = interner.Intern(packageInfo.Name)
+	versions := make(map[string]VersionInfo, len(packageInfo.Versions))
+	for version, versionInfo := range packageInfo.Versions {
+		versionInfo.Dependencies = interner.internMap(versionInfo.Dependencies)
+		versionInfo.DevDependencies = interner.internMap(versionInfo.DevDependencies)
+		versionInfo.PeerDependencies = interner.internMap(versionInfo.PeerDependencies)
+		versionInfo.OptionalDependencies = interner.internMap(versionInfo.OptionalDependencies)
+		if versionInfo.License != "" {
+			versionInfo.License = License(interner.Intern(string(versionInfo.License)))
+		}
+		if versionInfo.Deprecated != "" {
+			versionInfo.Deprecated = Deprecation(interner.Intern(string(versionInfo.Deprecated)))
+		}
+		if versionInfo.Maintainers != nil {
+			maintainers := make(Maintainers, len(versionInfo.Maintainers))
+			for i, maintainer := range versionInfo.Maintainers {
+				maintainers[i] = interner.Intern(maintainer)
+			}
+			versionInfo.Maintainers = maintainers
+		}
+		versions[interner.Intern(version)] = versionInfo
+	}
+	packageInfo.Versions = versions
+}
+
+func (interner *Interner) internMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	interned := make(map[string]string, len(m))
+	for key, value := range m {
+		interned[interner.Intern(key)] = interner.Intern(value)
+	}
+	return interned
+}
@@ -0,0 +1,97 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestInterner(t *testing.T) {
+	interner := NewInterner()
+	packageInfo := PackageInfo{
+		Name: "B",
+		Versions: map[string]VersionInfo{
+			"1.0.0": {
+				Timestamp:    "2021-04-22T20:15:37",
+				Dependencies: map[string]string{"A": "1.0.0"},
+			},
+		},
+	}
+	interner.InternPackage(&packageInfo)
+
+	t.Run("Adds every distinct string once", func(t *testing.T) {
+		// B, 1.0.0 and A; the range 1.0.0 is the same string as the version.
+		if interner.Len() != 3 {
+			t.Errorf("Expected 3 interned strings, got %d", interner.Len())
+		}
+		if interner.Bytes() != 7 {
+			t.Errorf("Expected 7 interned bytes, got %d", interner.Bytes())
+		}
+	})
+
+	t.Run("Keeps the package data intact", func(t *testing.T) {
+		if packageInfo.Name != "B" || packageInfo.Versions["1.0.0"].Dependencies["A"] != "1.0.0" {
+			t.Errorf("Expected the package to be unchanged, got %v", packageInfo)
+		}
+		if packageInfo.Versions["1.0.0"].DevDependencies != nil {
+			t.Error("Expected missing dependency classes to stay nil")
+		}
+	})
+}
+
+// writeBenchmarkFixture writes a synthetic dataset in which, like in npm, every package has a number of versions with
+// a handful of caret ranges each, referring to other packages.
+func writeBenchmarkFixture(b *testing.B, packages, versions, dependencies int) string {
+	rnd := rand.New(rand.NewSource(1))
+	packagesInfo := make([]PackageInfo, packages)
+	for i := range packagesInfo {
+		packagesInfo[i] = PackageInfo{Name: fmt.Sprintf("package-%d", i), Versions: make(map[string]VersionInfo)}
+		for v := 0; v < versions; v++ {
+			deps := make(map[string]string, dependencies)
+			for d := 0; d < dependencies; d++ {
+				deps[fmt.Sprintf("package-%d", rnd.Intn(packages))] = fmt.Sprintf("^%d.%d.0", rnd.Intn(3), rnd.Intn(5))
+			}
+			version := fmt.Sprintf("%d.%d.%d", v/10, v%10, rnd.Intn(3))
+			packagesInfo[i].Versions[version] = VersionInfo{Timestamp: "2021-04-22T20:15:37", Dependencies: deps}
+		}
+	}
+	encoded, err := json.Marshal(packagesInfo)
+	if err != nil {
+		b.Fatal(err)
+	}
+	path := filepath.Join(b.TempDir(), "packages.json")
+	if err := os.WriteFile(path, encoded, 0o644); err != nil {
+		b.Fatal(err)
+	}
+	return path
+}
+
+// BenchmarkParseJSONInterning reports the heap retained by the parsed packages with and without interning.
+func BenchmarkParseJSONInterning(b *testing.B) {
+	path := writeBenchmarkFixture(b, 5000, 20, 5)
+	for _, interning := range []bool{false, true} {
+		b.Run(fmt.Sprintf("interning=%v", interning), func(b *testing.B) {
+			var retained uint64
+			for i := 0; i < b.N; i++ {
+				var before, after runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+				var interner *Interner
+				if interning {
+					interner = NewInterner()
+				}
+				result := ParseJSONWithInterner(path, interner)
+				interner = nil
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				retained = after.HeapAlloc - before.HeapAlloc
+				runtime.KeepAlive(result)
+			}
+			b.ReportMetric(float64(retained)/(1<<20), "retained-MiB")
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package graph
+
+import (
+	"sort"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// CoreNumbers computes the core number of every node in g, treating the graph as undirected: a pair of nodes is
+// adjacent if there is an edge between them in either direction. The core number of a node is the largest k such that
+// the node belongs to a subgraph in which every node has at least k neighbours.
+//
+// It uses the bucket-based peeling algorithm by Batagelj and Zaversnik, which runs in O(V + E).
+func CoreNumbers(g graph.Directed) map[int64]int {
+	nodes := graph.NodesOf(g.Nodes())
+	index := make(map[int64]int, len(nodes))
+	for i, node := range nodes {
+		index[node.ID()] = i
+	}
+	neighbours := make([][]int, len(nodes))
+	for i, node := range nodes {
+		neighbours[i] = undirectedNeighbours(g, node.ID(), index)
+	}
+
+	degree := make([]int, len(nodes))
+	maxDegree := 0
+	for i := range nodes {
+		degree[i] = len(neighbours[i])
+		if degree[i] > maxDegree {
+			maxDegree = degree[i]
+		}
+	}
+
+	// Sort the vertices by degree with a counting sort. binStart[d] is the position of the first vertex with degree d.
+	binStart := make([]int, maxDegree+1)
+	for _, d := range degree {
+		binStart[d]++
+	}
+	start := 0
+	for d := range binStart {
+		count := binStart[d]
+		binStart[d] = start
+		start += count
+	}
+	position := make([]int, len(nodes))
+	vertices := make([]int, len(nodes))
+	for v, d := range degree {
+		position[v] = binStart[d]
+		vertices[position[v]] = v
+		binStart[d]++
+	}
+	for d := maxDegree; d > 0; d-- {
+		binStart[d] = binStart[d-1]
+	}
+	binStart[0] = 0
+
+	for i := range vertices {
+		v := vertices[i]
+		for _, u := range neighbours[v] {
+			if degree[u] > degree[v] {
+				// Move u to the front of its bin and shrink the bin, effectively decreasing its degree by one.
+				du := degree[u]
+				pu := position[u]
+				pw := binStart[du]
+				w := vertices[pw]
+				if u != w {
+					vertices[pu], vertices[pw] = w, u
+					position[u], position[w] = pw, pu
+				}
+				binStart[du]++
+				degree[u]--
+			}
+		}
+	}
+
+	coreNumbers := make(map[int64]int, len(nodes))
+	for i, node := range nodes {
+		coreNumbers[node.ID()] = degree[i]
+	}
+	return coreNumbers
+}
+
+// undirectedNeighbours returns the dense indices of the nodes adjacent to id in either direction, without duplicates.
+func undirectedNeighbours(g graph.Directed, id int64, index map[int64]int) []int {
+	var result []int
+	from := g.From(id)
+	for from.Next() {
+		result = append(result, index[from.Node().ID()])
+	}
+	to := g.To(id)
+	for to.Next() {
+		result = append(result, index[to.Node().ID()])
+	}
+	sort.Ints(result)
+	unique := result[:0]
+	for i, v := range result {
+		if i == 0 || v != result[i-1] {
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
+
+// KCore returns the subgraph induced by the nodes with a core number of at least k, together with their node
+// information. Node IDs are kept, so results on the subgraph can be joined with the original graph.
+func KCore(g graph.Directed, nodeMap map[int64]NodeInfo, k int) (*simple.DirectedGraph, map[int64]NodeInfo) {
+	coreNumbers := CoreNumbers(g)
+	return inducedSubgraph(g, nodeMap, func(id int64) bool {
+		return coreNumbers[id] >= k
+	})
+}
+
+// inducedSubgraph creates a new graph containing the nodes for which keep returns true and all the edges between them.
+func inducedSubgraph(g graph.Directed, nodeMap map[int64]NodeInfo, keep func(id int64) bool) (*simple.DirectedGraph, map[int64]NodeInfo) {
+	subgraph := simple.NewDirectedGraph()
+	subNodeMap := make(map[int64]NodeInfo)
+	nodes := g.Nodes()
+	for nodes.Next() {
+		id := nodes.Node().ID()
+		if keep(id) {
+			subgraph.AddNode(simple.Node(id))
+			if info, ok := nodeMap[id]; ok {
+				subNodeMap[id] = info
+			}
+		}
+	}
+	nodes.Reset()
+	for nodes.Next() {
+		fromID := nodes.Node().ID()
+		if subgraph.Node(fromID) == nil {
+			continue
+		}
+		to := g.From(fromID)
+		for to.Next() {
+			toID := to.Node().ID()
+			if subgraph.Node(toID) != nil {
+				subgraph.SetEdge(simple.Edge{F: simple.Node(fromID), T: simple.Node(toID)})
+			}
+		}
+	}
+	return subgraph, subNodeMap
+}
@@ -0,0 +1,44 @@
+package graph
+
+import (
+	"testing"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+func TestCoreNumbers(t *testing.T) {
+	// Nodes 0-3 form a 3-core (every pair is connected), 4 and 5 hang off of it and 6 is isolated.
+	graph := simple.NewDirectedGraph()
+	edges := [][2]int64{{0, 1}, {0, 2}, {0, 3}, {1, 2}, {1, 3}, {2, 3}, {3, 2}, {4, 0}, {4, 1}, {5, 4}}
+	for _, edge := range edges {
+		graph.SetEdge(simple.Edge{F: simple.Node(edge[0]), T: simple.Node(edge[1])})
+	}
+	graph.AddNode(simple.Node(6))
+
+	t.Run("Computes the core number of every node", func(t *testing.T) {
+		expected := map[int64]int{0: 3, 1: 3, 2: 3, 3: 3, 4: 2, 5: 1, 6: 0}
+		actual := CoreNumbers(graph)
+		for id, core := range expected {
+			if actual[id] != core {
+				t.Errorf("Expected core number %d for node %d, got %d", core, id, actual[id])
+			}
+		}
+	})
+
+	t.Run("Extracts the induced k-core subgraph", func(t *testing.T) {
+		nodeMap := make(map[int64]NodeInfo)
+		for id := int64(0); id < 7; id++ {
+			nodeMap[id] = *NewNodeInfo(id, "P", string(rune('a'+id)), "")
+		}
+		core, coreNodeMap := KCore(graph, nodeMap, 3)
+		if core.Nodes().Len() != 4 || len(coreNodeMap) != 4 {
+			t.Errorf("Expected 4 nodes in the 3-core, got %d", core.Nodes().Len())
+		}
+		if core.Edges().Len() != 7 {
+			t.Errorf("Expected 7 edges in the 3-core, got %d", core.Edges().Len())
+		}
+		if core.Node(4) != nil {
+			t.Error("Expected node 4 to be excluded from the 3-core")
+		}
+	})
+}
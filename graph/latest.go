@@ -0,0 +1,148 @@
+package graph
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Masterminds/semver"
+)
+
+// LatestPolicy selects the versions that LatestVersion considers. The policies other than AllVersions can be combined
+// with |.
+type LatestPolicy int
+
+const (
+	// AllVersions considers every version.
+	AllVersions LatestPolicy = 0
+	// SkipPrereleases leaves out the prerelease versions, such as "2.0.0-rc.1", and the versions that cannot be parsed
+	// as semver, which cannot be told apart from them.
+	SkipPrereleases LatestPolicy = 1 << (iota - 1)
+	// SkipDeprecated leaves out the deprecated versions.
+	SkipDeprecated
+)
+
+// latestEntry is a version of a package in the latest-version index.
+type latestEntry struct {
+	version string
+	// parsed is the normalized semver version, or nil if the version cannot be parsed.
+	parsed *semver.Version
+	// released is the zero time if the timestamp cannot be parsed.
+	released   time.Time
+	deprecated bool
+}
+
+// latestIndex maps the package keys to their versions, sorted from the latest to the oldest by laterVersion.
+type latestIndex map[PackageKey][]latestEntry
+
+// newLatestIndex indexes the versions of every node of the graph.
+func newLatestIndex(pg *PackageGraph) latestIndex {
+	index := make(latestIndex, len(pg.NameToVersions))
+	for _, node := range pg.Nodes {
+		if node.stringID != "" {
+			index[node.key()] = append(index[node.key()], newLatestEntry(node, pg.options.TruncateFourPartVersions))
+		}
+	}
+	for _, entries := range index {
+		sort.Slice(entries, func(i, j int) bool { return laterVersion(&entries[i], &entries[j]) })
+	}
+	return index
+}
+
+func newLatestEntry(info NodeInfo, truncateFourPart bool) latestEntry {
+	parsed, _ := parseVersion(info.Version, truncateFourPart)
+	released, _ := ParseTimestamp(info.Timestamp)
+	return latestEntry{version: info.Version, parsed: parsed, released: released, deprecated: info.Deprecated != ""}
+}
+
+// laterVersion reports whether a comes before b in the index, in the order described by LatestVersion.
+func laterVersion(a, b *latestEntry) bool {
+	switch {
+	case a.parsed != nil && b.parsed != nil:
+		if order := a.parsed.Compare(b.parsed); order != 0 {
+			return order > 0
+		}
+	case a.parsed != nil:
+		return true
+	case b.parsed != nil:
+		return false
+	}
+	return a.version > b.version
+}
+
+// add adds the version to the index.
+func (index latestIndex) add(info NodeInfo, truncateFourPart bool) {
+	entry := newLatestEntry(info, truncateFourPart)
+	entries := index[info.key()]
+	i := sort.Search(len(entries), func(i int) bool { return laterVersion(&entry, &entries[i]) })
+	entries = append(entries, latestEntry{})
+	copy(entries[i+1:], entries[i:])
+	entries[i] = entry
+	index[info.key()] = entries
+}
+
+// remove removes the version from the index, and the package once it has no versions left.
+func (index latestIndex) remove(key PackageKey, version string) {
+	entries := index[key]
+	for i := range entries {
+		if entries[i].version == version {
+			entries = append(entries[:i], entries[i+1:]...)
+			break
+		}
+	}
+	if len(entries) == 0 {
+		delete(index, key)
+	} else {
+		index[key] = entries
+	}
+}
+
+// latest returns the latest version of the package that the policy allows and that was released at or before at,
+// unless at is nil.
+func (index latestIndex) latest(key PackageKey, at *time.Time, policy LatestPolicy) (string, bool) {
+	for _, entry := range index[key] {
+		if policy&SkipPrereleases != 0 && (entry.parsed == nil || entry.parsed.Prerelease() != "") {
+			continue
+		}
+		if policy&SkipDeprecated != 0 && entry.deprecated {
+			continue
+		}
+		if at != nil && (entry.released.IsZero() || entry.released.After(*at)) {
+			continue
+		}
+		return entry.version, true
+	}
+	return "", false
+}
+
+// WithLatestIndex builds the index of LatestVersion while constructing or loading the graph, instead of on its first
+// use, so that the first query does not pay for it.
+func WithLatestIndex() Option {
+	return func(options *Options) {
+		options.LatestIndex = true
+	}
+}
+
+// LatestVersion returns the highest version of the package that the policy allows. The versions are compared by their
+// normalized semver version, so "v1.2" and "1.2.0" are equal, as are versions that only differ in their build
+// metadata; of equal versions, the one with the lexically greater version string is the latest, so "1.0.0+build.2"
+// wins over "1.0.0+build.1" whatever the order of the input. The versions that cannot be parsed as semver are below all
+// others. If at is not nil, only the versions released at or before it are considered, which leaves out the versions
+// whose timestamp cannot be parsed. The bool is false if the package is not part of the graph or none of its versions
+// qualifies. The name is normalized like the names the graph was built from.
+//
+// The answers come from an index of the versions of every package in order, which is built on the first call, or on
+// construction with WithLatestIndex, and kept up to date by AddVersion, AddPackage, RemoveVersion and RemovePackage,
+// so a query only scans the versions above the latest one that qualifies.
+func (pg *PackageGraph) LatestVersion(name string, at *time.Time, policy LatestPolicy) (string, bool) {
+	return pg.latestIndex().latest(pg.packageKey("", name), at, policy)
+}
+
+// latestIndex returns the index of LatestVersion, building it on the first call.
+func (pg *PackageGraph) latestIndex() latestIndex {
+	pg.latestMutex.Lock()
+	defer pg.latestMutex.Unlock()
+	if pg.latest == nil {
+		pg.latest = newLatestIndex(pg)
+	}
+	return pg.latest
+}
@@ -0,0 +1,120 @@
+package graph
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestLatestVersion(t *testing.T) {
+	build := func(opts ...Option) *PackageGraph {
+		packagesInfo := []PackageInfo{
+			{Name: "Lib", Versions: map[string]VersionInfo{
+				"1.0.0":         {Timestamp: "2020-01-01T00:00:00"},
+				"1.2.0":         {Timestamp: "2021-01-01T00:00:00"},
+				"v1.10.0":       {Timestamp: "2021-06-01T00:00:00", Deprecated: "broken"},
+				"2.0.0-rc.1":    {Timestamp: "2022-01-01T00:00:00"},
+				"1.3.0+build.1": {Timestamp: "2021-03-01T00:00:00"},
+				"1.3.0+build.2": {Timestamp: "yesterday"},
+				"nightly":       {Timestamp: "2023-01-01T00:00:00"},
+			}},
+			{Name: "App", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2022-01-01T00:00:00", Dependencies: map[string]string{"Lib": "^1.0.0"}},
+			}},
+			{Name: "Tool", Versions: map[string]VersionInfo{
+				"snapshot": {Timestamp: "2022-01-01T00:00:00"},
+			}},
+		}
+		return NewPackageGraph(&packagesInfo, false, opts...)
+	}
+	date := func(value string) *time.Time {
+		at, _ := time.Parse("2006-01-02", value)
+		return &at
+	}
+	type test struct {
+		name     string
+		at       *time.Time
+		policy   LatestPolicy
+		expected string
+	}
+
+	t.Run("Applies the policy and the date", func(t *testing.T) {
+		pg := build()
+		tests := []test{
+			{"Lib", nil, AllVersions, "2.0.0-rc.1"},
+			{"Lib", nil, SkipPrereleases, "v1.10.0"},
+			{"Lib", nil, SkipPrereleases | SkipDeprecated, "1.3.0+build.2"},
+			{"Lib", date("2021-12-31"), AllVersions, "v1.10.0"},
+			{"Lib", date("2021-05-01"), SkipPrereleases, "1.3.0+build.1"},
+			{"Lib", date("2020-06-01"), AllVersions, "1.0.0"},
+			{"Lib", date("2019-01-01"), AllVersions, ""},
+			{"Tool", nil, AllVersions, "snapshot"},
+			{"Tool", nil, SkipPrereleases, ""},
+			{"Missing", nil, AllVersions, ""},
+		}
+		for _, test := range tests {
+			if version, ok := pg.LatestVersion(test.name, test.at, test.policy); version != test.expected || ok != (test.expected != "") {
+				t.Errorf("Expected %q for %s at %v with policy %d, got %q and %v", test.expected, test.name, test.at,
+					test.policy, version, ok)
+			}
+		}
+	})
+
+	t.Run("Breaks ties by the version string", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			if version, _ := build().LatestVersion("Lib", nil, SkipPrereleases|SkipDeprecated); version != "1.3.0+build.2" {
+				t.Fatalf("Expected 1.3.0+build.2, got %q", version)
+			}
+		}
+	})
+
+	t.Run("Follows the incremental changes", func(t *testing.T) {
+		for _, pg := range []*PackageGraph{build(), build(WithLatestIndex())} {
+			pg.LatestVersion("Lib", nil, AllVersions)
+			if err := pg.AddVersion("Lib", "2.0.0", VersionInfo{Timestamp: "2022-02-01T00:00:00"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := pg.AddVersion("Lib", "1.1.0", VersionInfo{Timestamp: "2020-06-01T00:00:00"}); err != nil {
+				t.Fatal(err)
+			}
+			if version, _ := pg.LatestVersion("Lib", nil, SkipPrereleases); version != "2.0.0" {
+				t.Errorf("Expected the added 2.0.0, got %q", version)
+			}
+			if version, _ := pg.LatestVersion("Lib", date("2020-12-31"), AllVersions); version != "1.1.0" {
+				t.Errorf("Expected the added 1.1.0, got %q", version)
+			}
+			if err := pg.RemoveVersion("Lib", "2.0.0", true); err != nil {
+				t.Fatal(err)
+			}
+			if version, _ := pg.LatestVersion("Lib", nil, SkipPrereleases); version != "v1.10.0" {
+				t.Errorf("Expected v1.10.0 once 2.0.0 is removed, got %q", version)
+			}
+			if err := pg.RemovePackage("Tool", true); err != nil {
+				t.Fatal(err)
+			}
+			if version, ok := pg.LatestVersion("Tool", nil, AllVersions); ok {
+				t.Errorf("Expected no version of the removed package, got %q", version)
+			}
+		}
+	})
+
+	t.Run("Builds the index up front with WithLatestIndex", func(t *testing.T) {
+		if pg := build(); pg.latest != nil {
+			t.Errorf("Expected the index to be built on the first use")
+		}
+		if pg := build(WithLatestIndex()); pg.latest == nil {
+			t.Errorf("Expected the index to be built with the graph")
+		}
+		var cache bytes.Buffer
+		if err := SaveGraph(&cache, build()); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadGraphWithOptions(bytes.NewReader(cache.Bytes()), false, WithLatestIndex())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version, _ := loaded.LatestVersion("Lib", nil, SkipPrereleases); loaded.latest == nil || version != "v1.10.0" {
+			t.Errorf("Expected the index to be built with the loaded graph and give v1.10.0, got %q", version)
+		}
+	})
+}
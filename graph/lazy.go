@@ -0,0 +1,74 @@
+package graph
+
+import (
+	"fmt"
+)
+
+// ResolveDependencies matches the dependency ranges of the versions of the named package and creates their outgoing
+// edges, for example to show the dependency tree of a single package without resolving the whole graph. Versions
+// whose edges already exist are skipped, so calling it again does nothing. The ranges are matched against the sorted
+// version index and with the range matcher that the graph shares between all its resolutions, and their outcomes are
+// counted in the report of the options. The name is normalized like the names the graph was built from.
+//
+// Without WithLazyEdges, the edges of a graph built by NewPackageGraph already exist, so this only checks that the
+// package is part of the graph. The error is returned if it is not. It is safe to call concurrently with the queries
+// that create edges on demand.
+func (pg *PackageGraph) ResolveDependencies(name string) error {
+	key := pg.packageKey("", name)
+	ids := pg.versionIDs(key)
+	if len(ids) == 0 {
+		return fmt.Errorf("package %s is not part of the graph", key)
+	}
+	pg.lazyMutex.Lock()
+	defer pg.lazyMutex.Unlock()
+	pg.resolveVersions(ids)
+	return nil
+}
+
+// deferEdges indexes the versions of a graph without edges and marks all of them as unresolved.
+func (pg *PackageGraph) deferEdges() {
+	pg.resolver()
+	pg.unresolved = make(map[int64]bool, len(pg.ids))
+	for _, id := range pg.ids {
+		pg.unresolved[id] = true
+	}
+	pg.options.log(LevelInfo, "edge creation deferred", "versions", len(pg.unresolved))
+}
+
+// lockEdges returns whether the edges of some versions may still be created on demand, in which case it locks
+// lazyMutex so that the caller can create and read them.
+func (pg *PackageGraph) lockEdges() bool {
+	if !pg.options.LazyEdges {
+		return false
+	}
+	pg.lazyMutex.Lock()
+	return true
+}
+
+// resolveDependents creates the outgoing edges of the unresolved versions that declare a dependency on the package of
+// the version with the given ID, so that all its incoming edges exist.
+func (pg *PackageGraph) resolveDependents(id int64) {
+	if len(pg.unresolved) == 0 {
+		return
+	}
+	pg.ensureConstraintIndex()
+	pg.resolveVersions(pg.declaringDependents(pg.node(id).key()))
+}
+
+// resolveVersions creates the outgoing edges of the versions with the given IDs that are still unresolved.
+func (pg *PackageGraph) resolveVersions(ids []int64) {
+	var resolver *edgeResolver
+	for _, id := range ids {
+		if !pg.unresolved[id] {
+			continue
+		}
+		if resolver == nil {
+			resolver = pg.resolver()
+		}
+		info := pg.node(id)
+		versionInfo, _ := pg.VersionInfo(info.NameVersion())
+		pg.setWeightedEdges(resolver.resolveVersion(nil, info, versionInfo, pg.options.Report))
+		delete(pg.unresolved, id)
+		pg.reach = nil
+	}
+}
@@ -0,0 +1,177 @@
+package graph
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// withoutEdges removes all the edges of the graph and marks all its versions as unresolved.
+func withoutEdges(pg *PackageGraph) {
+	pg.unresolved = make(map[int64]bool)
+	for _, node := range pg.Nodes {
+		if node.stringID == "" {
+			continue
+		}
+		pg.unresolved[node.id] = true
+		for _, to := range sortedNodeIDs(pg.Graph.From(node.id)) {
+			pg.Graph.RemoveEdge(node.id, to)
+		}
+	}
+}
+
+func TestResolveDependencies(t *testing.T) {
+	packagesInfo := testPackages("options")
+	expected := edgeSet(NewPackageGraph(&packagesInfo, false, WithDependencyClasses(Runtime, Development)))
+
+	t.Run("Creates the edges of the named package only", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		pg := NewPackageGraph(&packagesInfo, false, WithDependencyClasses(Runtime, Development))
+		withoutEdges(pg)
+		if err := pg.ResolveDependencies("A"); err != nil {
+			t.Fatal(err)
+		}
+		if edges := pg.Graph.Edges().Len(); edges != 0 {
+			t.Errorf("Expected no edges for a package without dependencies, got %d", edges)
+		}
+		if err := pg.ResolveDependencies("App"); err != nil {
+			t.Fatal(err)
+		}
+		if edges := edgeSet(pg); !reflect.DeepEqual(edges, expected) {
+			t.Errorf("Expected the edges %v, got %v", expected, edges)
+		}
+	})
+
+	t.Run("Skips the versions that are already resolved", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		pg := NewPackageGraph(&packagesInfo, false, WithDependencyClasses(Runtime, Development))
+		withoutEdges(pg)
+		report := &ResolutionReport{}
+		pg.options.Report = report
+		for i := 0; i < 2; i++ {
+			if err := pg.ResolveDependencies("App"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if report.Declarations != 2 {
+			t.Errorf("Expected the 2 declarations of App to be matched once, got %d", report.Declarations)
+		}
+		if len(pg.unresolved) != 4 {
+			t.Errorf("Expected the 4 other versions to remain unresolved, got %d", len(pg.unresolved))
+		}
+	})
+
+	t.Run("Leaves a fully built graph unchanged", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		pg := NewPackageGraph(&packagesInfo, false, WithDependencyClasses(Runtime, Development))
+		if err := pg.ResolveDependencies("App"); err != nil {
+			t.Fatal(err)
+		}
+		if edges := edgeSet(pg); !reflect.DeepEqual(edges, expected) {
+			t.Errorf("Expected the edges %v, got %v", expected, edges)
+		}
+	})
+
+	t.Run("Rejects unknown packages", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		pg := NewPackageGraph(&packagesInfo, false)
+		if err := pg.ResolveDependencies("Unknown"); err == nil {
+			t.Error("Expected an error for an unknown package")
+		}
+	})
+}
+
+func TestLazyEdges(t *testing.T) {
+	packagesInfo := testPackages("lazy")
+	for _, resolution := range []Resolution{ResolveAll, ResolveHighest} {
+		eager := NewPackageGraph(&packagesInfo, false, WithResolution(resolution))
+
+		t.Run(fmt.Sprintf("Creates no edges on construction with resolution %d", resolution), func(t *testing.T) {
+			lazy := NewPackageGraph(&packagesInfo, false, WithResolution(resolution), WithLazyEdges())
+			if edges := lazy.Graph.Edges().Len(); edges != 0 {
+				t.Errorf("Expected no edges, got %d", edges)
+			}
+			if len(lazy.unresolved) != len(eager.ids) {
+				t.Errorf("Expected all %d versions to be unresolved, got %d", len(eager.ids), len(lazy.unresolved))
+			}
+		})
+
+		t.Run(fmt.Sprintf("Answers queries like the eager graph with resolution %d", resolution), func(t *testing.T) {
+			lazy := NewPackageGraph(&packagesInfo, false, WithResolution(resolution), WithLazyEdges())
+			for _, node := range eager.Nodes {
+				nameVersion := NameVersion{Name: node.Name, Version: node.Version}
+				for _, maxDepth := range []int{1, -1} {
+					expected, _ := eager.Dependencies(nameVersion, maxDepth)
+					if got, _ := lazy.Dependencies(nameVersion, maxDepth); !reflect.DeepEqual(got, expected) {
+						t.Errorf("Expected the dependencies %v of %s, got %v", expected, nameVersion, got)
+					}
+					expected, _ = eager.Dependents(nameVersion, maxDepth)
+					if got, _ := lazy.Dependents(nameVersion, maxDepth); !reflect.DeepEqual(got, expected) {
+						t.Errorf("Expected the dependents %v of %s, got %v", expected, nameVersion, got)
+					}
+				}
+			}
+			if edges := edgeSet(lazy); !reflect.DeepEqual(edges, edgeSet(eager)) {
+				t.Errorf("Expected the queries to create the edges of the eager graph, got %d of %d", len(edges), len(edgeSet(eager)))
+			}
+		})
+
+		t.Run(fmt.Sprintf("Builds the same subgraph with resolution %d", resolution), func(t *testing.T) {
+			lazy := NewPackageGraph(&packagesInfo, false, WithResolution(resolution), WithLazyEdges())
+			root := NameVersion{Name: "P19", Version: "1.2.0"}
+			expected, _ := eager.Subgraph(root, 1)
+			got, ok := lazy.Subgraph(root, 1)
+			if !ok {
+				t.Fatal("Expected the root to be found")
+			}
+			if !reflect.DeepEqual(edgeSet(got), edgeSet(expected)) {
+				t.Errorf("Expected the subgraph edges %v, got %v", edgeSet(expected), edgeSet(got))
+			}
+		})
+	}
+
+	t.Run("Creates the edges once for concurrent queries", func(t *testing.T) {
+		eager := NewPackageGraph(&packagesInfo, false)
+		lazy := NewPackageGraph(&packagesInfo, false, WithLazyEdges())
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := i; j < 20; j += 2 {
+					nameVersion := NameVersion{Name: fmt.Sprintf("P%d", j), Version: "1.0.0"}
+					lazy.Dependencies(nameVersion, -1)
+					lazy.Dependents(nameVersion, -1)
+				}
+			}(i)
+		}
+		wg.Wait()
+		if edges := edgeSet(lazy); !reflect.DeepEqual(edges, edgeSet(eager)) {
+			t.Errorf("Expected the edges of the eager graph, got %d of %d", len(edges), len(edgeSet(eager)))
+		}
+	})
+
+	t.Run("Keeps the unresolved versions in a cache", func(t *testing.T) {
+		eager := NewPackageGraph(&packagesInfo, false)
+		lazy := NewPackageGraph(&packagesInfo, false, WithLazyEdges())
+		root := NameVersion{Name: "P19", Version: "1.0.0"}
+		lazy.Dependencies(root, 1)
+		var buffer bytes.Buffer
+		if err := SaveGraph(&buffer, lazy); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadGraph(&buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(loaded.unresolved) != len(lazy.unresolved) {
+			t.Errorf("Expected %d unresolved versions, got %d", len(lazy.unresolved), len(loaded.unresolved))
+		}
+		expected, _ := eager.Dependencies(root, -1)
+		if got, _ := loaded.Dependencies(root, -1); !reflect.DeepEqual(got, expected) {
+			t.Errorf("Expected the dependencies %v, got %v", expected, got)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package graph
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// legacyCacheMagic starts the graph caches written before the cache became a container of sections.
+const legacyCacheMagic = "STM-GRAPH-CACHE\n"
+
+// legacyCacheFormatVersion is the last format version of the legacy caches, which LoadGraph still reads.
+const legacyCacheFormatVersion = 8
+
+// legacyCache is the serialized form of a PackageGraph before the cache became a container of sections.
+type legacyCache struct {
+	FormatVersion            int
+	IsMaven                  bool
+	Resolution               Resolution
+	DependencyClasses        []DependencyClass
+	TruncateFourPartVersions bool
+	Prereleases              PrereleasePolicy
+	Names                    NameNormalization
+	PreferNonDeprecated      bool
+	Packages                 []PackageInfo
+	Nodes                    []legacyNode
+	Edges                    [][2]int64
+	Unresolved               []int64
+}
+
+type legacyNode struct {
+	ID         int64
+	Name       string
+	Version    string
+	Timestamp  string
+	License    string
+	Deprecated string
+}
+
+// loadLegacyCache reads a legacy graph cache, after the magic. Its name index is rebuilt from the packages.
+func loadLegacyCache(r io.Reader) (*PackageGraph, cachedOptions, error) {
+	var cache legacyCache
+	if err := gob.NewDecoder(r).Decode(&cache); err != nil {
+		return nil, cachedOptions{}, fmt.Errorf("decoding graph cache: %w", err)
+	}
+	if cache.FormatVersion != legacyCacheFormatVersion {
+		return nil, cachedOptions{}, fmt.Errorf("graph cache has format version %d, expected %d", cache.FormatVersion, legacyCacheFormatVersion)
+	}
+	cached := cachedOptions{
+		IsMaven:                  cache.IsMaven,
+		Resolution:               cache.Resolution,
+		DependencyClasses:        cache.DependencyClasses,
+		TruncateFourPartVersions: cache.TruncateFourPartVersions,
+		Prereleases:              cache.Prereleases,
+		PreferNonDeprecated:      cache.PreferNonDeprecated,
+		Names:                    cache.Names,
+		// The legacy caches predate the ecosystems, so the normalization of their names never depended on one.
+		EcosystemNames: cachedEcosystemNamesOf(defaultEcosystemNames()),
+	}
+
+	graph := simple.NewDirectedGraph()
+	var nodes []NodeInfo
+	for _, node := range cache.Nodes {
+		info, err := newCachedNodeInfo(graph, node.ID, NewPackageKey("", node.Name), node.Version, node.Timestamp, node.License, node.Deprecated)
+		if err != nil {
+			return nil, cached, err
+		}
+		nodes = setNodeInfo(nodes, info)
+	}
+	pg, err := newCachedPackageGraph(graph, &cache.Packages, nodes, packageVersions(&cache.Packages), cache.Edges, cache.Unresolved)
+	return pg, cached, err
+}
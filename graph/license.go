@@ -0,0 +1,95 @@
+package graph
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// UnknownLicense is the license under which the versions without a declared license are counted.
+const UnknownLicense = "UNKNOWN"
+
+// License is the license declared by a package version. Besides a plain string, such as "MIT" or an SPDX expression,
+// it is decoded from the legacy npm forms {"type": "MIT"} and [{"type": "MIT"}, {"type": "Apache-2.0"}], the latter
+// becoming "(MIT OR Apache-2.0)". Other values decode to an empty license instead of failing the whole input.
+type License string
+
+// UnmarshalJSON decodes the license from any of the forms found in the input.
+func (license *License) UnmarshalJSON(data []byte) error {
+	type licenseObject struct {
+		Type string `json:"type"`
+	}
+	var plain string
+	var object licenseObject
+	var list []licenseObject
+	switch {
+	case json.Unmarshal(data, &plain) == nil:
+		*license = License(plain)
+	case json.Unmarshal(data, &object) == nil:
+		*license = License(object.Type)
+	case json.Unmarshal(data, &list) == nil && len(list) > 0:
+		types := make([]string, 0, len(list))
+		for _, entry := range list {
+			if entry.Type != "" {
+				types = append(types, entry.Type)
+			}
+		}
+		if len(types) == 1 {
+			*license = License(types[0])
+		} else if len(types) > 1 {
+			*license = License("(" + strings.Join(types, " OR ") + ")")
+		}
+	default:
+		*license = ""
+	}
+	return nil
+}
+
+// licenseName returns the license under which a node is counted, with UnknownLicense for an empty license.
+func licenseName(license string) string {
+	license = strings.TrimSpace(license)
+	if license == "" {
+		return UnknownLicense
+	}
+	return license
+}
+
+// LicenseBreakdown counts the package versions in the graph by license. Versions without a license are counted under
+// UnknownLicense.
+func LicenseBreakdown(pg *PackageGraph) map[string]int {
+	breakdown := make(map[string]int)
+	for _, node := range pg.Nodes {
+		if node.stringID != "" {
+			breakdown[licenseName(node.License)]++
+		}
+	}
+	return breakdown
+}
+
+// LicenseCounts is LicenseBreakdown sorted like SortedCounts, most common license first.
+func LicenseCounts(pg *PackageGraph) []NameCount {
+	return SortedCounts(LicenseBreakdown(pg))
+}
+
+// FindDependentsWithLicense returns the transitive dependencies of root whose license is one of licenses, such as the
+// GPL variants, so that the path by which they are pulled in can be reported. The licenses are compared
+// case-insensitively and UnknownLicense matches the versions without a license. The result is in increasing ID order,
+// and the bool is false if root is not part of the graph.
+func FindDependentsWithLicense(pg *PackageGraph, root NameVersion, licenses []string) ([]NodeInfo, bool) {
+	dependencies, ok := pg.Dependencies(root, -1)
+	if !ok {
+		return nil, false
+	}
+	wanted := make(map[string]bool, len(licenses))
+	for _, license := range licenses {
+		wanted[strings.ToLower(licenseName(license))] = true
+	}
+	var result []NodeInfo
+	for _, dependency := range dependencies {
+		if wanted[strings.ToLower(licenseName(dependency.License))] {
+			result = append(result, dependency)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].id < result[j].id })
+	return result, true
+}
@@ -0,0 +1,70 @@
+package graph
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestLicense(t *testing.T) {
+	t.Run("Decodes every license form", func(t *testing.T) {
+		for input, expected := range map[string]string{
+			`"MIT"`:                               "MIT",
+			`{"type": "ISC", "url": "https://x"}`: "ISC",
+			`[{"type": "MIT"}, {"type": "Apache-2.0"}]`: "(MIT OR Apache-2.0)",
+			`[{"type": "BSD"}]`:                         "BSD",
+			`42`:                                        "",
+		} {
+			var versionInfo VersionInfo
+			if err := json.Unmarshal([]byte(`{"timestamp": "2020-01-01", "license": `+input+`}`), &versionInfo); err != nil {
+				t.Fatalf("Expected no error for %s, got %v", input, err)
+			}
+			if string(versionInfo.License) != expected {
+				t.Errorf("Expected %q for %s, got %q", expected, input, versionInfo.License)
+			}
+		}
+	})
+
+	packagesInfo := []PackageInfo{
+		{Name: "App", Versions: map[string]VersionInfo{
+			"1.0.0": {Timestamp: "2022-01-01T00:00:00", License: "MIT", Dependencies: map[string]string{"A": "1.0.0", "B": "1.0.0"}},
+		}},
+		{Name: "A", Versions: map[string]VersionInfo{
+			"1.0.0": {Timestamp: "2021-01-01T00:00:00", License: "GPL-3.0", Dependencies: map[string]string{"C": "1.0.0"}},
+		}},
+		{Name: "B", Versions: map[string]VersionInfo{
+			"1.0.0": {Timestamp: "2021-01-01T00:00:00", License: "MIT", Dependencies: map[string]string{}},
+		}},
+		{Name: "C", Versions: map[string]VersionInfo{
+			"1.0.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{}},
+		}},
+	}
+	pg := NewPackageGraph(&packagesInfo, false)
+
+	t.Run("Carries the license to the nodes", func(t *testing.T) {
+		if info, _ := pg.FindNode(NameVersion{Name: "A", Version: "1.0.0"}); info.License != "GPL-3.0" {
+			t.Errorf("Expected GPL-3.0, got %q", info.License)
+		}
+	})
+
+	t.Run("Counts the versions by license", func(t *testing.T) {
+		breakdown := LicenseBreakdown(pg)
+		if len(breakdown) != 3 || breakdown["MIT"] != 2 || breakdown["GPL-3.0"] != 1 || breakdown[UnknownLicense] != 1 {
+			t.Errorf("Expected 2 MIT, 1 GPL-3.0 and 1 unknown, got %v", breakdown)
+		}
+		expected := []NameCount{{"MIT", 2}, {"GPL-3.0", 1}, {UnknownLicense, 1}}
+		if counts := LicenseCounts(pg); !reflect.DeepEqual(counts, expected) {
+			t.Errorf("Expected %v, got %v", expected, counts)
+		}
+	})
+
+	t.Run("Finds the transitive dependencies with a license", func(t *testing.T) {
+		found, ok := FindDependentsWithLicense(pg, NameVersion{Name: "App", Version: "1.0.0"}, []string{"gpl-3.0", "GPL-2.0", UnknownLicense})
+		if !ok || len(found) != 2 || found[0].Name != "A" || found[1].Name != "C" {
+			t.Errorf("Expected A and C, got %v", found)
+		}
+		if _, ok := FindDependentsWithLicense(pg, NameVersion{Name: "Missing", Version: "1.0.0"}, nil); ok {
+			t.Error("Expected an unknown root not to be found")
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package graph
+
+import "time"
+
+// Lifespan is the period over which a version was depended on: the release times of its earliest and latest direct
+// dependents.
+type Lifespan struct {
+	// First and Last are the release times of the earliest and the latest dependent whose timestamp can be parsed, and
+	// zero if there is none.
+	First time.Time
+	Last  time.Time
+	// Count is the number of direct dependents, including those without a parseable timestamp.
+	Count int
+}
+
+// DependentLifespan computes the lifespan of every version of the graph, from the timestamps of the ends of its edges
+// in one pass over them, and of the nodes themselves to parse every timestamp once. The versions without dependents
+// have a lifespan with a zero Count; HasDependents filters them out. Like the metrics, it sees the edges of the graph
+// as they are, so on a graph with lazy edges only the edges created so far are taken into account.
+func DependentLifespan(pg *PackageGraph) map[int64]Lifespan {
+	released := make(map[int64]time.Time, len(pg.Nodes))
+	lifespans := make(map[int64]Lifespan, len(pg.Nodes))
+	for _, node := range pg.Nodes {
+		if node.stringID == "" {
+			continue
+		}
+		if t, err := ParseTimestamp(node.Timestamp); err == nil {
+			released[node.id] = t.UTC()
+		}
+		lifespans[node.id] = Lifespan{}
+	}
+	edges := pg.Graph.Edges()
+	for edges.Next() {
+		edge := edges.Edge()
+		dependent, dependency := pg.DependencyEdge(edge.From().ID(), edge.To().ID())
+		lifespan := lifespans[dependency]
+		lifespan.Count++
+		if t, ok := released[dependent]; ok {
+			if lifespan.First.IsZero() || t.Before(lifespan.First) {
+				lifespan.First = t
+			}
+			if t.After(lifespan.Last) {
+				lifespan.Last = t
+			}
+		}
+		lifespans[dependency] = lifespan
+	}
+	return lifespans
+}
+
+// HasDependents returns a filter of the node IDs whose lifespan has at least one dependent, for the include argument
+// of the exporters.
+func HasDependents(lifespans map[int64]Lifespan) func(id int64) bool {
+	return func(id int64) bool {
+		return lifespans[id].Count > 0
+	}
+}
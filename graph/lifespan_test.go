@@ -0,0 +1,45 @@
+package graph
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDependentLifespan(t *testing.T) {
+	version := func(timestamp string, dependencies map[string]string) map[string]VersionInfo {
+		return map[string]VersionInfo{"1.0.0": {Timestamp: timestamp, Dependencies: dependencies}}
+	}
+	packagesInfo := []PackageInfo{
+		{Name: "Lib", Versions: version("2019-01-01T00:00:00", nil)},
+		{Name: "A", Versions: version("2020-03-01T00:00:00", map[string]string{"Lib": "1.0.0"})},
+		{Name: "B", Versions: version("2021-06-01T00:00:00", map[string]string{"Lib": "1.0.0", "A": "1.0.0"})},
+		{Name: "C", Versions: version("unknown", map[string]string{"Lib": "1.0.0", "A": "1.0.0"})},
+		{Name: "D", Versions: version("", map[string]string{"C": "1.0.0"})},
+	}
+	date := func(year int, month time.Month) time.Time { return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC) }
+	expected := map[string]Lifespan{
+		"Lib": {First: date(2020, 3), Last: date(2021, 6), Count: 3},
+		"A":   {First: date(2021, 6), Last: date(2021, 6), Count: 2},
+		"B":   {},
+		"C":   {Count: 1},
+		"D":   {},
+	}
+	for _, opts := range [][]Option{nil, {WithEdgeDirection(DependencyToDependent)}} {
+		infos := append([]PackageInfo(nil), packagesInfo...)
+		pg := NewPackageGraph(&infos, false, opts...)
+		lifespans := DependentLifespan(pg)
+		if len(lifespans) != len(expected) {
+			t.Errorf("Expected a lifespan of every version, got %v", lifespans)
+		}
+		hasDependents := HasDependents(lifespans)
+		for name, lifespan := range expected {
+			node, _ := pg.FindNode(NameVersion{Name: name, Version: "1.0.0"})
+			if actual := lifespans[node.ID()]; actual != lifespan {
+				t.Errorf("Expected the lifespan %+v of %s, got %+v", lifespan, name, actual)
+			}
+			if hasDependents(node.ID()) != (lifespan.Count > 0) {
+				t.Errorf("Expected %s to have dependents only if it is depended on", name)
+			}
+		}
+	}
+}
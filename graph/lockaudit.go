@@ -0,0 +1,345 @@
+package graph
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LockFormat is the format of a lockfile read by AuditLockfile.
+type LockFormat int
+
+const (
+	// NpmLock is a package-lock.json or npm-shrinkwrap.json with lockfileVersion 2 or 3, of which the "packages"
+	// object is read.
+	NpmLock LockFormat = iota
+	// YarnLock is a yarn.lock of yarn classic, the "yarn lockfile v1".
+	YarnLock
+)
+
+// AuditEntry is the audit of a single package version pinned by a lockfile.
+type AuditEntry struct {
+	// Location is the install location for npm, such as "node_modules/a/node_modules/b", and the line of
+	// specifiers for yarn, such as `a@^1.0.0, a@^1.2.0`.
+	Location string `json:"location"`
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	// Ranges are the ranges that the dependents requested and that the pinned version was chosen for.
+	Ranges []string `json:"ranges,omitempty"`
+	// UnknownPackage and UnknownVersion are set if the package or the pinned version is not part of the graph. The
+	// remaining fields are only filled for known packages.
+	UnknownPackage bool `json:"unknownPackage,omitempty"`
+	UnknownVersion bool `json:"unknownVersion,omitempty"`
+	// Highest is the highest version released at the date of the audit that satisfies all the ranges, or empty if
+	// there is none, for example because a range is not a semver range. NotHighest is set if it is not the pinned
+	// version.
+	Highest    string `json:"highest,omitempty"`
+	NotHighest bool   `json:"notHighest,omitempty"`
+	// Deprecated is the deprecation message of the pinned version.
+	Deprecated string `json:"deprecated,omitempty"`
+	// Latest is the highest release at the date of the audit, and Behind the number of releases up to then that are
+	// higher than the pinned version.
+	Latest string `json:"latest,omitempty"`
+	Behind int    `json:"behind"`
+}
+
+// AuditSummary counts the entries of an AuditReport by finding.
+type AuditSummary struct {
+	Entries        int `json:"entries"`
+	UnknownPackage int `json:"unknownPackage"`
+	UnknownVersion int `json:"unknownVersion"`
+	NotHighest     int `json:"notHighest"`
+	Deprecated     int `json:"deprecated"`
+	Behind         int `json:"behind"`
+}
+
+// AuditReport is the result of AuditLockfile.
+type AuditReport struct {
+	Entries []AuditEntry `json:"entries"`
+	Summary AuditSummary `json:"summary"`
+}
+
+// lockEntry is a package version pinned by a lockfile, with the ranges it was chosen for.
+type lockEntry struct {
+	location string
+	name     string
+	version  string
+	ranges   []string
+}
+
+// AuditLockfile checks every package version pinned by the lockfile in r against the graph: whether it is part of the
+// graph, whether it was the highest version satisfying its ranges at the given date, whether it is deprecated and how
+// many releases it is behind the latest one. The date is normally the date the lockfile was written; if it is zero,
+// every version of the graph is considered. The versions are compared with the settings of the graph, including
+// WithPreferNonDeprecated.
+//
+// The root of an npm lockfile, linked workspaces and the aliased names of "npm:" ranges are handled; ranges that are
+// not registry ranges, such as git URLs, leave Highest empty. Packages that are not part of the graph are reported by
+// their entry; only an unreadable lockfile is an error. The entries are sorted by location.
+func AuditLockfile(pg *PackageGraph, r io.Reader, format LockFormat, at time.Time) (AuditReport, error) {
+	var entries []lockEntry
+	var err error
+	switch format {
+	case NpmLock:
+		entries, err = readNpmLock(r)
+	case YarnLock:
+		entries, err = readYarnLock(r)
+	default:
+		err = fmt.Errorf("unknown lockfile format %d", format)
+	}
+	if err != nil {
+		return AuditReport{}, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].location < entries[j].location })
+
+	resolver := pg.queryResolver()
+	report := AuditReport{Entries: make([]AuditEntry, 0, len(entries))}
+	for _, entry := range entries {
+		audit := pg.auditEntry(resolver, entry, at)
+		report.Entries = append(report.Entries, audit)
+		report.Summary.Entries++
+		if audit.UnknownPackage {
+			report.Summary.UnknownPackage++
+		}
+		if audit.UnknownVersion {
+			report.Summary.UnknownVersion++
+		}
+		if audit.NotHighest {
+			report.Summary.NotHighest++
+		}
+		if audit.Deprecated != "" {
+			report.Summary.Deprecated++
+		}
+		if audit.Behind > 0 {
+			report.Summary.Behind++
+		}
+	}
+	return report, nil
+}
+
+// auditEntry compares a pinned version with the graph.
+func (pg *PackageGraph) auditEntry(resolver *edgeResolver, entry lockEntry, at time.Time) AuditEntry {
+	key := pg.packageKey("", entry.name)
+	audit := AuditEntry{Location: entry.location, Name: entry.name, Version: entry.version, Ranges: entry.ranges}
+	versions, ok := resolver.versions[key]
+	if !ok {
+		audit.UnknownPackage = true
+		return audit
+	}
+	info, ok := pg.lookup(key.version(entry.version))
+	if ok {
+		audit.Deprecated = info.Deprecated
+	} else {
+		audit.UnknownVersion = true
+	}
+	if len(entry.ranges) > 0 {
+		if id, outcome := resolver.highestAt(key, at, entry.ranges...); outcome == resolved {
+			highest, _ := pg.Node(id)
+			audit.Highest = highest.Version
+			audit.NotHighest = compareVersions(highest.Version, entry.version) != 0
+		}
+	}
+
+	pinned := indexedVersion{version: entry.version}
+	pinned.parsed, _ = parseVersion(entry.version, pg.options.TruncateFourPartVersions)
+	var latest *indexedVersion
+	for i := range versions {
+		version := &versions[i]
+		if version.parsed == nil || version.parsed.Prerelease() != "" || !releasedBy(resolver, key, version, at) {
+			continue
+		}
+		if compareIndexed(version, &pinned) > 0 {
+			audit.Behind++
+		}
+		if compareIndexed(version, latest) > 0 {
+			latest = version
+		}
+	}
+	if latest != nil {
+		audit.Latest = latest.version
+	}
+	return audit
+}
+
+// releasedBy reports whether the version was released at or before at, which every version is if at is zero.
+func releasedBy(resolver *edgeResolver, key PackageKey, version *indexedVersion, at time.Time) bool {
+	if at.IsZero() {
+		return true
+	}
+	info, _ := resolver.find(key.version(version.version))
+	released, err := ParseTimestamp(info.Timestamp)
+	return err == nil && !released.After(at)
+}
+
+// aliasedRange splits an "npm:name@range" alias into the aliased package and its range. Other ranges are returned
+// with the given name.
+func aliasedRange(name, dependencyRange string) (string, string) {
+	if !strings.HasPrefix(dependencyRange, "npm:") {
+		return name, dependencyRange
+	}
+	spec := strings.TrimPrefix(dependencyRange, "npm:")
+	if at := strings.LastIndex(spec, "@"); at > 0 {
+		return spec[:at], spec[at+1:]
+	}
+	return spec, "*"
+}
+
+// npmLockPackage is an entry of the "packages" object of a package-lock.json.
+type npmLockPackage struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Link                 bool              `json:"link"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+}
+
+// readNpmLock reads the packages of a package-lock.json, resolving the ranges of every package to the location that
+// Node's module resolution loads them from.
+func readNpmLock(r io.Reader) ([]lockEntry, error) {
+	var lock struct {
+		LockfileVersion int                       `json:"lockfileVersion"`
+		Packages        map[string]npmLockPackage `json:"packages"`
+	}
+	if err := json.NewDecoder(r).Decode(&lock); err != nil {
+		return nil, fmt.Errorf("decoding package-lock.json: %w", err)
+	}
+	if lock.LockfileVersion < 2 || lock.Packages == nil {
+		return nil, fmt.Errorf("package-lock.json has lockfileVersion %d, expected 2 or 3", lock.LockfileVersion)
+	}
+
+	entries := make(map[string]*lockEntry)
+	for location, locked := range lock.Packages {
+		if location == "" || locked.Link || locked.Version == "" {
+			continue
+		}
+		name := locked.Name
+		if name == "" {
+			name = location[strings.LastIndex(location, "node_modules/")+len("node_modules/"):]
+		}
+		entries[location] = &lockEntry{location: location, name: name, version: locked.Version}
+	}
+	for location, locked := range lock.Packages {
+		for _, dependencies := range []map[string]string{locked.Dependencies, locked.DevDependencies, locked.PeerDependencies, locked.OptionalDependencies} {
+			for name, dependencyRange := range dependencies {
+				entry := lookupNpmLocation(entries, location, name)
+				if entry == nil {
+					continue
+				}
+				_, dependencyRange = aliasedRange(name, dependencyRange)
+				entry.ranges = append(entry.ranges, dependencyRange)
+			}
+		}
+	}
+
+	result := make([]lockEntry, 0, len(entries))
+	for _, entry := range entries {
+		sort.Strings(entry.ranges)
+		entry.ranges = uniqueStrings(entry.ranges)
+		result = append(result, *entry)
+	}
+	return result, nil
+}
+
+// lookupNpmLocation returns the entry that a package at location loads for the name, looking in its own node_modules
+// directory first and then in those of the packages it is installed below.
+func lookupNpmLocation(entries map[string]*lockEntry, location, name string) *lockEntry {
+	for {
+		candidate := "node_modules/" + name
+		if location != "" {
+			candidate = location + "/" + candidate
+		}
+		if entry, ok := entries[candidate]; ok {
+			return entry
+		}
+		if location == "" {
+			return nil
+		}
+		if i := strings.LastIndex(location, "/node_modules/"); i >= 0 {
+			location = location[:i]
+		} else {
+			location = ""
+		}
+	}
+}
+
+// readYarnLock reads the entries of a yarn.lock of yarn classic. Every entry starts with a line of specifiers such as
+// `"@babel/core@^7.0.0", "@babel/core@^7.1.0":`, followed by indented fields, of which only the version is used.
+func readYarnLock(r io.Reader) ([]lockEntry, error) {
+	var entries []lockEntry
+	var current *lockEntry
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), " \r")
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if !strings.HasPrefix(text, " ") {
+			if strings.HasPrefix(text, "__metadata") {
+				return nil, errors.New("yarn.lock is written by yarn 2 or later, expected yarn classic")
+			}
+			if !strings.HasSuffix(text, ":") {
+				return nil, fmt.Errorf("yarn.lock line %d: expected a list of specifiers", line)
+			}
+			entries = append(entries, lockEntry{location: strings.TrimSuffix(text, ":")})
+			current = &entries[len(entries)-1]
+			for _, specifier := range strings.Split(current.location, ",") {
+				specifier = unquoteYarn(strings.TrimSpace(specifier))
+				if specifier == "" {
+					return nil, fmt.Errorf("yarn.lock line %d: empty specifier", line)
+				}
+				// The name ends at the first '@' that does not start a scope.
+				at := strings.Index(specifier[1:], "@") + 1
+				if at <= 0 {
+					return nil, fmt.Errorf("yarn.lock line %d: invalid specifier %q", line, specifier)
+				}
+				name, dependencyRange := aliasedRange(specifier[:at], specifier[at+1:])
+				current.name = name
+				current.ranges = append(current.ranges, dependencyRange)
+			}
+			continue
+		}
+		if current == nil {
+			return nil, fmt.Errorf("yarn.lock line %d: field outside of an entry", line)
+		}
+		if field := strings.TrimSpace(text); strings.HasPrefix(field, "version ") && strings.HasPrefix(text, "  ") && !strings.HasPrefix(text, "   ") {
+			current.version = unquoteYarn(strings.TrimSpace(strings.TrimPrefix(field, "version ")))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading yarn.lock: %w", err)
+	}
+	for i := range entries {
+		sort.Strings(entries[i].ranges)
+		entries[i].ranges = uniqueStrings(entries[i].ranges)
+	}
+	return entries, nil
+}
+
+// unquoteYarn removes the double quotes around a string of a yarn.lock, if any.
+func unquoteYarn(s string) string {
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		return unquoted
+	}
+	return s
+}
+
+// uniqueStrings removes the adjacent duplicates from a sorted slice.
+func uniqueStrings(sorted []string) []string {
+	unique := sorted[:0]
+	for i, s := range sorted {
+		if i == 0 || s != sorted[i-1] {
+			unique = append(unique, s)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,126 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const auditTestNpmLock = `{
+  "name": "App",
+  "version": "1.0.0",
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"name": "App", "version": "1.0.0", "dependencies": {"A": "^1.0.0", "Alias": "npm:A@^1.1.0", "B": "^1.0.0", "Missing": "^1.0.0"}},
+    "node_modules/A": {"version": "1.0.0"},
+    "node_modules/Alias": {"name": "A", "version": "1.1.0"},
+    "node_modules/B": {"version": "1.0.0", "dependencies": {"A": "^2.0.0", "C": "^1.0.0"}},
+    "node_modules/B/node_modules/A": {"version": "2.0.0"},
+    "node_modules/C": {"version": "1.0.0", "dependencies": {"B": "^1.0.0"}},
+    "node_modules/Missing": {"version": "1.0.0"},
+    "node_modules/Workspace": {"resolved": "packages/workspace", "link": true}
+  }
+}`
+
+const auditTestYarnLock = `# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.
+# yarn lockfile v1
+
+
+A@^1.0.0, "A@~1.0.0":
+  version "1.0.0"
+  resolved "https://registry.yarnpkg.com/A/-/A-1.0.0.tgz"
+
+"A@^2.0.0":
+  version "2.0.0"
+
+"Alias@npm:A@^1.1.0":
+  version "1.1.0"
+
+"@scope/x@^1.0.0":
+  version "1.0.0"
+  dependencies:
+    A "^1.0.0"
+`
+
+func createAuditTestGraph() *PackageGraph {
+	packagesInfo := testPackages("lockfile")
+	oldest := packagesInfo[1].Versions["1.0.0"]
+	oldest.Deprecated = "use 1.1.0"
+	packagesInfo[1].Versions["1.0.0"] = oldest
+	return NewPackageGraph(&packagesInfo, false)
+}
+
+func auditEntries(report AuditReport) map[string]AuditEntry {
+	entries := make(map[string]AuditEntry, len(report.Entries))
+	for _, entry := range report.Entries {
+		entries[entry.Location] = entry
+	}
+	return entries
+}
+
+func TestAuditLockfile(t *testing.T) {
+	pg := createAuditTestGraph()
+
+	t.Run("Audits the packages of an npm lockfile", func(t *testing.T) {
+		report, err := AuditLockfile(pg, strings.NewReader(auditTestNpmLock), NpmLock, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries := auditEntries(report)
+		if len(entries) != 6 {
+			t.Errorf("Expected 6 entries without the root and the link, got %v", report.Entries)
+		}
+		if a := entries["node_modules/A"]; !a.NotHighest || a.Highest != "1.1.0" || a.Deprecated != "use 1.1.0" || a.Latest != "2.0.0" || a.Behind != 2 {
+			t.Errorf("Expected A@1.0.0 to be deprecated, not the highest and 2 releases behind, got %+v", a)
+		}
+		if nested := entries["node_modules/B/node_modules/A"]; nested.NotHighest || !equalStrings(nested.Ranges, []string{"^2.0.0"}) || nested.Behind != 0 {
+			t.Errorf("Expected the nested A@2.0.0 to be up to date, got %+v", nested)
+		}
+		if alias := entries["node_modules/Alias"]; alias.Name != "A" || alias.NotHighest || !equalStrings(alias.Ranges, []string{"^1.1.0"}) {
+			t.Errorf("Expected the alias to be audited as A, got %+v", alias)
+		}
+		if missing := entries["node_modules/Missing"]; !missing.UnknownPackage {
+			t.Errorf("Expected Missing to be reported as unknown, got %+v", missing)
+		}
+		expected := AuditSummary{Entries: 6, UnknownPackage: 1, NotHighest: 1, Deprecated: 1, Behind: 2}
+		if report.Summary != expected {
+			t.Errorf("Expected the summary %+v, got %+v", expected, report.Summary)
+		}
+	})
+
+	t.Run("Only considers the versions released at the date", func(t *testing.T) {
+		report, _ := AuditLockfile(pg, strings.NewReader(auditTestNpmLock), NpmLock, time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC))
+		if a := auditEntries(report)["node_modules/A"]; a.NotHighest || a.Latest != "1.0.0" || a.Behind != 0 {
+			t.Errorf("Expected A@1.0.0 to be up to date in 2020, got %+v", a)
+		}
+	})
+
+	t.Run("Audits the entries of a yarn lockfile", func(t *testing.T) {
+		report, err := AuditLockfile(pg, strings.NewReader(auditTestYarnLock), YarnLock, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries := auditEntries(report)
+		if a := entries[`A@^1.0.0, "A@~1.0.0"`]; a.Version != "1.0.0" || !equalStrings(a.Ranges, []string{"^1.0.0", "~1.0.0"}) || a.NotHighest {
+			t.Errorf("Expected A@1.0.0 to be the highest version satisfying both ranges, got %+v", a)
+		}
+		if alias := entries[`"Alias@npm:A@^1.1.0"`]; alias.Name != "A" || alias.Version != "1.1.0" || alias.UnknownPackage {
+			t.Errorf("Expected the alias to be audited as A, got %+v", alias)
+		}
+		if scoped := entries[`"@scope/x@^1.0.0"`]; scoped.Name != "@scope/x" || !scoped.UnknownPackage {
+			t.Errorf("Expected @scope/x to be reported as unknown, got %+v", scoped)
+		}
+		if report.Summary.Entries != 4 {
+			t.Errorf("Expected 4 entries, got %d", report.Summary.Entries)
+		}
+	})
+
+	t.Run("Rejects unsupported lockfiles", func(t *testing.T) {
+		if _, err := AuditLockfile(pg, strings.NewReader(`{"lockfileVersion": 1, "dependencies": {}}`), NpmLock, time.Time{}); err == nil {
+			t.Error("Expected an error for lockfileVersion 1")
+		}
+		if _, err := AuditLockfile(pg, strings.NewReader("__metadata:\n  version: 6\n"), YarnLock, time.Time{}); err == nil {
+			t.Error("Expected an error for a yarn 2 lockfile")
+		}
+	})
+}
@@ -0,0 +1,236 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Lockfile is the simulated installation of a package version in the layout of an npm package-lock.json with
+// lockfileVersion 3, so that it can be compared with real lockfiles. Packages maps the install location, such as
+// "node_modules/a" or "node_modules/a/node_modules/b", to the installed version, with the root under "".
+type Lockfile struct {
+	Name            string                   `json:"name"`
+	Version         string                   `json:"version"`
+	LockfileVersion int                      `json:"lockfileVersion"`
+	Packages        map[string]LockedPackage `json:"packages"`
+	// Unresolved lists the dependencies that could not be installed, which npm would report as an error.
+	Unresolved []UnresolvedLock `json:"unresolved,omitempty"`
+}
+
+// LockedPackage is an installed package version of a Lockfile, with the ranges it requested for its dependencies.
+type LockedPackage struct {
+	// Name is only set for the root, as for the other packages it is part of the location.
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version"`
+	// Dev is set for the packages that are only installed for the development dependencies of the root.
+	Dev                  bool              `json:"dev,omitempty"`
+	Dependencies         map[string]string `json:"dependencies,omitempty"`
+	DevDependencies      map[string]string `json:"devDependencies,omitempty"`
+	PeerDependencies     map[string]string `json:"peerDependencies,omitempty"`
+	OptionalDependencies map[string]string `json:"optionalDependencies,omitempty"`
+}
+
+// UnresolvedLock is a dependency that ResolveLockfile could not install.
+type UnresolvedLock struct {
+	// Location is the install location of the package that declares the dependency.
+	Location string `json:"location"`
+	Name     string `json:"name"`
+	Range    string `json:"range"`
+	Reason   string `json:"reason"`
+}
+
+// lockNode is a package version installed at a location of the simulated node_modules tree.
+type lockNode struct {
+	location string
+	info     NodeInfo
+	parent   *lockNode
+	// children holds the packages installed in the node_modules directory of the package.
+	children map[string]*lockNode
+	depth    int
+}
+
+// lookup finds the package that Node's module resolution loads for the name from within the package.
+func (node *lockNode) lookup(name string) *lockNode {
+	for ancestor := node; ancestor != nil; ancestor = ancestor.parent {
+		if child, ok := ancestor.children[name]; ok {
+			return child
+		}
+	}
+	return nil
+}
+
+// within reports whether the package is installed at or below the location of ancestor.
+func (node *lockNode) within(ancestor *lockNode) bool {
+	for ; node != nil; node = node.parent {
+		if node == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// lockRequirement is the package from which a dependency was resolved.
+type lockRequirement struct {
+	from, to *lockNode
+	dev      bool
+}
+
+// ResolveLockfile simulates installing root at the given time with npm, returning the resulting Lockfile. Every range
+// resolves to the highest satisfying version released at or before at, as with ResolveHighest regardless of the
+// resolution of the graph; if at is zero the release times are ignored. The dependency classes of the graph are
+// installed for the root, and the same classes except Development for every other package.
+//
+// The packages are placed like npm does: breadth-first and as close to the root as possible. A package that an
+// already installed version satisfies reuses it, and a conflicting version is nested below the nearest package that
+// cannot see the installed one. A package is not placed where it would change the version that an already resolved
+// dependency loads. A version that would be nested below itself is reported as unresolved, which keeps conflicting
+// cycles from nesting without end. The error is only returned if root is not part of the graph.
+func ResolveLockfile(pg *PackageGraph, root NameVersion, at time.Time) (*Lockfile, error) {
+	rootInfo, ok := pg.FindNode(root)
+	if !ok {
+		return nil, fmt.Errorf("%s is not part of the graph", root)
+	}
+	resolver := pg.queryResolver()
+	lockfile := &Lockfile{Name: rootInfo.Name, Version: rootInfo.Version, LockfileVersion: 3, Packages: make(map[string]LockedPackage)}
+
+	rootNode := &lockNode{info: rootInfo, children: make(map[string]*lockNode)}
+	requirements := make(map[string][]lockRequirement)
+	var installed []*lockNode
+	queue := []*lockNode{rootNode}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		installed = append(installed, node)
+		versionInfo, _ := pg.VersionInfo(node.info.NameVersion())
+		locked := LockedPackage{Version: node.info.Version}
+		if node == rootNode {
+			locked.Name = node.info.Name
+		}
+
+		for _, class := range pg.options.DependencyClasses {
+			if class == Development && node != rootNode {
+				continue
+			}
+			dependencies := versionInfo.DependenciesOf(class)
+			if len(dependencies) > 0 {
+				locked.setDependencies(class, dependencies)
+			}
+			names := make([]string, 0, len(dependencies))
+			for name := range dependencies {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				dependencyRange := dependencies[name]
+				requirement := lockRequirement{from: node, dev: class == Development}
+				if found := node.lookup(name); found != nil && resolver.resolveRangeAgainst(dependencyRange, found.info) {
+					requirement.to = found
+					requirements[name] = append(requirements[name], requirement)
+					continue
+				}
+				if _, taken := node.children[name]; taken {
+					// The package declares the name in several classes with ranges that no single version satisfies.
+					lockfile.Unresolved = append(lockfile.Unresolved, UnresolvedLock{node.location, name, dependencyRange, "conflicting ranges"})
+					continue
+				}
+				id, outcome := resolver.highestAt(NewPackageKey(node.info.Ecosystem, name), at, dependencyRange)
+				if outcome != resolved {
+					lockfile.Unresolved = append(lockfile.Unresolved, UnresolvedLock{node.location, name, dependencyRange, outcome.String()})
+					continue
+				}
+				info, _ := pg.Node(id)
+				if nestsBelowItself(node, id) {
+					lockfile.Unresolved = append(lockfile.Unresolved, UnresolvedLock{node.location, name, dependencyRange, "cyclic conflict"})
+					continue
+				}
+				target := placement(node, name, requirements[name])
+				child := &lockNode{
+					location: strings.TrimPrefix(target.location+"/node_modules/"+name, "/"),
+					info:     info,
+					parent:   target,
+					children: make(map[string]*lockNode),
+					depth:    target.depth + 1,
+				}
+				target.children[name] = child
+				requirement.to = child
+				requirements[name] = append(requirements[name], requirement)
+				queue = append(queue, child)
+			}
+		}
+		lockfile.Packages[node.location] = locked
+	}
+
+	// The packages that the root needs without its development dependencies are installed for production.
+	production := map[*lockNode]bool{rootNode: true}
+	for changed := true; changed; {
+		changed = false
+		for _, nameRequirements := range requirements {
+			for _, requirement := range nameRequirements {
+				if production[requirement.from] && !requirement.dev && !production[requirement.to] {
+					production[requirement.to] = true
+					changed = true
+				}
+			}
+		}
+	}
+	for _, node := range installed {
+		if !production[node] {
+			locked := lockfile.Packages[node.location]
+			locked.Dev = true
+			lockfile.Packages[node.location] = locked
+		}
+	}
+	return lockfile, nil
+}
+
+// setDependencies stores the requested ranges of the class.
+func (locked *LockedPackage) setDependencies(class DependencyClass, dependencies map[string]string) {
+	switch class {
+	case Development:
+		locked.DevDependencies = dependencies
+	case Peer:
+		locked.PeerDependencies = dependencies
+	case Optional:
+		locked.OptionalDependencies = dependencies
+	default:
+		locked.Dependencies = dependencies
+	}
+}
+
+// nestsBelowItself reports whether the version with the given ID is the package itself or one of the packages it is
+// installed below. The root is not installed in a node_modules directory, so a copy of it can be installed below it.
+func nestsBelowItself(node *lockNode, id int64) bool {
+	for ; node.parent != nil; node = node.parent {
+		if node.info.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// placement returns the package in whose node_modules directory a new version of the named dependency of node is
+// installed: the one closest to the root that node can see, that has no version of the name yet and below which no
+// resolved dependency loads a version of the name from further up.
+func placement(node *lockNode, name string, requirements []lockRequirement) *lockNode {
+	target := node
+	for target.parent != nil {
+		parent := target.parent
+		if _, taken := parent.children[name]; taken {
+			break
+		}
+		shadows := false
+		for _, requirement := range requirements {
+			if requirement.from.within(parent) && requirement.to.depth < parent.depth+1 {
+				shadows = true
+				break
+			}
+		}
+		if shadows {
+			break
+		}
+		target = parent
+	}
+	return target
+}
@@ -0,0 +1,66 @@
+package graph
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveLockfile(t *testing.T) {
+	packagesInfo := testPackages("lockfile")
+	pg := NewPackageGraph(&packagesInfo, false, WithDependencyClasses(Runtime, Development))
+
+	t.Run("Hoists the packages and nests the conflicting versions", func(t *testing.T) {
+		lockfile, err := ResolveLockfile(pg, NameVersion{Name: "App", Version: "1.0.0"}, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := map[string]string{
+			"":                              "1.0.0",
+			"node_modules/A":                "1.1.0",
+			"node_modules/B":                "1.0.0",
+			"node_modules/B/node_modules/A": "2.0.0",
+			"node_modules/C":                "1.0.0",
+			"node_modules/T":                "1.0.0",
+			"node_modules/D":                "1.0.0",
+		}
+		if len(lockfile.Packages) != len(expected) {
+			t.Errorf("Expected %d packages, got %v", len(expected), lockfile.Packages)
+		}
+		for location, version := range expected {
+			if locked, ok := lockfile.Packages[location]; !ok || locked.Version != version {
+				t.Errorf("Expected version %s at %q, got %+v", version, location, locked)
+			}
+		}
+		if len(lockfile.Unresolved) != 0 {
+			t.Errorf("Expected every dependency to resolve, got %v", lockfile.Unresolved)
+		}
+		if root := lockfile.Packages[""]; root.Name != "App" || root.Dependencies["A"] != "^1.0.0" || root.DevDependencies["T"] != "1.0.0" {
+			t.Errorf("Expected the root with its requested ranges, got %+v", root)
+		}
+	})
+
+	t.Run("Marks the packages only needed for development", func(t *testing.T) {
+		lockfile, _ := ResolveLockfile(pg, NameVersion{Name: "App", Version: "1.0.0"}, time.Time{})
+		for location, dev := range map[string]bool{"node_modules/T": true, "node_modules/D": true, "node_modules/C": false, "node_modules/A": false} {
+			if lockfile.Packages[location].Dev != dev {
+				t.Errorf("Expected dev %v at %q", dev, location)
+			}
+		}
+	})
+
+	t.Run("Only installs versions released before the date", func(t *testing.T) {
+		lockfile, _ := ResolveLockfile(pg, NameVersion{Name: "App", Version: "1.0.0"}, time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC))
+		if locked := lockfile.Packages["node_modules/A"]; locked.Version != "1.0.0" {
+			t.Errorf("Expected A@1.0.0, got %+v", locked)
+		}
+		if len(lockfile.Unresolved) != 1 || lockfile.Unresolved[0] != (UnresolvedLock{"node_modules/B", "A", "^2.0.0", "no satisfying version"}) {
+			t.Errorf("Expected the range of B on A to be unresolved, got %v", lockfile.Unresolved)
+		}
+	})
+
+	t.Run("Rejects an unknown root", func(t *testing.T) {
+		if _, err := ResolveLockfile(pg, NameVersion{Name: "Missing", Version: "1.0.0"}, time.Time{}); err == nil {
+			t.Error("Expected an error")
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package graph
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// LogLevel is the severity of a log message.
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (level LogLevel) String() string {
+	switch level {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// Logger receives the messages and the timing events of the graph construction. The keysAndValues alternate between
+// a string key and its value, like the sugared zap logger, so an adapter only has to forward them. The timing events
+// are logged at LevelInfo with the name of the stage as message and, among others, a "duration" key holding a
+// time.Duration.
+type Logger interface {
+	Log(level LogLevel, message string, keysAndValues ...interface{})
+}
+
+// stdLogger writes the messages of at least a minimum level to a standard library logger.
+type stdLogger struct {
+	logger  *log.Logger
+	minimum LogLevel
+}
+
+// NewStdLogger returns a Logger that writes the messages of at least the minimum level to logger, as a single line
+// of the form "info edge creation duration=14m32s edges=83000000".
+func NewStdLogger(logger *log.Logger, minimum LogLevel) Logger {
+	return stdLogger{logger: logger, minimum: minimum}
+}
+
+func (l stdLogger) Log(level LogLevel, message string, keysAndValues ...interface{}) {
+	if level < l.minimum {
+		return
+	}
+	var line strings.Builder
+	line.WriteString(level.String())
+	line.WriteString(" ")
+	line.WriteString(message)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if i+1 < len(keysAndValues) {
+			fmt.Fprintf(&line, " %v=%v", keysAndValues[i], keysAndValues[i+1])
+		} else {
+			fmt.Fprintf(&line, " %v", keysAndValues[i])
+		}
+	}
+	l.logger.Print(line.String())
+}
+
+// log sends the message to the configured logger, if any.
+func (options *Options) log(level LogLevel, message string, keysAndValues ...interface{}) {
+	if options.Logger != nil {
+		options.Logger.Log(level, message, keysAndValues...)
+	}
+}
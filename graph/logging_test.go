@@ -0,0 +1,126 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type logEntry struct {
+	level   LogLevel
+	message string
+	fields  map[string]interface{}
+}
+
+// recordingLogger keeps every message, with its key-value pairs as a map.
+type recordingLogger struct {
+	entries []logEntry
+}
+
+func (logger *recordingLogger) Log(level LogLevel, message string, keysAndValues ...interface{}) {
+	fields := make(map[string]interface{})
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		fields[keysAndValues[i].(string)] = keysAndValues[i+1]
+	}
+	logger.entries = append(logger.entries, logEntry{level, message, fields})
+}
+
+func (logger *recordingLogger) find(message string) (logEntry, bool) {
+	for _, entry := range logger.entries {
+		if entry.message == message {
+			return entry, true
+		}
+	}
+	return logEntry{}, false
+}
+
+func TestLogging(t *testing.T) {
+	t.Run("Logs the timing of every stage", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		packagesInfo[0].Versions["1.0.0"].Dependencies["Unknown"] = "1.0.0"
+		packagesInfo[0].Versions["1.0.0"].Dependencies["Test"] = "not a range"
+		encoded, err := json.Marshal(packagesInfo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(t.TempDir(), "packages.json")
+		if err := os.WriteFile(path, encoded, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, workers := range []int{1, 4} {
+			logger := &recordingLogger{}
+			if _, err := OpenPackageGraph(path, false, WithLogger(logger), WithWorkers(workers)); err != nil {
+				t.Fatal(err)
+			}
+			for _, stage := range []string{"parse", "map build", "edge creation"} {
+				entry, ok := logger.find(stage)
+				if !ok {
+					t.Fatalf("Expected a %q event, got %+v", stage, logger.entries)
+				}
+				if _, ok := entry.fields["duration"].(time.Duration); !ok || entry.level != LevelInfo {
+					t.Errorf("Expected an info %q event with a duration, got %+v", stage, entry)
+				}
+			}
+			if entry, _ := logger.find("parse"); entry.fields["packages"] != len(packagesInfo) {
+				t.Errorf("Expected %d packages parsed, got %v", len(packagesInfo), entry.fields["packages"])
+			}
+			entry, _ := logger.find("edge creation")
+			if entry.fields["edges"] != 3 || entry.fields["skipped"] != 1 || entry.fields["unknownPackage"] != 1 {
+				t.Errorf("Expected 3 edges, 1 skipped and 1 unknown package with %d workers, got %+v", workers, entry.fields)
+			}
+		}
+	})
+
+	t.Run("Counts the edges created rather than the resolved declarations", func(t *testing.T) {
+		for _, opts := range [][]Option{nil, {WithSplitByClass()}, {WithWorkers(3)}} {
+			logger := &recordingLogger{}
+			packagesInfo := testPackages("weights")
+			pg := NewPackageGraph(&packagesInfo, false, append(opts, WithLogger(logger), WithDependencyClasses(Runtime, Peer))...)
+			entry, _ := logger.find("edge creation")
+			if edges := pg.Graph.Edges().Len(); entry.fields["edges"] != edges {
+				t.Errorf("Expected %d edges, got %v", edges, entry.fields["edges"])
+			}
+		}
+	})
+
+	t.Run("Does not fill the report of the caller twice", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		report := &ResolutionReport{}
+		NewPackageGraph(&packagesInfo, false, WithLogger(&recordingLogger{}), WithResolutionReport(report))
+		if report.Declarations != 1 {
+			t.Errorf("Expected 1 declaration, got %d", report.Declarations)
+		}
+	})
+
+	t.Run("Returns the parse error", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "invalid.json")
+		if err := os.WriteFile(path, []byte(`[{"name": 1}]`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := OpenPackageGraph(path, false); err == nil {
+			t.Errorf("Expected an error for invalid packages")
+		}
+		if _, err := OpenPackageGraph(filepath.Join(t.TempDir(), "missing.json"), false); err == nil {
+			t.Errorf("Expected an error for a missing file")
+		}
+	})
+
+	t.Run("Formats the standard logger output", func(t *testing.T) {
+		var buffer bytes.Buffer
+		logger := NewStdLogger(log.New(&buffer, "", 0), LevelInfo)
+		logger.Log(LevelDebug, "hidden")
+		logger.Log(LevelInfo, "edge creation", "duration", 14*time.Minute, "edges", 83)
+		if expected, got := "info edge creation duration=14m0s edges=83\n", buffer.String(); got != expected {
+			t.Errorf("Expected %q, got %q", expected, got)
+		}
+		if strings.Contains(buffer.String(), "hidden") {
+			t.Errorf("Expected messages below the minimum level to be left out")
+		}
+	})
+}
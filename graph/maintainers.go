@@ -0,0 +1,128 @@
+package graph
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// UnknownMaintainer is the maintainer under which the versions without maintainers are counted.
+const UnknownMaintainer = "unknown"
+
+// Maintainers are the maintainers of a package version. They are decoded from an array of names, or from an array of
+// objects with a "name" field as in the npm registry documents, such as [{"name": "alice", "email": "..."}]. Entries
+// without a name and other values are left out instead of failing the whole input.
+type Maintainers []string
+
+// UnmarshalJSON decodes the maintainers from any of the forms found in the input.
+func (maintainers *Maintainers) UnmarshalJSON(data []byte) error {
+	type maintainerObject struct {
+		Name string `json:"name"`
+	}
+	var entries []json.RawMessage
+	if json.Unmarshal(data, &entries) != nil {
+		*maintainers = nil
+		return nil
+	}
+	names := make(Maintainers, 0, len(entries))
+	for _, entry := range entries {
+		var name string
+		var object maintainerObject
+		switch {
+		case json.Unmarshal(entry, &name) == nil:
+		case json.Unmarshal(entry, &object) == nil:
+			name = object.Name
+		}
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	*maintainers = names
+	return nil
+}
+
+// maintainerNames returns the distinct maintainers of a version, or UnknownMaintainer if it has none.
+func maintainerNames(versionInfo VersionInfo) []string {
+	if len(versionInfo.Maintainers) == 0 {
+		return []string{UnknownMaintainer}
+	}
+	seen := make(map[string]bool, len(versionInfo.Maintainers))
+	names := make([]string, 0, len(versionInfo.Maintainers))
+	for _, name := range versionInfo.Maintainers {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// MaintainerExposure counts, for every maintainer, how many of the transitive dependencies of root they maintain:
+// the versions that a compromised account could publish into the install of root. The versions without maintainers
+// are counted under UnknownMaintainer. The result is nil if root is not part of the graph.
+func MaintainerExposure(pg *PackageGraph, root NameVersion) map[string]int {
+	dependencies, ok := pg.Dependencies(root, -1)
+	if !ok {
+		return nil
+	}
+	exposure := make(map[string]int)
+	for _, dependency := range dependencies {
+		versionInfo, _ := pg.VersionInfo(dependency.NameVersion())
+		for _, name := range maintainerNames(versionInfo) {
+			exposure[name]++
+		}
+	}
+	return exposure
+}
+
+// MaintainerExposureCounts is MaintainerExposure sorted like SortedCounts, the maintainer of the most dependencies
+// first. The result is nil if root is not part of the graph.
+func MaintainerExposureCounts(pg *PackageGraph, root NameVersion) []NameCount {
+	exposure := MaintainerExposure(pg, root)
+	if exposure == nil {
+		return nil
+	}
+	return SortedCounts(exposure)
+}
+
+// MaintainerReach is a maintainer together with the package versions they maintain and the versions that depend on
+// at least one of them, directly or transitively.
+type MaintainerReach struct {
+	Name                 string
+	Versions             int
+	TransitiveDependents int
+}
+
+// RankMaintainers ranks the maintainers of the graph by their transitive dependents, the versions that depend on a
+// version they maintain, most first and ties by name. A dependent that depends on several of their versions is
+// counted once, and their own versions are not counted. The versions without maintainers are ranked under
+// UnknownMaintainer. Like FindAbandonedPackages, it runs one multi-source reverse BFS per maintainer over the edges
+// of the graph as they are.
+func RankMaintainers(pg *PackageGraph) []MaintainerReach {
+	maintained := make(map[string][]int64)
+	for _, packageInfo := range *pg.Packages {
+		for version, versionInfo := range packageInfo.Versions {
+			id, ok := pg.ids[packageInfo.key().version(version)]
+			if !ok {
+				continue
+			}
+			for _, name := range maintainerNames(versionInfo) {
+				maintained[name] = append(maintained[name], id)
+			}
+		}
+	}
+
+	counter := newDependentCounter(pg)
+	ranking := make([]MaintainerReach, 0, len(maintained))
+	for name, ids := range maintained {
+		_, transitive := counter.count(ids)
+		ranking = append(ranking, MaintainerReach{Name: name, Versions: len(ids), TransitiveDependents: transitive})
+	}
+	sort.Slice(ranking, func(i, j int) bool {
+		if ranking[i].TransitiveDependents != ranking[j].TransitiveDependents {
+			return ranking[i].TransitiveDependents > ranking[j].TransitiveDependents
+		}
+		return ranking[i].Name < ranking[j].Name
+	})
+	return ranking
+}
@@ -0,0 +1,78 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMaintainers(t *testing.T) {
+	t.Run("Decodes names and objects", func(t *testing.T) {
+		var versionInfo VersionInfo
+		input := `{"maintainers": ["alice", {"name": "bob", "email": "bob@example.com"}, {"email": "x@example.com"}, 3]}`
+		if err := json.Unmarshal([]byte(input), &versionInfo); err != nil {
+			t.Fatal(err)
+		}
+		if expected := (Maintainers{"alice", "bob"}); !reflect.DeepEqual(versionInfo.Maintainers, expected) {
+			t.Errorf("Expected %v, got %v", expected, versionInfo.Maintainers)
+		}
+		if err := json.Unmarshal([]byte(`{"maintainers": "alice"}`), &versionInfo); err != nil || versionInfo.Maintainers != nil {
+			t.Errorf("Expected no maintainers for a plain string, got %v and %v", versionInfo.Maintainers, err)
+		}
+	})
+
+	version := func(maintainers Maintainers, dependencies map[string]string) map[string]VersionInfo {
+		return map[string]VersionInfo{"1.0.0": {Timestamp: "2022-01-01T00:00:00", Dependencies: dependencies, Maintainers: maintainers}}
+	}
+	// E -> C -> B -> A, with D -> B and C -> A.
+	packagesInfo := []PackageInfo{
+		{Name: "A", Versions: version(Maintainers{"alice"}, map[string]string{})},
+		{Name: "B", Versions: version(Maintainers{"alice", "bob", "alice"}, map[string]string{"A": "1.0.0"})},
+		{Name: "C", Versions: version(nil, map[string]string{"A": "1.0.0", "B": "1.0.0"})},
+		{Name: "D", Versions: version(Maintainers{"bob"}, map[string]string{"B": "1.0.0"})},
+		{Name: "E", Versions: version(Maintainers{"carol"}, map[string]string{"C": "1.0.0"})},
+	}
+	pg := NewPackageGraph(&packagesInfo, false)
+
+	t.Run("Counts the maintained transitive dependencies", func(t *testing.T) {
+		expected := map[string]int{"alice": 2, "bob": 1, UnknownMaintainer: 1}
+		if exposure := MaintainerExposure(pg, NameVersion{Name: "E", Version: "1.0.0"}); !reflect.DeepEqual(exposure, expected) {
+			t.Errorf("Expected %v, got %v", expected, exposure)
+		}
+		if exposure := MaintainerExposure(pg, NameVersion{Name: "E", Version: "2.0.0"}); exposure != nil {
+			t.Errorf("Expected no exposure for an unknown version, got %v", exposure)
+		}
+		sorted := []NameCount{{"alice", 2}, {"bob", 1}, {UnknownMaintainer, 1}}
+		if exposure := MaintainerExposureCounts(pg, NameVersion{Name: "E", Version: "1.0.0"}); !reflect.DeepEqual(exposure, sorted) {
+			t.Errorf("Expected %v, got %v", sorted, exposure)
+		}
+	})
+
+	t.Run("Ranks the maintainers by transitive dependents", func(t *testing.T) {
+		expected := []MaintainerReach{
+			{Name: "alice", Versions: 2, TransitiveDependents: 3},
+			{Name: "bob", Versions: 2, TransitiveDependents: 2},
+			{Name: UnknownMaintainer, Versions: 1, TransitiveDependents: 1},
+			{Name: "carol", Versions: 1, TransitiveDependents: 0},
+		}
+		if ranking := RankMaintainers(pg); !reflect.DeepEqual(ranking, expected) {
+			t.Errorf("Expected %v, got %v", expected, ranking)
+		}
+	})
+
+	t.Run("Keeps the maintainers in the cache", func(t *testing.T) {
+		var buffer bytes.Buffer
+		if err := SaveGraph(&buffer, pg); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadGraph(&buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		versionInfo, _ := loaded.VersionInfo(NameVersion{Name: "B", Version: "1.0.0"})
+		if expected := (Maintainers{"alice", "bob", "alice"}); !reflect.DeepEqual(versionInfo.Maintainers, expected) {
+			t.Errorf("Expected %v, got %v", expected, versionInfo.Maintainers)
+		}
+	})
+}
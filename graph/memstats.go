@@ -0,0 +1,179 @@
+package graph
+
+import (
+	"unsafe"
+
+	"github.com/Masterminds/semver"
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// MemStats is an estimate of the memory held by a PackageGraph, split by the structures that hold it. The sizes are
+// computed from the number of entries of every structure and the size of an entry, including the typical overhead of
+// a Go map, so they are approximate but proportional to what the runtime reports for large graphs.
+type MemStats struct {
+	// Nodes is the number of versions and NodeBytes the size of their node information, including the stringIDs.
+	Nodes     int `json:"nodes"`
+	NodeBytes int `json:"nodeBytes"`
+	// Packages is the number of packages and PackageBytes the size of their version and dependency maps, including the
+	// timestamps, which are not interned.
+	Packages     int `json:"packages"`
+	PackageBytes int `json:"packageBytes"`
+	// Strings is the number of distinct package names, versions, ranges, licenses and deprecation messages and
+	// StringBytes their total length. Identical strings are counted once, as they share their storage when the input
+	// was interned like ParseJSON does.
+	Strings     int `json:"strings"`
+	StringBytes int `json:"stringBytes"`
+	// IndexEntries is the number of entries of the lookup maps and indexes, such as StringIDToNodeInfo and the version
+	// index, and IndexBytes their size.
+	IndexEntries int `json:"indexEntries"`
+	IndexBytes   int `json:"indexBytes"`
+	// Edges is the number of edges and EdgeBytes the size of the gonum adjacency maps, including the node set.
+	Edges     int `json:"edges"`
+	EdgeBytes int `json:"edgeBytes"`
+	// TotalBytes is the sum of all the sizes above.
+	TotalBytes int `json:"totalBytes"`
+}
+
+const (
+	// mapHeaderBytes is the size of an allocated Go map without entries.
+	mapHeaderBytes = 48
+	// mapEntryOverhead is the tophash byte that a map stores for every entry besides the key and the value. The
+	// buckets are on average about 70% full, so the entries take 10/7 of their size.
+	mapEntryOverhead = 1
+	wordBytes        = int(unsafe.Sizeof(uintptr(0)))
+	stringBytes      = int(unsafe.Sizeof(""))
+	sliceBytes       = int(unsafe.Sizeof([]int64(nil)))
+	interfaceBytes   = int(unsafe.Sizeof(graph.Node(nil)))
+)
+
+// mapBytes estimates the size of a map with the given number of entries and key and value sizes.
+func mapBytes(entries, keyBytes, valueBytes int) int {
+	if entries == 0 {
+		return mapHeaderBytes
+	}
+	return mapHeaderBytes + entries*(keyBytes+valueBytes+mapEntryOverhead)*10/7
+}
+
+// MemoryStats estimates the memory used by the graph, walking all its packages and nodes once. The graph is not
+// modified.
+func (pg *PackageGraph) MemoryStats() MemStats {
+	if pg.lockEdges() {
+		defer pg.lazyMutex.Unlock()
+	}
+	var stats MemStats
+
+	nodeInfoBytes := int(unsafe.Sizeof(NodeInfo{}))
+	stats.NodeBytes = len(pg.Nodes) * nodeInfoBytes
+	if pg.slots != nil {
+		stats.NodeBytes += mapBytes(len(pg.slots), 8, 8)
+	}
+	for _, node := range pg.Nodes {
+		if node.stringID != "" {
+			stats.Nodes++
+			stats.NodeBytes += len(node.stringID)
+		}
+	}
+
+	distinct := make(map[string]struct{})
+	count := func(s string) {
+		if _, ok := distinct[s]; !ok && s != "" {
+			distinct[s] = struct{}{}
+			stats.StringBytes += len(s)
+		}
+	}
+	versionInfoBytes := int(unsafe.Sizeof(VersionInfo{}))
+	stats.Packages = len(*pg.Packages)
+	stats.PackageBytes = len(*pg.Packages) * int(unsafe.Sizeof(PackageInfo{}))
+	for _, packageInfo := range *pg.Packages {
+		count(packageInfo.Name)
+		stats.PackageBytes += mapBytes(len(packageInfo.Versions), stringBytes, versionInfoBytes)
+		for version, versionInfo := range packageInfo.Versions {
+			count(version)
+			count(string(versionInfo.License))
+			count(string(versionInfo.Deprecated))
+			stats.PackageBytes += len(versionInfo.Timestamp)
+			for _, class := range []DependencyClass{Runtime, Development, Peer, Optional} {
+				dependencies := versionInfo.DependenciesOf(class)
+				if dependencies == nil {
+					continue
+				}
+				stats.PackageBytes += mapBytes(len(dependencies), stringBytes, stringBytes)
+				for name, dependencyRange := range dependencies {
+					count(name)
+					count(dependencyRange)
+				}
+			}
+		}
+	}
+	stats.Strings = len(distinct)
+
+	versionCount := 0
+	for _, versions := range pg.NameToVersions {
+		versionCount += len(versions)
+	}
+	index := func(entries, bytes int) {
+		stats.IndexEntries += entries
+		stats.IndexBytes += bytes
+	}
+	index(len(pg.StringIDToNodeInfo), mapBytes(len(pg.StringIDToNodeInfo), stringBytes, nodeInfoBytes))
+	index(len(pg.ids), mapBytes(len(pg.ids), int(unsafe.Sizeof(NameVersion{})), 8))
+	index(len(pg.NameToVersions), mapBytes(len(pg.NameToVersions), stringBytes, sliceBytes)+versionCount*stringBytes)
+	index(len(pg.packageIndex), mapBytes(len(pg.packageIndex), stringBytes, wordBytes))
+	if pg.versions != nil {
+		indexed := 0
+		for _, versions := range pg.versions {
+			indexed += len(versions)
+		}
+		// Every parsed version also holds its original string and its parts.
+		parsedBytes := int(unsafe.Sizeof(semver.Version{}))
+		index(indexed, mapBytes(len(pg.versions), stringBytes, sliceBytes)+indexed*(int(unsafe.Sizeof(indexedVersion{}))+parsedBytes))
+	}
+	pg.constraintsMutex.Lock()
+	constraints := pg.constraints
+	pg.constraintsMutex.Unlock()
+	if constraints != nil {
+		entries := 0
+		for _, list := range constraints.entries {
+			entries += len(list)
+		}
+		rangeBytes := 0
+		for _, dependencyRange := range constraints.ranges {
+			rangeBytes += stringBytes + len(dependencyRange)
+		}
+		index(entries, mapBytes(len(constraints.entries), stringBytes, sliceBytes)+entries*int(unsafe.Sizeof(constraintEntry{}))+
+			rangeBytes+mapBytes(len(constraints.rangeRefs), stringBytes, 4))
+	}
+	if pg.unresolved != nil {
+		index(len(pg.unresolved), mapBytes(len(pg.unresolved), 8, 1))
+	}
+
+	stats.Edges, stats.EdgeBytes = gonumBytes(pg.Graph)
+	stats.EdgeBytes += mapBytes(len(pg.weights), 16, 4)
+	stats.TotalBytes = stats.NodeBytes + stats.PackageBytes + stats.StringBytes + stats.IndexBytes + stats.EdgeBytes
+	return stats
+}
+
+// gonumBytes returns the number of edges of the graph and estimates the size of its maps. simple.DirectedGraph keeps
+// a map of the nodes, a set of the used IDs and, for every node with edges, a map of its successors and one of its
+// predecessors. Both hold every edge, as an interface to a shared simple.Edge.
+func gonumBytes(g *simple.DirectedGraph) (int, int) {
+	nodes := g.Nodes().Len()
+	bytes := mapBytes(nodes, 8, interfaceBytes) + mapBytes(nodes, 8, 0)
+	withSuccessors, withPredecessors, edges := 0, 0, 0
+	it := g.Nodes()
+	for it.Next() {
+		id := it.Node().ID()
+		if successors := g.From(id).Len(); successors > 0 {
+			withSuccessors++
+			edges += successors
+		}
+		if g.To(id).Len() > 0 {
+			withPredecessors++
+		}
+	}
+	bytes += mapBytes(withSuccessors, 8, wordBytes) + mapBytes(withPredecessors, 8, wordBytes)
+	bytes += (withSuccessors + withPredecessors) * mapHeaderBytes
+	bytes += 2*edges*(8+interfaceBytes+mapEntryOverhead)*10/7 + edges*int(unsafe.Sizeof(simple.Edge{}))
+	return edges, bytes
+}
@@ -0,0 +1,59 @@
+package graph
+
+import (
+	"testing"
+)
+
+func TestMemoryStats(t *testing.T) {
+	packagesInfo := testPackages("lazy")
+	pg := NewPackageGraph(&packagesInfo, false)
+	stats := pg.MemoryStats()
+
+	t.Run("Counts the entries of the graph", func(t *testing.T) {
+		if stats.Nodes != 60 || stats.Packages != 20 {
+			t.Errorf("Expected 60 nodes of 20 packages, got %d and %d", stats.Nodes, stats.Packages)
+		}
+		if stats.Edges != pg.Graph.Edges().Len() {
+			t.Errorf("Expected %d edges, got %d", pg.Graph.Edges().Len(), stats.Edges)
+		}
+		// The 20 names, the 3 versions and the ranges "^1.0.0" to "^1.2.0", which are distinct from the versions.
+		if stats.Strings != 26 {
+			t.Errorf("Expected 26 distinct strings, got %d", stats.Strings)
+		}
+	})
+
+	t.Run("Adds up the sizes", func(t *testing.T) {
+		sum := stats.NodeBytes + stats.PackageBytes + stats.StringBytes + stats.IndexBytes + stats.EdgeBytes
+		if stats.TotalBytes != sum {
+			t.Errorf("Expected a total of %d bytes, got %d", sum, stats.TotalBytes)
+		}
+		for name, bytes := range map[string]int{"node": stats.NodeBytes, "package": stats.PackageBytes, "string": stats.StringBytes, "index": stats.IndexBytes, "edge": stats.EdgeBytes} {
+			if bytes <= 0 {
+				t.Errorf("Expected a positive %s size, got %d", name, bytes)
+			}
+		}
+	})
+
+	t.Run("Grows with the graph", func(t *testing.T) {
+		if err := pg.AddVersion("P19", "2.0.0", VersionInfo{Timestamp: "2021-01-01T00:00:00", Dependencies: map[string]string{"P0": "*"}}); err != nil {
+			t.Fatal(err)
+		}
+		grown := pg.MemoryStats()
+		if grown.Nodes != stats.Nodes+1 || grown.Edges <= stats.Edges || grown.TotalBytes <= stats.TotalBytes {
+			t.Errorf("Expected more nodes, edges and bytes than %+v, got %+v", stats, grown)
+		}
+	})
+
+	t.Run("Is logged after construction", func(t *testing.T) {
+		packagesInfo := testPackages("lazy")
+		logger := &recordingLogger{}
+		stats := NewPackageGraph(&packagesInfo, false, WithLogger(logger)).MemoryStats()
+		entry, ok := logger.find("memory")
+		if !ok {
+			t.Fatal("Expected the memory use to be logged")
+		}
+		if entry.fields["totalBytes"] != stats.TotalBytes || entry.fields["edges"] != stats.Edges {
+			t.Errorf("Expected the stats %+v, got %v", stats, entry.fields)
+		}
+	})
+}
@@ -0,0 +1,214 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// ConflictPolicy decides what Merge does when both graphs contain the same package version with different metadata.
+type ConflictPolicy int
+
+const (
+	// PreferA keeps the version of the first graph, for example an internal registry shadowing public packages.
+	PreferA ConflictPolicy = iota
+	// PreferB keeps the version of the second graph.
+	PreferB
+	// RejectConflicts makes Merge fail.
+	RejectConflicts
+)
+
+// mergeSource is one of the graphs being merged.
+type mergeSource struct {
+	pg *PackageGraph
+	// added holds the keys of the packages to which the other graph contributes versions, for which the ranges
+	// have to be resolved again.
+	added map[PackageKey]bool
+}
+
+// Merge returns the union of the packages and versions of two graphs, which must have been built with the same
+// settings. The nodes of a keep their relative order and get the first IDs, followed by the new nodes of b in their
+// order, so the result only depends on the inputs. With HashedIDs, every version keeps its ID instead, and an
+// *IDCollisionError is returned if versions of the two graphs collide. If a package version is part of both graphs with
+// different metadata, the policy decides which one is kept; identical versions are merged silently.
+//
+// The edges of both graphs are reused. Only the dependencies on packages to which the other graph contributes
+// versions are resolved again, which covers both dependencies that were unresolved before and, with ResolveHighest,
+// dependencies that now resolve to a higher version. The result has the same edges as a graph built from the union.
+// The versions of a graph with lazy edges whose edges have not been created yet are still created on demand in the
+// result.
+func Merge(a, b *PackageGraph, policy ConflictPolicy) (*PackageGraph, error) {
+	if a.isMaven != b.isMaven || a.options.Resolution != b.options.Resolution || !sameClasses(a.options.DependencyClasses, b.options.DependencyClasses) ||
+		a.options.TruncateFourPartVersions != b.options.TruncateFourPartVersions || a.options.Prereleases != b.options.Prereleases ||
+		a.options.Names != b.options.Names || !sameEcosystemNames(a.options.EcosystemNames, b.options.EcosystemNames) ||
+		a.options.PreferNonDeprecated != b.options.PreferNonDeprecated || a.options.IDScheme != b.options.IDScheme {
+		return nil, errors.New("graphs built with different settings cannot be merged")
+	}
+
+	// owner records from which graph every merged version is taken.
+	packages := make([]PackageInfo, 0, len(*a.Packages)+len(*b.Packages))
+	packageIndex := make(map[PackageKey]int)
+	owner := make(map[NameVersion]*PackageGraph)
+	sources := []*mergeSource{{pg: a, added: make(map[PackageKey]bool)}, {pg: b, added: make(map[PackageKey]bool)}}
+	for s, source := range sources {
+		other := sources[1-s]
+		for _, packageInfo := range *source.pg.Packages {
+			index, ok := packageIndex[packageInfo.key()]
+			if !ok {
+				index = len(packages)
+				packageIndex[packageInfo.key()] = index
+				packages = append(packages, PackageInfo{Name: packageInfo.Name, Ecosystem: packageInfo.Ecosystem, Versions: make(map[string]VersionInfo), Provenance: packageInfo.Provenance})
+			}
+			for version, versionInfo := range packageInfo.Versions {
+				nameVersion := packageInfo.key().version(version)
+				existing, exists := packages[index].Versions[version]
+				if exists {
+					if versionInfoEqual(existing, versionInfo) || policy == PreferA {
+						continue
+					}
+					if policy == RejectConflicts {
+						return nil, fmt.Errorf("%s differs between the graphs", nameVersion)
+					}
+				} else if _, ok := other.pg.lookup(nameVersion); !ok {
+					other.added[packageInfo.key()] = true
+				}
+				packages[index].Versions[version] = versionInfo
+				owner[nameVersion] = source.pg
+			}
+		}
+	}
+
+	graph := simple.NewDirectedGraph()
+	allocator := newNodeAllocator(graph, a.options.IDScheme)
+	var nodes []NodeInfo
+	newIDs := make(map[NameVersion]int64)
+	for _, source := range sources {
+		for _, node := range source.pg.Nodes {
+			nameVersion := node.NameVersion()
+			if _, done := newIDs[nameVersion]; node.stringID == "" || done {
+				continue
+			}
+			if _, ok := owner[nameVersion]; !ok {
+				continue
+			}
+			versionInfo := packages[packageIndex[node.key()]].Versions[node.Version]
+			id, _ := allocator.add(nameVersion)
+			newIDs[nameVersion] = id
+			nodes = appendNodeInfo(nodes, *newNodeInfoFromVersion(id, node.key(), node.Version, versionInfo), allocator.scheme)
+		}
+	}
+	if err := allocator.err(); err != nil {
+		return nil, err
+	}
+	pg := newPackageGraphFromParts(graph, &packages, nodes)
+	pg.isMaven = a.isMaven
+	// The report of the first graph does not describe the merged graph.
+	options := *a.options
+	options.Report = nil
+	pg.options = &options
+	if len(a.unresolved) > 0 || len(b.unresolved) > 0 {
+		pg.options.LazyEdges = true
+		pg.unresolved = make(map[int64]bool)
+	}
+
+	resolver := pg.resolver()
+	for _, node := range pg.Nodes {
+		if node.stringID == "" {
+			continue
+		}
+		source := sources[0]
+		if owner[node.NameVersion()] == b {
+			source = sources[1]
+		}
+		sourceNode, _ := source.pg.lookup(node.NameVersion())
+		if source.pg.unresolved[sourceNode.id] {
+			// The edges of the version are created on demand, against the merged versions.
+			pg.unresolved[node.id] = true
+			continue
+		}
+		for _, dependencyID := range sortedNodeIDs(source.pg.DependencyGraph().From(sourceNode.id)) {
+			dependency, _ := source.pg.Node(dependencyID)
+			if source.added[dependency.key()] {
+				continue
+			}
+			newID := newIDs[dependency.NameVersion()]
+			pg.setEdge(node.id, newID)
+			pg.setWeight(node.id, newID, source.pg.EdgeWeight(sourceNode.id, dependencyID))
+		}
+		versionInfo, _ := pg.VersionInfo(node.NameVersion())
+		var edges [][2]int64
+		for _, name := range pg.declaredDependencyNames(versionInfo) {
+			dependency := NewPackageKey(node.Ecosystem, name)
+			if !source.added[dependency] {
+				continue
+			}
+			for _, dependencyRange := range pg.declaredRanges(versionInfo, name) {
+				dependencyIDs, _ := resolver.resolveRange(dependency, dependencyRange)
+				for _, dependencyID := range dependencyIDs {
+					edges = append(edges, [2]int64{node.id, dependencyID})
+				}
+			}
+		}
+		pg.setWeightedEdges(edges)
+	}
+	for _, source := range sources {
+		source := source
+		pg.copyCrossEdges(source.pg, func(id int64) (int64, bool) {
+			node, _ := source.pg.Node(id)
+			newID, ok := newIDs[node.NameVersion()]
+			return newID, ok
+		})
+	}
+	return pg, nil
+}
+
+func sameClasses(a, b []DependencyClass) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sameEcosystemNames(a, b map[string]NameNormalization) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for ecosystem, normalization := range a {
+		if other, ok := b[ecosystem]; !ok || other != normalization {
+			return false
+		}
+	}
+	return true
+}
+
+// versionInfoEqual reports whether two versions have the same timestamp and dependencies. Missing and empty
+// dependency maps are equal.
+func versionInfoEqual(a, b VersionInfo) bool {
+	if a.Timestamp != b.Timestamp {
+		return false
+	}
+	for _, class := range []DependencyClass{Runtime, Development, Peer, Optional} {
+		if !stringMapsEqual(a.DependenciesOf(class), b.DependenciesOf(class)) {
+			return false
+		}
+	}
+	return true
+}
+
+func stringMapsEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
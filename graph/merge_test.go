@@ -0,0 +1,84 @@
+package graph
+
+import (
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	for name, resolution := range map[string]Resolution{"all": ResolveAll, "highest": ResolveHighest} {
+		t.Run("Equals a build of the union resolving "+name, func(t *testing.T) {
+			base, delta := testPackages("options"), testPackages("delta")
+			all := union(base, delta)
+			expected := edgeSet(NewPackageGraph(&all, false, WithResolution(resolution)))
+
+			a := NewPackageGraph(&base, false, WithResolution(resolution))
+			b := NewPackageGraph(&delta, false, WithResolution(resolution))
+			merged, err := Merge(a, b, RejectConflicts)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			actual := edgeSet(merged)
+			if len(actual) != len(expected) {
+				t.Errorf("Expected %d edges, got %d", len(expected), len(actual))
+			}
+			for edge := range expected {
+				if !actual[edge] {
+					t.Errorf("Expected edge %v", edge)
+				}
+			}
+		})
+	}
+
+	t.Run("Assigns the same IDs on every merge", func(t *testing.T) {
+		base, delta := testPackages("options"), testPackages("delta")
+		a := NewPackageGraph(&base, false)
+		b := NewPackageGraph(&delta, false)
+		first, _ := Merge(a, b, PreferA)
+		second, _ := Merge(a, b, PreferA)
+		if len(first.Nodes) != len(second.Nodes) {
+			t.Fatalf("Expected the same number of nodes, got %d and %d", len(first.Nodes), len(second.Nodes))
+		}
+		for i := range first.Nodes {
+			if first.Nodes[i] != second.Nodes[i] {
+				t.Errorf("Expected node %d to be equal, got %v and %v", i, first.Nodes[i], second.Nodes[i])
+			}
+		}
+		if info, _ := first.FindNode(NameVersion{Name: "App", Version: "1.0.0"}); info.id != a.StringIDToNodeInfo["App-1.0.0"].id {
+			t.Error("Expected the nodes of the first graph to keep their IDs")
+		}
+	})
+
+	t.Run("Applies the conflict policy", func(t *testing.T) {
+		base := testPackages("options")
+		shadow := []PackageInfo{{
+			Name: "A",
+			Versions: map[string]VersionInfo{
+				"1.2.0": {Timestamp: "2022-02-02T00:00:00", Dependencies: map[string]string{"Test": "1.0.0"}},
+			},
+		}}
+		a := NewPackageGraph(&shadow, false)
+		b := NewPackageGraph(&base, false)
+
+		if _, err := Merge(a, b, RejectConflicts); err == nil {
+			t.Error("Expected an error for the conflicting version")
+		}
+		preferA, err := Merge(a, b, PreferA)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info, _ := preferA.FindNode(NameVersion{Name: "A", Version: "1.2.0"}); info.Timestamp != "2022-02-02T00:00:00" || !edgeSet(preferA)[[2]string{"A-1.2.0", "Test-1.0.0"}] {
+			t.Errorf("Expected the version of the first graph with its edge, got %v", info)
+		}
+		preferB, _ := Merge(a, b, PreferB)
+		if info, _ := preferB.FindNode(NameVersion{Name: "A", Version: "1.2.0"}); info.Timestamp != "2022-01-01T00:00:00" || edgeSet(preferB)[[2]string{"A-1.2.0", "Test-1.0.0"}] {
+			t.Errorf("Expected the version of the second graph without the edge, got %v", info)
+		}
+	})
+
+	t.Run("Rejects graphs built with different settings", func(t *testing.T) {
+		base, delta := testPackages("options"), testPackages("delta")
+		if _, err := Merge(NewPackageGraph(&base, false), NewPackageGraph(&delta, false, WithResolution(ResolveHighest)), PreferA); err == nil {
+			t.Error("Expected an error")
+		}
+	})
+}
@@ -0,0 +1,257 @@
+package graph
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"unsafe"
+)
+
+// metadataMagic starts every metadata file written by SaveGraphWithMetadata.
+const metadataMagic = "STM-METADATA\n"
+
+// metadataFormatVersion is increased whenever the layout of the metadata file changes.
+const metadataFormatVersion = 1
+
+// metadataSection is the section of a graph cache whose strings are kept in a metadata file. Its presence tells that
+// the strings section is empty.
+const metadataSection = "metadata"
+
+// metadataHeaderBytes is the size of the header of a metadata file: the magic, the format version and the number of
+// strings.
+const metadataHeaderBytes = len(metadataMagic) + 4 + 4
+
+// cachedMetadata identifies the metadata file that holds the strings of a graph cache, so that a cache is not loaded
+// with the metadata file of another one.
+type cachedMetadata struct {
+	Strings  int
+	Checksum uint32
+}
+
+// metadataFile is a metadata file mapped into memory, or read into the heap where it cannot be mapped.
+//
+// The file starts with metadataMagic, followed by the format version and the number of strings n as little-endian
+// uint32s. Then come n+1 little-endian uint64 offsets and the bytes of the strings, of which string i spans the bytes
+// from offset i to offset i+1, counted from the end of the offsets. The strings are the string table of the cache, to
+// which its nodes section refers for the names, versions and timestamps of the nodes, and a string is found from its
+// two offsets without reading the rest of the file.
+type metadataFile struct {
+	data    []byte
+	strings int
+	// mapped is set if data is memory-mapped rather than read into the heap.
+	mapped bool
+}
+
+// SaveGraphWithMetadata writes the graph cache to w like SaveGraph, but writes its strings, among which the names,
+// versions and timestamps of the nodes, to metadata, in a flat file that LoadGraphFileWithMetadata maps read-only into
+// memory. The cache can then only be loaded together with that metadata file.
+func SaveGraphWithMetadata(w, metadata io.Writer, pg *PackageGraph) error {
+	return saveGraph(w, pg, metadata)
+}
+
+// SaveGraphFileWithMetadata writes the graph cache to the file at path and its strings to the metadata file at
+// metadataPath, see SaveGraphWithMetadata. Both are written to temporary files in the same directories first and then
+// renamed into place, so a metadata file that another process has mapped is replaced rather than truncated, and that
+// process keeps reading the old one.
+func SaveGraphFileWithMetadata(path, metadataPath string, pg *PackageGraph) (err error) {
+	file, err := createTemporary(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			file.Close()
+			os.Remove(file.Name())
+		}
+	}()
+	metadata, err := createTemporary(metadataPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			metadata.Close()
+			os.Remove(metadata.Name())
+		}
+	}()
+	if err := SaveGraphWithMetadata(file, metadata, pg); err != nil {
+		return err
+	}
+	for _, written := range []*os.File{file, metadata} {
+		if err := written.Sync(); err != nil {
+			return err
+		}
+		if err := written.Close(); err != nil {
+			return err
+		}
+	}
+	// The metadata file is renamed first, so that a graph loaded in between fails the check of its checksum instead of
+	// reading the new strings with the old cache unnoticed.
+	if err := os.Rename(metadata.Name(), metadataPath); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// createTemporary creates a temporary file next to path, to be renamed to path once it is written.
+func createTemporary(path string) (*os.File, error) {
+	return os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+}
+
+// LoadGraphFileWithMetadata reads the graph cache at path, written by SaveGraphFileWithMetadata, and maps its metadata
+// file at metadataPath read-only into memory. The strings of the loaded graph, such as the names, versions and
+// timestamps of its nodes and packages, point into the mapping instead of the heap, so several processes that load the
+// same files share a single copy of them through the page cache. They are ordinary read-only strings, so the graph is
+// used and changed as any other; the strings of the versions added later are allocated as usual.
+//
+// The mapping is private and never unmapped, as the strings may outlive the graph. The metadata file must not be
+// changed or truncated in place while it is mapped, which makes the processes that have it mapped crash, but may be
+// replaced by renaming another file over it, as SaveGraphFileWithMetadata does. Where files cannot be mapped, the
+// metadata file is read into the heap instead, see MetadataMapped.
+func LoadGraphFileWithMetadata(path, metadataPath string) (*PackageGraph, error) {
+	return loadGraphFileWithMetadata(path, metadataPath, nil)
+}
+
+// LoadGraphFileWithMetadataAndOptions reads the graph cache at path and maps its metadata file like
+// LoadGraphFileWithMetadata, but returns an *OptionMismatchError like LoadGraphWithOptions if the graph was not built
+// with the package manager and the options given.
+func LoadGraphFileWithMetadataAndOptions(path, metadataPath string, isUsingMaven bool, opts ...Option) (*PackageGraph, error) {
+	return loadGraphFileWithMetadata(path, metadataPath, &requestedOptions{isUsingMaven, newOptions(opts)})
+}
+
+func loadGraphFileWithMetadata(path, metadataPath string, requested *requestedOptions) (*PackageGraph, error) {
+	metadata, err := openMetadataFile(metadataPath)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		if metadata.mapped {
+			unmapFile(metadata.data)
+		}
+		return nil, err
+	}
+	defer file.Close()
+	pg, err := loadGraph(file, requested, metadata)
+	if err != nil {
+		// Nothing that points into the mapping has been returned.
+		if metadata.mapped {
+			unmapFile(metadata.data)
+		}
+		return nil, err
+	}
+	pg.metadata = metadata
+	return pg, nil
+}
+
+// MetadataMapped reports whether the strings of the graph point into a memory-mapped metadata file, which is only the
+// case for a graph loaded by LoadGraphFileWithMetadata on a platform that supports mapping files.
+func (pg *PackageGraph) MetadataMapped() bool {
+	return pg.metadata != nil && pg.metadata.mapped
+}
+
+// writeMetadata writes the strings to w as a metadata file and returns its identification.
+func writeMetadata(w io.Writer, strings []string) (cachedMetadata, error) {
+	checksum := crc32.NewIEEE()
+	buffered := bufio.NewWriter(io.MultiWriter(w, checksum))
+	var header [8]byte
+	binary.LittleEndian.PutUint32(header[:4], metadataFormatVersion)
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(strings)))
+	buffered.WriteString(metadataMagic)
+	buffered.Write(header[:])
+	var offset [8]byte
+	end := uint64(0)
+	for i := 0; i <= len(strings); i++ {
+		binary.LittleEndian.PutUint64(offset[:], end)
+		buffered.Write(offset[:])
+		if i < len(strings) {
+			end += uint64(len(strings[i]))
+		}
+	}
+	for _, s := range strings {
+		buffered.WriteString(s)
+	}
+	if err := buffered.Flush(); err != nil {
+		return cachedMetadata{}, err
+	}
+	return cachedMetadata{Strings: len(strings), Checksum: checksum.Sum32()}, nil
+}
+
+// openMetadataFile maps the metadata file at path and checks its header and offsets.
+func openMetadataFile(path string) (*metadataFile, error) {
+	data, mapped, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	metadata := &metadataFile{data: data, mapped: mapped}
+	if err := metadata.checkLayout(); err != nil {
+		if mapped {
+			unmapFile(data)
+		}
+		return nil, fmt.Errorf("metadata file %s: %w", path, err)
+	}
+	return metadata, nil
+}
+
+// checkLayout reads the number of strings from the header and checks the format version and the offsets.
+func (metadata *metadataFile) checkLayout() error {
+	data := metadata.data
+	if len(data) < metadataHeaderBytes || string(data[:len(metadataMagic)]) != metadataMagic {
+		return errors.New("not a metadata file")
+	}
+	header := data[len(metadataMagic):metadataHeaderBytes]
+	if version := binary.LittleEndian.Uint32(header[:4]); version != metadataFormatVersion {
+		return fmt.Errorf("format version %d, expected %d", version, metadataFormatVersion)
+	}
+	metadata.strings = int(binary.LittleEndian.Uint32(header[4:]))
+	if uint64(len(data)-metadataHeaderBytes)/8 <= uint64(metadata.strings) {
+		return errors.New("truncated offsets")
+	}
+	if uint64(len(data)-metadata.stringsStart()) != metadata.offset(metadata.strings) {
+		return errors.New("invalid length")
+	}
+	for i := 0; i < metadata.strings; i++ {
+		if metadata.offset(i) > metadata.offset(i+1) {
+			return errors.New("invalid offsets")
+		}
+	}
+	return nil
+}
+
+// check returns an error if the file does not hold the strings of the cache it belongs to.
+func (metadata *metadataFile) check(cached cachedMetadata) error {
+	if metadata.strings != cached.Strings || crc32.ChecksumIEEE(metadata.data) != cached.Checksum {
+		return errors.New("metadata file does not belong to the graph cache")
+	}
+	return nil
+}
+
+// stringsStart returns the position in data at which the bytes of the strings start.
+func (metadata *metadataFile) stringsStart() int {
+	return metadataHeaderBytes + 8*(metadata.strings+1)
+}
+
+func (metadata *metadataFile) offset(i int) uint64 {
+	position := metadataHeaderBytes + 8*i
+	return binary.LittleEndian.Uint64(metadata.data[position : position+8])
+}
+
+// lookup returns the string with the given index, which points into data rather than being copied. The offsets were
+// checked when the file was opened.
+func (metadata *metadataFile) lookup(ref uint32) (string, error) {
+	if int(ref) >= metadata.strings {
+		return "", fmt.Errorf("graph cache refers to an unknown string %d", ref)
+	}
+	start, end := metadata.offset(int(ref)), metadata.offset(int(ref)+1)
+	if start == end {
+		return "", nil
+	}
+	b := metadata.data[metadata.stringsStart()+int(start) : metadata.stringsStart()+int(end)]
+	// The bytes are never written to, as the file is mapped read-only and never unmapped, so they can back a string.
+	return *(*string)(unsafe.Pointer(&b)), nil
+}
@@ -0,0 +1,157 @@
+package graph
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestMetadataFile(t *testing.T) {
+	packagesInfo := testPackages("sample")
+	pg := NewPackageGraph(&packagesInfo, false)
+	dir := t.TempDir()
+	cachePath, metadataPath := filepath.Join(dir, "graph.cache"), filepath.Join(dir, "graph.meta")
+	if err := SaveGraphFileWithMetadata(cachePath, metadataPath, pg); err != nil {
+		t.Fatal(err)
+	}
+	load := func(t *testing.T) *PackageGraph {
+		t.Helper()
+		loaded, err := LoadGraphFileWithMetadata(cachePath, metadataPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return loaded
+	}
+
+	t.Run("Answers the same queries from two graphs sharing the file", func(t *testing.T) {
+		first, second := load(t), load(t)
+		for _, loaded := range []*PackageGraph{first, second} {
+			if !reflect.DeepEqual(loaded.NameToVersions, pg.NameToVersions) || !reflect.DeepEqual(*loaded.Packages, *pg.Packages) {
+				t.Fatal("Expected the versions and packages of the saved graph")
+			}
+			if !reflect.DeepEqual(edgeSet(loaded), edgeSet(pg)) {
+				t.Fatal("Expected the edges of the saved graph")
+			}
+		}
+		for _, node := range pg.Nodes {
+			nameVersion := NameVersion{Name: node.Name, Version: node.Version}
+			firstInfo, _ := first.FindNode(nameVersion)
+			secondInfo, _ := second.FindNode(nameVersion)
+			if firstInfo != node || secondInfo != node {
+				t.Errorf("Expected the node %v, got %v and %v", node, firstInfo, secondInfo)
+			}
+			firstDependents, _ := first.Dependents(nameVersion, -1)
+			secondDependents, _ := second.Dependents(nameVersion, -1)
+			expected, _ := pg.Dependents(nameVersion, -1)
+			if !reflect.DeepEqual(firstDependents, expected) || !reflect.DeepEqual(secondDependents, expected) {
+				t.Errorf("Expected the dependents %v of %s, got %v and %v", expected, nameVersion, firstDependents, secondDependents)
+			}
+		}
+		if latest, _ := second.LatestVersion("P3", nil, AllVersions); latest != "1.0.0" {
+			t.Errorf("Expected the latest version 1.0.0, got %q", latest)
+		}
+	})
+
+	t.Run("Points the strings into the mapped file", func(t *testing.T) {
+		loaded := load(t)
+		if runtime.GOOS != "linux" {
+			t.Skip("files are only known to be mapped on Linux")
+		}
+		if !loaded.MetadataMapped() {
+			t.Fatal("Expected the metadata file to be mapped")
+		}
+		start := uintptr(unsafe.Pointer(&loaded.metadata.data[0]))
+		end := start + uintptr(len(loaded.metadata.data))
+		node, _ := loaded.FindNode(NameVersion{Name: "P1", Version: "1.0.0"})
+		versionInfo, _ := loaded.VersionInfo(NameVersion{Name: "P1", Version: "1.0.0"})
+		for _, s := range []string{node.Name, node.Version, node.Timestamp, versionInfo.Timestamp, (*loaded.Packages)[0].Name} {
+			if data := stringData(s); data < start || data >= end {
+				t.Errorf("Expected %q to point into the mapped file", s)
+			}
+		}
+	})
+
+	t.Run("Adds versions to a mapped graph", func(t *testing.T) {
+		loaded := load(t)
+		if err := loaded.AddVersion("P0", "1.1.0", VersionInfo{Timestamp: "2021-01-01T00:00:00"}); err != nil {
+			t.Fatal(err)
+		}
+		if latest, _ := loaded.LatestVersion("P0", nil, AllVersions); latest != "1.1.0" {
+			t.Errorf("Expected the added version to be the latest, got %q", latest)
+		}
+	})
+
+	t.Run("Keeps the strings of a mapped graph when the files are saved again", func(t *testing.T) {
+		replacedCache, replacedMetadata := filepath.Join(dir, "replaced.cache"), filepath.Join(dir, "replaced.meta")
+		if err := SaveGraphFileWithMetadata(replacedCache, replacedMetadata, pg); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadGraphFileWithMetadata(replacedCache, replacedMetadata)
+		if err != nil {
+			t.Fatal(err)
+		}
+		smallerInfo := []PackageInfo{{Name: "Q", Versions: map[string]VersionInfo{"1.0.0": {}}}}
+		if err := SaveGraphFileWithMetadata(replacedCache, replacedMetadata, NewPackageGraph(&smallerInfo, false)); err != nil {
+			t.Fatal(err)
+		}
+		for i, node := range loaded.Nodes {
+			if node.Name != pg.Nodes[i].Name || node.Version != pg.Nodes[i].Version {
+				t.Fatalf("Expected %s, got %s", pg.Nodes[i], node)
+			}
+		}
+		reloaded, err := LoadGraphFileWithMetadata(replacedCache, replacedMetadata)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(reloaded.Nodes) != 1 || reloaded.Nodes[0].Name != "Q" {
+			t.Errorf("Expected the saved graph of Q, got %v", reloaded.Nodes)
+		}
+		if matches, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(matches) != 0 {
+			t.Errorf("Expected no temporary files, got %v", matches)
+		}
+	})
+
+	t.Run("Rejects a cache without its metadata file", func(t *testing.T) {
+		if _, err := LoadGraphFile(cachePath); err == nil || !strings.Contains(err.Error(), "metadata file") {
+			t.Errorf("Expected an error about the metadata file, got %v", err)
+		}
+		plainPath := filepath.Join(dir, "plain.cache")
+		if err := SaveGraphFile(plainPath, pg); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadGraphFileWithMetadata(plainPath, metadataPath); err == nil || !strings.Contains(err.Error(), "without a metadata file") {
+			t.Errorf("Expected an error about the missing metadata file, got %v", err)
+		}
+	})
+
+	t.Run("Rejects the metadata file of another graph", func(t *testing.T) {
+		otherInfo := testPackages("options")
+		otherCache, otherMetadata := filepath.Join(dir, "other.cache"), filepath.Join(dir, "other.meta")
+		if err := SaveGraphFileWithMetadata(otherCache, otherMetadata, NewPackageGraph(&otherInfo, false)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadGraphFileWithMetadata(cachePath, otherMetadata); err == nil || !strings.Contains(err.Error(), "does not belong") {
+			t.Errorf("Expected a mismatch, got %v", err)
+		}
+	})
+
+	t.Run("Rejects truncated metadata files", func(t *testing.T) {
+		data, err := os.ReadFile(metadataPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, size := range []int{0, len(metadataMagic), metadataHeaderBytes + 8, len(data) - 1} {
+			truncated := filepath.Join(dir, "truncated.meta")
+			if err := os.WriteFile(truncated, data[:size], 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadGraphFileWithMetadata(cachePath, truncated); err == nil {
+				t.Errorf("Expected an error for the first %d bytes", size)
+			}
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// MetricFunc scores the versions of a graph by node ID. Versions without a score are left out of the map.
+type MetricFunc func(*PackageGraph) (map[int64]float64, error)
+
+var (
+	metricsMutex sync.RWMutex
+	metrics      = map[string]MetricFunc{
+		"indegree": func(pg *PackageGraph) (map[int64]float64, error) {
+			return InDegrees(pg.DependencyGraph()), nil
+		},
+		"outdegree": func(pg *PackageGraph) (map[int64]float64, error) {
+			return OutDegrees(pg.DependencyGraph()), nil
+		},
+		"transitive": func(pg *PackageGraph) (map[int64]float64, error) {
+			return intScores(TransitiveDependencyCounts(pg.DependencyGraph(), pg.nodeIDs())), nil
+		},
+		"pagerank": func(pg *PackageGraph) (map[int64]float64, error) {
+			return PageRank(pg.DependencyGraph(), 0.85, 1e-6), nil
+		},
+		"core": func(pg *PackageGraph) (map[int64]float64, error) {
+			return intScores(CoreNumbers(pg.DependencyGraph())), nil
+		},
+		"depth": func(pg *PackageGraph) (map[int64]float64, error) {
+			depths, err := DependencyDepths(pg.DependencyGraph(), pg.NodeMap())
+			if err != nil {
+				return nil, err
+			}
+			return intScores(depths), nil
+		},
+	}
+)
+
+// RegisterMetric makes a metric available to ComputeMetrics and the metric flags of the command line tool under the
+// given name. The built-in metrics are indegree, outdegree, transitive (the number of transitive dependencies),
+// pagerank (see PageRank), core (the core number, see CoreNumbers) and depth (see DependencyDepths). Like
+// sql.Register, it panics if fn is nil or the name is empty or already registered, so it is meant to be called from an
+// init function.
+func RegisterMetric(name string, fn func(*PackageGraph) (map[int64]float64, error)) {
+	metricsMutex.Lock()
+	defer metricsMutex.Unlock()
+	if name == "" || fn == nil {
+		panic("graph: RegisterMetric needs a name and a function")
+	}
+	if _, taken := metrics[name]; taken {
+		panic("graph: RegisterMetric called twice for metric " + name)
+	}
+	metrics[name] = fn
+}
+
+// unregisterMetric removes a metric added by RegisterMetric, so that the tests can register theirs again.
+func unregisterMetric(name string) {
+	metricsMutex.Lock()
+	defer metricsMutex.Unlock()
+	delete(metrics, name)
+}
+
+// MetricNames returns the names of the registered metrics in alphabetical order.
+func MetricNames() []string {
+	metricsMutex.RLock()
+	defer metricsMutex.RUnlock()
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ComputeMetrics computes the named metrics on the graph and returns their scores by metric name. An error is
+// returned if a name is not registered, before any metric is computed, or if a metric fails. The metrics see the edges
+// of the graph as they are, so on a graph with lazy edges only the edges created so far are scored.
+func ComputeMetrics(pg *PackageGraph, names []string) (map[string]map[int64]float64, error) {
+	metricsMutex.RLock()
+	fns := make([]MetricFunc, len(names))
+	for i, name := range names {
+		fns[i] = metrics[name]
+		if fns[i] == nil {
+			metricsMutex.RUnlock()
+			return nil, fmt.Errorf("unknown metric %q", name)
+		}
+	}
+	metricsMutex.RUnlock()
+
+	scores := make(map[string]map[int64]float64, len(names))
+	for i, name := range names {
+		if _, done := scores[name]; done {
+			continue
+		}
+		metricScores, err := fns[i](pg)
+		if err != nil {
+			return nil, fmt.Errorf("metric %s: %w", name, err)
+		}
+		scores[name] = metricScores
+	}
+	return scores, nil
+}
+
+// nodeIDs returns the IDs of all the versions of the graph in increasing order.
+func (pg *PackageGraph) nodeIDs() []int64 {
+	ids := make([]int64, 0, len(pg.Nodes))
+	for _, node := range pg.Nodes {
+		if node.stringID != "" {
+			ids = append(ids, node.id)
+		}
+	}
+	// Nodes is only in ID order if the nodes are stored at their ID.
+	if pg.slots != nil {
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	}
+	return ids
+}
+
+func intScores(values map[int64]int) map[int64]float64 {
+	scores := make(map[int64]float64, len(values))
+	for id, value := range values {
+		scores[id] = float64(value)
+	}
+	return scores
+}
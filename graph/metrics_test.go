@@ -0,0 +1,64 @@
+package graph
+
+import (
+	"testing"
+)
+
+func TestComputeMetrics(t *testing.T) {
+	packagesInfo := testPackages("ego")
+	pg := NewPackageGraph(&packagesInfo, false)
+	a, _ := pg.FindNode(NameVersion{Name: "A", Version: "1.0.0"})
+	e, _ := pg.FindNode(NameVersion{Name: "E", Version: "1.0.0"})
+
+	t.Run("Computes the built-in metrics", func(t *testing.T) {
+		scores, err := ComputeMetrics(pg, []string{"indegree", "transitive", "depth"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(scores) != 3 {
+			t.Fatalf("Expected 3 metrics, got %v", scores)
+		}
+		if indegree := scores["indegree"][a.ID()]; indegree != 2 {
+			t.Errorf("Expected A to have 2 dependents, got %g", indegree)
+		}
+		if transitive := scores["transitive"][e.ID()]; transitive != 3 {
+			t.Errorf("Expected E to have 3 transitive dependencies, got %g", transitive)
+		}
+		if depth := scores["depth"][e.ID()]; depth != 3 {
+			t.Errorf("Expected E to have the depth 3, got %g", depth)
+		}
+	})
+
+	t.Run("Computes registered metrics", func(t *testing.T) {
+		RegisterMetric("test-constant", func(pg *PackageGraph) (map[int64]float64, error) {
+			return map[int64]float64{a.ID(): 7}, nil
+		})
+		t.Cleanup(func() { unregisterMetric("test-constant") })
+		scores, err := ComputeMetrics(pg, []string{"test-constant"})
+		if err != nil || scores["test-constant"][a.ID()] != 7 {
+			t.Errorf("Expected the registered score 7, got %v and %v", scores, err)
+		}
+		found := false
+		for _, name := range MetricNames() {
+			found = found || name == "test-constant"
+		}
+		if !found {
+			t.Errorf("Expected the registered metric to be listed, got %v", MetricNames())
+		}
+	})
+
+	t.Run("Rejects duplicate registrations", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected a panic for a duplicate metric")
+			}
+		}()
+		RegisterMetric("indegree", func(pg *PackageGraph) (map[int64]float64, error) { return nil, nil })
+	})
+
+	t.Run("Rejects unknown metrics", func(t *testing.T) {
+		if _, err := ComputeMetrics(pg, []string{"indegree", "unknown"}); err == nil {
+			t.Error("Expected an error for an unknown metric")
+		}
+	})
+}
@@ -0,0 +1,18 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+
+package graph
+
+import (
+	"os"
+)
+
+// mapFile reads the file at path into the heap, as files are not mapped into memory on this platform.
+func mapFile(path string) ([]byte, bool, error) {
+	data, err := os.ReadFile(path)
+	return data, false, err
+}
+
+// unmapFile does nothing, as mapFile never maps the files on this platform.
+func unmapFile(data []byte) error {
+	return nil
+}
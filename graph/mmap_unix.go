@@ -0,0 +1,40 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package graph
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// mapFile maps the file at path read-only and private into memory and reports whether it is mapped. The pages are
+// still shared with the other processes that map the file, as they are never written. A file that cannot be mapped,
+// such as an empty one or one on a file system without support for it, is read into the heap instead.
+func mapFile(path string) ([]byte, bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+	size := info.Size()
+	if size != int64(int(size)) {
+		return nil, false, fmt.Errorf("%s is too large to be mapped", path)
+	}
+	if size > 0 {
+		if data, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_PRIVATE); err == nil {
+			return data, true, nil
+		}
+	}
+	data, err := os.ReadFile(path)
+	return data, false, err
+}
+
+// unmapFile unmaps the data returned by mapFile, once nothing points into it any more.
+func unmapFile(data []byte) error {
+	return syscall.Munmap(data)
+}
@@ -0,0 +1,72 @@
+package graph
+
+// nameFilterBits is the number of bits of a nameFilter per name, and nameFilterProbes the number of bits set for every
+// name, which together let about 1.7% of the unknown names through.
+const (
+	nameFilterBits   = 10
+	nameFilterProbes = 3
+)
+
+// nameFilter is a Bloom filter of the package keys of a version index. Most dependency ranges on names that are not
+// part of the graph, such as typos and packages of private registries, are rejected by the first bit probed, which
+// is cheaper than the failed map lookup in a large index. Names are only ever added, so the names of removed packages
+// still pass, and their lookup fails as before.
+type nameFilter struct {
+	bits []uint64
+	mask uint64
+}
+
+// newNameFilter returns a filter of the names of the index, sized for the index to double before the filter lets
+// noticeably more unknown names through.
+func newNameFilter(index versionIndex) *nameFilter {
+	size := uint64(64)
+	for size < uint64(len(index))*nameFilterBits*2 {
+		size <<= 1
+	}
+	filter := &nameFilter{bits: make([]uint64, size/64), mask: size - 1}
+	for key := range index {
+		filter.add(key)
+	}
+	return filter
+}
+
+// add adds the package to the filter. A nil filter is left as it is.
+func (filter *nameFilter) add(key PackageKey) {
+	if filter == nil {
+		return
+	}
+	h1, h2 := nameHashes(key)
+	for i := uint64(0); i < nameFilterProbes; i++ {
+		bit := (h1 + i*h2) & filter.mask
+		filter.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// mayContain returns false if the package was certainly never added. A nil filter contains every package.
+func (filter *nameFilter) mayContain(key PackageKey) bool {
+	if filter == nil {
+		return true
+	}
+	h1, h2 := nameHashes(key)
+	for i := uint64(0); i < nameFilterProbes; i++ {
+		bit := (h1 + i*h2) & filter.mask
+		if filter.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// nameHashes returns the two hashes from which the probed bits are derived, from the 64-bit FNV-1a hash of the
+// ecosystem and the scope, each followed by a NUL byte, and the name. It hashes the strings in place, without the
+// allocation of a hash.Hash64.
+func nameHashes(key PackageKey) (uint64, uint64) {
+	h := uint64(14695981039346656037)
+	for _, part := range [...]string{key.Ecosystem, "\x00", key.Scope, "\x00", key.Name} {
+		for i := 0; i < len(part); i++ {
+			h ^= uint64(part[i])
+			h *= 1099511628211
+		}
+	}
+	return h, h>>32 | 1
+}
@@ -0,0 +1,83 @@
+package graph
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// resolveAllPackages returns the edges of every package of the graph, with or without the name filter.
+func resolveAllPackages(pg *PackageGraph, filtered bool, report *ResolutionReport) map[[2]int64]bool {
+	resolver := pg.resolver()
+	if !filtered {
+		resolver.names = nil
+	}
+	edges := make(map[[2]int64]bool)
+	for i := range *pg.Packages {
+		for _, edge := range resolver.resolvePackage(&(*pg.Packages)[i], report) {
+			edges[edge] = true
+		}
+	}
+	return edges
+}
+
+func TestNameFilter(t *testing.T) {
+	t.Run("Contains every added name", func(t *testing.T) {
+		index := make(versionIndex)
+		for i := 0; i < 1000; i++ {
+			index[NewPackageKey("", fmt.Sprintf("package-%d", i))] = nil
+		}
+		filter := newNameFilter(index)
+		for name := range index {
+			if !filter.mayContain(name) {
+				t.Fatalf("Expected %s to pass the filter", name)
+			}
+		}
+		passed := 0
+		for i := 0; i < 1000; i++ {
+			if filter.mayContain(NewPackageKey("", fmt.Sprintf("@private/package-%d", i))) {
+				passed++
+			}
+		}
+		if passed > 50 {
+			t.Errorf("Expected few unknown names to pass the filter, got %d of 1000", passed)
+		}
+		npm := 0
+		for i := 0; i < 1000; i++ {
+			if filter.mayContain(NewPackageKey("npm", fmt.Sprintf("package-%d", i))) {
+				npm++
+			}
+		}
+		if npm > 50 {
+			t.Errorf("Expected few names of another ecosystem to pass the filter, got %d of 1000", npm)
+		}
+		if !(*nameFilter)(nil).mayContain(NewPackageKey("", "anything")) {
+			t.Error("Expected a nil filter to contain every name")
+		}
+	})
+
+	t.Run("Resolves the same edges and outcomes as without the filter", func(t *testing.T) {
+		packagesInfo := testPackages("unknown names")
+		pg := NewPackageGraph(&packagesInfo, false)
+		var filteredReport, unfilteredReport ResolutionReport
+		filtered, unfiltered := resolveAllPackages(pg, true, &filteredReport), resolveAllPackages(pg, false, &unfilteredReport)
+		if !reflect.DeepEqual(filtered, unfiltered) {
+			t.Errorf("Expected %d edges, got %d", len(unfiltered), len(filtered))
+		}
+		if filteredReport.UnknownPackage != 1200 || !reflect.DeepEqual(filteredReport, unfilteredReport) {
+			t.Errorf("Expected the report %+v, got %+v", unfilteredReport, filteredReport)
+		}
+	})
+
+	t.Run("Passes the names of added packages", func(t *testing.T) {
+		packagesInfo := testPackages("unknown names")
+		pg := NewPackageGraph(&packagesInfo, false)
+		if err := pg.AddVersion("@private/package-0-1", "1.0.0", VersionInfo{Timestamp: "2022-03-01T00:00:00"}); err != nil {
+			t.Fatal(err)
+		}
+		dependents, _ := pg.Dependents(NameVersion{Name: "@private/package-0-1", Version: "1.0.0"}, 1)
+		if len(dependents) != 2 {
+			t.Errorf("Expected both versions of package-0 to depend on the added package, got %v", dependents)
+		}
+	})
+}
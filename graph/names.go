@@ -0,0 +1,116 @@
+package graph
+
+import (
+	"net/url"
+	"strings"
+)
+
+// decodeName decodes a URL-encoded name, returning the name as is if it is not encoded or not validly encoded.
+func decodeName(name string) string {
+	if !strings.Contains(name, "%") {
+		return name
+	}
+	decoded, err := url.PathUnescape(name)
+	if err != nil {
+		return name
+	}
+	return decoded
+}
+
+// NameNormalization determines how the package names in the input, both of the packages and of their dependencies,
+// are normalized before the graph is built. Lookups by name normalize the name the same way.
+type NameNormalization int
+
+const (
+	// DecodeNames decodes URL-encoded names such as "%40scope%2Fname" to "@scope/name". This is the default, as an
+	// encoded name otherwise never matches the name it encodes.
+	DecodeNames NameNormalization = iota
+	// KeepNames uses the names exactly as they appear in the input.
+	KeepNames
+	// NpmNames decodes the names and lowercases them, as npm names are case-insensitive. It is the default for the
+	// packages of the "npm" ecosystem.
+	NpmNames
+)
+
+// NormalizeName normalizes a package name.
+func NormalizeName(name string, normalization NameNormalization) string {
+	switch normalization {
+	case KeepNames:
+		return name
+	case NpmNames:
+		return strings.ToLower(decodeName(name))
+	}
+	return decodeName(name)
+}
+
+// normalizeName normalizes a name of the ecosystem that is looked up in the graph like the names the graph was built
+// from.
+func (pg *PackageGraph) normalizeName(ecosystem, name string) string {
+	return NormalizeName(name, pg.options.nameNormalization(ecosystem))
+}
+
+// normalizePackageNames returns the packages with normalized package and dependency names, normalizing the names of
+// every package and of its dependencies as the options normalize the names of its ecosystem. The input is returned as
+// is if no name changes; otherwise it is copied, leaving the caller's packages unchanged. If several dependencies of
+// a version normalize to the same name, the one that was already normalized is kept.
+func normalizePackageNames(packagesList *[]PackageInfo, options *Options) *[]PackageInfo {
+	if !needsNormalization(packagesList, options) {
+		return packagesList
+	}
+	normalized := make([]PackageInfo, len(*packagesList))
+	for i, packageInfo := range *packagesList {
+		normalization := options.nameNormalization(packageInfo.Ecosystem)
+		if normalization == KeepNames {
+			normalized[i] = packageInfo
+			continue
+		}
+		versions := make(map[string]VersionInfo, len(packageInfo.Versions))
+		for version, versionInfo := range packageInfo.Versions {
+			versionInfo.Dependencies = normalizeDependencyNames(versionInfo.Dependencies, normalization)
+			versionInfo.DevDependencies = normalizeDependencyNames(versionInfo.DevDependencies, normalization)
+			versionInfo.PeerDependencies = normalizeDependencyNames(versionInfo.PeerDependencies, normalization)
+			versionInfo.OptionalDependencies = normalizeDependencyNames(versionInfo.OptionalDependencies, normalization)
+			versions[version] = versionInfo
+		}
+		normalized[i] = PackageInfo{Name: NormalizeName(packageInfo.Name, normalization), Ecosystem: packageInfo.Ecosystem, Versions: versions, Provenance: packageInfo.Provenance}
+	}
+	return &normalized
+}
+
+func needsNormalization(packagesList *[]PackageInfo, options *Options) bool {
+	for _, packageInfo := range *packagesList {
+		normalization := options.nameNormalization(packageInfo.Ecosystem)
+		if normalization == KeepNames {
+			continue
+		}
+		changes := func(name string) bool { return NormalizeName(name, normalization) != name }
+		if changes(packageInfo.Name) {
+			return true
+		}
+		for _, versionInfo := range packageInfo.Versions {
+			for _, class := range []DependencyClass{Runtime, Development, Peer, Optional} {
+				for name := range versionInfo.DependenciesOf(class) {
+					if changes(name) {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+func normalizeDependencyNames(dependencies map[string]string, normalization NameNormalization) map[string]string {
+	if dependencies == nil {
+		return nil
+	}
+	normalized := make(map[string]string, len(dependencies))
+	for name, dependencyRange := range dependencies {
+		normalizedName := NormalizeName(name, normalization)
+		if _, exists := normalized[normalizedName]; exists && normalizedName != name {
+			continue
+		}
+		normalized[normalizedName] = dependencyRange
+	}
+	return normalized
+}
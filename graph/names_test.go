@@ -0,0 +1,138 @@
+package graph
+
+import (
+	"testing"
+)
+
+func TestNormalizeName(t *testing.T) {
+	t.Run("Decodes encoded scoped names", func(t *testing.T) {
+		for _, name := range []string{"@babel/core", "%40babel%2Fcore", "%40babel/core"} {
+			if normalized := NormalizeName(name, DecodeNames); normalized != "@babel/core" {
+				t.Errorf("Expected @babel/core for %s, got %s", name, normalized)
+			}
+		}
+	})
+
+	t.Run("Keeps unscoped and invalid names", func(t *testing.T) {
+		for _, name := range []string{"left-pad", "@", "@/core", "100%"} {
+			if normalized := NormalizeName(name, DecodeNames); normalized != name {
+				t.Errorf("Expected %s to be kept, got %s", name, normalized)
+			}
+		}
+	})
+}
+
+func TestNameNormalization(t *testing.T) {
+	t.Run("Resolves encoded dependency names by default", func(t *testing.T) {
+		packagesInfo := testPackages("names")
+		pg := NewPackageGraph(&packagesInfo, false)
+		if edges := edgeSet(pg); !edges[[2]string{"app-1.0.0", "@babel/core-7.1.0"}] || len(edges) != 1 {
+			t.Errorf("Expected only the edge to @babel/core, got %v", edges)
+		}
+		if _, ok := packagesInfo[0].Versions["1.0.0"].Dependencies["%40babel%2Fcore"]; !ok {
+			t.Error("Expected the input packages to be left unchanged")
+		}
+	})
+
+	t.Run("Finds nodes by either form of the name", func(t *testing.T) {
+		packagesInfo := testPackages("names")
+		pg := NewPackageGraph(&packagesInfo, false)
+		for _, name := range []string{"@babel/core", "%40babel%2Fcore"} {
+			if _, ok := pg.FindNode(NameVersion{Name: name, Version: "7.1.0"}); !ok {
+				t.Errorf("Expected to find %s@7.1.0", name)
+			}
+			if versions := pg.Versions(name); len(versions) != 1 {
+				t.Errorf("Expected 1 version of %s, got %d", name, len(versions))
+			}
+		}
+	})
+
+	t.Run("Lowercases npm names", func(t *testing.T) {
+		packagesInfo := testPackages("names")
+		pg := NewPackageGraph(&packagesInfo, false, WithNameNormalization(NpmNames))
+		if edges := edgeSet(pg); !edges[[2]string{"app-1.0.0", "left-pad-1.3.0"}] || len(edges) != 2 {
+			t.Errorf("Expected the edges to @babel/core and left-pad, got %v", edges)
+		}
+		if _, ok := pg.FindNode(NameVersion{Name: "Left-Pad", Version: "1.3.0"}); !ok {
+			t.Error("Expected to find Left-Pad@1.3.0")
+		}
+	})
+
+	t.Run("Normalizes the names of every ecosystem on its own", func(t *testing.T) {
+		packagesInfo := []PackageInfo{
+			{Name: "app", Ecosystem: "npm", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2022-01-01T00:00:00", Dependencies: map[string]string{"Left-Pad": "^1.0.0"}},
+			}},
+			{Name: "Left-Pad", Ecosystem: "npm", Versions: map[string]VersionInfo{
+				"1.3.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{}},
+			}},
+			{Name: "org.Example:App", Ecosystem: "maven", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2022-01-01T00:00:00", Dependencies: map[string]string{"org.Example:Lib": "^1.0.0"}},
+			}},
+			{Name: "org.Example:Lib", Ecosystem: "maven", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{}},
+			}},
+			{Name: "org.example:lib", Ecosystem: "maven", Versions: map[string]VersionInfo{
+				"1.1.0": {Timestamp: "2021-01-01T00:00:00", Dependencies: map[string]string{}},
+			}},
+		}
+		pg := NewPackageGraph(&packagesInfo, false)
+		edges := edgeSet(pg)
+		if !edges[[2]string{"npm:app-1.0.0", "npm:left-pad-1.3.0"}] || !edges[[2]string{"maven:org.Example:App-1.0.0", "maven:org.Example:Lib-1.0.0"}] || len(edges) != 2 {
+			t.Errorf("Expected the edges to npm left-pad and Maven org.Example:Lib, got %v", edges)
+		}
+		if node, ok := pg.FindNode(NameVersion{Name: "Left-Pad", Version: "1.3.0", Ecosystem: "npm"}); !ok || node.Name != "left-pad" {
+			t.Errorf("Expected Left-Pad@1.3.0 to be lowercased, got %v", node)
+		}
+		if versions := pg.Versions("maven:org.Example:Lib"); len(versions) != 1 || versions[0].Version != "1.0.0" {
+			t.Errorf("Expected org.Example:Lib to keep its case, got %v", versions)
+		}
+		if versions := pg.Versions("maven:org.example:lib"); len(versions) != 1 || versions[0].Version != "1.1.0" {
+			t.Errorf("Expected org.example:lib to be a package of its own, got %v", versions)
+		}
+	})
+
+	t.Run("Uses the normalization set for an ecosystem", func(t *testing.T) {
+		packagesInfo := []PackageInfo{
+			{Name: "Left-Pad", Ecosystem: "npm", Versions: map[string]VersionInfo{
+				"1.3.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{}},
+			}},
+			{Name: "Flask", Ecosystem: "pypi", Versions: map[string]VersionInfo{
+				"2.0.0": {Timestamp: "2021-01-01T00:00:00", Dependencies: map[string]string{}},
+			}},
+		}
+		pg := NewPackageGraph(&packagesInfo, false, WithNameNormalization(KeepNames), WithEcosystemNameNormalization("pypi", NpmNames))
+		if _, ok := pg.FindNode(NameVersion{Name: "flask", Version: "2.0.0", Ecosystem: "pypi"}); !ok {
+			t.Error("Expected the pypi names to be lowercased")
+		}
+		if versions := pg.Versions("npm:left-pad"); len(versions) != 1 {
+			t.Errorf("Expected the npm names to stay lowercased, got %v", versions)
+		}
+		if packagesInfo[1].Name != "Flask" {
+			t.Error("Expected the input to be left unchanged")
+		}
+	})
+
+	t.Run("Keeps the names as they are", func(t *testing.T) {
+		packagesInfo := testPackages("names")
+		pg := NewPackageGraph(&packagesInfo, false, WithNameNormalization(KeepNames))
+		if edges := edgeSet(pg); len(edges) != 0 {
+			t.Errorf("Expected no edges, got %v", edges)
+		}
+	})
+
+	t.Run("Normalizes the names of added versions", func(t *testing.T) {
+		packagesInfo := testPackages("names")
+		pg := NewPackageGraph(&packagesInfo, false)
+		err := pg.AddVersion("%40babel%2Fcli", "7.0.0", VersionInfo{
+			Timestamp:    "2022-01-01T00:00:00",
+			Dependencies: map[string]string{"%40babel%2Fcore": "^7.0.0"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if edges := edgeSet(pg); !edges[[2]string{"@babel/cli-7.0.0", "@babel/core-7.1.0"}] {
+			t.Errorf("Expected an edge from @babel/cli to @babel/core, got %v", edges)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package graph
+
+import (
+	"math/bits"
+)
+
+// NodeSet is a set of versions of a graph, stored as a bitset over the positions of the nodes in Nodes, so that the
+// set operations take a word per 64 nodes instead of a lookup per version. The operations leave their operands as they
+// are and return a new set. A set belongs to the graph it was created from: combining sets of different graphs, or
+// using a set after the graph was reloaded, gives meaningless results. Versions removed since are left out, but a
+// version added after a removal may take the position of the removed one and then be part of the older sets.
+type NodeSet struct {
+	pg    *PackageGraph
+	words []uint64
+}
+
+// DependentsSet returns the versions that depend on any of the roots, directly or transitively, like the union of
+// Dependents with a maxDepth of -1 of every root. A root is only part of the set if it depends on another root. Roots
+// that are not part of the graph are ignored. With WithLazyEdges, the incoming edges of every version in the set are
+// created.
+func DependentsSet(pg *PackageGraph, roots []NameVersion) NodeSet {
+	if pg.lockEdges() {
+		defer pg.lazyMutex.Unlock()
+	}
+	set := NodeSet{pg: pg, words: make([]uint64, (len(pg.Nodes)+63)/64)}
+	var queue []int64
+	for _, root := range roots {
+		if info, ok := pg.FindNode(root); ok {
+			queue = append(queue, info.id)
+		}
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		pg.resolveDependents(id)
+		dependents := pg.DependencyGraph().To(id)
+		for dependents.Next() {
+			dependent := dependents.Node().ID()
+			if slot, ok := pg.slot(dependent); ok && !set.hasSlot(slot) {
+				set.words[slot/64] |= 1 << (slot % 64)
+				queue = append(queue, dependent)
+			}
+		}
+	}
+	return set
+}
+
+// nodeSet returns the set of the versions with the node IDs, ignoring the IDs that are not part of the graph.
+func (pg *PackageGraph) nodeSet(ids []int64) NodeSet {
+	set := NodeSet{pg: pg, words: make([]uint64, (len(pg.Nodes)+63)/64)}
+	for _, id := range ids {
+		if slot, ok := pg.slot(id); ok {
+			set.words[slot/64] |= 1 << (slot % 64)
+		}
+	}
+	return set
+}
+
+// hasSlot reports whether the node at the position of Nodes is part of the set.
+func (set NodeSet) hasSlot(slot int) bool {
+	return slot/64 < len(set.words) && set.words[slot/64]&(1<<(slot%64)) != 0
+}
+
+// combine returns the set of the words of set and other combined by op. The result is as long as the longer set, so
+// the words missing from the shorter one, of versions added after it was created, count as empty.
+func (set NodeSet) combine(other NodeSet, op func(a, b uint64) uint64) NodeSet {
+	pg := set.pg
+	if pg == nil {
+		pg = other.pg
+	}
+	length := len(set.words)
+	if len(other.words) > length {
+		length = len(other.words)
+	}
+	result := NodeSet{pg: pg, words: make([]uint64, length)}
+	for i := range result.words {
+		var a, b uint64
+		if i < len(set.words) {
+			a = set.words[i]
+		}
+		if i < len(other.words) {
+			b = other.words[i]
+		}
+		result.words[i] = op(a, b)
+	}
+	return result
+}
+
+// Union returns the versions that are part of either set.
+func (set NodeSet) Union(other NodeSet) NodeSet {
+	return set.combine(other, func(a, b uint64) uint64 { return a | b })
+}
+
+// Intersect returns the versions that are part of both sets.
+func (set NodeSet) Intersect(other NodeSet) NodeSet {
+	return set.combine(other, func(a, b uint64) uint64 { return a & b })
+}
+
+// Difference returns the versions of set that are not part of other.
+func (set NodeSet) Difference(other NodeSet) NodeSet {
+	return set.combine(other, func(a, b uint64) uint64 { return a &^ b })
+}
+
+// Contains reports whether the version is part of the set.
+func (set NodeSet) Contains(nameVersion NameVersion) bool {
+	if set.pg == nil {
+		return false
+	}
+	info, ok := set.pg.FindNode(nameVersion)
+	if !ok {
+		return false
+	}
+	slot, ok := set.pg.slot(info.id)
+	return ok && set.hasSlot(slot)
+}
+
+// Len returns the number of versions in the set, counting versions removed from the graph since.
+func (set NodeSet) Len() int {
+	count := 0
+	for _, word := range set.words {
+		count += bits.OnesCount64(word)
+	}
+	return count
+}
+
+// IDs returns the node IDs of the versions in the set, in the order of Nodes.
+func (set NodeSet) IDs() []int64 {
+	ids := make([]int64, 0, set.Len())
+	for i, word := range set.words {
+		for word != 0 {
+			slot := i*64 + bits.TrailingZeros64(word)
+			word &= word - 1
+			if slot < len(set.pg.Nodes) && set.pg.Nodes[slot].stringID != "" {
+				ids = append(ids, set.pg.Nodes[slot].id)
+			}
+		}
+	}
+	return ids
+}
+
+// NodeInfos returns the node information of the versions in the set, sorted by name and version.
+func (set NodeSet) NodeInfos() []NodeInfo {
+	if set.pg == nil {
+		return nil
+	}
+	ids := set.IDs()
+	set.pg.sortNodeIDs(ids, SortByName)
+	nodes := make([]NodeInfo, len(ids))
+	for i, id := range ids {
+		nodes[i] = set.pg.node(id)
+	}
+	return nodes
+}
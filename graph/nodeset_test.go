@@ -0,0 +1,65 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNodeSet(t *testing.T) {
+	lib, web, cli := NameVersion{Name: "Lib", Version: "1.0.0"}, NameVersion{Name: "Web", Version: "1.0.0"}, NameVersion{Name: "Cli", Version: "1.0.0"}
+
+	t.Run("Combines the dependents of several roots", func(t *testing.T) {
+		for _, opts := range [][]Option{nil, {WithIDScheme(HashedIDs)}, {WithLazyEdges()}} {
+			packagesInfo := testPackages("page")
+			pg := NewPackageGraph(&packagesInfo, false, opts...)
+			webDependents, cliDependents := DependentsSet(pg, []NameVersion{web}), DependentsSet(pg, []NameVersion{cli})
+			tests := []struct {
+				set      NodeSet
+				expected []string
+			}{
+				{DependentsSet(pg, []NameVersion{lib, web, {Name: "Unknown", Version: "1.0.0"}}), []string{"App", "Blog", "Cli", "Site", "Web"}},
+				{webDependents.Union(cliDependents), []string{"Blog", "Site"}},
+				{webDependents.Intersect(cliDependents), []string{"Blog"}},
+				{webDependents.Difference(cliDependents), []string{"Site"}},
+				{cliDependents.Difference(webDependents), []string{}},
+			}
+			for _, test := range tests {
+				if names := pageNames(test.set.NodeInfos()); !reflect.DeepEqual(names, test.expected) {
+					t.Errorf("Expected %v, got %v", test.expected, names)
+				}
+				if test.set.Len() != len(test.expected) || len(test.set.IDs()) != len(test.expected) {
+					t.Errorf("Expected %d versions, got %d", len(test.expected), test.set.Len())
+				}
+			}
+			if !webDependents.Contains(NameVersion{Name: "Site", Version: "1.0.0"}) || webDependents.Contains(cli) {
+				t.Error("Expected the dependents of Web to contain Site but not Cli")
+			}
+		}
+	})
+
+	t.Run("Keeps added versions out of older sets and leaves out removed ones", func(t *testing.T) {
+		packagesInfo := testPackages("page")
+		pg := NewPackageGraph(&packagesInfo, false)
+		before := DependentsSet(pg, []NameVersion{lib})
+		if err := pg.AddVersion("Tool", "1.0.0", VersionInfo{Timestamp: "2022-04-01T00:00:00", Dependencies: map[string]string{"Cli": "1.0.0"}}); err != nil {
+			t.Fatal(err)
+		}
+		after := DependentsSet(pg, []NameVersion{lib})
+		if names := pageNames(after.Difference(before).NodeInfos()); !reflect.DeepEqual(names, []string{"Tool"}) {
+			t.Errorf("Expected only the added version to be new, got %v", names)
+		}
+		if err := pg.RemoveVersion("Site", "1.0.0", false); err != nil {
+			t.Fatal(err)
+		}
+		if names := pageNames(before.NodeInfos()); !reflect.DeepEqual(names, []string{"App", "Blog", "Cli", "Web"}) {
+			t.Errorf("Expected the removed version to be left out, got %v", names)
+		}
+	})
+
+	t.Run("Is empty as the zero value", func(t *testing.T) {
+		var empty NodeSet
+		if empty.Len() != 0 || len(empty.NodeInfos()) != 0 || empty.Contains(lib) {
+			t.Error("Expected the zero set to be empty")
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package graph
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+// NormalizeVersion rewrites a version string as found in the datasets into a full semver version: surrounding spaces
+// and a leading "v" or "=" are removed and a missing minor or patch number is padded with zeros, so "v1.2" becomes
+// "1.2.0". The prerelease and build metadata are kept as they are. Four-part versions such as "1.2.3.4", which some
+// ecosystems use, are truncated to "1.2.3" if truncateFourPart is set and rejected otherwise.
+func NormalizeVersion(version string, truncateFourPart bool) (string, error) {
+	normalized := strings.TrimSpace(version)
+	normalized = strings.TrimPrefix(normalized, "=")
+	normalized = strings.TrimLeft(normalized, "vV")
+
+	core, suffix := normalized, ""
+	if i := strings.IndexAny(normalized, "-+"); i >= 0 {
+		core, suffix = normalized[:i], normalized[i:]
+	}
+	parts := strings.Split(core, ".")
+	if len(parts) == 4 && truncateFourPart {
+		parts = parts[:3]
+	}
+	if len(parts) > 3 {
+		return "", fmt.Errorf("version %q has more than three parts", version)
+	}
+	for _, part := range parts {
+		if part == "" || strings.Trim(part, "0123456789") != "" {
+			return "", fmt.Errorf("version %q is not a semver version", version)
+		}
+	}
+	for len(parts) < 3 {
+		parts = append(parts, "0")
+	}
+	return strings.Join(parts, ".") + suffix, nil
+}
+
+// parseVersion parses a version string after normalizing it with NormalizeVersion.
+func parseVersion(version string, truncateFourPart bool) (*semver.Version, error) {
+	normalized, err := NormalizeVersion(version, truncateFourPart)
+	if err != nil {
+		return nil, err
+	}
+	return semver.NewVersion(normalized)
+}
+
+// fourPartVersionRegex matches the four-part versions within a range, keeping the first three parts in a group.
+var fourPartVersionRegex = regexp.MustCompile(`\b(\d+\.\d+\.\d+)\.\d+\b`)
+
+// normalizeRange rewrites the four-part versions within a range to their first three parts if truncateFourPart is
+// set. The other forms accepted by NormalizeVersion are already understood by the semver constraint parser.
+func normalizeRange(dependencyRange string, truncateFourPart bool) string {
+	if !truncateFourPart {
+		return dependencyRange
+	}
+	return fourPartVersionRegex.ReplaceAllString(dependencyRange, "$1")
+}
@@ -0,0 +1,99 @@
+package graph
+
+import (
+	"testing"
+)
+
+func TestNormalizeVersion(t *testing.T) {
+	t.Run("Normalizes the version forms found in the datasets", func(t *testing.T) {
+		for version, expected := range map[string]string{
+			"1.0":                 "1.0.0",
+			"v2.3.1":              "2.3.1",
+			"=1":                  "1.0.0",
+			" 1.2.3 ":             "1.2.3",
+			"2.0.0-beta.1+build5": "2.0.0-beta.1+build5",
+			"1.2-rc.1":            "1.2.0-rc.1",
+			"1.2.3.4":             "1.2.3",
+			"1.2.3.4-beta":        "1.2.3-beta",
+		} {
+			normalized, err := NormalizeVersion(version, true)
+			if err != nil || normalized != expected {
+				t.Errorf("Expected %q to normalize to %q, got %q and %v", version, expected, normalized, err)
+			}
+		}
+	})
+
+	t.Run("Rejects four-part versions without truncation", func(t *testing.T) {
+		if normalized, err := NormalizeVersion("1.2.3.4", false); err == nil {
+			t.Errorf("Expected an error, got %q", normalized)
+		}
+	})
+
+	t.Run("Rejects versions that are not semver", func(t *testing.T) {
+		for _, version := range []string{"latest", "", "1..2", "1.2.3.4.5", "1.x"} {
+			if normalized, err := NormalizeVersion(version, true); err == nil {
+				t.Errorf("Expected an error for %q, got %q", version, normalized)
+			}
+		}
+	})
+}
+
+func TestVersionNormalization(t *testing.T) {
+	t.Run("Skips and counts unparseable versions", func(t *testing.T) {
+		packagesInfo := testPackages("normalization")
+		report := &ResolutionReport{}
+		pg := NewPackageGraph(&packagesInfo, false, WithResolutionReport(report))
+		if report.UnparseableVersions != 2 {
+			t.Errorf("Expected 2 unparseable versions, got %d", report.UnparseableVersions)
+		}
+		if report.Unsatisfied != 1 {
+			t.Errorf("Expected the range to be unsatisfied, got %+v", report)
+		}
+		if pg.Graph.Edges().Len() != 0 {
+			t.Errorf("Expected no edges, got %d", pg.Graph.Edges().Len())
+		}
+	})
+
+	t.Run("Matches truncated four-part versions", func(t *testing.T) {
+		packagesInfo := testPackages("normalization")
+		report := &ResolutionReport{}
+		pg := NewPackageGraph(&packagesInfo, false, WithTruncatedVersions(), WithResolutionReport(report))
+		if report.UnparseableVersions != 1 {
+			t.Errorf("Expected 1 unparseable version, got %d", report.UnparseableVersions)
+		}
+		app, _ := pg.FindNode(NameVersion{Name: "App", Version: "1.0.0"})
+		a, _ := pg.FindNode(NameVersion{Name: "A", Version: "1.2.3.4"})
+		if !pg.Graph.HasEdgeFromTo(app.id, a.id) || pg.Graph.Edges().Len() != 1 {
+			t.Errorf("Expected a single edge to A 1.2.3.4, got %v", edgeSet(pg))
+		}
+	})
+
+	t.Run("Normalizes four-part versions within ranges", func(t *testing.T) {
+		packagesInfo := testPackages("normalization")
+		packagesInfo[0].Versions["1.0.0"].Dependencies["A"] = "1.2.3.4"
+		pg := NewPackageGraph(&packagesInfo, false, WithTruncatedVersions())
+		if pg.Graph.Edges().Len() != 1 {
+			t.Errorf("Expected a single edge, got %v", edgeSet(pg))
+		}
+	})
+
+	t.Run("Matches padded versions when added incrementally", func(t *testing.T) {
+		packagesInfo := testPackages("normalization")
+		report := &ResolutionReport{}
+		pg := NewPackageGraph(&packagesInfo, false, WithResolutionReport(report))
+		if err := pg.AddVersion("A", "v1.3", VersionInfo{Timestamp: "2021-01-01T00:00:00"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if err := pg.AddVersion("A", "1.4.0.0", VersionInfo{Timestamp: "2021-02-01T00:00:00"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		app, _ := pg.FindNode(NameVersion{Name: "App", Version: "1.0.0"})
+		a, _ := pg.FindNode(NameVersion{Name: "A", Version: "v1.3"})
+		if !pg.Graph.HasEdgeFromTo(app.id, a.id) || pg.Graph.Edges().Len() != 1 {
+			t.Errorf("Expected a single edge to A v1.3, got %v", edgeSet(pg))
+		}
+		if report.UnparseableVersions != 3 {
+			t.Errorf("Expected 3 unparseable versions, got %d", report.UnparseableVersions)
+		}
+	})
+}
@@ -0,0 +1,447 @@
+package graph
+
+import (
+	"time"
+)
+
+// Resolution determines to which of the versions satisfying a dependency range an edge is created.
+type Resolution int
+
+const (
+	// ResolveAll creates an edge to every version that satisfies the range. These are all the versions that could
+	// possibly be installed.
+	ResolveAll Resolution = iota
+	// ResolveHighest creates a single edge to the highest version that satisfies the range, which is the version a
+	// package manager would install.
+	ResolveHighest
+)
+
+// PrereleasePolicy determines whether prerelease versions such as "1.1.0-rc.1" can satisfy a dependency range.
+type PrereleasePolicy int
+
+const (
+	// ExcludePrereleases never matches prerelease versions, even if the range mentions a prerelease.
+	ExcludePrereleases PrereleasePolicy = iota
+	// IncludeIfRangeHasPrerelease only matches a prerelease version against the comparators of the range that have a
+	// prerelease themselves, so "^1.0.0" does not match "1.1.0-rc.1" but ">=1.1.0-rc.0" does. This is the behavior of
+	// the semver library and the default.
+	IncludeIfRangeHasPrerelease
+	// AlwaysIncludePrereleases also matches a prerelease version if both its release version and the release before
+	// it satisfy the range, which approximates ignoring the prerelease rule of the semver library. So "^1.0.0"
+	// matches "1.1.0-rc.1", but not "1.0.0-rc.1" or "2.0.0-rc.1". Prereleases just above an exclusive lower bound,
+	// such as "1.0.1-rc.1" for ">1.0.0", are not matched either.
+	AlwaysIncludePrereleases
+)
+
+// DependencyClass is the kind of dependency declaration, following the fields used by npm.
+type DependencyClass int
+
+const (
+	// Runtime dependencies are declared in the "dependencies" field.
+	Runtime DependencyClass = iota
+	// Development dependencies are declared in the "devDependencies" field.
+	Development
+	// Peer dependencies are declared in the "peerDependencies" field.
+	Peer
+	// Optional dependencies are declared in the "optionalDependencies" field.
+	Optional
+	// CrossEcosystem is the class of the edges between ecosystems added by MergeWithCrossEdges. No declaration has
+	// this class.
+	CrossEcosystem
+)
+
+func (class DependencyClass) String() string {
+	switch class {
+	case Runtime:
+		return "runtime"
+	case Development:
+		return "dev"
+	case Peer:
+		return "peer"
+	case Optional:
+		return "optional"
+	case CrossEcosystem:
+		return "cross-ecosystem"
+	}
+	return "unknown"
+}
+
+// Options holds the settings used while constructing a PackageGraph. The zero value is not valid, use the defaults
+// from newOptions instead.
+type Options struct {
+	// Resolution determines to which satisfying versions edges are created.
+	Resolution Resolution
+	// DependencyClasses are the kinds of dependencies that edges are created for.
+	DependencyClasses []DependencyClass
+	// Cutoff, if not zero, excludes the versions released after it. Versions without a parseable timestamp are kept.
+	Cutoff time.Time
+	// WindowStart and WindowEnd, if not zero, exclude the versions released before WindowStart or at or after
+	// WindowEnd, see WithTimeWindow. Versions without a parseable timestamp are kept, unless
+	// ExcludeUnparseableTimestamps is set.
+	WindowStart                  time.Time
+	WindowEnd                    time.Time
+	ExcludeUnparseableTimestamps bool
+	// NameFilter, if not nil, excludes the packages for which it returns false.
+	NameFilter func(ecosystem, name string) bool
+	// Ecosystems, if not empty, are the ecosystems whose packages are included.
+	Ecosystems []string
+	// Workers is the number of goroutines matching dependency ranges while creating edges.
+	Workers int
+	// Progress, if not nil, is called after the edges of every package have been created.
+	Progress func(done, total int)
+	// Report, if not nil, counts how the dependency declarations were resolved.
+	Report *ResolutionReport
+	// TruncateFourPartVersions makes versions and ranges with four-part versions such as "1.2.3.4" match as if the
+	// fourth part was left out. Otherwise such versions are never matched.
+	TruncateFourPartVersions bool
+	// Prereleases determines whether prerelease versions can satisfy the ranges.
+	Prereleases PrereleasePolicy
+	// Matcher, if not nil, parses the dependency ranges instead of a SemverMatcher with the settings above.
+	Matcher RangeMatcher
+	// PreferNonDeprecated makes ResolveHighest pick the highest satisfying version that is not deprecated, and only
+	// fall back to the highest deprecated version if every satisfying version is deprecated.
+	PreferNonDeprecated bool
+	// Names determines how the package names of the input are normalized.
+	Names NameNormalization
+	// EcosystemNames determines how the package names of the ecosystems in it are normalized instead of Names, see
+	// WithEcosystemNameNormalization.
+	EcosystemNames map[string]NameNormalization
+	// Logger, if not nil, receives the timing of every construction stage. A nil logger logs nothing.
+	Logger Logger
+	// LazyEdges defers the creation of the edges of every version until a query needs them.
+	LazyEdges bool
+	// ExternalEdges, if not empty, is the directory to which the edges are written instead of the graph, see
+	// WithExternalEdges.
+	ExternalEdges string
+	// ExternalRunSize is the number of edges sorted in memory at a time with ExternalEdges.
+	ExternalRunSize int
+	// StageHook, if not nil, is called around every named stage, see WithStageHook.
+	StageHook StageHook
+	// IDScheme determines the IDs of the nodes, see WithIDScheme.
+	IDScheme IDScheme
+	// SampleFraction, if not zero, is the fraction of the packages whose edges EstimateEdges counts, drawn with
+	// SampleSeed. Graphs are always built from all the packages.
+	SampleFraction float64
+	SampleSeed     int64
+	// SplitByClass also builds a graph for every dependency class, see WithSplitByClass.
+	SplitByClass bool
+	// Corrupt, if not nil, collects the corrupt records that ReadPackagesJSON skipped, of which there may be at most
+	// MaxCorrupt, or any number if it is negative, see WithSkipCorrupt.
+	Corrupt    *CorruptInputReport
+	MaxCorrupt int
+	// Trace, if not nil, records the resolution decisions on the traced dependencies, see WithTrace.
+	Trace *Tracer
+	// EdgeDirection is the direction in which the edges are stored, see WithEdgeDirection.
+	EdgeDirection EdgeDirection
+	// LatestIndex builds the index of LatestVersion up front, see WithLatestIndex.
+	LatestIndex bool
+	// Provenance records where every package was decoded from, see WithProvenance.
+	Provenance bool
+	// Aliases, if not nil, redirects the dependencies on renamed packages, see WithAliases.
+	Aliases *AliasTable
+}
+
+// Option configures the construction of a PackageGraph.
+type Option func(*Options)
+
+// newOptions applies the options on top of the defaults, which reproduce the behavior of CreateEdges without options:
+// edges to every satisfying version of every runtime dependency, using a single worker.
+func newOptions(opts []Option) *Options {
+	options := &Options{
+		Resolution:        ResolveAll,
+		DependencyClasses: []DependencyClass{Runtime},
+		Workers:           1,
+		Prereleases:       IncludeIfRangeHasPrerelease,
+		EcosystemNames:    defaultEcosystemNames(),
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.Workers < 1 {
+		options.Workers = 1
+	}
+	return options
+}
+
+// WithResolution sets to which of the satisfying versions edges are created.
+func WithResolution(resolution Resolution) Option {
+	return func(options *Options) {
+		options.Resolution = resolution
+	}
+}
+
+// WithDependencyClasses sets the kinds of dependencies that edges are created for.
+func WithDependencyClasses(classes ...DependencyClass) Option {
+	return func(options *Options) {
+		options.DependencyClasses = classes
+	}
+}
+
+// WithCutoff builds the graph as it was at the cutoff, leaving out the versions released after it.
+func WithCutoff(cutoff time.Time) Option {
+	return func(options *Options) {
+		options.Cutoff = cutoff
+	}
+}
+
+// WithTimeWindow only includes the versions released within [start, end), so that a specific period can be studied. A
+// zero start or end leaves that side of the window open. Unlike the other options, the window is also applied while
+// ReadPackagesJSON and OpenPackageGraph decode the input, so the versions outside it are never stored and the memory
+// use is proportional to the window. Packages without a version in the window are left out.
+func WithTimeWindow(start, end time.Time) Option {
+	return func(options *Options) {
+		options.WindowStart = start
+		options.WindowEnd = end
+	}
+}
+
+// WithoutUnparseableTimestamps leaves the versions whose timestamp cannot be parsed out of the time window of
+// WithTimeWindow, instead of keeping them.
+func WithoutUnparseableTimestamps() Option {
+	return func(options *Options) {
+		options.ExcludeUnparseableTimestamps = true
+	}
+}
+
+// WithNameFilter only includes the packages for which the filter returns true. It is called with the ecosystem of the
+// package, which is empty for the packages without one, and its name within the ecosystem.
+func WithNameFilter(filter func(ecosystem, name string) bool) Option {
+	return func(options *Options) {
+		options.NameFilter = filter
+	}
+}
+
+// WithWorkers sets the number of goroutines that match dependency ranges in parallel.
+func WithWorkers(workers int) Option {
+	return func(options *Options) {
+		options.Workers = workers
+	}
+}
+
+// WithProgress registers a function that is called with the number of packages whose edges have been created so far.
+func WithProgress(progress func(done, total int)) Option {
+	return func(options *Options) {
+		options.Progress = progress
+	}
+}
+
+// WithResolutionReport counts the outcome of every dependency declaration in report while the edges are created. The
+// counts are added to the report, so it should be empty. Versions added to the graph later are counted as well.
+func WithResolutionReport(report *ResolutionReport) Option {
+	return func(options *Options) {
+		options.Report = report
+	}
+}
+
+// WithTruncatedVersions matches four-part versions such as "1.2.3.4" by their first three parts, see NormalizeVersion.
+func WithTruncatedVersions() Option {
+	return func(options *Options) {
+		options.TruncateFourPartVersions = true
+	}
+}
+
+// WithPrereleases sets whether prerelease versions can satisfy the ranges, both for ResolveAll and ResolveHighest.
+func WithPrereleases(policy PrereleasePolicy) Option {
+	return func(options *Options) {
+		options.Prereleases = policy
+	}
+}
+
+// WithRangeMatcher parses the dependency ranges with the matcher, for ecosystems whose ranges are not semver ranges.
+// The settings for Maven ranges, four-part versions and prereleases only apply to the default SemverMatcher. The
+// matcher is not saved in a graph cache, so versions added to a loaded graph are matched as semver again.
+func WithRangeMatcher(matcher RangeMatcher) Option {
+	return func(options *Options) {
+		options.Matcher = matcher
+	}
+}
+
+// WithPreferNonDeprecated makes ResolveHighest avoid deprecated versions like npm does, resolving to a deprecated
+// version only if no other version satisfies the range. It has no effect on ResolveAll.
+func WithPreferNonDeprecated() Option {
+	return func(options *Options) {
+		options.PreferNonDeprecated = true
+	}
+}
+
+// WithNameNormalization sets how the package names of the input are normalized, see NameNormalization, except for the
+// ecosystems of WithEcosystemNameNormalization.
+func WithNameNormalization(normalization NameNormalization) Option {
+	return func(options *Options) {
+		options.Names = normalization
+	}
+}
+
+// WithEcosystemNameNormalization sets how the package names of one ecosystem are normalized, overriding
+// WithNameNormalization for its packages. By default npm names use NpmNames, as they are case-insensitive, and Maven
+// coordinates use KeepNames, as they are case-sensitive.
+func WithEcosystemNameNormalization(ecosystem string, normalization NameNormalization) Option {
+	return func(options *Options) {
+		ecosystemNames := make(map[string]NameNormalization, len(options.EcosystemNames)+1)
+		for name, existing := range options.EcosystemNames {
+			ecosystemNames[name] = existing
+		}
+		ecosystemNames[ecosystem] = normalization
+		options.EcosystemNames = ecosystemNames
+	}
+}
+
+// defaultEcosystemNames returns the name normalization of the ecosystems whose names are not normalized with Names
+// by default.
+func defaultEcosystemNames() map[string]NameNormalization {
+	return map[string]NameNormalization{"npm": NpmNames, "maven": KeepNames}
+}
+
+// nameNormalization returns how the package names of the ecosystem are normalized.
+func (options *Options) nameNormalization(ecosystem string) NameNormalization {
+	if normalization, ok := options.EcosystemNames[ecosystem]; ok {
+		return normalization
+	}
+	return options.Names
+}
+
+// WithLogger sends the messages and the timing of the parsing, the node creation and the edge creation to logger.
+func WithLogger(logger Logger) Option {
+	return func(options *Options) {
+		options.Logger = logger
+	}
+}
+
+// WithLazyEdges only creates the nodes and the version index on construction, and creates the edges of a version the
+// first time Dependencies, Dependents, Subgraph or ShortestPath reach it. The edges are the same as without the option,
+// but the startup of a service that only looks at small parts of a large graph takes a fraction of the time. The other
+// queries only see the edges created so far; use ResolveDependencies to create the edges of a package up front.
+func WithLazyEdges() Option {
+	return func(options *Options) {
+		options.LazyEdges = true
+	}
+}
+
+// WithExternalEdges writes the edges to an EdgeFile in dir instead of inserting them into the graph, for graphs whose
+// edges do not fit in memory. The edges are sorted runSize at a time, or about a million at a time if runSize is not
+// positive, and the sorted runs are merged into the file once all edges are resolved. The graph itself has no edges:
+// the exporters read them from the file, and LoadExternalEdges adds a part of them for the queries. EgoNetwork,
+// Subgraph, SampleSubgraph and SampleEdges load all of them on their first call, as they cannot extract a subgraph
+// otherwise. The option is ignored with WithLazyEdges.
+func WithExternalEdges(dir string, runSize int) Option {
+	return func(options *Options) {
+		options.ExternalEdges = dir
+		options.ExternalRunSize = runSize
+	}
+}
+
+// WithSampling makes EstimateEdges count the edges of a random fraction of the packages, drawn with the seed, and
+// extrapolate the counts to all of them. The ranges are still matched against all the versions. Building a graph
+// ignores the option.
+func WithSampling(fraction float64, seed int64) Option {
+	return func(options *Options) {
+		options.SampleFraction = fraction
+		options.SampleSeed = seed
+	}
+}
+
+// rangeMatcher returns the matcher that parses the ranges, caching the parsed ranges.
+func (options *Options) rangeMatcher(isMaven bool) RangeMatcher {
+	if options.Matcher != nil {
+		return NewCachedMatcher(options.Matcher)
+	}
+	return NewCachedMatcher(SemverMatcher{
+		Maven:                    isMaven,
+		TruncateFourPartVersions: options.TruncateFourPartVersions,
+		Prereleases:              options.Prereleases,
+	})
+}
+
+// filterPackages returns the packages and versions that pass the name filter, the ecosystems and the cutoff. The input
+// is returned as is when no filtering is configured.
+func filterPackages(packagesList *[]PackageInfo, options *Options) *[]PackageInfo {
+	if options.NameFilter == nil && options.Cutoff.IsZero() && len(options.Ecosystems) == 0 && !options.hasWindow() {
+		return packagesList
+	}
+	filtered := make([]PackageInfo, 0, len(*packagesList))
+	for _, packageInfo := range *packagesList {
+		if options.NameFilter != nil && !options.NameFilter(packageInfo.Ecosystem, packageInfo.Name) {
+			continue
+		}
+		if !options.includesEcosystem(packageInfo.Ecosystem) {
+			continue
+		}
+		allVersions := packageInfo.Versions
+		packageInfo, ok := options.windowPackage(packageInfo, false)
+		if !ok {
+			options.Trace.exclude(packageInfo.key(), allVersions, nil)
+			continue
+		}
+		if options.Cutoff.IsZero() {
+			options.Trace.exclude(packageInfo.key(), allVersions, packageInfo.Versions)
+			filtered = append(filtered, packageInfo)
+			continue
+		}
+		versions := make(map[string]VersionInfo, len(packageInfo.Versions))
+		for version, versionInfo := range packageInfo.Versions {
+			if releaseTime, err := ParseTimestamp(versionInfo.Timestamp); err == nil && releaseTime.After(options.Cutoff) {
+				continue
+			}
+			versions[version] = versionInfo
+		}
+		options.Trace.exclude(packageInfo.key(), allVersions, versions)
+		if len(versions) > 0 {
+			packageInfo.Versions = versions
+			filtered = append(filtered, packageInfo)
+		}
+	}
+	return &filtered
+}
+
+// hasWindow returns whether the options have a time window, see WithTimeWindow.
+func (options *Options) hasWindow() bool {
+	return !options.WindowStart.IsZero() || !options.WindowEnd.IsZero()
+}
+
+// inWindow returns whether the version was released within the time window of the options.
+func (options *Options) inWindow(versionInfo VersionInfo) bool {
+	if !options.hasWindow() {
+		return true
+	}
+	released, err := ParseTimestamp(versionInfo.Timestamp)
+	if err != nil {
+		return !options.ExcludeUnparseableTimestamps
+	}
+	return !released.Before(options.WindowStart) && (options.WindowEnd.IsZero() || released.Before(options.WindowEnd))
+}
+
+// windowPackage leaves the versions outside the time window out of the package, and returns false if none remain.
+// If owned is set, the versions are deleted from the versions map of the package, which only the caller refers to;
+// otherwise the map is copied once a version is left out.
+func (options *Options) windowPackage(packageInfo PackageInfo, owned bool) (PackageInfo, bool) {
+	if !options.hasWindow() {
+		return packageInfo, true
+	}
+	versions := packageInfo.Versions
+	for version, versionInfo := range packageInfo.Versions {
+		if options.inWindow(versionInfo) {
+			continue
+		}
+		if !owned {
+			versions = make(map[string]VersionInfo, len(packageInfo.Versions))
+			for version, versionInfo := range packageInfo.Versions {
+				if options.inWindow(versionInfo) {
+					versions[version] = versionInfo
+				}
+			}
+			break
+		}
+		delete(versions, version)
+	}
+	packageInfo.Versions = versions
+	return packageInfo, len(versions) > 0
+}
+
+// WithSplitByClass makes NewPackageGraph build a graph with the edges of every single dependency class in the same pass
+// over the packages as the graph itself, which ClassGraphs returns. With WithLazyEdges and WithExternalEdges, the
+// class graphs are built in a pass of their own once ClassGraphs is first called.
+func WithSplitByClass() Option {
+	return func(options *Options) {
+		options.SplitByClass = true
+	}
+}
@@ -0,0 +1,160 @@
+package graph
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// edgeSet returns the edges of the graph as name-version pairs, so graphs with different IDs can be compared.
+func edgeSet(pg *PackageGraph) map[[2]string]bool {
+	edges := make(map[[2]string]bool)
+	it := pg.Graph.Edges()
+	for it.Next() {
+		from := pg.node(it.Edge().From().ID()).stringID
+		to := pg.node(it.Edge().To().ID()).stringID
+		edges[[2]string{from, to}] = true
+	}
+	return edges
+}
+
+func TestPackageGraphOptions(t *testing.T) {
+	t.Run("Defaults create edges to every satisfying runtime dependency", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		edges := edgeSet(NewPackageGraph(&packagesInfo, false))
+		if len(edges) != 3 {
+			t.Errorf("Expected 3 edges, got %d", len(edges))
+		}
+		if edges[[2]string{"App-1.0.0", "Test-1.0.0"}] {
+			t.Error("Expected no edge for the dev dependency")
+		}
+	})
+
+	t.Run("Highest resolution creates one edge per dependency", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		edges := edgeSet(NewPackageGraph(&packagesInfo, false, WithResolution(ResolveHighest)))
+		if len(edges) != 1 || !edges[[2]string{"App-1.0.0", "A-1.2.0"}] {
+			t.Errorf("Expected a single edge to A-1.2.0, got %v", edges)
+		}
+	})
+
+	t.Run("Dependency classes select the declarations", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		edges := edgeSet(NewPackageGraph(&packagesInfo, false, WithDependencyClasses(Development)))
+		if len(edges) != 1 || !edges[[2]string{"App-1.0.0", "Test-1.0.0"}] {
+			t.Errorf("Expected a single edge to Test-1.0.0, got %v", edges)
+		}
+	})
+
+	t.Run("Cutoff leaves out later versions", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		cutoff := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+		pg := NewPackageGraph(&packagesInfo, false, WithCutoff(cutoff))
+		if _, ok := pg.StringIDToNodeInfo["A-1.2.0"]; ok {
+			t.Error("Expected A-1.2.0 to be left out")
+		}
+		if _, ok := pg.StringIDToNodeInfo["App-1.0.0"]; ok {
+			t.Error("Expected App-1.0.0 to be left out")
+		}
+		if pg.Graph.Nodes().Len() != 3 {
+			t.Errorf("Expected 3 nodes, got %d", pg.Graph.Nodes().Len())
+		}
+	})
+
+	t.Run("Name filter leaves out packages", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		pg := NewPackageGraph(&packagesInfo, false, WithNameFilter(func(_, name string) bool { return name != "A" }))
+		if pg.Graph.Nodes().Len() != 2 || pg.Graph.Edges().Len() != 0 {
+			t.Errorf("Expected 2 nodes and no edges, got %d and %d", pg.Graph.Nodes().Len(), pg.Graph.Edges().Len())
+		}
+	})
+
+	t.Run("Workers create the same edges and report progress", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		expected := edgeSet(NewPackageGraph(&packagesInfo, false))
+		calls := 0
+		last := 0
+		progress := func(done, total int) {
+			calls++
+			last = done
+			if total != len(packagesInfo) {
+				t.Errorf("Expected a total of %d, got %d", len(packagesInfo), total)
+			}
+		}
+		actual := edgeSet(NewPackageGraph(&packagesInfo, false, WithWorkers(4), WithProgress(progress)))
+		if len(actual) != len(expected) {
+			t.Errorf("Expected %d edges, got %d", len(expected), len(actual))
+		}
+		for edge := range expected {
+			if !actual[edge] {
+				t.Errorf("Expected edge %v", edge)
+			}
+		}
+		if calls != len(packagesInfo) || last != len(packagesInfo) {
+			t.Errorf("Expected %d progress calls ending at %d, got %d ending at %d", len(packagesInfo), len(packagesInfo), calls, last)
+		}
+	})
+}
+
+func TestTimeWindow(t *testing.T) {
+	packagesInfo := testPackages("options")
+	packagesInfo[1].Versions["0.9.0"] = VersionInfo{Timestamp: "not a timestamp", Dependencies: map[string]string{}}
+	encoded, err := json.Marshal(packagesInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "packages.json")
+	if err := os.WriteFile(path, encoded, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2022, 4, 22, 20, 15, 37, 0, time.UTC)
+	window := WithTimeWindow(start, end)
+
+	t.Run("Leaves the versions outside the window out while parsing", func(t *testing.T) {
+		parsed, err := ReadPackagesJSON(path, nil, window)
+		if err != nil {
+			t.Fatal(err)
+		}
+		versions := make(map[string][]string)
+		for _, packageInfo := range *parsed {
+			versions[packageInfo.Name] = sortedVersionKeys(packageInfo.Versions)
+		}
+		// App 1.0.0 was released exactly at the end of the window, which is excluded.
+		expected := map[string][]string{"A": {"0.9.0", "1.1.0", "1.2.0"}}
+		if !reflect.DeepEqual(versions, expected) {
+			t.Errorf("Expected %v, got %v", expected, versions)
+		}
+	})
+
+	t.Run("Leaves out unparseable timestamps if asked to", func(t *testing.T) {
+		parsed, err := ReadPackagesJSON(path, nil, window, WithoutUnparseableTimestamps())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(*parsed) != 1 || len((*parsed)[0].Versions) != 2 {
+			t.Errorf("Expected the two versions of A with a timestamp, got %v", *parsed)
+		}
+	})
+
+	t.Run("Builds the graph that filtering the full parse builds", func(t *testing.T) {
+		for _, end := range []time.Time{end, end.Add(time.Second), {}} {
+			opts := []Option{WithTimeWindow(start, end), WithDependencyClasses(Runtime, Development)}
+			windowed, err := OpenPackageGraph(path, false, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			full, err := ReadPackagesJSON(path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			filtered := NewPackageGraph(full, false, opts...)
+			if !reflect.DeepEqual(edgeSet(windowed), edgeSet(filtered)) || windowed.Graph.Nodes().Len() != filtered.Graph.Nodes().Len() {
+				t.Errorf("Expected the edges %v, got %v", edgeSet(filtered), edgeSet(windowed))
+			}
+		}
+	})
+}
@@ -0,0 +1,367 @@
+package graph
+
+import (
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// PackageGraph bundles the dependency graph together with the lookup structures that are created alongside it.
+// Edges point from the dependent package version to its dependency, unless the graph was built with
+// WithEdgeDirection(DependencyToDependent).
+//
+// Once constructed, a PackageGraph is safe for concurrent use by queries: all its methods except AddVersion,
+// AddPackage, RemoveVersion and RemovePackage only read the graph, and the caches they build on first use are
+// guarded. The mutating methods, and direct changes to the exported fields, must not run concurrently with any
+// other method. A graph built with WithLazyEdges creates its edges in Dependencies, Dependents, Subgraph, ShortestPath
+// and ResolveDependencies, which are safe to call concurrently with each other and with Stats and SaveGraph, but not
+// with the queries that read the edges directly, such as AllPaths. ReloadFrom may run concurrently with the queries
+// of callers that hold RLock.
+type PackageGraph struct {
+	Graph    *simple.DirectedGraph
+	Packages *[]PackageInfo
+	// StringIDToNodeInfo maps the "name-version" key of every version to its node information.
+	//
+	// Deprecated: the keys are ambiguous, "a-1" at version "2.0.0" and "a" at version "1-2.0.0" share a key, under
+	// which only the version with the lowest ID is stored. Use FindNode, which looks versions up by NameVersion.
+	StringIDToNodeInfo map[string]NodeInfo
+	// Nodes holds the node information indexed by node ID, or with HashedIDs in the order in which the nodes were
+	// added. Use Node to look up a single ID.
+	Nodes []NodeInfo
+	// NameToVersions maps the key of every package to its versions, in increasing semver order.
+	NameToVersions map[PackageKey][]string
+
+	// ids maps every version to the ID of its node. Unlike StringIDToNodeInfo, it holds every version.
+	ids map[NameVersion]int64
+	// slots maps the node IDs to their index in Nodes if the nodes are not stored at their ID, and is nil otherwise.
+	slots map[int64]int
+	// collisions holds the versions left out because their hashed ID was taken, see IDCollisions.
+	collisions []IDCollision
+	// packageIndex maps a package key to its index in Packages.
+	packageIndex map[PackageKey]int
+	// ecosystems holds the ecosystems of the packages, by which the lookups tell a qualified name, see packageKey.
+	ecosystems map[string]bool
+	// isMaven and options are the settings the edges were created with, which are reused when versions are added.
+	isMaven bool
+	options *Options
+	// constraints indexes the dependency declarations on every package name, see ConstraintsOn. ensureConstraintIndex
+	// builds it while holding constraintsMutex if the graph was not created by NewPackageGraph.
+	constraintsMutex sync.Mutex
+	constraints      *constraintIndex
+	// versions holds the parsed versions of every package. It is built when the edges are created, or for a loaded
+	// graph on the first change, and kept up to date by the incremental changes.
+	versions versionIndex
+	// names is the name filter of versions, which is built and updated with it.
+	names *nameFilter
+	// matcher is the range matcher shared by the resolvers of the graph, which caches the parsed ranges.
+	matcher RangeMatcher
+	// unresolved holds the IDs of the versions whose outgoing edges have not been created yet. The lazy queries
+	// create them while holding lazyMutex.
+	unresolved map[int64]bool
+	lazyMutex  sync.Mutex
+	// swapMutex is held for writing by ReloadFrom while it swaps in the new graph, and for reading by RLock.
+	swapMutex sync.RWMutex
+	// center is the version around which EgoNetwork extracted the graph, or nil.
+	center *NameVersion
+	// reach is the reachability index built by Reachability, which is discarded by every change.
+	reachMutex sync.Mutex
+	reach      *ReachIndex
+	// latest is the index of LatestVersion, which latestIndex builds while holding latestMutex and the incremental
+	// changes keep up to date.
+	latestMutex sync.Mutex
+	latest      latestIndex
+	// crossEdges holds the constraints of the edges added by MergeWithCrossEdges.
+	crossEdges map[[2]int64]string
+	// weights holds the weights of the edges whose weight is above 1, see EdgeWeight.
+	weights map[[2]int64]int32
+	// edgeFile holds the edges of a graph built with WithExternalEdges, and edgeFileErr the error that stopped them
+	// from being written.
+	edgeFile    *EdgeFile
+	edgeFileErr error
+	// externalLoaded is set once materializeExternalEdges has loaded the edge file, while holding externalMutex.
+	externalMutex  sync.Mutex
+	externalLoaded bool
+	// classGraphs holds the graphs of the single dependency classes built with WithSplitByClass, and split is set for
+	// them and the graph they were split from, which share their node side. ClassGraphs builds them while holding
+	// classGraphsMutex for the graphs whose edges are not created up front.
+	classGraphsMutex sync.Mutex
+	classGraphs      map[DependencyClass]*PackageGraph
+	split            bool
+	// attributes is the attribute schema of the exporters, which Attributes creates while holding attributesMutex.
+	attributes      *AttributeSchema
+	attributesMutex sync.Mutex
+	// metadata is the metadata file that the strings of a graph loaded by LoadGraphFileWithMetadata point into.
+	metadata *metadataFile
+}
+
+// NewPackageGraph creates the nodes, the lookup maps and the edges for an already parsed list of packages. The options
+// control which packages and versions become nodes and how their edges are created; without options every version
+// is included and the edges are created as described by CreateEdges. With WithLazyEdges, only the versions are
+// indexed and the edges are created on demand. Packages that are listed more than once are merged, see
+// MergeDuplicatePackages, and counted in the resolution report.
+func NewPackageGraph(packagesList *[]PackageInfo, isUsingMaven bool, opts ...Option) *PackageGraph {
+	options := newOptions(opts)
+	options.stageStarted(GraphStage)
+	defer options.stageFinished(GraphStage)
+	start := time.Now()
+	packagesList = preparePackages(packagesList, options)
+	graph := simple.NewDirectedGraph()
+	allocator := newNodeAllocator(graph, options.IDScheme)
+	nodes := createNodeInfos(packagesList, allocator)
+	packagesList = withoutVersions(packagesList, allocator.collisions)
+	pg := newPackageGraphFromParts(graph, packagesList, nodes)
+	pg.collisions = allocator.collisions
+	if len(pg.collisions) > 0 {
+		options.log(LevelWarn, "hashed node IDs collide", "collisions", len(pg.collisions), "error", allocator.err())
+	}
+	pg.ensureConstraintIndex()
+	options.log(LevelInfo, "map build", "duration", time.Since(start), "packages", len(*packagesList), "nodes", len(pg.ids))
+	pg.isMaven = isUsingMaven
+	pg.options = options
+	if options.LazyEdges {
+		pg.deferEdges()
+	} else if options.ExternalEdges != "" {
+		pg.edgeFile, pg.edgeFileErr = createExternalEdges(packagesList, pg.resolver())
+	} else if options.SplitByClass {
+		pg.createClassEdges(packagesList)
+	} else {
+		createEdges(pg.setWeightedEdges, packagesList, pg.resolver())
+	}
+	if options.LatestIndex {
+		pg.latest = newLatestIndex(pg)
+	}
+	if options.Logger != nil {
+		pg.logMemoryStats()
+	}
+	return pg
+}
+
+// preparePackages normalizes the names of the packages, merges the duplicates and leaves out the packages and
+// versions that the options exclude, which is what NewPackageGraph creates nodes for.
+func preparePackages(packagesList *[]PackageInfo, options *Options) *[]PackageInfo {
+	packagesList, duplicates := MergeDuplicatePackages(normalizePackageNames(packagesList, options))
+	if duplicates.MergedPackages > 0 {
+		options.log(LevelWarn, "merged duplicate packages", "packages", duplicates.MergedPackages, "conflictingVersions", duplicates.ConflictingVersions)
+		if options.Report != nil {
+			options.Report.Duplicates = duplicates
+		}
+	}
+	return filterPackages(packagesList, options)
+}
+
+// newPackageGraphFromParts derives the remaining lookup structures from the graph nodes, indexed by ID, and the
+// packages.
+func newPackageGraphFromParts(graph *simple.DirectedGraph, packagesList *[]PackageInfo, nodes []NodeInfo) *PackageGraph {
+	return newPackageGraphFromIndex(graph, packagesList, nodes, packageVersions(packagesList))
+}
+
+// newPackageGraphFromIndex is like newPackageGraphFromParts, with the versions of every package already known.
+func newPackageGraphFromIndex(graph *simple.DirectedGraph, packagesList *[]PackageInfo, nodes []NodeInfo, nameToVersions map[PackageKey][]string) *PackageGraph {
+	packageIndex := make(map[PackageKey]int, len(*packagesList))
+	for i, packageInfo := range *packagesList {
+		packageIndex[packageInfo.key()] = i
+	}
+	ids := make(map[NameVersion]int64, len(nodes))
+	stringIDToNodeInfo := make(map[string]NodeInfo, len(nodes))
+	for i, node := range nodes {
+		if node.stringID == "" {
+			continue
+		}
+		if index, ok := packageIndex[node.key()]; ok {
+			node.Provenance = (*packagesList)[index].Provenance
+			nodes[i] = node
+		}
+		ids[node.NameVersion()] = node.id
+		if _, taken := stringIDToNodeInfo[node.stringID]; !taken {
+			stringIDToNodeInfo[node.stringID] = node
+		}
+	}
+	pg := &PackageGraph{
+		Graph:              graph,
+		Packages:           packagesList,
+		StringIDToNodeInfo: stringIDToNodeInfo,
+		Nodes:              nodes,
+		NameToVersions:     nameToVersions,
+		ids:                ids,
+		packageIndex:       packageIndex,
+		ecosystems:         packageEcosystems(packagesList),
+		options:            newOptions(nil),
+	}
+	if !storedAtIDs(nodes) {
+		pg.indexSlots()
+	}
+	return pg
+}
+
+// logMemoryStats logs the estimated memory use of the graph once it has been constructed.
+func (pg *PackageGraph) logMemoryStats() {
+	stats := pg.MemoryStats()
+	pg.options.log(LevelInfo, "memory",
+		"totalBytes", stats.TotalBytes,
+		"nodes", stats.Nodes,
+		"nodeBytes", stats.NodeBytes,
+		"packages", stats.Packages,
+		"packageBytes", stats.PackageBytes,
+		"strings", stats.Strings,
+		"stringBytes", stats.StringBytes,
+		"indexEntries", stats.IndexEntries,
+		"indexBytes", stats.IndexBytes,
+		"edges", stats.Edges,
+		"edgeBytes", stats.EdgeBytes)
+}
+
+// CreatePackageGraph parses the JSON file at inputPath and builds a PackageGraph from it. The program exits if the
+// file cannot be parsed, after logging the error to the logger if one is configured; use OpenPackageGraph to handle
+// the error instead.
+func CreatePackageGraph(inputPath string, isUsingMaven bool, opts ...Option) *PackageGraph {
+	pg, err := OpenPackageGraph(inputPath, isUsingMaven, opts...)
+	if err != nil {
+		options := newOptions(opts)
+		if options.Logger == nil {
+			log.Fatal(err)
+		}
+		options.log(LevelError, "parse failed", "path", inputPath, "error", err)
+		os.Exit(1)
+	}
+	return pg
+}
+
+// OpenPackageGraph parses the JSON file at inputPath and builds a PackageGraph from it, returning an error if the file
+// cannot be parsed or, with WithExternalEdges, if the edge file cannot be written. The time window of WithTimeWindow
+// is already applied while parsing.
+func OpenPackageGraph(inputPath string, isUsingMaven bool, opts ...Option) (*PackageGraph, error) {
+	start := time.Now()
+	options := newOptions(opts)
+	options.stageStarted(ParseStage)
+	packagesList, err := ReadPackagesJSON(inputPath, NewInterner(), opts...)
+	options.stageFinished(ParseStage)
+	if err != nil {
+		return nil, err
+	}
+	options.log(LevelInfo, "parse", "duration", time.Since(start), "packages", len(*packagesList), "path", inputPath)
+	pg := NewPackageGraph(packagesList, isUsingMaven, opts...)
+	if pg.edgeFileErr != nil {
+		return nil, pg.edgeFileErr
+	}
+	if len(pg.collisions) > 0 {
+		return nil, &IDCollisionError{Collisions: pg.collisions}
+	}
+	return pg, nil
+}
+
+// Node returns the node information of the node with the given ID.
+func (pg *PackageGraph) Node(id int64) (NodeInfo, bool) {
+	slot, ok := pg.slot(id)
+	if !ok || pg.Nodes[slot].stringID == "" {
+		return NodeInfo{}, false
+	}
+	return pg.Nodes[slot], true
+}
+
+// node returns the node information of an ID that is part of the graph.
+func (pg *PackageGraph) node(id int64) NodeInfo {
+	info, _ := pg.Node(id)
+	return info
+}
+
+// NodeMap returns the node information keyed by node ID, for the functions that take a map. The map is built on every
+// call, so prefer Node and Nodes where possible.
+//
+// Deprecated: the map costs considerably more memory than Nodes; it only exists until every analysis can work on a
+// PackageGraph directly.
+func (pg *PackageGraph) NodeMap() map[int64]NodeInfo {
+	nodeMap := make(map[int64]NodeInfo, len(pg.Nodes))
+	for _, node := range pg.Nodes {
+		if node.stringID != "" {
+			nodeMap[node.id] = node
+		}
+	}
+	return nodeMap
+}
+
+// VersionInfo returns the parsed information of the given package version.
+func (pg *PackageGraph) VersionInfo(nameVersion NameVersion) (VersionInfo, bool) {
+	nameVersion = pg.canonical(nameVersion)
+	index, ok := pg.packageIndex[nameVersion.key()]
+	if !ok {
+		return VersionInfo{}, false
+	}
+	versionInfo, ok := (*pg.Packages)[index].Versions[nameVersion.Version]
+	return versionInfo, ok
+}
+
+// Constraint returns the version range with which the node from declared its dependency on the node to. If the
+// dependency is declared in several classes, the runtime declaration takes precedence.
+func (pg *PackageGraph) Constraint(from, to int64) (string, bool) {
+	fromInfo, ok := pg.Node(from)
+	if !ok {
+		return "", false
+	}
+	versionInfo, ok := pg.VersionInfo(fromInfo.NameVersion())
+	if !ok {
+		return "", false
+	}
+	if constraint, ok := pg.crossEdges[[2]int64{from, to}]; ok {
+		return constraint, true
+	}
+	toInfo, _ := pg.Node(to)
+	toName := toInfo.Name
+	if alias, ok := pg.EdgeAlias(from, to); ok {
+		toName = alias
+	}
+	for _, class := range []DependencyClass{Runtime, Development, Peer, Optional} {
+		if constraint, ok := versionInfo.DependenciesOf(class)[toName]; ok {
+			return constraint, true
+		}
+	}
+	return "", false
+}
+
+// EdgeWeight returns the weight of the edge from one version to another, which is the number of dependency
+// declarations of from, in the classes that create edges, that resolve to to. A package declared both as a runtime
+// and as a peer dependency with ranges that include the same version gives its edge weight 2. Most edges have weight
+// 1, which is also returned for an edge that does not exist. The weights of a graph built with WithExternalEdges are
+// not kept, so all its edges have weight 1.
+func (pg *PackageGraph) EdgeWeight(from, to int64) int {
+	if weight, ok := pg.weights[[2]int64{from, to}]; ok {
+		return int(weight)
+	}
+	return 1
+}
+
+// versionIDs returns the node IDs of all the versions of the package.
+func (pg *PackageGraph) versionIDs(key PackageKey) []int64 {
+	versions := pg.NameToVersions[key]
+	ids := make([]int64, 0, len(versions))
+	for _, version := range versions {
+		if id, ok := pg.ids[key.version(version)]; ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// lookup returns the node information of the given package version, without normalizing its name.
+func (pg *PackageGraph) lookup(nameVersion NameVersion) (NodeInfo, bool) {
+	id, ok := pg.ids[nameVersion]
+	if !ok {
+		return NodeInfo{}, false
+	}
+	return pg.Node(id)
+}
+
+// sortedNodeIDs drains the iterator and returns the IDs of its nodes in increasing order, so that traversals visit
+// neighbours deterministically.
+func sortedNodeIDs(nodes graph.Nodes) []int64 {
+	ids := make([]int64, 0, nodes.Len())
+	for nodes.Next() {
+		ids = append(ids, nodes.Node().ID())
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -0,0 +1,148 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SortOrder is the order of the versions returned by DependentsPage.
+type SortOrder int
+
+const (
+	// SortByName orders the versions by name and then by version.
+	SortByName SortOrder = iota
+	// SortByTimestamp orders the versions from the oldest to the newest release, with the versions whose timestamp
+	// cannot be parsed last. Versions released at the same time are ordered by name.
+	SortByTimestamp
+	// SortByDependents orders the versions from the most to the fewest direct dependents of their own, so that the
+	// dependents through which a change spreads the furthest come first. Versions with as many dependents are ordered
+	// by name.
+	SortByDependents
+)
+
+// String returns the name of the order.
+func (order SortOrder) String() string {
+	switch order {
+	case SortByTimestamp:
+		return "timestamp"
+	case SortByDependents:
+		return "dependents"
+	default:
+		return "name"
+	}
+}
+
+// DependentsPage returns a page of the dependents of a version, like Dependents with a maxDepth of 1 or, if transitive
+// is set, of -1, but in a deterministic order and without building the whole list: the limit versions after the first
+// offset ones, and the number of dependents. Only the IDs of the dependents are collected and sorted. For the
+// transitive dependents of a graph whose reachability index has been built, see Reachability, they are read from the
+// index, which also gives the total without listing them when the page is past the end or the limit is 0. Every
+// order ends with the name and the version, and versions that compare equal, such as 1.0 and 1.0.0, with the ID.
+//
+// It fails if the version is not part of the graph or if the offset or the limit is negative. With WithLazyEdges,
+// ordering with SortByDependents creates the incoming edges of every dependent.
+func DependentsPage(pg *PackageGraph, target NameVersion, transitive bool, offset, limit int, order SortOrder) ([]NodeInfo, int, error) {
+	if offset < 0 || limit < 0 {
+		return nil, 0, fmt.Errorf("invalid page with offset %d and limit %d", offset, limit)
+	}
+	root, ok := pg.FindNode(target)
+	if !ok {
+		return nil, 0, fmt.Errorf("%s is not part of the graph", target)
+	}
+	if pg.lockEdges() {
+		defer pg.lazyMutex.Unlock()
+	}
+
+	var ids []int64
+	pg.reachMutex.Lock()
+	index := pg.reach
+	pg.reachMutex.Unlock()
+	// The index of a lazy graph misses the edges that have not been created yet.
+	if transitive && index != nil && len(pg.unresolved) == 0 {
+		if total := index.CountDependents(root.id); offset >= total || limit == 0 {
+			return nil, total, nil
+		}
+		ids = index.Dependents(root.id)
+	} else {
+		ids = pg.dependentIDs(root.id, transitive)
+	}
+	total := len(ids)
+	if offset >= total || limit == 0 {
+		return nil, total, nil
+	}
+
+	pg.sortNodeIDs(ids, order)
+	end := offset + limit
+	if end > total || end < offset {
+		end = total
+	}
+	page := make([]NodeInfo, 0, end-offset)
+	for _, id := range ids[offset:end] {
+		page = append(page, pg.node(id))
+	}
+	return page, total, nil
+}
+
+// dependentIDs returns the IDs of the direct or transitive dependents of the node, creating the edges of a lazy graph
+// like Dependents. The caller holds lazyMutex if the edges are created on demand.
+func (pg *PackageGraph) dependentIDs(id int64, transitive bool) []int64 {
+	var ids []int64
+	Traverse(pg.DependencyGraph(), []int64{id}, Backward, func(dependent int64, depth int) TraverseSignal {
+		if depth > 0 {
+			ids = append(ids, dependent)
+			if !transitive {
+				return SkipChildren
+			}
+		}
+		pg.resolveDependents(dependent)
+		return Continue
+	})
+	return ids
+}
+
+// sortNodeIDs sorts the IDs of nodes of the graph in the order, breaking ties by name, version and ID.
+func (pg *PackageGraph) sortNodeIDs(ids []int64, order SortOrder) {
+	var dependents map[int64]int
+	var released map[int64]time.Time
+	switch order {
+	case SortByDependents:
+		dependents = make(map[int64]int, len(ids))
+		for _, id := range ids {
+			pg.resolveDependents(id)
+			dependents[id] = pg.DependencyGraph().To(id).Len()
+		}
+	case SortByTimestamp:
+		released = make(map[int64]time.Time, len(ids))
+		for _, id := range ids {
+			if timestamp, err := ParseTimestamp(pg.node(id).Timestamp); err == nil {
+				released[id] = timestamp
+			}
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		switch order {
+		case SortByDependents:
+			if dependents[ids[i]] != dependents[ids[j]] {
+				return dependents[ids[i]] > dependents[ids[j]]
+			}
+		case SortByTimestamp:
+			a, aOK := released[ids[i]]
+			b, bOK := released[ids[j]]
+			if aOK != bOK {
+				return aOK
+			}
+			if !a.Equal(b) {
+				return a.Before(b)
+			}
+		}
+		a, b := pg.node(ids[i]), pg.node(ids[j])
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if comparison := compareVersions(a.Version, b.Version); comparison != 0 {
+			return comparison < 0
+		}
+		return ids[i] < ids[j]
+	})
+}
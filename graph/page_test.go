@@ -0,0 +1,90 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func pageNames(nodes []NodeInfo) []string {
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	return names
+}
+
+func TestDependentsPage(t *testing.T) {
+	lib := NameVersion{Name: "Lib", Version: "1.0.0"}
+
+	t.Run("Pages through the dependents in every order", func(t *testing.T) {
+		packagesInfo := testPackages("page")
+		pg := NewPackageGraph(&packagesInfo, false)
+		tests := []struct {
+			transitive    bool
+			offset, limit int
+			order         SortOrder
+			expected      []string
+		}{
+			{false, 0, 10, SortByName, []string{"App", "Cli", "Web"}},
+			{false, 1, 1, SortByName, []string{"Cli"}},
+			{true, 0, 10, SortByName, []string{"App", "Blog", "Cli", "Site", "Web"}},
+			{true, 3, 10, SortByName, []string{"Site", "Web"}},
+			{true, 0, 10, SortByTimestamp, []string{"Blog", "Site", "App", "Cli", "Web"}},
+			{true, 0, 3, SortByDependents, []string{"Web", "Cli", "App"}},
+		}
+		for _, test := range tests {
+			page, total, err := DependentsPage(pg, lib, test.transitive, test.offset, test.limit, test.order)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if names := pageNames(page); !reflect.DeepEqual(names, test.expected) {
+				t.Errorf("Expected %v by %s, got %v", test.expected, test.order, names)
+			}
+			if expected := map[bool]int{false: 3, true: 5}[test.transitive]; total != expected {
+				t.Errorf("Expected %d dependents, got %d", expected, total)
+			}
+		}
+	})
+
+	t.Run("Agrees with the reachability index and lazy edges", func(t *testing.T) {
+		packagesInfo := testPackages("page")
+		expected, expectedTotal, _ := DependentsPage(NewPackageGraph(&packagesInfo, false), lib, true, 1, 3, SortByTimestamp)
+
+		packagesInfo = testPackages("page")
+		indexed := NewPackageGraph(&packagesInfo, false)
+		indexed.Reachability()
+		packagesInfo = testPackages("page")
+		lazy := NewPackageGraph(&packagesInfo, false, WithLazyEdges())
+		for _, pg := range []*PackageGraph{indexed, lazy} {
+			page, total, err := DependentsPage(pg, lib, true, 1, 3, SortByTimestamp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(pageNames(page), pageNames(expected)) || total != expectedTotal {
+				t.Errorf("Expected %v of %d, got %v of %d", pageNames(expected), expectedTotal, pageNames(page), total)
+			}
+		}
+		if page, total, _ := DependentsPage(indexed, lib, true, 0, 0, SortByName); page != nil || total != 5 {
+			t.Errorf("Expected only the total of 5, got %v of %d", page, total)
+		}
+	})
+
+	t.Run("Returns no dependents past the end", func(t *testing.T) {
+		packagesInfo := testPackages("page")
+		pg := NewPackageGraph(&packagesInfo, false)
+		if page, total, err := DependentsPage(pg, lib, false, 3, 10, SortByName); err != nil || page != nil || total != 3 {
+			t.Errorf("Expected an empty page of 3 dependents, got %v of %d and %v", page, total, err)
+		}
+	})
+
+	t.Run("Rejects unknown versions and negative bounds", func(t *testing.T) {
+		packagesInfo := testPackages("page")
+		pg := NewPackageGraph(&packagesInfo, false)
+		if _, _, err := DependentsPage(pg, NameVersion{Name: "Missing", Version: "1.0.0"}, false, 0, 10, SortByName); err == nil {
+			t.Error("Expected an error for an unknown version")
+		}
+		if _, _, err := DependentsPage(pg, lib, false, -1, 10, SortByName); err == nil {
+			t.Error("Expected an error for a negative offset")
+		}
+	})
+}
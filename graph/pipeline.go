@@ -0,0 +1,185 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// The names of the built-in stages of a Pipeline.
+const (
+	ParseStage = "parse"
+	GraphStage = "graph"
+)
+
+// stageNamePattern restricts the stage names to those that can be part of a file name.
+var stageNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// StageFunc is a stage of a Pipeline that runs on the graph. It may change the graph, for example by adding
+// versions, and the changes are part of the checkpoint written after it.
+type StageFunc func(pg *PackageGraph) error
+
+type pipelineStage struct {
+	name string
+	run  StageFunc
+}
+
+// Pipeline parses the packages, builds the graph and then runs the added stages on it, writing a checkpoint to the
+// workdir after every stage. A pipeline that did not complete, for example because a stage failed or the process was
+// killed, continues after the latest checkpoint that can be read when it is run again with the same stages, so the
+// completed stages are not repeated. The workdir has to be emptied to run the pipeline from the start, for example
+// when the input changes.
+//
+// The checkpoint of the parse stage holds the packages as written by SavePackages; the checkpoints of the following
+// stages hold the graph as written by SaveGraph. The checkpoints are written to a temporary file that is renamed once
+// it is complete, so a killed pipeline does not leave a partial checkpoint behind.
+type Pipeline struct {
+	workdir      string
+	inputPath    string
+	isUsingMaven bool
+	opts         []Option
+	stages       []pipelineStage
+}
+
+// NewPipeline returns a pipeline that parses the JSON file at inputPath and builds the graph with the options,
+// keeping its checkpoints in workdir. The logger of the options also receives the events of the pipeline, and the
+// stage hook of WithStageHook is called around every stage that runs.
+func NewPipeline(workdir, inputPath string, isUsingMaven bool, opts ...Option) *Pipeline {
+	return &Pipeline{workdir: workdir, inputPath: inputPath, isUsingMaven: isUsingMaven, opts: opts}
+}
+
+// AddStage adds a stage that runs after the graph is built and the previously added stages. A name consists of
+// letters, digits, '_', '.' and '-', and must be unique and differ from ParseStage and GraphStage, as it names the
+// checkpoint of the stage.
+func (p *Pipeline) AddStage(name string, run StageFunc) error {
+	if !stageNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid stage name %q", name)
+	}
+	if name == ParseStage || name == GraphStage {
+		return fmt.Errorf("stage name %q is reserved", name)
+	}
+	for _, stage := range p.stages {
+		if stage.name == name {
+			return fmt.Errorf("stage %q already exists", name)
+		}
+	}
+	p.stages = append(p.stages, pipelineStage{name, run})
+	return nil
+}
+
+// Stages returns the names of all the stages in the order they run, including the built-in ones.
+func (p *Pipeline) Stages() []string {
+	names := []string{ParseStage, GraphStage}
+	for _, stage := range p.stages {
+		names = append(names, stage.name)
+	}
+	return names
+}
+
+// Run runs the stages that did not complete in a previous run and returns the final graph.
+func (p *Pipeline) Run() (*PackageGraph, error) {
+	if err := os.MkdirAll(p.workdir, 0o755); err != nil {
+		return nil, err
+	}
+	options := newOptions(p.opts)
+	names := p.Stages()
+
+	next, packages, pg := p.resume(options)
+	if next > 0 {
+		options.log(LevelInfo, "pipeline resume", "stage", names[next-1])
+	}
+	for i := next; i < len(names); i++ {
+		start := time.Now()
+		var err error
+		switch i {
+		case 0:
+			options.stageStarted(ParseStage)
+			packages, err = ReadPackagesJSON(p.inputPath, NewInterner(), p.opts...)
+			options.stageFinished(ParseStage)
+			if err == nil {
+				err = p.writeCheckpoint(i, func(w io.Writer) error { return SavePackages(w, *packages) })
+			}
+		case 1:
+			pg = NewPackageGraph(packages, p.isUsingMaven, p.opts...)
+			err = p.writeCheckpoint(i, func(w io.Writer) error { return SaveGraph(w, pg) })
+		default:
+			options.stageStarted(names[i])
+			err = p.stages[i-2].run(pg)
+			options.stageFinished(names[i])
+			if err == nil {
+				err = p.writeCheckpoint(i, func(w io.Writer) error { return SaveGraph(w, pg) })
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("stage %s: %w", names[i], err)
+		}
+		options.log(LevelInfo, "pipeline stage", "stage", names[i], "duration", time.Since(start))
+	}
+	return pg, nil
+}
+
+// resume loads the latest checkpoint that can be read and returns the index of the stage that runs next, together
+// with the packages or the graph of the checkpoint.
+func (p *Pipeline) resume(options *Options) (int, *[]PackageInfo, *PackageGraph) {
+	for i := len(p.Stages()) - 1; i >= 0; i-- {
+		file, err := os.Open(p.checkpointPath(i))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err == nil {
+			if i == 0 {
+				var packages []PackageInfo
+				if packages, err = LoadPackages(file); err == nil {
+					file.Close()
+					return i + 1, &packages, nil
+				}
+			} else {
+				var pg *PackageGraph
+				if pg, err = LoadGraphWithOptions(file, p.isUsingMaven, p.opts...); err == nil {
+					file.Close()
+					return i + 1, nil, pg
+				}
+			}
+			file.Close()
+		}
+		options.log(LevelWarn, "pipeline checkpoint unreadable", "path", p.checkpointPath(i), "error", err)
+	}
+	return 0, nil, nil
+}
+
+// checkpointPath returns the path of the checkpoint written after the stage with the given index. The index is part
+// of the name, so the checkpoints of a pipeline whose stages were reordered are not mixed up.
+func (p *Pipeline) checkpointPath(index int) string {
+	return filepath.Join(p.workdir, fmt.Sprintf("%02d-%s.checkpoint", index, p.Stages()[index]))
+}
+
+// writeCheckpoint writes the checkpoint of the stage to a temporary file and renames it once it is complete.
+func (p *Pipeline) writeCheckpoint(index int, write func(io.Writer) error) (err error) {
+	path := p.checkpointPath(index)
+	temporary := path + ".tmp"
+	file, err := os.Create(temporary)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(temporary)
+		}
+	}()
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temporary, path)
+}
@@ -0,0 +1,167 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// newTestPipeline returns a pipeline that adds a version to the graph and then writes its edges to output. The
+// process exits in the stage named kill, as if it was killed.
+func newTestPipeline(t *testing.T, workdir, input, output, kill string) *Pipeline {
+	pipeline := NewPipeline(workdir, input, false)
+	stage := func(name string, run StageFunc) {
+		err := pipeline.AddStage(name, func(pg *PackageGraph) error {
+			if name == kill {
+				os.Exit(3)
+			}
+			return run(pg)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	stage("add", func(pg *PackageGraph) error {
+		return pg.AddVersion("A", "2.0.0", VersionInfo{Timestamp: "2022-06-01T00:00:00", Dependencies: map[string]string{"Test": "1.0.0"}})
+	})
+	stage("export", func(pg *PackageGraph) error {
+		var lines []string
+		for edge := range edgeSet(pg) {
+			lines = append(lines, edge[0]+" -> "+edge[1])
+		}
+		sort.Strings(lines)
+		encoded, err := json.Marshal(lines)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(output, encoded, 0o644)
+	})
+	return pipeline
+}
+
+func writeTestInput(t *testing.T, path string) {
+	encoded, err := json.Marshal(testPackages("options"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, encoded, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestPipelineHelperProcess runs the pipeline described by the environment in a separate process, which the stage
+// named by STM_PIPELINE_KILL ends. It is skipped when the tests run normally.
+func TestPipelineHelperProcess(t *testing.T) {
+	workdir := os.Getenv("STM_PIPELINE_WORKDIR")
+	if workdir == "" {
+		t.Skip("only runs as a separate process of TestPipeline")
+	}
+	pipeline := newTestPipeline(t, workdir, os.Getenv("STM_PIPELINE_INPUT"), os.Getenv("STM_PIPELINE_OUTPUT"), os.Getenv("STM_PIPELINE_KILL"))
+	if _, err := pipeline.Run(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.json")
+	writeTestInput(t, input)
+	expectedOutput := filepath.Join(dir, "expected.json")
+	if _, err := newTestPipeline(t, filepath.Join(dir, "uninterrupted"), input, expectedOutput, "").Run(); err != nil {
+		t.Fatal(err)
+	}
+	expected, err := os.ReadFile(expectedOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, kill := range []string{"add", "export"} {
+		t.Run("Resumes after being killed in stage "+kill, func(t *testing.T) {
+			workdir := filepath.Join(dir, "killed-"+kill)
+			output := filepath.Join(dir, "output-"+kill+".json")
+			input := filepath.Join(dir, "input-"+kill+".json")
+			writeTestInput(t, input)
+
+			command := exec.Command(os.Args[0], "-test.run=^TestPipelineHelperProcess$")
+			command.Env = append(os.Environ(), "STM_PIPELINE_WORKDIR="+workdir, "STM_PIPELINE_INPUT="+input,
+				"STM_PIPELINE_OUTPUT="+output, "STM_PIPELINE_KILL="+kill)
+			if err := command.Run(); err == nil {
+				t.Fatal("Expected the pipeline process to be killed")
+			}
+			if _, err := os.Stat(output); err == nil {
+				t.Fatal("Expected no output from the killed pipeline")
+			}
+
+			// The input is no longer needed, as the resumed pipeline starts from a checkpoint of the graph.
+			if err := os.Remove(input); err != nil {
+				t.Fatal(err)
+			}
+			pg, err := newTestPipeline(t, workdir, input, output, "").Run()
+			if err != nil {
+				t.Fatalf("Expected the pipeline to resume, got %v", err)
+			}
+			if _, ok := pg.FindNode(NameVersion{Name: "A", Version: "2.0.0"}); !ok {
+				t.Error("Expected the final graph to contain A@2.0.0")
+			}
+			if got, err := os.ReadFile(output); err != nil || !bytes.Equal(got, expected) {
+				t.Errorf("Expected the output %s, got %s (%v)", expected, got, err)
+			}
+		})
+	}
+
+	t.Run("Skips unreadable checkpoints", func(t *testing.T) {
+		workdir := filepath.Join(dir, "corrupted")
+		output := filepath.Join(dir, "output-corrupted.json")
+		pipeline := newTestPipeline(t, workdir, input, output, "")
+		if _, err := pipeline.Run(); err != nil {
+			t.Fatal(err)
+		}
+		for i := 1; i < len(pipeline.Stages()); i++ {
+			if err := os.WriteFile(pipeline.checkpointPath(i), []byte("partial"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.Remove(output); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pipeline.Run(); err != nil {
+			t.Fatalf("Expected the pipeline to resume from the parsed packages, got %v", err)
+		}
+		if got, err := os.ReadFile(output); err != nil || !bytes.Equal(got, expected) {
+			t.Errorf("Expected the output %s, got %s (%v)", expected, got, err)
+		}
+	})
+
+	t.Run("Rejects invalid stage names", func(t *testing.T) {
+		pipeline := NewPipeline(dir, input, false)
+		for _, name := range []string{"", "a/b", ParseStage, GraphStage} {
+			if err := pipeline.AddStage(name, nil); err == nil {
+				t.Errorf("Expected an error for the stage name %q", name)
+			}
+		}
+		if err := pipeline.AddStage("stage", nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := pipeline.AddStage("stage", nil); err == nil {
+			t.Error("Expected an error for a duplicate stage name")
+		}
+		if stages := pipeline.Stages(); !equalStrings(stages, []string{ParseStage, GraphStage, "stage"}) {
+			t.Errorf("Expected the parse, graph and added stage, got %v", stages)
+		}
+	})
+
+	t.Run("Reports the failing stage", func(t *testing.T) {
+		pipeline := NewPipeline(filepath.Join(dir, "failing"), input, false)
+		if err := pipeline.AddStage("fail", func(*PackageGraph) error { return fmt.Errorf("broken") }); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pipeline.Run(); err == nil || err.Error() != "stage fail: broken" {
+			t.Errorf("Expected the error of the failing stage, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package graph
+
+import (
+	"testing"
+)
+
+// dependencyVersions returns the versions of A that App depends on, in semver order.
+func dependencyVersions(pg *PackageGraph) []string {
+	app, _ := pg.FindNode(NameVersion{Name: "App", Version: "1.0.0"})
+	var versions []string
+	for _, id := range sortedNodeIDs(pg.Graph.From(app.id)) {
+		node, _ := pg.Node(id)
+		versions = append(versions, node.Version)
+	}
+	sortVersions(versions)
+	return versions
+}
+
+func TestPrereleasePolicy(t *testing.T) {
+	for _, test := range []struct {
+		name            string
+		dependencyRange string
+		policy          PrereleasePolicy
+		expected        []string
+	}{
+		{"Excludes prereleases", "^1.0.0", ExcludePrereleases, []string{"1.0.0"}},
+		{"Excludes prereleases even if the range has a prerelease", "^1.0.0-0", ExcludePrereleases, []string{"1.0.0"}},
+		{"Excludes prereleases if the range has none", "^1.0.0", IncludeIfRangeHasPrerelease, []string{"1.0.0"}},
+		{"Includes prereleases if the range has one", "^1.0.0-0", IncludeIfRangeHasPrerelease, []string{"1.0.0-rc.1", "1.0.0", "1.1.0-rc.1"}},
+		{"Always includes prereleases within the range", "^1.0.0", AlwaysIncludePrereleases, []string{"1.0.0", "1.1.0-rc.1"}},
+		{"Always includes prereleases greater than a lower bound", ">=0.1.0", AlwaysIncludePrereleases, []string{"1.0.0-rc.1", "1.0.0", "1.1.0-rc.1", "2.0.0-rc.1"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			packagesInfo := testPackages("prerelease")
+			packagesInfo[0].Versions["1.0.0"].Dependencies["A"] = test.dependencyRange
+			pg := NewPackageGraph(&packagesInfo, false, WithPrereleases(test.policy))
+			if versions := dependencyVersions(pg); !equalStrings(versions, test.expected) {
+				t.Errorf("Expected edges to %v, got %v", test.expected, versions)
+			}
+		})
+	}
+
+	for policy, expected := range map[PrereleasePolicy]string{
+		ExcludePrereleases:          "1.0.0",
+		IncludeIfRangeHasPrerelease: "1.0.0",
+		AlwaysIncludePrereleases:    "1.1.0-rc.1",
+	} {
+		t.Run("Resolves the highest version under the policy", func(t *testing.T) {
+			packagesInfo := testPackages("prerelease")
+			pg := NewPackageGraph(&packagesInfo, false, WithPrereleases(policy), WithResolution(ResolveHighest))
+			if versions := dependencyVersions(pg); len(versions) != 1 || versions[0] != expected {
+				t.Errorf("Expected an edge to %s under policy %d, got %v", expected, policy, versions)
+			}
+		})
+	}
+
+	t.Run("Applies the policy to added versions", func(t *testing.T) {
+		packagesInfo := testPackages("prerelease")
+		pg := NewPackageGraph(&packagesInfo, false, WithPrereleases(AlwaysIncludePrereleases))
+		if err := pg.AddVersion("A", "1.2.0-beta", VersionInfo{Timestamp: "2021-01-01T00:00:00"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		expected := []string{"1.0.0", "1.1.0-rc.1", "1.2.0-beta"}
+		if versions := dependencyVersions(pg); !equalStrings(versions, expected) {
+			t.Errorf("Expected edges to %v, got %v", expected, versions)
+		}
+	})
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,167 @@
+package graph
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"time"
+)
+
+// StageHook is called around every named stage of the construction of a graph: ParseStage while OpenPackageGraph or a
+// Pipeline parses the input, GraphStage while NewPackageGraph builds the graph, and the stages added to a Pipeline. It
+// is the point at which instrumentation such as a profiler or a tracer is attached without changing the package. The
+// stages run one after another, but a stage may build another graph, whose stage then runs within it.
+type StageHook interface {
+	StageStarted(stage string)
+	StageFinished(stage string)
+}
+
+// WithStageHook calls the hook around every named stage, see StageHook and Profiler.
+func WithStageHook(hook StageHook) Option {
+	return func(options *Options) {
+		options.StageHook = hook
+	}
+}
+
+// stageStarted and stageFinished call the stage hook of the options, if there is one.
+func (options *Options) stageStarted(stage string) {
+	if options.StageHook != nil {
+		options.StageHook.StageStarted(stage)
+	}
+}
+
+func (options *Options) stageFinished(stage string) {
+	if options.StageHook != nil {
+		options.StageHook.StageFinished(stage)
+	}
+}
+
+// profileTimeLayout is the layout of the timestamp in the names of the profiles.
+const profileTimeLayout = "20060102T150405"
+
+// Profiler is a StageHook that profiles the CPU use of every stage and writes a heap profile once it has finished, so
+// that a flame graph of a slow stage can be opened with go tool pprof. The profiles are named after the stage, the
+// time at which the stage started and the number of the stage among those the profiler has seen, such as
+// "graph-20220422T201537-2.cpu.pprof" and "graph-20220422T201537-2.heap.pprof". Existing files are never overwritten:
+// if another run already wrote a profile of the same name, a further number is appended, as in
+// "graph-20220422T201537-2-1.cpu.pprof". A stage that runs within another stage only gets a heap profile, as only one
+// CPU profile can run at a time.
+//
+// A hook cannot fail a stage, so the first error writing a profile is kept and returned by Err, and the stages after it
+// are not profiled.
+type Profiler struct {
+	dir string
+	// stack holds the stages that are running, the outermost first, and stages the number of stages started so far.
+	stack  []profiledStage
+	stages int
+	// cpu is the file of the CPU profile of the outermost stage, or nil.
+	cpu   *os.File
+	files []string
+	err   error
+}
+
+type profiledStage struct {
+	name     string
+	started  time.Time
+	sequence int
+}
+
+// NewProfiler returns a Profiler that writes the profiles to dir, which is created if needed.
+func NewProfiler(dir string) (*Profiler, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Profiler{dir: dir}, nil
+}
+
+// StageStarted starts the CPU profile of the stage, unless another stage is already being profiled.
+func (p *Profiler) StageStarted(stage string) {
+	p.stages++
+	p.stack = append(p.stack, profiledStage{stage, time.Now(), p.stages})
+	if p.err != nil || len(p.stack) > 1 {
+		return
+	}
+	file, err := p.create(p.stack[0], "cpu")
+	if err != nil {
+		return
+	}
+	if err := pprof.StartCPUProfile(file); err != nil {
+		file.Close()
+		p.fail(fmt.Errorf("starting the CPU profile of stage %s: %w", stage, err))
+		return
+	}
+	p.cpu = file
+}
+
+// StageFinished stops the CPU profile of the stage, if it has one, and writes its heap profile.
+func (p *Profiler) StageFinished(stage string) {
+	if len(p.stack) == 0 {
+		return
+	}
+	finished := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+	if len(p.stack) == 0 && p.cpu != nil {
+		pprof.StopCPUProfile()
+		if err := p.cpu.Close(); err != nil {
+			p.fail(err)
+		}
+		p.cpu = nil
+	}
+	if p.err != nil {
+		return
+	}
+	file, err := p.create(finished, "heap")
+	if err != nil {
+		return
+	}
+	// The collection makes the profile show the memory that is still in use after the stage.
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(file); err != nil {
+		file.Close()
+		p.fail(fmt.Errorf("writing the heap profile of stage %s: %w", stage, err))
+		return
+	}
+	if err := file.Close(); err != nil {
+		p.fail(err)
+	}
+}
+
+// Files returns the paths of the profiles written so far, in the order in which they were started.
+func (p *Profiler) Files() []string {
+	return p.files
+}
+
+// Err returns the first error that occurred while writing a profile, or nil.
+func (p *Profiler) Err() error {
+	return p.err
+}
+
+// create creates the file of a profile of the stage, under a name that is not taken yet.
+func (p *Profiler) create(stage profiledStage, kind string) (*os.File, error) {
+	base := fmt.Sprintf("%s-%s-%d", stage.name, stage.started.UTC().Format(profileTimeLayout), stage.sequence)
+	for attempt := 0; ; attempt++ {
+		name := base
+		if attempt > 0 {
+			name = fmt.Sprintf("%s-%d", base, attempt)
+		}
+		path := filepath.Join(p.dir, name+"."+kind+".pprof")
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			p.fail(err)
+			return nil, err
+		}
+		p.files = append(p.files, path)
+		return file, nil
+	}
+}
+
+func (p *Profiler) fail(err error) {
+	if p.err == nil {
+		p.err = err
+	}
+}
@@ -0,0 +1,119 @@
+package graph
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingHook records the calls of a StageHook.
+type recordingHook struct {
+	calls []string
+}
+
+func (hook *recordingHook) StageStarted(stage string) {
+	hook.calls = append(hook.calls, "start "+stage)
+}
+
+func (hook *recordingHook) StageFinished(stage string) {
+	hook.calls = append(hook.calls, "finish "+stage)
+}
+
+func TestStageHook(t *testing.T) {
+	t.Run("Is called around every stage of a pipeline", func(t *testing.T) {
+		dir := t.TempDir()
+		input := filepath.Join(dir, "packages.json")
+		writeTestInput(t, input)
+		hook := &recordingHook{}
+		pipeline := NewPipeline(filepath.Join(dir, "work"), input, false, WithStageHook(hook))
+		if err := pipeline.AddStage("count", func(pg *PackageGraph) error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pipeline.Run(); err != nil {
+			t.Fatal(err)
+		}
+		expected := []string{"start parse", "finish parse", "start graph", "finish graph", "start count", "finish count"}
+		if !reflect.DeepEqual(hook.calls, expected) {
+			t.Errorf("Expected %v, got %v", expected, hook.calls)
+		}
+	})
+
+	t.Run("Is called while opening a graph", func(t *testing.T) {
+		input := filepath.Join(t.TempDir(), "packages.json")
+		writeTestInput(t, input)
+		hook := &recordingHook{}
+		if _, err := OpenPackageGraph(input, false, WithStageHook(hook)); err != nil {
+			t.Fatal(err)
+		}
+		expected := []string{"start parse", "finish parse", "start graph", "finish graph"}
+		if !reflect.DeepEqual(hook.calls, expected) {
+			t.Errorf("Expected %v, got %v", expected, hook.calls)
+		}
+	})
+}
+
+func TestProfiler(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "packages.json")
+	writeTestInput(t, input)
+	dir := filepath.Join(t.TempDir(), "profiles")
+	profiler, err := NewProfiler(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenPackageGraph(input, false, WithStageHook(profiler)); err != nil {
+		t.Fatal(err)
+	}
+	// A stage within another one only gets a heap profile.
+	profiler.StageStarted("outer")
+	profiler.StageStarted("inner")
+	profiler.StageFinished("inner")
+	profiler.StageFinished("outer")
+	// A stage within a stage of the same name, which usually starts within the same second, gets a profile of its own.
+	profiler.StageStarted("graph")
+	profiler.StageStarted("graph")
+	profiler.StageFinished("graph")
+	profiler.StageFinished("graph")
+	if err := profiler.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Writes a CPU and a heap profile of every stage", func(t *testing.T) {
+		var kinds []string
+		for _, path := range profiler.Files() {
+			name := filepath.Base(path)
+			kinds = append(kinds, name[:strings.Index(name, "-")]+name[strings.Index(name, "."):])
+			if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+				t.Errorf("Expected a non-empty profile at %s, got %v", path, err)
+			}
+		}
+		expected := []string{"parse.cpu.pprof", "parse.heap.pprof", "graph.cpu.pprof", "graph.heap.pprof", "outer.cpu.pprof", "inner.heap.pprof", "outer.heap.pprof",
+			"graph.cpu.pprof", "graph.heap.pprof", "graph.heap.pprof"}
+		if !reflect.DeepEqual(kinds, expected) {
+			t.Errorf("Expected %v, got %v", expected, kinds)
+		}
+		if entries, err := os.ReadDir(dir); err != nil || len(entries) != len(expected) {
+			t.Errorf("Expected %d distinct profiles, got %d and %v", len(expected), len(entries), err)
+		}
+	})
+
+	t.Run("Does not overwrite the profiles of another run", func(t *testing.T) {
+		other, err := NewProfiler(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The first stage of another run starting in the same second would get the name of the first stage above.
+		started, _ := time.Parse(profileTimeLayout, strings.Split(filepath.Base(profiler.Files()[0]), "-")[1])
+		existing := profiler.Files()[0]
+		file, err := other.create(profiledStage{"parse", started, 1}, "cpu")
+		if err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+		if path := other.Files()[0]; path == existing || !strings.HasSuffix(path, "-1-1.cpu.pprof") {
+			t.Errorf("Expected a profile next to %s, got %s", existing, path)
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package graph
+
+import "fmt"
+
+// Provenance is the place in the JSON input that a package was decoded from, see WithProvenance.
+type Provenance struct {
+	// File is the path of the input file, as it was given to ReadPackagesJSON.
+	File string `json:"file"`
+	// Start and End are the byte offsets of the first byte of the package record in the file and of the byte after
+	// its closing brace.
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// WithProvenance makes ReadPackagesJSON and OpenPackageGraph record the file and the byte range that every package
+// was decoded from, so that a suspicious record can be looked up in the input, for example with
+// "tail -c +$((start+1)) file | head -c $((end-start))". The provenance is kept by the graph, its cache and
+// checkpoints, and is available from PackageGraph.Provenance and the Provenance of every NodeInfo. Of a package
+// listed several times in the input, the first record is kept. Reading is somewhat slower with it, as every record
+// is decoded twice.
+func WithProvenance() Option {
+	return func(options *Options) {
+		options.Provenance = true
+	}
+}
+
+// Provenance returns the file and the byte range of the record that the package was decoded from. The bool is false
+// if the package is not part of the graph or its provenance was not recorded, see WithProvenance. The name is looked
+// up like in Versions.
+func (pg *PackageGraph) Provenance(name string) (file string, start, end int64, ok bool) {
+	index, found := pg.packageIndex[pg.packageKey("", name)]
+	if !found || (*pg.Packages)[index].Provenance == nil {
+		return "", 0, 0, false
+	}
+	provenance := (*pg.Packages)[index].Provenance
+	return provenance.File, provenance.Start, provenance.End, true
+}
+
+// RegisterProvenance registers the node attributes source_file, source_start and source_end with the provenance of
+// the package of every node, which the nodes without a recorded provenance do not have, so that the exporters of
+// the schema, such as the CSV, GraphML and GEXF exporters, write them. It fails if they are already registered.
+func (schema *AttributeSchema) RegisterProvenance() error {
+	provenance := func(node NodeInfo, value func(*Provenance) interface{}) (interface{}, bool) {
+		if node.Provenance == nil {
+			return nil, false
+		}
+		return value(node.Provenance), true
+	}
+	attributes := []NodeAttribute{
+		{Name: "source_file", Value: func(_ *PackageGraph, node NodeInfo) (interface{}, bool) {
+			return provenance(node, func(p *Provenance) interface{} { return p.File })
+		}},
+		{Name: "source_start", Type: IntAttribute, Value: func(_ *PackageGraph, node NodeInfo) (interface{}, bool) {
+			return provenance(node, func(p *Provenance) interface{} { return int(p.Start) })
+		}},
+		{Name: "source_end", Type: IntAttribute, Value: func(_ *PackageGraph, node NodeInfo) (interface{}, bool) {
+			return provenance(node, func(p *Provenance) interface{} { return int(p.End) })
+		}},
+	}
+	for _, attribute := range attributes {
+		if err := schema.RegisterNode(attribute); err != nil {
+			return fmt.Errorf("registering the provenance: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// checkProvenance checks that the provenance of every package covers the record of the package in the file.
+func checkProvenance(t *testing.T, path string, packages []PackageInfo) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, packageInfo := range packages {
+		provenance := packageInfo.Provenance
+		if provenance == nil || provenance.File != path || provenance.Start < 0 || provenance.End > int64(len(data)) {
+			t.Errorf("Expected the provenance of %s in %s, got %+v", packageInfo.Name, path, provenance)
+			continue
+		}
+		var decoded PackageInfo
+		if err := json.Unmarshal(data[provenance.Start:provenance.End], &decoded); err != nil || decoded.Name != packageInfo.Name {
+			t.Errorf("Expected the record of %s at %d-%d, got %q", packageInfo.Name, provenance.Start, provenance.End,
+				data[provenance.Start:provenance.End])
+		}
+	}
+}
+
+func TestProvenance(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "packages.json")
+	input := "[\n  {\"name\": \"Lib\", \"versions\": {\"1.0.0\": {\"timestamp\": \"2020-01-01T00:00:00\"}}} ,\n" +
+		"\t{\"name\": \"App\", \"versions\": {\"1.0.0\": {\"dependencies\": {\"Lib\": \"^1.0.0\"}}}}\n]\n"
+	if err := os.WriteFile(path, []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Records the byte range of every record", func(t *testing.T) {
+		packages, err := ReadPackagesJSON(path, nil, WithProvenance())
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkProvenance(t, path, *packages)
+		if lib := (*packages)[0].Provenance; lib.Start != 4 || input[lib.End-1] != '}' || input[lib.End] != ' ' {
+			t.Errorf("Expected the record of Lib to start at 4 and end at its brace, got %+v", lib)
+		}
+		if packages, _ := ReadPackagesJSON(path, nil); (*packages)[0].Provenance != nil {
+			t.Errorf("Expected no provenance without WithProvenance")
+		}
+	})
+
+	t.Run("Records the byte range of the records around corrupt ones", func(t *testing.T) {
+		report := &CorruptInputReport{}
+		corrupt := filepath.Join("testdata", "corrupt_middle.json")
+		packages, err := ReadPackagesJSON(corrupt, nil, WithProvenance(), WithSkipCorrupt(report, -1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(*packages) == 0 || len(report.Skipped) == 0 {
+			t.Fatalf("Expected packages and skipped records, got %d and %v", len(*packages), report.Skipped)
+		}
+		checkProvenance(t, corrupt, *packages)
+	})
+
+	t.Run("Keeps the provenance in the graph and its cache", func(t *testing.T) {
+		pg, err := OpenPackageGraph(path, false, WithProvenance())
+		if err != nil {
+			t.Fatal(err)
+		}
+		file, start, end, ok := pg.Provenance("App")
+		expected := (*pg.Packages)[pg.packageIndex[NewPackageKey("", "App")]].Provenance
+		if !ok || file != path || start != expected.Start || end != expected.End {
+			t.Errorf("Expected the provenance %+v of App, got %s, %d, %d and %v", expected, file, start, end, ok)
+		}
+		if app, _ := pg.FindNode(NameVersion{Name: "App", Version: "1.0.0"}); app.Provenance != expected {
+			t.Errorf("Expected the node of App to share the provenance of the package, got %+v", app.Provenance)
+		}
+		if _, _, _, ok := pg.Provenance("Missing"); ok {
+			t.Errorf("Expected no provenance of a missing package")
+		}
+
+		var cache bytes.Buffer
+		if err := SaveGraph(&cache, pg); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadGraph(&cache)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lib, _ := loaded.FindNode(NameVersion{Name: "Lib", Version: "1.0.0"}); !reflect.DeepEqual(lib.Provenance, (*pg.Packages)[pg.packageIndex[NewPackageKey("", "Lib")]].Provenance) {
+			t.Errorf("Expected the provenance of Lib to survive the cache, got %+v", lib.Provenance)
+		}
+	})
+
+	t.Run("Takes the provenance of added packages", func(t *testing.T) {
+		pg, err := OpenPackageGraph(path, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, _, ok := pg.Provenance("Lib"); ok {
+			t.Errorf("Expected no provenance without WithProvenance")
+		}
+		provenance := &Provenance{File: "other.json", Start: 10, End: 20}
+		if err := pg.AddPackage(PackageInfo{Name: "Web", Versions: map[string]VersionInfo{"1.0.0": {}}, Provenance: provenance}); err != nil {
+			t.Fatal(err)
+		}
+		if file, start, end, ok := pg.Provenance("Web"); !ok || file != "other.json" || start != 10 || end != 20 {
+			t.Errorf("Expected the provenance of the added package, got %s, %d, %d and %v", file, start, end, ok)
+		}
+	})
+
+	t.Run("Registers the provenance attributes", func(t *testing.T) {
+		pg, err := OpenPackageGraph(path, false, WithProvenance())
+		if err != nil {
+			t.Fatal(err)
+		}
+		schema := pg.Attributes()
+		if err := schema.RegisterProvenance(); err != nil {
+			t.Fatal(err)
+		}
+		if err := schema.RegisterProvenance(); err == nil {
+			t.Errorf("Expected registering the provenance twice to fail")
+		}
+		lib, _ := pg.FindNode(NameVersion{Name: "Lib", Version: "1.0.0"})
+		values := make(map[string]interface{})
+		for _, attribute := range schema.Nodes() {
+			if value, ok := attribute.Value(pg, lib); ok {
+				values[attribute.Name] = value
+			}
+		}
+		if values["source_file"] != path || values["source_start"] != 4 || values["source_end"] != int(lib.Provenance.End) {
+			t.Errorf("Expected the provenance of Lib as attributes, got %v", values)
+		}
+		if _, ok := schema.Nodes()[len(schema.Nodes())-1].Value(pg, NodeInfo{}); ok {
+			t.Errorf("Expected no value for a node without provenance")
+		}
+	})
+}
@@ -0,0 +1,301 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"gonum.org/v1/gonum/graph"
+)
+
+// ParseNameVersion parses a "name@version" string. The version is separated at the last "@", so scoped npm names such
+// as "@babel/core@7.0.0" are supported.
+func ParseNameVersion(s string) (NameVersion, error) {
+	separator := strings.LastIndex(s, "@")
+	if separator <= 0 || separator == len(s)-1 {
+		return NameVersion{}, fmt.Errorf("%q is not of the form name@version", s)
+	}
+	return NameVersion{Name: s[:separator], Version: s[separator+1:]}, nil
+}
+
+// FindNode returns the node information of the given package version. The name is normalized like the names the graph
+// was built from, so with the default normalization "%40babel%2Fcore" finds the versions of "@babel/core". A version
+// without an ecosystem may name it as a qualified name, see QualifiedName.
+func (pg *PackageGraph) FindNode(nameVersion NameVersion) (NodeInfo, bool) {
+	return pg.lookup(pg.canonical(nameVersion))
+}
+
+// Versions returns the node information of all the versions of the package with the given name, from the lowest to
+// the highest version. The name is normalized like in FindNode; a name qualified with an ecosystem of the graph, such
+// as "npm:lodash", only finds the versions in that ecosystem. The result is empty if the package is not part of the
+// graph.
+func (pg *PackageGraph) Versions(name string) []NodeInfo {
+	ids := pg.versionIDs(pg.packageKey("", name))
+	versions := make([]NodeInfo, len(ids))
+	for i, id := range ids {
+		versions[i], _ = pg.Node(id)
+	}
+	sort.Slice(versions, func(i, j int) bool { return compareVersions(versions[i].Version, versions[j].Version) < 0 })
+	return versions
+}
+
+// Dependencies returns the package versions the given version depends on, up to maxDepth edges away. A negative
+// maxDepth returns all the transitive dependencies. The result is in BFS order, visiting neighbours by ID, and does not
+// include the version itself. The bool is false if the version is not part of the graph.
+func (pg *PackageGraph) Dependencies(nameVersion NameVersion, maxDepth int) ([]NodeInfo, bool) {
+	return pg.bfs(nameVersion, maxDepth, Forward)
+}
+
+// Dependents returns the package versions that depend on the given version, up to maxDepth edges away. A negative
+// maxDepth returns all the transitive dependents. The result is ordered like the result of Dependencies.
+func (pg *PackageGraph) Dependents(nameVersion NameVersion, maxDepth int) ([]NodeInfo, bool) {
+	return pg.bfs(nameVersion, maxDepth, Backward)
+}
+
+// Subgraph returns a PackageGraph of the given version and its dependencies up to maxDepth edges away, with all the
+// edges between them and the settings of pg, like Dependencies with a negative maxDepth returning all of them. The
+// versions keep their relative order and get new IDs from 0 up, unless they keep their ID with HashedIDs. The bool is
+// false if the version is not part of the graph.
+func (pg *PackageGraph) Subgraph(nameVersion NameVersion, maxDepth int) (*PackageGraph, bool) {
+	pg.materializeExternalEdges()
+	dependencies, ok := pg.Dependencies(nameVersion, maxDepth)
+	if !ok {
+		return nil, false
+	}
+	root, _ := pg.FindNode(nameVersion)
+	members := map[int64]bool{root.id: true}
+	ids := []int64{root.id}
+	for _, dependency := range dependencies {
+		members[dependency.id] = true
+		ids = append(ids, dependency.id)
+	}
+	if pg.lockEdges() {
+		defer pg.lazyMutex.Unlock()
+	}
+	// The versions at maxDepth may be unresolved, while their edges to the other members are part of the subgraph.
+	pg.resolveVersions(ids)
+	return pg.inducedSubgraph(members), true
+}
+
+func (pg *PackageGraph) bfs(nameVersion NameVersion, maxDepth int, dir Direction) ([]NodeInfo, bool) {
+	root, ok := pg.FindNode(nameVersion)
+	if !ok {
+		return nil, false
+	}
+	if pg.lockEdges() {
+		defer pg.lazyMutex.Unlock()
+	}
+	var result []NodeInfo
+	Traverse(pg.DependencyGraph(), []int64{root.id}, dir, func(id int64, depth int) TraverseSignal {
+		if depth > 0 {
+			info, _ := pg.Node(id)
+			result = append(result, info)
+		}
+		if maxDepth >= 0 && depth >= maxDepth {
+			return SkipChildren
+		}
+		if dir == Forward {
+			pg.resolveVersions([]int64{id})
+		} else {
+			pg.resolveDependents(id)
+		}
+		return Continue
+	})
+	return result, true
+}
+
+// ShortestPath returns a shortest chain of dependency edges from one package version to another, including both ends.
+// If there are several, the one visiting the lowest node IDs first is returned. The bool is false if either version is
+// not part of the graph or if from does not depend on to, directly or transitively.
+func (pg *PackageGraph) ShortestPath(from, to NameVersion) ([]NodeInfo, bool) {
+	source, ok := pg.FindNode(from)
+	if !ok {
+		return nil, false
+	}
+	target, ok := pg.FindNode(to)
+	if !ok {
+		return nil, false
+	}
+	if pg.lockEdges() {
+		defer pg.lazyMutex.Unlock()
+	}
+	parents := map[int64]int64{source.id: source.id}
+	queue := []int64{source.id}
+	for head := 0; head < len(queue) && queue[head] != target.id; head++ {
+		id := queue[head]
+		pg.resolveVersions([]int64{id})
+		for _, successor := range sortedNodeIDs(pg.DependencyGraph().From(id)) {
+			if _, seen := parents[successor]; !seen {
+				parents[successor] = id
+				queue = append(queue, successor)
+			}
+		}
+	}
+	if _, reached := parents[target.id]; !reached {
+		return nil, false
+	}
+	var path []NodeInfo
+	for id := target.id; ; id = parents[id] {
+		info, _ := pg.Node(id)
+		path = append(path, info)
+		if id == source.id {
+			break
+		}
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, true
+}
+
+// allPathsExpansions is the number of chain extensions that AllPaths spends per requested path.
+const allPathsExpansions = 10000
+
+// AllPaths returns simple chains of dependency edges from one package version to another, including both ends, of at
+// most maxLen edges. A negative maxLen does not limit the length. The paths are searched depth first, trying the
+// dependencies of a version by increasing node ID, and returned in the order found. At most maxPaths paths are
+// returned, and a maxPaths below one does not limit the number. The result is nil if either version is not part of the
+// graph or to is not reached.
+//
+// The search skips the versions from which to cannot be reached within the remaining length, but not the chains that
+// could only reach to through versions already on them. So that these cannot make it explode on dense graphs, a
+// maxPaths of one or more also limits the search to maxPaths times allPathsExpansions chain extensions, plus the
+// number of versions that reach to for every path. The bool is true if the search stopped at maxPaths although another
+// path was found, or ran out of extensions, possibly with fewer than maxPaths paths.
+//
+// Only the edges that exist in the graph are followed, so a graph with lazy edges only yields the paths over the edges
+// created so far.
+func (pg *PackageGraph) AllPaths(from, to NameVersion, maxLen, maxPaths int) ([][]NodeInfo, bool) {
+	source, ok := pg.FindNode(from)
+	if !ok {
+		return nil, false
+	}
+	target, ok := pg.FindNode(to)
+	if !ok {
+		return nil, false
+	}
+
+	// distances holds the length of the shortest chain from every node to the target, within maxLen.
+	distances := make(map[int64]int)
+	Traverse(pg.DependencyGraph(), []int64{target.id}, Backward, func(id int64, depth int) TraverseSignal {
+		distances[id] = depth
+		if maxLen >= 0 && depth >= maxLen {
+			return SkipChildren
+		}
+		return Continue
+	})
+	if _, reached := distances[source.id]; !reached {
+		return nil, false
+	}
+
+	var paths [][]NodeInfo
+	truncated := false
+	// budget counts down the chain extensions that are left, unless maxPaths is unlimited or too large to count.
+	perPath := allPathsExpansions + len(distances)
+	limited := maxPaths > 0 && maxPaths <= math.MaxInt/perPath
+	budget := maxPaths * perPath
+	onPath := map[int64]bool{}
+	var path []int64
+	var visit func(id int64) bool
+	// visit extends the path with id and returns false once the search should stop.
+	visit = func(id int64) bool {
+		if limited {
+			if budget == 0 {
+				truncated = true
+				return false
+			}
+			budget--
+		}
+		path = append(path, id)
+		onPath[id] = true
+		defer func() {
+			path = path[:len(path)-1]
+			delete(onPath, id)
+		}()
+		if id == target.id {
+			if maxPaths > 0 && len(paths) == maxPaths {
+				truncated = true
+				return false
+			}
+			found := make([]NodeInfo, len(path))
+			for i, node := range path {
+				found[i], _ = pg.Node(node)
+			}
+			paths = append(paths, found)
+			return true
+		}
+		remaining := maxLen - (len(path) - 1)
+		for _, successor := range sortedNodeIDs(pg.DependencyGraph().From(id)) {
+			distance, ok := distances[successor]
+			if !ok || onPath[successor] || (maxLen >= 0 && distance+1 > remaining) {
+				continue
+			}
+			if !visit(successor) {
+				return false
+			}
+		}
+		return true
+	}
+	visit(source.id)
+	return paths, truncated
+}
+
+// GraphStats summarizes the size of a PackageGraph.
+type GraphStats struct {
+	Packages     int     `json:"packages"`
+	Nodes        int     `json:"nodes"`
+	Edges        int     `json:"edges"`
+	MaxInDegree  int     `json:"maxInDegree"`
+	MaxOutDegree int     `json:"maxOutDegree"`
+	MeanDegree   float64 `json:"meanDegree"`
+}
+
+// Stats computes the number of packages, nodes and edges and the degree statistics of the graph. For a graph with
+// lazy edges, only the edges created so far are counted.
+func (pg *PackageGraph) Stats() GraphStats {
+	if pg.lockEdges() {
+		defer pg.lazyMutex.Unlock()
+	}
+	stats := GraphStats{
+		Packages: len(pg.NameToVersions),
+		Nodes:    pg.Graph.Nodes().Len(),
+		Edges:    pg.Graph.Edges().Len(),
+	}
+	nodes := pg.Graph.Nodes()
+	for nodes.Next() {
+		id := nodes.Node().ID()
+		if in := pg.DependencyGraph().To(id).Len(); in > stats.MaxInDegree {
+			stats.MaxInDegree = in
+		}
+		if out := pg.DependencyGraph().From(id).Len(); out > stats.MaxOutDegree {
+			stats.MaxOutDegree = out
+		}
+	}
+	if stats.Nodes > 0 {
+		stats.MeanDegree = float64(stats.Edges) / float64(stats.Nodes)
+	}
+	return stats
+}
+
+// InDegrees returns the number of direct dependents of every node.
+func InDegrees(g graph.Directed) map[int64]float64 {
+	degrees := make(map[int64]float64)
+	nodes := g.Nodes()
+	for nodes.Next() {
+		id := nodes.Node().ID()
+		degrees[id] = float64(g.To(id).Len())
+	}
+	return degrees
+}
+
+// OutDegrees returns the number of direct dependencies of every node.
+func OutDegrees(g graph.Directed) map[int64]float64 {
+	degrees := make(map[int64]float64)
+	nodes := g.Nodes()
+	for nodes.Next() {
+		id := nodes.Node().ID()
+		degrees[id] = float64(g.From(id).Len())
+	}
+	return degrees
+}
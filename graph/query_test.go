@@ -0,0 +1,238 @@
+package graph
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseNameVersion(t *testing.T) {
+	t.Run("Splits at the last @", func(t *testing.T) {
+		nameVersion, err := ParseNameVersion("@babel/core@7.0.0")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if nameVersion.Name != "@babel/core" || nameVersion.Version != "7.0.0" {
+			t.Errorf("Expected @babel/core and 7.0.0, got %s and %s", nameVersion.Name, nameVersion.Version)
+		}
+	})
+
+	t.Run("Rejects strings without a name or version", func(t *testing.T) {
+		for _, s := range []string{"A", "@1.0.0", "A@"} {
+			if _, err := ParseNameVersion(s); err == nil {
+				t.Errorf("Expected an error for %q", s)
+			}
+		}
+	})
+}
+
+func TestPackageGraphQueries(t *testing.T) {
+	packagesInfo := testPackages("options")
+	pg := NewPackageGraph(&packagesInfo, false, WithDependencyClasses(Runtime, Development))
+
+	t.Run("Dependencies lists the direct and transitive dependencies", func(t *testing.T) {
+		dependencies, ok := pg.Dependencies(NameVersion{Name: "App", Version: "1.0.0"}, -1)
+		if !ok {
+			t.Fatal("Expected App 1.0.0 to be found")
+		}
+		if len(dependencies) != 4 {
+			t.Errorf("Expected 4 dependencies, got %d", len(dependencies))
+		}
+	})
+
+	t.Run("Dependents respects the maximum depth", func(t *testing.T) {
+		dependents, ok := pg.Dependents(NameVersion{Name: "A", Version: "1.1.0"}, 0)
+		if !ok || len(dependents) != 0 {
+			t.Errorf("Expected no dependents at depth 0, got %d", len(dependents))
+		}
+		dependents, _ = pg.Dependents(NameVersion{Name: "A", Version: "1.1.0"}, 1)
+		if len(dependents) != 1 || dependents[0].Name != "App" {
+			t.Errorf("Expected App as the only dependent, got %v", dependents)
+		}
+	})
+
+	t.Run("Reports missing versions", func(t *testing.T) {
+		if _, ok := pg.Dependencies(NameVersion{Name: "A", Version: "9.0.0"}, -1); ok {
+			t.Error("Expected A 9.0.0 not to be found")
+		}
+	})
+
+	t.Run("Finds a shortest path along the dependency edges", func(t *testing.T) {
+		path, ok := pg.ShortestPath(NameVersion{Name: "App", Version: "1.0.0"}, NameVersion{Name: "Test", Version: "1.0.0"})
+		if !ok || len(path) != 2 || path[0].Name != "App" || path[1].Name != "Test" {
+			t.Errorf("Expected the path App, Test, got %v", path)
+		}
+		if _, ok := pg.ShortestPath(NameVersion{Name: "Test", Version: "1.0.0"}, NameVersion{Name: "App", Version: "1.0.0"}); ok {
+			t.Error("Expected no path against the edge direction")
+		}
+	})
+
+	t.Run("Computes the graph statistics", func(t *testing.T) {
+		stats := pg.Stats()
+		if stats.Packages != 3 || stats.Nodes != 5 || stats.Edges != 4 {
+			t.Errorf("Expected 3 packages, 5 nodes and 4 edges, got %d, %d and %d", stats.Packages, stats.Nodes, stats.Edges)
+		}
+		if stats.MaxOutDegree != 4 || stats.MaxInDegree != 1 {
+			t.Errorf("Expected degrees 4 and 1, got %d and %d", stats.MaxOutDegree, stats.MaxInDegree)
+		}
+	})
+}
+
+func TestAllPaths(t *testing.T) {
+	version := func(dependencies map[string]string) map[string]VersionInfo {
+		return map[string]VersionInfo{"1.0.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: dependencies}}
+	}
+	packagesInfo := []PackageInfo{
+		{Name: "A", Versions: version(map[string]string{"B": ">=1.0.0", "C": "1.0.0"})},
+		{Name: "B", Versions: map[string]VersionInfo{
+			"1.0.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{"C": "1.0.0"}},
+			"2.0.0": {Timestamp: "2021-01-01T00:00:00", Dependencies: map[string]string{"C": "1.0.0"}},
+		}},
+		// The dependency of C on A creates cycles, which simple paths do not follow.
+		{Name: "C", Versions: version(map[string]string{"A": "1.0.0"})},
+	}
+	pg := NewPackageGraph(&packagesInfo, false)
+	from, to := NameVersion{Name: "A", Version: "1.0.0"}, NameVersion{Name: "C", Version: "1.0.0"}
+
+	t.Run("Finds every simple path", func(t *testing.T) {
+		paths, truncated := pg.AllPaths(from, to, -1, 0)
+		if len(paths) != 3 || truncated {
+			t.Fatalf("Expected 3 paths without truncation, got %v and %t", paths, truncated)
+		}
+		for _, path := range paths {
+			if path[0].Name != "A" || path[len(path)-1].Name != "C" {
+				t.Errorf("Expected a path from A to C, got %v", path)
+			}
+		}
+	})
+
+	t.Run("Leaves out paths longer than the limit", func(t *testing.T) {
+		paths, _ := pg.AllPaths(from, to, 1, 0)
+		if len(paths) != 1 || len(paths[0]) != 2 {
+			t.Errorf("Expected only the direct path, got %v", paths)
+		}
+	})
+
+	t.Run("Reports truncation at the path limit", func(t *testing.T) {
+		paths, truncated := pg.AllPaths(from, to, -1, 2)
+		if len(paths) != 2 || !truncated {
+			t.Errorf("Expected 2 paths with truncation, got %d and %t", len(paths), truncated)
+		}
+		if paths, truncated := pg.AllPaths(from, to, -1, 3); len(paths) != 3 || truncated {
+			t.Errorf("Expected 3 paths without truncation, got %d and %t", len(paths), truncated)
+		}
+	})
+
+	t.Run("Returns nothing without a path", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		acyclic := NewPackageGraph(&packagesInfo, false)
+		if paths, _ := acyclic.AllPaths(NameVersion{Name: "A", Version: "1.0.0"}, NameVersion{Name: "App", Version: "1.0.0"}, -1, 0); paths != nil {
+			t.Errorf("Expected no paths against the edge direction, got %v", paths)
+		}
+		if paths, _ := pg.AllPaths(from, NameVersion{Name: "D", Version: "1.0.0"}, -1, 0); paths != nil {
+			t.Errorf("Expected no paths to an unknown version, got %v", paths)
+		}
+	})
+
+	t.Run("Stops at the path limit on dense graphs", func(t *testing.T) {
+		// Every package depends on all the packages after it, so there are 2^38 paths from the first to the last.
+		var dense []PackageInfo
+		for i := 0; i < 40; i++ {
+			dependencies := map[string]string{}
+			for j := i + 1; j < 40; j++ {
+				dependencies[fmt.Sprintf("P%d", j)] = "1.0.0"
+			}
+			dense = append(dense, PackageInfo{Name: fmt.Sprintf("P%d", i), Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: dependencies},
+			}})
+		}
+		pg := NewPackageGraph(&dense, false)
+		paths, truncated := pg.AllPaths(NameVersion{Name: "P0", Version: "1.0.0"}, NameVersion{Name: "P39", Version: "1.0.0"}, -1, 100)
+		if len(paths) != 100 || !truncated {
+			t.Errorf("Expected 100 paths with truncation, got %d and %t", len(paths), truncated)
+		}
+	})
+
+	t.Run("Stops on dense graphs whose chains find no path", func(t *testing.T) {
+		// Gate depends on the target and on 25 packages that all depend on each other and on Gate, so that every chain
+		// through them can only reach the target through Gate, which is already on it.
+		versions := func(dependencies map[string]string) map[string]VersionInfo {
+			return map[string]VersionInfo{"1.0.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: dependencies}}
+		}
+		gate := map[string]string{"Target": "1.0.0"}
+		dense := []PackageInfo{
+			{Name: "Source", Versions: versions(map[string]string{"Gate": "1.0.0"})},
+			{Name: "Gate", Versions: versions(gate)},
+			{Name: "Target", Versions: versions(nil)},
+		}
+		for i := 0; i < 25; i++ {
+			dependencies := map[string]string{"Gate": "1.0.0"}
+			for j := 0; j < 25; j++ {
+				if j != i {
+					dependencies[fmt.Sprintf("P%d", j)] = "1.0.0"
+				}
+			}
+			gate[fmt.Sprintf("P%d", i)] = "1.0.0"
+			dense = append(dense, PackageInfo{Name: fmt.Sprintf("P%d", i), Versions: versions(dependencies)})
+		}
+		pg := NewPackageGraph(&dense, false)
+		paths, truncated := pg.AllPaths(NameVersion{Name: "Source", Version: "1.0.0"}, NameVersion{Name: "Target", Version: "1.0.0"}, -1, 5)
+		if len(paths) != 1 || !truncated {
+			t.Errorf("Expected the only path with truncation, got %v and %t", paths, truncated)
+		}
+	})
+}
+
+func TestNameVersionCollisions(t *testing.T) {
+	// "a-1" at version "2.0.0" and "a" at version "1-2.0.0" share the stringID "a-1-2.0.0".
+	newGraph := func() *PackageGraph {
+		packagesInfo := []PackageInfo{
+			{Name: "App", Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{"a-1": "^2.0.0", "a": "1-2.0.0"}},
+			}},
+			{Name: "a-1", Versions: map[string]VersionInfo{"2.0.0": {Timestamp: "2020-01-01T00:00:00"}}},
+			{Name: "a", Versions: map[string]VersionInfo{"1-2.0.0": {Timestamp: "2020-01-01T00:00:00"}}},
+		}
+		return NewPackageGraph(&packagesInfo, false)
+	}
+	hyphenatedName, hyphenatedVersion := NameVersion{Name: "a-1", Version: "2.0.0"}, NameVersion{Name: "a", Version: "1-2.0.0"}
+
+	t.Run("Finds both versions", func(t *testing.T) {
+		pg := newGraph()
+		first, ok := pg.FindNode(hyphenatedName)
+		if !ok || first.Name != "a-1" {
+			t.Fatalf("Expected to find a-1@2.0.0, got %v (%t)", first, ok)
+		}
+		second, ok := pg.FindNode(hyphenatedVersion)
+		if !ok || second.Name != "a" || second.ID() == first.ID() {
+			t.Fatalf("Expected to find a@1-2.0.0 as another node, got %v (%t)", second, ok)
+		}
+		dependencies, _ := pg.Dependencies(NameVersion{Name: "App", Version: "1.0.0"}, 1)
+		if len(dependencies) != 2 {
+			t.Errorf("Expected App to depend on both versions, got %v", dependencies)
+		}
+		if errs := Validate(pg); len(errs) != 0 {
+			t.Errorf("Expected no validation errors, got %v", errs)
+		}
+	})
+
+	t.Run("Keeps the deprecated map consistent", func(t *testing.T) {
+		pg := newGraph()
+		if info := pg.StringIDToNodeInfo["a-1-2.0.0"]; info.Name != "a-1" {
+			t.Errorf("Expected the version with the lowest ID under the shared key, got %v", info)
+		}
+		if err := pg.RemoveVersion("a-1", "2.0.0", false); err != nil {
+			t.Fatal(err)
+		}
+		if info := pg.StringIDToNodeInfo["a-1-2.0.0"]; info.Name != "a" {
+			t.Errorf("Expected the remaining version under the shared key, got %v", info)
+		}
+		if _, ok := pg.FindNode(hyphenatedVersion); !ok {
+			t.Error("Expected a@1-2.0.0 to remain part of the graph")
+		}
+		for _, err := range Validate(pg) {
+			if err.Kind != SparseID {
+				t.Errorf("Expected only sparse IDs after the removal, got %v", err)
+			}
+		}
+	})
+}
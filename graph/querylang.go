@@ -0,0 +1,1051 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Query is a parsed query expression, see ParseQuery. A query can be evaluated any number of times, on any graph.
+type Query struct {
+	text string
+	root queryExpr
+}
+
+// QueryResult is the table of versions that a query evaluates to. Columns names the scores added by the top stages, in
+// stage order, and every row holds the scores of its version in that order; a query without top stages evaluates to a
+// plain list of versions.
+type QueryResult struct {
+	Columns []string   `json:"columns,omitempty"`
+	Rows    []QueryRow `json:"rows"`
+}
+
+// QueryRow is a version of a QueryResult with its scores.
+type QueryRow struct {
+	Node   NodeInfo  `json:"node"`
+	Values []float64 `json:"values,omitempty"`
+}
+
+// Nodes returns the versions of the rows, in row order.
+func (result *QueryResult) Nodes() []NodeInfo {
+	nodes := make([]NodeInfo, len(result.Rows))
+	for i, row := range result.Rows {
+		nodes[i] = row.Node
+	}
+	return nodes
+}
+
+// QueryError is the error of a query that cannot be parsed or evaluated. Offset is the position in the query, in
+// bytes, of the part that caused it.
+type QueryError struct {
+	Query   string
+	Offset  int
+	Message string
+	// NotFound is set if the query starts from a package or version that is not part of the graph.
+	NotFound bool
+}
+
+func (err *QueryError) Error() string {
+	return fmt.Sprintf("query at offset %d: %s", err.Offset, err.Message)
+}
+
+// Context returns the query and, below it, a caret under the offset, to be shown along with the error.
+func (err *QueryError) Context() string {
+	offset := err.Offset
+	if offset > len(err.Query) {
+		offset = len(err.Query)
+	}
+	return err.Query + "\n" + strings.Repeat(" ", utf8.RuneCountInString(err.Query[:offset])) + "^"
+}
+
+// ParseQuery parses a query expression, which selects versions of a graph and ranks them. A query is a source, piped
+// through any number of stages with |, and queries can be combined with set operations, as in
+//
+//	dependents(name="lodash", version="4.17.21", transitive=true) | filter(timestamp < "2020-01-01") | top(indegree, 20)
+//
+// The sources are:
+//
+//	dependents(name, version, transitive, depth)  the dependents of a version, see PackageGraph.Dependents
+//	deps(name, version, transitive, depth)        the dependencies of a version, see PackageGraph.Dependencies
+//	path(from, to)                                the versions of a shortest path between two "name@version"s
+//	search(pattern, mode, ignorecase)             the versions of the packages whose name matches, see SearchNodes
+//	all()                                         every version of the graph
+//
+// The arguments are given as name=value or by position, in the order above. Strings are double-quoted with the escapes
+// of Go, numbers are decimal and the bools are true and false. Without a version, dependents and deps start from the
+// highest release of the package, or its highest version if it has no releases. They follow depth edges, 1 unless
+// given, or every edge with transitive=true. The search mode is exact, prefix, glob, the default, or regexp.
+//
+// The stages are:
+//
+//	filter(condition)  the versions for which the condition holds
+//	top(metric, n)     the n versions with the highest score of the metric, highest first, with the score as column
+//
+// A negative n in top keeps every version that has a score, ranked the same way.
+//
+// A condition compares an attribute of the AttributeSchema of the graph, such as timestamp or license, or a metric
+// registered with RegisterMetric, with a value using =, !=, <, <=, > or >=, or matches a string attribute against a
+// regular expression with ~. Conditions combine with and, or, not and parentheses. Versions compare in semver order,
+// timestamps as times and numbers and other strings as such; a version without a value fails every comparison.
+//
+// The set operations are + for the union, & for the intersection and - for the difference. They apply from left to
+// right and bind less tightly than |, so parentheses are needed to pipe their result. Their result is sorted by name
+// and version and has no columns.
+//
+// The error of a query that cannot be parsed is a *QueryError.
+func ParseQuery(text string) (*Query, error) {
+	tokens, err := tokenizeQuery(text)
+	if err != nil {
+		return nil, err
+	}
+	parser := &queryParser{text: text, tokens: tokens}
+	root, err := parser.parseSetExpr()
+	if err != nil {
+		return nil, err
+	}
+	if token := parser.peek(); token.kind != endToken {
+		return nil, parser.errorf(token.offset, "expected |, +, & or - but found %s", token)
+	}
+	return &Query{text: text, root: root}, nil
+}
+
+// String returns the text the query was parsed from.
+func (query *Query) String() string {
+	return query.text
+}
+
+// Evaluate runs the query on the graph. The metrics are computed on the whole graph, once per query, so on a graph
+// with lazy edges they only see the edges created so far, see ComputeMetrics. The error of a query that refers to
+// versions, attributes or metrics that do not exist is a *QueryError.
+func (query *Query) Evaluate(pg *PackageGraph) (*QueryResult, error) {
+	evaluation := &queryEvaluation{pg: pg, query: query, scores: make(map[string]map[int64]float64)}
+	rows, err := query.root.evaluate(evaluation)
+	if err != nil {
+		return nil, err
+	}
+	result := &QueryResult{Columns: rows.columns, Rows: make([]QueryRow, len(rows.ids))}
+	for i, id := range rows.ids {
+		result.Rows[i] = QueryRow{Node: pg.node(id), Values: rows.values[id]}
+	}
+	return result, nil
+}
+
+type queryTokenKind int
+
+const (
+	endToken queryTokenKind = iota
+	identToken
+	stringToken
+	numberToken
+	punctToken
+)
+
+type queryToken struct {
+	kind queryTokenKind
+	// text is the token as written, except that a string token holds its unquoted value.
+	text   string
+	offset int
+}
+
+func (token queryToken) String() string {
+	if token.kind == endToken {
+		return "the end of the query"
+	}
+	return strconv.Quote(token.text)
+}
+
+// queryPunctuation are the operators and delimiters of the query language, the longer ones first.
+var queryPunctuation = []string{"!=", "<=", ">=", "(", ")", ",", "=", "<", ">", "~", "|", "+", "-", "&"}
+
+func tokenizeQuery(text string) ([]queryToken, error) {
+	var tokens []queryToken
+	fail := func(offset int, format string, args ...interface{}) error {
+		return &QueryError{Query: text, Offset: offset, Message: fmt.Sprintf(format, args...)}
+	}
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isIdentByte(c) && !isDigit(c):
+			start := i
+			for i < len(text) && isIdentByte(text[i]) {
+				i++
+			}
+			tokens = append(tokens, queryToken{kind: identToken, text: text[start:i], offset: start})
+		case isDigit(c):
+			start := i
+			for i < len(text) && (isDigit(text[i]) || text[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, queryToken{kind: numberToken, text: text[start:i], offset: start})
+		case c == '"':
+			start := i
+			for i++; i < len(text) && text[i] != '"'; i++ {
+				if text[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(text) {
+				return nil, fail(start, "unterminated string")
+			}
+			i++
+			value, err := strconv.Unquote(text[start:i])
+			if err != nil {
+				return nil, fail(start, "invalid string %s", text[start:i])
+			}
+			tokens = append(tokens, queryToken{kind: stringToken, text: value, offset: start})
+		default:
+			found := false
+			for _, punct := range queryPunctuation {
+				if strings.HasPrefix(text[i:], punct) {
+					tokens = append(tokens, queryToken{kind: punctToken, text: punct, offset: i})
+					i += len(punct)
+					found = true
+					break
+				}
+			}
+			if !found {
+				r, _ := utf8.DecodeRuneInString(text[i:])
+				return nil, fail(i, "unexpected character %q", r)
+			}
+		}
+	}
+	return append(tokens, queryToken{kind: endToken, offset: len(text)}), nil
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || isDigit(c)
+}
+
+type queryValueKind int
+
+const (
+	stringValue queryValueKind = iota
+	numberValue
+	boolValue
+	// identValue is a bare identifier, such as the metric of top, which is also accepted as a string.
+	identValue
+)
+
+func (kind queryValueKind) String() string {
+	switch kind {
+	case numberValue:
+		return "a number"
+	case boolValue:
+		return "true or false"
+	case identValue:
+		return "a name"
+	default:
+		return "a string"
+	}
+}
+
+type queryValue struct {
+	kind    queryValueKind
+	text    string
+	number  float64
+	boolean bool
+	offset  int
+}
+
+// queryParam is a parameter of a source or stage. An identValue parameter also accepts a string, so that names that
+// are not identifiers can be quoted.
+type queryParam struct {
+	name     string
+	kind     queryValueKind
+	required bool
+}
+
+var neighbourhoodParams = []queryParam{
+	{"name", stringValue, true}, {"version", stringValue, false}, {"transitive", boolValue, false}, {"depth", numberValue, false},
+}
+
+// querySources builds the sources from their arguments, by name.
+var querySources = map[string]struct {
+	params []queryParam
+	build  func(p *queryParser, call queryToken, args map[string]queryValue) (queryExpr, error)
+}{
+	"dependents": {neighbourhoodParams, buildNeighbourhood},
+	"deps":       {neighbourhoodParams, buildNeighbourhood},
+	"path":       {[]queryParam{{"from", stringValue, true}, {"to", stringValue, true}}, buildPath},
+	"search":     {[]queryParam{{"pattern", stringValue, true}, {"mode", identValue, false}, {"ignorecase", boolValue, false}}, buildSearch},
+	"all":        {nil, func(*queryParser, queryToken, map[string]queryValue) (queryExpr, error) { return allExpr{}, nil }},
+}
+
+type queryParser struct {
+	text   string
+	tokens []queryToken
+	next   int
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.next]
+}
+
+func (p *queryParser) take() queryToken {
+	token := p.tokens[p.next]
+	if token.kind != endToken {
+		p.next++
+	}
+	return token
+}
+
+// is reports whether the next token is the punctuation or keyword.
+func (p *queryParser) is(text string) bool {
+	token := p.peek()
+	return (token.kind == punctToken || token.kind == identToken) && token.text == text
+}
+
+func (p *queryParser) expect(punct string) error {
+	if token := p.take(); token.kind != punctToken || token.text != punct {
+		return p.errorf(token.offset, "expected %s but found %s", punct, token)
+	}
+	return nil
+}
+
+func (p *queryParser) errorf(offset int, format string, args ...interface{}) *QueryError {
+	return &QueryError{Query: p.text, Offset: offset, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *queryParser) parseSetExpr() (queryExpr, error) {
+	left, err := p.parsePipeline()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("+") || p.is("&") || p.is("-") {
+		op := p.take().text
+		right, err := p.parsePipeline()
+		if err != nil {
+			return nil, err
+		}
+		left = setExpr{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parsePipeline() (queryExpr, error) {
+	source, err := p.parseSource()
+	if err != nil {
+		return nil, err
+	}
+	pipeline := pipelineExpr{source: source}
+	for p.is("|") {
+		p.take()
+		stage, err := p.parseStage()
+		if err != nil {
+			return nil, err
+		}
+		pipeline.stages = append(pipeline.stages, stage)
+	}
+	if len(pipeline.stages) == 0 {
+		return source, nil
+	}
+	return pipeline, nil
+}
+
+func (p *queryParser) parseSource() (queryExpr, error) {
+	if p.is("(") {
+		p.take()
+		expr, err := p.parseSetExpr()
+		if err != nil {
+			return nil, err
+		}
+		return expr, p.expect(")")
+	}
+	call := p.take()
+	if call.kind != identToken {
+		return nil, p.errorf(call.offset, "expected a source such as dependents or search but found %s", call)
+	}
+	source, ok := querySources[call.text]
+	if !ok {
+		if call.text == "filter" || call.text == "top" {
+			return nil, p.errorf(call.offset, "%s is a stage and must follow |", call.text)
+		}
+		return nil, p.errorf(call.offset, "unknown source %q, expected dependents, deps, path, search or all", call.text)
+	}
+	args, err := p.parseArguments(call, source.params)
+	if err != nil {
+		return nil, err
+	}
+	return source.build(p, call, args)
+}
+
+func (p *queryParser) parseStage() (queryStage, error) {
+	call := p.take()
+	switch {
+	case call.kind == identToken && call.text == "filter":
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		condition, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return filterStage{condition}, p.expect(")")
+	case call.kind == identToken && call.text == "top":
+		args, err := p.parseArguments(call, []queryParam{{"metric", identValue, true}, {"n", numberValue, true}})
+		if err != nil {
+			return nil, err
+		}
+		metric := args["metric"]
+		if _, ok := registeredMetric(metric.text); !ok {
+			return nil, p.errorf(metric.offset, "unknown metric %q, expected %s", metric.text, strings.Join(MetricNames(), ", "))
+		}
+		n, err := p.intArgument(args["n"])
+		if err != nil {
+			return nil, err
+		}
+		return topStage{metric: metric.text, n: n}, nil
+	case call.kind == identToken && querySources[call.text].build != nil:
+		return nil, p.errorf(call.offset, "%s is a source and cannot follow |, use + or & to combine it", call.text)
+	}
+	return nil, p.errorf(call.offset, "expected a stage, filter or top, but found %s", call)
+}
+
+// parseArguments parses the parenthesized arguments of a call and checks them against the parameters.
+func (p *queryParser) parseArguments(call queryToken, params []queryParam) (map[string]queryValue, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	args := make(map[string]queryValue)
+	for position := 0; !p.is(")"); position++ {
+		if position > 0 {
+			if token := p.take(); token.kind != punctToken || token.text != "," {
+				return nil, p.errorf(token.offset, "expected , or ) but found %s", token)
+			}
+		}
+		var param *queryParam
+		start := p.peek()
+		if start.kind == identToken && p.tokens[p.next+1].kind == punctToken && p.tokens[p.next+1].text == "=" {
+			p.take()
+			p.take()
+			for i := range params {
+				if params[i].name == start.text {
+					param = &params[i]
+				}
+			}
+			if param == nil {
+				return nil, p.errorf(start.offset, "%s has no parameter %q%s", call.text, start.text, paramList(params))
+			}
+		} else if position < len(params) {
+			param = &params[position]
+		} else {
+			return nil, p.errorf(start.offset, "too many arguments for %s%s", call.text, paramList(params))
+		}
+		if _, dup := args[param.name]; dup {
+			return nil, p.errorf(start.offset, "%s of %s is given twice", param.name, call.text)
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if value.kind != param.kind && !(param.kind == identValue && value.kind == stringValue) {
+			return nil, p.errorf(value.offset, "%s of %s must be %s", param.name, call.text, param.kind)
+		}
+		args[param.name] = value
+	}
+	p.take()
+	for _, param := range params {
+		if _, given := args[param.name]; param.required && !given {
+			return nil, p.errorf(call.offset, "%s needs %s%s", call.text, param.name, paramList(params))
+		}
+	}
+	return args, nil
+}
+
+func paramList(params []queryParam) string {
+	if len(params) == 0 {
+		return ", which takes no arguments"
+	}
+	names := make([]string, len(params))
+	for i, param := range params {
+		names[i] = param.name
+	}
+	return ", expected " + strings.Join(names, ", ")
+}
+
+func (p *queryParser) parseValue() (queryValue, error) {
+	token := p.take()
+	switch token.kind {
+	case stringToken:
+		return queryValue{kind: stringValue, text: token.text, offset: token.offset}, nil
+	case identToken:
+		if token.text == "true" || token.text == "false" {
+			return queryValue{kind: boolValue, text: token.text, boolean: token.text == "true", offset: token.offset}, nil
+		}
+		return queryValue{kind: identValue, text: token.text, offset: token.offset}, nil
+	case numberToken:
+		return p.number(token, token.text)
+	case punctToken:
+		if next := p.peek(); token.text == "-" && next.kind == numberToken && next.offset == token.offset+1 {
+			p.take()
+			return p.number(token, "-"+next.text)
+		}
+	}
+	return queryValue{}, p.errorf(token.offset, "expected a value but found %s", token)
+}
+
+func (p *queryParser) number(token queryToken, text string) (queryValue, error) {
+	number, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return queryValue{}, p.errorf(token.offset, "invalid number %s", text)
+	}
+	return queryValue{kind: numberValue, text: text, number: number, offset: token.offset}, nil
+}
+
+func (p *queryParser) intArgument(value queryValue) (int, error) {
+	if value.number != math.Trunc(value.number) || math.Abs(value.number) > math.MaxInt32 {
+		return 0, p.errorf(value.offset, "expected a whole number but found %s", value.text)
+	}
+	return int(value.number), nil
+}
+
+func (p *queryParser) parseOr() (queryCondition, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.is("or") {
+		p.take()
+		var right queryCondition
+		if right, err = p.parseAnd(); err == nil {
+			left = orCondition{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *queryParser) parseAnd() (queryCondition, error) {
+	left, err := p.parseUnary()
+	for err == nil && p.is("and") {
+		p.take()
+		var right queryCondition
+		if right, err = p.parseUnary(); err == nil {
+			left = andCondition{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *queryParser) parseUnary() (queryCondition, error) {
+	switch {
+	case p.is("not"):
+		p.take()
+		condition, err := p.parseUnary()
+		return notCondition{condition}, err
+	case p.is("("):
+		p.take()
+		condition, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return condition, p.expect(")")
+	}
+	attribute := p.take()
+	if attribute.kind != identToken {
+		return nil, p.errorf(attribute.offset, "expected an attribute or metric but found %s", attribute)
+	}
+	op := p.take()
+	if op.kind != punctToken || !strings.Contains(" = != < <= > >= ~ ", " "+op.text+" ") {
+		return nil, p.errorf(op.offset, "expected a comparison such as = or < but found %s", op)
+	}
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	comparison := &comparisonCondition{attribute: attribute.text, attributeOffset: attribute.offset, op: op.text, value: value}
+	if op.text == "~" {
+		if value.kind != stringValue {
+			return nil, p.errorf(value.offset, "~ needs a regular expression as a string")
+		}
+		if comparison.pattern, err = regexp.Compile(value.text); err != nil {
+			return nil, p.errorf(value.offset, "invalid regular expression: %v", err)
+		}
+	}
+	return comparison, nil
+}
+
+func buildNeighbourhood(p *queryParser, call queryToken, args map[string]queryValue) (queryExpr, error) {
+	expr := neighbourhoodExpr{dependents: call.text == "dependents", name: args["name"].text, version: args["version"].text,
+		depth: 1, offset: call.offset}
+	depth, hasDepth := args["depth"]
+	if hasDepth {
+		var err error
+		if expr.depth, err = p.intArgument(depth); err != nil {
+			return nil, err
+		}
+	}
+	if transitive, ok := args["transitive"]; ok && transitive.boolean {
+		if hasDepth {
+			return nil, p.errorf(transitive.offset, "%s takes either transitive=true or a depth", call.text)
+		}
+		expr.depth = -1
+	}
+	return expr, nil
+}
+
+func buildPath(p *queryParser, _ queryToken, args map[string]queryValue) (queryExpr, error) {
+	var expr pathExpr
+	for i, end := range []*NameVersion{&expr.from, &expr.to} {
+		value := args[[]string{"from", "to"}[i]]
+		nameVersion, err := ParseNameVersion(value.text)
+		if err != nil {
+			return nil, p.errorf(value.offset, "%v", err)
+		}
+		*end = nameVersion
+		expr.offsets[i] = value.offset
+	}
+	return expr, nil
+}
+
+func buildSearch(p *queryParser, _ queryToken, args map[string]queryValue) (queryExpr, error) {
+	expr := searchExpr{pattern: args["pattern"].text, mode: MatchGlob, ignoreCase: args["ignorecase"].boolean}
+	if mode, ok := args["mode"]; ok {
+		modes := map[string]MatchMode{"exact": MatchExact, "prefix": MatchPrefix, "glob": MatchGlob, "regexp": MatchRegexp}
+		if expr.mode, ok = modes[mode.text]; !ok {
+			return nil, p.errorf(mode.offset, "invalid mode %q, expected exact, prefix, glob or regexp", mode.text)
+		}
+	}
+	if _, err := nameMatcher(expr.pattern, expr.mode, expr.ignoreCase); err != nil {
+		return nil, p.errorf(args["pattern"].offset, "invalid pattern: %v", err)
+	}
+	return expr, nil
+}
+
+// queryRows is the intermediate result of a query: the node IDs in order, with their scores.
+type queryRows struct {
+	ids     []int64
+	columns []string
+	values  map[int64][]float64
+}
+
+func nodeRows(nodes []NodeInfo) queryRows {
+	rows := queryRows{ids: make([]int64, len(nodes))}
+	for i, node := range nodes {
+		rows.ids[i] = node.id
+	}
+	return rows
+}
+
+type queryEvaluation struct {
+	pg    *PackageGraph
+	query *Query
+	// scores caches the scores of the metrics computed so far.
+	scores map[string]map[int64]float64
+}
+
+func (e *queryEvaluation) errorf(offset int, format string, args ...interface{}) *QueryError {
+	return &QueryError{Query: e.query.text, Offset: offset, Message: fmt.Sprintf(format, args...)}
+}
+
+func (e *queryEvaluation) notFound(offset int, format string, args ...interface{}) *QueryError {
+	err := e.errorf(offset, format, args...)
+	err.NotFound = true
+	return err
+}
+
+func (e *queryEvaluation) metric(name string) (map[int64]float64, error) {
+	if scores, ok := e.scores[name]; ok {
+		return scores, nil
+	}
+	scores, err := ComputeMetrics(e.pg, []string{name})
+	if err != nil {
+		return nil, err
+	}
+	e.scores[name] = scores[name]
+	return scores[name], nil
+}
+
+// registeredMetric returns the function of the metric, if it is registered.
+func registeredMetric(name string) (MetricFunc, bool) {
+	metricsMutex.RLock()
+	defer metricsMutex.RUnlock()
+	fn, ok := metrics[name]
+	return fn, ok
+}
+
+type queryExpr interface {
+	evaluate(e *queryEvaluation) (queryRows, error)
+}
+
+type neighbourhoodExpr struct {
+	dependents    bool
+	name, version string
+	depth         int
+	offset        int
+}
+
+func (expr neighbourhoodExpr) evaluate(e *queryEvaluation) (queryRows, error) {
+	nameVersion := NameVersion{Name: expr.name, Version: expr.version}
+	if expr.version == "" {
+		id, ok := e.pg.latestVersion(e.pg.packageKey("", expr.name))
+		if !ok {
+			return queryRows{}, e.notFound(expr.offset, "package %s is not part of the graph", expr.name)
+		}
+		info := e.pg.node(id)
+		nameVersion = info.NameVersion()
+	}
+	var nodes []NodeInfo
+	var ok bool
+	if expr.dependents {
+		nodes, ok = e.pg.Dependents(nameVersion, expr.depth)
+	} else {
+		nodes, ok = e.pg.Dependencies(nameVersion, expr.depth)
+	}
+	if !ok {
+		return queryRows{}, e.notFound(expr.offset, "%s is not part of the graph", nameVersion)
+	}
+	return nodeRows(nodes), nil
+}
+
+type pathExpr struct {
+	from, to NameVersion
+	offsets  [2]int
+}
+
+func (expr pathExpr) evaluate(e *queryEvaluation) (queryRows, error) {
+	for i, end := range []NameVersion{expr.from, expr.to} {
+		if _, ok := e.pg.FindNode(end); !ok {
+			return queryRows{}, e.notFound(expr.offsets[i], "%s is not part of the graph", end)
+		}
+	}
+	// Versions without a path between them make an empty result rather than an error, like an empty intersection.
+	path, _ := e.pg.ShortestPath(expr.from, expr.to)
+	return nodeRows(path), nil
+}
+
+type searchExpr struct {
+	pattern    string
+	mode       MatchMode
+	ignoreCase bool
+}
+
+func (expr searchExpr) evaluate(e *queryEvaluation) (queryRows, error) {
+	ids, err := e.pg.SearchNodes(expr.pattern, expr.mode, expr.ignoreCase)
+	return queryRows{ids: ids}, err
+}
+
+type allExpr struct{}
+
+func (allExpr) evaluate(e *queryEvaluation) (queryRows, error) {
+	return queryRows{ids: e.pg.nodeIDs()}, nil
+}
+
+type setExpr struct {
+	op          string
+	left, right queryExpr
+}
+
+func (expr setExpr) evaluate(e *queryEvaluation) (queryRows, error) {
+	left, err := expr.left.evaluate(e)
+	if err != nil {
+		return queryRows{}, err
+	}
+	right, err := expr.right.evaluate(e)
+	if err != nil {
+		return queryRows{}, err
+	}
+	a, b := e.pg.nodeSet(left.ids), e.pg.nodeSet(right.ids)
+	var result NodeSet
+	switch expr.op {
+	case "+":
+		result = a.Union(b)
+	case "&":
+		result = a.Intersect(b)
+	default:
+		result = a.Difference(b)
+	}
+	ids := result.IDs()
+	e.pg.sortNodeIDs(ids, SortByName)
+	return queryRows{ids: ids}, nil
+}
+
+type pipelineExpr struct {
+	source queryExpr
+	stages []queryStage
+}
+
+func (expr pipelineExpr) evaluate(e *queryEvaluation) (queryRows, error) {
+	rows, err := expr.source.evaluate(e)
+	for _, stage := range expr.stages {
+		if err != nil {
+			break
+		}
+		rows, err = stage.apply(e, rows)
+	}
+	return rows, err
+}
+
+type queryStage interface {
+	apply(e *queryEvaluation, rows queryRows) (queryRows, error)
+}
+
+type filterStage struct {
+	condition queryCondition
+}
+
+func (stage filterStage) apply(e *queryEvaluation, rows queryRows) (queryRows, error) {
+	test, err := stage.condition.bind(e)
+	if err != nil {
+		return queryRows{}, err
+	}
+	kept := make([]int64, 0, len(rows.ids))
+	for _, id := range rows.ids {
+		if test(e.pg.node(id)) {
+			kept = append(kept, id)
+		}
+	}
+	rows.ids = kept
+	return rows, nil
+}
+
+type topStage struct {
+	metric string
+	n      int
+}
+
+// apply ranks the rows that have a score like TopNodes.
+func (stage topStage) apply(e *queryEvaluation, rows queryRows) (queryRows, error) {
+	scores, err := e.metric(stage.metric)
+	if err != nil {
+		return queryRows{}, err
+	}
+	ranked := make([]int64, 0, len(rows.ids))
+	for _, id := range rows.ids {
+		if _, ok := scores[id]; ok {
+			ranked = append(ranked, id)
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		a, _ := e.pg.Node(ranked[i])
+		b, _ := e.pg.Node(ranked[j])
+		return rankedBefore(scores[ranked[i]], scores[ranked[j]], a, b)
+	})
+	if stage.n >= 0 && stage.n < len(ranked) {
+		ranked = ranked[:stage.n]
+	}
+	values := make(map[int64][]float64, len(ranked))
+	for _, id := range ranked {
+		values[id] = append(append([]float64(nil), rows.values[id]...), scores[id])
+	}
+	columns := append(append([]string(nil), rows.columns...), stage.metric)
+	return queryRows{ids: ranked, columns: columns, values: values}, nil
+}
+
+// queryCondition is a condition of a filter stage.
+type queryCondition interface {
+	// bind looks up the attributes and metrics of the condition in the graph and returns the test of a node.
+	bind(e *queryEvaluation) (func(node NodeInfo) bool, error)
+}
+
+type andCondition struct {
+	left, right queryCondition
+}
+
+func (condition andCondition) bind(e *queryEvaluation) (func(node NodeInfo) bool, error) {
+	left, right, err := bindBoth(e, condition.left, condition.right)
+	if err != nil {
+		return nil, err
+	}
+	return func(node NodeInfo) bool { return left(node) && right(node) }, nil
+}
+
+type orCondition struct {
+	left, right queryCondition
+}
+
+func (condition orCondition) bind(e *queryEvaluation) (func(node NodeInfo) bool, error) {
+	left, right, err := bindBoth(e, condition.left, condition.right)
+	if err != nil {
+		return nil, err
+	}
+	return func(node NodeInfo) bool { return left(node) || right(node) }, nil
+}
+
+func bindBoth(e *queryEvaluation, a, b queryCondition) (func(NodeInfo) bool, func(NodeInfo) bool, error) {
+	left, err := a.bind(e)
+	if err != nil {
+		return nil, nil, err
+	}
+	right, err := b.bind(e)
+	return left, right, err
+}
+
+type notCondition struct {
+	condition queryCondition
+}
+
+func (condition notCondition) bind(e *queryEvaluation) (func(node NodeInfo) bool, error) {
+	test, err := condition.condition.bind(e)
+	if err != nil {
+		return nil, err
+	}
+	return func(node NodeInfo) bool { return !test(node) }, nil
+}
+
+type comparisonCondition struct {
+	attribute       string
+	attributeOffset int
+	op              string
+	value           queryValue
+	// pattern is the compiled regular expression of ~.
+	pattern *regexp.Regexp
+}
+
+func (condition *comparisonCondition) bind(e *queryEvaluation) (func(node NodeInfo) bool, error) {
+	for _, attribute := range e.pg.Attributes().Nodes() {
+		if attribute.Name == condition.attribute {
+			return condition.bindAttribute(e, attribute)
+		}
+	}
+	if _, ok := registeredMetric(condition.attribute); ok {
+		if err := condition.checkValue(e, numberValue); err != nil {
+			return nil, err
+		}
+		scores, err := e.metric(condition.attribute)
+		if err != nil {
+			return nil, err
+		}
+		return func(node NodeInfo) bool {
+			score, ok := scores[node.id]
+			return ok && condition.holds(compareFloats(score, condition.value.number))
+		}, nil
+	}
+	var names []string
+	for _, attribute := range e.pg.Attributes().Nodes() {
+		names = append(names, attribute.Name)
+	}
+	return nil, e.errorf(condition.attributeOffset, "unknown attribute or metric %q, expected one of %s",
+		condition.attribute, strings.Join(append(names, MetricNames()...), ", "))
+}
+
+func (condition *comparisonCondition) bindAttribute(e *queryEvaluation, attribute NodeAttribute) (func(node NodeInfo) bool, error) {
+	pg := e.pg
+	switch attribute.Type {
+	case IntAttribute, FloatAttribute:
+		if err := condition.checkValue(e, numberValue); err != nil {
+			return nil, err
+		}
+		return func(node NodeInfo) bool {
+			value, ok := attribute.Value(pg, node)
+			number, isNumber := toFloat(value)
+			return ok && isNumber && condition.holds(compareFloats(number, condition.value.number))
+		}, nil
+	case BoolAttribute:
+		if err := condition.checkValue(e, boolValue); err != nil {
+			return nil, err
+		}
+		if condition.op != "=" && condition.op != "!=" {
+			return nil, e.errorf(condition.value.offset, "%s is a bool attribute and only compares with = and !=", attribute.Name)
+		}
+		return func(node NodeInfo) bool {
+			value, ok := attribute.Value(pg, node)
+			boolean, isBool := value.(bool)
+			return ok && isBool && (boolean == condition.value.boolean) == (condition.op == "=")
+		}, nil
+	}
+	if err := condition.checkValue(e, stringValue); err != nil {
+		return nil, err
+	}
+	compare := func(value string) int { return strings.Compare(value, condition.value.text) }
+	switch {
+	case condition.op == "~":
+		compare = nil
+	case attribute.Name == "version":
+		compare = func(value string) int { return compareVersions(value, condition.value.text) }
+	case attribute.Name == "timestamp":
+		at, err := ParseTimestamp(condition.value.text)
+		if err != nil {
+			return nil, e.errorf(condition.value.offset, "invalid timestamp %q, expected a date such as \"2020-01-31\"", condition.value.text)
+		}
+		compare = func(value string) int {
+			released, err := ParseTimestamp(value)
+			if err != nil {
+				// Unparseable timestamps fail every comparison, as is documented for values that are missing.
+				return math.MinInt32
+			}
+			return compareTimes(released, at)
+		}
+	}
+	return func(node NodeInfo) bool {
+		value, ok := attribute.Value(pg, node)
+		text, isString := value.(string)
+		if !ok || !isString {
+			return false
+		}
+		if compare == nil {
+			return condition.pattern.MatchString(text)
+		}
+		order := compare(text)
+		return order != math.MinInt32 && condition.holds(order)
+	}, nil
+}
+
+// checkValue returns an error if the value compared with is not of the kind.
+func (condition *comparisonCondition) checkValue(e *queryEvaluation, kind queryValueKind) error {
+	if condition.op == "~" && kind != stringValue {
+		return e.errorf(condition.attributeOffset, "~ only matches string attributes, but %s compares with %s", condition.attribute, kind)
+	}
+	if condition.value.kind != kind {
+		return e.errorf(condition.value.offset, "%s compares with %s", condition.attribute, kind)
+	}
+	return nil
+}
+
+// holds reports whether the comparison holds for a value that compares to the value of the condition as order does.
+func (condition *comparisonCondition) holds(order int) bool {
+	switch condition.op {
+	case "=":
+		return order == 0
+	case "!=":
+		return order != 0
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	default:
+		return order >= 0
+	}
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
+
+// toFloat converts the value of a numeric attribute to a float64.
+func toFloat(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case int:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	case float64:
+		return value, true
+	}
+	return 0, false
+}
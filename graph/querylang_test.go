@@ -0,0 +1,164 @@
+package graph
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	packagesInfo := testPackages("page")
+	pg := NewPackageGraph(&packagesInfo, false)
+	run := func(t *testing.T, text string) *QueryResult {
+		t.Helper()
+		query, err := ParseQuery(text)
+		if err != nil {
+			t.Fatalf("Expected %s to parse, got %v", text, err)
+		}
+		result, err := query.Evaluate(pg)
+		if err != nil {
+			t.Fatalf("Expected %s to evaluate, got %v", text, err)
+		}
+		return result
+	}
+	sortedNames := func(result *QueryResult) []string {
+		names := pageNames(result.Nodes())
+		sort.Strings(names)
+		return names
+	}
+
+	t.Run("Pipes a source through filters and top", func(t *testing.T) {
+		result := run(t, `dependents(name="Lib", version="1.0.0", transitive=true) | filter(timestamp >= "2021-06-01") | top(indegree, 2)`)
+		if !reflect.DeepEqual(result.Columns, []string{"indegree"}) || !reflect.DeepEqual(pageNames(result.Nodes()), []string{"Cli", "App"}) {
+			t.Fatalf("Expected Cli and App ranked by indegree, got %v of %v", pageNames(result.Nodes()), result.Columns)
+		}
+		if result.Rows[0].Values[0] != 1 || result.Rows[1].Values[0] != 0 {
+			t.Errorf("Expected the indegrees 1 and 0, got %v and %v", result.Rows[0].Values, result.Rows[1].Values)
+		}
+		result = run(t, `dependents("Lib", "1.0.0", true) | top(indegree, 2) | top(outdegree, -1)`)
+		if !reflect.DeepEqual(result.Columns, []string{"indegree", "outdegree"}) || !reflect.DeepEqual(result.Rows[0].Values, []float64{1, 1}) {
+			t.Errorf("Expected a column per top stage, got %+v", result)
+		}
+		if len(result.Rows) != 2 {
+			t.Errorf("Expected a negative n to keep both rows, got %+v", result.Rows)
+		}
+	})
+
+	t.Run("Evaluates the sources", func(t *testing.T) {
+		tests := map[string][]string{
+			`deps(name="Blog", version="1.0.0")`:                 {"Cli", "Web"},
+			`deps(name="Blog", depth=-1)`:                        {"Cli", "Lib", "Web"},
+			`dependents(name="Lib")`:                             {"App", "Cli", "Web"},
+			`path(from="Blog@1.0.0", to="Lib@1.0.0")`:            {"Blog", "Cli", "Lib"},
+			`path(from="Lib@1.0.0", to="Blog@1.0.0")`:            {},
+			`search("*i*")`:                                      {"Cli", "Lib", "Site"},
+			`search(pattern="^b", mode=regexp, ignorecase=true)`: {"Blog"},
+			`all()`: {"App", "Blog", "Cli", "Lib", "Site", "Web"},
+		}
+		for text, expected := range tests {
+			if names := sortedNames(run(t, text)); !reflect.DeepEqual(names, expected) && len(names)+len(expected) > 0 {
+				t.Errorf("Expected %v for %s, got %v", expected, text, names)
+			}
+		}
+		if names := pageNames(run(t, `path(from="Blog@1.0.0", to="Lib@1.0.0")`).Nodes()); !reflect.DeepEqual(names, []string{"Blog", "Cli", "Lib"}) {
+			t.Errorf("Expected the path in order, got %v", names)
+		}
+	})
+
+	t.Run("Filters by attributes and metrics", func(t *testing.T) {
+		// The timestamp of Web cannot be parsed, so it fails every comparison of its timestamp, but passes the negation.
+		tests := map[string][]string{
+			`filter(name = "Lib" or name ~ "^S")`:                             {"Lib", "Site"},
+			`filter(not (timestamp < "2022-01-01") and version = "1.0")`:      {"App", "Cli", "Site", "Web"},
+			`filter(indegree > 1)`:                                            {"Lib", "Web"},
+			`filter(license = "MIT")`:                                         {},
+			`filter(license != "MIT")`:                                        {},
+			`filter(timestamp <= "2021-01-01T00:00:00Z" or outdegree >= 2.5)`: {"Blog", "Lib"},
+		}
+		for text, expected := range tests {
+			if names := sortedNames(run(t, "all() | "+text)); !reflect.DeepEqual(names, expected) && len(names)+len(expected) > 0 {
+				t.Errorf("Expected %v for %s, got %v", expected, text, names)
+			}
+		}
+	})
+
+	t.Run("Combines results with set operations", func(t *testing.T) {
+		tests := map[string][]string{
+			`dependents(name="Lib", transitive=true) & deps(name="Blog", transitive=true)`:               {"Cli", "Web"},
+			`dependents(name="Lib", transitive=true) - deps(name="Blog", transitive=true)`:               {"App", "Blog", "Site"},
+			`deps(name="Site") + deps(name="App") + path(from="Blog@1.0.0", to="Cli@1.0.0")`:             {"Blog", "Cli", "Lib", "Web"},
+			`(dependents(name="Lib") - search("Web")) | top(indegree, 1)`:                                {"Cli"},
+			`dependents(name="Lib") | top(indegree, 1) + deps(name="Site")`:                              {"Web"},
+			`dependents(name="Lib") - (dependents(name="Lib") | filter(name = "App")) & search("[AC]*")`: {"Cli"},
+		}
+		for text, expected := range tests {
+			if names := sortedNames(run(t, text)); !reflect.DeepEqual(names, expected) {
+				t.Errorf("Expected %v for %s, got %v", expected, text, names)
+			}
+		}
+		if names := pageNames(run(t, `all() - search("Lib")`).Nodes()); !reflect.DeepEqual(names, []string{"App", "Blog", "Cli", "Site", "Web"}) {
+			t.Errorf("Expected the result sorted by name, got %v", names)
+		}
+	})
+
+	t.Run("Reports errors at their offset", func(t *testing.T) {
+		tests := []struct {
+			text     string
+			offset   int
+			message  string
+			notFound bool
+		}{
+			{`dependents(name="Lib"`, 21, "expected , or ) but found the end of the query", false},
+			{`top(indegree, 1)`, 0, "top is a stage and must follow |", false},
+			{`all() | deps(name="Lib")`, 8, "deps is a source and cannot follow |", false},
+			{`all() | top(fame, 1)`, 12, `unknown metric "fame"`, false},
+			{`all() | top(indegree, 1.5)`, 22, "expected a whole number", false},
+			{`dependents(nme="Lib")`, 11, `dependents has no parameter "nme", expected name, version, transitive, depth`, false},
+			{`dependents(name=1)`, 16, "name of dependents must be a string", false},
+			{`dependents(version="1.0.0")`, 0, "dependents needs name", false},
+			{`all(1)`, 4, "too many arguments for all, which takes no arguments", false},
+			{`deps(name="Lib", depth=2, transitive=true)`, 37, "either transitive=true or a depth", false},
+			{`path(from="Lib", to="Cli@1.0.0")`, 10, "is not of the form name@version", false},
+			{`search(pattern="[a", mode=glob)`, 15, "unterminated character class", false},
+			{`search("a", mode=fuzzy)`, 17, `invalid mode "fuzzy"`, false},
+			{`all() all()`, 6, `expected |, +, & or - but found "all"`, false},
+			{`all() | filter(name = "a" and)`, 29, `expected an attribute or metric but found ")"`, false},
+			{`all() | filter(name == "a")`, 21, `expected a value but found "="`, false},
+			{`all() | filter(name ~ "(")`, 22, "invalid regular expression", false},
+			{`all() | filter(timestamp < 2020)`, 27, "timestamp compares with a string", false},
+			{`all() | filter(timestamp < "soon")`, 27, `invalid timestamp "soon"`, false},
+			{`all() | filter(indegree ~ "1")`, 15, "~ only matches string attributes", false},
+			{`all() | filter(colour = "red")`, 15, `unknown attribute or metric "colour", expected one of name, version`, false},
+			{`search("a") $`, 12, `unexpected character '$'`, false},
+			{`search("a`, 7, "unterminated string", false},
+			{`dependents(name="Lib", version="9.9.9")`, 0, "Lib@9.9.9 is not part of the graph", true},
+			{`deps(name="Missing")`, 0, "package Missing is not part of the graph", true},
+			{`path(from="Lib@1.0.0", to="Lib@2.0.0")`, 26, "Lib@2.0.0 is not part of the graph", true},
+		}
+		for _, test := range tests {
+			query, err := ParseQuery(test.text)
+			if err == nil {
+				_, err = query.Evaluate(pg)
+			}
+			var queryErr *QueryError
+			if !errors.As(err, &queryErr) {
+				t.Errorf("Expected a QueryError for %s, got %v", test.text, err)
+				continue
+			}
+			if queryErr.Offset != test.offset || !strings.Contains(queryErr.Message, test.message) || queryErr.NotFound != test.notFound {
+				t.Errorf("Expected %q at offset %d for %s, got %q at offset %d (not found: %v)", test.message, test.offset,
+					test.text, queryErr.Message, queryErr.Offset, queryErr.NotFound)
+			}
+		}
+	})
+
+	t.Run("Points at the error", func(t *testing.T) {
+		_, err := ParseQuery(`search("é") | top(fame, 1)`)
+		var queryErr *QueryError
+		if !errors.As(err, &queryErr) || queryErr.Context() != "search(\"é\") | top(fame, 1)\n                  ^" {
+			t.Errorf("Expected a caret under fame, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,214 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/semver"
+)
+
+// Range is a parsed dependency range, which can be matched against many versions without being parsed again.
+type Range interface {
+	// Matches reports whether the version satisfies the range.
+	Matches(version string) bool
+}
+
+// RangeMatcher parses the dependency ranges of an ecosystem. Its ranges must be safe to use from several goroutines at
+// once, as the edges can be created in parallel.
+type RangeMatcher interface {
+	// ParseRange parses the range. Ranges that are valid in the ecosystem but use syntax the matcher does not support,
+	// such as a URL instead of a version range, return an error wrapping ErrUnsupportedSyntax.
+	ParseRange(dependencyRange string) (Range, error)
+}
+
+// ErrUnsupportedSyntax is wrapped by the errors of ranges that a RangeMatcher does not support, as opposed to ranges
+// that are malformed.
+var ErrUnsupportedSyntax = errors.New("unsupported range syntax")
+
+// RangeError is the error returned by the range matchers of this package for a range that cannot be parsed.
+type RangeError struct {
+	Range string
+	Err   error
+}
+
+func (err *RangeError) Error() string {
+	return fmt.Sprintf("range %q: %v", err.Range, err.Err)
+}
+
+func (err *RangeError) Unwrap() error {
+	return err.Err
+}
+
+// versionRange is implemented by ranges that can match a version that has already been parsed as semver, which saves
+// parsing the versions of a package once for every range.
+type versionRange interface {
+	Range
+	matchesVersion(version *semver.Version) bool
+	// candidates returns the versions that the range can match, given versions sorted by sortIndexedVersions.
+	candidates(versions []indexedVersion) []indexedVersion
+}
+
+// SemverMatcher matches npm-style semver ranges, or Maven ranges translated to them. It is the matcher used unless
+// another one is set with WithRangeMatcher.
+type SemverMatcher struct {
+	// Maven translates the Maven range syntax, such as "[1.0,2.0)", before parsing.
+	Maven bool
+	// TruncateFourPartVersions matches four-part versions by their first three parts, see NormalizeVersion.
+	TruncateFourPartVersions bool
+	// Prereleases determines whether prerelease versions can satisfy the ranges.
+	Prereleases PrereleasePolicy
+}
+
+// ParseRange parses the range as a semver constraint. Ranges that refer to the package by a URL, a path or an alias,
+// which contain a ":" or a "/", are unsupported.
+func (matcher SemverMatcher) ParseRange(dependencyRange string) (Range, error) {
+	if strings.ContainsAny(dependencyRange, ":/") {
+		return nil, &RangeError{dependencyRange, ErrUnsupportedSyntax}
+	}
+	translated := dependencyRange
+	if matcher.Maven {
+		translated = parseMultipleMavenSemVers(translated, mavenRangeRegex)
+	}
+	normalized := normalizeRange(translated, matcher.TruncateFourPartVersions)
+	constraint, err := semver.NewConstraint(normalized)
+	if err != nil {
+		return nil, &RangeError{dependencyRange, err}
+	}
+	return semverRange{constraint, matcher, rangeWindow(normalized)}, nil
+}
+
+type semverRange struct {
+	constraint *semver.Constraints
+	matcher    SemverMatcher
+	// window, if not nil, contains all the versions the range can match.
+	window *versionWindow
+}
+
+func (r semverRange) Matches(version string) bool {
+	parsed, err := parseVersion(version, r.matcher.TruncateFourPartVersions)
+	return err == nil && r.matchesVersion(parsed)
+}
+
+// candidates returns the versions within the window of the range, which are found by binary search, or all of them
+// if the range has no window.
+func (r semverRange) candidates(versions []indexedVersion) []indexedVersion {
+	if r.window == nil {
+		return versions
+	}
+	return r.window.within(versions)
+}
+
+// matchesVersion reports whether the version satisfies the constraint under the prerelease policy.
+func (r semverRange) matchesVersion(version *semver.Version) bool {
+	if version.Prerelease() == "" {
+		return r.constraint.Check(version)
+	}
+	switch r.matcher.Prereleases {
+	case ExcludePrereleases:
+		return false
+	case AlwaysIncludePrereleases:
+		if r.constraint.Check(version) {
+			return true
+		}
+		// The prerelease lies between the release before its release version and the release version itself, so
+		// it is within the range if both are. This excludes the prereleases of an inclusive lower bound.
+		release := releaseVersion(version)
+		if !r.constraint.Check(release) {
+			return false
+		}
+		previous, ok := previousRelease(release)
+		return ok && r.constraint.Check(previous)
+	}
+	return r.constraint.Check(version)
+}
+
+// releaseVersion returns the version without its prerelease and build metadata.
+func releaseVersion(version *semver.Version) *semver.Version {
+	return newRelease(version.Major(), version.Minor(), version.Patch())
+}
+
+// unboundedPart stands in for an arbitrarily high version part when computing the release before a version.
+const unboundedPart = math.MaxInt32
+
+// previousRelease returns the highest release version that is lower than the given release version, with
+// unboundedPart for the parts that have no upper limit. There is none before 0.0.0.
+func previousRelease(release *semver.Version) (*semver.Version, bool) {
+	major, minor, patch := release.Major(), release.Minor(), release.Patch()
+	switch {
+	case patch > 0:
+		patch--
+	case minor > 0:
+		minor, patch = minor-1, unboundedPart
+	case major > 0:
+		major, minor, patch = major-1, unboundedPart, unboundedPart
+	default:
+		return nil, false
+	}
+	return newRelease(major, minor, patch), true
+}
+
+func newRelease(major, minor, patch int64) *semver.Version {
+	return semver.MustParse(fmt.Sprintf("%d.%d.%d", major, minor, patch))
+}
+
+// ExactMatcher matches ranges that are a single exact version, as used by ecosystems that pin their dependencies such
+// as Go modules. The range and the versions are compared after normalization, so "v1.2" matches "1.2.0"; versions
+// that cannot be normalized only match the identical range. Ranges with operators are unsupported.
+type ExactMatcher struct{}
+
+// ParseRange parses the range as an exact version.
+func (ExactMatcher) ParseRange(dependencyRange string) (Range, error) {
+	trimmed := strings.TrimSpace(dependencyRange)
+	if trimmed == "" {
+		return nil, &RangeError{dependencyRange, errors.New("empty range")}
+	}
+	if strings.ContainsAny(trimmed, "<>^~*|, ") {
+		return nil, &RangeError{dependencyRange, ErrUnsupportedSyntax}
+	}
+	return exactRange(normalizeOrKeep(trimmed)), nil
+}
+
+type exactRange string
+
+func (r exactRange) Matches(version string) bool {
+	return normalizeOrKeep(version) == string(r)
+}
+
+func normalizeOrKeep(version string) string {
+	if normalized, err := NormalizeVersion(version, false); err == nil {
+		return normalized
+	}
+	return version
+}
+
+// cachedMatcher remembers the ranges it has parsed, as many dependents declare the same ranges.
+type cachedMatcher struct {
+	matcher RangeMatcher
+	ranges  sync.Map
+}
+
+type cachedRange struct {
+	parsed Range
+	err    error
+}
+
+// NewCachedMatcher returns a matcher that parses every distinct range only once with the given matcher and returns
+// the same result for it afterwards. It is safe to use from several goroutines at once.
+func NewCachedMatcher(matcher RangeMatcher) RangeMatcher {
+	if _, ok := matcher.(*cachedMatcher); ok {
+		return matcher
+	}
+	return &cachedMatcher{matcher: matcher}
+}
+
+func (matcher *cachedMatcher) ParseRange(dependencyRange string) (Range, error) {
+	if cached, ok := matcher.ranges.Load(dependencyRange); ok {
+		return cached.(cachedRange).parsed, cached.(cachedRange).err
+	}
+	parsed, err := matcher.matcher.ParseRange(dependencyRange)
+	matcher.ranges.Store(dependencyRange, cachedRange{parsed, err})
+	return parsed, err
+}
@@ -0,0 +1,107 @@
+package graph
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSemverMatcher(t *testing.T) {
+	t.Run("Matches npm ranges", func(t *testing.T) {
+		parsed, err := SemverMatcher{}.ParseRange("^1.2.0")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		for version, expected := range map[string]bool{"1.2.0": true, "v1.3": true, "2.0.0": false, "latest": false} {
+			if matches := parsed.Matches(version); matches != expected {
+				t.Errorf("Expected %s to match %t, got %t", version, expected, matches)
+			}
+		}
+	})
+
+	t.Run("Rejects malformed ranges", func(t *testing.T) {
+		_, err := SemverMatcher{}.ParseRange("not a range")
+		var rangeError *RangeError
+		if !errors.As(err, &rangeError) || rangeError.Range != "not a range" || errors.Is(err, ErrUnsupportedSyntax) {
+			t.Errorf("Expected a RangeError that is not ErrUnsupportedSyntax, got %v", err)
+		}
+	})
+
+	t.Run("Reports URLs as unsupported", func(t *testing.T) {
+		for _, dependencyRange := range []string{"git+https://github.com/user/repo.git", "file:../local", "user/repo"} {
+			if _, err := (SemverMatcher{}).ParseRange(dependencyRange); !errors.Is(err, ErrUnsupportedSyntax) {
+				t.Errorf("Expected ErrUnsupportedSyntax for %s, got %v", dependencyRange, err)
+			}
+		}
+	})
+}
+
+func TestExactMatcher(t *testing.T) {
+	t.Run("Matches the normalized version", func(t *testing.T) {
+		parsed, err := ExactMatcher{}.ParseRange("v1.2")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		for version, expected := range map[string]bool{"1.2.0": true, "v1.2.0": true, "1.2.1": false} {
+			if matches := parsed.Matches(version); matches != expected {
+				t.Errorf("Expected %s to match %t, got %t", version, expected, matches)
+			}
+		}
+	})
+
+	t.Run("Reports operators as unsupported", func(t *testing.T) {
+		for _, dependencyRange := range []string{"^1.0.0", ">=1.0.0 <2.0.0", "1.x || 2.x"} {
+			if _, err := (ExactMatcher{}).ParseRange(dependencyRange); !errors.Is(err, ErrUnsupportedSyntax) {
+				t.Errorf("Expected ErrUnsupportedSyntax for %s, got %v", dependencyRange, err)
+			}
+		}
+	})
+
+	t.Run("Creates edges and reports unsupported ranges", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		packagesInfo[0].Versions["1.0.0"].Dependencies["A"] = "1.1.0"
+		packagesInfo[0].Versions["1.0.0"].Dependencies["Test"] = "^1.0.0"
+		report := &ResolutionReport{}
+		pg := NewPackageGraph(&packagesInfo, false, WithRangeMatcher(ExactMatcher{}), WithResolutionReport(report))
+		app, _ := pg.FindNode(NameVersion{Name: "App", Version: "1.0.0"})
+		a, _ := pg.FindNode(NameVersion{Name: "A", Version: "1.1.0"})
+		if !pg.Graph.HasEdgeFromTo(app.id, a.id) || pg.Graph.From(app.id).Len() != 1 {
+			t.Errorf("Expected a single edge from App to A 1.1.0, got %v", edgeSet(pg))
+		}
+		if report.Resolved != 1 || report.UnsupportedRange != 1 {
+			t.Errorf("Expected 1 resolved and 1 unsupported range, got %+v", report)
+		}
+	})
+}
+
+// countingMatcher counts how often ParseRange is called.
+type countingMatcher struct {
+	calls int64
+}
+
+func (matcher *countingMatcher) ParseRange(dependencyRange string) (Range, error) {
+	atomic.AddInt64(&matcher.calls, 1)
+	return SemverMatcher{}.ParseRange(dependencyRange)
+}
+
+func TestCachedMatcher(t *testing.T) {
+	t.Run("Parses every range once", func(t *testing.T) {
+		counting := &countingMatcher{}
+		matcher := NewCachedMatcher(counting)
+		for i := 0; i < 3; i++ {
+			matcher.ParseRange("^1.0.0")
+			matcher.ParseRange("not a range")
+		}
+		if counting.calls != 2 {
+			t.Errorf("Expected 2 calls, got %d", counting.calls)
+		}
+	})
+
+	t.Run("Keeps the error", func(t *testing.T) {
+		matcher := NewCachedMatcher(SemverMatcher{})
+		matcher.ParseRange("file:../local")
+		if _, err := matcher.ParseRange("file:../local"); !errors.Is(err, ErrUnsupportedSyntax) {
+			t.Errorf("Expected ErrUnsupportedSyntax, got %v", err)
+		}
+	})
+}
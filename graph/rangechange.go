@@ -0,0 +1,186 @@
+package graph
+
+import (
+	"sort"
+	"strings"
+)
+
+// RangeChangeKind classifies how the range of a dependency changed between two versions of a package.
+type RangeChangeKind int
+
+const (
+	// Tightened ranges accept fewer versions, such as "^1.0.0" becoming the pinned "1.4.2".
+	Tightened RangeChangeKind = iota
+	// Loosened ranges accept more versions, such as the pinned "1.4.2" becoming "^1.4.0".
+	Loosened
+	// SwitchedTarget ranges accept other versions, such as "1.4.2" becoming "1.4.3" or "^1.0.0" becoming "^2.0.0".
+	SwitchedTarget
+)
+
+func (kind RangeChangeKind) String() string {
+	switch kind {
+	case Tightened:
+		return "tightened"
+	case Loosened:
+		return "loosened"
+	}
+	return "switched target"
+}
+
+// RangeChange is a change of the range of a runtime dependency between two consecutive versions of a package.
+type RangeChange struct {
+	Name string
+	// From and To are the versions, in release order.
+	From string
+	To   string
+	// Dependency is the name of the dependency, and OldRange and NewRange its range in From and To.
+	Dependency string
+	OldRange   string
+	NewRange   string
+	Kind       RangeChangeKind
+}
+
+// RangeChangeEvents compares the runtime dependency ranges of every two consecutive versions of every package and
+// reports the ranges that changed, which often shows the reactions to a breaking release of a dependency. The
+// versions are ordered by semver, followed by the versions that cannot be parsed in the order of their timestamps,
+// see compareReleases. A change from a floating range to a pinned version is Tightened and the reverse Loosened; two
+// pinned versions are SwitchedTarget. Two floating ranges are Tightened or Loosened if the versions one can match
+// strictly contain those of the other, and SwitchedTarget otherwise. Dependencies that are added or removed are not
+// reported. The changes are sorted by package name, version order and dependency name.
+func RangeChangeEvents(packages []PackageInfo) []RangeChange {
+	sorted := make([]PackageInfo, len(packages))
+	copy(sorted, packages)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	var changes []RangeChange
+	for _, packageInfo := range sorted {
+		versions := releaseOrder(packageInfo)
+		for i := 1; i < len(versions); i++ {
+			old := packageInfo.Versions[versions[i-1]].Dependencies
+			current := packageInfo.Versions[versions[i]].Dependencies
+			changes = append(changes, rangeChanges(packageInfo.Name, versions[i-1], versions[i], old, current)...)
+		}
+	}
+	return changes
+}
+
+// releaseOrder returns the versions of the package ordered by compareReleases.
+func releaseOrder(packageInfo PackageInfo) []string {
+	versions := make([]string, 0, len(packageInfo.Versions))
+	for version := range packageInfo.Versions {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return compareReleases(versions[i], packageInfo.Versions[versions[i]], versions[j], packageInfo.Versions[versions[j]]) < 0
+	})
+	return versions
+}
+
+// rangeChanges returns the changes of the ranges of the dependencies declared both by the version from and by the
+// version to of the package, sorted by dependency name.
+func rangeChanges(name, from, to string, old, current map[string]string) []RangeChange {
+	var changes []RangeChange
+	for _, dependency := range sortedDependencyNames(current) {
+		oldRange, ok := old[dependency]
+		if !ok || oldRange == current[dependency] {
+			continue
+		}
+		changes = append(changes, RangeChange{
+			Name:       name,
+			From:       from,
+			To:         to,
+			Dependency: dependency,
+			OldRange:   oldRange,
+			NewRange:   current[dependency],
+			Kind:       classifyRangeChange(oldRange, current[dependency]),
+		})
+	}
+	return changes
+}
+
+// compareReleases orders two versions of a package: the versions that can be parsed come first in semver order,
+// followed by the others in the order of their timestamps, of which those without a parseable timestamp come last.
+// Versions that are equal in this order are ordered by their raw strings, so that the order is total.
+func compareReleases(a string, aInfo VersionInfo, b string, bInfo VersionInfo) int {
+	if order := compareReleaseKeys(a, aInfo, b, bInfo); order != 0 {
+		return order
+	}
+	return strings.Compare(a, b)
+}
+
+func compareReleaseKeys(a string, aInfo VersionInfo, b string, bInfo VersionInfo) int {
+	versionA, errA := parseVersion(a, true)
+	versionB, errB := parseVersion(b, true)
+	switch {
+	case errA == nil && errB == nil:
+		return versionA.Compare(versionB)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	releasedA, errA := ParseTimestamp(aInfo.Timestamp)
+	releasedB, errB := ParseTimestamp(bInfo.Timestamp)
+	switch {
+	case errA == nil && errB == nil && releasedA.Before(releasedB):
+		return -1
+	case errA == nil && errB == nil && releasedB.Before(releasedA):
+		return 1
+	case errA == nil && errB == nil:
+		return 0
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return 0
+}
+
+// classifyRangeChange classifies the change of a range from oldRange to newRange.
+func classifyRangeChange(oldRange, newRange string) RangeChangeKind {
+	oldPinned, newPinned := isPinnedRange(oldRange), isPinnedRange(newRange)
+	switch {
+	case !oldPinned && newPinned:
+		return Tightened
+	case oldPinned && !newPinned:
+		return Loosened
+	case oldPinned && newPinned:
+		return SwitchedTarget
+	}
+	oldWindow, newWindow := rangeWindow(oldRange), rangeWindow(newRange)
+	if oldWindow == nil || newWindow == nil {
+		return SwitchedTarget
+	}
+	narrower, wider := oldWindow.contains(newWindow), newWindow.contains(oldWindow)
+	switch {
+	case narrower && !wider:
+		return Tightened
+	case wider && !narrower:
+		return Loosened
+	}
+	return SwitchedTarget
+}
+
+// isPinnedRange reports whether the range is a single exact version, such as "1.4.2" or "=1.4.2".
+func isPinnedRange(dependencyRange string) bool {
+	if strings.Contains(dependencyRange, "||") || strings.Contains(dependencyRange, ",") {
+		return false
+	}
+	match := comparatorRegex.FindStringSubmatch(dependencyRange)
+	return match != nil && (match[1] == "" || match[1] == "=") && match[4] != ""
+}
+
+// contains reports whether every version within other is also within the window.
+func (window *versionWindow) contains(other *versionWindow) bool {
+	if window.lower != nil && (other.lower == nil || other.lower.LessThan(window.lower)) {
+		return false
+	}
+	if window.upper == nil {
+		return true
+	}
+	if other.upper == nil {
+		return false
+	}
+	order := other.upper.Compare(window.upper)
+	return order < 0 || order == 0 && (window.upperInclusive || !other.upperInclusive)
+}
@@ -0,0 +1,68 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRangeChangeEvents(t *testing.T) {
+	version := func(timestamp string, dependencies map[string]string) VersionInfo {
+		return VersionInfo{Timestamp: timestamp, Dependencies: dependencies}
+	}
+	packagesInfo := []PackageInfo{
+		{Name: "App", Versions: map[string]VersionInfo{
+			"2.3.0":  version("2020-01-01T00:00:00", map[string]string{"A": "^1.0.0", "B": "1.0.0", "C": "^1.0.0", "D": "^1.0.0"}),
+			"2.3.1":  version("2020-02-01T00:00:00", map[string]string{"A": "1.4.2", "B": "^1.0.0", "C": "^1.2.0", "D": "^1.0.0"}),
+			"2.10.0": version("2020-03-01T00:00:00", map[string]string{"A": "1.4.3", "C": "^2.0.0", "D": ">=1.0.0"}),
+			"next":   version("2020-04-01T00:00:00", map[string]string{"A": "1.4.3", "C": "^2.0.0", "D": "^1.0.0"}),
+		}},
+		{Name: "Lib", Versions: map[string]VersionInfo{
+			"1.0.0": version("2020-01-01T00:00:00", map[string]string{"A": "1.0.0"}),
+		}},
+	}
+	changes := RangeChangeEvents(packagesInfo)
+
+	expected := []RangeChange{
+		{"App", "2.3.0", "2.3.1", "A", "^1.0.0", "1.4.2", Tightened},
+		{"App", "2.3.0", "2.3.1", "B", "1.0.0", "^1.0.0", Loosened},
+		{"App", "2.3.0", "2.3.1", "C", "^1.0.0", "^1.2.0", Tightened},
+		{"App", "2.3.1", "2.10.0", "A", "1.4.2", "1.4.3", SwitchedTarget},
+		{"App", "2.3.1", "2.10.0", "C", "^1.2.0", "^2.0.0", SwitchedTarget},
+		{"App", "2.3.1", "2.10.0", "D", "^1.0.0", ">=1.0.0", Loosened},
+		{"App", "2.10.0", "next", "D", ">=1.0.0", "^1.0.0", Tightened},
+	}
+
+	t.Run("Reports the changed ranges in version order", func(t *testing.T) {
+		if len(changes) != len(expected) {
+			t.Fatalf("Expected %d changes, got %v", len(expected), changes)
+		}
+		for i, change := range expected {
+			if changes[i] != change {
+				t.Errorf("Expected the change %+v, got %+v", change, changes[i])
+			}
+		}
+	})
+
+	t.Run("Orders mixed releases totally", func(t *testing.T) {
+		// By semver 1.0.0 comes before 2.0.0, but by timestamp 2.0.0 comes before next and next before 1.0.0.
+		mixed := PackageInfo{Name: "Mixed", Versions: map[string]VersionInfo{
+			"1.0.0":  version("2020-03-01T00:00:00", nil),
+			"2.0.0":  version("2020-01-01T00:00:00", nil),
+			"next":   version("2020-02-01T00:00:00", nil),
+			"canary": version("", nil),
+			"beta":   version("", nil),
+		}}
+		expected := []string{"1.0.0", "2.0.0", "next", "beta", "canary"}
+		for i := 0; i < 10; i++ {
+			if order := releaseOrder(mixed); !reflect.DeepEqual(order, expected) {
+				t.Fatalf("Expected the order %v, got %v", expected, order)
+			}
+		}
+	})
+
+	t.Run("Names the kinds of changes", func(t *testing.T) {
+		if SwitchedTarget.String() != "switched target" || Tightened.String() != "tightened" {
+			t.Errorf("Expected the names of the kinds, got %s and %s", SwitchedTarget, Tightened)
+		}
+	})
+}
@@ -0,0 +1,247 @@
+package graph
+
+import (
+	"math/bits"
+
+	"gonum.org/v1/gonum/graph"
+)
+
+// ReachIndex answers reachability queries on a graph that does not change, such as many "who transitively depends on
+// X" queries, without a breadth-first search per query. The graph is condensed into its strongly connected
+// components, and for every component the set of components that reach it is stored as a sharded bitset, in which
+// only the shards that contain any bit take up memory. The index does not follow later changes to the graph. It is
+// safe to query from several goroutines at once.
+type ReachIndex struct {
+	// condensation numbers the components in topological order, so all the components that reach a component have a
+	// lower index.
+	condensation *Condensation
+	// ancestors holds the components that reach each component, or nil for the components left out by the memory
+	// limit, which are computed from their predecessors when queried.
+	ancestors []shardedBitset
+	indexed   []bool
+	shardBits int
+	bytes     int
+	// options are the options that the index was built with, after rounding.
+	options reachOptions
+}
+
+// ReachOption configures BuildReachabilityIndex.
+type ReachOption func(*reachOptions)
+
+type reachOptions struct {
+	shardBits   int
+	memoryLimit int
+}
+
+// defaultShardBits is the default number of components covered by a single shard of a bitset.
+const defaultShardBits = 4096
+
+// WithShardBits sets the number of components covered by one shard of the bitsets, rounded up to a multiple of 64.
+// Smaller shards use less memory for sparse reachability at the cost of more bookkeeping per shard.
+func WithShardBits(shardBits int) ReachOption {
+	return func(options *reachOptions) {
+		options.shardBits = shardBits
+	}
+}
+
+// WithMemoryLimit stops storing bitsets once they take up about the given number of bytes. Queries on the
+// components without a bitset combine the bitsets of their predecessors instead, which is slower but never wrong.
+func WithMemoryLimit(bytes int) ReachOption {
+	return func(options *reachOptions) {
+		options.memoryLimit = bytes
+	}
+}
+
+// newReachOptions applies the options to the defaults and rounds the shard size.
+func newReachOptions(opts []ReachOption) reachOptions {
+	options := reachOptions{shardBits: defaultShardBits}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.shardBits < 64 {
+		options.shardBits = 64
+	}
+	options.shardBits = (options.shardBits + 63) / 64 * 64
+	return options
+}
+
+// BuildReachabilityIndex builds the reachability index of the graph.
+func BuildReachabilityIndex(g graph.Directed, opts ...ReachOption) *ReachIndex {
+	return condense(g).ReachabilityIndex(opts...)
+}
+
+// ReachabilityIndex builds the reachability index of the graph that was condensed.
+func (c *Condensation) ReachabilityIndex(opts ...ReachOption) *ReachIndex {
+	options := newReachOptions(opts)
+
+	index := &ReachIndex{
+		condensation: c,
+		ancestors:    make([]shardedBitset, c.Len()),
+		indexed:      make([]bool, c.Len()),
+		shardBits:    options.shardBits,
+		options:      options,
+	}
+	for component := range index.ancestors {
+		if options.memoryLimit > 0 && index.bytes >= options.memoryLimit {
+			break
+		}
+		index.ancestors[component] = index.computeAncestors(component)
+		index.indexed[component] = true
+		index.bytes += index.ancestors[component].bytes()
+	}
+	return index
+}
+
+// Reachability returns the reachability index of the graph, building it on the first call, for example to answer
+// many transitive dependents queries. Concurrent callers with the same options share the same index. A call with other
+// options than the index was built with builds a new one, which replaces it for later calls, while the callers that
+// hold the old one can keep querying it. Changes to the graph discard the index, so the next call builds a new one.
+func (pg *PackageGraph) Reachability(opts ...ReachOption) *ReachIndex {
+	options := newReachOptions(opts)
+	pg.reachMutex.Lock()
+	defer pg.reachMutex.Unlock()
+	if pg.reach == nil || pg.reach.options != options {
+		pg.reach = BuildReachabilityIndex(pg.DependencyGraph(), opts...)
+	}
+	return pg.reach
+}
+
+// computeAncestors returns the components that reach c, from the bitsets of its predecessors.
+func (index *ReachIndex) computeAncestors(c int) shardedBitset {
+	ancestors := shardedBitset{}
+	var visit func(c int)
+	visited := make(map[int]bool)
+	visit = func(c int) {
+		for _, p := range index.condensation.predecessors[c] {
+			if visited[p] {
+				continue
+			}
+			visited[p] = true
+			ancestors.set(p, index.shardBits)
+			if index.indexed[p] {
+				ancestors.union(index.ancestors[p])
+			} else {
+				visit(p)
+			}
+		}
+	}
+	visit(c)
+	return ancestors
+}
+
+func (index *ReachIndex) ancestorsOf(c int) shardedBitset {
+	if index.indexed[c] {
+		return index.ancestors[c]
+	}
+	return index.computeAncestors(c)
+}
+
+// Reaches reports whether b can be reached from a by following the edges, that is whether a depends on b directly
+// or transitively. Every node reaches itself. It returns false if either node is not part of the index.
+func (index *ReachIndex) Reaches(a, b int64) bool {
+	componentA, okA := index.condensation.Component(a)
+	componentB, okB := index.condensation.Component(b)
+	if !okA || !okB {
+		return false
+	}
+	if componentA == componentB {
+		return true
+	}
+	return componentA < componentB && index.ancestorsOf(componentB).has(componentA, index.shardBits)
+}
+
+// Dependents returns the IDs of the nodes from which the node can be reached, that is its transitive dependents, in
+// increasing order and without the node itself. It returns nil if the node is not part of the index.
+func (index *ReachIndex) Dependents(id int64) []int64 {
+	c, ok := index.condensation.Component(id)
+	if !ok {
+		return nil
+	}
+	components := []int{c}
+	index.ancestorsOf(c).each(index.shardBits, func(ancestor int) {
+		components = append(components, ancestor)
+	})
+	dependents := index.condensation.Expand(components)
+	for i, member := range dependents {
+		if member == id {
+			return append(dependents[:i], dependents[i+1:]...)
+		}
+	}
+	return dependents
+}
+
+// CountDependents returns the number of nodes that Dependents returns for the node, from the sizes of the components
+// that reach it, without listing them.
+func (index *ReachIndex) CountDependents(id int64) int {
+	c, ok := index.condensation.Component(id)
+	if !ok {
+		return 0
+	}
+	count := len(index.condensation.members[c]) - 1
+	index.ancestorsOf(c).each(index.shardBits, func(ancestor int) {
+		count += len(index.condensation.members[ancestor])
+	})
+	return count
+}
+
+// Bytes returns the approximate number of bytes taken up by the bitsets.
+func (index *ReachIndex) Bytes() int {
+	return index.bytes
+}
+
+// shardedBitset is a set of component indices, split into shards of a fixed number of bits that are only allocated
+// when they contain a bit.
+type shardedBitset map[int][]uint64
+
+func (set shardedBitset) set(bit, shardBits int) {
+	shard := set[bit/shardBits]
+	if shard == nil {
+		shard = make([]uint64, shardBits/64)
+		set[bit/shardBits] = shard
+	}
+	offset := bit % shardBits
+	shard[offset/64] |= 1 << (offset % 64)
+}
+
+func (set shardedBitset) has(bit, shardBits int) bool {
+	shard := set[bit/shardBits]
+	if shard == nil {
+		return false
+	}
+	offset := bit % shardBits
+	return shard[offset/64]&(1<<(offset%64)) != 0
+}
+
+func (set shardedBitset) union(other shardedBitset) {
+	for key, otherShard := range other {
+		shard := set[key]
+		if shard == nil {
+			shard = make([]uint64, len(otherShard))
+			set[key] = shard
+		}
+		for i, word := range otherShard {
+			shard[i] |= word
+		}
+	}
+}
+
+// each calls f for every bit in the set.
+func (set shardedBitset) each(shardBits int, f func(bit int)) {
+	for key, shard := range set {
+		for i, word := range shard {
+			for word != 0 {
+				f(key*shardBits + i*64 + bits.TrailingZeros64(word))
+				word &= word - 1
+			}
+		}
+	}
+}
+
+// bytes returns the approximate size of the set, counting the words of the shards and the map entries.
+func (set shardedBitset) bytes() int {
+	size := 0
+	for _, shard := range set {
+		size += len(shard)*8 + 32
+	}
+	return size
+}
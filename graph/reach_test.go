@@ -0,0 +1,111 @@
+package graph
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// createRandomGraph returns a graph whose edges mostly point from higher to lower IDs, with a few edges in the other
+// direction that create cycles.
+func createRandomGraph(random *rand.Rand, nodes, edges int) *simple.DirectedGraph {
+	g := simple.NewDirectedGraph()
+	for i := 0; i < nodes; i++ {
+		g.AddNode(simple.Node(i))
+	}
+	for i := 0; i < edges; i++ {
+		from, to := random.Intn(nodes), random.Intn(nodes)
+		if from < to && random.Intn(10) != 0 {
+			from, to = to, from
+		}
+		if from != to {
+			g.SetEdge(simple.Edge{F: simple.Node(from), T: simple.Node(to)})
+		}
+	}
+	return g
+}
+
+// sortedReachable returns the nodes reachable from id in increasing order, like ReachIndex.Dependents.
+func sortedReachable(g graph.Directed, id int64, forward bool) []int64 {
+	result := reachable(g, id, nil, forward)
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+func TestReachIndex(t *testing.T) {
+	for name, opts := range map[string][]ReachOption{
+		"by default":          nil,
+		"with small shards":   {WithShardBits(64)},
+		"with a memory limit": {WithShardBits(64), WithMemoryLimit(2000)},
+		"without any bitsets": {WithMemoryLimit(1)},
+	} {
+		t.Run("Agrees with a breadth-first search "+name, func(t *testing.T) {
+			random := rand.New(rand.NewSource(1))
+			for i := 0; i < 20; i++ {
+				g := createRandomGraph(random, 150, 300)
+				index := BuildReachabilityIndex(g, opts...)
+				for id := int64(0); id < 150; id++ {
+					if expected, got := sortedReachable(g, id, false), index.Dependents(id); !equalIDs(expected, got) {
+						t.Fatalf("Expected dependents %v of %d, got %v", expected, id, got)
+					}
+					descendants := make(map[int64]bool)
+					for _, descendant := range reachable(g, id, nil, true) {
+						descendants[descendant] = true
+					}
+					for other := int64(0); other < 150; other++ {
+						if expected := other == id || descendants[other]; index.Reaches(id, other) != expected {
+							t.Fatalf("Expected Reaches(%d, %d) to be %t", id, other, expected)
+						}
+					}
+				}
+			}
+		})
+	}
+
+	t.Run("Stops storing bitsets at the memory limit", func(t *testing.T) {
+		g := createRandomGraph(rand.New(rand.NewSource(1)), 500, 2000)
+		unlimited := BuildReachabilityIndex(g, WithShardBits(64))
+		limited := BuildReachabilityIndex(g, WithShardBits(64), WithMemoryLimit(unlimited.Bytes()/4))
+		if limited.Bytes() >= unlimited.Bytes()/2 {
+			t.Errorf("Expected less than %d bytes, got %d", unlimited.Bytes()/2, limited.Bytes())
+		}
+	})
+
+	t.Run("Caches the index of a graph by its options", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		pg := NewPackageGraph(&packagesInfo, false)
+		index := pg.Reachability()
+		if pg.Reachability(WithShardBits(defaultShardBits)) != index {
+			t.Error("Expected the options equal to the defaults to share the index")
+		}
+		small := pg.Reachability(WithShardBits(64))
+		if small == index || small.shardBits != 64 {
+			t.Errorf("Expected an index with shards of 64 bits, got %d", small.shardBits)
+		}
+		if pg.Reachability(WithShardBits(10)) != small {
+			t.Error("Expected the options that round to the same shards to share the index")
+		}
+	})
+
+	t.Run("Does not know nodes outside the graph", func(t *testing.T) {
+		index := BuildReachabilityIndex(simple.NewDirectedGraph())
+		if index.Dependents(1) != nil || index.Reaches(1, 1) {
+			t.Errorf("Expected unknown nodes to have no dependents and not to be reachable")
+		}
+	})
+}
+
+func equalIDs(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,163 @@
+package graph
+
+import (
+	"sort"
+)
+
+// AffectedDependent is a dependency declaration of an existing version on the package of a simulated release.
+type AffectedDependent struct {
+	Dependent NameVersion
+	Class     DependencyClass
+	Range     string
+	// Previous is the version the declaration resolves to under ResolveHighest without the release, or empty if no
+	// version satisfies it.
+	Previous string
+}
+
+// ReleaseImpact is the outcome of SimulateNewVersion.
+type ReleaseImpact struct {
+	NameVersion NameVersion
+	// AlreadyPublished is set if the version is already part of the graph, in which case nothing is simulated.
+	AlreadyPublished bool
+	// Satisfied are the declarations whose range the new version satisfies, which with ResolveAll would all get an
+	// edge to it.
+	Satisfied []AffectedDependent
+	// Resolving are the satisfied declarations that would resolve to the new version under ResolveHighest, because it
+	// is higher than every version they resolve to now.
+	Resolving []AffectedDependent
+	// Dependencies are the versions that the dependencies of the new version resolve to under ResolveHighest, sorted,
+	// and Unresolved the names of the dependencies that no version satisfies.
+	Dependencies []NameVersion
+	Unresolved   []string
+}
+
+// SimulateNewVersion reports how publishing a new version of a package with the given runtime dependencies would
+// change the resolution of the existing versions that depend on the package, without changing the graph. The version
+// is added to a copy-on-write overlay of the version index, in which only the versions of the package are copied and
+// the rest is shared with the graph, and the declarations on the package in the classes that create edges are
+// resolved again against the overlay with ResolveHighest, whatever the resolution of the graph; the new version gets
+// the ID that AddVersion would give it, so equal versions are ordered the same. The name is looked up like in
+// AddVersion, so a qualified name simulates a version of the package of that ecosystem. The declarations are sorted
+// by dependent and then by class.
+//
+// The graph is left unchanged, but like ConstraintsOn, a graph that was loaded, merged or extracted builds its
+// constraint index on the first call, which must then not run concurrently with other calls.
+func SimulateNewVersion(pg *PackageGraph, pkg string, newVersion string, deps map[string]string) ReleaseImpact {
+	ecosystem, name := splitQualifiedName(pg.ecosystems, pkg)
+	packageInfo := pg.normalizePackage(PackageInfo{Name: name, Ecosystem: ecosystem, Versions: map[string]VersionInfo{newVersion: {Dependencies: deps}}})
+	key := packageInfo.key()
+	nameVersion := key.version(newVersion)
+	impact := ReleaseImpact{NameVersion: nameVersion}
+	if _, exists := pg.lookup(nameVersion); exists {
+		impact.AlreadyPublished = true
+		return impact
+	}
+
+	base := pg.queryResolver()
+	options := *pg.options
+	options.Resolution = ResolveHighest
+	base.options = &options
+	info := *newNodeInfoFromVersion(pg.simulatedID(nameVersion), key, newVersion, VersionInfo{})
+	overlay := pg.overlayResolver(base, info)
+
+	for _, constraint := range pg.constraintsOn(key) {
+		if !includesClass(options.DependencyClasses, constraint.Class) {
+			continue
+		}
+		dependent := pg.node(constraint.Dependent)
+		affected := AffectedDependent{Dependent: dependent.NameVersion(), Class: constraint.Class, Range: constraint.Range}
+		if !overlay.satisfies(key, constraint.Range, info) {
+			continue
+		}
+		if previous, outcome := base.resolveRange(key, constraint.Range); outcome == resolved {
+			affected.Previous = pg.node(previous[0]).Version
+		}
+		impact.Satisfied = append(impact.Satisfied, affected)
+		if ids, _ := overlay.resolveRange(key, constraint.Range); len(ids) == 1 && ids[0] == info.id {
+			impact.Resolving = append(impact.Resolving, affected)
+		}
+	}
+	sortAffectedDependents(impact.Satisfied)
+	sortAffectedDependents(impact.Resolving)
+
+	dependencies := packageInfo.Versions[newVersion].Dependencies
+	for _, name := range sortedDependencyNames(dependencies) {
+		dependency := NewPackageKey(key.Ecosystem, name)
+		resolver := base
+		if dependency == key {
+			resolver = overlay
+		}
+		ids, outcome := resolver.resolveRange(dependency, dependencies[name])
+		switch {
+		case outcome != resolved:
+			impact.Unresolved = append(impact.Unresolved, name)
+		case ids[0] != info.id:
+			impact.Dependencies = append(impact.Dependencies, pg.node(ids[0]).NameVersion())
+		}
+	}
+	return impact
+}
+
+// simulatedID returns the ID that AddVersion would give the version, without adding a node.
+func (pg *PackageGraph) simulatedID(nameVersion NameVersion) int64 {
+	if pg.options.IDScheme == HashedIDs {
+		return hashID(nameVersion)
+	}
+	return pg.Graph.NewNode().ID()
+}
+
+// overlayResolver returns a resolver that sees the versions of base and the added version. The index of the overlay
+// only holds the package of the added version, with a copy of its versions, so it must only be used to resolve
+// ranges on that package.
+func (pg *PackageGraph) overlayResolver(base *edgeResolver, info NodeInfo) *edgeResolver {
+	versions := versionIndex{info.key(): append([]indexedVersion(nil), base.versions[info.key()]...)}
+	versions.add(info, base.options.TruncateFourPartVersions)
+	overlay := *base
+	overlay.versions = versions
+	overlay.names = nil
+	overlay.find = func(nameVersion NameVersion) (NodeInfo, bool) {
+		if nameVersion == info.NameVersion() {
+			return info, true
+		}
+		return base.find(nameVersion)
+	}
+	return &overlay
+}
+
+// satisfies reports whether the version of the package satisfies the range, matching it like the versions of the
+// index are matched.
+func (r *edgeResolver) satisfies(key PackageKey, dependencyRange string, info NodeInfo) bool {
+	versions, parsedRange, outcome := r.candidates(key, dependencyRange)
+	if outcome != resolved {
+		return false
+	}
+	for i := range versions {
+		if versions[i].id == info.id {
+			return matchesIndexed(parsedRange, &versions[i])
+		}
+	}
+	return false
+}
+
+// includesClass reports whether the class is one of the classes.
+func includesClass(classes []DependencyClass, class DependencyClass) bool {
+	for _, included := range classes {
+		if included == class {
+			return true
+		}
+	}
+	return false
+}
+
+func sortAffectedDependents(affected []AffectedDependent) {
+	sort.Slice(affected, func(i, j int) bool {
+		a, b := affected[i], affected[j]
+		if a.Dependent.Name != b.Dependent.Name {
+			return a.Dependent.Name < b.Dependent.Name
+		}
+		if a.Dependent.Version != b.Dependent.Version {
+			return compareVersions(a.Dependent.Version, b.Dependent.Version) < 0
+		}
+		return a.Class < b.Class
+	})
+}
@@ -0,0 +1,90 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSimulateNewVersion(t *testing.T) {
+	classes := WithDependencyClasses(Runtime, Peer)
+	deps := map[string]string{"Util": "^1.0.0", "Missing": "1.0.0"}
+
+	t.Run("Reports the dependents that would resolve to the new version", func(t *testing.T) {
+		for _, scheme := range []IDScheme{SequentialIDs, HashedIDs} {
+			packagesInfo := testPackages("release")
+			pg := NewPackageGraph(&packagesInfo, false, classes, WithIDScheme(scheme))
+			impact := SimulateNewVersion(pg, "Lib", "1.2.0", deps)
+			expected := []AffectedDependent{
+				{Dependent: NameVersion{Name: "App", Version: "1.0.0"}, Class: Runtime, Range: "^1.0.0", Previous: "1.1.0"},
+				{Dependent: NameVersion{Name: "Web", Version: "1.0.0"}, Class: Runtime, Range: ">=1.0.0", Previous: "1.1.0"},
+				{Dependent: NameVersion{Name: "Web", Version: "1.0.0"}, Class: Peer, Range: "^1.2.0"},
+			}
+			if !reflect.DeepEqual(impact.Resolving, expected) || !reflect.DeepEqual(impact.Satisfied, expected) {
+				t.Errorf("Expected %v, got %v and %v", expected, impact.Satisfied, impact.Resolving)
+			}
+			if expected := []NameVersion{{Name: "Util", Version: "1.3.0"}}; !reflect.DeepEqual(impact.Dependencies, expected) {
+				t.Errorf("Expected the dependencies %v, got %v", expected, impact.Dependencies)
+			}
+			if expected := []string{"Missing"}; !reflect.DeepEqual(impact.Unresolved, expected) {
+				t.Errorf("Expected the unresolved %v, got %v", expected, impact.Unresolved)
+			}
+		}
+	})
+
+	t.Run("Separates the satisfied from the resolving dependents", func(t *testing.T) {
+		packagesInfo := testPackages("release")
+		pg := NewPackageGraph(&packagesInfo, false)
+		impact := SimulateNewVersion(pg, "Lib", "1.0.5", nil)
+		var satisfied []NameVersion
+		for _, affected := range impact.Satisfied {
+			satisfied = append(satisfied, affected.Dependent)
+		}
+		if expected := []NameVersion{{Name: "App", Version: "1.0.0"}, {Name: "Cli", Version: "1.0.0"}, {Name: "Web", Version: "1.0.0"}}; !reflect.DeepEqual(satisfied, expected) {
+			t.Errorf("Expected %v to be satisfied, got %v", expected, satisfied)
+		}
+		expected := []AffectedDependent{{Dependent: NameVersion{Name: "Cli", Version: "1.0.0"}, Class: Runtime, Range: "~1.0.0", Previous: "1.0.0"}}
+		if !reflect.DeepEqual(impact.Resolving, expected) {
+			t.Errorf("Expected %v, got %v", expected, impact.Resolving)
+		}
+	})
+
+	t.Run("Agrees with adding the version and leaves the graph unchanged", func(t *testing.T) {
+		packagesInfo := testPackages("release")
+		pg := NewPackageGraph(&packagesInfo, false, classes, WithResolution(ResolveHighest))
+		stats, edges := pg.Stats(), edgeSet(pg)
+		impact := SimulateNewVersion(pg, "Lib", "1.2.0", deps)
+		if pg.Stats() != stats || !reflect.DeepEqual(edgeSet(pg), edges) {
+			t.Errorf("Expected the graph to be unchanged, got %+v instead of %+v", pg.Stats(), stats)
+		}
+		if _, ok := pg.FindNode(NameVersion{Name: "Lib", Version: "1.2.0"}); ok || len(pg.NameToVersions[NewPackageKey("", "Lib")]) != 2 {
+			t.Error("Expected the simulated version not to be added")
+		}
+
+		packagesInfo = testPackages("release")
+		added := NewPackageGraph(&packagesInfo, false, classes, WithResolution(ResolveHighest))
+		if err := added.AddVersion("Lib", "1.2.0", VersionInfo{Timestamp: "2022-02-01T00:00:00", Dependencies: deps}); err != nil {
+			t.Fatal(err)
+		}
+		dependents, _ := added.Dependents(NameVersion{Name: "Lib", Version: "1.2.0"}, 1)
+		names := make(map[NameVersion]bool)
+		for _, dependent := range dependents {
+			names[NameVersion{Name: dependent.Name, Version: dependent.Version}] = true
+		}
+		for _, affected := range impact.Resolving {
+			if !names[affected.Dependent] {
+				t.Errorf("Expected %v to depend on the added version, got %v", affected.Dependent, dependents)
+			}
+		}
+		if len(names) != 2 {
+			t.Errorf("Expected 2 dependents of the added version, got %v", dependents)
+		}
+	})
+
+	t.Run("Does not simulate published versions", func(t *testing.T) {
+		packagesInfo := testPackages("release")
+		pg := NewPackageGraph(&packagesInfo, false)
+		if impact := SimulateNewVersion(pg, "Lib", "1.1.0", nil); !impact.AlreadyPublished || impact.Satisfied != nil {
+			t.Errorf("Expected the version to be published already, got %+v", impact)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package graph
+
+import (
+	"os"
+	"time"
+)
+
+// ReloadFrom replaces the contents of the graph with a graph built from the JSON packages at path, for example after a
+// nightly export overwrote the input. The graph is built with the package manager and the options of the old graph,
+// except for the resolution report, which is not filled again, and the tracer of WithTrace, which starts over with the
+// versions that the new input leaves out of the graph. It is built while the old graph keeps answering queries, and
+// then swapped in while holding the write lock, so the readers that hold RLock see either the old or the new graph,
+// never a partially built one. Readers that do not hold RLock may see a mix of both while the swap takes place.
+//
+// If the file cannot be read or parsed, the graph is left unchanged and the error is logged and returned.
+func (pg *PackageGraph) ReloadFrom(path string) error {
+	start := time.Now()
+	pg.swapMutex.RLock()
+	options, isMaven := *pg.options, pg.isMaven
+	pg.swapMutex.RUnlock()
+	options.Report = nil
+	options.Trace = options.Trace.rebuild()
+	next, err := OpenPackageGraph(path, isMaven, withOptions(options))
+	if err != nil {
+		pg.options.log(LevelError, "reload failed", "path", path, "error", err)
+		return err
+	}
+	pg.swapMutex.Lock()
+	pg.swap(next)
+	pg.swapMutex.Unlock()
+	pg.options.log(LevelInfo, "reload", "duration", time.Since(start), "path", path, "nodes", len(next.ids))
+	return nil
+}
+
+// RLock locks the graph for reading, so that ReloadFrom waits with swapping in a new graph until RUnlock is called.
+// The query methods do not lock by themselves, so callers that query a graph that may be reloaded hold the lock
+// around all the queries that have to see the same graph.
+func (pg *PackageGraph) RLock() {
+	pg.swapMutex.RLock()
+}
+
+// RUnlock undoes a single RLock call.
+func (pg *PackageGraph) RUnlock() {
+	pg.swapMutex.RUnlock()
+}
+
+// swap moves the contents of next into pg. The caller holds the write lock, so no query, including one creating lazy
+// edges, runs at the same time.
+func (pg *PackageGraph) swap(next *PackageGraph) {
+	pg.Graph = next.Graph
+	pg.Packages = next.Packages
+	pg.StringIDToNodeInfo = next.StringIDToNodeInfo
+	pg.Nodes = next.Nodes
+	pg.NameToVersions = next.NameToVersions
+	pg.ids = next.ids
+	pg.slots = next.slots
+	pg.collisions = next.collisions
+	pg.packageIndex = next.packageIndex
+	pg.options = next.options
+	pg.constraints = next.constraints
+	pg.versions = next.versions
+	pg.latest = next.latest
+	pg.names = next.names
+	pg.matcher = next.matcher
+	pg.unresolved = next.unresolved
+	pg.crossEdges = next.crossEdges
+	pg.weights = next.weights
+	if pg.edgeFile != nil && pg.edgeFile != next.edgeFile {
+		// No reader of the old graph is left to read its edge file.
+		if err := pg.edgeFile.Remove(); err != nil {
+			next.options.log(LevelWarn, "removing the replaced edge file failed", "path", pg.edgeFile.path, "error", err)
+		}
+	}
+	pg.edgeFile = next.edgeFile
+	pg.edgeFileErr = next.edgeFileErr
+	pg.externalLoaded = next.externalLoaded
+	pg.classGraphs = next.classGraphs
+	pg.split = next.split
+	pg.metadata = next.metadata
+	pg.center = nil
+	pg.reachMutex.Lock()
+	pg.reach = nil
+	pg.reachMutex.Unlock()
+}
+
+// withOptions sets all the options at once, to build a graph with the options of another one.
+func withOptions(options Options) Option {
+	return func(target *Options) {
+		*target = options
+	}
+}
+
+// WatchFile reloads the graph with ReloadFrom whenever the modification time or the size of the file at path change,
+// checking every interval. Reloads run one at a time in the background, and their failures are logged like those of
+// ReloadFrom, so a file that is still being written is reloaded again once it changes the next time. Calling the
+// returned function stops watching; a reload that is already running completes.
+//
+// The file is polled with os.Stat rather than watched through the notifications of the operating system, which would
+// need a dependency such as fsnotify for every platform. A change is therefore only picked up at the next check, so
+// a reload starts up to interval after the file changed.
+func (pg *PackageGraph) WatchFile(path string, interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	var last os.FileInfo
+	if info, err := os.Stat(path); err == nil {
+		last = info
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			if last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+				continue
+			}
+			last = info
+			pg.ReloadFrom(path)
+		}
+	}()
+	return func() {
+		close(done)
+	}
+}
@@ -0,0 +1,131 @@
+package graph
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writePackagesFile writes the packages as a JSON array to path.
+func writePackagesFile(t *testing.T, path string, packagesInfo []PackageInfo) {
+	encoded, err := json.Marshal(packagesInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, encoded, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadFrom(t *testing.T) {
+	added := PackageInfo{Name: "New", Versions: map[string]VersionInfo{
+		"1.0.0": {Timestamp: "2022-01-01T00:00:00", Dependencies: map[string]string{"A": "1.0.0"}},
+	}}
+
+	t.Run("Swaps in the new graph with the same options", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "packages.json")
+		writePackagesFile(t, path, testPackages("options"))
+		logger := &recordingLogger{}
+		pg, err := OpenPackageGraph(path, false, WithResolution(ResolveHighest), WithLogger(logger))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pg.Reachability()
+		writePackagesFile(t, path, append(testPackages("options"), added))
+		if err := pg.ReloadFrom(path); err != nil {
+			t.Fatal(err)
+		}
+		dependencies, ok := pg.Dependencies(NameVersion{Name: "New", Version: "1.0.0"}, 1)
+		if !ok || len(dependencies) != 1 {
+			t.Errorf("Expected the new version with 1 dependency, got %v", dependencies)
+		}
+		if pg.options.Resolution != ResolveHighest {
+			t.Errorf("Expected the options to be kept, got %+v", pg.options)
+		}
+		if dependents := pg.Reachability().Dependents(dependencies[0].id); len(dependents) == 0 {
+			t.Error("Expected the reachability index to be rebuilt")
+		}
+		if _, ok := logger.find("reload"); !ok {
+			t.Errorf("Expected a reload event, got %+v", logger.entries)
+		}
+	})
+
+	t.Run("Keeps the old graph when the reload fails", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "packages.json")
+		writePackagesFile(t, path, testPackages("options"))
+		logger := &recordingLogger{}
+		pg, err := OpenPackageGraph(path, false, WithLogger(logger))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := pg.Stats()
+		if err := os.WriteFile(path, []byte(`[{"name": 1}]`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := pg.ReloadFrom(path); err == nil {
+			t.Error("Expected an error for invalid packages")
+		}
+		if stats := pg.Stats(); stats != expected {
+			t.Errorf("Expected the stats %+v, got %+v", expected, stats)
+		}
+		if entry, ok := logger.find("reload failed"); !ok || entry.level != LevelError {
+			t.Errorf("Expected an error event, got %+v", logger.entries)
+		}
+	})
+
+	t.Run("Serves consistent graphs to readers holding the lock", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "packages.json")
+		writePackagesFile(t, path, testPackages("options"))
+		pg, err := OpenPackageGraph(path, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		old, next := pg.Stats().Nodes, pg.Stats().Nodes+1
+		writePackagesFile(t, path, append(testPackages("options"), added))
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					pg.RLock()
+					_, found := pg.FindNode(NameVersion{Name: "New", Version: "1.0.0"})
+					nodes := pg.Stats().Nodes
+					pg.RUnlock()
+					if found != (nodes == next) || nodes != old && nodes != next {
+						t.Errorf("Expected %d or %d nodes matching the lookup, got %d", old, next, nodes)
+						return
+					}
+				}
+			}()
+		}
+		if err := pg.ReloadFrom(path); err != nil {
+			t.Error(err)
+		}
+		wg.Wait()
+	})
+
+	t.Run("Reloads when the watched file changes", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "packages.json")
+		writePackagesFile(t, path, testPackages("options"))
+		pg, err := OpenPackageGraph(path, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stop := pg.WatchFile(path, 5*time.Millisecond)
+		defer stop()
+		writePackagesFile(t, path, append(testPackages("options"), added))
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+			pg.RLock()
+			_, found := pg.FindNode(NameVersion{Name: "New", Version: "1.0.0"})
+			pg.RUnlock()
+			if found {
+				return
+			}
+		}
+		t.Error("Expected the changed file to be reloaded")
+	})
+}
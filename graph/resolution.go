@@ -0,0 +1,153 @@
+package graph
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// resolutionOutcome is the result of resolving a single dependency declaration.
+type resolutionOutcome int
+
+const (
+	resolved resolutionOutcome = iota
+	unsatisfied
+	unknownPackage
+	unparseableRange
+	unsupportedRange
+)
+
+func (outcome resolutionOutcome) String() string {
+	switch outcome {
+	case resolved:
+		return "resolved"
+	case unsatisfied:
+		return "no satisfying version"
+	case unknownPackage:
+		return "unknown package"
+	case unparseableRange:
+		return "unparseable range"
+	case unsupportedRange:
+		return "unsupported range"
+	}
+	return "unknown"
+}
+
+// ResolutionReport counts how the dependency declarations were resolved while creating edges. It is filled by the
+// same code that creates the edges, see WithResolutionReport.
+type ResolutionReport struct {
+	// Declarations is the number of dependency declarations considered, in the dependency classes that create edges.
+	Declarations int `json:"declarations"`
+	// Resolved is the number of declarations that resolved to at least one version.
+	Resolved int `json:"resolved"`
+	// Unsatisfied is the number of declarations on a known package that no version satisfies.
+	Unsatisfied int `json:"unsatisfied"`
+	// UnknownPackage is the number of declarations on a package that is not part of the graph.
+	UnknownPackage int `json:"unknownPackage"`
+	// UnparseableRange is the number of declarations on a known package whose range cannot be parsed.
+	UnparseableRange int `json:"unparseableRange"`
+	// UnsupportedRange is the number of declarations on a known package whose range uses syntax that the range
+	// matcher does not support, such as a URL.
+	UnsupportedRange int `json:"unsupportedRange"`
+	// SelfEdges is the number of edges that were skipped because a version satisfies its own dependency range.
+	SelfEdges int `json:"selfEdges"`
+	// UnparseableVersions is the number of versions that cannot be parsed even after normalization, see
+	// NormalizeVersion. No dependency range can resolve to them.
+	UnparseableVersions int `json:"unparseableVersions"`
+	// Duplicates counts the package entries of the input that were merged with MergeDuplicatePackages.
+	Duplicates DuplicateStats `json:"duplicates"`
+
+	// unresolved counts the declarations that did not resolve, per dependency name.
+	unresolved map[string]int
+}
+
+// UnresolvedDependency is a dependency name together with the number of declarations on it that did not resolve.
+type UnresolvedDependency struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func (report *ResolutionReport) record(dependencyName string, outcome resolutionOutcome) {
+	if report == nil {
+		return
+	}
+	report.Declarations++
+	switch outcome {
+	case resolved:
+		report.Resolved++
+		return
+	case unsatisfied:
+		report.Unsatisfied++
+	case unknownPackage:
+		report.UnknownPackage++
+	case unparseableRange:
+		report.UnparseableRange++
+	case unsupportedRange:
+		report.UnsupportedRange++
+	}
+	if report.unresolved == nil {
+		report.unresolved = make(map[string]int)
+	}
+	report.unresolved[dependencyName]++
+}
+
+func (report *ResolutionReport) recordSelfEdge() {
+	if report != nil {
+		report.SelfEdges++
+	}
+}
+
+func (report *ResolutionReport) recordUnparseableVersion() {
+	if report != nil {
+		report.UnparseableVersions++
+	}
+}
+
+// add adds the counts of other, which was filled by another worker, to the report.
+func (report *ResolutionReport) add(other *ResolutionReport) {
+	report.Declarations += other.Declarations
+	report.Resolved += other.Resolved
+	report.Unsatisfied += other.Unsatisfied
+	report.UnknownPackage += other.UnknownPackage
+	report.UnparseableRange += other.UnparseableRange
+	report.UnsupportedRange += other.UnsupportedRange
+	report.SelfEdges += other.SelfEdges
+	report.UnparseableVersions += other.UnparseableVersions
+	report.Duplicates.MergedPackages += other.Duplicates.MergedPackages
+	report.Duplicates.ConflictingVersions += other.Duplicates.ConflictingVersions
+	for name, count := range other.unresolved {
+		if report.unresolved == nil {
+			report.unresolved = make(map[string]int)
+		}
+		report.unresolved[name] += count
+	}
+}
+
+// TopUnresolved returns the n dependency names with the most declarations that did not resolve, most first and ties
+// by name. A negative n returns all of them.
+func (report *ResolutionReport) TopUnresolved(n int) []UnresolvedDependency {
+	top := make([]UnresolvedDependency, 0, len(report.unresolved))
+	for name, count := range report.unresolved {
+		top = append(top, UnresolvedDependency{Name: name, Count: count})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Name < top[j].Name
+	})
+	if n >= 0 && n < len(top) {
+		top = top[:n]
+	}
+	return top
+}
+
+// WriteJSON writes the counters of the report together with the top n unresolved dependency names as JSON.
+func (report *ResolutionReport) WriteJSON(w io.Writer, n int) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		*ResolutionReport
+		TopUnresolved []UnresolvedDependency `json:"topUnresolved"`
+	}{report, report.TopUnresolved(n)})
+}
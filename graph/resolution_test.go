@@ -0,0 +1,44 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestResolutionReport(t *testing.T) {
+	for name, workers := range map[string]int{"sequentially": 1, "in parallel": 4} {
+		t.Run("Counts every outcome "+name, func(t *testing.T) {
+			packagesInfo := testPackages("resolution")
+			report := &ResolutionReport{}
+			NewPackageGraph(&packagesInfo, false, WithWorkers(workers), WithResolutionReport(report))
+			if report.Declarations != 5 || report.Resolved != 1 || report.Unsatisfied != 2 || report.UnknownPackage != 1 || report.UnparseableRange != 1 {
+				t.Errorf("Expected 5 declarations, 1 resolved, 2 unsatisfied, 1 unknown and 1 unparseable, got %+v", report)
+			}
+			top := report.TopUnresolved(2)
+			if len(top) != 2 || top[0] != (UnresolvedDependency{"B", 2}) || top[1] != (UnresolvedDependency{"A", 1}) {
+				t.Errorf("Expected B and A as the top unresolved dependencies, got %v", top)
+			}
+		})
+	}
+
+	t.Run("Writes the report as JSON", func(t *testing.T) {
+		packagesInfo := testPackages("resolution")
+		report := &ResolutionReport{}
+		NewPackageGraph(&packagesInfo, false, WithResolutionReport(report))
+		var buffer bytes.Buffer
+		if err := report.WriteJSON(&buffer, 1); err != nil {
+			t.Fatal(err)
+		}
+		var decoded struct {
+			Declarations  int                    `json:"declarations"`
+			TopUnresolved []UnresolvedDependency `json:"topUnresolved"`
+		}
+		if err := json.Unmarshal(buffer.Bytes(), &decoded); err != nil {
+			t.Fatalf("Expected valid JSON, got %v", err)
+		}
+		if decoded.Declarations != 5 || len(decoded.TopUnresolved) != 1 || decoded.TopUnresolved[0].Name != "B" {
+			t.Errorf("Expected 5 declarations and B as the top unresolved dependency, got %s", buffer.String())
+		}
+	})
+}
@@ -0,0 +1,306 @@
+package graph
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/Masterminds/semver"
+)
+
+// indexedVersion is a version of a package together with the ID of its node. The version is parsed as semver once, so
+// that the ranges of all dependents can be matched against it; parsed is nil if that fails.
+type indexedVersion struct {
+	version string
+	parsed  *semver.Version
+	id      int64
+	// deprecated is set for the deprecated versions, which ResolveHighest can be told to avoid.
+	deprecated bool
+}
+
+// versionIndex maps the package keys to their versions, sorted by sortIndexedVersions so that the versions within a
+// range can be found by binary search.
+type versionIndex map[PackageKey][]indexedVersion
+
+// newVersionIndex indexes the versions of every package that have a node. The versions that cannot be parsed as
+// semver even after normalization are counted in the report, unless it is nil; only range matchers that do not use
+// semver can match them.
+func newVersionIndex(find func(NameVersion) (NodeInfo, bool), nameToVersionMap map[PackageKey][]string, truncateFourPart bool, report *ResolutionReport) versionIndex {
+	index := make(versionIndex, len(nameToVersionMap))
+	for key, versions := range nameToVersionMap {
+		entries := make([]indexedVersion, 0, len(versions))
+		for _, version := range versions {
+			info, ok := find(key.version(version))
+			if !ok {
+				continue
+			}
+			parsed, err := parseVersion(version, truncateFourPart)
+			if err != nil {
+				report.recordUnparseableVersion()
+			}
+			entries = append(entries, indexedVersion{version: version, parsed: parsed, id: info.id, deprecated: info.Deprecated != ""})
+		}
+		sortIndexedVersions(entries)
+		index[key] = entries
+	}
+	return index
+}
+
+// add adds the version to the index, returning false if it cannot be parsed as semver.
+func (index versionIndex) add(info NodeInfo, truncateFourPart bool) bool {
+	parsed, err := parseVersion(info.Version, truncateFourPart)
+	entry := indexedVersion{version: info.Version, parsed: parsed, id: info.id, deprecated: info.Deprecated != ""}
+	entries := index[info.key()]
+	i := sort.Search(len(entries), func(i int) bool { return lessIndexed(&entry, &entries[i]) })
+	entries = append(entries, indexedVersion{})
+	copy(entries[i+1:], entries[i:])
+	entries[i] = entry
+	index[info.key()] = entries
+	return err == nil
+}
+
+// remove removes the version with the given node ID from the index, and the package once it has no versions left.
+func (index versionIndex) remove(key PackageKey, id int64, lastVersion bool) {
+	entries := index[key]
+	for i, entry := range entries {
+		if entry.id == id {
+			entries = append(entries[:i], entries[i+1:]...)
+			break
+		}
+	}
+	if lastVersion {
+		delete(index, key)
+	} else {
+		index[key] = entries
+	}
+}
+
+// edgeResolver matches the dependency ranges of packages against the available versions. It only reads shared state,
+// so it can be used from several goroutines at once.
+type edgeResolver struct {
+	// find looks up the node information of a package version.
+	find     func(NameVersion) (NodeInfo, bool)
+	versions versionIndex
+	// names is the filter of the package names of versions, or nil to look every name up.
+	names   *nameFilter
+	matcher RangeMatcher
+	options *Options
+}
+
+// newEdgeResolver creates a resolver for the given nodes, indexing their versions. Versions that cannot be parsed are
+// counted in the report of the options.
+func newEdgeResolver(find func(NameVersion) (NodeInfo, bool), nameToVersionMap map[PackageKey][]string, isMaven bool, options *Options) *edgeResolver {
+	versions := newVersionIndex(find, nameToVersionMap, options.TruncateFourPartVersions, options.Report)
+	return &edgeResolver{
+		find:     find,
+		versions: versions,
+		names:    newNameFilter(versions),
+		matcher:  options.rangeMatcher(isMaven),
+		options:  options,
+	}
+}
+
+// resolvePackage returns the (from, to) node IDs of the edges for all the versions of the package, see resolveVersion.
+// The outcome of every dependency declaration is recorded in the report, unless it is nil.
+func (r *edgeResolver) resolvePackage(packageInfo *PackageInfo, report *ResolutionReport) [][2]int64 {
+	var edges [][2]int64
+	for packageVersion, versionInfo := range packageInfo.Versions {
+		packageNode, ok := r.find(packageInfo.key().version(packageVersion))
+		if !ok {
+			continue
+		}
+		edges = r.resolveVersion(edges, packageNode, versionInfo, report)
+	}
+	return edges
+}
+
+// resolvePackageByClass returns the edges of all the versions of the package like resolvePackage, but separately for
+// every dependency class of the options, in their order.
+func (r *edgeResolver) resolvePackageByClass(packageInfo *PackageInfo, report *ResolutionReport) [][][2]int64 {
+	edgeSets := make([][][2]int64, len(r.options.DependencyClasses))
+	for packageVersion, versionInfo := range packageInfo.Versions {
+		packageNode, ok := r.find(packageInfo.key().version(packageVersion))
+		if !ok {
+			continue
+		}
+		for i, class := range r.options.DependencyClasses {
+			edgeSets[i] = r.resolveClass(edgeSets[i], packageNode, versionInfo, class, report)
+		}
+	}
+	return edgeSets
+}
+
+// resolveVersion appends the edges of the version of the node to edges. A dependency declared in several
+// classes resolves to the same versions more than once, and its edges are then repeated, once for every declaration,
+// which is what the edge weights count.
+func (r *edgeResolver) resolveVersion(edges [][2]int64, node NodeInfo, versionInfo VersionInfo, report *ResolutionReport) [][2]int64 {
+	for _, class := range r.options.DependencyClasses {
+		edges = r.resolveClass(edges, node, versionInfo, class, report)
+	}
+	return edges
+}
+
+// resolveClass appends the edges of the declarations of the version in a single dependency class to edges, and traces
+// the declarations on the traced dependencies. A declaration resolves against the versions of the package of that name
+// in the ecosystem of the version, or for an aliased name of the name at the end of its aliases, but is reported and
+// traced under the declared name.
+func (r *edgeResolver) resolveClass(edges [][2]int64, node NodeInfo, versionInfo VersionInfo, class DependencyClass, report *ResolutionReport) [][2]int64 {
+	id := node.id
+	for dependencyName, dependencyVersion := range versionInfo.DependenciesOf(class) {
+		target, _ := r.options.Aliases.resolve(dependencyName, versionInfo.Timestamp)
+		dependencyIDs, outcome := r.resolveRange(NewPackageKey(node.Ecosystem, target), dependencyVersion)
+		report.record(dependencyName, outcome)
+		if r.options.Trace.traces(dependencyName) {
+			r.trace(node, class, dependencyName, dependencyVersion, dependencyIDs, outcome)
+		}
+		for _, dependencyID := range dependencyIDs {
+			// Some packages depend on themselves, which simple.DirectedGraph does not allow.
+			if dependencyID == id {
+				report.recordSelfEdge()
+				continue
+			}
+			edges = append(edges, [2]int64{id, dependencyID})
+		}
+	}
+	return edges
+}
+
+// resolveRange returns the node IDs of the versions of the dependency that the range resolves to, and whether it
+// resolved at all.
+func (r *edgeResolver) resolveRange(dependency PackageKey, dependencyVersion string) ([]int64, resolutionOutcome) {
+	versions, parsedRange, outcome := r.candidates(dependency, dependencyVersion)
+	if outcome != resolved {
+		return nil, outcome
+	}
+
+	var matches []int64
+	var highest *indexedVersion
+	for i := range versions {
+		candidate := &versions[i]
+		if !matchesIndexed(parsedRange, candidate) {
+			continue
+		}
+		if r.options.Resolution == ResolveHighest {
+			if highest == nil || r.higherThan(candidate, highest) {
+				highest = candidate
+			}
+			continue
+		}
+		matches = append(matches, candidate.id)
+	}
+	if highest != nil {
+		matches = append(matches, highest.id)
+	}
+	if len(matches) == 0 {
+		return nil, unsatisfied
+	}
+	return matches, resolved
+}
+
+// candidates parses the range and returns it together with the versions of the dependency that may satisfy it, or
+// the reason why the range cannot resolve. The names filtered out by the name filter are unknown without a lookup.
+func (r *edgeResolver) candidates(dependency PackageKey, dependencyVersion string) ([]indexedVersion, Range, resolutionOutcome) {
+	if !r.names.mayContain(dependency) {
+		return nil, nil, unknownPackage
+	}
+	versions, ok := r.versions[dependency]
+	if !ok {
+		return nil, nil, unknownPackage
+	}
+	parsedRange, err := r.matcher.ParseRange(dependencyVersion)
+	if errors.Is(err, ErrUnsupportedSyntax) {
+		return nil, nil, unsupportedRange
+	} else if err != nil {
+		return nil, nil, unparseableRange
+	}
+	if semverRange, ok := parsedRange.(versionRange); ok {
+		versions = semverRange.candidates(versions)
+	}
+	return versions, parsedRange, resolved
+}
+
+// highestAt returns the node ID of the version that ResolveHighest picks for the ranges among the versions released at
+// or before at, as a package manager installing at that time would. A version has to satisfy all the ranges, of which
+// there must be at least one. If at is zero, every version is considered; otherwise the versions without a parseable
+// timestamp are left out.
+func (r *edgeResolver) highestAt(dependency PackageKey, at time.Time, dependencyVersions ...string) (int64, resolutionOutcome) {
+	versions, parsedRange, outcome := r.candidates(dependency, dependencyVersions[0])
+	if outcome != resolved {
+		return -1, outcome
+	}
+	otherRanges := make([]Range, 0, len(dependencyVersions)-1)
+	for _, dependencyVersion := range dependencyVersions[1:] {
+		_, otherRange, outcome := r.candidates(dependency, dependencyVersion)
+		if outcome != resolved {
+			return -1, outcome
+		}
+		otherRanges = append(otherRanges, otherRange)
+	}
+	var highest *indexedVersion
+	for i := range versions {
+		candidate := &versions[i]
+		if !matchesIndexed(parsedRange, candidate) || (highest != nil && !r.higherThan(candidate, highest)) {
+			continue
+		}
+		if !matchesAll(otherRanges, candidate) {
+			continue
+		}
+		if !at.IsZero() {
+			info, _ := r.find(dependency.version(candidate.version))
+			if released, err := ParseTimestamp(info.Timestamp); err != nil || released.After(at) {
+				continue
+			}
+		}
+		highest = candidate
+	}
+	if highest == nil {
+		return -1, unsatisfied
+	}
+	return highest.id, resolved
+}
+
+// higherThan reports whether ResolveHighest prefers candidate over highest. Equal versions such as "1.0" and "1.0.0"
+// resolve to the lower node ID, which does not depend on the order of the index.
+func (r *edgeResolver) higherThan(candidate, highest *indexedVersion) bool {
+	if r.options.PreferNonDeprecated && candidate.deprecated != highest.deprecated {
+		return highest.deprecated
+	}
+	order := compareIndexed(candidate, highest)
+	return order > 0 || (order == 0 && candidate.id < highest.id)
+}
+
+// resolveRangeAgainst reports whether the given version satisfies the range, for adding a single version to a graph
+// that was built with ResolveAll.
+func (r *edgeResolver) resolveRangeAgainst(dependencyVersion string, info NodeInfo) bool {
+	parsedRange, err := r.matcher.ParseRange(dependencyVersion)
+	return err == nil && parsedRange.Matches(info.Version)
+}
+
+// matchesAll reports whether the version satisfies every range.
+func matchesAll(ranges []Range, candidate *indexedVersion) bool {
+	for _, parsedRange := range ranges {
+		if !matchesIndexed(parsedRange, candidate) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesIndexed matches the version against the range, reusing the parsed version for semver ranges.
+func matchesIndexed(parsedRange Range, candidate *indexedVersion) bool {
+	if semverRange, ok := parsedRange.(versionRange); ok {
+		return candidate.parsed != nil && semverRange.matchesVersion(candidate.parsed)
+	}
+	return parsedRange.Matches(candidate.version)
+}
+
+// compareIndexed compares two versions like compareVersions, reusing the parsed versions.
+func compareIndexed(a, b *indexedVersion) int {
+	if b == nil {
+		return 1
+	}
+	if a.parsed != nil && b.parsed != nil {
+		return a.parsed.Compare(b.parsed)
+	}
+	return compareVersions(a.version, b.version)
+}
@@ -0,0 +1,271 @@
+package graph
+
+import (
+	"math/rand"
+	"sort"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// SampleMethod determines how SampleSubgraph picks the package versions of the sample.
+type SampleMethod int
+
+const (
+	// SampleUniform picks the versions uniformly at random. The sample keeps the edges between them, which leaves
+	// most of the sampled versions disconnected in a sparse graph.
+	SampleUniform SampleMethod = iota
+	// SampleRandomWalk walks from a random version to random dependencies and dependents, returning to the start of
+	// the walk with probability 0.15 at every step. A walk that finds no new versions for 100 steps starts again
+	// at another random version.
+	SampleRandomWalk
+	// SampleForestFire starts at a random version and burns a geometrically distributed number of its unburnt
+	// dependencies and dependents, with a mean of about two, and from each of them onwards. Once the fire dies out it
+	// starts again at another random version.
+	SampleForestFire
+	// SampleTopInDegree takes the versions with the most dependents, each followed by its direct dependencies and
+	// dependents, until the sample is full.
+	SampleTopInDegree
+)
+
+const (
+	// walkRestartProbability is the probability with which a random walk returns to its start.
+	walkRestartProbability = 0.15
+	// walkPatience is the number of steps after which a random walk that finds no new versions starts elsewhere.
+	walkPatience = 100
+	// fireBurnProbability is the forward burning probability of the forest fire, giving a mean of p/(1-p) burnt
+	// neighbours per version.
+	fireBurnProbability = 0.7
+)
+
+// SampleSubgraph returns a graph of n versions of pg, and the edges between them, picked by method. The sample only
+// depends on the graph and the seed. If n is at least the number of versions, the sample contains all of them.
+//
+// The sample is a complete PackageGraph built with the settings of pg. Its versions keep their relative order and get
+// the IDs from 0 up, or keep their ID with HashedIDs, and its Packages only contain the sampled versions. The edges are
+// those of pg and are not resolved again, so with ResolveHighest a version may lack an edge to a package of which only
+// lower versions were sampled.
+func SampleSubgraph(pg *PackageGraph, n int, method SampleMethod, seed int64) *PackageGraph {
+	pg.materializeExternalEdges()
+	ids := pg.nodeIDs()
+	if n > len(ids) {
+		n = len(ids)
+	}
+	if n < 0 {
+		n = 0
+	}
+
+	sample := newSampleSet(n)
+	if n > 0 && n < len(ids) {
+		// The versions are visited in a random order that only depends on the seed, starting every walk or fire at
+		// the next version that has not been sampled yet.
+		random := rand.New(rand.NewSource(seed))
+		order := random.Perm(len(ids))
+		next := 0
+		nextStart := func() int64 {
+			for sample.contains(ids[order[next]]) {
+				next++
+			}
+			return ids[order[next]]
+		}
+		switch method {
+		case SampleUniform:
+			for !sample.full() {
+				sample.add(nextStart())
+			}
+		case SampleRandomWalk:
+			pg.sampleRandomWalk(sample, random, nextStart)
+		case SampleForestFire:
+			pg.sampleForestFire(sample, random, nextStart)
+		case SampleTopInDegree:
+			pg.sampleTopInDegree(sample, ids)
+		}
+	} else if n > 0 {
+		for _, id := range ids {
+			sample.add(id)
+		}
+	}
+	return pg.inducedSubgraph(sample.members)
+}
+
+// sampleSet is the set of sampled versions, which holds at most n of them.
+type sampleSet struct {
+	members map[int64]bool
+	n       int
+}
+
+func newSampleSet(n int) *sampleSet {
+	return &sampleSet{members: make(map[int64]bool, n), n: n}
+}
+
+func (sample *sampleSet) contains(id int64) bool {
+	return sample.members[id]
+}
+
+func (sample *sampleSet) full() bool {
+	return len(sample.members) >= sample.n
+}
+
+// add adds the version to the sample unless it is full, and reports whether it was new.
+func (sample *sampleSet) add(id int64) bool {
+	if sample.full() || sample.members[id] {
+		return false
+	}
+	sample.members[id] = true
+	return true
+}
+
+// neighbours returns the dependencies and dependents of the node, in increasing ID order.
+func (pg *PackageGraph) neighbours(id int64) []int64 {
+	neighbours := append(sortedNodeIDs(pg.Graph.From(id)), sortedNodeIDs(pg.Graph.To(id))...)
+	sort.Slice(neighbours, func(i, j int) bool { return neighbours[i] < neighbours[j] })
+	unique := neighbours[:0]
+	for i, neighbour := range neighbours {
+		if i == 0 || neighbour != neighbours[i-1] {
+			unique = append(unique, neighbour)
+		}
+	}
+	return unique
+}
+
+func (pg *PackageGraph) sampleRandomWalk(sample *sampleSet, random *rand.Rand, nextStart func() int64) {
+	start := nextStart()
+	sample.add(start)
+	current, idle := start, 0
+	for !sample.full() {
+		neighbours := pg.neighbours(current)
+		if len(neighbours) == 0 || idle >= walkPatience {
+			start = nextStart()
+			sample.add(start)
+			current, idle = start, 0
+			continue
+		}
+		if random.Float64() < walkRestartProbability {
+			current = start
+		} else {
+			current = neighbours[random.Intn(len(neighbours))]
+		}
+		if sample.add(current) {
+			idle = 0
+		} else {
+			idle++
+		}
+	}
+}
+
+func (pg *PackageGraph) sampleForestFire(sample *sampleSet, random *rand.Rand, nextStart func() int64) {
+	for !sample.full() {
+		start := nextStart()
+		sample.add(start)
+		burning := []int64{start}
+		for len(burning) > 0 && !sample.full() {
+			current := burning[0]
+			burning = burning[1:]
+			var unburnt []int64
+			for _, neighbour := range pg.neighbours(current) {
+				if !sample.contains(neighbour) {
+					unburnt = append(unburnt, neighbour)
+				}
+			}
+			// The number of neighbours to burn is geometrically distributed with mean p/(1-p).
+			burn := 0
+			for random.Float64() < fireBurnProbability {
+				burn++
+			}
+			if burn > len(unburnt) {
+				burn = len(unburnt)
+			}
+			random.Shuffle(len(unburnt), func(i, j int) { unburnt[i], unburnt[j] = unburnt[j], unburnt[i] })
+			for _, neighbour := range unburnt[:burn] {
+				if sample.add(neighbour) {
+					burning = append(burning, neighbour)
+				}
+			}
+		}
+	}
+}
+
+func (pg *PackageGraph) sampleTopInDegree(sample *sampleSet, ids []int64) {
+	inDegrees := make(map[int64]int, len(ids))
+	ranked := append([]int64(nil), ids...)
+	for _, id := range ranked {
+		inDegrees[id] = pg.DependencyGraph().To(id).Len()
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return inDegrees[ranked[i]] > inDegrees[ranked[j]] })
+	for _, id := range ranked {
+		if sample.full() {
+			return
+		}
+		sample.add(id)
+		for _, neighbour := range pg.neighbours(id) {
+			sample.add(neighbour)
+		}
+	}
+}
+
+// inducedSubgraph returns a PackageGraph of the given versions of pg and the edges between them, with the settings
+// of pg. The versions keep their relative order and get new IDs from 0 up, or keep their ID with HashedIDs.
+func (pg *PackageGraph) inducedSubgraph(members map[int64]bool) *PackageGraph {
+	return pg.subgraph(members, nil)
+}
+
+// subgraph is inducedSubgraph keeping only the edges between the versions for which keepEdge, if not nil, returns
+// true. keepEdge is called with the ends of the edges as they are stored in Graph.
+func (pg *PackageGraph) subgraph(members map[int64]bool, keepEdge func(from, to int64) bool) *PackageGraph {
+	var packages []PackageInfo
+	packageIndex := make(map[PackageKey]int)
+	graph := simple.NewDirectedGraph()
+	allocator := newNodeAllocator(graph, pg.options.IDScheme)
+	var nodes []NodeInfo
+	newIDs := make(map[int64]int64, len(members))
+	for _, node := range pg.Nodes {
+		if node.stringID == "" || !members[node.id] {
+			continue
+		}
+		index, ok := packageIndex[node.key()]
+		if !ok {
+			index = len(packages)
+			packageIndex[node.key()] = index
+			packages = append(packages, PackageInfo{Name: node.Name, Ecosystem: node.Ecosystem, Versions: make(map[string]VersionInfo), Provenance: node.Provenance})
+		}
+		versionInfo, _ := pg.VersionInfo(node.NameVersion())
+		packages[index].Versions[node.Version] = versionInfo
+		// The versions of pg cannot collide.
+		id, _ := allocator.add(node.NameVersion())
+		newIDs[node.id] = id
+		nodes = appendNodeInfo(nodes, *newNodeInfoFromVersion(id, node.key(), node.Version, versionInfo), allocator.scheme)
+	}
+	// The edges are copied as they are stored, as the subgraph keeps the direction of pg.
+	for from, newFrom := range newIDs {
+		for _, to := range sortedNodeIDs(pg.Graph.From(from)) {
+			if newTo, ok := newIDs[to]; ok && (keepEdge == nil || keepEdge(from, to)) {
+				graph.SetEdge(simple.Edge{F: graph.Node(newFrom), T: graph.Node(newTo)})
+			}
+		}
+	}
+
+	subgraph := newPackageGraphFromParts(graph, &packages, nodes)
+	subgraph.isMaven = pg.isMaven
+	// The report of pg does not describe the subgraph.
+	options := *pg.options
+	options.Report = nil
+	subgraph.options = &options
+	for edge, weight := range pg.weights {
+		newFrom, fromOK := newIDs[edge[0]]
+		newTo, toOK := newIDs[edge[1]]
+		if fromOK && toOK && (keepEdge == nil || keepEdge(pg.storedEdge(edge[0], edge[1]))) {
+			subgraph.setWeight(newFrom, newTo, int(weight))
+		}
+	}
+	subgraph.copyCrossEdges(pg, func(id int64) (int64, bool) {
+		newID, ok := newIDs[id]
+		return newID, ok
+	})
+	if keepEdge != nil {
+		for edge := range subgraph.crossEdges {
+			if from, to := subgraph.storedEdge(edge[0], edge[1]); !graph.HasEdgeFromTo(from, to) {
+				delete(subgraph.crossEdges, edge)
+			}
+		}
+	}
+	return subgraph
+}
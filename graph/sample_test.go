@@ -0,0 +1,73 @@
+package graph
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSampleSubgraph(t *testing.T) {
+	packagesInfo := testPackages("sample")
+	pg := NewPackageGraph(&packagesInfo, false)
+	original := edgeSet(pg)
+
+	for _, method := range []SampleMethod{SampleUniform, SampleRandomWalk, SampleForestFire, SampleTopInDegree} {
+		t.Run(fmt.Sprintf("Samples a valid graph with method %d", method), func(t *testing.T) {
+			sample := SampleSubgraph(pg, 20, method, 7)
+			if len(sample.StringIDToNodeInfo) != 20 {
+				t.Errorf("Expected 20 nodes, got %d", len(sample.StringIDToNodeInfo))
+			}
+			if errs := Validate(sample); len(errs) != 0 {
+				t.Errorf("Expected no validation errors, got %v", errs)
+			}
+			edges := edgeSet(sample)
+			for edge := range edges {
+				if !original[edge] {
+					t.Errorf("Expected only edges of the original graph, got %v", edge)
+				}
+			}
+			for edge := range original {
+				_, fromSampled := sample.StringIDToNodeInfo[edge[0]]
+				_, toSampled := sample.StringIDToNodeInfo[edge[1]]
+				if fromSampled && toSampled && !edges[edge] {
+					t.Errorf("Expected the induced edge %v", edge)
+				}
+			}
+
+			again := SampleSubgraph(pg, 20, method, 7)
+			for key, info := range sample.StringIDToNodeInfo {
+				if other, ok := again.StringIDToNodeInfo[key]; !ok || other.id != info.id {
+					t.Errorf("Expected the same sample for the same seed, got %v and %v", info, other)
+				}
+			}
+		})
+	}
+
+	t.Run("Starts from the versions with the most dependents", func(t *testing.T) {
+		most, mostDependents := int64(-1), -1
+		for _, node := range pg.Nodes {
+			if dependents := pg.Graph.To(node.id).Len(); dependents > mostDependents {
+				most, mostDependents = node.id, dependents
+			}
+		}
+		info, _ := pg.Node(most)
+		if _, ok := SampleSubgraph(pg, 1, SampleTopInDegree, 1).StringIDToNodeInfo[info.stringID]; !ok {
+			t.Errorf("Expected %s in the sample", info)
+		}
+	})
+
+	t.Run("Keeps every version if the sample is larger than the graph", func(t *testing.T) {
+		sample := SampleSubgraph(pg, 1000, SampleForestFire, 1)
+		if len(sample.Nodes) != len(pg.Nodes) || len(edgeSet(sample)) != len(original) {
+			t.Errorf("Expected %d nodes and %d edges, got %d and %d", len(pg.Nodes), len(original), len(sample.Nodes), len(edgeSet(sample)))
+		}
+	})
+
+	t.Run("Supports the queries on the sample", func(t *testing.T) {
+		sample := SampleSubgraph(pg, 30, SampleRandomWalk, 3)
+		for _, node := range sample.Nodes {
+			if _, ok := sample.Dependencies(NameVersion{Name: node.Name, Version: node.Version}, -1); !ok {
+				t.Errorf("Expected to find the dependencies of %s", node)
+			}
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package graph
+
+import (
+	"fmt"
+	"time"
+)
+
+// VersionIndex answers which versions of a package satisfy a range without building a graph. It indexes the versions
+// like NewPackageGraph and matches the ranges with the resolver that CreateEdges uses, with the same range matcher,
+// name normalization, prerelease handling and filters, so its answers agree with the edges of a graph built from the
+// same input and options. Like the lookups of the graph, it takes the QualifiedName of a package of an ecosystem.
+type VersionIndex struct {
+	resolver   *edgeResolver
+	ecosystems map[string]bool
+}
+
+// NewVersionIndex indexes the versions of the packages with the given options, which are applied like in
+// NewPackageGraph. The input is left unchanged.
+func NewVersionIndex(packagesList *[]PackageInfo, isUsingMaven bool, opts ...Option) *VersionIndex {
+	options := newOptions(opts)
+	packagesList, _ = MergeDuplicatePackages(normalizePackageNames(packagesList, options))
+	packagesList = filterPackages(packagesList, options)
+	// The versions get the IDs that createNodeInfos would give them, so that ResolveHighest breaks the ties between
+	// equal versions the same way.
+	nodes := make(map[NameVersion]NodeInfo)
+	var id int64
+	for _, packageInfo := range *packagesList {
+		for _, version := range sortedVersionKeys(packageInfo.Versions) {
+			nodes[packageInfo.key().version(version)] = *newNodeInfoFromVersion(id, packageInfo.key(), version, packageInfo.Versions[version])
+			id++
+		}
+	}
+	find := func(nameVersion NameVersion) (NodeInfo, bool) {
+		info, ok := nodes[nameVersion]
+		return info, ok
+	}
+	return &VersionIndex{
+		resolver:   newEdgeResolver(find, packageVersions(packagesList), isUsingMaven, options),
+		ecosystems: packageEcosystems(packagesList),
+	}
+}
+
+// key returns the key of the package with the given name, normalized like the names of the index.
+func (index *VersionIndex) key(name string) PackageKey {
+	ecosystem, name := splitQualifiedName(index.ecosystems, name)
+	return NewPackageKey(ecosystem, NormalizeName(name, index.resolver.options.nameNormalization(ecosystem)))
+}
+
+// SatisfyingVersions returns the versions of the package that satisfy the range in increasing order, which are the
+// versions that ResolveAll creates edges to. If at is not nil, only the versions released at or before it are
+// returned, leaving out the versions without a parseable timestamp. The result is empty if no version satisfies the
+// range; an error is returned if the package is unknown or the range cannot be parsed.
+func (index *VersionIndex) SatisfyingVersions(name, dependencyRange string, at *time.Time) ([]string, error) {
+	key := index.key(name)
+	versions, parsedRange, outcome := index.resolver.candidates(key, dependencyRange)
+	if outcome != resolved {
+		return nil, satisfyingError(key, dependencyRange, outcome)
+	}
+	var cutoff time.Time
+	if at != nil {
+		cutoff = *at
+	}
+	satisfying := make([]string, 0)
+	for i := range versions {
+		candidate := &versions[i]
+		if matchesIndexed(parsedRange, candidate) && releasedBy(index.resolver, key, candidate, cutoff) {
+			satisfying = append(satisfying, candidate.version)
+		}
+	}
+	return satisfying, nil
+}
+
+// HighestSatisfying returns the version of the package that ResolveHighest picks for the range, among the versions
+// released at or before at if it is not nil. Unlike SatisfyingVersions, it returns an error if no version satisfies
+// the range.
+func (index *VersionIndex) HighestSatisfying(name, dependencyRange string, at *time.Time) (string, error) {
+	key := index.key(name)
+	var cutoff time.Time
+	if at != nil {
+		cutoff = *at
+	}
+	id, outcome := index.resolver.highestAt(key, cutoff, dependencyRange)
+	if outcome != resolved {
+		return "", satisfyingError(key, dependencyRange, outcome)
+	}
+	for _, candidate := range index.resolver.versions[key] {
+		if candidate.id == id {
+			return candidate.version, nil
+		}
+	}
+	return "", satisfyingError(key, dependencyRange, unsatisfied)
+}
+
+func satisfyingError(key PackageKey, dependencyRange string, outcome resolutionOutcome) error {
+	return fmt.Errorf("resolving %s %q: %s", key, dependencyRange, outcome)
+}
@@ -0,0 +1,79 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestVersionIndex(t *testing.T) {
+	t.Run("Returns the versions that satisfy the range", func(t *testing.T) {
+		packagesInfo := testPackages("satisfying")
+		index := NewVersionIndex(&packagesInfo, false)
+		versions, err := index.SatisfyingVersions("A", ">= 1.1.0", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := []string{"1.1.0", "1.2.0", "1.2.1"}; !reflect.DeepEqual(versions, expected) {
+			t.Errorf("Expected %v, got %v", expected, versions)
+		}
+	})
+
+	t.Run("Agrees with the edges of the graph", func(t *testing.T) {
+		packagesInfo := testPackages("satisfying")
+		index := NewVersionIndex(&packagesInfo, false)
+		pg := NewPackageGraph(&packagesInfo, false)
+		versions, err := index.SatisfyingVersions("A", ">= 1.0.0", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dependencies, _ := pg.Dependencies(NameVersion{Name: "App", Version: "1.0.0"}, 1)
+		if len(dependencies) != len(versions) {
+			t.Errorf("Expected %d dependencies like the graph, got %v", len(dependencies), versions)
+		}
+	})
+
+	t.Run("Leaves out the versions released after the date", func(t *testing.T) {
+		packagesInfo := testPackages("satisfying")
+		index := NewVersionIndex(&packagesInfo, false)
+		at := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+		versions, err := index.SatisfyingVersions("A", ">= 1.0.0", &at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := []string{"1.0.0", "1.1.0"}; !reflect.DeepEqual(versions, expected) {
+			t.Errorf("Expected %v, got %v", expected, versions)
+		}
+		highest, err := index.HighestSatisfying("A", ">= 1.0.0", &at)
+		if err != nil || highest != "1.1.0" {
+			t.Errorf("Expected 1.1.0, got %q and %v", highest, err)
+		}
+	})
+
+	t.Run("Returns the highest satisfying version", func(t *testing.T) {
+		packagesInfo := testPackages("satisfying")
+		index := NewVersionIndex(&packagesInfo, false)
+		highest, err := index.HighestSatisfying("A", "< 1.2.1", nil)
+		if err != nil || highest != "1.2.0" {
+			t.Errorf("Expected 1.2.0, got %q and %v", highest, err)
+		}
+		if _, err := index.HighestSatisfying("A", "> 5.0.0", nil); err == nil {
+			t.Error("Expected an error without a satisfying version")
+		}
+	})
+
+	t.Run("Returns an error for unknown packages and invalid ranges", func(t *testing.T) {
+		packagesInfo := testPackages("satisfying")
+		index := NewVersionIndex(&packagesInfo, false)
+		if _, err := index.SatisfyingVersions("Missing", "*", nil); err == nil {
+			t.Error("Expected an error for an unknown package")
+		}
+		if _, err := index.SatisfyingVersions("A", ">= not a version", nil); err == nil {
+			t.Error("Expected an error for an invalid range")
+		}
+		versions, err := index.SatisfyingVersions("A", "> 5.0.0", nil)
+		if err != nil || len(versions) != 0 {
+			t.Errorf("Expected no versions and no error, got %v and %v", versions, err)
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package graph
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MatchMode determines how SearchNodes matches a pattern against the package names.
+type MatchMode int
+
+const (
+	// MatchExact matches the names equal to the pattern.
+	MatchExact MatchMode = iota
+	// MatchPrefix matches the names starting with the pattern, such as all the packages of the "@babel/" scope.
+	MatchPrefix
+	// MatchGlob matches the names against a shell pattern in which "*" matches any sequence of characters, including
+	// "/", "?" matches a single character and "[...]" matches a character class.
+	MatchGlob
+	// MatchRegexp matches the names containing a match of the regular expression, so "polyfill" matches every name
+	// containing it. Anchor the expression with ^ and $ to match whole names.
+	MatchRegexp
+)
+
+// nameMatcher returns a function reporting whether a package name matches the pattern.
+func nameMatcher(pattern string, mode MatchMode, ignoreCase bool) (func(name string) bool, error) {
+	switch mode {
+	case MatchExact:
+		if ignoreCase {
+			return func(name string) bool { return strings.EqualFold(name, pattern) }, nil
+		}
+		return func(name string) bool { return name == pattern }, nil
+	case MatchPrefix:
+		if ignoreCase {
+			prefix := strings.ToLower(pattern)
+			return func(name string) bool { return strings.HasPrefix(strings.ToLower(name), prefix) }, nil
+		}
+		return func(name string) bool { return strings.HasPrefix(name, pattern) }, nil
+	case MatchGlob, MatchRegexp:
+		expression := pattern
+		if mode == MatchGlob {
+			var err error
+			if expression, err = globToRegexp(pattern); err != nil {
+				return nil, err
+			}
+		}
+		if ignoreCase {
+			expression = "(?i)" + expression
+		}
+		compiled, err := regexp.Compile(expression)
+		if err != nil {
+			return nil, err
+		}
+		return compiled.MatchString, nil
+	}
+	return nil, fmt.Errorf("unknown match mode %d", mode)
+}
+
+// globToRegexp translates a glob pattern to an anchored regular expression.
+func globToRegexp(pattern string) (string, error) {
+	var expression strings.Builder
+	expression.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			expression.WriteString(".*")
+		case '?':
+			expression.WriteString(".")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return "", fmt.Errorf("glob %q has an unterminated character class", pattern)
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expression.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expression.WriteString("$")
+	return expression.String(), nil
+}
+
+// SearchNodes returns the nodes whose package name matches the pattern, in increasing ID order. With ignoreCase, the
+// names are matched case-insensitively, which is mostly useful for Maven coordinates as npm names are lowercase. It
+// returns an error if the pattern is not a valid glob or regular expression.
+func SearchNodes(nodeMap map[int64]NodeInfo, pattern string, mode MatchMode, ignoreCase bool) ([]NodeInfo, error) {
+	matches, err := nameMatcher(pattern, mode, ignoreCase)
+	if err != nil {
+		return nil, err
+	}
+	var result []NodeInfo
+	for _, info := range nodeMap {
+		if matches(info.Name) {
+			result = append(result, info)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].id < result[j].id })
+	return result, nil
+}
+
+// SearchNodes returns the IDs of the versions of every package whose name matches the pattern, like the SearchNodes
+// function, in increasing order. Every name is matched once, however many versions the package has.
+func (pg *PackageGraph) SearchNodes(pattern string, mode MatchMode, ignoreCase bool) ([]int64, error) {
+	matches, err := nameMatcher(pattern, mode, ignoreCase)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for key := range pg.NameToVersions {
+		if matches(key.FullName()) {
+			ids = append(ids, pg.versionIDs(key)...)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
@@ -0,0 +1,74 @@
+package graph
+
+import (
+	"testing"
+)
+
+func searchNames(t *testing.T, nodeMap map[int64]NodeInfo, pattern string, mode MatchMode, ignoreCase bool) []string {
+	nodes, err := SearchNodes(nodeMap, pattern, mode, ignoreCase)
+	if err != nil {
+		t.Fatalf("Expected no error for %q, got %v", pattern, err)
+	}
+	names := make([]string, len(nodes))
+	for i, node := range nodes {
+		names[i] = node.Name
+	}
+	return names
+}
+
+func TestSearchNodes(t *testing.T) {
+	version := map[string]VersionInfo{"1.0.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{}}}
+	var packagesInfo []PackageInfo
+	for _, name := range []string{"@babel/core", "@babel/preset-env", "babel", "core-js-polyfill", "org.Apache:Commons"} {
+		packagesInfo = append(packagesInfo, PackageInfo{Name: name, Versions: version})
+	}
+	pg := NewPackageGraph(&packagesInfo, false)
+	nodeMap := pg.NodeMap()
+
+	for _, test := range []struct {
+		pattern    string
+		mode       MatchMode
+		ignoreCase bool
+		expected   []string
+	}{
+		{"babel", MatchExact, false, []string{"babel"}},
+		{"@babel/", MatchPrefix, false, []string{"@babel/core", "@babel/preset-env"}},
+		{"@babel/*", MatchGlob, false, []string{"@babel/core", "@babel/preset-env"}},
+		{"*polyfill*", MatchGlob, false, []string{"core-js-polyfill"}},
+		{"?abel", MatchGlob, false, []string{"babel"}},
+		{"[!@]*", MatchGlob, false, []string{"babel", "core-js-polyfill", "org.Apache:Commons"}},
+		{"polyfill", MatchRegexp, false, []string{"core-js-polyfill"}},
+		{"^@babel/(core|cli)$", MatchRegexp, false, []string{"@babel/core"}},
+		{"org.apache:commons", MatchExact, false, []string{}},
+		{"org.apache:commons", MatchExact, true, []string{"org.Apache:Commons"}},
+		{"org.apache", MatchPrefix, true, []string{"org.Apache:Commons"}},
+		{"*commons", MatchGlob, true, []string{"org.Apache:Commons"}},
+	} {
+		t.Run("Matches "+test.pattern, func(t *testing.T) {
+			if got := searchNames(t, nodeMap, test.pattern, test.mode, test.ignoreCase); !equalStrings(test.expected, got) {
+				t.Errorf("Expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+
+	t.Run("Rejects invalid patterns", func(t *testing.T) {
+		if _, err := SearchNodes(nodeMap, "(", MatchRegexp, false); err == nil {
+			t.Error("Expected an error for an invalid regular expression")
+		}
+		if _, err := SearchNodes(nodeMap, "[a", MatchGlob, false); err == nil {
+			t.Error("Expected an error for an unterminated character class")
+		}
+	})
+
+	t.Run("Returns the matching node IDs from the graph", func(t *testing.T) {
+		ids, err := pg.SearchNodes("@babel/", MatchPrefix, false)
+		if err != nil || len(ids) != 2 {
+			t.Fatalf("Expected 2 IDs, got %v and %v", ids, err)
+		}
+		for _, id := range ids {
+			if info, _ := pg.Node(id); info.Name[:7] != "@babel/" {
+				t.Errorf("Expected a package of the @babel scope, got %s", info.Name)
+			}
+		}
+	})
+}
@@ -0,0 +1,44 @@
+package graph
+
+import (
+	"testing"
+)
+
+func TestSelfDependencies(t *testing.T) {
+	report := &ResolutionReport{}
+	pg := NewPackageGraph(ParseJSON("testdata/self_dependency.json"), false,
+		WithDependencyClasses(Runtime, Development, Peer), WithResolutionReport(report))
+
+	t.Run("Builds a graph with a self-depending package", func(t *testing.T) {
+		edges := edgeSet(pg)
+		// The ranges of the versions on their own package also match the other version, but not themselves.
+		expected := [][2]string{{"self-1.0.0", "lib-1.0.0"}, {"self-1.0.0", "self-1.1.0"}, {"self-1.1.0", "self-1.0.0"}, {"self-1.1.0", "lib-1.0.0"}}
+		if len(edges) != len(expected) {
+			t.Errorf("Expected %d edges, got %v", len(expected), edges)
+		}
+		for _, edge := range expected {
+			if !edges[edge] {
+				t.Errorf("Expected edge %v", edge)
+			}
+		}
+	})
+
+	t.Run("Counts the skipped self-edges", func(t *testing.T) {
+		if report.SelfEdges != 2 {
+			t.Errorf("Expected 2 self-edges, got %d", report.SelfEdges)
+		}
+	})
+
+	t.Run("Inserts an edge declared in several classes once, with its weight", func(t *testing.T) {
+		packageInfo := (*pg.Packages)[pg.packageIndex[NewPackageKey("", "self")]]
+		edges := pg.resolver().resolvePackage(&packageInfo, nil)
+		if len(edges) != 5 {
+			t.Errorf("Expected 5 resolved declarations, got %v", edges)
+		}
+		from, _ := pg.FindNode(NameVersion{Name: "self", Version: "1.1.0"})
+		to, _ := pg.FindNode(NameVersion{Name: "lib", Version: "1.0.0"})
+		if weight := pg.EdgeWeight(from.id, to.id); weight != 2 {
+			t.Errorf("Expected weight 2, got %d", weight)
+		}
+	})
+}
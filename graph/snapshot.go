@@ -0,0 +1,94 @@
+package graph
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// SeriesPoint is the value of a metric computed on the graph as it was at a point in time.
+type SeriesPoint struct {
+	Time  time.Time
+	Value float64
+}
+
+// snapshotVersion is a version that is added to the snapshots once its release time has passed.
+type snapshotVersion struct {
+	key      PackageKey
+	version  string
+	info     VersionInfo
+	released time.Time
+}
+
+// SnapshotSeries materializes the graph at each of the dates, which must be in increasing order, and computes a
+// metric on every snapshot. The snapshot at a date contains the versions released at or before it, like a graph built
+// with WithCutoff; the options other than the cutoff apply as usual.
+//
+// Only the first snapshot is built from scratch. Every following snapshot is derived from the previous one by adding
+// the versions released in between, including the edges from existing versions whose ranges they satisfy, so the
+// total work is close to that of building the last snapshot once. The packages are not modified. The graph passed to
+// compute is changed after it returns, so compute must not keep it or modify it.
+func SnapshotSeries(packages []PackageInfo, isUsingMaven bool, dates []time.Time, compute func(*PackageGraph) (float64, error), opts ...Option) ([]SeriesPoint, error) {
+	if len(dates) == 0 {
+		return nil, nil
+	}
+	for i := 1; i < len(dates); i++ {
+		if !dates[i].After(dates[i-1]) {
+			return nil, errors.New("snapshot dates must be in increasing order")
+		}
+	}
+
+	// The base snapshot gets its own version maps, so that adding versions does not modify the input.
+	base := make([]PackageInfo, 0, len(packages))
+	var pending []snapshotVersion
+	for _, packageInfo := range packages {
+		versions := make(map[string]VersionInfo)
+		for version, versionInfo := range packageInfo.Versions {
+			released, err := ParseTimestamp(versionInfo.Timestamp)
+			if err == nil && released.After(dates[0]) {
+				pending = append(pending, snapshotVersion{packageInfo.key(), version, versionInfo, released})
+				continue
+			}
+			versions[version] = versionInfo
+		}
+		if len(versions) > 0 {
+			packageInfo.Versions = versions
+			base = append(base, packageInfo)
+		}
+	}
+	// The versions are added in release order, with ties broken by name and version to keep the node IDs stable.
+	sort.Slice(pending, func(i, j int) bool {
+		if !pending[i].released.Equal(pending[j].released) {
+			return pending[i].released.Before(pending[j].released)
+		}
+		if pending[i].key != pending[j].key {
+			return pending[i].key.less(pending[j].key)
+		}
+		return compareVersions(pending[i].version, pending[j].version) < 0
+	})
+
+	// The cutoff of the options is overridden by the dates.
+	opts = append(opts[:len(opts):len(opts)], WithCutoff(time.Time{}))
+	pg := NewPackageGraph(&base, isUsingMaven, opts...)
+	nameFilter := pg.options.NameFilter
+
+	points := make([]SeriesPoint, 0, len(dates))
+	next := 0
+	for _, date := range dates {
+		for ; next < len(pending) && !pending[next].released.After(date); next++ {
+			version := pending[next]
+			if nameFilter != nil && !nameFilter(version.key.Ecosystem, version.key.FullName()) || !pg.options.includesEcosystem(version.key.Ecosystem) {
+				continue
+			}
+			if _, err := pg.addVersion(version.key.Ecosystem, nil, version.key.FullName(), version.version, version.info); err != nil {
+				return nil, err
+			}
+		}
+		value, err := compute(pg)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, SeriesPoint{Time: date, Value: value})
+	}
+	return points, nil
+}
@@ -0,0 +1,74 @@
+package graph
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSnapshotSeries(t *testing.T) {
+	dates := []time.Time{
+		time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	for name, resolution := range map[string]Resolution{"all": ResolveAll, "highest": ResolveHighest} {
+		t.Run("Matches a graph built with the same cutoff resolving "+name, func(t *testing.T) {
+			// Early depends on A before the later versions of A are released, so its edges change between snapshots.
+			packagesInfo := append(testPackages("options"), PackageInfo{
+				Name: "Early",
+				Versions: map[string]VersionInfo{
+					"1.0.0": {Timestamp: "2020-03-01T00:00:00", Dependencies: map[string]string{"A": ">= 1.0.0"}},
+				},
+			})
+			i := 0
+			points, err := SnapshotSeries(packagesInfo, false, dates, func(pg *PackageGraph) (float64, error) {
+				expected := NewPackageGraph(&packagesInfo, false, WithCutoff(dates[i]), WithResolution(resolution))
+				actual := edgeSet(pg)
+				if len(actual) != len(edgeSet(expected)) {
+					t.Errorf("Expected %d edges at %v, got %d", len(edgeSet(expected)), dates[i], len(actual))
+				}
+				for edge := range edgeSet(expected) {
+					if !actual[edge] {
+						t.Errorf("Expected edge %v at %v", edge, dates[i])
+					}
+				}
+				i++
+				return float64(pg.Graph.Nodes().Len()), nil
+			}, WithResolution(resolution))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			expected := []float64{3, 4, 6}
+			for i, point := range points {
+				if point.Value != expected[i] || !point.Time.Equal(dates[i]) {
+					t.Errorf("Expected %v nodes at %v, got %v at %v", expected[i], dates[i], point.Value, point.Time)
+				}
+			}
+		})
+	}
+
+	t.Run("Does not modify the packages", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		_, _ = SnapshotSeries(packagesInfo, false, dates, func(pg *PackageGraph) (float64, error) { return 0, nil })
+		if len(packagesInfo[1].Versions) != 3 {
+			t.Errorf("Expected 3 versions of A, got %d", len(packagesInfo[1].Versions))
+		}
+	})
+
+	t.Run("Returns the error of compute", func(t *testing.T) {
+		failure := errors.New("failure")
+		_, err := SnapshotSeries(testPackages("options"), false, dates, func(pg *PackageGraph) (float64, error) { return 0, failure })
+		if !errors.Is(err, failure) {
+			t.Errorf("Expected the error of compute, got %v", err)
+		}
+	})
+
+	t.Run("Rejects dates out of order", func(t *testing.T) {
+		_, err := SnapshotSeries(testPackages("options"), false, []time.Time{dates[1], dates[0]}, func(pg *PackageGraph) (float64, error) { return 0, nil })
+		if err == nil {
+			t.Error("Expected an error")
+		}
+	})
+}
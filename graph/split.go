@@ -0,0 +1,115 @@
+package graph
+
+import (
+	"errors"
+	"time"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// ErrSplitGraph is returned by the incremental changes to a graph built with WithSplitByClass or one of its class
+// graphs, which would change the node side that they share.
+var ErrSplitGraph = errors.New("graphs split by dependency class cannot be changed incrementally")
+
+// ClassGraphs returns the graphs of the single dependency classes of a graph built with WithSplitByClass, keyed by
+// class, or nil for other graphs. A class graph has the same nodes as the graph it was split from, but only the edges
+// created by the declarations in its class, so the edges of the graph are the union of the edges of its class graphs.
+// The class graphs share the node information, the lookup maps and the version index of that graph by reference,
+// so they take little more memory than their edges, and can be queried, exported and saved like any other graph.
+// Neither the graph nor its class graphs can be changed incrementally, see ErrSplitGraph, and the class graphs are
+// not part of the cache written by SaveGraph; ReloadFrom splits the reloaded graph again.
+//
+// The class graphs of a graph built with WithLazyEdges or WithExternalEdges, whose own edges are not all created up
+// front, are built by the first call in a single pass over the packages, and the graph cannot be changed
+// incrementally from then on.
+func (pg *PackageGraph) ClassGraphs() map[DependencyClass]*PackageGraph {
+	if pg.options == nil || !pg.options.SplitByClass {
+		return pg.classGraphs
+	}
+	pg.classGraphsMutex.Lock()
+	defer pg.classGraphsMutex.Unlock()
+	if pg.classGraphs == nil {
+		if pg.lockEdges() {
+			defer pg.lazyMutex.Unlock()
+		}
+		start := time.Now()
+		resolver := pg.resolver()
+		// The declarations were counted and traced when the edges of the graph were created.
+		options := *pg.options
+		options.Report, options.Trace = nil, nil
+		resolver.options = &options
+		graphs := pg.newClassGraphs()
+		createEdgeSets(func(edgeSets [][][2]int64) {
+			for i, classEdges := range edgeSets {
+				graphs[i].setWeightedEdges(classEdges)
+			}
+		}, pg.Packages, resolver, true)
+		pg.options.log(LevelInfo, "class graphs built", "duration", time.Since(start), "classes", len(pg.classGraphs))
+	}
+	return pg.classGraphs
+}
+
+// createClassEdges creates the edges of the graph and of a graph for every dependency class, resolving every
+// declaration only once.
+func (pg *PackageGraph) createClassEdges(packagesList *[]PackageInfo) {
+	resolver := pg.resolver()
+	graphs := pg.newClassGraphs()
+	var edges [][2]int64
+	createEdgeSets(func(edgeSets [][][2]int64) {
+		edges = edges[:0]
+		for i, classEdges := range edgeSets {
+			graphs[i].setWeightedEdges(classEdges)
+			edges = append(edges, classEdges...)
+		}
+		pg.setWeightedEdges(edges)
+	}, packagesList, resolver, true)
+}
+
+// newClassGraphs creates the class graphs without edges, marks the graph as split and returns the class graph of
+// every class of the options, in their order. The resolver of the graph must have been created.
+func (pg *PackageGraph) newClassGraphs() []*PackageGraph {
+	classes := pg.options.DependencyClasses
+	pg.classGraphs = make(map[DependencyClass]*PackageGraph, len(classes))
+	graphs := make([]*PackageGraph, len(classes))
+	for i, class := range classes {
+		if _, ok := pg.classGraphs[class]; !ok {
+			pg.classGraphs[class] = pg.newClassGraph(class)
+		}
+		graphs[i] = pg.classGraphs[class]
+	}
+	pg.split = true
+	return graphs
+}
+
+// newClassGraph returns a graph without edges for the class, which shares the node side of the graph. The resolver of
+// the graph must have been created, so that its version index and range matcher are shared as well.
+func (pg *PackageGraph) newClassGraph(class DependencyClass) *PackageGraph {
+	graph := simple.NewDirectedGraph()
+	for nodes := pg.Graph.Nodes(); nodes.Next(); {
+		graph.AddNode(nodes.Node())
+	}
+	options := *pg.options
+	options.DependencyClasses = []DependencyClass{class}
+	options.SplitByClass = false
+	// The edges of a class graph are all created when it is built.
+	options.LazyEdges, options.ExternalEdges = false, ""
+	return &PackageGraph{
+		Graph:              graph,
+		Packages:           pg.Packages,
+		StringIDToNodeInfo: pg.StringIDToNodeInfo,
+		Nodes:              pg.Nodes,
+		NameToVersions:     pg.NameToVersions,
+		ids:                pg.ids,
+		slots:              pg.slots,
+		collisions:         pg.collisions,
+		packageIndex:       pg.packageIndex,
+		ecosystems:         pg.ecosystems,
+		isMaven:            pg.isMaven,
+		options:            &options,
+		constraints:        pg.constraints,
+		versions:           pg.versions,
+		names:              pg.names,
+		matcher:            pg.matcher,
+		split:              true,
+	}
+}
@@ -0,0 +1,104 @@
+package graph
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSplitByClass(t *testing.T) {
+	classes := WithDependencyClasses(Runtime, Peer)
+
+	t.Run("Builds the graph of every class like a build with only that class", func(t *testing.T) {
+		for _, workers := range []int{1, 3} {
+			packagesInfo := testPackages("weights")
+			pg := NewPackageGraph(&packagesInfo, false, classes, WithSplitByClass(), WithWorkers(workers))
+			if len(pg.ClassGraphs()) != 2 {
+				t.Fatalf("Expected 2 class graphs, got %d", len(pg.ClassGraphs()))
+			}
+			for class, classGraph := range pg.ClassGraphs() {
+				packagesInfo := testPackages("weights")
+				expected := NewPackageGraph(&packagesInfo, false, WithDependencyClasses(class))
+				if edges := edgeSet(classGraph); !reflect.DeepEqual(edges, edgeSet(expected)) {
+					t.Errorf("Expected the %s edges %v, got %v", class, edgeSet(expected), edges)
+				}
+				if stats := classGraph.Stats(); stats != expected.Stats() {
+					t.Errorf("Expected the %s stats %+v, got %+v", class, expected.Stats(), stats)
+				}
+			}
+		}
+	})
+
+	t.Run("Builds the graph of all classes as without the option", func(t *testing.T) {
+		packagesInfo := testPackages("weights")
+		pg := NewPackageGraph(&packagesInfo, false, classes, WithSplitByClass())
+		packagesInfo = testPackages("weights")
+		expected := NewPackageGraph(&packagesInfo, false, classes)
+		if edges := edgeSet(pg); !reflect.DeepEqual(edges, edgeSet(expected)) {
+			t.Errorf("Expected %v, got %v", edgeSet(expected), edges)
+		}
+		if weights := weightSet(pg); !reflect.DeepEqual(weights, weightSet(expected)) {
+			t.Errorf("Expected the weights %v, got %v", weightSet(expected), weights)
+		}
+	})
+
+	t.Run("Shares the node side", func(t *testing.T) {
+		packagesInfo := testPackages("weights")
+		pg := NewPackageGraph(&packagesInfo, false, classes, WithSplitByClass())
+		peer := pg.ClassGraphs()[Peer]
+		if &peer.Nodes[0] != &pg.Nodes[0] || peer.Packages != pg.Packages {
+			t.Error("Expected the nodes and packages to be shared")
+		}
+		if reflect.ValueOf(peer.ids).Pointer() != reflect.ValueOf(pg.ids).Pointer() || reflect.ValueOf(peer.versions).Pointer() != reflect.ValueOf(pg.versions).Pointer() {
+			t.Error("Expected the lookup maps to be shared")
+		}
+		dependencies, _ := peer.Dependencies(NameVersion{Name: "App", Version: "1.0.0"}, 1)
+		if len(dependencies) != 2 {
+			t.Errorf("Expected App 1.0.0 to have 2 peer dependencies, got %v", dependencies)
+		}
+	})
+
+	t.Run("Rejects incremental changes", func(t *testing.T) {
+		packagesInfo := testPackages("weights")
+		pg := NewPackageGraph(&packagesInfo, false, classes, WithSplitByClass())
+		for _, target := range []*PackageGraph{pg, pg.ClassGraphs()[Runtime]} {
+			if err := target.AddVersion("Util", "1.1.0", VersionInfo{Timestamp: "2021-02-01T00:00:00"}); !errors.Is(err, ErrSplitGraph) {
+				t.Errorf("Expected ErrSplitGraph, got %v", err)
+			}
+			if err := target.RemovePackage("Util", false); !errors.Is(err, ErrSplitGraph) {
+				t.Errorf("Expected ErrSplitGraph, got %v", err)
+			}
+		}
+	})
+
+	t.Run("Builds the class graphs of lazy and external graphs once", func(t *testing.T) {
+		for _, opt := range []Option{WithLazyEdges(), WithExternalEdges(t.TempDir(), 0)} {
+			packagesInfo := testPackages("weights")
+			pg := NewPackageGraph(&packagesInfo, false, classes, WithSplitByClass(), opt)
+			classGraphs := pg.ClassGraphs()
+			if len(classGraphs) != 2 {
+				t.Fatalf("Expected 2 class graphs, got %d", len(classGraphs))
+			}
+			if again := pg.ClassGraphs(); reflect.ValueOf(again).Pointer() != reflect.ValueOf(classGraphs).Pointer() {
+				t.Error("Expected the class graphs to be built once")
+			}
+			for class, classGraph := range classGraphs {
+				packagesInfo := testPackages("weights")
+				expected := NewPackageGraph(&packagesInfo, false, WithDependencyClasses(class))
+				if edges := edgeSet(classGraph); !reflect.DeepEqual(edges, edgeSet(expected)) {
+					t.Errorf("Expected the %s edges %v, got %v", class, edgeSet(expected), edges)
+				}
+			}
+			if err := pg.AddVersion("Util", "1.1.0", VersionInfo{Timestamp: "2021-02-01T00:00:00"}); !errors.Is(err, ErrSplitGraph) {
+				t.Errorf("Expected ErrSplitGraph once the graph is split, got %v", err)
+			}
+		}
+	})
+
+	t.Run("Has no class graphs without the option", func(t *testing.T) {
+		packagesInfo := testPackages("weights")
+		if classGraphs := NewPackageGraph(&packagesInfo, false, classes).ClassGraphs(); classGraphs != nil {
+			t.Errorf("Expected no class graphs, got %v", classGraphs)
+		}
+	})
+}
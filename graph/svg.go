@@ -0,0 +1,319 @@
+package graph
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// RenderOption configures RenderSVG. It is the same as VisualizationOption, so the options that limit, color, size and
+// highlight the nodes of the DOT output apply to the SVG output as well.
+type RenderOption = VisualizationOption
+
+// The measures of the SVG layout, in pixels. The text is set in a 12 pixel monospace font, whose characters are about
+// 7.2 pixels wide.
+const (
+	svgFontSize   = 12.0
+	svgCharWidth  = 7.2
+	svgLineHeight = 15.0
+	svgPadding    = 8.0
+	svgNodeGap    = 24.0
+	svgLayerGap   = 56.0
+	svgMargin     = 16.0
+	// svgPixelsPerInch converts the node sizes of WithNodeSize, which are in inches like those of GraphViz.
+	svgPixelsPerInch = 72.0
+	// svgSweeps is the number of passes that reorder the layers to reduce the edge crossings.
+	svgSweeps = 8
+)
+
+// svgNode is a node of the SVG layout, with the position of its center.
+type svgNode struct {
+	id            int64
+	lines         []string
+	layer         int
+	x, y          float64
+	width, height float64
+}
+
+// RenderSVG draws the graph as an SVG image without GraphViz, for example for reports on machines where GraphViz
+// cannot be installed. It is meant for small subgraphs of up to a few hundred nodes, such as those extracted by
+// EgoNetwork; the options limit the drawn nodes like those of WriteVisualization, and the center of an ego network is
+// drawn with a double outline.
+//
+// The layout is layered: the dependents are drawn above their dependencies, the versions on a dependency cycle share a
+// layer, and the nodes of every layer are ordered by the mean position of their neighbours to reduce the crossings.
+// It does not match the quality of GraphViz, but the output is deterministic. Of the GraphViz attributes of
+// WithHighlight, color, fillcolor, fontcolor and penwidth are drawn, as are the grayed-out nodes of WithDimOthers.
+func RenderSVG(sub *PackageGraph, w io.Writer, opts ...RenderOption) error {
+	if center, ok := sub.Center(); ok {
+		if info, ok := sub.FindNode(center); ok {
+			opts = append([]RenderOption{withCenter(info.id)}, opts...)
+		}
+	}
+	v, err := newVisualization(sub.Graph, sub.Nodes, sub.FindNode, opts)
+	if err != nil {
+		return err
+	}
+	nodes, edges := layoutSVG(sub.Graph, v)
+	return writeSVG(w, v, nodes, edges)
+}
+
+// layoutSVG positions the nodes of the visualization and returns them by ID, together with the edges between them in
+// (from, to) order.
+func layoutSVG(g *simple.DirectedGraph, v *visualization) (map[int64]*svgNode, [][2]int64) {
+	induced := simple.NewDirectedGraph()
+	for _, id := range v.ids {
+		induced.AddNode(simple.Node(id))
+	}
+	var edges [][2]int64
+	for _, from := range v.ids {
+		for _, to := range sortedNodeIDs(g.From(from)) {
+			if _, ok := v.labels[to]; ok && to != from {
+				induced.SetEdge(simple.Edge{F: simple.Node(from), T: simple.Node(to)})
+				edges = append(edges, [2]int64{from, to})
+			}
+		}
+	}
+
+	// Every component is one layer below the lowest of its dependents, so the layers only depend on the structure
+	// of the graph and not on the order in which the components are found.
+	c := condense(induced)
+	componentLayers := make([]int, c.Len())
+	for component := range componentLayers {
+		for _, predecessor := range c.predecessors[component] {
+			if componentLayers[predecessor]+1 > componentLayers[component] {
+				componentLayers[component] = componentLayers[predecessor] + 1
+			}
+		}
+	}
+	nodes := make(map[int64]*svgNode, len(v.ids))
+	var layers [][]*svgNode
+	for _, id := range v.ids {
+		component, _ := c.Component(id)
+		node := &svgNode{id: id, lines: strings.Split(v.label(id), "\n"), layer: componentLayers[component]}
+		nodes[id] = node
+		for len(layers) <= node.layer {
+			layers = append(layers, nil)
+		}
+		layers[node.layer] = append(layers[node.layer], node)
+	}
+	orderLayers(layers, edges, nodes)
+
+	// The nodes are as large as their labels, or as WithNodeSize makes them.
+	for _, node := range nodes {
+		longest := 0
+		for _, line := range node.lines {
+			if len(line) > longest {
+				longest = len(line)
+			}
+		}
+		node.width = float64(longest)*svgCharWidth + 2*svgPadding
+		node.height = float64(len(node.lines))*svgLineHeight + 2*svgPadding
+		if width, height, ok := v.size(node.id); ok {
+			node.width = math.Max(node.width, width*svgPixelsPerInch)
+			node.height = math.Max(node.height, height*svgPixelsPerInch)
+		}
+	}
+	widest := 0.0
+	layerWidths := make([]float64, len(layers))
+	for i, layer := range layers {
+		for j, node := range layer {
+			if j > 0 {
+				layerWidths[i] += svgNodeGap
+			}
+			layerWidths[i] += node.width
+		}
+		widest = math.Max(widest, layerWidths[i])
+	}
+	top := svgMargin
+	for i, layer := range layers {
+		height := 0.0
+		for _, node := range layer {
+			height = math.Max(height, node.height)
+		}
+		left := svgMargin + (widest-layerWidths[i])/2
+		for _, node := range layer {
+			node.x = left + node.width/2
+			node.y = top + height/2
+			left += node.width + svgNodeGap
+		}
+		top += height + svgLayerGap
+	}
+	return nodes, edges
+}
+
+// orderLayers orders the nodes of every layer by the mean relative position of their neighbours, alternately in the
+// layers above and below, starting from the order by ID.
+func orderLayers(layers [][]*svgNode, edges [][2]int64, nodes map[int64]*svgNode) {
+	neighbours := make(map[int64][]int64)
+	for _, edge := range edges {
+		neighbours[edge[0]] = append(neighbours[edge[0]], edge[1])
+		neighbours[edge[1]] = append(neighbours[edge[1]], edge[0])
+	}
+	// position holds the relative position of every node within its layer, in (0, 1).
+	position := make(map[int64]float64, len(nodes))
+	place := func(layer []*svgNode) {
+		for i, node := range layer {
+			position[node.id] = (float64(i) + 0.5) / float64(len(layer))
+		}
+	}
+	for _, layer := range layers {
+		place(layer)
+	}
+	for sweep := 0; sweep < svgSweeps; sweep++ {
+		down := sweep%2 == 0
+		for step := 1; step < len(layers); step++ {
+			i := step
+			if !down {
+				i = len(layers) - 1 - step
+			}
+			layer := layers[i]
+			barycenter := make(map[int64]float64, len(layer))
+			for _, node := range layer {
+				sum, count := 0.0, 0
+				for _, neighbour := range neighbours[node.id] {
+					if layer := nodes[neighbour].layer; (down && layer < i) || (!down && layer > i) {
+						sum += position[neighbour]
+						count++
+					}
+				}
+				barycenter[node.id] = position[node.id]
+				if count > 0 {
+					barycenter[node.id] = sum / float64(count)
+				}
+			}
+			sort.SliceStable(layer, func(a, b int) bool { return barycenter[layer[a].id] < barycenter[layer[b].id] })
+			place(layer)
+		}
+	}
+}
+
+// writeSVG writes the positioned nodes and the edges between them, with the edges below the nodes.
+func writeSVG(w io.Writer, v *visualization, nodes map[int64]*svgNode, edges [][2]int64) error {
+	width, height := 0.0, 0.0
+	for _, node := range nodes {
+		width = math.Max(width, node.x+node.width/2+svgMargin)
+		height = math.Max(height, node.y+node.height/2+svgMargin)
+	}
+	buffered := bufio.NewWriter(w)
+	fmt.Fprintf(buffered, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%s\" height=\"%s\" viewBox=\"0 0 %s %s\" font-family=\"monospace\" font-size=\"%s\">\n",
+		svgNumber(width), svgNumber(height), svgNumber(width), svgNumber(height), svgNumber(svgFontSize))
+	if v.comment != "" {
+		fmt.Fprintf(buffered, "  <!-- %s -->\n", v.comment)
+	}
+
+	// Every edge color gets its own arrowhead, as a marker does not take the color of the line it ends.
+	type edgeStyle struct {
+		color, width string
+	}
+	styles := make([]edgeStyle, len(edges))
+	markers := make(map[string]int)
+	var colors []string
+	for i, edge := range edges {
+		attrs := v.highlights(edge[0], edge[1])
+		styles[i] = edgeStyle{color: "black", width: "1"}
+		if color, ok := attrs["color"]; ok {
+			styles[i].color = color
+		}
+		if width, ok := attrs["penwidth"]; ok {
+			styles[i].width = width
+		}
+		if _, ok := markers[styles[i].color]; !ok {
+			markers[styles[i].color] = len(colors)
+			colors = append(colors, styles[i].color)
+		}
+	}
+	if len(colors) > 0 {
+		buffered.WriteString("  <defs>\n")
+		for i, color := range colors {
+			fmt.Fprintf(buffered, "    <marker id=\"arrow%d\" viewBox=\"0 0 10 10\" refX=\"10\" refY=\"5\" markerWidth=\"8\" markerHeight=\"8\" orient=\"auto\"><path d=\"M 0 0 L 10 5 L 0 10 z\" fill=\"%s\"/></marker>\n", i, svgEscape(color))
+		}
+		buffered.WriteString("  </defs>\n")
+	}
+
+	for i, edge := range edges {
+		from, to := nodes[edge[0]], nodes[edge[1]]
+		x1, y1 := from.boundary(to.x, to.y)
+		x2, y2 := to.boundary(from.x, from.y)
+		fmt.Fprintf(buffered, "  <line x1=\"%s\" y1=\"%s\" x2=\"%s\" y2=\"%s\" stroke=\"%s\" stroke-width=\"%s\" marker-end=\"url(#arrow%d)\"/>\n",
+			svgNumber(x1), svgNumber(y1), svgNumber(x2), svgNumber(y2), svgEscape(styles[i].color), svgEscape(styles[i].width), markers[styles[i].color])
+	}
+
+	for _, id := range v.ids {
+		node := nodes[id]
+		attrs := v.highlights(id, id)
+		fill, ok := v.fillColor(id)
+		if !ok {
+			fill = defaultNodeColor
+		}
+		if color, ok := attrs["fillcolor"]; ok {
+			fill = color
+		}
+		stroke, strokeWidth, fontColor := "black", "1", "black"
+		if v.isCenter(id) {
+			strokeWidth = "2"
+		}
+		if color, ok := attrs["color"]; ok {
+			stroke = color
+		}
+		if width, ok := attrs["penwidth"]; ok {
+			strokeWidth = width
+		}
+		if color, ok := attrs["fontcolor"]; ok {
+			fontColor = color
+		}
+		left, top := node.x-node.width/2, node.y-node.height/2
+		fmt.Fprintf(buffered, "  <g id=\"n%d\">\n    <title>%s</title>\n", id, svgEscape(v.labels[id][:strings.IndexByte(v.labels[id], '\n')]))
+		if v.isCenter(id) {
+			fmt.Fprintf(buffered, "    <rect x=\"%s\" y=\"%s\" width=\"%s\" height=\"%s\" rx=\"6\" fill=\"none\" stroke=\"%s\" stroke-width=\"%s\"/>\n",
+				svgNumber(left-4), svgNumber(top-4), svgNumber(node.width+8), svgNumber(node.height+8), svgEscape(stroke), svgEscape(strokeWidth))
+		}
+		fmt.Fprintf(buffered, "    <rect x=\"%s\" y=\"%s\" width=\"%s\" height=\"%s\" rx=\"4\" fill=\"%s\" stroke=\"%s\" stroke-width=\"%s\"/>\n",
+			svgNumber(left), svgNumber(top), svgNumber(node.width), svgNumber(node.height), svgEscape(fill), svgEscape(stroke), svgEscape(strokeWidth))
+		// The baseline of the first line is placed so that the lines are centered vertically in the node.
+		baseline := node.y - float64(len(node.lines))*svgLineHeight/2 + svgLineHeight*0.75
+		fmt.Fprintf(buffered, "    <text text-anchor=\"middle\" fill=\"%s\">\n", svgEscape(fontColor))
+		for i, line := range node.lines {
+			fmt.Fprintf(buffered, "      <tspan x=\"%s\" y=\"%s\">%s</tspan>\n", svgNumber(node.x), svgNumber(baseline+float64(i)*svgLineHeight), svgEscape(line))
+		}
+		buffered.WriteString("    </text>\n  </g>\n")
+	}
+	buffered.WriteString("</svg>\n")
+	// bufio.Writer keeps the first write error and returns it from Flush.
+	return buffered.Flush()
+}
+
+// boundary returns the point at which the line from the center of the node towards (x, y) leaves its rectangle.
+func (node *svgNode) boundary(x, y float64) (float64, float64) {
+	dx, dy := x-node.x, y-node.y
+	if dx == 0 && dy == 0 {
+		return node.x, node.y
+	}
+	t := math.Inf(1)
+	if dx != 0 {
+		t = math.Min(t, node.width/2/math.Abs(dx))
+	}
+	if dy != 0 {
+		t = math.Min(t, node.height/2/math.Abs(dy))
+	}
+	return node.x + t*dx, node.y + t*dy
+}
+
+// svgNumber formats a coordinate with a single decimal, so that the output is the same on every platform.
+func svgNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', 1, 64)
+}
+
+// svgEscape escapes s for use as SVG character data or attribute value.
+func svgEscape(s string) string {
+	var escaped strings.Builder
+	_ = xml.EscapeText(&escaped, []byte(s))
+	return escaped.String()
+}
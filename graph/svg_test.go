@@ -0,0 +1,105 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderSVG(t *testing.T) {
+	packagesInfo := testPackages("render")
+	pg := NewPackageGraph(&packagesInfo, false)
+	inDegrees := make(map[int64]float64)
+	for _, node := range pg.Nodes {
+		inDegrees[node.id] = float64(pg.Graph.To(node.id).Len())
+	}
+	render := func(t *testing.T, sub *PackageGraph, opts ...RenderOption) []byte {
+		var svg bytes.Buffer
+		if err := RenderSVG(sub, &svg, opts...); err != nil {
+			t.Fatal(err)
+		}
+		return svg.Bytes()
+	}
+
+	t.Run("Matches the snapshot", func(t *testing.T) {
+		if pg.Graph.Nodes().Len() != 20 {
+			t.Fatalf("Expected 20 nodes, got %d", pg.Graph.Nodes().Len())
+		}
+		path := []NameVersion{{Name: "Cli", Version: "1.0.0"}, {Name: "App", Version: "1.0.0"}, {Name: "Web", Version: "2.1.0"}}
+		svg := render(t, pg, WithNodeColor("dependents", inDegrees, LinearScale), WithHighlightVersions(path, map[string]string{"color": "red", "penwidth": "2"}))
+		expected, err := os.ReadFile(filepath.Join("testdata", "render.svg"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(svg, expected) {
+			t.Errorf("Output differs from testdata/render.svg.\nExpected:\n%s\nActual:\n%s", expected, svg)
+		}
+	})
+
+	t.Run("Writes well-formed SVG with a node per version and an edge per dependency", func(t *testing.T) {
+		svg := render(t, pg)
+		decoder := xml.NewDecoder(bytes.NewReader(svg))
+		nodes, edges := 0, 0
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				break
+			}
+			if element, ok := token.(xml.StartElement); ok {
+				switch element.Name.Local {
+				case "g":
+					nodes++
+				case "line":
+					edges++
+				}
+			}
+		}
+		if nodes != pg.Graph.Nodes().Len() || edges != pg.Graph.Edges().Len() {
+			t.Errorf("Expected %d nodes and %d edges, got %d and %d", pg.Graph.Nodes().Len(), pg.Graph.Edges().Len(), nodes, edges)
+		}
+	})
+
+	t.Run("Draws the dependents above their dependencies", func(t *testing.T) {
+		v, err := newVisualization(pg.Graph, pg.Nodes, pg.FindNode, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes, edges := layoutSVG(pg.Graph, v)
+		util, _ := pg.FindNode(NameVersion{Name: "Util", Version: "1.0.0"})
+		core, _ := pg.FindNode(NameVersion{Name: "Core", Version: "1.0.0"})
+		for _, edge := range edges {
+			from, to := nodes[edge[0]], nodes[edge[1]]
+			inCycle := (edge[0] == util.id || edge[0] == core.id) && (edge[1] == util.id || edge[1] == core.id)
+			if inCycle && from.y != to.y {
+				t.Errorf("Expected the cycle between Util and Core in a single layer")
+			}
+			if !inCycle && from.y >= to.y {
+				t.Errorf("Expected %s above %s", pg.Nodes[edge[0]], pg.Nodes[edge[1]])
+			}
+		}
+	})
+
+	t.Run("Draws the center of an ego network with a double outline", func(t *testing.T) {
+		ego, err := EgoNetwork(pg, NameVersion{Name: "App", Version: "1.0.0"}, 1, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		svg := string(render(t, ego))
+		if strings.Count(svg, `fill="none"`) != 1 {
+			t.Errorf("Expected a single outer outline, got %s", svg)
+		}
+	})
+
+	t.Run("Limits the nodes like the DOT output", func(t *testing.T) {
+		svg := string(render(t, pg, WithMaxNodes(5, NameVersion{Name: "App", Version: "1.0.0"})))
+		if nodes := strings.Count(svg, "<g id="); nodes != 5 {
+			t.Errorf("Expected 5 nodes, got %d", nodes)
+		}
+		if !strings.Contains(svg, "<!-- 15 nodes and") {
+			t.Errorf("Expected a comment on the omitted nodes, got %s", svg)
+		}
+	})
+}
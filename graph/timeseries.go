@@ -0,0 +1,102 @@
+package graph
+
+import (
+	"time"
+)
+
+// Interval is the width of the buckets of a time series. The zero value buckets by calendar month.
+type Interval struct {
+	duration time.Duration
+}
+
+// Monthly buckets a time series by calendar month, in UTC.
+func Monthly() Interval {
+	return Interval{}
+}
+
+// Every buckets a time series into consecutive intervals of the given duration, aligned to the zero time. Like
+// time.NewTicker, it panics if the duration is not positive.
+func Every(duration time.Duration) Interval {
+	if duration <= 0 {
+		panic("graph: non-positive interval for Every")
+	}
+	return Interval{duration: duration}
+}
+
+// start returns the start of the bucket containing t.
+func (interval Interval) start(t time.Time) time.Time {
+	t = t.UTC()
+	if interval.duration == 0 {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return t.Truncate(interval.duration)
+}
+
+// next returns the start of the bucket following the one that starts at start.
+func (interval Interval) next(start time.Time) time.Time {
+	if interval.duration == 0 {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.Add(interval.duration)
+}
+
+// TimePoint is one bucket of a dependents time series.
+type TimePoint struct {
+	// Time is the start of the bucket.
+	Time time.Time
+	// Dependents is the number of distinct packages that had released a version depending on the target by the end of
+	// the bucket.
+	Dependents int
+	// New is the number of those packages whose first version depending on the target was released in the bucket.
+	New int
+}
+
+// DependentTimeSeries counts how many distinct packages depended on any version of the target package over time. A
+// dependency "appears" when the first version of the dependent package that declares it as a runtime dependency is
+// released. The series covers every bucket from the first to the last appearance, including empty buckets.
+//
+// Versions whose timestamp cannot be parsed are left out; the number of dependency declarations on the target that
+// were skipped because of this is returned separately.
+func DependentTimeSeries(packages []PackageInfo, target string, interval Interval) (points []TimePoint, unparseable int) {
+	firstDependency := make(map[PackageKey]time.Time)
+	for _, packageInfo := range packages {
+		if packageInfo.Name == target {
+			continue
+		}
+		for _, versionInfo := range packageInfo.Versions {
+			if _, ok := versionInfo.Dependencies[target]; !ok {
+				continue
+			}
+			released, err := ParseTimestamp(versionInfo.Timestamp)
+			if err != nil {
+				unparseable++
+				continue
+			}
+			if first, ok := firstDependency[packageInfo.key()]; !ok || released.Before(first) {
+				firstDependency[packageInfo.key()] = released
+			}
+		}
+	}
+	if len(firstDependency) == 0 {
+		return nil, unparseable
+	}
+
+	newPerBucket := make(map[time.Time]int)
+	var first, last time.Time
+	for _, released := range firstDependency {
+		bucket := interval.start(released)
+		newPerBucket[bucket]++
+		if first.IsZero() || bucket.Before(first) {
+			first = bucket
+		}
+		if bucket.After(last) {
+			last = bucket
+		}
+	}
+	dependents := 0
+	for bucket := first; !bucket.After(last); bucket = interval.next(bucket) {
+		dependents += newPerBucket[bucket]
+		points = append(points, TimePoint{Time: bucket, Dependents: dependents, New: newPerBucket[bucket]})
+	}
+	return points, unparseable
+}
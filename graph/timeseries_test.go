@@ -0,0 +1,65 @@
+package graph
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDependentTimeSeries(t *testing.T) {
+	packagesInfo := []PackageInfo{
+		{
+			Name: "Early",
+			Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2021-01-05T00:00:00", Dependencies: map[string]string{"Target": "^1.0.0"}},
+				"1.1.0": {Timestamp: "2021-03-05T00:00:00", Dependencies: map[string]string{"Target": "^1.1.0"}},
+			},
+		},
+		{
+			Name: "Late",
+			Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2021-02-01T00:00:00", Dependencies: map[string]string{}},
+				"2.0.0": {Timestamp: "2021-03-31T23:59:59", Dependencies: map[string]string{"Target": "*"}},
+				"3.0.0": {Timestamp: "not a timestamp", Dependencies: map[string]string{"Target": "*"}},
+			},
+		},
+		{
+			Name: "Target",
+			Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{"Target": "*"}},
+			},
+		},
+	}
+
+	t.Run("Counts the distinct dependents per month", func(t *testing.T) {
+		points, unparseable := DependentTimeSeries(packagesInfo, "Target", Monthly())
+		if unparseable != 1 {
+			t.Errorf("Expected 1 unparseable version, got %d", unparseable)
+		}
+		expected := []TimePoint{
+			{Time: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Dependents: 1, New: 1},
+			{Time: time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC), Dependents: 1, New: 0},
+			{Time: time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), Dependents: 2, New: 1},
+		}
+		if len(points) != len(expected) {
+			t.Fatalf("Expected %d points, got %v", len(expected), points)
+		}
+		for i, point := range expected {
+			if !points[i].Time.Equal(point.Time) || points[i].Dependents != point.Dependents || points[i].New != point.New {
+				t.Errorf("Expected %v at position %d, got %v", point, i, points[i])
+			}
+		}
+	})
+
+	t.Run("Supports fixed-width buckets", func(t *testing.T) {
+		points, _ := DependentTimeSeries(packagesInfo, "Target", Every(30*24*time.Hour))
+		if len(points) == 0 || points[len(points)-1].Dependents != 2 {
+			t.Errorf("Expected 2 dependents at the end, got %v", points)
+		}
+	})
+
+	t.Run("Returns no points without dependents", func(t *testing.T) {
+		if points, _ := DependentTimeSeries(packagesInfo, "Early", Monthly()); points != nil {
+			t.Errorf("Expected no points, got %v", points)
+		}
+	})
+}
@@ -0,0 +1,186 @@
+package graph
+
+import (
+	"sync"
+)
+
+// TraceVerdict is what the resolution decided about a single candidate version of a traced dependency declaration.
+type TraceVerdict int
+
+const (
+	// TraceChosen versions satisfy the range and get an edge.
+	TraceChosen TraceVerdict = iota
+	// TraceSatisfied versions satisfy the range, but ResolveHighest chose a higher one.
+	TraceSatisfied
+	// TraceDeprecated versions satisfy the range and are higher than the choice, but were passed over because they
+	// are deprecated, see WithPreferNonDeprecated.
+	TraceDeprecated
+	// TraceOutOfRange versions do not satisfy the range.
+	TraceOutOfRange
+	// TracePrerelease versions are prereleases excluded by the prerelease policy: their release version satisfies the
+	// range, but they do not.
+	TracePrerelease
+	// TraceUnparseable versions cannot be parsed as semver, so no semver range matches them.
+	TraceUnparseable
+	// TraceOutsideTime versions were left out of the graph by the cutoff or the time window, before any range was
+	// resolved.
+	TraceOutsideTime
+)
+
+// String returns the name of the verdict.
+func (verdict TraceVerdict) String() string {
+	switch verdict {
+	case TraceChosen:
+		return "chosen"
+	case TraceSatisfied:
+		return "satisfied"
+	case TraceDeprecated:
+		return "deprecated"
+	case TraceOutOfRange:
+		return "out of range"
+	case TracePrerelease:
+		return "prerelease"
+	case TraceUnparseable:
+		return "unparseable"
+	case TraceOutsideTime:
+		return "outside time"
+	}
+	return "unknown"
+}
+
+// MarshalText encodes the verdict by its name.
+func (verdict TraceVerdict) MarshalText() ([]byte, error) {
+	return []byte(verdict.String()), nil
+}
+
+// TraceCandidate is a version of a traced dependency and the verdict on it.
+type TraceCandidate struct {
+	Version string       `json:"version"`
+	Verdict TraceVerdict `json:"verdict"`
+}
+
+// TraceEvent records how a single dependency declaration was resolved, see WithTrace.
+type TraceEvent struct {
+	Dependent  NameVersion     `json:"dependent"`
+	Class      DependencyClass `json:"class"`
+	Dependency string          `json:"dependency"`
+	Range      string          `json:"range"`
+	// Outcome is how the declaration resolved, such as "resolved", "no satisfying version" or "unknown package", as
+	// counted by the resolution report.
+	Outcome string `json:"outcome"`
+	// Candidates are all the versions of the dependency in version order, followed by the versions left out by the
+	// cutoff or the time window. They are empty if the range could not be parsed or the package is unknown.
+	Candidates []TraceCandidate `json:"candidates"`
+	// Chosen are the versions that the declaration resolved to.
+	Chosen []string `json:"chosen"`
+}
+
+// Tracer records the resolution decisions of the traced dependencies, see WithTrace.
+type Tracer struct {
+	filter func(depName string) bool
+	sink   func(TraceEvent)
+	// mutex is shared with the tracers of the graphs that ReloadFrom builds, so that the sink is never called by both.
+	mutex *sync.Mutex
+	// excluded holds the versions of the traced packages that the cutoff or the time window left out.
+	excluded map[PackageKey][]string
+}
+
+// WithTrace records how the dependency declarations on the packages for which filter returns true are resolved: for
+// every declaration, sink receives the candidate versions with the verdict on each of them and the versions chosen.
+// A nil filter traces every dependency, which is only practical for small graphs. The events are emitted while the
+// edges are created, including by lazy queries and incremental changes, and the calls of sink never overlap, even
+// with several workers, so it does not need to synchronize. The names are the normalized names of the graph.
+func WithTrace(filter func(depName string) bool, sink func(TraceEvent)) Option {
+	return func(options *Options) {
+		options.Trace = &Tracer{filter: filter, sink: sink, mutex: &sync.Mutex{}, excluded: make(map[PackageKey][]string)}
+	}
+}
+
+// rebuild returns a tracer for another build of the graph, which emits to the same sink but records the versions that
+// are left out of that build by itself.
+func (tracer *Tracer) rebuild() *Tracer {
+	if tracer == nil {
+		return nil
+	}
+	return &Tracer{filter: tracer.filter, sink: tracer.sink, mutex: tracer.mutex, excluded: make(map[PackageKey][]string)}
+}
+
+// traces reports whether the declarations on the named package are traced.
+func (tracer *Tracer) traces(name string) bool {
+	return tracer != nil && (tracer.filter == nil || tracer.filter(name))
+}
+
+// exclude records the versions of the package that the time filters left out, if the package is traced.
+func (tracer *Tracer) exclude(key PackageKey, before, after map[string]VersionInfo) {
+	if !tracer.traces(key.FullName()) || len(before) == len(after) {
+		return
+	}
+	for version := range before {
+		if _, kept := after[version]; !kept {
+			tracer.excluded[key] = append(tracer.excluded[key], version)
+		}
+	}
+	sortVersions(tracer.excluded[key])
+}
+
+func (tracer *Tracer) emit(event TraceEvent) {
+	tracer.mutex.Lock()
+	defer tracer.mutex.Unlock()
+	tracer.sink(event)
+}
+
+// trace emits the event of a declaration of the dependent, which resolved to the chosen IDs with the outcome.
+func (r *edgeResolver) trace(dependent NodeInfo, class DependencyClass, dependencyName, dependencyVersion string, chosenIDs []int64, outcome resolutionOutcome) {
+	event := TraceEvent{
+		Dependent:  dependent.NameVersion(),
+		Class:      class,
+		Dependency: dependencyName,
+		Range:      dependencyVersion,
+		Outcome:    outcome.String(),
+	}
+	versions := r.versions[NewPackageKey(dependent.Ecosystem, dependencyName)]
+	chosen := make(map[int64]*indexedVersion, len(chosenIDs))
+	for _, id := range chosenIDs {
+		chosen[id] = nil
+	}
+	for i := range versions {
+		if _, ok := chosen[versions[i].id]; ok {
+			chosen[versions[i].id] = &versions[i]
+			event.Chosen = append(event.Chosen, versions[i].version)
+		}
+	}
+	if outcome == resolved || outcome == unsatisfied {
+		parsedRange, _ := r.matcher.ParseRange(dependencyVersion)
+		for i := range versions {
+			event.Candidates = append(event.Candidates, TraceCandidate{versions[i].version, r.verdict(parsedRange, &versions[i], chosen)})
+		}
+	}
+	for _, version := range r.options.Trace.excluded[NewPackageKey(dependent.Ecosystem, dependencyName)] {
+		event.Candidates = append(event.Candidates, TraceCandidate{version, TraceOutsideTime})
+	}
+	r.options.Trace.emit(event)
+}
+
+// verdict returns the verdict on a candidate of a range that resolved to the chosen versions.
+func (r *edgeResolver) verdict(parsedRange Range, candidate *indexedVersion, chosen map[int64]*indexedVersion) TraceVerdict {
+	if _, ok := chosen[candidate.id]; ok {
+		return TraceChosen
+	}
+	semverRange, isSemver := parsedRange.(versionRange)
+	switch {
+	case matchesIndexed(parsedRange, candidate):
+		for _, choice := range chosen {
+			if choice != nil && candidate.deprecated && !choice.deprecated && compareIndexed(candidate, choice) > 0 {
+				return TraceDeprecated
+			}
+		}
+		return TraceSatisfied
+	case isSemver && candidate.parsed == nil:
+		return TraceUnparseable
+	case isSemver && candidate.parsed.Prerelease() != "":
+		if release, err := candidate.parsed.SetPrerelease(""); err == nil && semverRange.matchesVersion(&release) {
+			return TracePrerelease
+		}
+	}
+	return TraceOutOfRange
+}
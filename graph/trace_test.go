@@ -0,0 +1,105 @@
+package graph
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTrace(t *testing.T) {
+	cutoff := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	traced := func(name string) bool { return name == "Lib" }
+
+	t.Run("Records the verdict on every candidate", func(t *testing.T) {
+		var events []TraceEvent
+		packagesInfo := testPackages("trace")
+		NewPackageGraph(&packagesInfo, false, WithResolution(ResolveHighest), WithPreferNonDeprecated(), WithCutoff(cutoff),
+			WithTrace(traced, func(event TraceEvent) { events = append(events, event) }))
+		if len(events) != 2 {
+			t.Fatalf("Expected 2 events, got %v", events)
+		}
+		if events[0].Dependent.Version != "1.0.0" {
+			events[0], events[1] = events[1], events[0]
+		}
+		expected := TraceEvent{
+			Dependent:  NameVersion{Name: "App", Version: "1.0.0"},
+			Class:      Runtime,
+			Dependency: "Lib",
+			Range:      "^1.0.0",
+			Outcome:    "resolved",
+			Candidates: []TraceCandidate{
+				{"1.0.0", TraceChosen},
+				{"1.1.0", TraceDeprecated},
+				{"1.2.0-rc.1", TracePrerelease},
+				{"2.0.0", TraceOutOfRange},
+				{"1.3.0", TraceOutsideTime},
+			},
+			Chosen: []string{"1.0.0"},
+		}
+		if !reflect.DeepEqual(events[0], expected) {
+			t.Errorf("Expected %+v, got %+v", expected, events[0])
+		}
+		if events[1].Outcome != "no satisfying version" || events[1].Chosen != nil || len(events[1].Candidates) != 5 {
+			t.Errorf("Expected an unsatisfied declaration with 5 candidates, got %+v", events[1])
+		}
+	})
+
+	t.Run("Marks every satisfying version as chosen with ResolveAll", func(t *testing.T) {
+		var events []TraceEvent
+		packagesInfo := testPackages("trace")
+		NewPackageGraph(&packagesInfo, false, WithPrereleases(AlwaysIncludePrereleases),
+			WithTrace(traced, func(event TraceEvent) { events = append(events, event) }))
+		for _, event := range events {
+			if event.Dependent.Version == "1.0.0" {
+				if expected := []string{"1.0.0", "1.1.0", "1.2.0-rc.1", "1.3.0"}; !reflect.DeepEqual(event.Chosen, expected) {
+					t.Errorf("Expected %v to be chosen, got %v", expected, event.Chosen)
+				}
+			}
+		}
+	})
+
+	t.Run("Serializes the events of parallel and lazy builds", func(t *testing.T) {
+		for _, opt := range []Option{WithWorkers(4), WithLazyEdges()} {
+			count := 0
+			packagesInfo := testPackages("trace")
+			pg := NewPackageGraph(&packagesInfo, false, opt, WithTrace(nil, func(TraceEvent) { count++ }))
+			pg.Dependencies(NameVersion{Name: "App", Version: "1.0.0"}, -1)
+			pg.Dependencies(NameVersion{Name: "App", Version: "2.0.0"}, -1)
+			if count != 3 {
+				t.Errorf("Expected 3 events, got %d", count)
+			}
+		}
+	})
+
+	t.Run("Records the left out versions of a reloaded graph once", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "packages.json")
+		writePackagesFile(t, path, testPackages("trace"))
+		var events []TraceEvent
+		pg, err := OpenPackageGraph(path, false, WithCutoff(cutoff),
+			WithTrace(traced, func(event TraceEvent) { events = append(events, event) }))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 2; i++ {
+			events = nil
+			if err := pg.ReloadFrom(path); err != nil {
+				t.Fatal(err)
+			}
+			for _, event := range events {
+				outside := 0
+				for _, candidate := range event.Candidates {
+					if candidate.Verdict == TraceOutsideTime {
+						outside++
+					}
+				}
+				if outside != 1 {
+					t.Errorf("Expected 1.3.0 to be left out once after reload %d, got %+v", i+1, event.Candidates)
+				}
+			}
+			if len(events) != 2 {
+				t.Errorf("Expected 2 events after reload %d, got %d", i+1, len(events))
+			}
+		}
+	})
+}
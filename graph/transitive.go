@@ -0,0 +1,83 @@
+package graph
+
+import (
+	"math/bits"
+
+	"gonum.org/v1/gonum/graph"
+)
+
+// rootBatchSize is the number of roots whose reachability is tracked at the same time by TransitiveDependencyCounts.
+// Every component of the condensation holds one bit per root in the batch, so memory use is
+// components * rootBatchSize / 8 bytes.
+const rootBatchSize = 1024
+
+// TransitiveDependencyCounts returns, for each of the roots, the number of distinct package versions it transitively
+// depends on, not counting the root itself. Shared dependencies, for example in diamonds, are counted once. Roots that
+// are not part of the graph are left out of the result.
+//
+// Instead of running one BFS per root, the graph is condensed into its strongly connected components once, and the
+// reachability of a batch of roots is propagated through the condensation in topological order using one bit per root.
+// This costs O(E * R / 64) time for R roots, and the batches bound the memory to rootBatchSize bits per component.
+func TransitiveDependencyCounts(g graph.Directed, roots []int64) map[int64]int {
+	return condense(g).TransitiveDependencyCounts(roots)
+}
+
+// TransitiveDependencyCounts is like the function of the same name, on the graph that was condensed.
+func (c *Condensation) TransitiveDependencyCounts(roots []int64) map[int64]int {
+	counts := make(map[int64]int, len(roots))
+
+	var present []int64
+	for _, root := range roots {
+		if _, ok := c.componentOf[root]; ok {
+			present = append(present, root)
+		}
+	}
+
+	for start := 0; start < len(present); start += rootBatchSize {
+		end := start + rootBatchSize
+		if end > len(present) {
+			end = len(present)
+		}
+		batch := present[start:end]
+		words := (len(batch) + 63) / 64
+		reached := make([]uint64, len(c.members)*words)
+		for i, root := range batch {
+			component := c.componentOf[root]
+			reached[component*words+i/64] |= 1 << (uint(i) % 64)
+		}
+
+		batchCounts := make([]int, len(batch))
+		for component := range c.members {
+			own := reached[component*words : (component+1)*words]
+			empty := true
+			for _, word := range own {
+				if word != 0 {
+					empty = false
+					break
+				}
+			}
+			if empty {
+				continue
+			}
+			for _, successor := range c.successors[component] {
+				successorBits := reached[successor*words : (successor+1)*words]
+				for w := range own {
+					successorBits[w] |= own[w]
+				}
+			}
+			size := len(c.members[component])
+			for w, word := range own {
+				for word != 0 {
+					bit := bits.TrailingZeros64(word)
+					batchCounts[w*64+bit] += size
+					word &= word - 1
+				}
+			}
+		}
+
+		for i, root := range batch {
+			counts[root] = batchCounts[i] - 1
+		}
+	}
+	return counts
+}
@@ -0,0 +1,56 @@
+package graph
+
+import (
+	"testing"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+func TestTransitiveDependencyCounts(t *testing.T) {
+	// Two stacked diamonds 0 -> {1, 2} -> 3 -> {4, 5} -> 6, and a cycle 7 <-> 8 that depends on the bottom diamond.
+	graph := simple.NewDirectedGraph()
+	edges := [][2]int64{{0, 1}, {0, 2}, {1, 3}, {2, 3}, {3, 4}, {3, 5}, {4, 6}, {5, 6}, {7, 8}, {8, 7}, {8, 3}}
+	for _, edge := range edges {
+		graph.SetEdge(simple.Edge{F: simple.Node(edge[0]), T: simple.Node(edge[1])})
+	}
+	graph.AddNode(simple.Node(9))
+
+	t.Run("Counts shared dependencies once", func(t *testing.T) {
+		counts := TransitiveDependencyCounts(graph, []int64{0, 3, 6, 9})
+		expected := map[int64]int{0: 6, 3: 3, 6: 0, 9: 0}
+		for id, count := range expected {
+			if counts[id] != count {
+				t.Errorf("Expected %d transitive dependencies for node %d, got %d", count, id, counts[id])
+			}
+		}
+	})
+
+	t.Run("Counts the other members of a cycle but not the root", func(t *testing.T) {
+		counts := TransitiveDependencyCounts(graph, []int64{7})
+		if counts[7] != 5 {
+			t.Errorf("Expected 5 transitive dependencies for node 7, got %d", counts[7])
+		}
+	})
+
+	t.Run("Agrees with a BFS from every root", func(t *testing.T) {
+		roots := []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+		counts := TransitiveDependencyCounts(graph, roots)
+		for _, root := range roots {
+			expected := 0
+			for _, id := range reachable(graph, root, nil, true) {
+				if id != root {
+					expected++
+				}
+			}
+			if counts[root] != expected {
+				t.Errorf("Expected %d transitive dependencies for node %d, got %d", expected, root, counts[root])
+			}
+		}
+	})
+
+	t.Run("Leaves out roots that are not in the graph", func(t *testing.T) {
+		if _, ok := TransitiveDependencyCounts(graph, []int64{42})[42]; ok {
+			t.Error("Expected no count for a node that is not in the graph")
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package graph
+
+import (
+	"sort"
+
+	"gonum.org/v1/gonum/graph"
+)
+
+// Direction selects the edges that Traverse follows.
+type Direction int
+
+const (
+	// Forward follows the edges from dependents to their dependencies.
+	Forward Direction = iota
+	// Backward follows the edges from dependencies to their dependents.
+	Backward
+	// Both follows the edges in either direction.
+	Both
+)
+
+// TraverseSignal tells Traverse how to continue after visiting a node.
+type TraverseSignal int
+
+const (
+	// Continue visits the neighbours of the node that were not visited yet.
+	Continue TraverseSignal = iota
+	// SkipChildren does not visit the neighbours of the node through it, though they may still be reached through
+	// other nodes.
+	SkipChildren
+	// Stop ends the traversal without visiting any more nodes.
+	Stop
+)
+
+// Traverse runs a breadth-first search from the sources over the edges in the given direction, calling visit once for
+// every node it reaches with the number of edges between the node and the nearest source. The sources are visited
+// first, in the given order and at depth 0, leaving out the duplicates and the IDs that are not part of g. The
+// neighbours of a node are visited in ID order, so the order of the visits only depends on the graph.
+//
+// The neighbours of a node are only read from g after visit returned for it, so the visitor may add the edges of the
+// node first, as the lazy edges of a PackageGraph are added.
+func Traverse(g graph.Directed, sources []int64, dir Direction, visit func(id int64, depth int) TraverseSignal) {
+	type entry struct {
+		id    int64
+		depth int
+	}
+	seen := make(map[int64]bool, len(sources))
+	queue := make([]entry, 0, len(sources))
+	for _, id := range sources {
+		if !seen[id] && g.Node(id) != nil {
+			seen[id] = true
+			queue = append(queue, entry{id, 0})
+		}
+	}
+	for head := 0; head < len(queue); head++ {
+		current := queue[head]
+		switch visit(current.id, current.depth) {
+		case Stop:
+			return
+		case SkipChildren:
+			continue
+		}
+		for _, neighbour := range neighbourIDs(g, current.id, dir) {
+			if !seen[neighbour] {
+				seen[neighbour] = true
+				queue = append(queue, entry{neighbour, current.depth + 1})
+			}
+		}
+	}
+}
+
+// neighbourIDs returns the IDs of the neighbours of the node in the given direction in ascending order. With Both, a
+// node that is both a dependency and a dependent is included twice.
+func neighbourIDs(g graph.Directed, id int64, dir Direction) []int64 {
+	switch dir {
+	case Forward:
+		return sortedNodeIDs(g.From(id))
+	case Backward:
+		return sortedNodeIDs(g.To(id))
+	}
+	neighbours := append(sortedNodeIDs(g.From(id)), sortedNodeIDs(g.To(id))...)
+	sort.Slice(neighbours, func(i, j int) bool { return neighbours[i] < neighbours[j] })
+	return neighbours
+}
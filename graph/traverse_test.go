@@ -0,0 +1,75 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTraverse(t *testing.T) {
+	// 0 -> 1 -> 3, 0 -> 2 -> 3 -> 4, 5 -> 2
+	g, _ := createDepthTestGraph([][2]int64{{0, 1}, {0, 2}, {1, 3}, {2, 3}, {3, 4}, {5, 2}})
+	type visit struct {
+		id    int64
+		depth int
+	}
+	collect := func(sources []int64, dir Direction, signal func(id int64, depth int) TraverseSignal) []visit {
+		var visits []visit
+		Traverse(g, sources, dir, func(id int64, depth int) TraverseSignal {
+			visits = append(visits, visit{id, depth})
+			return signal(id, depth)
+		})
+		return visits
+	}
+	always := func(int64, int) TraverseSignal { return Continue }
+
+	t.Run("Visits every node once in breadth-first order", func(t *testing.T) {
+		expected := []visit{{0, 0}, {1, 1}, {2, 1}, {3, 2}, {4, 3}}
+		if visits := collect([]int64{0}, Forward, always); !reflect.DeepEqual(visits, expected) {
+			t.Errorf("Expected %v, got %v", expected, visits)
+		}
+	})
+
+	t.Run("Starts from several sources", func(t *testing.T) {
+		expected := []visit{{4, 0}, {1, 0}, {3, 1}, {0, 1}, {2, 2}, {5, 3}}
+		if visits := collect([]int64{4, 1, 4, 99}, Backward, always); !reflect.DeepEqual(visits, expected) {
+			t.Errorf("Expected %v, got %v", expected, visits)
+		}
+	})
+
+	t.Run("Follows both directions", func(t *testing.T) {
+		expected := []visit{{1, 0}, {0, 1}, {3, 1}, {2, 2}, {4, 2}, {5, 3}}
+		if visits := collect([]int64{1}, Both, always); !reflect.DeepEqual(visits, expected) {
+			t.Errorf("Expected %v, got %v", expected, visits)
+		}
+	})
+
+	t.Run("Skips the children of a node", func(t *testing.T) {
+		skip := func(id int64, depth int) TraverseSignal {
+			if id == 2 {
+				return SkipChildren
+			}
+			return Continue
+		}
+		expected := []visit{{0, 0}, {1, 1}, {2, 1}, {3, 2}, {4, 3}}
+		if visits := collect([]int64{0}, Forward, skip); !reflect.DeepEqual(visits, expected) {
+			t.Errorf("Expected 3 to be reached through 1, got %v", visits)
+		}
+		expected = []visit{{5, 0}, {2, 1}}
+		if visits := collect([]int64{5}, Forward, skip); !reflect.DeepEqual(visits, expected) {
+			t.Errorf("Expected %v, got %v", expected, visits)
+		}
+	})
+
+	t.Run("Stops early", func(t *testing.T) {
+		stop := func(id int64, depth int) TraverseSignal {
+			if depth == 1 {
+				return Stop
+			}
+			return Continue
+		}
+		expected := []visit{{0, 0}, {1, 1}}
+		if visits := collect([]int64{0}, Forward, stop); !reflect.DeepEqual(visits, expected) {
+			t.Errorf("Expected %v, got %v", expected, visits)
+		}
+	})
+}
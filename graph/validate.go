@@ -0,0 +1,211 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ValidationKind is the kind of inconsistency found by Validate.
+type ValidationKind int
+
+const (
+	// MissingNodeInfo is a node of the graph, or the endpoint of an edge, without node information.
+	MissingNodeInfo ValidationKind = iota
+	// MissingNode is node information, or a version of the packages, without a node in the graph.
+	MissingNode
+	// MismatchedNodeInfo is node information that differs between Nodes and StringIDToNodeInfo, or that is stored
+	// or indexed under another ID or key than its own.
+	MismatchedNodeInfo
+	// DuplicateKey is a name@version that is listed more than once.
+	DuplicateKey
+	// SparseID is an ID below the highest node ID that has no node. Removing versions leaves such gaps.
+	SparseID
+	// ImplausibleTimestamp is a timestamp that parses, but lies before 1990 or in the future.
+	ImplausibleTimestamp
+)
+
+func (kind ValidationKind) String() string {
+	switch kind {
+	case MissingNodeInfo:
+		return "missing node info"
+	case MissingNode:
+		return "missing node"
+	case MismatchedNodeInfo:
+		return "mismatched node info"
+	case DuplicateKey:
+		return "duplicate key"
+	case SparseID:
+		return "sparse id"
+	case ImplausibleTimestamp:
+		return "implausible timestamp"
+	}
+	return "unknown"
+}
+
+// ValidationError describes a single inconsistency in a PackageGraph.
+type ValidationError struct {
+	Kind ValidationKind
+	// ID is the node ID the error is about, or -1 if it is about a key without a node.
+	ID int64
+	// NameVersion is the package version the error is about, if known.
+	NameVersion NameVersion
+	Message     string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Kind, e.Message)
+}
+
+// earliestTimestamp is the earliest timestamp that Validate and AuditInput consider plausible.
+var earliestTimestamp = time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Validate checks that the graph and its lookup structures are consistent: every node and edge endpoint has node
+// information and vice versa, the lookup maps agree, there are no duplicate keys, the IDs are dense and the timestamps
+// that can be parsed are plausible. Self-edges need no check, as the graph type rejects them. The errors are ordered
+// by check and then by ID or key, so the result only depends on the graph. A graph built by NewPackageGraph passes
+// every check; removed versions are reported as SparseID.
+func Validate(pg *PackageGraph) []ValidationError {
+	var errs []ValidationError
+	pg.validate(func(err ValidationError) bool {
+		errs = append(errs, err)
+		return true
+	})
+	return errs
+}
+
+// ValidateStrict runs the checks of Validate and returns the first inconsistency as a *ValidationError, without
+// running the remaining checks, or nil if the graph is consistent.
+func ValidateStrict(pg *PackageGraph) error {
+	var first *ValidationError
+	pg.validate(func(err ValidationError) bool {
+		first = &err
+		return false
+	})
+	if first == nil {
+		return nil
+	}
+	return first
+}
+
+// validate reports every inconsistency to report, stopping as soon as it returns false.
+func (pg *PackageGraph) validate(report func(ValidationError) bool) {
+	fail := func(kind ValidationKind, id int64, nameVersion NameVersion, format string, args ...interface{}) bool {
+		return !report(ValidationError{Kind: kind, ID: id, NameVersion: nameVersion, Message: fmt.Sprintf(format, args...)})
+	}
+
+	graphIDs := sortedNodeIDs(pg.Graph.Nodes())
+	for _, id := range graphIDs {
+		if _, ok := pg.Node(id); !ok && fail(MissingNodeInfo, id, NameVersion{}, "node %d has no node info", id) {
+			return
+		}
+	}
+	for slot, info := range pg.Nodes {
+		if info.stringID == "" {
+			continue
+		}
+		id := info.id
+		nameVersion := info.NameVersion()
+		if stored, ok := pg.slot(id); (!ok || stored != slot) && fail(MismatchedNodeInfo, id, nameVersion, "node info of %s with ID %d is stored at %d", nameVersion, id, slot) {
+			return
+		}
+		if pg.Graph.Node(id) == nil && fail(MissingNode, id, nameVersion, "%s has node info but no node %d", nameVersion, id) {
+			return
+		}
+		if info.stringID != nameVersion.stringID() && fail(MismatchedNodeInfo, id, nameVersion, "%s has the key %q", nameVersion, info.stringID) {
+			return
+		}
+		if indexed, ok := pg.ids[nameVersion]; (!ok || indexed != id) &&
+			fail(MismatchedNodeInfo, id, nameVersion, "%s is not indexed under its ID %d", nameVersion, id) {
+			return
+		}
+		// Versions that share their key with a version of a lower ID are not part of StringIDToNodeInfo.
+		if stored, ok := pg.StringIDToNodeInfo[info.stringID]; (!ok || stored.id == info.id && stored != info) &&
+			fail(MismatchedNodeInfo, id, nameVersion, "%s differs between Nodes and StringIDToNodeInfo", nameVersion) {
+			return
+		}
+	}
+	indexed := make([]NameVersion, 0, len(pg.ids))
+	for nameVersion := range pg.ids {
+		indexed = append(indexed, nameVersion)
+	}
+	sort.Slice(indexed, func(i, j int) bool { return pg.ids[indexed[i]] < pg.ids[indexed[j]] })
+	for _, nameVersion := range indexed {
+		id := pg.ids[nameVersion]
+		if info, ok := pg.Node(id); (!ok || info.NameVersion() != nameVersion) &&
+			fail(MismatchedNodeInfo, id, nameVersion, "%s is indexed under node %d", nameVersion, id) {
+			return
+		}
+	}
+	for _, key := range sortedKeys(pg.StringIDToNodeInfo) {
+		info := pg.StringIDToNodeInfo[key]
+		if stored, ok := pg.Node(info.id); (!ok || stored != info || key != info.stringID) &&
+			fail(MismatchedNodeInfo, info.id, info.NameVersion(), "StringIDToNodeInfo entry %q does not match node %d", key, info.id) {
+			return
+		}
+	}
+
+	for _, id := range graphIDs {
+		for _, to := range sortedNodeIDs(pg.DependencyGraph().From(id)) {
+			if _, ok := pg.Node(to); !ok && fail(MissingNodeInfo, to, NameVersion{}, "edge %d -> %d ends at a node without node info", id, to) {
+				return
+			}
+		}
+	}
+
+	for _, key := range sortedPackageKeys(pg.NameToVersions) {
+		seen := make(map[string]bool)
+		for _, version := range pg.NameToVersions[key] {
+			nameVersion := key.version(version)
+			if seen[version] && fail(DuplicateKey, -1, nameVersion, "%s is listed twice in NameToVersions", nameVersion) {
+				return
+			}
+			seen[version] = true
+			if _, ok := pg.ids[nameVersion]; !ok && fail(MissingNode, -1, nameVersion, "%s is listed in NameToVersions but has no node", nameVersion) {
+				return
+			}
+		}
+	}
+	packageKeys := make(map[PackageKey]bool, len(*pg.Packages))
+	for _, packageInfo := range *pg.Packages {
+		if packageKeys[packageInfo.key()] && fail(DuplicateKey, -1, packageInfo.key().version(""), "package %s is listed twice in Packages", packageInfo.key()) {
+			return
+		}
+		packageKeys[packageInfo.key()] = true
+		for _, version := range sortedVersionKeys(packageInfo.Versions) {
+			nameVersion := packageInfo.key().version(version)
+			if _, ok := pg.ids[nameVersion]; !ok && fail(MissingNode, -1, nameVersion, "%s is part of Packages but has no node", nameVersion) {
+				return
+			}
+		}
+	}
+
+	// Only the nodes that are stored at their ID, which are numbered sequentially, leave no gaps.
+	for id, info := range pg.Nodes {
+		if pg.slots == nil && info.stringID == "" && fail(SparseID, int64(id), NameVersion{}, "ID %d below the highest ID %d has no node", id, len(pg.Nodes)-1) {
+			return
+		}
+	}
+
+	latest := time.Now().Add(24 * time.Hour)
+	for _, info := range pg.Nodes {
+		if info.stringID == "" {
+			continue
+		}
+		timestamp, err := ParseTimestamp(info.Timestamp)
+		if err == nil && (timestamp.Before(earliestTimestamp) || timestamp.After(latest)) &&
+			fail(ImplausibleTimestamp, info.id, info.NameVersion(), "%s has the timestamp %s", info.NameVersion(), info.Timestamp) {
+			return
+		}
+	}
+}
+
+// sortedKeys returns the keys of the map in increasing order.
+func sortedKeys(m map[string]NodeInfo) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
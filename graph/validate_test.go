@@ -0,0 +1,74 @@
+package graph
+
+import (
+	"errors"
+	"testing"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+func validationKinds(errs []ValidationError) map[ValidationKind]int {
+	kinds := make(map[ValidationKind]int)
+	for _, err := range errs {
+		kinds[err.Kind]++
+	}
+	return kinds
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("Accepts constructed graphs", func(t *testing.T) {
+		for _, opts := range [][]Option{nil, {WithResolution(ResolveHighest)}, {WithWorkers(4), WithDependencyClasses(Runtime, Development)}} {
+			packagesInfo := testPackages("options")
+			if errs := Validate(NewPackageGraph(&packagesInfo, false, opts...)); len(errs) != 0 {
+				t.Errorf("Expected no errors, got %v", errs)
+			}
+		}
+	})
+
+	t.Run("Accepts incremental changes apart from the gaps they leave", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		pg := NewPackageGraph(&packagesInfo, false)
+		if err := pg.AddVersion("A", "2.0.0", VersionInfo{Timestamp: "2022-06-01T00:00:00"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := pg.RemoveVersion("A", "1.0.0", true); err != nil {
+			t.Fatal(err)
+		}
+		if kinds := validationKinds(Validate(pg)); len(kinds) != 1 || kinds[SparseID] != 1 {
+			t.Errorf("Expected a single sparse ID, got %v", kinds)
+		}
+	})
+
+	t.Run("Reports every inconsistency", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		pg := NewPackageGraph(&packagesInfo, false)
+		app, _ := pg.FindNode(NameVersion{Name: "App", Version: "1.0.0"})
+		test, _ := pg.FindNode(NameVersion{Name: "Test", Version: "1.0.0"})
+		pg.Graph.AddNode(simple.Node(len(pg.Nodes) + 1))
+		delete(pg.StringIDToNodeInfo, test.stringID)
+		pg.Nodes[app.id].Timestamp = "1970-01-01T00:00:00"
+		pg.NameToVersions[NewPackageKey("", "Test")] = append(pg.NameToVersions[NewPackageKey("", "Test")], "1.0.0")
+
+		kinds := validationKinds(Validate(pg))
+		if kinds[MissingNodeInfo] != 1 || kinds[MismatchedNodeInfo] < 2 || kinds[DuplicateKey] != 1 || kinds[ImplausibleTimestamp] != 1 {
+			t.Errorf("Expected every kind of inconsistency, got %v", kinds)
+		}
+	})
+
+	t.Run("Returns the first inconsistency in strict mode", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		pg := NewPackageGraph(&packagesInfo, false)
+		if err := ValidateStrict(pg); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+		future := pg.Nodes[0]
+		future.Timestamp = "2999-01-01T00:00:00"
+		pg.Nodes[0] = future
+		pg.StringIDToNodeInfo[future.stringID] = future
+		delete(pg.StringIDToNodeInfo, pg.Nodes[1].stringID)
+		var validationError *ValidationError
+		if err := ValidateStrict(pg); !errors.As(err, &validationError) || validationError.Kind != MismatchedNodeInfo || validationError.ID != 1 {
+			t.Errorf("Expected mismatched node info for node 1, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package graph
+
+import (
+	"sort"
+)
+
+// compareVersions compares two version strings in semver order, returning -1, 0 or 1. The versions are normalized
+// first, truncating four-part versions. Versions that cannot be parsed are ordered after the ones that can, and
+// compared as plain strings among themselves.
+func compareVersions(a, b string) int {
+	versionA, errA := parseVersion(a, true)
+	versionB, errB := parseVersion(b, true)
+	switch {
+	case errA == nil && errB == nil:
+		return versionA.Compare(versionB)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// sortVersions sorts the version strings in increasing semver order. Versions that compare as equal, such as 1.0 and
+// 1.0.0, are ordered as plain strings, so that the order does not depend on the order they were given in.
+func sortVersions(versions []string) {
+	sort.Slice(versions, func(i, j int) bool {
+		return versionLess(versions[i], versions[j])
+	})
+}
+
+func versionLess(a, b string) bool {
+	if order := compareVersions(a, b); order != 0 {
+		return order < 0
+	}
+	return a < b
+}
+
+// insertVersion inserts the version into the versions, which are sorted like sortVersions, at its place.
+func insertVersion(versions []string, version string) []string {
+	i := sort.Search(len(versions), func(i int) bool { return !versionLess(versions[i], version) })
+	versions = append(versions, "")
+	copy(versions[i+1:], versions[i:])
+	versions[i] = version
+	return versions
+}
+
+// sortedVersionKeys returns the versions of a package in increasing semver order.
+func sortedVersionKeys(versions map[string]VersionInfo) []string {
+	keys := make([]string, 0, len(versions))
+	for version := range versions {
+		keys = append(keys, version)
+	}
+	sortVersions(keys)
+	return keys
+}
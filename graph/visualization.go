@@ -0,0 +1,568 @@
+package graph
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// Visualization writes the graph to name.dot so it can be visualized with GraphViz. This includes only IDs.
+func Visualization(graph *simple.DirectedGraph, name string) error {
+	return writeDotFile(name, func(w io.Writer) error {
+		return WriteVisualization(w, graph, name)
+	})
+}
+
+// VisualizationNodeInfo writes the graph to name.dot like Visualization, labelling every node with its name, version
+// and timestamp. The options limit the nodes that are written.
+//
+// Deprecated: versions that share an ambiguous "name-version" key with another version are missing from the map, see
+// CreateStringIDToNodeInfoMap. Use PackageGraph.Visualization instead.
+func VisualizationNodeInfo(iDToNodeInfo *map[string]NodeInfo, graph *simple.DirectedGraph, name string, opts ...VisualizationOption) error {
+	return writeDotFile(name, func(w io.Writer) error {
+		return WriteVisualizationNodeInfo(w, *iDToNodeInfo, graph, name, opts...)
+	})
+}
+
+// Visualization writes the graph to name.dot like VisualizationNodeInfo, labelling every version of the graph.
+func (pg *PackageGraph) Visualization(name string, opts ...VisualizationOption) error {
+	return writeDotFile(name, func(w io.Writer) error {
+		return pg.WriteVisualization(w, name, opts...)
+	})
+}
+
+// WriteVisualization writes the graph like WriteVisualizationNodeInfo, labelling every version of the graph. The center
+// of a graph extracted by EgoNetwork is drawn with a bold double outline.
+func (pg *PackageGraph) WriteVisualization(w io.Writer, name string, opts ...VisualizationOption) error {
+	if center, ok := pg.Center(); ok {
+		if info, ok := pg.FindNode(center); ok {
+			opts = append([]VisualizationOption{withCenter(info.id)}, opts...)
+		}
+	}
+	return writeVisualizationNodes(w, pg.Graph, name, pg.Nodes, pg.FindNode, opts)
+}
+
+// WriteVisualization writes the graph in the DOT language, with nodes sorted by ID and edges by their (from, to) IDs,
+// so the output for the same graph is always identical and can be diffed.
+func WriteVisualization(w io.Writer, graph *simple.DirectedGraph, name string) error {
+	return writeDot(w, graph, name, sortedNodeIDs(graph.Nodes()), func(id int64) []string { return nil }, nil, "")
+}
+
+// VisualizationOption limits the nodes written by WriteVisualizationNodeInfo, so that the output of large graphs stays
+// small enough for GraphViz to lay out.
+type VisualizationOption func(*visualizationOptions)
+
+type visualizationOptions struct {
+	maxNodes     int
+	root         NameVersion
+	filter       func(NodeInfo) bool
+	dropIsolated bool
+	color        *nodeMetric
+	size         *nodeMetric
+	// center is the ID of the node that is highlighted, if hasCenter is set.
+	center     int64
+	hasCenter  bool
+	highlights []highlight
+	dimOthers  bool
+}
+
+// highlight is a set of nodes, given by ID or by version, that is drawn with extra attributes.
+type highlight struct {
+	ids      []int64
+	versions []NameVersion
+	// attrs holds the attributes as given, and attributes the same attributes formatted for GraphViz.
+	attrs      map[string]string
+	attributes []string
+}
+
+// WithMaxNodes writes at most maxNodes nodes, chosen breadth-first from root. The search follows edges in both
+// directions, so it includes both the dependencies and the dependents around the root.
+func WithMaxNodes(maxNodes int, root NameVersion) VisualizationOption {
+	return func(options *visualizationOptions) {
+		options.maxNodes = maxNodes
+		options.root = root
+	}
+}
+
+// WithNodeFilter only writes the nodes for which the filter returns true, such as the nodes whose name matches a
+// pattern. The breadth-first search of WithMaxNodes does not pass through the nodes that are filtered out.
+func WithNodeFilter(filter func(NodeInfo) bool) VisualizationOption {
+	return func(options *visualizationOptions) {
+		options.filter = filter
+	}
+}
+
+// withCenter highlights the node with the given ID as the center of the graph.
+func withCenter(id int64) VisualizationOption {
+	return func(options *visualizationOptions) {
+		options.center = id
+		options.hasCenter = true
+	}
+}
+
+// WithHighlight adds the GraphViz attributes to the nodes with the given IDs and to the edges between two of them, such
+// as color "red" and penwidth "2" for the path from a vulnerable package to an application. The attributes come after
+// the others, so they take precedence over the fill color of WithNodeColor. Every highlight applies to the edges
+// within its own nodes, and the IDs that are not written are ignored.
+func WithHighlight(ids []int64, attrs map[string]string) VisualizationOption {
+	return func(options *visualizationOptions) {
+		highlight := newHighlight(attrs)
+		highlight.ids = ids
+		options.highlights = append(options.highlights, highlight)
+	}
+}
+
+// WithHighlightVersions highlights the given versions like WithHighlight. The versions are looked up in the graph that
+// is written, so the same versions can be highlighted in a graph extracted by EgoNetwork, which has other IDs.
+func WithHighlightVersions(versions []NameVersion, attrs map[string]string) VisualizationOption {
+	return func(options *visualizationOptions) {
+		highlight := newHighlight(attrs)
+		highlight.versions = versions
+		options.highlights = append(options.highlights, highlight)
+	}
+}
+
+// WithDimOthers grays out the nodes and edges that are not part of any highlight of WithHighlight or
+// WithHighlightVersions, so that the highlighted ones stand out in a large graph. The center of an ego network is not
+// dimmed.
+func WithDimOthers() VisualizationOption {
+	return func(options *visualizationOptions) {
+		options.dimOthers = true
+	}
+}
+
+// dimmedAttributes are the attributes of the nodes and edges that WithDimOthers grays out, and dimmedDOTAttributes
+// the same attributes formatted for GraphViz.
+var (
+	dimmedAttributes    = map[string]string{"color": "gray70", "fontcolor": "gray70"}
+	dimmedDOTAttributes = dotAttributes(dimmedAttributes)
+)
+
+// newHighlight returns a highlight with a copy of the attributes, for no nodes yet.
+func newHighlight(attrs map[string]string) highlight {
+	copied := make(map[string]string, len(attrs))
+	for name, value := range attrs {
+		copied[name] = value
+	}
+	return highlight{attrs: copied, attributes: dotAttributes(attrs)}
+}
+
+// dotAttributes formats the attributes as quoted GraphViz attributes, sorted by name.
+func dotAttributes(attrs map[string]string) []string {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	attributes := make([]string, 0, len(names))
+	for _, name := range names {
+		attributes = append(attributes, name+"="+strconv.Quote(attrs[name]))
+	}
+	return attributes
+}
+
+// WithoutIsolatedNodes leaves out the nodes that have no edges to the other written nodes.
+func WithoutIsolatedNodes() VisualizationOption {
+	return func(options *visualizationOptions) {
+		options.dropIsolated = true
+	}
+}
+
+// ScaleFunc maps a metric value onto [0, 1], given the smallest and largest value among the written nodes.
+type ScaleFunc func(value, min, max float64) float64
+
+// LinearScale maps the values linearly, with min at 0 and max at 1.
+func LinearScale(value, min, max float64) float64 {
+	if max <= min {
+		return 0
+	}
+	return (value - min) / (max - min)
+}
+
+// LogScale maps the logarithms of the values linearly, which separates the many small values of heavy-tailed metrics
+// such as the in-degree. The values are shifted so that min maps to 0, so it also works for zero values.
+func LogScale(value, min, max float64) float64 {
+	return LinearScale(math.Log1p(value-min), 0, math.Log1p(max-min))
+}
+
+// nodeColorPalette is a sequential palette from light yellow to dark red (ColorBrewer YlOrRd), used for the buckets of
+// the color metric.
+var nodeColorPalette = []string{"#ffffb2", "#fecc5c", "#fd8d3c", "#f03b20", "#bd0026"}
+
+const (
+	defaultNodeColor = "#ffffff"
+	minNodeWidth     = 0.75
+	maxNodeWidth     = 3.0
+	minNodeHeight    = 0.5
+	maxNodeHeight    = 2.0
+)
+
+// nodeMetric is a metric that is encoded visually, together with its range among the written nodes.
+type nodeMetric struct {
+	name     string
+	values   map[int64]float64
+	scale    ScaleFunc
+	min, max float64
+}
+
+// WithNodeColor fills every node with a color from a small palette, bucketed by the scaled value of the metric, so
+// that for example nodes with a high in-degree stand out. The metric value is added to the label under name. Nodes
+// missing from values are filled white.
+func WithNodeColor(name string, values map[int64]float64, scale ScaleFunc) VisualizationOption {
+	return func(options *visualizationOptions) {
+		options.color = &nodeMetric{name: name, values: values, scale: scale}
+	}
+}
+
+// WithNodeSize scales the width and height of every node by the scaled value of the metric. The metric value is added
+// to the label under name. Nodes missing from values get the GraphViz default size.
+func WithNodeSize(name string, values map[int64]float64, scale ScaleFunc) VisualizationOption {
+	return func(options *visualizationOptions) {
+		options.size = &nodeMetric{name: name, values: values, scale: scale}
+	}
+}
+
+// prepare computes the range of the metric over the given nodes.
+func (metric *nodeMetric) prepare(ids []int64) {
+	first := true
+	for _, id := range ids {
+		value, ok := metric.values[id]
+		if !ok {
+			continue
+		}
+		if first || value < metric.min {
+			metric.min = value
+		}
+		if first || value > metric.max {
+			metric.max = value
+		}
+		first = false
+	}
+}
+
+// scaled returns the value of the metric for the node mapped onto [0, 1].
+func (metric *nodeMetric) scaled(id int64) (float64, bool) {
+	value, ok := metric.values[id]
+	if !ok {
+		return 0, false
+	}
+	return math.Max(0, math.Min(1, metric.scale(value, metric.min, metric.max))), true
+}
+
+// WriteVisualizationNodeInfo writes the graph like WriteVisualization, labelling every node with its name, version and
+// timestamp. Nodes in the map that have been removed from the graph are left out. If the options leave out any nodes
+// or edges, a comment at the top of the output says how many.
+func WriteVisualizationNodeInfo(w io.Writer, iDToNodeInfo map[string]NodeInfo, graph *simple.DirectedGraph, name string, opts ...VisualizationOption) error {
+	nodes := make([]NodeInfo, 0, len(iDToNodeInfo))
+	for _, element := range iDToNodeInfo {
+		nodes = append(nodes, element)
+	}
+	return writeVisualizationNodes(w, graph, name, nodes, lookupMap(iDToNodeInfo), opts)
+}
+
+// writeVisualizationNodes writes the graph with a label for each of the nodes, looking up the root of WithMaxNodes
+// with find. Nodes with an empty stringID are skipped.
+func writeVisualizationNodes(w io.Writer, graph *simple.DirectedGraph, name string, nodes []NodeInfo, find func(NameVersion) (NodeInfo, bool), opts []VisualizationOption) error {
+	v, err := newVisualization(graph, nodes, find, opts)
+	if err != nil {
+		return err
+	}
+	return writeDot(w, graph, name, v.ids, v.dotAttributes, v.dotEdgeAttributes, v.comment)
+}
+
+// visualization holds the nodes that the options select for writing and how they are drawn, which the DOT output and
+// RenderSVG share.
+type visualization struct {
+	options *visualizationOptions
+	// ids holds the written nodes, sorted, and labels their labels without the metrics.
+	ids    []int64
+	labels map[int64]string
+	// comment says how many nodes and edges the options left out, if any.
+	comment     string
+	metrics     []*nodeMetric
+	highlighted []map[int64]bool
+}
+
+// newVisualization selects the nodes to write with the options, looking up the root of WithMaxNodes and the
+// highlighted versions with find.
+func newVisualization(graph *simple.DirectedGraph, nodes []NodeInfo, find func(NameVersion) (NodeInfo, bool), opts []VisualizationOption) (*visualization, error) {
+	options := &visualizationOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	labels := make(map[int64]string, len(nodes))
+	for _, element := range nodes {
+		if element.stringID == "" || graph.Node(element.id) == nil || (options.filter != nil && !options.filter(element)) {
+			continue
+		}
+		labels[element.id] = element.stringID + "\n" + element.Version + "\n" + element.Timestamp
+	}
+	if options.maxNodes > 0 && len(labels) > options.maxNodes {
+		root, ok := find(options.root)
+		if _, included := labels[root.id]; !ok || !included {
+			return nil, fmt.Errorf("root %s is not part of the visualized graph", options.root)
+		}
+		keep := nearestNodes(graph, root.id, options.maxNodes, func(id int64) bool {
+			_, ok := labels[id]
+			return ok
+		})
+		for id := range labels {
+			if !keep[id] {
+				delete(labels, id)
+			}
+		}
+	}
+	if options.dropIsolated {
+		for id := range labels {
+			if !hasEdgeWithin(graph, id, labels) {
+				delete(labels, id)
+			}
+		}
+	}
+
+	v := &visualization{options: options, labels: labels, ids: make([]int64, 0, len(labels))}
+	for id := range labels {
+		v.ids = append(v.ids, id)
+	}
+	sort.Slice(v.ids, func(i, j int) bool { return v.ids[i] < v.ids[j] })
+
+	omittedNodes := graph.Nodes().Len() - len(v.ids)
+	omittedEdges := graph.Edges().Len() - countEdgesWithin(graph, v.ids, labels)
+	if omittedNodes > 0 || omittedEdges > 0 {
+		v.comment = fmt.Sprintf("%d nodes and %d edges were omitted", omittedNodes, omittedEdges)
+	}
+	for _, metric := range []*nodeMetric{options.color, options.size} {
+		if metric != nil {
+			metric.prepare(v.ids)
+			v.metrics = append(v.metrics, metric)
+		}
+	}
+	v.highlighted = make([]map[int64]bool, len(options.highlights))
+	for i, highlight := range options.highlights {
+		v.highlighted[i] = make(map[int64]bool, len(highlight.ids)+len(highlight.versions))
+		for _, id := range highlight.ids {
+			v.highlighted[i][id] = true
+		}
+		for _, version := range highlight.versions {
+			if info, ok := find(version); ok {
+				v.highlighted[i][info.id] = true
+			}
+		}
+	}
+	return v, nil
+}
+
+// label returns the label of the node, followed by the values of the metrics.
+func (v *visualization) label(id int64) string {
+	label := v.labels[id]
+	for _, metric := range v.metrics {
+		if value, ok := metric.values[id]; ok {
+			label += "\n" + metric.name + ": " + strconv.FormatFloat(value, 'g', 4, 64)
+		}
+	}
+	return label
+}
+
+// fillColor returns the fill color of the node for WithNodeColor, and false without it.
+func (v *visualization) fillColor(id int64) (string, bool) {
+	if v.options.color == nil {
+		return "", false
+	}
+	if scaled, ok := v.options.color.scaled(id); ok {
+		return nodeColorPalette[int(math.Min(scaled*float64(len(nodeColorPalette)), float64(len(nodeColorPalette)-1)))], true
+	}
+	return defaultNodeColor, true
+}
+
+// size returns the width and height of the node in inches for WithNodeSize, and false if it has the default size.
+func (v *visualization) size(id int64) (float64, float64, bool) {
+	if v.options.size == nil {
+		return 0, 0, false
+	}
+	scaled, ok := v.options.size.scaled(id)
+	if !ok {
+		return 0, 0, false
+	}
+	return minNodeWidth + scaled*(maxNodeWidth-minNodeWidth), minNodeHeight + scaled*(maxNodeHeight-minNodeHeight), true
+}
+
+// isCenter returns whether the node is the center of an ego network.
+func (v *visualization) isCenter(id int64) bool {
+	return v.options.hasCenter && id == v.options.center
+}
+
+// dimmed reports whether WithDimOthers grays out the edge from one node to another, or the node of dimmed(id, id),
+// because no highlight contains both.
+func (v *visualization) dimmed(from, to int64) bool {
+	if !v.options.dimOthers || (from == to && v.isCenter(from)) {
+		return false
+	}
+	for i := range v.options.highlights {
+		if v.highlighted[i][from] && v.highlighted[i][to] {
+			return false
+		}
+	}
+	return true
+}
+
+// highlights returns the attributes of the highlights that contain both from and to, so highlights(id, id) returns
+// those of a single node. The attributes of later highlights take precedence. The nodes and edges that WithDimOthers
+// grays out get dimmedAttributes, which must not be changed.
+func (v *visualization) highlights(from, to int64) map[string]string {
+	if v.dimmed(from, to) {
+		return dimmedAttributes
+	}
+	var attrs map[string]string
+	for i, highlight := range v.options.highlights {
+		if v.highlighted[i][from] && v.highlighted[i][to] {
+			if attrs == nil {
+				attrs = make(map[string]string)
+			}
+			for name, value := range highlight.attrs {
+				attrs[name] = value
+			}
+		}
+	}
+	return attrs
+}
+
+// dotAttributes returns the GraphViz attributes of the node.
+func (v *visualization) dotAttributes(id int64) []string {
+	attributes := []string{"label=" + strconv.Quote(v.label(id))}
+	if color, ok := v.fillColor(id); ok {
+		attributes = append(attributes, "style=filled", "fillcolor="+strconv.Quote(color))
+	}
+	if width, height, ok := v.size(id); ok {
+		attributes = append(attributes,
+			"width="+strconv.FormatFloat(width, 'f', 2, 64),
+			"height="+strconv.FormatFloat(height, 'f', 2, 64))
+	}
+	if v.isCenter(id) {
+		attributes = append(attributes, "peripheries=2", "penwidth=2")
+	}
+	for i, highlight := range v.options.highlights {
+		if v.highlighted[i][id] {
+			attributes = append(attributes, highlight.attributes...)
+		}
+	}
+	if v.dimmed(id, id) {
+		attributes = append(attributes, dimmedDOTAttributes...)
+	}
+	return attributes
+}
+
+// dotEdgeAttributes returns the GraphViz attributes of the highlighted and the dimmed edges.
+func (v *visualization) dotEdgeAttributes(from, to int64) []string {
+	if v.dimmed(from, to) {
+		return dimmedDOTAttributes
+	}
+	var attributes []string
+	for i, highlight := range v.options.highlights {
+		if v.highlighted[i][from] && v.highlighted[i][to] {
+			attributes = append(attributes, highlight.attributes...)
+		}
+	}
+	return attributes
+}
+
+// nearestNodes returns up to n nodes found by a breadth-first search from root over the edges in both directions,
+// visiting neighbours by ID and only passing through the nodes for which include returns true.
+func nearestNodes(g graph.Directed, root int64, n int, include func(int64) bool) map[int64]bool {
+	visited := make(map[int64]bool)
+	Traverse(g, []int64{root}, Both, func(id int64, depth int) TraverseSignal {
+		if depth > 0 && len(visited) >= n {
+			return Stop
+		}
+		if depth > 0 && !include(id) {
+			return SkipChildren
+		}
+		visited[id] = true
+		return Continue
+	})
+	return visited
+}
+
+func hasEdgeWithin(g graph.Directed, id int64, nodes map[int64]string) bool {
+	for _, neighbours := range []graph.Nodes{g.From(id), g.To(id)} {
+		for neighbours.Next() {
+			if neighbour := neighbours.Node().ID(); neighbour != id {
+				if _, ok := nodes[neighbour]; ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func countEdgesWithin(g graph.Directed, ids []int64, nodes map[int64]string) int {
+	count := 0
+	for _, from := range ids {
+		successors := g.From(from)
+		for successors.Next() {
+			if _, ok := nodes[successors.Node().ID()]; ok {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// writeDot writes the nodes in the given order, with the attributes returned by attributes, followed by the edges
+// between them in (from, to) order, with the attributes returned by edgeAttributes unless it is nil. A non-empty
+// comment is written at the top of the graph.
+func writeDot(w io.Writer, g graph.Directed, name string, ids []int64, attributes func(id int64) []string, edgeAttributes func(from, to int64) []string, comment string) error {
+	buffered := bufio.NewWriter(w)
+	fmt.Fprintf(buffered, "strict digraph %s {\n", strconv.Quote(name))
+	if comment != "" {
+		fmt.Fprintf(buffered, "  // %s\n", comment)
+	}
+	included := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		included[id] = true
+		if list := attributes(id); len(list) > 0 {
+			fmt.Fprintf(buffered, "  %d [%s];\n", id, strings.Join(list, ", "))
+		} else {
+			fmt.Fprintf(buffered, "  %d;\n", id)
+		}
+	}
+	for _, from := range ids {
+		for _, to := range sortedNodeIDs(g.From(from)) {
+			if !included[to] {
+				continue
+			}
+			if edgeAttributes != nil {
+				if list := edgeAttributes(from, to); len(list) > 0 {
+					fmt.Fprintf(buffered, "  %d -> %d [%s];\n", from, to, strings.Join(list, ", "))
+					continue
+				}
+			}
+			fmt.Fprintf(buffered, "  %d -> %d;\n", from, to)
+		}
+	}
+	buffered.WriteString("}\n")
+	// bufio.Writer keeps the first write error and returns it from Flush.
+	return buffered.Flush()
+}
+
+func writeDotFile(name string, write func(io.Writer) error) (err error) {
+	file, err := os.Create(name + ".dot")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+	return write(file)
+}
@@ -0,0 +1,223 @@
+package graph
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestWriteVisualization(t *testing.T) {
+	build := func() *PackageGraph {
+		packagesInfo := testPackages("options")
+		return NewPackageGraph(&packagesInfo, false, WithDependencyClasses(Runtime, Development))
+	}
+
+	t.Run("Writes identical output for two builds of the same input", func(t *testing.T) {
+		var first, second bytes.Buffer
+		pg := build()
+		if err := WriteVisualizationNodeInfo(&first, pg.StringIDToNodeInfo, pg.Graph, "test"); err != nil {
+			t.Fatal(err)
+		}
+		pg = build()
+		if err := WriteVisualizationNodeInfo(&second, pg.StringIDToNodeInfo, pg.Graph, "test"); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(first.Bytes(), second.Bytes()) {
+			t.Errorf("Expected identical output, got\n%s\nand\n%s", first.String(), second.String())
+		}
+	})
+
+	t.Run("Writes the same output with the PackageGraph method", func(t *testing.T) {
+		var expected, got bytes.Buffer
+		pg := build()
+		if err := WriteVisualizationNodeInfo(&expected, pg.StringIDToNodeInfo, pg.Graph, "test"); err != nil {
+			t.Fatal(err)
+		}
+		if err := pg.WriteVisualization(&got, "test"); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(expected.Bytes(), got.Bytes()) {
+			t.Errorf("Expected\n%s\ngot\n%s", expected.String(), got.String())
+		}
+	})
+
+	t.Run("Sorts nodes and edges by ID", func(t *testing.T) {
+		var buffer bytes.Buffer
+		if err := WriteVisualization(&buffer, build().Graph, "test"); err != nil {
+			t.Fatal(err)
+		}
+		expected := "strict digraph \"test\" {\n  0;\n  1;\n  2;\n  3;\n  4;\n  0 -> 1;\n  0 -> 2;\n  0 -> 3;\n  0 -> 4;\n}\n"
+		if buffer.String() != expected {
+			t.Errorf("Expected\n%s\ngot\n%s", expected, buffer.String())
+		}
+	})
+
+	t.Run("Labels nodes with their name, version and timestamp", func(t *testing.T) {
+		var buffer bytes.Buffer
+		pg := build()
+		if err := WriteVisualizationNodeInfo(&buffer, pg.StringIDToNodeInfo, pg.Graph, "test"); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buffer.String(), `0 [label="App-1.0.0\n1.0.0\n2022-04-22T20:15:37"];`) {
+			t.Errorf("Expected a label for App 1.0.0, got\n%s", buffer.String())
+		}
+	})
+}
+
+func TestVisualizationOptions(t *testing.T) {
+	write := func(t *testing.T, opts ...VisualizationOption) string {
+		t.Helper()
+		packagesInfo := testPackages("options")
+		packagesInfo = append(packagesInfo, PackageInfo{
+			Name:     "Lonely",
+			Versions: map[string]VersionInfo{"1.0.0": {Timestamp: "2020-01-01T00:00:00", Dependencies: map[string]string{}}},
+		})
+		pg := NewPackageGraph(&packagesInfo, false, WithDependencyClasses(Runtime, Development))
+		var buffer bytes.Buffer
+		if err := WriteVisualizationNodeInfo(&buffer, pg.StringIDToNodeInfo, pg.Graph, "test", opts...); err != nil {
+			t.Fatal(err)
+		}
+		return buffer.String()
+	}
+
+	t.Run("Truncates breadth-first from the root", func(t *testing.T) {
+		output := write(t, WithMaxNodes(3, NameVersion{Name: "A", Version: "1.0.0"}))
+		if strings.Count(output, "[label=") != 3 {
+			t.Errorf("Expected 3 nodes, got\n%s", output)
+		}
+		if !strings.Contains(output, "// 3 nodes and 2 edges were omitted") {
+			t.Errorf("Expected a comment about the omitted nodes, got\n%s", output)
+		}
+	})
+
+	t.Run("Filters nodes by their information", func(t *testing.T) {
+		output := write(t, WithNodeFilter(func(info NodeInfo) bool { return info.Name != "A" }))
+		if strings.Contains(output, "A-1") || !strings.Contains(output, "App-1.0.0") {
+			t.Errorf("Expected only the versions of A to be left out, got\n%s", output)
+		}
+	})
+
+	t.Run("Drops isolated nodes", func(t *testing.T) {
+		output := write(t, WithoutIsolatedNodes())
+		if strings.Contains(output, "Lonely") || strings.Count(output, "[label=") != 5 {
+			t.Errorf("Expected the isolated node to be left out, got\n%s", output)
+		}
+	})
+
+	t.Run("Rejects an unknown root", func(t *testing.T) {
+		packagesInfo := testPackages("options")
+		pg := NewPackageGraph(&packagesInfo, false)
+		var buffer bytes.Buffer
+		if err := WriteVisualizationNodeInfo(&buffer, pg.StringIDToNodeInfo, pg.Graph, "test", WithMaxNodes(1, NameVersion{Name: "B", Version: "1.0.0"})); err == nil {
+			t.Error("Expected an error for an unknown root")
+		}
+	})
+}
+
+func TestVisualizationMetrics(t *testing.T) {
+	packagesInfo := testPackages("options")
+	pg := NewPackageGraph(&packagesInfo, false, WithDependencyClasses(Runtime, Development))
+	appID := pg.StringIDToNodeInfo["App-1.0.0"].id
+	testID := pg.StringIDToNodeInfo["Test-1.0.0"].id
+
+	var buffer bytes.Buffer
+	err := WriteVisualizationNodeInfo(&buffer, pg.StringIDToNodeInfo, pg.Graph, "test",
+		WithNodeColor("out-degree", OutDegrees(pg.Graph), LinearScale),
+		WithNodeSize("importance", map[int64]float64{appID: 1, testID: 3}, LinearScale),
+		WithNodeFilter(func(info NodeInfo) bool { return info.Version == "1.0.0" }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := buffer.String()
+
+	t.Run("Buckets the color metric into the palette", func(t *testing.T) {
+		if !strings.Contains(output, `0 [label="App-1.0.0\n1.0.0\n2022-04-22T20:15:37\nout-degree: 4\nimportance: 1", style=filled, fillcolor="#bd0026", width=0.75, height=0.50];`) {
+			t.Errorf("Expected App 1.0.0 in the darkest color with the minimum size, got\n%s", output)
+		}
+	})
+
+	t.Run("Scales the size metric", func(t *testing.T) {
+		if !strings.Contains(output, `fillcolor="#ffffb2", width=3.00, height=2.00];`) {
+			t.Errorf("Expected Test 1.0.0 in the lightest color with the maximum size, got\n%s", output)
+		}
+	})
+
+	t.Run("Uses defaults for nodes without a value", func(t *testing.T) {
+		if strings.Count(output, "width=") != 2 {
+			t.Errorf("Expected sizes only for the nodes with a value, got\n%s", output)
+		}
+	})
+
+	t.Run("Maps the logarithm of the values", func(t *testing.T) {
+		if scaled := LogScale(math.E-1, 0, math.E*math.E-1); math.Abs(scaled-0.5) > 1e-9 {
+			t.Errorf("Expected 0.5, got %v", scaled)
+		}
+	})
+}
+
+func TestVisualizationHighlight(t *testing.T) {
+	red := map[string]string{"color": "red", "fontcolor": "red"}
+	path := []NameVersion{{Name: "E", Version: "1.0.0"}, {Name: "C", Version: "1.0.0"}, {Name: "B", Version: "1.0.0"}}
+
+	t.Run("Highlights the nodes and the edges between them", func(t *testing.T) {
+		packagesInfo := testPackages("ego")
+		pg := NewPackageGraph(&packagesInfo, false)
+		ids := []int64{pg.StringIDToNodeInfo["C-1.0.0"].id, pg.StringIDToNodeInfo["B-1.0.0"].id}
+		var buffer bytes.Buffer
+		if err := pg.WriteVisualization(&buffer, "test", WithHighlight(ids, red)); err != nil {
+			t.Fatal(err)
+		}
+		output := buffer.String()
+		if count := strings.Count(output, `color="red", fontcolor="red"]`); count != 3 {
+			t.Errorf("Expected 2 highlighted nodes and 1 highlighted edge, got\n%s", output)
+		}
+		edge := fmt.Sprintf("  %d -> %d [color=\"red\", fontcolor=\"red\"];", ids[0], ids[1])
+		if !strings.Contains(output, edge) {
+			t.Errorf("Expected the edge from C to B to be highlighted, got\n%s", output)
+		}
+	})
+
+	t.Run("Composes with the center of an ego network", func(t *testing.T) {
+		packagesInfo := testPackages("ego")
+		pg := NewPackageGraph(&packagesInfo, false)
+		ego, _ := EgoNetwork(pg, NameVersion{Name: "B", Version: "1.0.0"}, 1, 1)
+		var buffer bytes.Buffer
+		if err := ego.WriteVisualization(&buffer, "ego", WithHighlightVersions(path, red)); err != nil {
+			t.Fatal(err)
+		}
+		output := buffer.String()
+		if !strings.Contains(output, `peripheries=2, penwidth=2, color="red", fontcolor="red"]`) {
+			t.Errorf("Expected the center to be drawn as the center and highlighted, got\n%s", output)
+		}
+		// E is not part of the ego network, so only C and B and the edge between them are highlighted.
+		if count := strings.Count(output, `fontcolor="red"`); count != 3 {
+			t.Errorf("Expected 2 highlighted nodes and 1 highlighted edge, got\n%s", output)
+		}
+	})
+
+	t.Run("Dims the nodes and edges outside the highlights", func(t *testing.T) {
+		packagesInfo := testPackages("ego")
+		pg := NewPackageGraph(&packagesInfo, false)
+		var buffer bytes.Buffer
+		if err := pg.WriteVisualization(&buffer, "test", WithHighlightVersions(path, red), WithDimOthers()); err != nil {
+			t.Fatal(err)
+		}
+		output := buffer.String()
+		highlighted, dimmed := strings.Count(output, `fontcolor="red"`), strings.Count(output, `color="gray70", fontcolor="gray70"`)
+		nodes, edges := pg.Graph.Nodes().Len(), pg.Graph.Edges().Len()
+		// The path E -> C -> B has 3 nodes and 2 edges, and everything else is dimmed.
+		if highlighted != 5 || dimmed != nodes+edges-5 {
+			t.Errorf("Expected 5 highlighted and %d dimmed nodes and edges, got %d and %d in\n%s", nodes+edges-5, highlighted, dimmed, output)
+		}
+
+		buffer.Reset()
+		if err := RenderSVG(pg, &buffer, WithHighlightVersions(path, red), WithDimOthers()); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buffer.String(), `stroke="gray70"`) || !strings.Contains(buffer.String(), `fill="gray70"`) {
+			t.Errorf("Expected dimmed nodes and edges in the SVG, got\n%s", buffer.String())
+		}
+	})
+}
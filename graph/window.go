@@ -0,0 +1,175 @@
+package graph
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+// versionWindow is an interval of versions that contains every version a range can match, so that only the versions
+// within it have to be matched against the range. A nil bound is unbounded.
+type versionWindow struct {
+	lower          *semver.Version
+	upper          *semver.Version
+	upperInclusive bool
+}
+
+// comparatorRegex matches the comparators for which rangeWindow computes bounds: an operator followed by a version
+// with at least a major and a minor number and no wildcards.
+var comparatorRegex = regexp.MustCompile(`^\s*(\^|~>|~|>=|=>|<=|=<|!=|>|<|=)?\s*v?(\d+)\.(\d+)(?:\.(\d+))?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?\s*$`)
+
+// rangeWindow computes the window of a semver range, splitting it like the semver constraint parser does. It returns
+// nil for ranges with other shapes, such as hyphen ranges and wildcards, whose versions are all matched instead. The
+// window of a union of ranges is the smallest window containing all of their windows.
+func rangeWindow(constraint string) *versionWindow {
+	if strings.Contains(constraint, " - ") {
+		return nil
+	}
+	var window *versionWindow
+	for _, alternative := range strings.Split(constraint, "||") {
+		intersection := &versionWindow{}
+		for _, comparator := range strings.Split(alternative, ",") {
+			bounds, ok := comparatorWindow(comparator)
+			if !ok {
+				return nil
+			}
+			intersection.intersect(bounds)
+		}
+		if window == nil {
+			window = intersection
+		} else {
+			window.union(intersection)
+		}
+	}
+	return window
+}
+
+// comparatorWindow computes the window of a single comparator. The lower bound is lowered to the first prerelease of
+// its release version, which keeps the window valid under every prerelease policy.
+func comparatorWindow(comparator string) (versionWindow, bool) {
+	match := comparatorRegex.FindStringSubmatch(comparator)
+	if match == nil {
+		return versionWindow{}, false
+	}
+	major, _ := strconv.ParseInt(match[2], 10, 64)
+	minor, _ := strconv.ParseInt(match[3], 10, 64)
+	patch, _ := strconv.ParseInt(match[4], 10, 64)
+	lower := firstPrerelease(major, minor, patch)
+	version := newRelease(major, minor, patch)
+	if match[5] != "" {
+		withPrerelease, err := version.SetPrerelease(match[5])
+		if err != nil {
+			return versionWindow{}, false
+		}
+		version = &withPrerelease
+	}
+
+	switch match[1] {
+	case "", "=":
+		return versionWindow{lower: lower, upper: version, upperInclusive: true}, true
+	case ">", ">=", "=>":
+		return versionWindow{lower: lower}, true
+	case "<":
+		return versionWindow{upper: version}, true
+	case "<=", "=<":
+		return versionWindow{upper: version, upperInclusive: true}, true
+	case "!=":
+		return versionWindow{}, true
+	case "^":
+		return versionWindow{lower: lower, upper: newRelease(major+1, 0, 0)}, true
+	default:
+		// ~0.0 and ~0.0.0 accept every version, like >=0.0.0.
+		if major == 0 && minor == 0 && patch == 0 {
+			return versionWindow{lower: lower}, true
+		}
+		return versionWindow{lower: lower, upper: newRelease(major, minor+1, 0)}, true
+	}
+}
+
+// firstPrerelease returns the lowest version with the given release version, which is lower than all its prereleases.
+func firstPrerelease(major, minor, patch int64) *semver.Version {
+	version, _ := newRelease(major, minor, patch).SetPrerelease("0")
+	return &version
+}
+
+// intersect narrows the window to the part that is also within other.
+func (window *versionWindow) intersect(other versionWindow) {
+	if other.lower != nil && (window.lower == nil || other.lower.GreaterThan(window.lower)) {
+		window.lower = other.lower
+	}
+	if other.upper != nil {
+		order := 0
+		if window.upper != nil {
+			order = other.upper.Compare(window.upper)
+		}
+		if window.upper == nil || order < 0 || (order == 0 && !other.upperInclusive) {
+			window.upper, window.upperInclusive = other.upper, other.upperInclusive
+		}
+	}
+}
+
+// union widens the window to also contain other.
+func (window *versionWindow) union(other *versionWindow) {
+	if window.lower != nil && (other.lower == nil || other.lower.LessThan(window.lower)) {
+		window.lower = other.lower
+	}
+	if window.upper != nil {
+		order := 0
+		if other.upper != nil {
+			order = other.upper.Compare(window.upper)
+		}
+		if other.upper == nil || order > 0 || (order == 0 && other.upperInclusive) {
+			window.upper, window.upperInclusive = other.upper, other.upperInclusive
+		}
+	}
+}
+
+// within returns the versions within the window, given versions sorted by sortIndexedVersions.
+func (window *versionWindow) within(versions []indexedVersion) []indexedVersion {
+	start := 0
+	if window.lower != nil {
+		start = sort.Search(len(versions), func(i int) bool {
+			return versions[i].parsed == nil || versions[i].parsed.Compare(window.lower) >= 0
+		})
+	}
+	end := sort.Search(len(versions), func(i int) bool {
+		if versions[i].parsed == nil {
+			return true
+		}
+		if window.upper == nil {
+			return false
+		}
+		order := versions[i].parsed.Compare(window.upper)
+		return order > 0 || (order == 0 && !window.upperInclusive)
+	})
+	if end < start {
+		return nil
+	}
+	return versions[start:end]
+}
+
+// sortIndexedVersions sorts the versions in semver order followed by the versions that could not be parsed, with
+// equal versions ordered by node ID.
+func sortIndexedVersions(versions []indexedVersion) {
+	sort.Slice(versions, func(i, j int) bool {
+		return lessIndexed(&versions[i], &versions[j])
+	})
+}
+
+func lessIndexed(a, b *indexedVersion) bool {
+	switch {
+	case a.parsed == nil && b.parsed == nil:
+		return a.id < b.id
+	case a.parsed == nil:
+		return false
+	case b.parsed == nil:
+		return true
+	}
+	if order := a.parsed.Compare(b.parsed); order != 0 {
+		return order < 0
+	}
+	return a.id < b.id
+}
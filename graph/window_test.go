@@ -0,0 +1,139 @@
+package graph
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// linearMatcher hides the windows of the semver ranges, so that every version is matched against every range.
+type linearMatcher struct {
+	matcher SemverMatcher
+}
+
+type linearRange struct {
+	semverRange
+}
+
+func (matcher linearMatcher) ParseRange(dependencyRange string) (Range, error) {
+	parsed, err := matcher.matcher.ParseRange(dependencyRange)
+	if err != nil {
+		return nil, err
+	}
+	return linearRange{parsed.(semverRange)}, nil
+}
+
+func (r linearRange) candidates(versions []indexedVersion) []indexedVersion {
+	return versions
+}
+
+func randomVersion(random *rand.Rand) string {
+	// Equal versions such as "1.0.0" and "v1.0.0" are left out, as the one ResolveHighest picks depends on the node
+	// IDs, which differ between the graphs that are compared.
+	version := fmt.Sprintf("%d.%d.%d", random.Intn(3), random.Intn(3), random.Intn(3))
+	if random.Intn(4) == 0 {
+		version += fmt.Sprintf("-rc.%d", random.Intn(2))
+	}
+	return version
+}
+
+func randomRange(random *rand.Rand) string {
+	comparator := func() string {
+		operators := []string{"", "=", "^", "~", ">", ">=", "<", "<=", "!="}
+		version := fmt.Sprintf("%d.%d", random.Intn(3), random.Intn(3))
+		if random.Intn(2) == 0 {
+			version += fmt.Sprintf(".%d", random.Intn(3))
+		}
+		if random.Intn(5) == 0 {
+			version += "-rc.0"
+		}
+		return operators[random.Intn(len(operators))] + version
+	}
+	switch random.Intn(6) {
+	case 0:
+		return comparator() + ", " + comparator()
+	case 1:
+		return comparator() + " || " + comparator()
+	case 2:
+		// Shapes without a window.
+		return []string{"1.x", "*", "1.0.0 - 2.0.0", "^1"}[random.Intn(4)]
+	}
+	return comparator()
+}
+
+func TestRangeWindows(t *testing.T) {
+	t.Run("Creates the same edges as matching every version", func(t *testing.T) {
+		random := rand.New(rand.NewSource(1))
+		policies := []PrereleasePolicy{ExcludePrereleases, IncludeIfRangeHasPrerelease, AlwaysIncludePrereleases}
+		for i := 0; i < 100; i++ {
+			dependency := PackageInfo{Name: "Dependency", Versions: make(map[string]VersionInfo)}
+			for j := 0; j < 15; j++ {
+				dependency.Versions[randomVersion(random)] = VersionInfo{Timestamp: "2020-01-01T00:00:00"}
+			}
+			packagesInfo := []PackageInfo{dependency}
+			for j := 0; j < 30; j++ {
+				packagesInfo = append(packagesInfo, PackageInfo{
+					Name: fmt.Sprintf("Dependent%d", j),
+					Versions: map[string]VersionInfo{
+						"1.0.0": {Timestamp: "2021-01-01T00:00:00", Dependencies: map[string]string{"Dependency": randomRange(random)}},
+					},
+				})
+			}
+			for _, policy := range policies {
+				for _, resolution := range []Resolution{ResolveAll, ResolveHighest} {
+					matcher := SemverMatcher{Prereleases: policy}
+					windowed := NewPackageGraph(&packagesInfo, false, WithRangeMatcher(matcher), WithResolution(resolution))
+					linear := NewPackageGraph(&packagesInfo, false, WithRangeMatcher(linearMatcher{matcher}), WithResolution(resolution))
+					if expected, got := edgeSet(linear), edgeSet(windowed); len(expected) != len(got) {
+						t.Fatalf("Expected %d edges, got %d for %+v", len(expected), len(got), packagesInfo)
+					} else {
+						for edge := range expected {
+							if !got[edge] {
+								t.Fatalf("Expected edge %v for %+v", edge, packagesInfo)
+							}
+						}
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("Finds the versions within a caret range", func(t *testing.T) {
+		versions := []indexedVersion{}
+		for i, version := range []string{"0.9.0", "1.0.0-rc.1", "1.0.0", "1.5.0", "2.0.0-rc.1", "2.0.0", "latest"} {
+			parsed, _ := parseVersion(version, false)
+			versions = append(versions, indexedVersion{version: version, parsed: parsed, id: int64(i)})
+		}
+		sortIndexedVersions(versions)
+		within := rangeWindow("^1.0.0").within(versions)
+		if len(within) != 4 || within[0].version != "1.0.0-rc.1" || within[3].version != "2.0.0-rc.1" {
+			t.Errorf("Expected 1.0.0-rc.1 to 2.0.0-rc.1, got %v", within)
+		}
+	})
+
+	t.Run("Has no window for wildcards", func(t *testing.T) {
+		for _, constraint := range []string{"1.x", "*", "^1", "1.0.0 - 2.0.0"} {
+			if window := rangeWindow(constraint); window != nil {
+				t.Errorf("Expected no window for %s, got %+v", constraint, window)
+			}
+		}
+	})
+}
+
+func BenchmarkRangeWindows(b *testing.B) {
+	// The popular package gets ten times the versions, so that matching every version of it takes noticeably longer.
+	packagesInfo := testPackages("popular")
+	for i := 50; i < 500; i++ {
+		packagesInfo[0].Versions[fmt.Sprintf("%d.%d.%d", i/100, i/10%10, i%10)] = VersionInfo{Timestamp: "2020-01-01T00:00:00"}
+	}
+	for name, matcher := range map[string]RangeMatcher{
+		"Windowed": SemverMatcher{},
+		"Linear":   linearMatcher{SemverMatcher{}},
+	} {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				NewPackageGraph(&packagesInfo, false, WithRangeMatcher(matcher), WithResolution(ResolveHighest))
+			}
+		})
+	}
+}
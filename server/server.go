@@ -0,0 +1,346 @@
+// Package server exposes a PackageGraph over HTTP, so that a graph is built once and then queried interactively.
+//
+// All endpoints but /query are GET and answer with JSON:
+//
+//	/package/{name}                                      the versions of a package
+//	/package/{name}/{version}/dependencies[?transitive=true]
+//	/package/{name}/{version}/dependents[?transitive=true][&offset=N][&limit=N][&sort=name|timestamp|dependents]
+//	/path?from={name}@{version}&to={name}@{version}      a shortest dependency chain
+//	/impact?op=union|intersection|difference&set={name}@{version},...&set=...
+//	/stats                                               the size of the graph
+//	POST /query {"query": "..."}                         the result of a query expression, see graph.ParseQuery
+//
+// Names containing a slash, such as scoped npm packages, can be given as is or with the slash escaped as %2F. Errors
+// are answered with {"error": "..."} and status 400, 404, 405, 413 or, if a metric of a query fails, 500.
+//
+// Given an offset, a limit or a sort order, the dependents are answered as a page with the total number of dependents,
+// see graph.DependentsPage, sorted by name unless told otherwise and with at most 100 versions unless the limit is
+// given.
+//
+// The impact endpoint combines the transitive dependents of every set of roots, see graph.DependentsSet, from left
+// to right with the operation, which defaults to the union, and answers with the versions and their number.
+//
+// The query endpoint answers with a graph.QueryResult. The error of a query that cannot be parsed or evaluated also
+// holds the byte offset in the query at which the problem is, as {"error": "...", "offset": N}.
+//
+// The handler only reads the graph. Concurrent reads of the gonum graph and of the maps of a PackageGraph are safe,
+// so requests are served in parallel, as long as the graph is not modified while serving. Every request holds the
+// read lock of the graph, so that PackageGraph.ReloadFrom can swap in a new graph between requests.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+type packageResponse struct {
+	Name     string       `json:"name"`
+	Versions []g.NodeInfo `json:"versions"`
+}
+
+type nodesResponse struct {
+	Package g.NodeInfo   `json:"package"`
+	Nodes   []g.NodeInfo `json:"nodes"`
+}
+
+type pageResponse struct {
+	Package g.NodeInfo   `json:"package"`
+	Nodes   []g.NodeInfo `json:"nodes"`
+	Total   int          `json:"total"`
+	Offset  int          `json:"offset"`
+	Limit   int          `json:"limit"`
+}
+
+// defaultPageLimit is the number of dependents in a page whose limit is not given.
+const defaultPageLimit = 100
+
+type impactResponse struct {
+	Nodes []g.NodeInfo `json:"nodes"`
+	Total int          `json:"total"`
+}
+
+type pathResponse struct {
+	Path []g.NodeInfo `json:"path"`
+}
+
+type queryRequest struct {
+	Query string `json:"query"`
+}
+
+// maxQueryBytes is the size of the largest query request body.
+const maxQueryBytes = 1 << 20
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type queryErrorResponse struct {
+	Error  string `json:"error"`
+	Offset int    `json:"offset"`
+}
+
+// Serve listens on addr and answers queries about the graph until the listener fails.
+func Serve(addr string, pg *g.PackageGraph) error {
+	return http.ListenAndServe(addr, NewHandler(pg))
+}
+
+// NewHandler returns the handler serving the endpoints described in the package documentation.
+func NewHandler(pg *g.PackageGraph) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/package/", getOnly(pg, func(w http.ResponseWriter, r *http.Request) {
+		handlePackage(w, r, pg)
+	}))
+	mux.HandleFunc("/path", getOnly(pg, func(w http.ResponseWriter, r *http.Request) {
+		handlePath(w, r, pg)
+	}))
+	mux.HandleFunc("/impact", getOnly(pg, func(w http.ResponseWriter, r *http.Request) {
+		handleImpact(w, r, pg)
+	}))
+	mux.HandleFunc("/stats", getOnly(pg, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, pg.Stats())
+	}))
+	mux.HandleFunc("/query", methodOnly(http.MethodPost, pg, func(w http.ResponseWriter, r *http.Request) {
+		handleQuery(w, r, pg)
+	}))
+	return mux
+}
+
+// getOnly rejects the requests other than GET and answers the others while holding the read lock of the graph.
+func getOnly(pg *g.PackageGraph, handler http.HandlerFunc) http.HandlerFunc {
+	return methodOnly(http.MethodGet, pg, handler)
+}
+
+// methodOnly rejects the requests with another method and answers the others while holding the read lock of the graph.
+func methodOnly(method string, pg *g.PackageGraph, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeError(w, http.StatusMethodNotAllowed, "only "+method+" is supported")
+			return
+		}
+		pg.RLock()
+		defer pg.RUnlock()
+		handler(w, r)
+	}
+}
+
+func handlePackage(w http.ResponseWriter, r *http.Request, pg *g.PackageGraph) {
+	segments, err := pathSegments(strings.TrimPrefix(r.URL.EscapedPath(), "/package/"))
+	if err != nil || len(segments) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid package path")
+		return
+	}
+
+	last := segments[len(segments)-1]
+	if (last == "dependencies" || last == "dependents") && len(segments) >= 3 {
+		nameVersion := g.NameVersion{
+			Name:    strings.Join(segments[:len(segments)-2], "/"),
+			Version: segments[len(segments)-2],
+		}
+		info, ok := pg.FindNode(nameVersion)
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("%s is not part of the graph", nameVersion))
+			return
+		}
+		maxDepth := 1
+		if r.URL.Query().Get("transitive") == "true" {
+			maxDepth = -1
+		}
+		if query := r.URL.Query(); last == "dependents" && (query.Has("offset") || query.Has("limit") || query.Has("sort")) {
+			handleDependentsPage(w, r, pg, info, maxDepth < 0)
+			return
+		}
+		var nodes []g.NodeInfo
+		if last == "dependencies" {
+			nodes, _ = pg.Dependencies(nameVersion, maxDepth)
+		} else {
+			nodes, _ = pg.Dependents(nameVersion, maxDepth)
+		}
+		if nodes == nil {
+			nodes = []g.NodeInfo{}
+		}
+		writeJSON(w, http.StatusOK, nodesResponse{Package: info, Nodes: nodes})
+		return
+	}
+
+	name := strings.Join(segments, "/")
+	versions := pg.Versions(name)
+	if len(versions) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("package %s is not part of the graph", name))
+		return
+	}
+	writeJSON(w, http.StatusOK, packageResponse{Name: name, Versions: versions})
+}
+
+func handleDependentsPage(w http.ResponseWriter, r *http.Request, pg *g.PackageGraph, info g.NodeInfo, transitive bool) {
+	query := r.URL.Query()
+	bounds := map[string]int{"offset": 0, "limit": defaultPageLimit}
+	for _, name := range []string{"offset", "limit"} {
+		if value := query.Get(name); value != "" {
+			bound, err := strconv.Atoi(value)
+			if err != nil || bound < 0 {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid %s %q", name, value))
+				return
+			}
+			bounds[name] = bound
+		}
+	}
+	var order g.SortOrder
+	switch query.Get("sort") {
+	case "", "name":
+		order = g.SortByName
+	case "timestamp":
+		order = g.SortByTimestamp
+	case "dependents":
+		order = g.SortByDependents
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid sort %q, expected name, timestamp or dependents", query.Get("sort")))
+		return
+	}
+	nameVersion := info.NameVersion()
+	nodes, total, err := g.DependentsPage(pg, nameVersion, transitive, bounds["offset"], bounds["limit"], order)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if nodes == nil {
+		nodes = []g.NodeInfo{}
+	}
+	writeJSON(w, http.StatusOK, pageResponse{Package: info, Nodes: nodes, Total: total, Offset: bounds["offset"], Limit: bounds["limit"]})
+}
+
+func handlePath(w http.ResponseWriter, r *http.Request, pg *g.PackageGraph) {
+	query := r.URL.Query()
+	from, err := g.ParseNameVersion(query.Get("from"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "from: "+err.Error())
+		return
+	}
+	to, err := g.ParseNameVersion(query.Get("to"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "to: "+err.Error())
+		return
+	}
+	for _, nameVersion := range []g.NameVersion{from, to} {
+		if _, ok := pg.FindNode(nameVersion); !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("%s is not part of the graph", nameVersion))
+			return
+		}
+	}
+	path, ok := pg.ShortestPath(from, to)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%s does not depend on %s", from, to))
+		return
+	}
+	writeJSON(w, http.StatusOK, pathResponse{Path: path})
+}
+
+func handleImpact(w http.ResponseWriter, r *http.Request, pg *g.PackageGraph) {
+	query := r.URL.Query()
+	var combine func(g.NodeSet, g.NodeSet) g.NodeSet
+	switch query.Get("op") {
+	case "", "union":
+		combine = g.NodeSet.Union
+	case "intersection":
+		combine = g.NodeSet.Intersect
+	case "difference":
+		combine = g.NodeSet.Difference
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid op %q, expected union, intersection or difference", query.Get("op")))
+		return
+	}
+	arguments := query["set"]
+	if len(arguments) == 0 {
+		writeError(w, http.StatusBadRequest, "no set given")
+		return
+	}
+	var result g.NodeSet
+	for i, argument := range arguments {
+		var roots []g.NameVersion
+		for _, root := range strings.Split(argument, ",") {
+			nameVersion, err := g.ParseNameVersion(root)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "set: "+err.Error())
+				return
+			}
+			if _, ok := pg.FindNode(nameVersion); !ok {
+				writeError(w, http.StatusNotFound, fmt.Sprintf("%s is not part of the graph", nameVersion))
+				return
+			}
+			roots = append(roots, nameVersion)
+		}
+		if set := g.DependentsSet(pg, roots); i == 0 {
+			result = set
+		} else {
+			result = combine(result, set)
+		}
+	}
+	nodes := result.NodeInfos()
+	writeJSON(w, http.StatusOK, impactResponse{Nodes: nodes, Total: len(nodes)})
+}
+
+func handleQuery(w http.ResponseWriter, r *http.Request, pg *g.PackageGraph) {
+	var request queryRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxQueryBytes))
+	if err := decoder.Decode(&request); err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "http: request body too large" {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeError(w, status, "invalid query request: "+err.Error())
+		return
+	}
+	query, err := g.ParseQuery(request.Query)
+	var result *g.QueryResult
+	if err == nil {
+		result, err = query.Evaluate(pg)
+	}
+	var queryErr *g.QueryError
+	switch {
+	case errors.As(err, &queryErr):
+		status := http.StatusBadRequest
+		if queryErr.NotFound {
+			status = http.StatusNotFound
+		}
+		writeJSON(w, status, queryErrorResponse{Error: queryErr.Error(), Offset: queryErr.Offset})
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	default:
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// pathSegments splits an escaped path at its slashes and unescapes every segment, so that an escaped slash stays part
+// of its segment.
+func pathSegments(escapedPath string) ([]string, error) {
+	var segments []string
+	for _, segment := range strings.Split(strings.Trim(escapedPath, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, unescaped)
+	}
+	return segments, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	// The status has been sent, so an encoding error can only come from a broken connection.
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
@@ -0,0 +1,222 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+func createServerTestGraph() *g.PackageGraph {
+	packagesInfo := []g.PackageInfo{
+		{
+			Name: "App",
+			Versions: map[string]g.VersionInfo{
+				"1.0.0": {
+					Timestamp:    "2022-04-22T20:15:37",
+					Dependencies: map[string]string{"@scope/lib": "^1.0.0"},
+				},
+			},
+		},
+		{
+			Name: "@scope/lib",
+			Versions: map[string]g.VersionInfo{
+				"1.0.0": {
+					Timestamp:    "2021-04-22T20:15:37",
+					Dependencies: map[string]string{"Leaf": "1.0.0"},
+				},
+				"2.0.0": {
+					Timestamp:    "2021-05-22T20:15:37",
+					Dependencies: map[string]string{},
+				},
+			},
+		},
+		{
+			Name: "Leaf",
+			Versions: map[string]g.VersionInfo{
+				"1.0.0": {
+					Timestamp:    "2020-01-01T00:00:00",
+					Dependencies: map[string]string{},
+				},
+			},
+		},
+	}
+	return g.NewPackageGraph(&packagesInfo, false)
+}
+
+func get(t *testing.T, handler http.Handler, target string, value interface{}) int {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+	if err := json.Unmarshal(recorder.Body.Bytes(), value); err != nil {
+		t.Fatalf("Expected a JSON body for %s, got %q", target, recorder.Body.String())
+	}
+	return recorder.Code
+}
+
+func TestHandler(t *testing.T) {
+	handler := NewHandler(createServerTestGraph())
+
+	t.Run("Lists the versions of a scoped package", func(t *testing.T) {
+		for _, target := range []string{"/package/@scope/lib", "/package/@scope%2Flib"} {
+			var response packageResponse
+			if code := get(t, handler, target, &response); code != http.StatusOK {
+				t.Fatalf("Expected status 200 for %s, got %d", target, code)
+			}
+			if len(response.Versions) != 2 || response.Versions[0].Version != "1.0.0" || response.Versions[1].Version != "2.0.0" {
+				t.Errorf("Expected versions 1.0.0 and 2.0.0, got %v", response.Versions)
+			}
+		}
+	})
+
+	t.Run("Lists direct and transitive dependencies", func(t *testing.T) {
+		var direct, transitive nodesResponse
+		get(t, handler, "/package/App/1.0.0/dependencies", &direct)
+		get(t, handler, "/package/App/1.0.0/dependencies?transitive=true", &transitive)
+		if len(direct.Nodes) != 1 || len(transitive.Nodes) != 2 {
+			t.Errorf("Expected 1 direct and 2 transitive dependencies, got %d and %d", len(direct.Nodes), len(transitive.Nodes))
+		}
+	})
+
+	t.Run("Lists the dependents", func(t *testing.T) {
+		var response nodesResponse
+		get(t, handler, "/package/Leaf/1.0.0/dependents?transitive=true", &response)
+		if len(response.Nodes) != 2 {
+			t.Errorf("Expected 2 dependents, got %d", len(response.Nodes))
+		}
+	})
+
+	t.Run("Pages through the dependents", func(t *testing.T) {
+		var response pageResponse
+		if code := get(t, handler, "/package/Leaf/1.0.0/dependents?transitive=true&offset=1&limit=1", &response); code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", code)
+		}
+		if response.Total != 2 || len(response.Nodes) != 1 || response.Nodes[0].Name != "App" {
+			t.Errorf("Expected App as the second of 2 dependents, got %v of %d", response.Nodes, response.Total)
+		}
+		get(t, handler, "/package/Leaf/1.0.0/dependents?sort=timestamp", &response)
+		if response.Total != 1 || len(response.Nodes) != 1 || response.Limit != defaultPageLimit {
+			t.Errorf("Expected a page of the direct dependent with the default limit, got %+v", response)
+		}
+		for _, target := range []string{"/package/Leaf/1.0.0/dependents?limit=-1", "/package/Leaf/1.0.0/dependents?sort=size"} {
+			var response errorResponse
+			if code := get(t, handler, target, &response); code != http.StatusBadRequest || response.Error == "" {
+				t.Errorf("Expected status 400 with an error for %s, got %d and %q", target, code, response.Error)
+			}
+		}
+	})
+
+	t.Run("Combines the dependents of several sets", func(t *testing.T) {
+		tests := map[string][]string{
+			"/impact?set=Leaf@1.0.0,@scope/lib@2.0.0":                                   {"@scope/lib", "App"},
+			"/impact?op=intersection&set=Leaf@1.0.0&set=%40scope%2Flib@1.0.0":           {"App"},
+			"/impact?op=difference&set=Leaf@1.0.0&set=@scope/lib@1.0.0":                 {"@scope/lib"},
+			"/impact?op=intersection&set=Leaf@1.0.0&set=@scope/lib@1.0.0&set=App@1.0.0": {},
+		}
+		for target, expected := range tests {
+			var response impactResponse
+			if code := get(t, handler, target, &response); code != http.StatusOK {
+				t.Fatalf("Expected status 200 for %s, got %d", target, code)
+			}
+			names := []string{}
+			for _, node := range response.Nodes {
+				names = append(names, node.Name)
+			}
+			if !reflect.DeepEqual(names, expected) || response.Total != len(expected) {
+				t.Errorf("Expected %v for %s, got %v of %d", expected, target, names, response.Total)
+			}
+		}
+		invalid := map[string]int{
+			"/impact":                       http.StatusBadRequest,
+			"/impact?op=xor&set=Leaf@1.0.0": http.StatusBadRequest,
+			"/impact?set=Leaf":              http.StatusBadRequest,
+			"/impact?set=Leaf@9.0.0":        http.StatusNotFound,
+		}
+		for target, status := range invalid {
+			var response errorResponse
+			if code := get(t, handler, target, &response); code != status || response.Error == "" {
+				t.Errorf("Expected status %d with an error for %s, got %d and %q", status, target, code, response.Error)
+			}
+		}
+	})
+
+	t.Run("Finds a shortest path", func(t *testing.T) {
+		var response pathResponse
+		if code := get(t, handler, "/path?from=App@1.0.0&to=Leaf@1.0.0", &response); code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", code)
+		}
+		if len(response.Path) != 3 || response.Path[1].Name != "@scope/lib" {
+			t.Errorf("Expected the path through @scope/lib, got %v", response.Path)
+		}
+	})
+
+	t.Run("Answers unknown packages with a JSON error", func(t *testing.T) {
+		for _, target := range []string{"/package/Missing", "/package/App/9.9.9/dependents", "/path?from=App@1.0.0&to=Missing@1.0.0"} {
+			var response errorResponse
+			if code := get(t, handler, target, &response); code != http.StatusNotFound || response.Error == "" {
+				t.Errorf("Expected status 404 with an error for %s, got %d and %q", target, code, response.Error)
+			}
+		}
+	})
+
+	t.Run("Evaluates posted queries", func(t *testing.T) {
+		post := func(body string, value interface{}) int {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body)))
+			if err := json.Unmarshal(recorder.Body.Bytes(), value); err != nil {
+				t.Fatalf("Expected a JSON body for %s, got %q", body, recorder.Body.String())
+			}
+			return recorder.Code
+		}
+		var result g.QueryResult
+		if code := post(`{"query": "dependents(name=\"Leaf\", transitive=true) | top(indegree, 1)"}`, &result); code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", code)
+		}
+		if !reflect.DeepEqual(result.Columns, []string{"indegree"}) || len(result.Rows) != 1 || result.Rows[0].Node.Name != "@scope/lib" || result.Rows[0].Values[0] != 1 {
+			t.Errorf("Expected @scope/lib with an indegree of 1, got %+v", result)
+		}
+		var failure queryErrorResponse
+		if code := post(`{"query": "all() | top(fame, 1)"}`, &failure); code != http.StatusBadRequest || failure.Offset != 12 {
+			t.Errorf("Expected status 400 at offset 12, got %d and %+v", code, failure)
+		}
+		if code := post(`{"query": "deps(name=\"Missing\")"}`, &failure); code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d and %+v", code, failure)
+		}
+		var response errorResponse
+		if code := get(t, handler, "/query", &response); code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected status 405 for GET, got %d", code)
+		}
+	})
+
+	t.Run("Serves parallel requests", func(t *testing.T) {
+		targets := []string{
+			"/package/App/1.0.0/dependencies?transitive=true",
+			"/package/Leaf/1.0.0/dependents?transitive=true",
+			"/path?from=App@1.0.0&to=Leaf@1.0.0",
+			"/stats",
+		}
+		var wg sync.WaitGroup
+		errors := make(chan string, 100)
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func(target string) {
+				defer wg.Done()
+				recorder := httptest.NewRecorder()
+				handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+				if recorder.Code != http.StatusOK {
+					errors <- target
+				}
+			}(targets[i%len(targets)])
+		}
+		wg.Wait()
+		close(errors)
+		for target := range errors {
+			t.Errorf("Expected status 200 for %s", target)
+		}
+	})
+}